@@ -57,6 +57,7 @@ type Price = {
   pricing_model: string
   billing_mode: string
   billing_interval: string
+  billing_interval_count?: number | null
   active: boolean
   retired_at?: string | null
 }
@@ -90,19 +91,37 @@ const cycleLabels: Record<string, string> = {
   DAILY: "Daily",
   WEEKLY: "Weekly",
   MONTHLY: "Monthly",
+  QUARTERLY: "Quarterly",
+  YEARLY: "Yearly",
 }
 
-const getCycleFromInterval = (interval?: string | null) => {
-  switch ((interval ?? "").toUpperCase()) {
-    case "DAY":
-      return "DAILY"
-    case "WEEK":
-      return "WEEKLY"
-    case "MONTH":
-      return "MONTHLY"
-    default:
-      return ""
+const formatCycleLabel = (cycle: string) => {
+  if (cycleLabels[cycle]) return cycleLabels[cycle]
+  const [unit, count] = cycle.split(":")
+  if (unit && count) return `Every ${count} ${unit.toLowerCase()}s`
+  return cycle
+}
+
+// Mirrors the backend billing_cycle_type names: common cycles keep their
+// names and anything else is sent as "<UNIT>:<COUNT>".
+const getCycleFromInterval = (interval?: string | null, count?: number | null) => {
+  const unit = (interval ?? "").toUpperCase()
+  const n = count && count > 0 ? count : 1
+  if (n === 1) {
+    switch (unit) {
+      case "DAY":
+        return "DAILY"
+      case "WEEK":
+        return "WEEKLY"
+      case "MONTH":
+        return "MONTHLY"
+      case "YEAR":
+        return "YEARLY"
+    }
   }
+  if (unit === "MONTH" && n === 3) return "QUARTERLY"
+  if (["DAY", "WEEK", "MONTH", "YEAR"].includes(unit)) return `${unit}:${n}`
+  return ""
 }
 
 const formatCustomerLabel = (customer: Customer) => {
@@ -171,12 +190,14 @@ export default function OrgSubscriptionCreatePage() {
   const cycleOptions = useMemo(() => {
     const set = new Set<string>()
     prices.forEach((price) => {
-      const cycle = getCycleFromInterval(price.billing_interval)
+      const cycle = getCycleFromInterval(price.billing_interval, price.billing_interval_count)
       if (cycle) set.add(cycle)
     })
-    const order = ["MONTHLY", "WEEKLY", "DAILY"]
+    const order = ["MONTHLY", "QUARTERLY", "YEARLY", "WEEKLY", "DAILY"]
     const filtered = order.filter((cycle) => set.has(cycle))
-    return filtered.length > 0 ? filtered : order
+    const custom = Array.from(set).filter((cycle) => !order.includes(cycle)).sort()
+    const options = [...filtered, ...custom]
+    return options.length > 0 ? options : order
   }, [prices])
 
   const priceLookup = useMemo(() => {
@@ -194,7 +215,7 @@ export default function OrgSubscriptionCreatePage() {
   const filteredPrices = useMemo(() => {
     return prices.filter((price) => {
       if (!price.active || price.retired_at) return false
-      const cycle = getCycleFromInterval(price.billing_interval)
+      const cycle = getCycleFromInterval(price.billing_interval, price.billing_interval_count)
       return cycle === billingCycleType
     })
   }, [prices, billingCycleType])
@@ -393,7 +414,7 @@ export default function OrgSubscriptionCreatePage() {
       prev.map((item) => {
         if (!item.priceId) return item
         const price = priceLookup.get(item.priceId)
-        const cycle = price ? getCycleFromInterval(price.billing_interval) : ""
+        const cycle = price ? getCycleFromInterval(price.billing_interval, price.billing_interval_count) : ""
         if (cycle && cycle !== next) {
           return { ...item, priceId: "", meterId: "" }
         }
@@ -621,7 +642,7 @@ export default function OrgSubscriptionCreatePage() {
                 <SelectContent>
                   {cycleOptions.map((cycle) => (
                     <SelectItem key={cycle} value={cycle}>
-                      {cycleLabels[cycle] ?? cycle}
+                      {formatCycleLabel(cycle)}
                     </SelectItem>
                   ))}
                 </SelectContent>
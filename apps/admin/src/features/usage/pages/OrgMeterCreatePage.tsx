@@ -21,6 +21,8 @@ const aggregationOptions = [
   { label: "Max", value: "MAX" },
   { label: "Min", value: "MIN" },
   { label: "Average", value: "AVG" },
+  { label: "Last value", value: "LAST" },
+  { label: "Unique count", value: "UNIQUE_COUNT" },
 ]
 
 export default function OrgMeterCreatePage() {
@@ -32,6 +34,7 @@ export default function OrgMeterCreatePage() {
   const [name, setName] = useState("")
   const [code, setCode] = useState("")
   const [aggregation, setAggregation] = useState("SUM")
+  const [aggregationKey, setAggregationKey] = useState("")
   const [unit, setUnit] = useState("unit")
   const [description, setDescription] = useState("")
   const [active, setActive] = useState(true)
@@ -58,6 +61,7 @@ export default function OrgMeterCreatePage() {
         name: name.trim(),
         code: code.trim(),
         aggregation_type: aggregation,
+        aggregation_key: aggregation === "UNIQUE_COUNT" ? aggregationKey.trim() : undefined,
         unit: unit.trim(),
         active,
         description: description.trim() || undefined,
@@ -136,6 +140,19 @@ export default function OrgMeterCreatePage() {
                 </SelectContent>
               </Select>
             </div>
+            {aggregation === "UNIQUE_COUNT" && (
+              <div className="space-y-2">
+                <Label htmlFor="meter-aggregation-key">Metadata key</Label>
+                <Input
+                  id="meter-aggregation-key"
+                  data-testid="meter-aggregation-key"
+                  placeholder="user_id"
+                  value={aggregationKey}
+                  onChange={(event) => setAggregationKey(event.target.value)}
+                  required
+                />
+              </div>
+            )}
             <div className="space-y-2">
               <Label htmlFor="meter-unit">Unit</Label>
               <Input
@@ -53,6 +53,7 @@ type Invoice = {
   billToName: string
   billToEmail: string
   currency: string
+  minorUnit: number
   amountDue: number
   items: Array<{
     name: string
@@ -87,6 +88,7 @@ type PublicInvoiceResponse = {
     bill_to_name: string
     bill_to_email: string
     currency: string
+    minor_unit?: number
     amount_due: number
     subtotal_amount: number
     tax_amount: number
@@ -125,6 +127,7 @@ const sampleInvoice: Invoice = {
   billToName: 'Juniper Market Ltd.',
   billToEmail: 'billing@junipermarket.co',
   currency: 'USD',
+  minorUnit: 2,
   amountDue: 108,
   items: [
     {
@@ -724,7 +727,7 @@ function InvoiceScreen({
                   })}
                 </div>
                 <div className="mt-2 text-4xl font-semibold text-neutral-900">
-                  {formatMoney(intl, invoice.currency, invoice.total)}
+                  {formatMoney(intl, invoice.currency, invoice.total, invoice.minorUnit)}
                 </div>
                 <div className="mt-2 text-sm text-neutral-600">
                   {intl.formatMessage(
@@ -976,14 +979,15 @@ function InvoiceScreen({
                                     price: formatMoney(
                                       intl,
                                       invoice.currency,
-                                      item.unitPrice
+                                      item.unitPrice,
+                                      invoice.minorUnit
                                     ),
                                   }
                                 )}
                               </p>
                             </div>
                             <span className="text-sm font-semibold text-neutral-900">
-                              {formatMoney(intl, invoice.currency, item.total)}
+                              {formatMoney(intl, invoice.currency, item.total, invoice.minorUnit)}
                             </span>
                           </div>
                         ))}
@@ -995,15 +999,15 @@ function InvoiceScreen({
                     <section className="grid gap-2 text-sm text-neutral-600">
                       <div className="flex justify-between">
                         <span>{intl.formatMessage({ id: 'invoice.subtotal', defaultMessage: 'Subtotal' })}</span>
-                        <span className="font-medium text-neutral-900">{formatMoney(intl, invoice.currency, invoice.subtotal)}</span>
+                        <span className="font-medium text-neutral-900">{formatMoney(intl, invoice.currency, invoice.subtotal, invoice.minorUnit)}</span>
                       </div>
                       <div className="flex justify-between">
                         <span>{intl.formatMessage({ id: 'invoice.tax', defaultMessage: 'Tax' })}</span>
-                        <span className="font-medium text-neutral-900">{formatMoney(intl, invoice.currency, invoice.tax)}</span>
+                        <span className="font-medium text-neutral-900">{formatMoney(intl, invoice.currency, invoice.tax, invoice.minorUnit)}</span>
                       </div>
                       <div className="mt-2 flex justify-between text-base font-bold text-neutral-900">
                         <span>{intl.formatMessage({ id: 'invoice.total', defaultMessage: 'Total' })}</span>
-                        <span>{formatMoney(intl, invoice.currency, invoice.total)}</span>
+                        <span>{formatMoney(intl, invoice.currency, invoice.total, invoice.minorUnit)}</span>
                       </div>
                     </section>
                   </div>
@@ -1057,7 +1061,7 @@ function InvoiceScreen({
                   })}
                 </p>
                 <p className="text-lg font-semibold text-neutral-900">
-                  {formatMoney(intl, invoice.currency, invoice.total)}
+                  {formatMoney(intl, invoice.currency, invoice.total, invoice.minorUnit)}
                 </p>
               </div>
               <motion.button
@@ -1098,7 +1102,7 @@ function PaymentSummaryScreen({
     >
       <section className="text-center">
         <div className="text-3xl font-semibold text-neutral-900">
-          {formatMoney(intl, invoice.currency, invoice.amountDue)}
+          {formatMoney(intl, invoice.currency, invoice.amountDue, invoice.minorUnit)}
         </div>
         <p className="mt-2 text-sm text-neutral-600">
           {intl.formatMessage(
@@ -1445,7 +1449,7 @@ function ProcessingScreen({
     >
       <div className="text-center">
         <div className="text-3xl font-semibold text-neutral-900">
-          {formatMoney(intl, invoice.currency, invoice.amountDue)}
+          {formatMoney(intl, invoice.currency, invoice.amountDue, invoice.minorUnit)}
         </div>
         <p className="mt-2 text-sm text-neutral-500">
           {paymentMethodLabel(paymentMethod, intl)}
@@ -1484,7 +1488,7 @@ function SuccessScreen({
   const intl = useIntl()
   const methodSummary =
     paymentMethod?.display_name ?? paymentMethodLabel(paymentMethod, intl)
-  const formattedTotal = formatMoney(intl, invoice.currency, invoice.amountDue)
+  const formattedTotal = formatMoney(intl, invoice.currency, invoice.amountDue, invoice.minorUnit)
   return (
     <CardShell
       title={invoice.orgName}
@@ -1768,6 +1772,7 @@ function mapInvoicePayload(
     billToName: payload.bill_to_name,
     billToEmail: payload.bill_to_email,
     currency: payload.currency,
+    minorUnit: payload.minor_unit ?? 2,
     amountDue: payload.amount_due,
     subtotal: payload.subtotal_amount,
     tax: payload.tax_amount,
@@ -1782,11 +1787,15 @@ function mapInvoicePayload(
   }
 }
 
-function formatMoney(intl: IntlShape, currency: string, amount: number) {
-  const value = amount / 100
+// Amounts are in the currency's minor units: cents for USD, yen for JPY,
+// fils for KWD.
+function formatMoney(intl: IntlShape, currency: string, amount: number, minorUnit = 2) {
+  const value = amount / 10 ** minorUnit
   return intl.formatNumber(value, {
     style: 'currency',
     currency,
+    minimumFractionDigits: minorUnit,
+    maximumFractionDigits: minorUnit,
   })
 }
 
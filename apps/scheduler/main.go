@@ -4,12 +4,17 @@ import (
 	"context"
 
 	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/adjustment"
+	"github.com/smallbiznis/railzway/internal/alert"
 	"github.com/smallbiznis/railzway/internal/audit"
 	"github.com/smallbiznis/railzway/internal/authorization"
 	"github.com/smallbiznis/railzway/internal/billingdashboard/rollup"
 	"github.com/smallbiznis/railzway/internal/billingoperations"
 	"github.com/smallbiznis/railzway/internal/clock"
 	"github.com/smallbiznis/railzway/internal/config"
+	"github.com/smallbiznis/railzway/internal/credit"
+	"github.com/smallbiznis/railzway/internal/dunning"
+	"github.com/smallbiznis/railzway/internal/events"
 	"github.com/smallbiznis/railzway/internal/feature"
 	"github.com/smallbiznis/railzway/internal/invoice"
 	"github.com/smallbiznis/railzway/internal/invoicetemplate"
@@ -21,9 +26,12 @@ import (
 	"github.com/smallbiznis/railzway/internal/pricetier"
 	"github.com/smallbiznis/railzway/internal/product"
 	"github.com/smallbiznis/railzway/internal/productfeature"
+	"github.com/smallbiznis/railzway/internal/providers/email"
+	"github.com/smallbiznis/railzway/internal/providers/slack"
 	"github.com/smallbiznis/railzway/internal/rating"
 	"github.com/smallbiznis/railzway/internal/scheduler"
 	"github.com/smallbiznis/railzway/internal/subscription"
+	"github.com/smallbiznis/railzway/internal/webhook"
 	"github.com/smallbiznis/railzway/pkg/db"
 	"go.uber.org/fx"
 )
@@ -46,6 +54,12 @@ func main() {
 		authorization.Module,
 		billingoperations.Module,
 		rollup.Module,
+		webhook.Module,
+		dunning.Module,
+		adjustment.Module,
+		alert.Module,
+		credit.Module,
+		events.Module,
 
 		// Transitive dependencies (invoice needs product/price etc)
 		product.Module,
@@ -56,6 +70,8 @@ func main() {
 		pricetier.Module,
 		invoicetemplate.Module,
 		meter.Module,
+		email.Module,
+		slack.Module,
 
 		// No server module!
 		fx.Invoke(StartScheduler),
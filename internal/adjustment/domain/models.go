@@ -0,0 +1,47 @@
+// Package domain contains persistence models for billing adjustments.
+package domain
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// AdjustmentStatus represents the invoicing state of an adjustment.
+type AdjustmentStatus string
+
+const (
+	// AdjustmentStatusPending waits for the next invoice of the subscription.
+	AdjustmentStatusPending AdjustmentStatus = "PENDING"
+	// AdjustmentStatusInvoiced has been added to an invoice as an adjustment line.
+	AdjustmentStatusInvoiced AdjustmentStatus = "INVOICED"
+	// AdjustmentStatusNoChange records a correction that did not change the
+	// rated amount (for example usage still within an included tier).
+	AdjustmentStatusNoChange AdjustmentStatus = "NO_CHANGE"
+)
+
+// Adjustment is the explicit billing record of a usage correction that
+// landed in an already CLOSED billing cycle. The closed cycle's rating results
+// and ledger entry stay untouched; the delta is carried by rating results
+// linked through AdjustmentID and by a ledger entry sourced from the adjustment.
+type Adjustment struct {
+	ID                snowflake.ID     `gorm:"primaryKey" json:"id"`
+	OrgID             snowflake.ID     `gorm:"not null;index;uniqueIndex:ux_billing_adjustments_correction,priority:1" json:"organization_id"`
+	CustomerID        snowflake.ID     `gorm:"not null;index" json:"customer_id"`
+	SubscriptionID    snowflake.ID     `gorm:"not null;index" json:"subscription_id"`
+	BillingCycleID    snowflake.ID     `gorm:"not null;index" json:"billing_cycle_id"`
+	UsageEventID      snowflake.ID     `gorm:"not null" json:"usage_event_id"`
+	CorrectionEventID snowflake.ID     `gorm:"not null;uniqueIndex:ux_billing_adjustments_correction,priority:2" json:"correction_event_id"`
+	Reason            string           `gorm:"type:text;not null" json:"reason"`
+	Amount            int64            `gorm:"not null;default:0" json:"amount"`
+	Currency          string           `gorm:"type:text;not null" json:"currency"`
+	Status            AdjustmentStatus `gorm:"type:text;not null;default:'PENDING'" json:"status"`
+	LedgerEntryID     *snowflake.ID    `json:"ledger_entry_id,omitempty"`
+	InvoiceID         *snowflake.ID    `json:"invoice_id,omitempty"`
+	InvoicedAt        *time.Time       `json:"invoiced_at,omitempty"`
+	CreatedAt         time.Time        `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt         time.Time        `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName sets the database table name.
+func (Adjustment) TableName() string { return "billing_adjustments" }
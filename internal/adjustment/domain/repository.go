@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	"gorm.io/gorm"
+)
+
+// UsageEventRef is the subset of a usage event an adjustment depends on.
+type UsageEventRef struct {
+	ID               snowflake.ID  `gorm:"column:id"`
+	OrgID            snowflake.ID  `gorm:"column:org_id"`
+	CustomerID       snowflake.ID  `gorm:"column:customer_id"`
+	SubscriptionID   snowflake.ID  `gorm:"column:subscription_id"`
+	MeterID          snowflake.ID  `gorm:"column:meter_id"`
+	RecordedAt       time.Time     `gorm:"column:recorded_at"`
+	CorrectsEventID  *snowflake.ID `gorm:"column:corrects_event_id"`
+	CorrectionReason *string       `gorm:"column:correction_reason"`
+	LatePolicy       *string       `gorm:"column:late_policy"`
+	BillableAt       *time.Time    `gorm:"column:billable_at"`
+}
+
+// CycleRef is the subset of a billing cycle an adjustment depends on.
+type CycleRef struct {
+	ID             snowflake.ID                          `gorm:"column:id"`
+	OrgID          snowflake.ID                          `gorm:"column:org_id"`
+	SubscriptionID snowflake.ID                          `gorm:"column:subscription_id"`
+	PeriodStart    time.Time                             `gorm:"column:period_start"`
+	PeriodEnd      time.Time                             `gorm:"column:period_end"`
+	Status         billingcycledomain.BillingCycleStatus `gorm:"column:status"`
+}
+
+// RatedTotal sums the stored rating results of one price and feature,
+// including the deltas of earlier adjustments.
+type RatedTotal struct {
+	PriceID     snowflake.ID `gorm:"column:price_id"`
+	FeatureCode string       `gorm:"column:feature_code"`
+	Currency    string       `gorm:"column:currency"`
+	Quantity    float64      `gorm:"column:quantity"`
+	Amount      int64        `gorm:"column:amount"`
+}
+
+type Repository interface {
+	FindUsageEvent(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*UsageEventRef, error)
+	// FindCycleForEvent returns the cycle of the subscription whose period
+	// contains billedAt, mirroring the window used by usage aggregation.
+	FindCycleForEvent(ctx context.Context, db *gorm.DB, orgID, subscriptionID snowflake.ID, billedAt time.Time) (*CycleRef, error)
+	LockCycle(ctx context.Context, db *gorm.DB, id snowflake.ID) (*CycleRef, error)
+	SumRatedUsage(ctx context.Context, db *gorm.DB, orgID, billingCycleID, meterID snowflake.ID) ([]RatedTotal, error)
+	// ListPendingLateUsage returns enriched late events of the adjustment
+	// policy whose cycle is CLOSED and that have no adjustment yet.
+	ListPendingLateUsage(ctx context.Context, db *gorm.DB, limit int) ([]UsageEventRef, error)
+
+	FindByCorrectionEvent(ctx context.Context, db *gorm.DB, orgID, correctionEventID snowflake.ID) (*Adjustment, error)
+	// Insert returns false when the correction already has an adjustment.
+	Insert(ctx context.Context, db *gorm.DB, adjustment *Adjustment) (bool, error)
+	InsertRatingResult(ctx context.Context, db *gorm.DB, result ratingdomain.RatingResult) error
+}
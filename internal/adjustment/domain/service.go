@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+type Service interface {
+	// ApplyUsageCorrection records the billing effect of a usage correction
+	// event. It returns nil when the corrected event belongs to a cycle that
+	// is still OPEN, where aggregation already reflects the correction.
+	// Calling it again for the same correction returns the same adjustment.
+	ApplyUsageCorrection(ctx context.Context, correctionEventID string) (*Adjustment, error)
+	// ApplyLateUsage records the billing effect of a late usage event stored
+	// under the adjustment late policy, once its cycle is CLOSED.
+	ApplyLateUsage(ctx context.Context, usageEventID string) (*Adjustment, error)
+	// ProcessLateUsage applies ApplyLateUsage to late events still waiting
+	// for an adjustment.
+	ProcessLateUsage(ctx context.Context, limit int) error
+}
+
+var (
+	ErrInvalidOrganization  = errors.New("invalid_organization")
+	ErrInvalidUsageEvent    = errors.New("invalid_usage_event")
+	ErrUsageEventNotFound   = errors.New("usage_event_not_found")
+	ErrNotACorrection       = errors.New("usage_event_not_a_correction")
+	ErrNotLateUsage         = errors.New("usage_event_not_late_adjustment")
+	ErrBillingCycleClosing  = errors.New("billing_cycle_closing")
+	ErrCurrencyMismatch     = errors.New("adjustment_currency_mismatch")
+	ErrLedgerAccountMissing = errors.New("ledger_account_missing")
+)
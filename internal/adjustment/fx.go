@@ -0,0 +1,12 @@
+package adjustment
+
+import (
+	"github.com/smallbiznis/railzway/internal/adjustment/repository"
+	"github.com/smallbiznis/railzway/internal/adjustment/service"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("adjustment.service",
+	fx.Provide(repository.Provide),
+	fx.Provide(service.NewService),
+)
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	"gorm.io/gorm"
+)
+
+// Usage event values matched by ListPendingLateUsage; the adjustment module
+// does not import the usage domain.
+const (
+	lateUsagePolicyAdjustment = "adjustment"
+	usageStatusEnriched       = "enriched"
+)
+
+type repo struct{}
+
+func Provide() adjustmentdomain.Repository {
+	return &repo{}
+}
+
+const adjustmentColumns = `id, org_id, customer_id, subscription_id, billing_cycle_id, usage_event_id,
+	correction_event_id, reason, amount, currency, status, ledger_entry_id, invoice_id, invoiced_at,
+	created_at, updated_at`
+
+const cycleColumns = `id, org_id, subscription_id, period_start, period_end, status`
+
+const usageEventColumns = `id, org_id, customer_id, subscription_id, meter_id, recorded_at,
+	corrects_event_id, correction_reason, late_policy, billable_at`
+
+func (r *repo) FindUsageEvent(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*adjustmentdomain.UsageEventRef, error) {
+	var event adjustmentdomain.UsageEventRef
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+usageEventColumns+`
+		 FROM usage_events
+		 WHERE org_id = ? AND id = ?`,
+		orgID,
+		id,
+	).Scan(&event).Error
+	if err != nil {
+		return nil, err
+	}
+	if event.ID == 0 {
+		return nil, nil
+	}
+	return &event, nil
+}
+
+func (r *repo) FindCycleForEvent(ctx context.Context, db *gorm.DB, orgID, subscriptionID snowflake.ID, billedAt time.Time) (*adjustmentdomain.CycleRef, error) {
+	var cycle adjustmentdomain.CycleRef
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+cycleColumns+`
+		 FROM billing_cycles
+		 WHERE org_id = ? AND subscription_id = ?
+		   AND period_start <= ? AND period_end > ?
+		 ORDER BY period_start DESC
+		 LIMIT 1`,
+		orgID,
+		subscriptionID,
+		billedAt,
+		billedAt,
+	).Scan(&cycle).Error
+	if err != nil {
+		return nil, err
+	}
+	if cycle.ID == 0 {
+		return nil, nil
+	}
+	return &cycle, nil
+}
+
+func (r *repo) LockCycle(ctx context.Context, db *gorm.DB, id snowflake.ID) (*adjustmentdomain.CycleRef, error) {
+	query := `SELECT ` + cycleColumns + `
+		 FROM billing_cycles
+		 WHERE id = ?`
+	if db.Dialector.Name() != "sqlite" {
+		query += " FOR UPDATE"
+	}
+
+	var cycle adjustmentdomain.CycleRef
+	if err := db.WithContext(ctx).Raw(query, id).Scan(&cycle).Error; err != nil {
+		return nil, err
+	}
+	if cycle.ID == 0 {
+		return nil, nil
+	}
+	return &cycle, nil
+}
+
+func (r *repo) SumRatedUsage(ctx context.Context, db *gorm.DB, orgID, billingCycleID, meterID snowflake.ID) ([]adjustmentdomain.RatedTotal, error) {
+	var totals []adjustmentdomain.RatedTotal
+	err := db.WithContext(ctx).Raw(
+		`SELECT price_id, feature_code, currency,
+		        SUM(quantity) AS quantity,
+		        SUM(amount) AS amount
+		 FROM rating_results
+		 WHERE org_id = ? AND billing_cycle_id = ? AND meter_id = ?
+		 GROUP BY price_id, feature_code, currency`,
+		orgID,
+		billingCycleID,
+		meterID,
+	).Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+func (r *repo) ListPendingLateUsage(ctx context.Context, db *gorm.DB, limit int) ([]adjustmentdomain.UsageEventRef, error) {
+	var events []adjustmentdomain.UsageEventRef
+	err := db.WithContext(ctx).Raw(
+		`SELECT ue.id, ue.org_id, ue.customer_id, ue.subscription_id, ue.meter_id, ue.recorded_at,
+		        ue.corrects_event_id, ue.correction_reason, ue.late_policy, ue.billable_at
+		 FROM usage_events ue
+		 JOIN billing_cycles bc ON bc.org_id = ue.org_id
+		   AND bc.subscription_id = ue.subscription_id
+		   AND bc.period_start <= ue.recorded_at AND bc.period_end > ue.recorded_at
+		 WHERE ue.late_policy = ? AND ue.status = ? AND ue.corrects_event_id IS NULL
+		   AND bc.status = ?
+		   AND NOT EXISTS (
+		     SELECT 1 FROM billing_adjustments ba
+		     WHERE ba.org_id = ue.org_id AND ba.correction_event_id = ue.id
+		   )
+		 ORDER BY ue.id ASC
+		 LIMIT ?`,
+		lateUsagePolicyAdjustment,
+		usageStatusEnriched,
+		billingcycledomain.BillingCycleStatusClosed,
+		limit,
+	).Scan(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *repo) FindByCorrectionEvent(ctx context.Context, db *gorm.DB, orgID, correctionEventID snowflake.ID) (*adjustmentdomain.Adjustment, error) {
+	var adjustment adjustmentdomain.Adjustment
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+adjustmentColumns+`
+		 FROM billing_adjustments
+		 WHERE org_id = ? AND correction_event_id = ?`,
+		orgID,
+		correctionEventID,
+	).Scan(&adjustment).Error
+	if err != nil {
+		return nil, err
+	}
+	if adjustment.ID == 0 {
+		return nil, nil
+	}
+	return &adjustment, nil
+}
+
+func (r *repo) Insert(ctx context.Context, db *gorm.DB, adjustment *adjustmentdomain.Adjustment) (bool, error) {
+	result := db.WithContext(ctx).Exec(
+		`INSERT INTO billing_adjustments (`+adjustmentColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (org_id, correction_event_id) DO NOTHING`,
+		adjustment.ID,
+		adjustment.OrgID,
+		adjustment.CustomerID,
+		adjustment.SubscriptionID,
+		adjustment.BillingCycleID,
+		adjustment.UsageEventID,
+		adjustment.CorrectionEventID,
+		adjustment.Reason,
+		adjustment.Amount,
+		adjustment.Currency,
+		adjustment.Status,
+		adjustment.LedgerEntryID,
+		adjustment.InvoiceID,
+		adjustment.InvoicedAt,
+		adjustment.CreatedAt,
+		adjustment.UpdatedAt,
+	)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repo) InsertRatingResult(ctx context.Context, db *gorm.DB, result ratingdomain.RatingResult) error {
+	return db.WithContext(ctx).Exec(
+		`INSERT INTO rating_results (
+			id, org_id, subscription_id, billing_cycle_id, meter_id, price_id, feature_code,
+			quantity, unit_price, amount, currency, period_start, period_end,
+			source, checksum, adjustment_id, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		result.ID,
+		result.OrgID,
+		result.SubscriptionID,
+		result.BillingCycleID,
+		result.MeterID,
+		result.PriceID,
+		result.FeatureCode,
+		result.Quantity,
+		result.UnitPrice,
+		result.Amount,
+		result.Currency,
+		result.PeriodStart,
+		result.PeriodEnd,
+		result.Source,
+		result.Checksum,
+		result.AdjustmentID,
+		result.CreatedAt,
+	).Error
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
+	"github.com/smallbiznis/railzway/internal/events"
+	ledgerdomain "github.com/smallbiznis/railzway/internal/ledger/domain"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// postAdjustmentToLedger posts the adjustment delta inside the adjustment
+// transaction, sourced from the adjustment so the closed cycle's own entry
+// stays untouched.
+//
+// Double-entry logic:
+//
+//	Positive delta: Debit Accounts Receivable / Credit Revenue (usage)
+//	Negative delta: Debit Revenue (usage) / Credit Accounts Receivable
+func (s *Service) postAdjustmentToLedger(ctx context.Context, tx *gorm.DB, adjustment *adjustmentdomain.Adjustment) error {
+	accounts, err := s.loadLedgerAccounts(ctx, tx, adjustment.OrgID, []ledgerdomain.LedgerAccountCode{
+		ledgerdomain.AccountCodeAccountsReceivable,
+		ledgerdomain.AccountCodeRevenueUsage,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load ledger accounts: %w", err)
+	}
+	arAccount, ok := accounts[ledgerdomain.AccountCodeAccountsReceivable]
+	if !ok {
+		return adjustmentdomain.ErrLedgerAccountMissing
+	}
+	revenueAccount, ok := accounts[ledgerdomain.AccountCodeRevenueUsage]
+	if !ok {
+		return adjustmentdomain.ErrLedgerAccountMissing
+	}
+
+	amount := adjustment.Amount
+	debit, credit := arAccount.ID, revenueAccount.ID
+	if amount < 0 {
+		amount = -amount
+		debit, credit = revenueAccount.ID, arAccount.ID
+	}
+	lines := []ledgerdomain.LedgerEntryLine{
+		{AccountID: debit, Direction: ledgerdomain.LedgerEntryDirectionDebit, Currency: adjustment.Currency, Amount: amount},
+		{AccountID: credit, Direction: ledgerdomain.LedgerEntryDirectionCredit, Currency: adjustment.Currency, Amount: amount},
+	}
+	if err := ledgerdomain.ValidateBalanced(lines); err != nil {
+		return fmt.Errorf("ledger entry not balanced: %w", err)
+	}
+
+	entryID := *adjustment.LedgerEntryID
+	now := time.Now().UTC()
+	if err := tx.WithContext(ctx).Exec(
+		`INSERT INTO ledger_entries (
+			id, org_id, source_type, source_id, currency, occurred_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entryID,
+		adjustment.OrgID,
+		string(ledgerdomain.SourceTypeAdjustment),
+		adjustment.ID,
+		adjustment.Currency,
+		adjustment.CreatedAt,
+		now,
+	).Error; err != nil {
+		return fmt.Errorf("failed to insert ledger entry: %w", err)
+	}
+
+	for _, line := range lines {
+		if err := tx.WithContext(ctx).Exec(
+			`INSERT INTO ledger_entry_lines (
+				id, ledger_entry_id, account_id, direction, currency, amount, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			s.genID.Generate(),
+			entryID,
+			line.AccountID,
+			string(line.Direction),
+			line.Currency,
+			line.Amount,
+			now,
+		).Error; err != nil {
+			return fmt.Errorf("failed to insert ledger entry line: %w", err)
+		}
+	}
+
+	if s.outbox != nil {
+		if err := s.outbox.PublishTx(ctx, tx, events.Event{
+			OrgID: adjustment.OrgID,
+			Type:  events.EventLedgerEntryCreated,
+			Payload: map[string]any{
+				"ledger_entry_id": entryID.String(),
+				"source_type":     string(ledgerdomain.SourceTypeAdjustment),
+				"source_id":       adjustment.ID.String(),
+			},
+			DedupeKey: "ledger_entry:" + entryID.String(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	s.log.Info("posted adjustment to ledger",
+		zap.String("adjustment_id", adjustment.ID.String()),
+		zap.String("ledger_entry_id", entryID.String()),
+		zap.Int64("amount", adjustment.Amount),
+	)
+	return nil
+}
+
+func (s *Service) loadLedgerAccounts(ctx context.Context, tx *gorm.DB, orgID snowflake.ID, codes []ledgerdomain.LedgerAccountCode) (map[ledgerdomain.LedgerAccountCode]ledgerdomain.LedgerAccount, error) {
+	var accounts []ledgerdomain.LedgerAccount
+	if err := tx.WithContext(ctx).
+		Where("org_id = ? AND code IN ?", orgID, codes).
+		Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[ledgerdomain.LedgerAccountCode]ledgerdomain.LedgerAccount, len(accounts))
+	for _, acc := range accounts {
+		result[acc.Code] = acc
+	}
+	return result, nil
+}
@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	"github.com/smallbiznis/railzway/internal/events"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ratingSourceAdjustment marks rating results that carry an adjustment delta.
+const ratingSourceAdjustment = "adjustment"
+
+const (
+	// lateUsagePolicyAdjustment mirrors the usage late policy that bills late
+	// events through adjustments.
+	lateUsagePolicyAdjustment = "adjustment"
+	lateUsageReason           = "late usage"
+	defaultLateUsageLimit     = 100
+)
+
+type ServiceParam struct {
+	fx.In
+
+	DB        *gorm.DB
+	Log       *zap.Logger
+	GenID     *snowflake.Node
+	Repo      adjustmentdomain.Repository
+	RatingSvc ratingdomain.Service
+	Outbox    *events.Outbox `optional:"true"`
+}
+
+type Service struct {
+	db        *gorm.DB
+	log       *zap.Logger
+	genID     *snowflake.Node
+	repo      adjustmentdomain.Repository
+	ratingSvc ratingdomain.Service
+	outbox    *events.Outbox
+}
+
+func NewService(p ServiceParam) adjustmentdomain.Service {
+	return &Service{
+		db:        p.DB,
+		log:       p.Log.Named("adjustment.service"),
+		genID:     p.GenID,
+		repo:      p.Repo,
+		ratingSvc: p.RatingSvc,
+		outbox:    p.Outbox,
+	}
+}
+
+// ApplyUsageCorrection prices a usage correction that landed in a CLOSED
+// billing cycle. The cycle is re-rated against its current usage and compared
+// with what is already stored for the corrected meter (original results plus
+// earlier adjustment deltas); the difference is persisted as delta rating
+// results, an adjustment record and an adjustment ledger entry, in one
+// transaction. The adjustment stays PENDING until the next invoice of the
+// subscription picks it up.
+func (s *Service) ApplyUsageCorrection(ctx context.Context, correctionEventID string) (*adjustmentdomain.Adjustment, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, adjustmentdomain.ErrInvalidOrganization
+	}
+	id, err := snowflake.ParseString(strings.TrimSpace(correctionEventID))
+	if err != nil || id == 0 {
+		return nil, adjustmentdomain.ErrInvalidUsageEvent
+	}
+
+	db := s.db.WithContext(ctx)
+	existing, err := s.repo.FindByCorrectionEvent(ctx, db, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	correction, err := s.repo.FindUsageEvent(ctx, db, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if correction == nil {
+		return nil, adjustmentdomain.ErrUsageEventNotFound
+	}
+	if correction.CorrectsEventID == nil {
+		return nil, adjustmentdomain.ErrNotACorrection
+	}
+	original, err := s.repo.FindUsageEvent(ctx, db, orgID, *correction.CorrectsEventID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil {
+		return nil, adjustmentdomain.ErrUsageEventNotFound
+	}
+
+	reason := ""
+	if correction.CorrectionReason != nil {
+		reason = *correction.CorrectionReason
+	}
+	return s.adjustCycle(ctx, orgID, original, correction.ID, reason)
+}
+
+// ApplyLateUsage prices a late usage event stored under the adjustment late
+// policy once its CLOSED cycle can be re-rated. The late event is both the
+// adjusted and the triggering event, so retries return the same adjustment.
+// It returns nil while the event is not enriched yet or its cycle is OPEN.
+func (s *Service) ApplyLateUsage(ctx context.Context, usageEventID string) (*adjustmentdomain.Adjustment, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, adjustmentdomain.ErrInvalidOrganization
+	}
+	id, err := snowflake.ParseString(strings.TrimSpace(usageEventID))
+	if err != nil || id == 0 {
+		return nil, adjustmentdomain.ErrInvalidUsageEvent
+	}
+
+	db := s.db.WithContext(ctx)
+	existing, err := s.repo.FindByCorrectionEvent(ctx, db, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	event, err := s.repo.FindUsageEvent(ctx, db, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, adjustmentdomain.ErrUsageEventNotFound
+	}
+	if event.LatePolicy == nil || *event.LatePolicy != lateUsagePolicyAdjustment {
+		return nil, adjustmentdomain.ErrNotLateUsage
+	}
+	return s.adjustCycle(ctx, orgID, event, event.ID, lateUsageReason)
+}
+
+// ProcessLateUsage applies the adjustments of late usage events whose cycle
+// has been CLOSED since they arrived.
+func (s *Service) ProcessLateUsage(ctx context.Context, limit int) error {
+	if limit <= 0 {
+		limit = defaultLateUsageLimit
+	}
+	pending, err := s.repo.ListPendingLateUsage(ctx, s.db.WithContext(ctx), limit)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, event := range pending {
+		orgCtx := orgcontext.WithOrgID(ctx, int64(event.OrgID))
+		if _, err := s.ApplyLateUsage(orgCtx, event.ID.String()); err != nil {
+			errs = append(errs, err)
+			s.log.Warn("failed to apply late usage",
+				zap.String("usage_event_id", event.ID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// adjustCycle re-rates the CLOSED cycle that billed original and stores the
+// difference as an adjustment keyed by triggerID. It returns nil when the
+// cycle is still OPEN.
+func (s *Service) adjustCycle(
+	ctx context.Context,
+	orgID snowflake.ID,
+	original *adjustmentdomain.UsageEventRef,
+	triggerID snowflake.ID,
+	reason string,
+) (*adjustmentdomain.Adjustment, error) {
+	db := s.db.WithContext(ctx)
+	billedAt := original.RecordedAt
+	if original.BillableAt != nil {
+		billedAt = *original.BillableAt
+	}
+	cycle, err := s.repo.FindCycleForEvent(ctx, db, orgID, original.SubscriptionID, billedAt)
+	if err != nil {
+		return nil, err
+	}
+	if cycle == nil || cycle.Status == billingcycledomain.BillingCycleStatusOpen {
+		return nil, nil
+	}
+	if cycle.Status != billingcycledomain.BillingCycleStatusClosed {
+		return nil, adjustmentdomain.ErrBillingCycleClosing
+	}
+
+	var adjustment *adjustmentdomain.Adjustment
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// The cycle lock serializes corrections of the same cycle, so each one
+		// compares against the deltas already stored by the previous ones.
+		locked, err := s.repo.LockCycle(ctx, tx, cycle.ID)
+		if err != nil {
+			return err
+		}
+		if locked == nil || locked.Status != billingcycledomain.BillingCycleStatusClosed {
+			return adjustmentdomain.ErrBillingCycleClosing
+		}
+		found, err := s.repo.FindByCorrectionEvent(ctx, tx, orgID, triggerID)
+		if err != nil {
+			return err
+		}
+		if found != nil {
+			adjustment = found
+			return nil
+		}
+
+		rerated, err := s.ratingSvc.RerateCycle(ctx, cycle.ID.String())
+		if err != nil {
+			return err
+		}
+		stored, err := s.repo.SumRatedUsage(ctx, tx, orgID, cycle.ID, original.MeterID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		record := &adjustmentdomain.Adjustment{
+			ID:                s.genID.Generate(),
+			OrgID:             orgID,
+			CustomerID:        original.CustomerID,
+			SubscriptionID:    cycle.SubscriptionID,
+			BillingCycleID:    cycle.ID,
+			UsageEventID:      original.ID,
+			CorrectionEventID: triggerID,
+			Reason:            reason,
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		}
+		deltas, err := s.buildDeltas(record, locked, original.MeterID, rerated, stored, now)
+		if err != nil {
+			return err
+		}
+		record.Currency = adjustmentCurrency(deltas, rerated, stored)
+		for _, delta := range deltas {
+			record.Amount += delta.Amount
+		}
+		record.Status = adjustmentdomain.AdjustmentStatusPending
+		if record.Amount == 0 {
+			record.Status = adjustmentdomain.AdjustmentStatusNoChange
+		} else {
+			entryID := s.genID.Generate()
+			record.LedgerEntryID = &entryID
+		}
+
+		inserted, err := s.repo.Insert(ctx, tx, record)
+		if err != nil {
+			return err
+		}
+		if !inserted {
+			found, err := s.repo.FindByCorrectionEvent(ctx, tx, orgID, triggerID)
+			if err != nil {
+				return err
+			}
+			adjustment = found
+			return nil
+		}
+		for _, delta := range deltas {
+			if err := s.repo.InsertRatingResult(ctx, tx, delta); err != nil {
+				return err
+			}
+		}
+		if record.LedgerEntryID != nil {
+			if err := s.postAdjustmentToLedger(ctx, tx, record); err != nil {
+				return err
+			}
+		}
+		adjustment = record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info("applied usage correction",
+		zap.String("adjustment_id", adjustment.ID.String()),
+		zap.String("billing_cycle_id", adjustment.BillingCycleID.String()),
+		zap.String("correction_event_id", adjustment.CorrectionEventID.String()),
+		zap.Int64("amount", adjustment.Amount),
+	)
+	return adjustment, nil
+}
+
+type deltaKey struct {
+	priceID     snowflake.ID
+	featureCode string
+	currency    string
+}
+
+// buildDeltas returns one rating result per price and feature of the corrected
+// meter whose re-rated quantity or amount differs from what is stored.
+func (s *Service) buildDeltas(
+	adjustment *adjustmentdomain.Adjustment,
+	cycle *adjustmentdomain.CycleRef,
+	meterID snowflake.ID,
+	rerated []ratingdomain.RatingResult,
+	stored []adjustmentdomain.RatedTotal,
+	now time.Time,
+) ([]ratingdomain.RatingResult, error) {
+	type total struct {
+		quantity  float64
+		amount    int64
+		unitPrice int64
+	}
+	totals := make(map[deltaKey]*total)
+	keys := make([]deltaKey, 0)
+	get := func(key deltaKey) *total {
+		t, ok := totals[key]
+		if !ok {
+			t = &total{}
+			totals[key] = t
+			keys = append(keys, key)
+		}
+		return t
+	}
+
+	for _, r := range rerated {
+		if r.MeterID == nil || *r.MeterID != meterID {
+			continue
+		}
+		t := get(deltaKey{priceID: r.PriceID, featureCode: r.FeatureCode, currency: r.Currency})
+		t.quantity += r.Quantity
+		t.amount += r.Amount
+		t.unitPrice = r.UnitPrice
+	}
+	for _, r := range stored {
+		t := get(deltaKey{priceID: r.PriceID, featureCode: r.FeatureCode, currency: r.Currency})
+		t.quantity -= r.Quantity
+		t.amount -= r.Amount
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].priceID != keys[j].priceID {
+			return keys[i].priceID < keys[j].priceID
+		}
+		return keys[i].featureCode < keys[j].featureCode
+	})
+
+	deltas := make([]ratingdomain.RatingResult, 0, len(keys))
+	currency := ""
+	for _, key := range keys {
+		t := totals[key]
+		if t.amount == 0 && t.quantity == 0 {
+			continue
+		}
+		if currency == "" {
+			currency = key.currency
+		} else if currency != key.currency {
+			return nil, adjustmentdomain.ErrCurrencyMismatch
+		}
+		meter := meterID
+		deltas = append(deltas, ratingdomain.RatingResult{
+			ID:             s.genID.Generate(),
+			OrgID:          adjustment.OrgID,
+			SubscriptionID: adjustment.SubscriptionID,
+			BillingCycleID: cycle.ID,
+			PriceID:        key.priceID,
+			FeatureCode:    key.featureCode,
+			MeterID:        &meter,
+			Quantity:       t.quantity,
+			UnitPrice:      t.unitPrice,
+			Amount:         t.amount,
+			Currency:       key.currency,
+			PeriodStart:    cycle.PeriodStart,
+			PeriodEnd:      cycle.PeriodEnd,
+			Source:         ratingSourceAdjustment,
+			Checksum:       deltaChecksum(adjustment.ID, key),
+			AdjustmentID:   &adjustment.ID,
+			CreatedAt:      now,
+		})
+	}
+	return deltas, nil
+}
+
+// adjustmentCurrency prefers the currency of the deltas and falls back to the
+// cycle's rated currency for corrections without a priced effect.
+func adjustmentCurrency(deltas, rerated []ratingdomain.RatingResult, stored []adjustmentdomain.RatedTotal) string {
+	if len(deltas) > 0 {
+		return deltas[0].Currency
+	}
+	if len(stored) > 0 {
+		return stored[0].Currency
+	}
+	if len(rerated) > 0 {
+		return rerated[0].Currency
+	}
+	return ""
+}
+
+func deltaChecksum(adjustmentID snowflake.ID, key deltaKey) string {
+	raw := fmt.Sprintf("%s|%s|%s|%s|%s", ratingSourceAdjustment, adjustmentID, key.priceID, key.featureCode, key.currency)
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
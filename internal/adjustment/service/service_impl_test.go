@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/glebarez/sqlite"
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
+	"github.com/smallbiznis/railzway/internal/adjustment/repository"
+	ledgerdomain "github.com/smallbiznis/railzway/internal/ledger/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type rerateStub struct {
+	results []ratingdomain.RatingResult
+	calls   int
+}
+
+func (r *rerateStub) RunRating(context.Context, string) error { return nil }
+
+func (r *rerateStub) PreviewRating(context.Context, string) ([]ratingdomain.RatingResult, error) {
+	return nil, nil
+}
+
+func (r *rerateStub) RerateCycle(context.Context, string) ([]ratingdomain.RatingResult, error) {
+	r.calls++
+	return r.results, nil
+}
+
+func TestApplyUsageCorrection_ClosedCycle(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&ratingdomain.RatingResult{},
+		&adjustmentdomain.Adjustment{},
+		&ledgerdomain.LedgerAccount{},
+		&ledgerdomain.LedgerEntry{},
+		&ledgerdomain.LedgerEntryLine{},
+	))
+	require.NoError(t, db.Exec(`CREATE TABLE usage_events (
+		id BIGINT PRIMARY KEY, org_id BIGINT, customer_id BIGINT, subscription_id BIGINT, meter_id BIGINT,
+		recorded_at DATETIME, corrects_event_id BIGINT, correction_reason TEXT,
+		late_policy TEXT, billable_at DATETIME)`).Error)
+	require.NoError(t, db.Exec("CREATE TABLE billing_cycles (id BIGINT, org_id BIGINT, subscription_id BIGINT, period_start DATETIME, period_end DATETIME, status TEXT)").Error)
+
+	node, _ := snowflake.NewNode(1)
+	orgID := node.Generate()
+	customerID := node.Generate()
+	subID := node.Generate()
+	meterID := node.Generate()
+	priceID := node.Generate()
+	cycleID := node.Generate()
+	openCycleID := node.Generate()
+	now := time.Now().UTC()
+	start := now.AddDate(0, -1, 0)
+
+	ar := ledgerdomain.LedgerAccount{ID: node.Generate(), OrgID: orgID, Code: ledgerdomain.AccountCodeAccountsReceivable, Type: ledgerdomain.Assets, Name: "AR"}
+	revenue := ledgerdomain.LedgerAccount{ID: node.Generate(), OrgID: orgID, Code: ledgerdomain.AccountCodeRevenueUsage, Type: ledgerdomain.Income, Name: "Usage revenue"}
+	require.NoError(t, db.Create(&ar).Error)
+	require.NoError(t, db.Create(&revenue).Error)
+
+	require.NoError(t, db.Exec("INSERT INTO billing_cycles VALUES (?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?)",
+		cycleID, orgID, subID, start, now, "CLOSED",
+		openCycleID, orgID, subID, now, now.AddDate(0, 1, 0), "OPEN").Error)
+
+	rated := ratingdomain.RatingResult{
+		ID: node.Generate(), OrgID: orgID, SubscriptionID: subID, BillingCycleID: cycleID,
+		PriceID: priceID, FeatureCode: "api_calls", MeterID: &meterID,
+		Quantity: 10, UnitPrice: 100, Amount: 1000, Currency: "USD",
+		PeriodStart: start, PeriodEnd: now, Source: "usage", Checksum: "original", CreatedAt: now,
+	}
+	require.NoError(t, db.Create(&rated).Error)
+
+	insertCorrection := func(recordedAt time.Time) snowflake.ID {
+		originalID := node.Generate()
+		correctionID := node.Generate()
+		require.NoError(t, db.Exec("INSERT INTO usage_events VALUES (?, ?, ?, ?, ?, ?, NULL, NULL, NULL, NULL), (?, ?, ?, ?, ?, ?, ?, ?, NULL, NULL)",
+			originalID, orgID, customerID, subID, meterID, recordedAt,
+			correctionID, orgID, customerID, subID, meterID, recordedAt, originalID, "over-reported").Error)
+		return correctionID
+	}
+
+	rating := &rerateStub{results: []ratingdomain.RatingResult{{
+		OrgID: orgID, SubscriptionID: subID, BillingCycleID: cycleID,
+		PriceID: priceID, FeatureCode: "api_calls", MeterID: &meterID,
+		Quantity: 4, UnitPrice: 100, Amount: 400, Currency: "USD",
+	}}}
+	svc := NewService(ServiceParam{DB: db, Log: zap.NewNop(), GenID: node, Repo: repository.Provide(), RatingSvc: rating})
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	correctionID := insertCorrection(start.Add(time.Hour))
+	adjustment, err := svc.ApplyUsageCorrection(ctx, correctionID.String())
+	require.NoError(t, err)
+	require.NotNil(t, adjustment)
+	assert.Equal(t, adjustmentdomain.AdjustmentStatusPending, adjustment.Status)
+	assert.Equal(t, int64(-600), adjustment.Amount)
+	assert.Equal(t, "USD", adjustment.Currency)
+	assert.Equal(t, cycleID, adjustment.BillingCycleID)
+	assert.Equal(t, "over-reported", adjustment.Reason)
+	require.NotNil(t, adjustment.LedgerEntryID)
+
+	var deltas []ratingdomain.RatingResult
+	require.NoError(t, db.Where("adjustment_id = ?", adjustment.ID).Find(&deltas).Error)
+	require.Len(t, deltas, 1)
+	assert.Equal(t, float64(-6), deltas[0].Quantity)
+	assert.Equal(t, int64(-600), deltas[0].Amount)
+	assert.Equal(t, cycleID, deltas[0].BillingCycleID)
+
+	var entry ledgerdomain.LedgerEntry
+	require.NoError(t, db.First(&entry, "id = ?", *adjustment.LedgerEntryID).Error)
+	assert.Equal(t, ledgerdomain.SourceTypeAdjustment, entry.SourceType)
+	assert.Equal(t, adjustment.ID, entry.SourceID)
+	var lines []ledgerdomain.LedgerEntryLine
+	require.NoError(t, db.Where("ledger_entry_id = ?", entry.ID).Order("direction ASC").Find(&lines).Error)
+	require.Len(t, lines, 2)
+	assert.Equal(t, ledgerdomain.LedgerEntryDirectionCredit, lines[0].Direction)
+	assert.Equal(t, ar.ID, lines[0].AccountID)
+	assert.Equal(t, revenue.ID, lines[1].AccountID)
+	assert.Equal(t, int64(600), lines[1].Amount)
+
+	// Retries return the stored adjustment without re-rating.
+	again, err := svc.ApplyUsageCorrection(ctx, correctionID.String())
+	require.NoError(t, err)
+	assert.Equal(t, adjustment.ID, again.ID)
+	assert.Equal(t, 1, rating.calls)
+
+	// A later correction compares against the stored deltas as well.
+	unchanged, err := svc.ApplyUsageCorrection(ctx, insertCorrection(start.Add(2*time.Hour)).String())
+	require.NoError(t, err)
+	assert.Equal(t, adjustmentdomain.AdjustmentStatusNoChange, unchanged.Status)
+	assert.Zero(t, unchanged.Amount)
+	assert.Nil(t, unchanged.LedgerEntryID)
+
+	// Corrections of an OPEN cycle are left to aggregation.
+	open, err := svc.ApplyUsageCorrection(ctx, insertCorrection(now.Add(time.Hour)).String())
+	require.NoError(t, err)
+	assert.Nil(t, open)
+
+	var count int64
+	require.NoError(t, db.Model(&ledgerdomain.LedgerEntry{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestProcessLateUsage(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&ratingdomain.RatingResult{},
+		&adjustmentdomain.Adjustment{},
+		&ledgerdomain.LedgerAccount{},
+		&ledgerdomain.LedgerEntry{},
+		&ledgerdomain.LedgerEntryLine{},
+	))
+	require.NoError(t, db.Exec(`CREATE TABLE usage_events (
+		id BIGINT PRIMARY KEY, org_id BIGINT, customer_id BIGINT, subscription_id BIGINT, meter_id BIGINT,
+		recorded_at DATETIME, status TEXT, corrects_event_id BIGINT, correction_reason TEXT,
+		late_policy TEXT, billable_at DATETIME)`).Error)
+	require.NoError(t, db.Exec("CREATE TABLE billing_cycles (id BIGINT, org_id BIGINT, subscription_id BIGINT, period_start DATETIME, period_end DATETIME, status TEXT)").Error)
+
+	node, _ := snowflake.NewNode(1)
+	orgID := node.Generate()
+	customerID := node.Generate()
+	subID := node.Generate()
+	meterID := node.Generate()
+	priceID := node.Generate()
+	cycleID := node.Generate()
+	now := time.Now().UTC()
+	start := now.AddDate(0, -1, 0)
+
+	require.NoError(t, db.Create(&ledgerdomain.LedgerAccount{ID: node.Generate(), OrgID: orgID, Code: ledgerdomain.AccountCodeAccountsReceivable, Type: ledgerdomain.Assets, Name: "AR"}).Error)
+	require.NoError(t, db.Create(&ledgerdomain.LedgerAccount{ID: node.Generate(), OrgID: orgID, Code: ledgerdomain.AccountCodeRevenueUsage, Type: ledgerdomain.Income, Name: "Usage revenue"}).Error)
+	require.NoError(t, db.Exec("INSERT INTO billing_cycles VALUES (?, ?, ?, ?, ?, ?)", cycleID, orgID, subID, start, now, "CLOSED").Error)
+	require.NoError(t, db.Create(&ratingdomain.RatingResult{
+		ID: node.Generate(), OrgID: orgID, SubscriptionID: subID, BillingCycleID: cycleID,
+		PriceID: priceID, FeatureCode: "api_calls", MeterID: &meterID,
+		Quantity: 10, UnitPrice: 100, Amount: 1000, Currency: "USD",
+		PeriodStart: start, PeriodEnd: now, Source: "usage", Checksum: "original", CreatedAt: now,
+	}).Error)
+
+	lateID := node.Generate()
+	pendingID := node.Generate()
+	require.NoError(t, db.Exec("INSERT INTO usage_events VALUES (?, ?, ?, ?, ?, ?, ?, NULL, NULL, ?, NULL), (?, ?, ?, ?, ?, ?, ?, NULL, NULL, ?, NULL)",
+		lateID, orgID, customerID, subID, meterID, start.Add(time.Hour), "enriched", "adjustment",
+		pendingID, orgID, customerID, 0, 0, start.Add(time.Hour), "accepted", "adjustment").Error)
+
+	rating := &rerateStub{results: []ratingdomain.RatingResult{{
+		OrgID: orgID, SubscriptionID: subID, BillingCycleID: cycleID,
+		PriceID: priceID, FeatureCode: "api_calls", MeterID: &meterID,
+		Quantity: 12, UnitPrice: 100, Amount: 1200, Currency: "USD",
+	}}}
+	svc := NewService(ServiceParam{DB: db, Log: zap.NewNop(), GenID: node, Repo: repository.Provide(), RatingSvc: rating})
+
+	require.NoError(t, svc.ProcessLateUsage(context.Background(), 10))
+	require.NoError(t, svc.ProcessLateUsage(context.Background(), 10))
+	assert.Equal(t, 1, rating.calls)
+
+	var adjustments []adjustmentdomain.Adjustment
+	require.NoError(t, db.Find(&adjustments).Error)
+	require.Len(t, adjustments, 1)
+	assert.Equal(t, lateID, adjustments[0].UsageEventID)
+	assert.Equal(t, lateID, adjustments[0].CorrectionEventID)
+	assert.Equal(t, int64(200), adjustments[0].Amount)
+	assert.Equal(t, adjustmentdomain.AdjustmentStatusPending, adjustments[0].Status)
+
+	// Events without the adjustment late policy are not priced here.
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+	correctionID := node.Generate()
+	require.NoError(t, db.Exec("INSERT INTO usage_events VALUES (?, ?, ?, ?, ?, ?, ?, NULL, NULL, NULL, NULL)",
+		correctionID, orgID, customerID, subID, meterID, start.Add(time.Hour), "enriched").Error)
+	_, err = svc.ApplyLateUsage(ctx, correctionID.String())
+	assert.ErrorIs(t, err, adjustmentdomain.ErrNotLateUsage)
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/bwmarrin/snowflake"
+	"gorm.io/datatypes"
 )
 
 type AlertFrequency string
@@ -16,17 +17,55 @@ type AlertApplyTo string
 
 const (
 	AlertApplyToAllCustomers AlertApplyTo = "all_customers"
+	AlertApplyToCustomer     AlertApplyTo = "customer"
 )
 
+// Notification channels recorded on alert events.
+const (
+	ChannelEvent = "event"
+	ChannelEmail = "email"
+	ChannelSlack = "slack"
+)
+
+// Alert fires when a customer's cycle-to-date usage of MeterID reaches
+// Threshold. CustomerID is set when ApplyTo is customer.
 type Alert struct {
-	ID        snowflake.ID   `gorm:"primaryKey"`
-	OrgID     snowflake.ID   `gorm:"index"`
-	Name      string         `gorm:"not null"`
-	MeterID   snowflake.ID   `gorm:"not null;index"`
-	Threshold float64        `gorm:"not null"`
-	Frequency AlertFrequency `gorm:"not null"`
-	ApplyTo   AlertApplyTo   `gorm:"not null"`
-	Active    bool           `gorm:"not null;default:false"`
-	CreatedAt time.Time      `gorm:"autoCreateTime"`
-	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
+	ID           snowflake.ID                `gorm:"primaryKey"`
+	OrgID        snowflake.ID                `gorm:"index"`
+	Name         string                      `gorm:"not null"`
+	MeterID      snowflake.ID                `gorm:"not null;index"`
+	Threshold    float64                     `gorm:"not null"`
+	Frequency    AlertFrequency              `gorm:"not null"`
+	ApplyTo      AlertApplyTo                `gorm:"not null"`
+	CustomerID   *snowflake.ID               `gorm:"index"`
+	NotifyEmails datatypes.JSONSlice[string] `gorm:"type:jsonb"`
+	SlackChannel *string                     `gorm:"type:text"`
+	Active       bool                        `gorm:"not null;default:false"`
+	CreatedAt    time.Time                   `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time                   `gorm:"autoUpdateTime"`
 }
+
+// TableName sets the database table name.
+func (Alert) TableName() string { return "alerts" }
+
+// AlertEvent records one firing of an alert. An alert fires at most once per
+// customer and billing cycle; Channels lists the notifications delivered.
+type AlertEvent struct {
+	ID             snowflake.ID                `gorm:"primaryKey"`
+	OrgID          snowflake.ID                `gorm:"not null;index"`
+	AlertID        snowflake.ID                `gorm:"not null;uniqueIndex:ux_alert_events_cycle,priority:1"`
+	CustomerID     snowflake.ID                `gorm:"not null;uniqueIndex:ux_alert_events_cycle,priority:2"`
+	BillingCycleID snowflake.ID                `gorm:"not null;uniqueIndex:ux_alert_events_cycle,priority:3"`
+	SubscriptionID snowflake.ID                `gorm:"not null"`
+	MeterID        snowflake.ID                `gorm:"not null"`
+	Threshold      float64                     `gorm:"not null"`
+	Value          float64                     `gorm:"not null"`
+	PeriodStart    time.Time                   `gorm:"not null"`
+	PeriodEnd      time.Time                   `gorm:"not null"`
+	Channels       datatypes.JSONSlice[string] `gorm:"type:jsonb"`
+	FiredAt        time.Time                   `gorm:"not null"`
+	CreatedAt      time.Time                   `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName sets the database table name.
+func (AlertEvent) TableName() string { return "alert_events" }
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/gorm"
+)
+
+// EventFilter narrows alert history listings.
+type EventFilter struct {
+	AlertID    *snowflake.ID
+	CustomerID *snowflake.ID
+	Limit      int
+}
+
+// Meter is the subset of a meter the evaluator aggregates by.
+type Meter struct {
+	ID             snowflake.ID `gorm:"column:id"`
+	Code           string       `gorm:"column:code"`
+	Name           string       `gorm:"column:name"`
+	Aggregation    string       `gorm:"column:aggregation"`
+	AggregationKey string       `gorm:"column:aggregation_key"`
+}
+
+// Candidate is an open billing cycle an alert has not fired for yet.
+type Candidate struct {
+	BillingCycleID snowflake.ID `gorm:"column:billing_cycle_id"`
+	SubscriptionID snowflake.ID `gorm:"column:subscription_id"`
+	CustomerID     snowflake.ID `gorm:"column:customer_id"`
+	OrgName        string       `gorm:"column:org_name"`
+	CustomerName   string       `gorm:"column:customer_name"`
+	CustomerEmail  string       `gorm:"column:customer_email"`
+	PeriodStart    time.Time    `gorm:"column:period_start"`
+	PeriodEnd      time.Time    `gorm:"column:period_end"`
+}
+
+type Repository interface {
+	Insert(ctx context.Context, db *gorm.DB, alert *Alert) error
+	FindByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*Alert, error)
+	List(ctx context.Context, db *gorm.DB, orgID snowflake.ID) ([]Alert, error)
+	Update(ctx context.Context, db *gorm.DB, alert *Alert) error
+	Delete(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) error
+
+	// ListActive returns active alerts of all organizations.
+	ListActive(ctx context.Context, db *gorm.DB) ([]Alert, error)
+	FindMeter(ctx context.Context, db *gorm.DB, orgID, meterID snowflake.ID) (*Meter, error)
+	CustomerExists(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID) (bool, error)
+	// ListCandidates returns open billing cycles in scope of the alert that
+	// have no alert event yet, ordered by cycle ID and starting after afterID.
+	ListCandidates(ctx context.Context, db *gorm.DB, alert *Alert, afterID snowflake.ID, limit int) ([]Candidate, error)
+	// CycleUsage aggregates the customer's usage of meter billed in [start, end).
+	// Accepted events have no meter ID yet, so events are matched by meter code.
+	CycleUsage(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, meter *Meter, start, end time.Time) (float64, error)
+
+	// InsertEvent returns false when the alert already fired for the cycle.
+	InsertEvent(ctx context.Context, db *gorm.DB, event *AlertEvent) (bool, error)
+	UpdateEventChannels(ctx context.Context, db *gorm.DB, event *AlertEvent) error
+	ListEvents(ctx context.Context, db *gorm.DB, orgID snowflake.ID, filter EventFilter) ([]AlertEvent, error)
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// MaxNotifyEmails bounds the number of recipients per alert.
+const MaxNotifyEmails = 10
+
+type CreateRequest struct {
+	Name         string   `json:"name"`
+	MeterID      string   `json:"meter_id"`
+	Threshold    float64  `json:"threshold"`
+	ApplyTo      string   `json:"apply_to"`
+	CustomerID   string   `json:"customer_id"`
+	NotifyEmails []string `json:"notify_emails"`
+	SlackChannel string   `json:"slack_channel"`
+	Active       *bool    `json:"active"`
+}
+
+// UpdateRequest changes the provided fields only. The meter and scope of an
+// alert are fixed once created.
+type UpdateRequest struct {
+	Name         *string   `json:"name"`
+	Threshold    *float64  `json:"threshold"`
+	NotifyEmails *[]string `json:"notify_emails"`
+	SlackChannel *string   `json:"slack_channel"`
+	Active       *bool     `json:"active"`
+}
+
+type Response struct {
+	ID           string    `json:"id"`
+	OrgID        string    `json:"organization_id"`
+	Name         string    `json:"name"`
+	MeterID      string    `json:"meter_id"`
+	Threshold    float64   `json:"threshold"`
+	Frequency    string    `json:"frequency"`
+	ApplyTo      string    `json:"apply_to"`
+	CustomerID   *string   `json:"customer_id,omitempty"`
+	NotifyEmails []string  `json:"notify_emails"`
+	SlackChannel *string   `json:"slack_channel,omitempty"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type ListHistoryRequest struct {
+	AlertID    string `form:"alert_id"`
+	CustomerID string `form:"customer_id"`
+	Limit      int    `form:"limit"`
+}
+
+type EventResponse struct {
+	ID             string    `json:"id"`
+	OrgID          string    `json:"organization_id"`
+	AlertID        string    `json:"alert_id"`
+	CustomerID     string    `json:"customer_id"`
+	SubscriptionID string    `json:"subscription_id"`
+	BillingCycleID string    `json:"billing_cycle_id"`
+	MeterID        string    `json:"meter_id"`
+	Threshold      float64   `json:"threshold"`
+	Value          float64   `json:"value"`
+	PeriodStart    time.Time `json:"period_start"`
+	PeriodEnd      time.Time `json:"period_end"`
+	Channels       []string  `json:"channels"`
+	FiredAt        time.Time `json:"fired_at"`
+}
+
+type Service interface {
+	Create(ctx context.Context, req CreateRequest) (*Response, error)
+	List(ctx context.Context) ([]Response, error)
+	Get(ctx context.Context, id string) (*Response, error)
+	Update(ctx context.Context, id string, req UpdateRequest) (*Response, error)
+	Delete(ctx context.Context, id string) error
+	ListHistory(ctx context.Context, req ListHistoryRequest) ([]EventResponse, error)
+
+	// Evaluate compares cycle-to-date usage of every open billing cycle against
+	// active alerts and fires each alert at most once per customer and cycle.
+	Evaluate(ctx context.Context, limit int) error
+}
+
+var (
+	ErrInvalidOrganization = errors.New("invalid_organization")
+	ErrInvalidID           = errors.New("invalid_id")
+	ErrInvalidName         = errors.New("invalid_name")
+	ErrInvalidMeter        = errors.New("invalid_meter")
+	ErrInvalidThreshold    = errors.New("invalid_threshold")
+	ErrInvalidApplyTo      = errors.New("invalid_apply_to")
+	ErrInvalidCustomer     = errors.New("invalid_customer")
+	ErrInvalidNotifyEmail  = errors.New("invalid_notify_email")
+	ErrInvalidSlackChannel = errors.New("invalid_slack_channel")
+	ErrAlertNotFound       = errors.New("alert_not_found")
+)
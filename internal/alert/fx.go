@@ -1 +1,12 @@
 package alert
+
+import (
+	"github.com/smallbiznis/railzway/internal/alert/repository"
+	"github.com/smallbiznis/railzway/internal/alert/service"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("alert.service",
+	fx.Provide(repository.Provide),
+	fx.Provide(service.NewService),
+)
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	alertdomain "github.com/smallbiznis/railzway/internal/alert/domain"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"gorm.io/gorm"
+)
+
+type repo struct{}
+
+func Provide() alertdomain.Repository {
+	return &repo{}
+}
+
+const alertColumns = `id, org_id, name, meter_id, threshold, frequency, apply_to, customer_id,
+	notify_emails, slack_channel, active, created_at, updated_at`
+
+const eventColumns = `id, org_id, alert_id, customer_id, subscription_id, billing_cycle_id, meter_id,
+	threshold, value, period_start, period_end, channels, fired_at, created_at`
+
+// countedUsageStatuses are the usage statuses counted towards alert thresholds.
+// Accepted events are included so alerts do not wait for enrichment.
+var countedUsageStatuses = []string{
+	usagedomain.UsageStatusAccepted,
+	usagedomain.UsageStatusEnriched,
+	usagedomain.UsageStatusRated,
+}
+
+func (r *repo) Insert(ctx context.Context, db *gorm.DB, alert *alertdomain.Alert) error {
+	return db.WithContext(ctx).Exec(
+		`INSERT INTO alerts (`+alertColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		alert.ID,
+		alert.OrgID,
+		alert.Name,
+		alert.MeterID,
+		alert.Threshold,
+		alert.Frequency,
+		alert.ApplyTo,
+		alert.CustomerID,
+		alert.NotifyEmails,
+		alert.SlackChannel,
+		alert.Active,
+		alert.CreatedAt,
+		alert.UpdatedAt,
+	).Error
+}
+
+func (r *repo) FindByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*alertdomain.Alert, error) {
+	var alert alertdomain.Alert
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+alertColumns+`
+		 FROM alerts
+		 WHERE org_id = ? AND id = ?`,
+		orgID,
+		id,
+	).Scan(&alert).Error
+	if err != nil {
+		return nil, err
+	}
+	if alert.ID == 0 {
+		return nil, nil
+	}
+	return &alert, nil
+}
+
+func (r *repo) List(ctx context.Context, db *gorm.DB, orgID snowflake.ID) ([]alertdomain.Alert, error) {
+	var alerts []alertdomain.Alert
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+alertColumns+`
+		 FROM alerts
+		 WHERE org_id = ?
+		 ORDER BY id DESC`,
+		orgID,
+	).Scan(&alerts).Error
+	if err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func (r *repo) Update(ctx context.Context, db *gorm.DB, alert *alertdomain.Alert) error {
+	return db.WithContext(ctx).Exec(
+		`UPDATE alerts
+		 SET name = ?, threshold = ?, notify_emails = ?, slack_channel = ?, active = ?, updated_at = ?
+		 WHERE org_id = ? AND id = ?`,
+		alert.Name,
+		alert.Threshold,
+		alert.NotifyEmails,
+		alert.SlackChannel,
+		alert.Active,
+		alert.UpdatedAt,
+		alert.OrgID,
+		alert.ID,
+	).Error
+}
+
+func (r *repo) Delete(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) error {
+	return db.WithContext(ctx).Exec(
+		`DELETE FROM alerts WHERE org_id = ? AND id = ?`,
+		orgID,
+		id,
+	).Error
+}
+
+func (r *repo) ListActive(ctx context.Context, db *gorm.DB) ([]alertdomain.Alert, error) {
+	var alerts []alertdomain.Alert
+	err := db.WithContext(ctx).Raw(
+		`SELECT ` + alertColumns + `
+		 FROM alerts
+		 WHERE active = TRUE
+		 ORDER BY id ASC`,
+	).Scan(&alerts).Error
+	if err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func (r *repo) FindMeter(ctx context.Context, db *gorm.DB, orgID, meterID snowflake.ID) (*alertdomain.Meter, error) {
+	var meter alertdomain.Meter
+	err := db.WithContext(ctx).Raw(
+		`SELECT id, code, name, aggregation, COALESCE(aggregation_key, '') AS aggregation_key
+		 FROM meters
+		 WHERE org_id = ? AND id = ?`,
+		orgID,
+		meterID,
+	).Scan(&meter).Error
+	if err != nil {
+		return nil, err
+	}
+	if meter.ID == 0 {
+		return nil, nil
+	}
+	return &meter, nil
+}
+
+func (r *repo) CustomerExists(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID) (bool, error) {
+	var count int64
+	err := db.WithContext(ctx).Raw(
+		`SELECT COUNT(1) FROM customers WHERE org_id = ? AND id = ?`,
+		orgID,
+		customerID,
+	).Scan(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *repo) ListCandidates(ctx context.Context, db *gorm.DB, alert *alertdomain.Alert, afterID snowflake.ID, limit int) ([]alertdomain.Candidate, error) {
+	query := `SELECT bc.id AS billing_cycle_id, bc.subscription_id, s.customer_id,
+		        COALESCE(o.name, '') AS org_name,
+		        COALESCE(c.name, '') AS customer_name, COALESCE(c.email, '') AS customer_email,
+		        bc.period_start, bc.period_end
+		 FROM billing_cycles bc
+		 JOIN subscriptions s ON s.id = bc.subscription_id AND s.org_id = bc.org_id
+		 LEFT JOIN customers c ON c.id = s.customer_id AND c.org_id = s.org_id
+		 LEFT JOIN organizations o ON o.id = bc.org_id
+		 WHERE bc.org_id = ? AND bc.status = ? AND bc.id > ?`
+	args := []any{alert.OrgID, billingcycledomain.BillingCycleStatusOpen, afterID}
+	if alert.CustomerID != nil {
+		query += " AND s.customer_id = ?"
+		args = append(args, *alert.CustomerID)
+	}
+	query += ` AND NOT EXISTS (
+		     SELECT 1 FROM alert_events ae
+		     WHERE ae.alert_id = ? AND ae.customer_id = s.customer_id AND ae.billing_cycle_id = bc.id
+		 )
+		 ORDER BY bc.id ASC
+		 LIMIT ?`
+	args = append(args, alert.ID, limit)
+
+	var candidates []alertdomain.Candidate
+	if err := db.WithContext(ctx).Raw(query, args...).Scan(&candidates).Error; err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+func (r *repo) CycleUsage(
+	ctx context.Context,
+	db *gorm.DB,
+	orgID, customerID snowflake.ID,
+	meter *alertdomain.Meter,
+	start, end time.Time,
+) (float64, error) {
+	const window = `FROM usage_events
+		 WHERE org_id = ? AND customer_id = ? AND meter_code = ?
+		 AND COALESCE(billable_at, recorded_at) >= ? AND COALESCE(billable_at, recorded_at) < ?
+		 AND status IN ?`
+	args := []any{orgID, customerID, meter.Code, start, end, countedUsageStatuses}
+
+	aggregation, _ := meterdomain.NormalizeAggregation(meter.Aggregation)
+	var query string
+	switch aggregation {
+	case meterdomain.AggregationMax:
+		query = `SELECT COALESCE(MAX(value), 0) ` + window
+	case meterdomain.AggregationMin:
+		query = `SELECT COALESCE(MIN(value), 0) ` + window
+	case meterdomain.AggregationAvg:
+		query = `SELECT COALESCE(AVG(value), 0) ` + window
+	case meterdomain.AggregationCount:
+		query = `SELECT COUNT(*) ` + window
+	case meterdomain.AggregationLast:
+		query = `SELECT value ` + window + ` ORDER BY recorded_at DESC, id DESC LIMIT 1`
+	case meterdomain.AggregationUniqueCount:
+		query = `SELECT COUNT(DISTINCT ` + metadataValueExpr(db) + `) ` + window
+		args = append([]any{strings.TrimSpace(meter.AggregationKey)}, args...)
+	default:
+		query = `SELECT COALESCE(SUM(value), 0) ` + window
+	}
+
+	var value float64
+	if err := db.WithContext(ctx).Raw(query, args...).Scan(&value).Error; err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+func (r *repo) InsertEvent(ctx context.Context, db *gorm.DB, event *alertdomain.AlertEvent) (bool, error) {
+	result := db.WithContext(ctx).Exec(
+		`INSERT INTO alert_events (`+eventColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (alert_id, customer_id, billing_cycle_id) DO NOTHING`,
+		event.ID,
+		event.OrgID,
+		event.AlertID,
+		event.CustomerID,
+		event.SubscriptionID,
+		event.BillingCycleID,
+		event.MeterID,
+		event.Threshold,
+		event.Value,
+		event.PeriodStart,
+		event.PeriodEnd,
+		event.Channels,
+		event.FiredAt,
+		event.CreatedAt,
+	)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repo) UpdateEventChannels(ctx context.Context, db *gorm.DB, event *alertdomain.AlertEvent) error {
+	return db.WithContext(ctx).Exec(
+		`UPDATE alert_events SET channels = ? WHERE id = ?`,
+		event.Channels,
+		event.ID,
+	).Error
+}
+
+func (r *repo) ListEvents(ctx context.Context, db *gorm.DB, orgID snowflake.ID, filter alertdomain.EventFilter) ([]alertdomain.AlertEvent, error) {
+	query := `SELECT ` + eventColumns + `
+		 FROM alert_events
+		 WHERE org_id = ?`
+	args := []any{orgID}
+	if filter.AlertID != nil {
+		query += " AND alert_id = ?"
+		args = append(args, *filter.AlertID)
+	}
+	if filter.CustomerID != nil {
+		query += " AND customer_id = ?"
+		args = append(args, *filter.CustomerID)
+	}
+	query += " ORDER BY fired_at DESC, id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	var events []alertdomain.AlertEvent
+	if err := db.WithContext(ctx).Raw(query, args...).Scan(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func metadataValueExpr(db *gorm.DB) string {
+	if db != nil && strings.EqualFold(db.Dialector.Name(), "sqlite") {
+		return `json_extract(metadata, '$.' || ?)`
+	}
+	return `metadata->>?`
+}
@@ -0,0 +1,623 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	alertdomain "github.com/smallbiznis/railzway/internal/alert/domain"
+	auditdomain "github.com/smallbiznis/railzway/internal/audit/domain"
+	"github.com/smallbiznis/railzway/internal/clock"
+	"github.com/smallbiznis/railzway/internal/events"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	"github.com/smallbiznis/railzway/internal/providers/email"
+	"github.com/smallbiznis/railzway/internal/providers/slack"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+
+	alertTemplate = "usage_alert"
+	dateLayout    = "January 2, 2006"
+)
+
+type Params struct {
+	fx.In
+
+	DB            *gorm.DB
+	Log           *zap.Logger
+	GenID         *snowflake.Node
+	Clock         clock.Clock
+	Repo          alertdomain.Repository
+	Outbox        *events.Outbox      `optional:"true"`
+	AuditSvc      auditdomain.Service `optional:"true"`
+	EmailProvider email.Provider      `optional:"true"`
+	SlackProvider slack.Provider      `optional:"true"`
+}
+
+type Service struct {
+	db            *gorm.DB
+	log           *zap.Logger
+	genID         *snowflake.Node
+	clock         clock.Clock
+	repo          alertdomain.Repository
+	outbox        *events.Outbox
+	auditSvc      auditdomain.Service
+	emailProvider email.Provider
+	slackProvider slack.Provider
+}
+
+func NewService(p Params) alertdomain.Service {
+	return &Service{
+		db:            p.DB,
+		log:           p.Log.Named("alert.service"),
+		genID:         p.GenID,
+		clock:         p.Clock,
+		repo:          p.Repo,
+		outbox:        p.Outbox,
+		auditSvc:      p.AuditSvc,
+		emailProvider: p.EmailProvider,
+		slackProvider: p.SlackProvider,
+	}
+}
+
+func (s *Service) Create(ctx context.Context, req alertdomain.CreateRequest) (*alertdomain.Response, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, alertdomain.ErrInvalidOrganization
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, alertdomain.ErrInvalidName
+	}
+	if err := validateThreshold(req.Threshold); err != nil {
+		return nil, err
+	}
+	meterID, err := parseID(req.MeterID, alertdomain.ErrInvalidMeter)
+	if err != nil {
+		return nil, err
+	}
+	notifyEmails, err := normalizeEmails(req.NotifyEmails)
+	if err != nil {
+		return nil, err
+	}
+	slackChannel, err := normalizeSlackChannel(req.SlackChannel)
+	if err != nil {
+		return nil, err
+	}
+
+	applyTo := alertdomain.AlertApplyTo(strings.ToLower(strings.TrimSpace(req.ApplyTo)))
+	if applyTo == "" {
+		applyTo = alertdomain.AlertApplyToAllCustomers
+	}
+	var customerID *snowflake.ID
+	switch applyTo {
+	case alertdomain.AlertApplyToAllCustomers:
+		if strings.TrimSpace(req.CustomerID) != "" {
+			return nil, alertdomain.ErrInvalidApplyTo
+		}
+	case alertdomain.AlertApplyToCustomer:
+		id, err := parseID(req.CustomerID, alertdomain.ErrInvalidCustomer)
+		if err != nil {
+			return nil, err
+		}
+		customerID = &id
+	default:
+		return nil, alertdomain.ErrInvalidApplyTo
+	}
+
+	meter, err := s.repo.FindMeter(ctx, s.db, orgID, meterID)
+	if err != nil {
+		return nil, err
+	}
+	if meter == nil {
+		return nil, alertdomain.ErrInvalidMeter
+	}
+	if customerID != nil {
+		exists, err := s.repo.CustomerExists(ctx, s.db, orgID, *customerID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, alertdomain.ErrInvalidCustomer
+		}
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	now := s.clock.Now().UTC()
+	alert := &alertdomain.Alert{
+		ID:           s.genID.Generate(),
+		OrgID:        orgID,
+		Name:         name,
+		MeterID:      meterID,
+		Threshold:    req.Threshold,
+		Frequency:    alertdomain.AlertFrequencyPerCustomer,
+		ApplyTo:      applyTo,
+		CustomerID:   customerID,
+		NotifyEmails: datatypes.JSONSlice[string](notifyEmails),
+		SlackChannel: slackChannel,
+		Active:       active,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.repo.Insert(ctx, s.db, alert); err != nil {
+		return nil, err
+	}
+
+	s.emitAudit(ctx, orgID, "alert.create", alert.ID, map[string]any{
+		"name":      alert.Name,
+		"meter_id":  alert.MeterID.String(),
+		"threshold": alert.Threshold,
+		"apply_to":  string(alert.ApplyTo),
+	})
+	return toResponse(alert), nil
+}
+
+func (s *Service) List(ctx context.Context) ([]alertdomain.Response, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, alertdomain.ErrInvalidOrganization
+	}
+
+	alerts, err := s.repo.List(ctx, s.db, orgID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]alertdomain.Response, 0, len(alerts))
+	for i := range alerts {
+		resp = append(resp, *toResponse(&alerts[i]))
+	}
+	return resp, nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*alertdomain.Response, error) {
+	alert, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(alert), nil
+}
+
+func (s *Service) Update(ctx context.Context, id string, req alertdomain.UpdateRequest) (*alertdomain.Response, error) {
+	alert, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, alertdomain.ErrInvalidName
+		}
+		alert.Name = name
+	}
+	if req.Threshold != nil {
+		if err := validateThreshold(*req.Threshold); err != nil {
+			return nil, err
+		}
+		alert.Threshold = *req.Threshold
+	}
+	if req.NotifyEmails != nil {
+		notifyEmails, err := normalizeEmails(*req.NotifyEmails)
+		if err != nil {
+			return nil, err
+		}
+		alert.NotifyEmails = datatypes.JSONSlice[string](notifyEmails)
+	}
+	if req.SlackChannel != nil {
+		slackChannel, err := normalizeSlackChannel(*req.SlackChannel)
+		if err != nil {
+			return nil, err
+		}
+		alert.SlackChannel = slackChannel
+	}
+	if req.Active != nil {
+		alert.Active = *req.Active
+	}
+	alert.UpdatedAt = s.clock.Now().UTC()
+
+	if err := s.repo.Update(ctx, s.db, alert); err != nil {
+		return nil, err
+	}
+
+	s.emitAudit(ctx, alert.OrgID, "alert.update", alert.ID, map[string]any{
+		"name":      alert.Name,
+		"threshold": alert.Threshold,
+		"active":    alert.Active,
+	})
+	return toResponse(alert), nil
+}
+
+func (s *Service) Delete(ctx context.Context, id string) error {
+	alert, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, s.db, alert.OrgID, alert.ID); err != nil {
+		return err
+	}
+
+	s.emitAudit(ctx, alert.OrgID, "alert.delete", alert.ID, map[string]any{
+		"name": alert.Name,
+	})
+	return nil
+}
+
+func (s *Service) ListHistory(ctx context.Context, req alertdomain.ListHistoryRequest) ([]alertdomain.EventResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, alertdomain.ErrInvalidOrganization
+	}
+
+	filter := alertdomain.EventFilter{Limit: req.Limit}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+	if value := strings.TrimSpace(req.AlertID); value != "" {
+		id, err := parseID(value, alertdomain.ErrInvalidID)
+		if err != nil {
+			return nil, err
+		}
+		filter.AlertID = &id
+	}
+	if value := strings.TrimSpace(req.CustomerID); value != "" {
+		id, err := parseID(value, alertdomain.ErrInvalidCustomer)
+		if err != nil {
+			return nil, err
+		}
+		filter.CustomerID = &id
+	}
+
+	items, err := s.repo.ListEvents(ctx, s.db, orgID, filter)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]alertdomain.EventResponse, 0, len(items))
+	for i := range items {
+		resp = append(resp, toEventResponse(&items[i]))
+	}
+	return resp, nil
+}
+
+// Evaluate pages through the open billing cycles of every active alert. An
+// alert is recorded and published in one transaction, so the unique
+// (alert, customer, cycle) key keeps it from firing twice in a cycle even
+// when passes overlap. Email and Slack notifications are best effort.
+func (s *Service) Evaluate(ctx context.Context, limit int) error {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	alerts, err := s.repo.ListActive(ctx, s.db)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range alerts {
+		if err := s.evaluateAlert(ctx, &alerts[i], limit); err != nil {
+			errs = append(errs, err)
+			s.log.Warn("failed to evaluate alert", zap.String("alert_id", alerts[i].ID.String()), zap.Error(err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Service) evaluateAlert(ctx context.Context, alert *alertdomain.Alert, limit int) error {
+	meter, err := s.repo.FindMeter(ctx, s.db, alert.OrgID, alert.MeterID)
+	if err != nil {
+		return err
+	}
+	if meter == nil {
+		return nil
+	}
+
+	var errs []error
+	var afterID snowflake.ID
+	for {
+		candidates, err := s.repo.ListCandidates(ctx, s.db, alert, afterID, limit)
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		for i := range candidates {
+			if err := s.evaluateCandidate(ctx, alert, meter, &candidates[i]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(candidates) < limit {
+			return errors.Join(errs...)
+		}
+		afterID = candidates[len(candidates)-1].BillingCycleID
+	}
+}
+
+func (s *Service) evaluateCandidate(ctx context.Context, alert *alertdomain.Alert, meter *alertdomain.Meter, candidate *alertdomain.Candidate) error {
+	value, err := s.repo.CycleUsage(ctx, s.db, alert.OrgID, candidate.CustomerID, meter, candidate.PeriodStart, candidate.PeriodEnd)
+	if err != nil {
+		return err
+	}
+	if value < alert.Threshold {
+		return nil
+	}
+
+	now := s.clock.Now().UTC()
+	event := &alertdomain.AlertEvent{
+		ID:             s.genID.Generate(),
+		OrgID:          alert.OrgID,
+		AlertID:        alert.ID,
+		CustomerID:     candidate.CustomerID,
+		SubscriptionID: candidate.SubscriptionID,
+		BillingCycleID: candidate.BillingCycleID,
+		MeterID:        alert.MeterID,
+		Threshold:      alert.Threshold,
+		Value:          value,
+		PeriodStart:    candidate.PeriodStart,
+		PeriodEnd:      candidate.PeriodEnd,
+		Channels:       datatypes.JSONSlice[string]{},
+		FiredAt:        now,
+		CreatedAt:      now,
+	}
+	if s.outbox != nil {
+		event.Channels = append(event.Channels, alertdomain.ChannelEvent)
+	}
+
+	var inserted bool
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		inserted, err = s.repo.InsertEvent(ctx, tx, event)
+		if err != nil || !inserted || s.outbox == nil {
+			return err
+		}
+		return s.outbox.PublishTx(ctx, tx, events.Event{
+			OrgID:     alert.OrgID,
+			Type:      events.EventAlertTriggered,
+			Payload:   eventPayload(alert, meter, event),
+			DedupeKey: fmt.Sprintf("alert:%s:%s:%s", alert.ID, candidate.BillingCycleID, candidate.CustomerID),
+		})
+	})
+	if err != nil || !inserted {
+		return err
+	}
+
+	delivered := false
+	if s.sendEmail(ctx, alert, meter, candidate, event) {
+		event.Channels = append(event.Channels, alertdomain.ChannelEmail)
+		delivered = true
+	}
+	if s.postSlack(ctx, alert, meter, candidate, event) {
+		event.Channels = append(event.Channels, alertdomain.ChannelSlack)
+		delivered = true
+	}
+	if delivered {
+		if err := s.repo.UpdateEventChannels(ctx, s.db, event); err != nil {
+			s.log.Warn("failed to record alert channels", zap.String("alert_event_id", event.ID.String()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (s *Service) sendEmail(ctx context.Context, alert *alertdomain.Alert, meter *alertdomain.Meter, candidate *alertdomain.Candidate, event *alertdomain.AlertEvent) bool {
+	if s.emailProvider == nil || len(alert.NotifyEmails) == 0 {
+		return false
+	}
+
+	data := struct {
+		OrgName      string
+		AlertName    string
+		MeterName    string
+		CustomerName string
+		Threshold    string
+		Value        string
+		PeriodStart  string
+		PeriodEnd    string
+	}{
+		OrgName:      candidate.OrgName,
+		AlertName:    alert.Name,
+		MeterName:    meterName(meter),
+		CustomerName: customerName(candidate),
+		Threshold:    formatValue(event.Threshold),
+		Value:        formatValue(event.Value),
+		PeriodStart:  event.PeriodStart.Format(dateLayout),
+		PeriodEnd:    event.PeriodEnd.Format(dateLayout),
+	}
+	msg := email.EmailMessage{
+		To:         append([]string(nil), alert.NotifyEmails...),
+		SenderName: candidate.OrgName,
+		Subject:    fmt.Sprintf("Usage alert: %s for %s", alert.Name, customerName(candidate)),
+	}
+	if err := s.emailProvider.SendTemplate(ctx, msg, alertTemplate, data); err != nil {
+		s.log.Warn("failed to send alert email", zap.String("alert_event_id", event.ID.String()), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+func (s *Service) postSlack(ctx context.Context, alert *alertdomain.Alert, meter *alertdomain.Meter, candidate *alertdomain.Candidate, event *alertdomain.AlertEvent) bool {
+	if s.slackProvider == nil || alert.SlackChannel == nil {
+		return false
+	}
+
+	message := fmt.Sprintf(
+		"Usage alert *%s*: %s usage of %s reached %s (threshold %s) in the billing period %s - %s.",
+		alert.Name,
+		customerName(candidate),
+		meterName(meter),
+		formatValue(event.Value),
+		formatValue(event.Threshold),
+		event.PeriodStart.Format(dateLayout),
+		event.PeriodEnd.Format(dateLayout),
+	)
+	if err := s.slackProvider.PostMessage(ctx, *alert.SlackChannel, message); err != nil {
+		s.log.Warn("failed to post alert to slack", zap.String("alert_event_id", event.ID.String()), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+func (s *Service) find(ctx context.Context, id string) (*alertdomain.Alert, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, alertdomain.ErrInvalidOrganization
+	}
+	alertID, err := parseID(id, alertdomain.ErrInvalidID)
+	if err != nil {
+		return nil, err
+	}
+	alert, err := s.repo.FindByID(ctx, s.db, orgID, alertID)
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		return nil, alertdomain.ErrAlertNotFound
+	}
+	return alert, nil
+}
+
+func (s *Service) emitAudit(ctx context.Context, orgID snowflake.ID, action string, targetID snowflake.ID, metadata map[string]any) {
+	if s.auditSvc == nil {
+		return
+	}
+	target := targetID.String()
+	_ = s.auditSvc.AuditLog(ctx, &orgID, "", nil, action, "alert", &target, metadata)
+}
+
+func eventPayload(alert *alertdomain.Alert, meter *alertdomain.Meter, event *alertdomain.AlertEvent) map[string]any {
+	return map[string]any{
+		"alert_event_id":   event.ID.String(),
+		"alert_id":         alert.ID.String(),
+		"alert_name":       alert.Name,
+		"customer_id":      event.CustomerID.String(),
+		"subscription_id":  event.SubscriptionID.String(),
+		"billing_cycle_id": event.BillingCycleID.String(),
+		"meter_id":         event.MeterID.String(),
+		"meter_code":       meter.Code,
+		"threshold":        event.Threshold,
+		"value":            event.Value,
+		"period_start":     event.PeriodStart.Format(time.RFC3339),
+		"period_end":       event.PeriodEnd.Format(time.RFC3339),
+		"fired_at":         event.FiredAt.Format(time.RFC3339),
+	}
+}
+
+func validateThreshold(threshold float64) error {
+	if threshold <= 0 {
+		return alertdomain.ErrInvalidThreshold
+	}
+	return nil
+}
+
+func normalizeEmails(values []string) ([]string, error) {
+	if len(values) > alertdomain.MaxNotifyEmails {
+		return nil, alertdomain.ErrInvalidNotifyEmail
+	}
+	out := make([]string, 0, len(values))
+	seen := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		addr, err := mail.ParseAddress(strings.TrimSpace(value))
+		if err != nil {
+			return nil, alertdomain.ErrInvalidNotifyEmail
+		}
+		key := strings.ToLower(addr.Address)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, addr.Address)
+	}
+	return out, nil
+}
+
+// normalizeSlackChannel accepts a channel ID or a #name; empty clears it.
+func normalizeSlackChannel(value string) (*string, error) {
+	channel := strings.TrimSpace(value)
+	if channel == "" {
+		return nil, nil
+	}
+	if len(channel) > 80 || strings.ContainsAny(channel, " \t\r\n") {
+		return nil, alertdomain.ErrInvalidSlackChannel
+	}
+	return &channel, nil
+}
+
+func parseID(value string, invalidErr error) (snowflake.ID, error) {
+	id, err := snowflake.ParseString(strings.TrimSpace(value))
+	if err != nil || id == 0 {
+		return 0, invalidErr
+	}
+	return id, nil
+}
+
+func meterName(meter *alertdomain.Meter) string {
+	if name := strings.TrimSpace(meter.Name); name != "" {
+		return name
+	}
+	return meter.Code
+}
+
+func customerName(candidate *alertdomain.Candidate) string {
+	if name := strings.TrimSpace(candidate.CustomerName); name != "" {
+		return name
+	}
+	return candidate.CustomerID.String()
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func toResponse(alert *alertdomain.Alert) *alertdomain.Response {
+	resp := &alertdomain.Response{
+		ID:           alert.ID.String(),
+		OrgID:        alert.OrgID.String(),
+		Name:         alert.Name,
+		MeterID:      alert.MeterID.String(),
+		Threshold:    alert.Threshold,
+		Frequency:    string(alert.Frequency),
+		ApplyTo:      string(alert.ApplyTo),
+		NotifyEmails: append([]string{}, alert.NotifyEmails...),
+		SlackChannel: alert.SlackChannel,
+		Active:       alert.Active,
+		CreatedAt:    alert.CreatedAt,
+		UpdatedAt:    alert.UpdatedAt,
+	}
+	if alert.CustomerID != nil {
+		id := alert.CustomerID.String()
+		resp.CustomerID = &id
+	}
+	return resp
+}
+
+func toEventResponse(event *alertdomain.AlertEvent) alertdomain.EventResponse {
+	return alertdomain.EventResponse{
+		ID:             event.ID.String(),
+		OrgID:          event.OrgID.String(),
+		AlertID:        event.AlertID.String(),
+		CustomerID:     event.CustomerID.String(),
+		SubscriptionID: event.SubscriptionID.String(),
+		BillingCycleID: event.BillingCycleID.String(),
+		MeterID:        event.MeterID.String(),
+		Threshold:      event.Threshold,
+		Value:          event.Value,
+		PeriodStart:    event.PeriodStart,
+		PeriodEnd:      event.PeriodEnd,
+		Channels:       append([]string{}, event.Channels...),
+		FiredAt:        event.FiredAt,
+	}
+}
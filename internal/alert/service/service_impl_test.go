@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/glebarez/sqlite"
+	alertdomain "github.com/smallbiznis/railzway/internal/alert/domain"
+	"github.com/smallbiznis/railzway/internal/alert/repository"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	"github.com/smallbiznis/railzway/internal/clock"
+	customerdomain "github.com/smallbiznis/railzway/internal/customer/domain"
+	"github.com/smallbiznis/railzway/internal/events"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	"github.com/smallbiznis/railzway/internal/providers/email"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type recordingEmail struct {
+	mu   sync.Mutex
+	sent []email.EmailMessage
+}
+
+func (r *recordingEmail) Send(context.Context, email.EmailMessage) error { return nil }
+
+func (r *recordingEmail) SendTemplate(_ context.Context, msg email.EmailMessage, _ string, _ interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+type recordingSlack struct {
+	mu       sync.Mutex
+	channels []string
+}
+
+func (r *recordingSlack) PostMessage(_ context.Context, channelID string, _ string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.channels = append(r.channels, channelID)
+	return nil
+}
+
+type alertFixture struct {
+	db         *gorm.DB
+	node       *snowflake.Node
+	clock      *clock.FakeClock
+	svc        alertdomain.Service
+	mail       *recordingEmail
+	slack      *recordingSlack
+	ctx        context.Context
+	orgID      snowflake.ID
+	customerID snowflake.ID
+	subID      snowflake.ID
+	cycleID    snowflake.ID
+	meterID    snowflake.ID
+}
+
+func setupAlertTest(t *testing.T) *alertFixture {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&customerdomain.Customer{},
+		&meterdomain.Meter{},
+		&subscriptiondomain.Subscription{},
+		&billingcycledomain.BillingCycle{},
+		&usagedomain.UsageEvent{},
+		&alertdomain.Alert{},
+		&alertdomain.AlertEvent{},
+	))
+	require.NoError(t, db.Exec("CREATE TABLE organizations (id BIGINT PRIMARY KEY, name TEXT)").Error)
+	require.NoError(t, db.Exec(`CREATE TABLE billing_events (
+		id BIGINT PRIMARY KEY, org_id BIGINT, event_type TEXT, payload TEXT,
+		dedupe_key TEXT, published BOOLEAN, created_at DATETIME, UNIQUE (org_id, dedupe_key))`).Error)
+
+	node, _ := snowflake.NewNode(1)
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	f := &alertFixture{
+		db:         db,
+		node:       node,
+		clock:      clock.NewFakeClock(now),
+		mail:       &recordingEmail{},
+		slack:      &recordingSlack{},
+		orgID:      node.Generate(),
+		customerID: node.Generate(),
+		subID:      node.Generate(),
+		cycleID:    node.Generate(),
+		meterID:    node.Generate(),
+	}
+	f.ctx = orgcontext.WithOrgID(context.Background(), int64(f.orgID))
+	f.svc = NewService(Params{
+		DB:            db,
+		Log:           zap.NewNop(),
+		GenID:         node,
+		Clock:         f.clock,
+		Repo:          repository.Provide(),
+		Outbox:        events.NewOutbox(db, node),
+		EmailProvider: f.mail,
+		SlackProvider: f.slack,
+	})
+
+	require.NoError(t, db.Exec("INSERT INTO organizations (id, name) VALUES (?, ?)", f.orgID, "Railzway Test").Error)
+	require.NoError(t, db.Create(&customerdomain.Customer{ID: f.customerID, OrgID: f.orgID, Name: "Acme", Email: "ap@acme.test"}).Error)
+	require.NoError(t, db.Create(&meterdomain.Meter{ID: f.meterID, OrgID: f.orgID, Code: "api_calls", Name: "API calls", Aggregation: meterdomain.AggregationSum}).Error)
+	require.NoError(t, db.Create(&subscriptiondomain.Subscription{
+		ID: f.subID, OrgID: f.orgID, CustomerID: f.customerID, Status: subscriptiondomain.SubscriptionStatusActive,
+		CollectionMode: subscriptiondomain.SendInvoice, StartAt: now.AddDate(0, -1, 0),
+	}).Error)
+	require.NoError(t, db.Create(&billingcycledomain.BillingCycle{
+		ID: f.cycleID, OrgID: f.orgID, SubscriptionID: f.subID,
+		PeriodStart: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), PeriodEnd: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		Status: billingcycledomain.BillingCycleStatusOpen,
+	}).Error)
+	return f
+}
+
+func (f *alertFixture) recordUsage(t *testing.T, value float64, recordedAt time.Time) {
+	require.NoError(t, f.db.Create(&usagedomain.UsageEvent{
+		ID: f.node.Generate(), OrgID: f.orgID, CustomerID: f.customerID, SubscriptionID: f.subID, MeterID: f.meterID,
+		MeterCode: "api_calls", Value: value, RecordedAt: recordedAt, Status: usagedomain.UsageStatusEnriched,
+		IdempotencyKey: f.node.Generate().String(), CreatedAt: recordedAt, UpdatedAt: recordedAt,
+	}).Error)
+}
+
+func TestCreateAlertValidation(t *testing.T) {
+	f := setupAlertTest(t)
+
+	_, err := f.svc.Create(f.ctx, alertdomain.CreateRequest{Name: "calls", MeterID: f.meterID.String(), Threshold: 0})
+	assert.ErrorIs(t, err, alertdomain.ErrInvalidThreshold)
+	_, err = f.svc.Create(f.ctx, alertdomain.CreateRequest{Name: "calls", MeterID: f.node.Generate().String(), Threshold: 10})
+	assert.ErrorIs(t, err, alertdomain.ErrInvalidMeter)
+	_, err = f.svc.Create(f.ctx, alertdomain.CreateRequest{Name: "calls", MeterID: f.meterID.String(), Threshold: 10, ApplyTo: "customer"})
+	assert.ErrorIs(t, err, alertdomain.ErrInvalidCustomer)
+	_, err = f.svc.Create(f.ctx, alertdomain.CreateRequest{Name: "calls", MeterID: f.meterID.String(), Threshold: 10, NotifyEmails: []string{"not-an-email"}})
+	assert.ErrorIs(t, err, alertdomain.ErrInvalidNotifyEmail)
+
+	created, err := f.svc.Create(f.ctx, alertdomain.CreateRequest{
+		Name:         "calls",
+		MeterID:      f.meterID.String(),
+		Threshold:    10,
+		ApplyTo:      "customer",
+		CustomerID:   f.customerID.String(),
+		NotifyEmails: []string{"ops@railzway.test", "OPS@railzway.test"},
+	})
+	require.NoError(t, err)
+	assert.True(t, created.Active)
+	assert.Equal(t, []string{"ops@railzway.test"}, created.NotifyEmails)
+
+	inactive := false
+	updated, err := f.svc.Update(f.ctx, created.ID, alertdomain.UpdateRequest{Active: &inactive})
+	require.NoError(t, err)
+	assert.False(t, updated.Active)
+
+	require.NoError(t, f.svc.Delete(f.ctx, created.ID))
+	_, err = f.svc.Get(f.ctx, created.ID)
+	assert.ErrorIs(t, err, alertdomain.ErrAlertNotFound)
+}
+
+func TestEvaluateFiresOncePerCycle(t *testing.T) {
+	f := setupAlertTest(t)
+
+	alert, err := f.svc.Create(f.ctx, alertdomain.CreateRequest{
+		Name:         "High API usage",
+		MeterID:      f.meterID.String(),
+		Threshold:    100,
+		NotifyEmails: []string{"ops@railzway.test"},
+		SlackChannel: "#billing-alerts",
+	})
+	require.NoError(t, err)
+
+	f.recordUsage(t, 60, time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC))
+	f.recordUsage(t, 500, time.Date(2026, 2, 20, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, f.svc.Evaluate(context.Background(), 10))
+
+	history, err := f.svc.ListHistory(f.ctx, alertdomain.ListHistoryRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, history, "usage outside the cycle must not count")
+
+	f.recordUsage(t, 45, time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, f.svc.Evaluate(context.Background(), 10))
+	f.recordUsage(t, 200, time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, f.svc.Evaluate(context.Background(), 10))
+
+	history, err = f.svc.ListHistory(f.ctx, alertdomain.ListHistoryRequest{AlertID: alert.ID})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, 105.0, history[0].Value)
+	assert.Equal(t, f.cycleID.String(), history[0].BillingCycleID)
+	assert.ElementsMatch(t, []string{alertdomain.ChannelEvent, alertdomain.ChannelEmail, alertdomain.ChannelSlack}, history[0].Channels)
+
+	assert.Len(t, f.mail.sent, 1)
+	assert.Equal(t, []string{"#billing-alerts"}, f.slack.channels)
+
+	var published int64
+	require.NoError(t, f.db.Raw("SELECT COUNT(*) FROM billing_events WHERE event_type = ?", events.EventAlertTriggered).Scan(&published).Error)
+	assert.Equal(t, int64(1), published)
+}
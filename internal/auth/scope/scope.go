@@ -29,6 +29,7 @@ const (
 	ScopeInvoiceGenerate Scope = "invoice:generate"
 	ScopeInvoiceFinalize Scope = "invoice:finalize"
 	ScopeInvoiceVoid     Scope = "invoice:void"
+	ScopeInvoiceMarkPaid Scope = "invoice:mark_paid"
 
 	ScopeAPIKeyView   Scope = "api_key:view"
 	ScopeAPIKeyCreate Scope = "api_key:create"
@@ -37,8 +38,9 @@ const (
 
 	ScopeAuditLogView Scope = "audit_log:view"
 
-	ScopeUsageIngest Scope = "usage:ingest"
-	ScopeUsageWrite  Scope = "usage:write"
+	ScopeUsageIngest  Scope = "usage:ingest"
+	ScopeUsageWrite   Scope = "usage:write"
+	ScopeUsageCorrect Scope = "usage:correct"
 
 	// New CRUD Scopes
 	ScopeProductView   Scope = "product:view"
@@ -87,6 +89,7 @@ var authzScopeMap = map[authzKey]Scope{
 	{normalize(authorization.ObjectInvoice), normalize(authorization.ActionInvoiceGenerate)}: ScopeInvoiceGenerate,
 	{normalize(authorization.ObjectInvoice), normalize(authorization.ActionInvoiceFinalize)}: ScopeInvoiceFinalize,
 	{normalize(authorization.ObjectInvoice), normalize(authorization.ActionInvoiceVoid)}:     ScopeInvoiceVoid,
+	{normalize(authorization.ObjectInvoice), normalize(authorization.ActionInvoiceMarkPaid)}: ScopeInvoiceMarkPaid,
 
 	{normalize(authorization.ObjectAPIKey), normalize(authorization.ActionAPIKeyView)}:   ScopeAPIKeyView,
 	{normalize(authorization.ObjectAPIKey), normalize(authorization.ActionAPIKeyCreate)}: ScopeAPIKeyCreate,
@@ -95,6 +98,8 @@ var authzScopeMap = map[authzKey]Scope{
 
 	{normalize(authorization.ObjectAuditLog), normalize(authorization.ActionAuditLogView)}: ScopeAuditLogView,
 
+	{normalize(authorization.ObjectUsage), normalize(authorization.ActionUsageCorrect)}: ScopeUsageCorrect,
+
 	// New Mappings
 	{normalize(authorization.ObjectProduct), normalize(authorization.ActionProductView)}:   ScopeProductView,
 	{normalize(authorization.ObjectProduct), normalize(authorization.ActionProductCreate)}: ScopeProductCreate,
@@ -135,6 +140,7 @@ var allScopes = []Scope{
 	ScopeInvoiceGenerate,
 	ScopeInvoiceFinalize,
 	ScopeInvoiceVoid,
+	ScopeInvoiceMarkPaid,
 	ScopeAPIKeyView,
 	ScopeAPIKeyCreate,
 	ScopeAPIKeyRotate,
@@ -142,6 +148,7 @@ var allScopes = []Scope{
 	ScopeAuditLogView,
 	ScopeUsageIngest,
 	ScopeUsageWrite,
+	ScopeUsageCorrect,
 	ScopeProductView,
 	ScopeProductCreate,
 	ScopeProductUpdate,
@@ -53,6 +53,7 @@ const (
 	ActionInvoiceGenerate = "invoice.generate"
 	ActionInvoiceFinalize = "invoice.finalize"
 	ActionInvoiceVoid     = "invoice.void"
+	ActionInvoiceMarkPaid = "invoice.mark_paid"
 
 	ActionBillingDashboardView  = "billing_dashboard.view"
 	ActionBillingOperationsView = "billing_operations.view"
@@ -113,7 +114,8 @@ const (
 	ActionInvoiceUpdate = "invoice.update"
 	ActionInvoiceDelete = "invoice.delete"
 
-	ActionUsageIngest = "usage.ingest"
+	ActionUsageIngest  = "usage.ingest"
+	ActionUsageCorrect = "usage.correct"
 )
 
 type Params struct {
@@ -347,7 +349,7 @@ func actorSubject(actorType string, actorID *string) string {
 
 func shouldAuditGrant(action string) bool {
 	switch action {
-	case ActionAPIKeyRotate, ActionAPIKeyRevoke, ActionInvoiceVoid:
+	case ActionAPIKeyRotate, ActionAPIKeyRevoke, ActionInvoiceVoid, ActionInvoiceMarkPaid, ActionUsageCorrect:
 		return true
 	default:
 		return false
@@ -365,6 +367,7 @@ func seedPolicies(enforcer *casbin.SyncedEnforcer) error {
 		{"role:admin", ObjectSubscription, ActionSubscriptionPause},
 		{"role:admin", ObjectSubscription, ActionSubscriptionResume},
 		{"role:admin", ObjectInvoice, ActionInvoiceFinalize},
+		{"role:admin", ObjectInvoice, ActionInvoiceMarkPaid},
 		{"role:admin", ObjectBillingDashboard, ActionBillingDashboardView},
 		{"role:admin", ObjectBillingOperations, ActionBillingOperationsView},
 		{"role:admin", ObjectBillingOperations, ActionBillingOperationsAct},
@@ -374,6 +377,7 @@ func seedPolicies(enforcer *casbin.SyncedEnforcer) error {
 		{"role:admin", ObjectAPIKey, ActionAPIKeyView},
 		{"role:admin", ObjectAuditLog, ActionAuditLogView},
 		{"role:admin", ObjectPaymentProvider, ActionPaymentProviderManage},
+		{"role:admin", ObjectUsage, ActionUsageCorrect},
 
 		// Owner permissions
 		{"role:owner", ObjectSubscription, ActionSubscriptionActivate},
@@ -382,6 +386,7 @@ func seedPolicies(enforcer *casbin.SyncedEnforcer) error {
 		{"role:owner", ObjectSubscription, ActionSubscriptionCancel},
 		{"role:owner", ObjectInvoice, ActionInvoiceFinalize},
 		{"role:owner", ObjectInvoice, ActionInvoiceVoid},
+		{"role:owner", ObjectInvoice, ActionInvoiceMarkPaid},
 		{"role:owner", ObjectBillingDashboard, ActionBillingDashboardView},
 		{"role:owner", ObjectBillingOperations, ActionBillingOperationsView},
 		{"role:owner", ObjectBillingOperations, ActionBillingOperationsAct},
@@ -392,6 +397,7 @@ func seedPolicies(enforcer *casbin.SyncedEnforcer) error {
 		{"role:owner", ObjectAPIKey, ActionAPIKeyRevoke},
 		{"role:owner", ObjectAuditLog, ActionAuditLogView},
 		{"role:owner", ObjectPaymentProvider, ActionPaymentProviderManage},
+		{"role:owner", ObjectUsage, ActionUsageCorrect},
 
 		// FinOps permissions
 		{"role:finops", ObjectBillingOperations, ActionBillingOperationsView},
@@ -399,6 +405,7 @@ func seedPolicies(enforcer *casbin.SyncedEnforcer) error {
 		{"role:finops", ObjectBillingDashboard, ActionBillingDashboardView},
 		{"role:finops", ObjectBillingOverview, ActionBillingOverviewView},
 		{"role:finops", ObjectInvoice, "view"},
+		{"role:finops", ObjectInvoice, ActionInvoiceMarkPaid},
 
 		// System permissions (for automated processes and API keys)
 		{"role:system", ObjectSubscription, ActionSubscriptionEnd},
@@ -408,6 +415,8 @@ func seedPolicies(enforcer *casbin.SyncedEnforcer) error {
 		{"role:system", ObjectBillingCycle, ActionBillingCycleClose},
 		{"role:system", ObjectInvoice, ActionInvoiceGenerate},
 		{"role:system", ObjectInvoice, ActionInvoiceFinalize},
+		{"role:system", ObjectInvoice, ActionInvoiceVoid},
+		{"role:system", ObjectInvoice, ActionInvoiceMarkPaid},
 
 		// System CRUD permissions for API operations
 		{"role:system", ObjectCustomer, ActionCustomerView},
@@ -456,6 +465,7 @@ func seedPolicies(enforcer *casbin.SyncedEnforcer) error {
 		{"role:system", ObjectInvoice, ActionInvoiceDelete},
 
 		{"role:system", ObjectUsage, ActionUsageIngest},
+		{"role:system", ObjectUsage, ActionUsageCorrect},
 	}
 
 	for _, policy := range policies {
@@ -0,0 +1,277 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/gorm"
+)
+
+// CycleUnit is the calendar unit a billing cycle advances by.
+type CycleUnit string
+
+const (
+	CycleUnitDay   CycleUnit = "day"
+	CycleUnitWeek  CycleUnit = "week"
+	CycleUnitMonth CycleUnit = "month"
+	CycleUnitYear  CycleUnit = "year"
+)
+
+// CycleSpec describes a billing cycle as Count x Unit, e.g. 3 x month.
+//
+// Specs are persisted on subscriptions as billing_cycle_type. Common specs
+// keep their historical names ("daily", "weekly", "monthly", "quarterly",
+// "yearly"); anything else is stored as "<unit>:<count>", e.g. "month:6".
+type CycleSpec struct {
+	Unit  CycleUnit
+	Count int
+}
+
+// NewCycleSpec builds a spec from a unit and count, defaulting count to 1.
+func NewCycleSpec(unit CycleUnit, count int) (CycleSpec, bool) {
+	if count <= 0 {
+		count = 1
+	}
+	switch unit {
+	case CycleUnitDay, CycleUnitWeek, CycleUnitMonth, CycleUnitYear:
+		return CycleSpec{Unit: unit, Count: count}, true
+	default:
+		return CycleSpec{}, false
+	}
+}
+
+// ParseCycleType parses a stored or user supplied billing cycle type.
+// Matching is case-insensitive.
+func ParseCycleType(value string) (CycleSpec, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	switch normalized {
+	case "daily":
+		return CycleSpec{Unit: CycleUnitDay, Count: 1}, true
+	case "weekly":
+		return CycleSpec{Unit: CycleUnitWeek, Count: 1}, true
+	case "monthly":
+		return CycleSpec{Unit: CycleUnitMonth, Count: 1}, true
+	case "quarterly":
+		return CycleSpec{Unit: CycleUnitMonth, Count: 3}, true
+	case "yearly", "annual", "annually":
+		return CycleSpec{Unit: CycleUnitYear, Count: 1}, true
+	}
+
+	unit, rawCount, found := strings.Cut(normalized, ":")
+	if !found {
+		return CycleSpec{}, false
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(rawCount))
+	if err != nil || count <= 0 {
+		return CycleSpec{}, false
+	}
+	return NewCycleSpec(CycleUnit(strings.TrimSpace(unit)), count)
+}
+
+// String returns the canonical billing_cycle_type for the spec.
+func (c CycleSpec) String() string {
+	switch {
+	case c.Count == 1 && c.Unit == CycleUnitDay:
+		return "daily"
+	case c.Count == 1 && c.Unit == CycleUnitWeek:
+		return "weekly"
+	case c.Count == 1 && c.Unit == CycleUnitMonth:
+		return "monthly"
+	case c.Count == 3 && c.Unit == CycleUnitMonth:
+		return "quarterly"
+	case c.Count == 1 && c.Unit == CycleUnitYear:
+		return "yearly"
+	}
+	return fmt.Sprintf("%s:%d", c.Unit, c.Count)
+}
+
+// Boundary returns the end of the n-th cycle counted from anchor.
+//
+// Month based cycles are computed from the anchor rather than chained from
+// the previous boundary, so an anchor on the 31st clamps to the last day of
+// shorter months without drifting: Jan 31 -> Feb 28 -> Mar 31.
+func (c CycleSpec) Boundary(anchor time.Time, n int) time.Time {
+	switch c.Unit {
+	case CycleUnitDay:
+		return anchor.AddDate(0, 0, n*c.Count)
+	case CycleUnitWeek:
+		return anchor.AddDate(0, 0, 7*n*c.Count)
+	case CycleUnitMonth:
+		return addMonthsClamped(anchor, n*c.Count)
+	case CycleUnitYear:
+		return addMonthsClamped(anchor, 12*n*c.Count)
+	}
+	return anchor
+}
+
+// NextPeriodEnd returns the first cycle boundary after start for cycles
+// anchored at anchor.
+func (c CycleSpec) NextPeriodEnd(anchor, start time.Time) time.Time {
+	if c.Count <= 0 {
+		return start
+	}
+
+	n := c.estimateCycles(anchor, start)
+	if n < 1 {
+		n = 1
+	}
+	for n > 1 && c.Boundary(anchor, n-1).After(start) {
+		n--
+	}
+	for !c.Boundary(anchor, n).After(start) {
+		n++
+	}
+	return c.Boundary(anchor, n)
+}
+
+// NominalPeriod returns the boundaries of the cycle anchored at anchor that
+// contains at. A cycle shortened by a mid-cycle plan change keeps its nominal
+// period, which is what its proration is measured against.
+func (c CycleSpec) NominalPeriod(anchor, at time.Time) (time.Time, time.Time) {
+	if c.Count <= 0 || !at.After(anchor) {
+		return anchor, c.Boundary(anchor, 1)
+	}
+
+	n := c.estimateCycles(anchor, at)
+	for n > 0 && c.Boundary(anchor, n).After(at) {
+		n--
+	}
+	for !c.Boundary(anchor, n+1).After(at) {
+		n++
+	}
+	return c.Boundary(anchor, n), c.Boundary(anchor, n+1)
+}
+
+// estimateCycles approximates how many whole cycles separate anchor and start
+// so NextPeriodEnd only needs a couple of corrective steps.
+func (c CycleSpec) estimateCycles(anchor, start time.Time) int {
+	if !start.After(anchor) {
+		return 0
+	}
+	switch c.Unit {
+	case CycleUnitDay, CycleUnitWeek:
+		days := int(start.Sub(anchor).Hours() / 24)
+		step := c.Count
+		if c.Unit == CycleUnitWeek {
+			step *= 7
+		}
+		return days / step
+	case CycleUnitMonth, CycleUnitYear:
+		months := (start.Year()-anchor.Year())*12 + int(start.Month()) - int(anchor.Month())
+		step := c.Count
+		if c.Unit == CycleUnitYear {
+			step *= 12
+		}
+		return months / step
+	}
+	return 0
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	hour, minute, sec := t.Clock()
+	first := time.Date(year, month+time.Month(months), 1, hour, minute, sec, t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, hour, minute, sec, t.Nanosecond(), t.Location())
+}
+
+// SupportsAnchorDay reports whether cycles of this spec can be pinned to a
+// day of the month.
+func (c CycleSpec) SupportsAnchorDay() bool {
+	return c.Unit == CycleUnitMonth || c.Unit == CycleUnitYear
+}
+
+// ValidAnchorDay reports whether day can be used as a billing anchor day.
+// Days past the end of a short month clamp to its last day.
+func ValidAnchorDay(day int) bool {
+	return day >= 1 && day <= 31
+}
+
+// NextAnchoredPeriodEnd returns the end of the cycle starting at start when
+// cycles are pinned to local midnight of day in loc.
+//
+// A start that already sits on an anchor boundary spans a full cycle. Any
+// other start opens a partial period that ends at the next anchor boundary,
+// which rating prorates against the full cycle.
+func (c CycleSpec) NextAnchoredPeriodEnd(start time.Time, day int, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := start.In(loc)
+	year, month, _ := local.Date()
+
+	current := anchoredDate(year, month, day, loc)
+	if current.Equal(start) {
+		return anchoredDate(year, month+time.Month(c.monthStep()), day, loc)
+	}
+	if current.After(start) {
+		return current
+	}
+	return anchoredDate(year, month+1, day, loc)
+}
+
+// AnchoredPeriodStart returns the nominal start of the full anchored cycle
+// that ends at end. It is used to size the proration of a partial period.
+func (c CycleSpec) AnchoredPeriodStart(end time.Time, day int, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	year, month, _ := end.In(loc).Date()
+	return anchoredDate(year, month-time.Month(c.monthStep()), day, loc)
+}
+
+func (c CycleSpec) monthStep() int {
+	if c.Unit == CycleUnitYear {
+		return 12 * c.Count
+	}
+	return c.Count
+}
+
+// anchoredDate returns local midnight of day in the given month, clamped to
+// the last day of that month.
+func anchoredDate(year int, month time.Month, day int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, loc)
+}
+
+// BillingLocation resolves an organization billing timezone, falling back to
+// UTC when it is empty or unknown.
+func BillingLocation(name string) *time.Location {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// LoadBillingLocation reads the organization's billing timezone through db,
+// so callers inside a transaction see the same preferences they lock with.
+func LoadBillingLocation(ctx context.Context, db *gorm.DB, orgID snowflake.ID) (*time.Location, error) {
+	var timezone string
+	err := db.WithContext(ctx).Raw(
+		`SELECT timezone
+		 FROM organization_billing_preferences
+		 WHERE org_id = ?
+		 LIMIT 1`,
+		orgID,
+	).Scan(&timezone).Error
+	if err != nil {
+		return nil, err
+	}
+	return BillingLocation(timezone), nil
+}
@@ -2,7 +2,6 @@ package service
 
 import (
 	"context"
-	"strings"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
@@ -68,6 +67,7 @@ type activeSubscription struct {
 	Status           subscriptiondomain.SubscriptionStatus
 	ActivatedAt      *time.Time
 	BillingCycleType string
+	BillingAnchorDay *int16
 }
 
 type billingCycleRow struct {
@@ -86,11 +86,12 @@ type billingCycleRow struct {
 func (s *Service) listActiveSubscriptions(ctx context.Context) ([]activeSubscription, error) {
 	var subscriptions []activeSubscription
 	err := s.db.WithContext(ctx).Raw(
-		`SELECT id, org_id, status, activated_at, billing_cycle_type
+		`SELECT id, org_id, status, activated_at, billing_cycle_type, billing_anchor_day
 		 FROM subscriptions
-		 WHERE status = ?
+		 WHERE status IN (?, ?)
 		 ORDER BY id`,
 		subscriptiondomain.SubscriptionStatusActive,
+		subscriptiondomain.SubscriptionStatusPastDue,
 	).Scan(&subscriptions).Error
 	if err != nil {
 		return nil, err
@@ -104,7 +105,7 @@ func (s *Service) ensureSubscriptionCycles(ctx context.Context, subscription act
 		if err != nil {
 			return err
 		}
-		if locked == nil || locked.Status != subscriptiondomain.SubscriptionStatusActive {
+		if locked == nil || (locked.Status != subscriptiondomain.SubscriptionStatusActive && locked.Status != subscriptiondomain.SubscriptionStatusPastDue) {
 			return nil
 		}
 		if locked.ActivatedAt == nil {
@@ -143,7 +144,15 @@ func (s *Service) ensureSubscriptionCycles(ctx context.Context, subscription act
 			return nil
 		}
 
-		periodEnd, err := nextPeriodEnd(periodStart, locked.BillingCycleType)
+		var loc *time.Location
+		if locked.BillingAnchorDay != nil {
+			loc, err = billingcycledomain.LoadBillingLocation(ctx, tx, locked.OrgID)
+			if err != nil {
+				return err
+			}
+		}
+
+		periodEnd, err := nextPeriodEnd(*locked.ActivatedAt, periodStart, locked.BillingCycleType, locked.BillingAnchorDay, loc)
 		if err != nil {
 			return err
 		}
@@ -158,7 +167,7 @@ func (s *Service) ensureSubscriptionCycles(ctx context.Context, subscription act
 func (s *Service) lockSubscription(ctx context.Context, tx *gorm.DB, orgID, subscriptionID snowflake.ID) (*activeSubscription, error) {
 	var subscription activeSubscription
 	err := tx.WithContext(ctx).Raw(
-		`SELECT id, org_id, status, activated_at, billing_cycle_type
+		`SELECT id, org_id, status, activated_at, billing_cycle_type, billing_anchor_day
 		 FROM subscriptions
 		 WHERE org_id = ? AND id = ?
 		 FOR UPDATE`,
@@ -303,15 +312,16 @@ func (s *Service) insertCycle(ctx context.Context, tx *gorm.DB, orgID, subscript
 	).Error
 }
 
-func nextPeriodEnd(start time.Time, cycleType string) (time.Time, error) {
-	switch strings.ToLower(strings.TrimSpace(cycleType)) {
-	case "monthly":
-		return start.AddDate(0, 1, 0), nil
-	case "weekly":
-		return start.AddDate(0, 0, 7), nil
-	case "daily":
-		return start.AddDate(0, 0, 1), nil
-	default:
+func nextPeriodEnd(activatedAt, start time.Time, cycleType string, anchorDay *int16, loc *time.Location) (time.Time, error) {
+	spec, ok := billingcycledomain.ParseCycleType(cycleType)
+	if !ok {
 		return time.Time{}, subscriptiondomain.ErrInvalidBillingCycleType
 	}
+	if anchorDay != nil {
+		if !spec.SupportsAnchorDay() || !billingcycledomain.ValidAnchorDay(int(*anchorDay)) {
+			return time.Time{}, subscriptiondomain.ErrInvalidBillingAnchorDay
+		}
+		return spec.NextAnchoredPeriodEnd(start, int(*anchorDay), loc), nil
+	}
+	return spec.NextPeriodEnd(activatedAt, start), nil
 }
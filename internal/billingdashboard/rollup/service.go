@@ -197,7 +197,8 @@ func (s *Service) applyCustomerBalanceDelta(
 			FROM ledger_entries le
 			JOIN ledger_entry_lines l ON l.ledger_entry_id = le.id
 			JOIN ledger_accounts a ON a.id = l.account_id
-			JOIN billing_cycles bc ON bc.id = le.source_id
+			LEFT JOIN billing_adjustments ba ON ba.id = le.source_id AND le.source_type = ?
+			JOIN billing_cycles bc ON bc.id = COALESCE(ba.billing_cycle_id, le.source_id)
 			JOIN subscriptions s ON s.id = bc.subscription_id
 			WHERE le.id = ?
 			  AND le.source_type IN (?, ?)
@@ -237,6 +238,40 @@ func (s *Service) applyCustomerBalanceDelta(
 			  AND le.source_type IN (?, ?, ?)
 			  AND a.code = ?
 			GROUP BY le.org_id, pd.customer_id, le.currency
+
+			UNION ALL
+
+			-- Credit notes (invoice corrections)
+			SELECT
+				le.org_id,
+				cn.customer_id,
+				le.currency,
+				SUM(CASE l.direction WHEN 'debit' THEN l.amount ELSE -l.amount END) AS delta
+			FROM ledger_entries le
+			JOIN ledger_entry_lines l ON l.ledger_entry_id = le.id
+			JOIN ledger_accounts a ON a.id = l.account_id
+			JOIN credit_notes cn ON cn.id = le.source_id
+			WHERE le.id = ?
+			  AND le.source_type = ?
+			  AND a.code = ?
+			GROUP BY le.org_id, cn.customer_id, le.currency
+
+			UNION ALL
+
+			-- Credit applied to invoices
+			SELECT
+				le.org_id,
+				i.customer_id,
+				le.currency,
+				SUM(CASE l.direction WHEN 'debit' THEN l.amount ELSE -l.amount END) AS delta
+			FROM ledger_entries le
+			JOIN ledger_entry_lines l ON l.ledger_entry_id = le.id
+			JOIN ledger_accounts a ON a.id = l.account_id
+			JOIN invoices i ON i.id = le.source_id
+			WHERE le.id = ?
+			  AND le.source_type = ?
+			  AND a.code = ?
+			GROUP BY le.org_id, i.customer_id, le.currency
 		)
 
 		SELECT org_id, customer_id, currency, SUM(delta) AS delta
@@ -244,6 +279,7 @@ func (s *Service) applyCustomerBalanceDelta(
 		GROUP BY org_id, customer_id, currency
 		`,
 		// billing / adjustment
+		ledgerdomain.SourceTypeAdjustment,
 		entryID,
 		ledgerdomain.SourceTypeBillingCycle,
 		ledgerdomain.SourceTypeAdjustment,
@@ -262,6 +298,16 @@ func (s *Service) applyCustomerBalanceDelta(
 		ledgerdomain.SourceTypeDisputeLoss,
 		ledgerdomain.SourceTypeDisputeWin,
 		ledgerdomain.AccountCodeAccountsReceivable,
+
+		// credit notes
+		entryID,
+		ledgerdomain.SourceTypeCreditNote,
+		ledgerdomain.AccountCodeAccountsReceivable,
+
+		// credit use
+		entryID,
+		ledgerdomain.SourceTypeCreditUse,
+		ledgerdomain.AccountCodeAccountsReceivable,
 	).Scan(&rows).Error; err != nil {
 		return err
 	}
@@ -313,23 +359,26 @@ func (s *Service) applyRevenueDelta(
 		`
 		SELECT
 			le.org_id AS org_id,
-			le.source_id AS billing_cycle_id,
+			bc.id AS billing_cycle_id,
 			bc.period_start AS period_start,
 			bc.status AS status,
 			SUM(CASE l.direction WHEN 'credit' THEN l.amount ELSE -l.amount END) AS delta
 		FROM ledger_entries le
 		JOIN ledger_entry_lines l ON l.ledger_entry_id = le.id
 		JOIN ledger_accounts a ON a.id = l.account_id
-		JOIN billing_cycles bc ON bc.id = le.source_id
+		-- adjustment entries are sourced from the adjustment, not the cycle
+		LEFT JOIN billing_adjustments ba ON ba.id = le.source_id AND le.source_type = ?
+		JOIN billing_cycles bc ON bc.id = COALESCE(ba.billing_cycle_id, le.source_id)
 		WHERE le.id = ?
 		  AND le.source_type IN (?, ?)
 		  AND a.code IN (?, ?)
 		GROUP BY
 			le.org_id,
-			le.source_id,
+			bc.id,
 			bc.period_start,
 			bc.status
 		`,
+		ledgerdomain.SourceTypeAdjustment,
 		entryID,
 		ledgerdomain.SourceTypeBillingCycle,
 		ledgerdomain.SourceTypeAdjustment,
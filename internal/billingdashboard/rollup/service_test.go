@@ -6,8 +6,10 @@ import (
 	"time"
 
 	"github.com/bwmarrin/snowflake"
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
 	"github.com/smallbiznis/railzway/internal/billingcycle/domain"
 	billingevent "github.com/smallbiznis/railzway/internal/billingevent/domain"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
 	customerdomain "github.com/smallbiznis/railzway/internal/customer/domain"
 	"github.com/smallbiznis/railzway/internal/events"
 	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
@@ -152,6 +154,8 @@ func setupRollupDB(t *testing.T) (*gorm.DB, *snowflake.Node) {
 		&paymentdomain.EventRecord{},
 		&customerBalance{},
 		&billingCycleStats{},
+		&creditnotedomain.CreditNote{},
+		&adjustmentdomain.Adjustment{},
 	); err != nil {
 		t.Fatalf("auto migrate: %v", err)
 	}
@@ -12,7 +12,7 @@ type InboxItem struct {
 	EntityType   string     `json:"entity_type"` // "invoice" | "customer"
 	EntityID     string     `json:"entity_id"`
 	EntityName   string     `json:"entity_name"`   // invoice_number or customer_name
-	RiskCategory string     `json:"risk_category"` // "overdue" | "failed_payment" | "high_exposure"
+	RiskCategory string     `json:"risk_category"` // "overdue" | "dunning" | "failed_payment" | "high_exposure"
 	RiskScore    int        `json:"risk_score"`    // For sorting
 	AmountDue    int64      `json:"amount_due"`
 	Currency     string     `json:"currency"`
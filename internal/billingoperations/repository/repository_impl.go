@@ -1038,14 +1038,14 @@ func (r *RepositoryImpl) ListInboxItems(
 				'invoice' AS entity_type,
 				i.id::text AS entity_id,
 				COALESCE(i.invoice_number::text, i.id::text) AS entity_name,
-				'overdue' AS risk_category,
+				CASE WHEN dc.id IS NOT NULL THEN 'dunning' ELSE 'overdue' END AS risk_category,
 				GREATEST(i.subtotal_amount - COALESCE(s.settled_amount, 0), 0) AS amount_due,
 				i.due_at,
-				EXTRACT(EPOCH FROM (? - i.due_at)) / 86400 AS days_overdue,
-				NULL::timestamp AS last_attempt,
+				COALESCE(GREATEST(EXTRACT(EPOCH FROM (? - i.due_at)) / 86400, 0), 0) AS days_overdue,
+				dc.last_action_at::timestamp AS last_attempt,
 				ipt.token_hash,
-				-- Risk score: higher = more urgent
-				(EXTRACT(EPOCH FROM (? - i.due_at)) / 86400 * 10 + i.subtotal_amount / 10000)::int AS risk_score
+				-- Risk score: higher = more urgent; each dunning step escalates further
+				(COALESCE(GREATEST(EXTRACT(EPOCH FROM (? - i.due_at)) / 86400, 0), 0) * 10 + i.subtotal_amount / 10000 + COALESCE(dc.step, 0) * 25)::int AS risk_score
 			FROM invoices i
 			LEFT JOIN (
 				SELECT
@@ -1059,6 +1059,7 @@ func (r *RepositoryImpl) ListInboxItems(
 				GROUP BY 1
 			) s ON s.invoice_id_text = i.id::text
 			LEFT JOIN invoice_public_tokens ipt ON ipt.invoice_id = i.id AND ipt.revoked_at IS NULL
+			LEFT JOIN dunning_cases dc ON dc.invoice_id = i.id AND dc.status = 'open'
 			LEFT JOIN billing_operation_assignments boa 
 				ON boa.org_id = ? AND boa.entity_type = 'invoice' AND boa.entity_id = i.id 
 				AND boa.status IN ('assigned', 'in_progress')
@@ -1067,8 +1068,8 @@ func (r *RepositoryImpl) ListInboxItems(
 				AND i.voided_at IS NULL
 				AND i.paid_at IS NULL
 				AND i.currency = ?
-				AND i.due_at IS NOT NULL
-				AND i.due_at < ?
+				-- Invoices under dunning surface even before their due date
+				AND ((i.due_at IS NOT NULL AND i.due_at < ?) OR dc.id IS NOT NULL)
 				AND GREATEST(i.subtotal_amount - COALESCE(s.settled_amount, 0), 0) > 0
 				AND boa.id IS NULL  -- No active assignment
 		),
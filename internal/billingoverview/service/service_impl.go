@@ -438,7 +438,7 @@ func (s *Service) listMRRSeries(
 			COALESCE(
 				SUM(
 					ROUND(
-						COALESCE(si.quantity, 1) * COALESCE(pa.unit_amount_cents, 0) *
+						COALESCE(si.quantity, 1) * COALESCE(pa.unit_amount_decimal, pa.unit_amount_cents, 0) *
 						CASE p.billing_interval
 							WHEN 'MONTH' THEN 1.0 / NULLIF(p.billing_interval_count, 0)
 							WHEN 'YEAR' THEN 1.0 / (12.0 * NULLIF(p.billing_interval_count, 0))
@@ -515,7 +515,7 @@ func (s *Service) listMRRSnapshot(
 			COALESCE(
 				SUM(
 					ROUND(
-						COALESCE(si.quantity, 1) * COALESCE(pa.unit_amount_cents, 0) *
+						COALESCE(si.quantity, 1) * COALESCE(pa.unit_amount_decimal, pa.unit_amount_cents, 0) *
 						CASE p.billing_interval
 							WHEN 'MONTH' THEN 1.0 / NULLIF(p.billing_interval_count, 0)
 							WHEN 'YEAR' THEN 1.0 / (12.0 * NULLIF(p.billing_interval_count, 0))
@@ -18,8 +18,8 @@ const (
 type UsageResolverCache interface {
 	GetMeter(orgID, meterCode string) (*meterdomain.Response, bool)
 	SetMeter(orgID, meterCode string, meter *meterdomain.Response)
-	GetActiveSubscription(orgID, customerID string) (subscriptiondomain.Subscription, bool)
-	SetActiveSubscription(orgID, customerID string, subscription subscriptiondomain.Subscription)
+	GetActiveSubscription(orgID, customerID, meterCode, subscriptionID string) (subscriptiondomain.Subscription, bool)
+	SetActiveSubscription(orgID, customerID, meterCode, subscriptionID string, subscription subscriptiondomain.Subscription)
 	GetSubscriptionItem(subscriptionID, meterID string) (subscriptiondomain.SubscriptionItem, bool)
 	SetSubscriptionItem(subscriptionID, meterID string, item subscriptiondomain.SubscriptionItem)
 }
@@ -56,15 +56,17 @@ func (c *usageResolverCache) SetMeter(orgID, meterCode string, meter *meterdomai
 	c.meters.Set(cacheKey(orgID, meterCode), meter, c.meterTTL)
 }
 
-func (c *usageResolverCache) GetActiveSubscription(orgID, customerID string) (subscriptiondomain.Subscription, bool) {
-	return c.subscriptions.Get(cacheKey(orgID, customerID))
+// Customers may hold several active subscriptions, so the routed subscription
+// is cached per meter and explicit subscription.
+func (c *usageResolverCache) GetActiveSubscription(orgID, customerID, meterCode, subscriptionID string) (subscriptiondomain.Subscription, bool) {
+	return c.subscriptions.Get(subscriptionRouteKey(orgID, customerID, meterCode, subscriptionID))
 }
 
-func (c *usageResolverCache) SetActiveSubscription(orgID, customerID string, subscription subscriptiondomain.Subscription) {
+func (c *usageResolverCache) SetActiveSubscription(orgID, customerID, meterCode, subscriptionID string, subscription subscriptiondomain.Subscription) {
 	if subscription.ID == 0 {
 		return
 	}
-	c.subscriptions.Set(cacheKey(orgID, customerID), subscription, c.subTTL)
+	c.subscriptions.Set(subscriptionRouteKey(orgID, customerID, meterCode, subscriptionID), subscription, c.subTTL)
 }
 
 func (c *usageResolverCache) GetSubscriptionItem(subscriptionID, meterID string) (subscriptiondomain.SubscriptionItem, bool) {
@@ -89,3 +91,7 @@ func cacheKey(parts ...string) string {
 	}
 	return strings.Join(values, "|")
 }
+
+func subscriptionRouteKey(orgID, customerID, meterCode, subscriptionID string) string {
+	return cacheKey(orgID, customerID, "meter:"+meterCode, "subscription:"+subscriptionID)
+}
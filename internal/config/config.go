@@ -54,6 +54,7 @@ type Config struct {
 
 	RateLimit RateLimitConfig
 	Email     EmailConfig
+	Slack     SlackConfig
 	Logger    LoggerConfig
 }
 
@@ -65,6 +66,10 @@ type EmailConfig struct {
 	SMTPFrom     string
 }
 
+type SlackConfig struct {
+	BotToken string
+}
+
 type LoggerConfig struct {
 	Level string
 }
@@ -216,6 +221,10 @@ func Load() Config {
 			SMTPFrom:     getenv("SMTP_FROM", "no-reply@railzway.test"),
 		},
 
+		Slack: SlackConfig{
+			BotToken: getenv("SLACK_BOT_TOKEN", ""),
+		},
+
 		Logger: LoggerConfig{
 			Level: getenv("LOG_LEVEL", "info"),
 		},
@@ -0,0 +1,164 @@
+// Package domain contains persistence models for coupons, promotion codes and discounts.
+package domain
+
+import (
+	"math"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/datatypes"
+)
+
+// DiscountType controls how a coupon reduces an invoice.
+type DiscountType string
+
+const (
+	DiscountTypePercent DiscountType = "percent"
+	DiscountTypeAmount  DiscountType = "amount"
+)
+
+// CouponDuration controls for how many billing cycles a discount applies.
+type CouponDuration string
+
+const (
+	DurationOnce      CouponDuration = "once"
+	DurationRepeating CouponDuration = "repeating"
+	DurationForever   CouponDuration = "forever"
+)
+
+// DiscountStatus represents the lifecycle of an attached coupon.
+type DiscountStatus string
+
+const (
+	DiscountStatusActive  DiscountStatus = "ACTIVE"
+	DiscountStatusEnded   DiscountStatus = "ENDED"
+	DiscountStatusRemoved DiscountStatus = "REMOVED"
+)
+
+// Coupon describes a reusable discount definition.
+type Coupon struct {
+	ID               snowflake.ID      `gorm:"primaryKey"`
+	OrgID            snowflake.ID      `gorm:"not null;index;uniqueIndex:ux_coupons_org_code,priority:1"`
+	Code             string            `gorm:"type:text;not null;uniqueIndex:ux_coupons_org_code,priority:2"`
+	Name             string            `gorm:"type:text;not null"`
+	DiscountType     DiscountType      `gorm:"type:text;not null"`
+	PercentOff       *float64          `gorm:"column:percent_off"`
+	AmountOff        *int64            `gorm:"column:amount_off"`
+	Currency         *string           `gorm:"type:text"`
+	Duration         CouponDuration    `gorm:"type:text;not null"`
+	DurationInCycles *int              `gorm:"column:duration_in_cycles"`
+	MaxRedemptions   *int              `gorm:"column:max_redemptions"`
+	TimesRedeemed    int               `gorm:"not null;default:0"`
+	RedeemBy         *time.Time        `gorm:"column:redeem_by"`
+	Active           bool              `gorm:"not null;default:true"`
+	Metadata         datatypes.JSONMap `gorm:"type:jsonb;not null;default:'{}'"`
+	CreatedAt        time.Time         `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt        time.Time         `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName sets the database table name.
+func (Coupon) TableName() string { return "coupons" }
+
+// DiscountFor returns how much of base the coupon takes off, never more than base.
+func (c Coupon) DiscountFor(base int64) int64 {
+	if base <= 0 {
+		return 0
+	}
+
+	var amount int64
+	switch c.DiscountType {
+	case DiscountTypePercent:
+		if c.PercentOff != nil {
+			amount = int64(math.Round(float64(base) * *c.PercentOff / 100))
+		}
+	case DiscountTypeAmount:
+		if c.AmountOff != nil {
+			amount = *c.AmountOff
+		}
+	}
+
+	if amount > base {
+		return base
+	}
+	if amount < 0 {
+		return 0
+	}
+	return amount
+}
+
+// Redeemable reports whether the coupon can still be attached at the given time.
+func (c Coupon) Redeemable(now time.Time) bool {
+	if !c.Active {
+		return false
+	}
+	if c.RedeemBy != nil && !now.Before(*c.RedeemBy) {
+		return false
+	}
+	if c.MaxRedemptions != nil && c.TimesRedeemed >= *c.MaxRedemptions {
+		return false
+	}
+	return true
+}
+
+// ExhaustedAfter reports whether the discount has no cycles left once it has
+// been applied the given number of times.
+func (c Coupon) ExhaustedAfter(cyclesApplied int) bool {
+	switch c.Duration {
+	case DurationOnce:
+		return cyclesApplied >= 1
+	case DurationRepeating:
+		return c.DurationInCycles == nil || cyclesApplied >= *c.DurationInCycles
+	default:
+		return false
+	}
+}
+
+// PromotionCode is a customer-facing code that redeems a coupon.
+type PromotionCode struct {
+	ID             snowflake.ID `gorm:"primaryKey"`
+	OrgID          snowflake.ID `gorm:"not null;index;uniqueIndex:ux_promotion_codes_org_code,priority:1"`
+	CouponID       snowflake.ID `gorm:"not null;index"`
+	Code           string       `gorm:"type:text;not null;uniqueIndex:ux_promotion_codes_org_code,priority:2"`
+	MaxRedemptions *int         `gorm:"column:max_redemptions"`
+	TimesRedeemed  int          `gorm:"not null;default:0"`
+	ExpiresAt      *time.Time   `gorm:"column:expires_at"`
+	Active         bool         `gorm:"not null;default:true"`
+	CreatedAt      time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt      time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName sets the database table name.
+func (PromotionCode) TableName() string { return "promotion_codes" }
+
+// Redeemable reports whether the promotion code can still be used at the given time.
+func (p PromotionCode) Redeemable(now time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.ExpiresAt != nil && !now.Before(*p.ExpiresAt) {
+		return false
+	}
+	if p.MaxRedemptions != nil && p.TimesRedeemed >= *p.MaxRedemptions {
+		return false
+	}
+	return true
+}
+
+// Discount attaches a coupon to a customer, or to a single subscription of that customer.
+type Discount struct {
+	ID              snowflake.ID   `gorm:"primaryKey"`
+	OrgID           snowflake.ID   `gorm:"not null;index"`
+	CouponID        snowflake.ID   `gorm:"not null;index"`
+	PromotionCodeID *snowflake.ID  `gorm:"index"`
+	CustomerID      snowflake.ID   `gorm:"not null;index"`
+	SubscriptionID  *snowflake.ID  `gorm:"index"`
+	Status          DiscountStatus `gorm:"type:text;not null;default:'ACTIVE'"`
+	CyclesApplied   int            `gorm:"not null;default:0"`
+	StartedAt       time.Time      `gorm:"not null"`
+	EndedAt         *time.Time     `gorm:""`
+	CreatedAt       time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt       time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName sets the database table name.
+func (Discount) TableName() string { return "discounts" }
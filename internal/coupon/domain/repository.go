@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/gorm"
+)
+
+// DiscountFilter narrows discount listings.
+type DiscountFilter struct {
+	CustomerID     *snowflake.ID
+	SubscriptionID *snowflake.ID
+	Status         DiscountStatus
+}
+
+type Repository interface {
+	InsertCoupon(ctx context.Context, db *gorm.DB, coupon *Coupon) error
+	FindCouponByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*Coupon, error)
+	FindCouponByCode(ctx context.Context, db *gorm.DB, orgID snowflake.ID, code string) (*Coupon, error)
+	ListCoupons(ctx context.Context, db *gorm.DB, orgID snowflake.ID) ([]Coupon, error)
+	DeactivateCoupon(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID, now time.Time) error
+	// IncrementCouponRedemptions returns false when the coupon has reached its redemption limit.
+	IncrementCouponRedemptions(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID, now time.Time) (bool, error)
+
+	InsertPromotionCode(ctx context.Context, db *gorm.DB, code *PromotionCode) error
+	FindPromotionCodeByCode(ctx context.Context, db *gorm.DB, orgID snowflake.ID, code string) (*PromotionCode, error)
+	ListPromotionCodes(ctx context.Context, db *gorm.DB, orgID snowflake.ID, couponID *snowflake.ID) ([]PromotionCode, error)
+	// IncrementPromotionCodeRedemptions returns false when the code has reached its redemption limit.
+	IncrementPromotionCodeRedemptions(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID, now time.Time) (bool, error)
+
+	InsertDiscount(ctx context.Context, db *gorm.DB, discount *Discount) error
+	FindDiscountByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*Discount, error)
+	ListDiscounts(ctx context.Context, db *gorm.DB, orgID snowflake.ID, filter DiscountFilter) ([]Discount, error)
+	HasActiveDiscount(ctx context.Context, db *gorm.DB, orgID, couponID, customerID snowflake.ID, subscriptionID *snowflake.ID) (bool, error)
+	EndDiscount(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID, status DiscountStatus, now time.Time) error
+}
@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// CreateCouponRequest defines a coupon. PercentOff is used for percent coupons,
+// AmountOff and Currency for amount coupons. DurationInCycles is required for
+// repeating coupons.
+type CreateCouponRequest struct {
+	Code             string         `json:"code"`
+	Name             string         `json:"name"`
+	DiscountType     string         `json:"discount_type"`
+	PercentOff       *float64       `json:"percent_off,omitempty"`
+	AmountOff        *int64         `json:"amount_off,omitempty"`
+	Currency         string         `json:"currency,omitempty"`
+	Duration         string         `json:"duration"`
+	DurationInCycles *int           `json:"duration_in_cycles,omitempty"`
+	MaxRedemptions   *int           `json:"max_redemptions,omitempty"`
+	RedeemBy         *time.Time     `json:"redeem_by,omitempty"`
+	Metadata         map[string]any `json:"metadata,omitempty"`
+}
+
+type CouponResponse struct {
+	ID               string         `json:"id"`
+	OrgID            string         `json:"organization_id"`
+	Code             string         `json:"code"`
+	Name             string         `json:"name"`
+	DiscountType     string         `json:"discount_type"`
+	PercentOff       *float64       `json:"percent_off,omitempty"`
+	AmountOff        *int64         `json:"amount_off,omitempty"`
+	Currency         *string        `json:"currency,omitempty"`
+	Duration         string         `json:"duration"`
+	DurationInCycles *int           `json:"duration_in_cycles,omitempty"`
+	MaxRedemptions   *int           `json:"max_redemptions,omitempty"`
+	TimesRedeemed    int            `json:"times_redeemed"`
+	RedeemBy         *time.Time     `json:"redeem_by,omitempty"`
+	Active           bool           `json:"active"`
+	Metadata         map[string]any `json:"metadata,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+type CreatePromotionCodeRequest struct {
+	CouponID       string     `json:"coupon_id"`
+	Code           string     `json:"code"`
+	MaxRedemptions *int       `json:"max_redemptions,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+type ListPromotionCodesRequest struct {
+	CouponID string `form:"coupon_id"`
+}
+
+type PromotionCodeResponse struct {
+	ID             string     `json:"id"`
+	OrgID          string     `json:"organization_id"`
+	CouponID       string     `json:"coupon_id"`
+	Code           string     `json:"code"`
+	MaxRedemptions *int       `json:"max_redemptions,omitempty"`
+	TimesRedeemed  int        `json:"times_redeemed"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	Active         bool       `json:"active"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ApplyDiscountRequest attaches a coupon to a customer, or to one of its
+// subscriptions when SubscriptionID is set. Exactly one of CouponID and
+// PromotionCode must be provided.
+type ApplyDiscountRequest struct {
+	CustomerID     string `json:"customer_id"`
+	SubscriptionID string `json:"subscription_id,omitempty"`
+	CouponID       string `json:"coupon_id,omitempty"`
+	PromotionCode  string `json:"promotion_code,omitempty"`
+}
+
+type ListDiscountsRequest struct {
+	CustomerID     string `form:"customer_id"`
+	SubscriptionID string `form:"subscription_id"`
+	Status         string `form:"status"`
+}
+
+type DiscountResponse struct {
+	ID              string     `json:"id"`
+	OrgID           string     `json:"organization_id"`
+	CouponID        string     `json:"coupon_id"`
+	PromotionCodeID *string    `json:"promotion_code_id,omitempty"`
+	CustomerID      string     `json:"customer_id"`
+	SubscriptionID  *string    `json:"subscription_id,omitempty"`
+	Status          string     `json:"status"`
+	CyclesApplied   int        `json:"cycles_applied"`
+	StartedAt       time.Time  `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type Service interface {
+	CreateCoupon(ctx context.Context, req CreateCouponRequest) (*CouponResponse, error)
+	ListCoupons(ctx context.Context) ([]CouponResponse, error)
+	GetCoupon(ctx context.Context, id string) (*CouponResponse, error)
+	ArchiveCoupon(ctx context.Context, id string) (*CouponResponse, error)
+
+	CreatePromotionCode(ctx context.Context, req CreatePromotionCodeRequest) (*PromotionCodeResponse, error)
+	ListPromotionCodes(ctx context.Context, req ListPromotionCodesRequest) ([]PromotionCodeResponse, error)
+
+	ApplyDiscount(ctx context.Context, req ApplyDiscountRequest) (*DiscountResponse, error)
+	ListDiscounts(ctx context.Context, req ListDiscountsRequest) ([]DiscountResponse, error)
+	RemoveDiscount(ctx context.Context, id string) error
+}
+
+// NormalizeCode upper-cases and trims coupon and promotion codes so lookups
+// are case-insensitive.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+var (
+	ErrInvalidOrganization     = errors.New("invalid_organization")
+	ErrInvalidID               = errors.New("invalid_id")
+	ErrInvalidCode             = errors.New("invalid_code")
+	ErrInvalidName             = errors.New("invalid_name")
+	ErrInvalidDiscountType     = errors.New("invalid_discount_type")
+	ErrInvalidPercentOff       = errors.New("invalid_percent_off")
+	ErrInvalidAmountOff        = errors.New("invalid_amount_off")
+	ErrInvalidCurrency         = errors.New("invalid_currency")
+	ErrInvalidDuration         = errors.New("invalid_duration")
+	ErrInvalidDurationInCycles = errors.New("invalid_duration_in_cycles")
+	ErrInvalidMaxRedemptions   = errors.New("invalid_max_redemptions")
+	ErrInvalidRedeemBy         = errors.New("invalid_redeem_by")
+	ErrInvalidExpiresAt        = errors.New("invalid_expires_at")
+	ErrInvalidCouponID         = errors.New("invalid_coupon_id")
+	ErrInvalidCustomerID       = errors.New("invalid_customer_id")
+	ErrInvalidSubscriptionID   = errors.New("invalid_subscription_id")
+	ErrInvalidPromotionCode    = errors.New("invalid_promotion_code")
+	ErrInvalidStatus           = errors.New("invalid_status")
+	ErrCodeAlreadyExists       = errors.New("code_already_exists")
+	ErrCouponNotRedeemable     = errors.New("coupon_not_redeemable")
+	ErrPromotionNotRedeemable  = errors.New("promotion_code_not_redeemable")
+	ErrDiscountAlreadyApplied  = errors.New("discount_already_applied")
+	ErrDiscountNotActive       = errors.New("discount_not_active")
+	ErrCouponNotFound          = errors.New("coupon_not_found")
+	ErrPromotionCodeNotFound   = errors.New("promotion_code_not_found")
+	ErrCustomerNotFound        = errors.New("customer_not_found")
+	ErrSubscriptionNotFound    = errors.New("subscription_not_found")
+	ErrNotFound                = errors.New("not_found")
+)
+
+func ParseID(raw string) (snowflake.ID, error) {
+	return snowflake.ParseString(strings.TrimSpace(raw))
+}
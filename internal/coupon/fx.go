@@ -0,0 +1,12 @@
+package coupon
+
+import (
+	"github.com/smallbiznis/railzway/internal/coupon/repository"
+	"github.com/smallbiznis/railzway/internal/coupon/service"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("coupon.service",
+	fx.Provide(repository.Provide),
+	fx.Provide(service.NewService),
+)
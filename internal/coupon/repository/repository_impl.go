@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	coupondomain "github.com/smallbiznis/railzway/internal/coupon/domain"
+	"gorm.io/gorm"
+)
+
+type repo struct{}
+
+func Provide() coupondomain.Repository {
+	return &repo{}
+}
+
+const couponColumns = `id, org_id, code, name, discount_type, percent_off, amount_off, currency,
+	duration, duration_in_cycles, max_redemptions, times_redeemed, redeem_by, active,
+	metadata, created_at, updated_at`
+
+func (r *repo) InsertCoupon(ctx context.Context, db *gorm.DB, coupon *coupondomain.Coupon) error {
+	return db.WithContext(ctx).Exec(
+		`INSERT INTO coupons (`+couponColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		coupon.ID,
+		coupon.OrgID,
+		coupon.Code,
+		coupon.Name,
+		coupon.DiscountType,
+		coupon.PercentOff,
+		coupon.AmountOff,
+		coupon.Currency,
+		coupon.Duration,
+		coupon.DurationInCycles,
+		coupon.MaxRedemptions,
+		coupon.TimesRedeemed,
+		coupon.RedeemBy,
+		coupon.Active,
+		coupon.Metadata,
+		coupon.CreatedAt,
+		coupon.UpdatedAt,
+	).Error
+}
+
+func (r *repo) FindCouponByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*coupondomain.Coupon, error) {
+	var coupon coupondomain.Coupon
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+couponColumns+`
+		 FROM coupons
+		 WHERE org_id = ? AND id = ?`,
+		orgID,
+		id,
+	).Scan(&coupon).Error
+	if err != nil {
+		return nil, err
+	}
+	if coupon.ID == 0 {
+		return nil, nil
+	}
+	return &coupon, nil
+}
+
+func (r *repo) FindCouponByCode(ctx context.Context, db *gorm.DB, orgID snowflake.ID, code string) (*coupondomain.Coupon, error) {
+	var coupon coupondomain.Coupon
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+couponColumns+`
+		 FROM coupons
+		 WHERE org_id = ? AND code = ?`,
+		orgID,
+		code,
+	).Scan(&coupon).Error
+	if err != nil {
+		return nil, err
+	}
+	if coupon.ID == 0 {
+		return nil, nil
+	}
+	return &coupon, nil
+}
+
+func (r *repo) ListCoupons(ctx context.Context, db *gorm.DB, orgID snowflake.ID) ([]coupondomain.Coupon, error) {
+	var coupons []coupondomain.Coupon
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+couponColumns+`
+		 FROM coupons
+		 WHERE org_id = ?
+		 ORDER BY created_at DESC`,
+		orgID,
+	).Scan(&coupons).Error
+	if err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+func (r *repo) DeactivateCoupon(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID, now time.Time) error {
+	return db.WithContext(ctx).Exec(
+		`UPDATE coupons SET active = ?, updated_at = ? WHERE org_id = ? AND id = ?`,
+		false,
+		now,
+		orgID,
+		id,
+	).Error
+}
+
+func (r *repo) IncrementCouponRedemptions(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID, now time.Time) (bool, error) {
+	result := db.WithContext(ctx).Exec(
+		`UPDATE coupons
+		 SET times_redeemed = times_redeemed + 1, updated_at = ?
+		 WHERE org_id = ? AND id = ?
+		   AND (max_redemptions IS NULL OR times_redeemed < max_redemptions)`,
+		now,
+		orgID,
+		id,
+	)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repo) InsertPromotionCode(ctx context.Context, db *gorm.DB, code *coupondomain.PromotionCode) error {
+	return db.WithContext(ctx).Exec(
+		`INSERT INTO promotion_codes (
+			id, org_id, coupon_id, code, max_redemptions, times_redeemed, expires_at, active, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		code.ID,
+		code.OrgID,
+		code.CouponID,
+		code.Code,
+		code.MaxRedemptions,
+		code.TimesRedeemed,
+		code.ExpiresAt,
+		code.Active,
+		code.CreatedAt,
+		code.UpdatedAt,
+	).Error
+}
+
+func (r *repo) FindPromotionCodeByCode(ctx context.Context, db *gorm.DB, orgID snowflake.ID, code string) (*coupondomain.PromotionCode, error) {
+	var promo coupondomain.PromotionCode
+	err := db.WithContext(ctx).Raw(
+		`SELECT id, org_id, coupon_id, code, max_redemptions, times_redeemed, expires_at, active, created_at, updated_at
+		 FROM promotion_codes
+		 WHERE org_id = ? AND code = ?`,
+		orgID,
+		code,
+	).Scan(&promo).Error
+	if err != nil {
+		return nil, err
+	}
+	if promo.ID == 0 {
+		return nil, nil
+	}
+	return &promo, nil
+}
+
+func (r *repo) ListPromotionCodes(ctx context.Context, db *gorm.DB, orgID snowflake.ID, couponID *snowflake.ID) ([]coupondomain.PromotionCode, error) {
+	var codes []coupondomain.PromotionCode
+	stmt := db.WithContext(ctx).Model(&coupondomain.PromotionCode{}).Where("org_id = ?", orgID)
+	if couponID != nil {
+		stmt = stmt.Where("coupon_id = ?", *couponID)
+	}
+	if err := stmt.Order("created_at DESC").Find(&codes).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (r *repo) IncrementPromotionCodeRedemptions(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID, now time.Time) (bool, error) {
+	result := db.WithContext(ctx).Exec(
+		`UPDATE promotion_codes
+		 SET times_redeemed = times_redeemed + 1, updated_at = ?
+		 WHERE org_id = ? AND id = ?
+		   AND (max_redemptions IS NULL OR times_redeemed < max_redemptions)`,
+		now,
+		orgID,
+		id,
+	)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repo) InsertDiscount(ctx context.Context, db *gorm.DB, discount *coupondomain.Discount) error {
+	return db.WithContext(ctx).Exec(
+		`INSERT INTO discounts (
+			id, org_id, coupon_id, promotion_code_id, customer_id, subscription_id,
+			status, cycles_applied, started_at, ended_at, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		discount.ID,
+		discount.OrgID,
+		discount.CouponID,
+		discount.PromotionCodeID,
+		discount.CustomerID,
+		discount.SubscriptionID,
+		discount.Status,
+		discount.CyclesApplied,
+		discount.StartedAt,
+		discount.EndedAt,
+		discount.CreatedAt,
+		discount.UpdatedAt,
+	).Error
+}
+
+func (r *repo) FindDiscountByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*coupondomain.Discount, error) {
+	var discount coupondomain.Discount
+	err := db.WithContext(ctx).Raw(
+		`SELECT id, org_id, coupon_id, promotion_code_id, customer_id, subscription_id,
+		        status, cycles_applied, started_at, ended_at, created_at, updated_at
+		 FROM discounts
+		 WHERE org_id = ? AND id = ?`,
+		orgID,
+		id,
+	).Scan(&discount).Error
+	if err != nil {
+		return nil, err
+	}
+	if discount.ID == 0 {
+		return nil, nil
+	}
+	return &discount, nil
+}
+
+func (r *repo) ListDiscounts(ctx context.Context, db *gorm.DB, orgID snowflake.ID, filter coupondomain.DiscountFilter) ([]coupondomain.Discount, error) {
+	var discounts []coupondomain.Discount
+	stmt := db.WithContext(ctx).Model(&coupondomain.Discount{}).Where("org_id = ?", orgID)
+
+	if filter.CustomerID != nil {
+		stmt = stmt.Where("customer_id = ?", *filter.CustomerID)
+	}
+	if filter.SubscriptionID != nil {
+		stmt = stmt.Where("subscription_id = ?", *filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		stmt = stmt.Where("status = ?", filter.Status)
+	}
+
+	if err := stmt.Order("created_at DESC").Find(&discounts).Error; err != nil {
+		return nil, err
+	}
+	return discounts, nil
+}
+
+func (r *repo) HasActiveDiscount(ctx context.Context, db *gorm.DB, orgID, couponID, customerID snowflake.ID, subscriptionID *snowflake.ID) (bool, error) {
+	stmt := db.WithContext(ctx).Model(&coupondomain.Discount{}).
+		Where("org_id = ? AND coupon_id = ? AND customer_id = ? AND status = ?", orgID, couponID, customerID, coupondomain.DiscountStatusActive)
+	if subscriptionID != nil {
+		stmt = stmt.Where("subscription_id = ?", *subscriptionID)
+	} else {
+		stmt = stmt.Where("subscription_id IS NULL")
+	}
+
+	var count int64
+	if err := stmt.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *repo) EndDiscount(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID, status coupondomain.DiscountStatus, now time.Time) error {
+	return db.WithContext(ctx).Exec(
+		`UPDATE discounts SET status = ?, ended_at = ?, updated_at = ? WHERE org_id = ? AND id = ?`,
+		status,
+		now,
+		now,
+		orgID,
+		id,
+	).Error
+}
@@ -0,0 +1,658 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	auditdomain "github.com/smallbiznis/railzway/internal/audit/domain"
+	coupondomain "github.com/smallbiznis/railzway/internal/coupon/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type Params struct {
+	fx.In
+
+	DB       *gorm.DB
+	Log      *zap.Logger
+	GenID    *snowflake.Node
+	Repo     coupondomain.Repository
+	AuditSvc auditdomain.Service `optional:"true"`
+}
+
+type Service struct {
+	db       *gorm.DB
+	log      *zap.Logger
+	genID    *snowflake.Node
+	repo     coupondomain.Repository
+	auditSvc auditdomain.Service
+}
+
+func NewService(p Params) coupondomain.Service {
+	return &Service{
+		db:       p.DB,
+		log:      p.Log.Named("coupon.service"),
+		genID:    p.GenID,
+		repo:     p.Repo,
+		auditSvc: p.AuditSvc,
+	}
+}
+
+func (s *Service) CreateCoupon(ctx context.Context, req coupondomain.CreateCouponRequest) (*coupondomain.CouponResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, coupondomain.ErrInvalidOrganization
+	}
+
+	now := time.Now().UTC()
+	coupon, err := buildCoupon(req, now)
+	if err != nil {
+		return nil, err
+	}
+	coupon.ID = s.genID.Generate()
+	coupon.OrgID = orgID
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := s.repo.FindCouponByCode(ctx, tx, orgID, coupon.Code)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return coupondomain.ErrCodeAlreadyExists
+		}
+		return s.repo.InsertCoupon(ctx, tx, coupon)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitAudit(ctx, orgID, "coupon.create", "coupon", coupon.ID, map[string]any{
+		"code":          coupon.Code,
+		"discount_type": string(coupon.DiscountType),
+		"duration":      string(coupon.Duration),
+	})
+	return toCouponResponse(coupon), nil
+}
+
+func (s *Service) ListCoupons(ctx context.Context) ([]coupondomain.CouponResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, coupondomain.ErrInvalidOrganization
+	}
+
+	coupons, err := s.repo.ListCoupons(ctx, s.db, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]coupondomain.CouponResponse, 0, len(coupons))
+	for i := range coupons {
+		resp = append(resp, *toCouponResponse(&coupons[i]))
+	}
+	return resp, nil
+}
+
+func (s *Service) GetCoupon(ctx context.Context, id string) (*coupondomain.CouponResponse, error) {
+	coupon, err := s.loadCoupon(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toCouponResponse(coupon), nil
+}
+
+// ArchiveCoupon stops a coupon from being redeemed again. Discounts that are
+// already attached keep running until their duration ends.
+func (s *Service) ArchiveCoupon(ctx context.Context, id string) (*coupondomain.CouponResponse, error) {
+	coupon, err := s.loadCoupon(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !coupon.Active {
+		return toCouponResponse(coupon), nil
+	}
+
+	now := time.Now().UTC()
+	if err := s.repo.DeactivateCoupon(ctx, s.db, coupon.OrgID, coupon.ID, now); err != nil {
+		return nil, err
+	}
+	coupon.Active = false
+	coupon.UpdatedAt = now
+
+	s.emitAudit(ctx, coupon.OrgID, "coupon.archive", "coupon", coupon.ID, map[string]any{
+		"code": coupon.Code,
+	})
+	return toCouponResponse(coupon), nil
+}
+
+func (s *Service) CreatePromotionCode(ctx context.Context, req coupondomain.CreatePromotionCodeRequest) (*coupondomain.PromotionCodeResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, coupondomain.ErrInvalidOrganization
+	}
+
+	couponID, err := coupondomain.ParseID(req.CouponID)
+	if err != nil {
+		return nil, coupondomain.ErrInvalidCouponID
+	}
+	code := coupondomain.NormalizeCode(req.Code)
+	if code == "" {
+		return nil, coupondomain.ErrInvalidCode
+	}
+	if req.MaxRedemptions != nil && *req.MaxRedemptions <= 0 {
+		return nil, coupondomain.ErrInvalidMaxRedemptions
+	}
+
+	now := time.Now().UTC()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, coupondomain.ErrInvalidExpiresAt
+	}
+
+	promo := &coupondomain.PromotionCode{
+		ID:             s.genID.Generate(),
+		OrgID:          orgID,
+		CouponID:       couponID,
+		Code:           code,
+		MaxRedemptions: req.MaxRedemptions,
+		ExpiresAt:      utcPtr(req.ExpiresAt),
+		Active:         true,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		coupon, err := s.repo.FindCouponByID(ctx, tx, orgID, couponID)
+		if err != nil {
+			return err
+		}
+		if coupon == nil {
+			return coupondomain.ErrCouponNotFound
+		}
+		if !coupon.Active {
+			return coupondomain.ErrCouponNotRedeemable
+		}
+
+		existing, err := s.repo.FindPromotionCodeByCode(ctx, tx, orgID, code)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return coupondomain.ErrCodeAlreadyExists
+		}
+		return s.repo.InsertPromotionCode(ctx, tx, promo)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitAudit(ctx, orgID, "promotion_code.create", "promotion_code", promo.ID, map[string]any{
+		"code":      promo.Code,
+		"coupon_id": promo.CouponID.String(),
+	})
+	return toPromotionCodeResponse(promo), nil
+}
+
+func (s *Service) ListPromotionCodes(ctx context.Context, req coupondomain.ListPromotionCodesRequest) ([]coupondomain.PromotionCodeResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, coupondomain.ErrInvalidOrganization
+	}
+
+	var couponID *snowflake.ID
+	if strings.TrimSpace(req.CouponID) != "" {
+		id, err := coupondomain.ParseID(req.CouponID)
+		if err != nil {
+			return nil, coupondomain.ErrInvalidCouponID
+		}
+		couponID = &id
+	}
+
+	codes, err := s.repo.ListPromotionCodes(ctx, s.db, orgID, couponID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]coupondomain.PromotionCodeResponse, 0, len(codes))
+	for i := range codes {
+		resp = append(resp, *toPromotionCodeResponse(&codes[i]))
+	}
+	return resp, nil
+}
+
+// ApplyDiscount redeems a coupon, directly or through a promotion code, and
+// attaches it to a customer or subscription. The redemption counters are
+// incremented atomically so limits hold under concurrent redemptions.
+func (s *Service) ApplyDiscount(ctx context.Context, req coupondomain.ApplyDiscountRequest) (*coupondomain.DiscountResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, coupondomain.ErrInvalidOrganization
+	}
+
+	var (
+		customerID     snowflake.ID
+		subscriptionID *snowflake.ID
+		couponID       snowflake.ID
+		err            error
+	)
+	if strings.TrimSpace(req.CustomerID) != "" {
+		customerID, err = coupondomain.ParseID(req.CustomerID)
+		if err != nil {
+			return nil, coupondomain.ErrInvalidCustomerID
+		}
+	}
+	if strings.TrimSpace(req.SubscriptionID) != "" {
+		id, err := coupondomain.ParseID(req.SubscriptionID)
+		if err != nil {
+			return nil, coupondomain.ErrInvalidSubscriptionID
+		}
+		subscriptionID = &id
+	}
+	if customerID == 0 && subscriptionID == nil {
+		return nil, coupondomain.ErrInvalidCustomerID
+	}
+
+	promoCode := coupondomain.NormalizeCode(req.PromotionCode)
+	hasCoupon := strings.TrimSpace(req.CouponID) != ""
+	switch {
+	case hasCoupon && promoCode != "":
+		return nil, coupondomain.ErrInvalidPromotionCode
+	case hasCoupon:
+		couponID, err = coupondomain.ParseID(req.CouponID)
+		if err != nil {
+			return nil, coupondomain.ErrInvalidCouponID
+		}
+	case promoCode == "":
+		return nil, coupondomain.ErrInvalidCouponID
+	}
+
+	now := time.Now().UTC()
+	var (
+		discount *coupondomain.Discount
+		coupon   *coupondomain.Coupon
+	)
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var promoID *snowflake.ID
+		if promoCode != "" {
+			promo, err := s.repo.FindPromotionCodeByCode(ctx, tx, orgID, promoCode)
+			if err != nil {
+				return err
+			}
+			if promo == nil {
+				return coupondomain.ErrPromotionCodeNotFound
+			}
+			if !promo.Redeemable(now) {
+				return coupondomain.ErrPromotionNotRedeemable
+			}
+			ok, err := s.repo.IncrementPromotionCodeRedemptions(ctx, tx, orgID, promo.ID, now)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return coupondomain.ErrPromotionNotRedeemable
+			}
+			promoID = &promo.ID
+			couponID = promo.CouponID
+		}
+
+		coupon, err = s.repo.FindCouponByID(ctx, tx, orgID, couponID)
+		if err != nil {
+			return err
+		}
+		if coupon == nil {
+			return coupondomain.ErrCouponNotFound
+		}
+		if !coupon.Redeemable(now) {
+			return coupondomain.ErrCouponNotRedeemable
+		}
+
+		if subscriptionID != nil {
+			owner, err := s.loadSubscriptionCustomer(ctx, tx, orgID, *subscriptionID)
+			if err != nil {
+				return err
+			}
+			if owner == 0 {
+				return coupondomain.ErrSubscriptionNotFound
+			}
+			if customerID != 0 && customerID != owner {
+				return coupondomain.ErrInvalidSubscriptionID
+			}
+			customerID = owner
+		} else {
+			exists, err := s.customerExists(ctx, tx, orgID, customerID)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return coupondomain.ErrCustomerNotFound
+			}
+		}
+
+		applied, err := s.repo.HasActiveDiscount(ctx, tx, orgID, coupon.ID, customerID, subscriptionID)
+		if err != nil {
+			return err
+		}
+		if applied {
+			return coupondomain.ErrDiscountAlreadyApplied
+		}
+
+		ok, err := s.repo.IncrementCouponRedemptions(ctx, tx, orgID, coupon.ID, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return coupondomain.ErrCouponNotRedeemable
+		}
+
+		discount = &coupondomain.Discount{
+			ID:              s.genID.Generate(),
+			OrgID:           orgID,
+			CouponID:        coupon.ID,
+			PromotionCodeID: promoID,
+			CustomerID:      customerID,
+			SubscriptionID:  subscriptionID,
+			Status:          coupondomain.DiscountStatusActive,
+			StartedAt:       now,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		return s.repo.InsertDiscount(ctx, tx, discount)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]any{
+		"coupon_id":   coupon.ID.String(),
+		"coupon_code": coupon.Code,
+		"customer_id": discount.CustomerID.String(),
+	}
+	if discount.SubscriptionID != nil {
+		metadata["subscription_id"] = discount.SubscriptionID.String()
+	}
+	if promoCode != "" {
+		metadata["promotion_code"] = promoCode
+	}
+	s.emitAudit(ctx, orgID, "discount.apply", "discount", discount.ID, metadata)
+	return toDiscountResponse(discount), nil
+}
+
+func (s *Service) ListDiscounts(ctx context.Context, req coupondomain.ListDiscountsRequest) ([]coupondomain.DiscountResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, coupondomain.ErrInvalidOrganization
+	}
+
+	var filter coupondomain.DiscountFilter
+	if strings.TrimSpace(req.CustomerID) != "" {
+		id, err := coupondomain.ParseID(req.CustomerID)
+		if err != nil {
+			return nil, coupondomain.ErrInvalidCustomerID
+		}
+		filter.CustomerID = &id
+	}
+	if strings.TrimSpace(req.SubscriptionID) != "" {
+		id, err := coupondomain.ParseID(req.SubscriptionID)
+		if err != nil {
+			return nil, coupondomain.ErrInvalidSubscriptionID
+		}
+		filter.SubscriptionID = &id
+	}
+	if status := strings.ToUpper(strings.TrimSpace(req.Status)); status != "" {
+		switch coupondomain.DiscountStatus(status) {
+		case coupondomain.DiscountStatusActive, coupondomain.DiscountStatusEnded, coupondomain.DiscountStatusRemoved:
+			filter.Status = coupondomain.DiscountStatus(status)
+		default:
+			return nil, coupondomain.ErrInvalidStatus
+		}
+	}
+
+	discounts, err := s.repo.ListDiscounts(ctx, s.db, orgID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]coupondomain.DiscountResponse, 0, len(discounts))
+	for i := range discounts {
+		resp = append(resp, *toDiscountResponse(&discounts[i]))
+	}
+	return resp, nil
+}
+
+func (s *Service) RemoveDiscount(ctx context.Context, id string) error {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return coupondomain.ErrInvalidOrganization
+	}
+
+	discountID, err := coupondomain.ParseID(id)
+	if err != nil {
+		return coupondomain.ErrInvalidID
+	}
+
+	discount, err := s.repo.FindDiscountByID(ctx, s.db, orgID, discountID)
+	if err != nil {
+		return err
+	}
+	if discount == nil {
+		return coupondomain.ErrNotFound
+	}
+	if discount.Status != coupondomain.DiscountStatusActive {
+		return coupondomain.ErrDiscountNotActive
+	}
+
+	if err := s.repo.EndDiscount(ctx, s.db, orgID, discount.ID, coupondomain.DiscountStatusRemoved, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	s.emitAudit(ctx, orgID, "discount.remove", "discount", discount.ID, map[string]any{
+		"coupon_id":      discount.CouponID.String(),
+		"customer_id":    discount.CustomerID.String(),
+		"cycles_applied": discount.CyclesApplied,
+	})
+	return nil
+}
+
+func (s *Service) loadCoupon(ctx context.Context, id string) (*coupondomain.Coupon, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, coupondomain.ErrInvalidOrganization
+	}
+
+	couponID, err := coupondomain.ParseID(id)
+	if err != nil {
+		return nil, coupondomain.ErrInvalidID
+	}
+
+	coupon, err := s.repo.FindCouponByID(ctx, s.db, orgID, couponID)
+	if err != nil {
+		return nil, err
+	}
+	if coupon == nil {
+		return nil, coupondomain.ErrCouponNotFound
+	}
+	return coupon, nil
+}
+
+func (s *Service) loadSubscriptionCustomer(ctx context.Context, db *gorm.DB, orgID, subscriptionID snowflake.ID) (snowflake.ID, error) {
+	var customerID snowflake.ID
+	err := db.WithContext(ctx).Raw(
+		`SELECT customer_id FROM subscriptions WHERE org_id = ? AND id = ?`,
+		orgID,
+		subscriptionID,
+	).Scan(&customerID).Error
+	if err != nil {
+		return 0, err
+	}
+	return customerID, nil
+}
+
+func (s *Service) customerExists(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID) (bool, error) {
+	var count int64
+	err := db.WithContext(ctx).Raw(
+		`SELECT COUNT(1) FROM customers WHERE org_id = ? AND id = ?`,
+		orgID,
+		customerID,
+	).Scan(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *Service) emitAudit(ctx context.Context, orgID snowflake.ID, action, targetType string, targetID snowflake.ID, metadata map[string]any) {
+	if s.auditSvc == nil {
+		return
+	}
+	target := targetID.String()
+	_ = s.auditSvc.AuditLog(ctx, &orgID, "", nil, action, targetType, &target, metadata)
+}
+
+func buildCoupon(req coupondomain.CreateCouponRequest, now time.Time) (*coupondomain.Coupon, error) {
+	code := coupondomain.NormalizeCode(req.Code)
+	if code == "" {
+		return nil, coupondomain.ErrInvalidCode
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, coupondomain.ErrInvalidName
+	}
+
+	coupon := &coupondomain.Coupon{
+		Code:           code,
+		Name:           name,
+		MaxRedemptions: req.MaxRedemptions,
+		RedeemBy:       utcPtr(req.RedeemBy),
+		Active:         true,
+		Metadata:       datatypes.JSONMap(req.Metadata),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if coupon.Metadata == nil {
+		coupon.Metadata = datatypes.JSONMap{}
+	}
+
+	switch coupondomain.DiscountType(strings.ToLower(strings.TrimSpace(req.DiscountType))) {
+	case coupondomain.DiscountTypePercent:
+		if req.PercentOff == nil || *req.PercentOff <= 0 || *req.PercentOff > 100 {
+			return nil, coupondomain.ErrInvalidPercentOff
+		}
+		if req.AmountOff != nil {
+			return nil, coupondomain.ErrInvalidAmountOff
+		}
+		coupon.DiscountType = coupondomain.DiscountTypePercent
+		coupon.PercentOff = req.PercentOff
+	case coupondomain.DiscountTypeAmount:
+		if req.AmountOff == nil || *req.AmountOff <= 0 {
+			return nil, coupondomain.ErrInvalidAmountOff
+		}
+		if req.PercentOff != nil {
+			return nil, coupondomain.ErrInvalidPercentOff
+		}
+		currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+		if len(currency) != 3 {
+			return nil, coupondomain.ErrInvalidCurrency
+		}
+		coupon.DiscountType = coupondomain.DiscountTypeAmount
+		coupon.AmountOff = req.AmountOff
+		coupon.Currency = &currency
+	default:
+		return nil, coupondomain.ErrInvalidDiscountType
+	}
+
+	switch coupondomain.CouponDuration(strings.ToLower(strings.TrimSpace(req.Duration))) {
+	case coupondomain.DurationOnce:
+		coupon.Duration = coupondomain.DurationOnce
+	case coupondomain.DurationForever:
+		coupon.Duration = coupondomain.DurationForever
+	case coupondomain.DurationRepeating:
+		if req.DurationInCycles == nil || *req.DurationInCycles <= 0 {
+			return nil, coupondomain.ErrInvalidDurationInCycles
+		}
+		coupon.Duration = coupondomain.DurationRepeating
+		coupon.DurationInCycles = req.DurationInCycles
+	default:
+		return nil, coupondomain.ErrInvalidDuration
+	}
+	if coupon.Duration != coupondomain.DurationRepeating && req.DurationInCycles != nil {
+		return nil, coupondomain.ErrInvalidDurationInCycles
+	}
+
+	if req.MaxRedemptions != nil && *req.MaxRedemptions <= 0 {
+		return nil, coupondomain.ErrInvalidMaxRedemptions
+	}
+	if req.RedeemBy != nil && !req.RedeemBy.After(now) {
+		return nil, coupondomain.ErrInvalidRedeemBy
+	}
+	return coupon, nil
+}
+
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := t.UTC()
+	return &v
+}
+
+func toCouponResponse(coupon *coupondomain.Coupon) *coupondomain.CouponResponse {
+	return &coupondomain.CouponResponse{
+		ID:               coupon.ID.String(),
+		OrgID:            coupon.OrgID.String(),
+		Code:             coupon.Code,
+		Name:             coupon.Name,
+		DiscountType:     string(coupon.DiscountType),
+		PercentOff:       coupon.PercentOff,
+		AmountOff:        coupon.AmountOff,
+		Currency:         coupon.Currency,
+		Duration:         string(coupon.Duration),
+		DurationInCycles: coupon.DurationInCycles,
+		MaxRedemptions:   coupon.MaxRedemptions,
+		TimesRedeemed:    coupon.TimesRedeemed,
+		RedeemBy:         coupon.RedeemBy,
+		Active:           coupon.Active,
+		Metadata:         map[string]any(coupon.Metadata),
+		CreatedAt:        coupon.CreatedAt,
+		UpdatedAt:        coupon.UpdatedAt,
+	}
+}
+
+func toPromotionCodeResponse(promo *coupondomain.PromotionCode) *coupondomain.PromotionCodeResponse {
+	return &coupondomain.PromotionCodeResponse{
+		ID:             promo.ID.String(),
+		OrgID:          promo.OrgID.String(),
+		CouponID:       promo.CouponID.String(),
+		Code:           promo.Code,
+		MaxRedemptions: promo.MaxRedemptions,
+		TimesRedeemed:  promo.TimesRedeemed,
+		ExpiresAt:      promo.ExpiresAt,
+		Active:         promo.Active,
+		CreatedAt:      promo.CreatedAt,
+	}
+}
+
+func toDiscountResponse(discount *coupondomain.Discount) *coupondomain.DiscountResponse {
+	resp := &coupondomain.DiscountResponse{
+		ID:            discount.ID.String(),
+		OrgID:         discount.OrgID.String(),
+		CouponID:      discount.CouponID.String(),
+		CustomerID:    discount.CustomerID.String(),
+		Status:        string(discount.Status),
+		CyclesApplied: discount.CyclesApplied,
+		StartedAt:     discount.StartedAt,
+		EndedAt:       discount.EndedAt,
+		CreatedAt:     discount.CreatedAt,
+	}
+	if discount.PromotionCodeID != nil {
+		id := discount.PromotionCodeID.String()
+		resp.PromotionCodeID = &id
+	}
+	if discount.SubscriptionID != nil {
+		id := discount.SubscriptionID.String()
+		resp.SubscriptionID = &id
+	}
+	return resp
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/glebarez/sqlite"
+	coupondomain "github.com/smallbiznis/railzway/internal/coupon/domain"
+	"github.com/smallbiznis/railzway/internal/coupon/repository"
+	customerdomain "github.com/smallbiznis/railzway/internal/customer/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type couponFixture struct {
+	db         *gorm.DB
+	svc        coupondomain.Service
+	ctx        context.Context
+	orgID      snowflake.ID
+	customerID snowflake.ID
+	subID      snowflake.ID
+}
+
+func setupCouponTest(t *testing.T) *couponFixture {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&customerdomain.Customer{},
+		&coupondomain.Coupon{},
+		&coupondomain.PromotionCode{},
+		&coupondomain.Discount{},
+	))
+	require.NoError(t, db.Exec("CREATE TABLE subscriptions (id BIGINT PRIMARY KEY, org_id BIGINT, customer_id BIGINT)").Error)
+
+	node, _ := snowflake.NewNode(1)
+	f := &couponFixture{
+		db:         db,
+		orgID:      node.Generate(),
+		customerID: node.Generate(),
+		subID:      node.Generate(),
+	}
+	f.ctx = orgcontext.WithOrgID(context.Background(), int64(f.orgID))
+	f.svc = NewService(Params{
+		DB:    db,
+		Log:   zap.NewNop(),
+		GenID: node,
+		Repo:  repository.Provide(),
+	})
+
+	require.NoError(t, db.Create(&customerdomain.Customer{ID: f.customerID, OrgID: f.orgID, Name: "Acme", Email: "billing@acme.test"}).Error)
+	require.NoError(t, db.Exec("INSERT INTO subscriptions (id, org_id, customer_id) VALUES (?, ?, ?)", f.subID, f.orgID, f.customerID).Error)
+	return f
+}
+
+func TestCreateCoupon_Validation(t *testing.T) {
+	f := setupCouponTest(t)
+
+	percent := 150.0
+	_, err := f.svc.CreateCoupon(f.ctx, coupondomain.CreateCouponRequest{Code: "big", Name: "Big", DiscountType: "percent", PercentOff: &percent, Duration: "once"})
+	assert.ErrorIs(t, err, coupondomain.ErrInvalidPercentOff)
+
+	amount := int64(500)
+	_, err = f.svc.CreateCoupon(f.ctx, coupondomain.CreateCouponRequest{Code: "flat", Name: "Flat", DiscountType: "amount", AmountOff: &amount, Duration: "once"})
+	assert.ErrorIs(t, err, coupondomain.ErrInvalidCurrency)
+
+	_, err = f.svc.CreateCoupon(f.ctx, coupondomain.CreateCouponRequest{Code: "flat", Name: "Flat", DiscountType: "amount", AmountOff: &amount, Currency: "usd", Duration: "repeating"})
+	assert.ErrorIs(t, err, coupondomain.ErrInvalidDurationInCycles)
+
+	coupon, err := f.svc.CreateCoupon(f.ctx, coupondomain.CreateCouponRequest{Code: "flat", Name: "Flat", DiscountType: "amount", AmountOff: &amount, Currency: "usd", Duration: "forever"})
+	require.NoError(t, err)
+	assert.Equal(t, "FLAT", coupon.Code)
+	assert.Equal(t, "USD", *coupon.Currency)
+
+	_, err = f.svc.CreateCoupon(f.ctx, coupondomain.CreateCouponRequest{Code: "Flat", Name: "Again", DiscountType: "amount", AmountOff: &amount, Currency: "USD", Duration: "once"})
+	assert.ErrorIs(t, err, coupondomain.ErrCodeAlreadyExists)
+}
+
+func TestApplyDiscount_PromotionCodeLimits(t *testing.T) {
+	f := setupCouponTest(t)
+
+	percent := 25.0
+	cycles := 3
+	coupon, err := f.svc.CreateCoupon(f.ctx, coupondomain.CreateCouponRequest{
+		Code:             "launch",
+		Name:             "Launch",
+		DiscountType:     "percent",
+		PercentOff:       &percent,
+		Duration:         "repeating",
+		DurationInCycles: &cycles,
+	})
+	require.NoError(t, err)
+
+	limit := 1
+	promo, err := f.svc.CreatePromotionCode(f.ctx, coupondomain.CreatePromotionCodeRequest{
+		CouponID:       coupon.ID,
+		Code:           "launch-friends",
+		MaxRedemptions: &limit,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "LAUNCH-FRIENDS", promo.Code)
+
+	discount, err := f.svc.ApplyDiscount(f.ctx, coupondomain.ApplyDiscountRequest{
+		SubscriptionID: f.subID.String(),
+		PromotionCode:  "launch-friends",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, f.customerID.String(), discount.CustomerID)
+	require.NotNil(t, discount.SubscriptionID)
+	assert.Equal(t, f.subID.String(), *discount.SubscriptionID)
+	assert.Equal(t, promo.ID, *discount.PromotionCodeID)
+
+	// The code is used up, even for a different target.
+	_, err = f.svc.ApplyDiscount(f.ctx, coupondomain.ApplyDiscountRequest{
+		CustomerID:    f.customerID.String(),
+		PromotionCode: "LAUNCH-FRIENDS",
+	})
+	assert.ErrorIs(t, err, coupondomain.ErrPromotionNotRedeemable)
+
+	// The coupon itself is still redeemable, but not twice on the same subscription.
+	_, err = f.svc.ApplyDiscount(f.ctx, coupondomain.ApplyDiscountRequest{
+		SubscriptionID: f.subID.String(),
+		CouponID:       coupon.ID,
+	})
+	assert.ErrorIs(t, err, coupondomain.ErrDiscountAlreadyApplied)
+
+	customerWide, err := f.svc.ApplyDiscount(f.ctx, coupondomain.ApplyDiscountRequest{
+		CustomerID: f.customerID.String(),
+		CouponID:   coupon.ID,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, customerWide.SubscriptionID)
+
+	reloaded, err := f.svc.GetCoupon(f.ctx, coupon.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reloaded.TimesRedeemed)
+
+	require.NoError(t, f.svc.RemoveDiscount(f.ctx, customerWide.ID))
+	assert.ErrorIs(t, f.svc.RemoveDiscount(f.ctx, customerWide.ID), coupondomain.ErrDiscountNotActive)
+
+	active, err := f.svc.ListDiscounts(f.ctx, coupondomain.ListDiscountsRequest{CustomerID: f.customerID.String(), Status: "active"})
+	require.NoError(t, err)
+	assert.Len(t, active, 1)
+
+	// Archived coupons and expired codes cannot be redeemed.
+	expired := coupondomain.PromotionCode{
+		ID:        snowflake.ID(1),
+		OrgID:     f.orgID,
+		Code:      "OLD",
+		Active:    true,
+		ExpiresAt: ptrTime(time.Now().Add(-time.Hour)),
+	}
+	couponID, _ := coupondomain.ParseID(coupon.ID)
+	expired.CouponID = couponID
+	require.NoError(t, f.db.Create(&expired).Error)
+	_, err = f.svc.ApplyDiscount(f.ctx, coupondomain.ApplyDiscountRequest{CustomerID: f.customerID.String(), PromotionCode: "old"})
+	assert.ErrorIs(t, err, coupondomain.ErrPromotionNotRedeemable)
+
+	_, err = f.svc.ArchiveCoupon(f.ctx, coupon.ID)
+	require.NoError(t, err)
+	_, err = f.svc.ApplyDiscount(f.ctx, coupondomain.ApplyDiscountRequest{CustomerID: f.customerID.String(), CouponID: coupon.ID})
+	assert.ErrorIs(t, err, coupondomain.ErrCouponNotRedeemable)
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
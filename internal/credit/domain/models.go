@@ -0,0 +1,85 @@
+// Package domain contains persistence models for customer credit wallets.
+package domain
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/datatypes"
+)
+
+// GrantType decides how a grant is funded in the ledger.
+type GrantType string
+
+const (
+	// GrantTypePromotional is goodwill or promotional credit, funded by the
+	// billing adjustment account.
+	GrantTypePromotional GrantType = "promotional"
+	// GrantTypePrepaid is credit the customer paid for up front, e.g. a
+	// prepaid pack settled outside Railzway.
+	GrantTypePrepaid GrantType = "prepaid"
+)
+
+// GrantStatus represents credit grant lifecycle states.
+type GrantStatus string
+
+const (
+	// GrantStatusPending holds credit carried by a draft invoice until the
+	// invoice is finalized.
+	GrantStatusPending  GrantStatus = "pending"
+	GrantStatusActive   GrantStatus = "active"
+	GrantStatusDepleted GrantStatus = "depleted"
+	GrantStatusExpired  GrantStatus = "expired"
+)
+
+// TransactionType classifies movements of a customer's credit balance.
+type TransactionType string
+
+const (
+	TransactionTypeGrant  TransactionType = "grant"
+	TransactionTypeUse    TransactionType = "use"
+	TransactionTypeExpiry TransactionType = "expiry"
+	// TransactionTypeReversal gives back credit drawn by a voided invoice.
+	TransactionTypeReversal TransactionType = "reversal"
+)
+
+// CreditGrant is a block of customer credit. Invoices draw grants down by
+// ascending priority, then by earliest expiry.
+type CreditGrant struct {
+	ID              snowflake.ID      `gorm:"primaryKey"`
+	OrgID           snowflake.ID      `gorm:"not null;index"`
+	CustomerID      snowflake.ID      `gorm:"not null;index"`
+	Name            string            `gorm:"type:text;not null"`
+	Type            GrantType         `gorm:"type:text;not null"`
+	Amount          int64             `gorm:"not null"`
+	RemainingAmount int64             `gorm:"not null"`
+	Currency        string            `gorm:"type:text;not null"`
+	Priority        int               `gorm:"not null;default:0"`
+	ExpiresAt       *time.Time        `gorm:""`
+	Status          GrantStatus       `gorm:"type:text;not null;default:'active'"`
+	ExpiredAt       *time.Time        `gorm:""`
+	Metadata        datatypes.JSONMap `gorm:"type:jsonb;not null;default:'{}'"`
+	CreatedAt       time.Time         `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt       time.Time         `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName sets the database table name.
+func (CreditGrant) TableName() string { return "credit_grants" }
+
+// CreditTransaction records one movement of a grant's balance. Amount is
+// positive for grants and negative for uses and expiries.
+type CreditTransaction struct {
+	ID          snowflake.ID    `gorm:"primaryKey"`
+	OrgID       snowflake.ID    `gorm:"not null;index"`
+	CustomerID  snowflake.ID    `gorm:"not null;index"`
+	GrantID     snowflake.ID    `gorm:"not null;index"`
+	Type        TransactionType `gorm:"type:text;not null"`
+	Amount      int64           `gorm:"not null"`
+	Currency    string          `gorm:"type:text;not null"`
+	InvoiceID   *snowflake.ID   `gorm:"index"`
+	Description string          `gorm:"type:text"`
+	CreatedAt   time.Time       `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName sets the database table name.
+func (CreditTransaction) TableName() string { return "credit_transactions" }
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/gorm"
+)
+
+// GrantFilter narrows credit grant listings.
+type GrantFilter struct {
+	Status *GrantStatus
+	Limit  int
+}
+
+// TransactionFilter narrows credit transaction listings.
+type TransactionFilter struct {
+	GrantID *snowflake.ID
+	Limit   int
+}
+
+type Repository interface {
+	CustomerExists(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID) (bool, error)
+
+	InsertGrant(ctx context.Context, db *gorm.DB, grant *CreditGrant) error
+	FindGrantForUpdate(ctx context.Context, db *gorm.DB, id snowflake.ID) (*CreditGrant, error)
+	UpdateGrant(ctx context.Context, db *gorm.DB, grant *CreditGrant) error
+	ListGrants(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, filter GrantFilter) ([]CreditGrant, error)
+	// ListAvailable returns active grants with a remaining balance that have
+	// not expired at now.
+	ListAvailable(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, now time.Time) ([]CreditGrant, error)
+	// ListDueExpiry returns active grants, across organizations, whose expiry
+	// is at or before now.
+	ListDueExpiry(ctx context.Context, db *gorm.DB, now time.Time, limit int) ([]CreditGrant, error)
+
+	InsertTransaction(ctx context.Context, db *gorm.DB, txn *CreditTransaction) error
+	ListTransactions(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, filter TransactionFilter) ([]CreditTransaction, error)
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type CreateGrantRequest struct {
+	Name      string         `json:"name"`
+	Type      string         `json:"type"`
+	Amount    int64          `json:"amount"`
+	Currency  string         `json:"currency"`
+	Priority  int            `json:"priority"`
+	ExpiresAt *time.Time     `json:"expires_at"`
+	Metadata  map[string]any `json:"metadata"`
+}
+
+type ListGrantsRequest struct {
+	Status string `form:"status"`
+	Limit  int    `form:"limit"`
+}
+
+type ListTransactionsRequest struct {
+	GrantID string `form:"grant_id"`
+	Limit   int    `form:"limit"`
+}
+
+type GrantResponse struct {
+	ID              string         `json:"id"`
+	OrgID           string         `json:"organization_id"`
+	CustomerID      string         `json:"customer_id"`
+	Name            string         `json:"name"`
+	Type            string         `json:"type"`
+	Amount          int64          `json:"amount"`
+	RemainingAmount int64          `json:"remaining_amount"`
+	Currency        string         `json:"currency"`
+	Priority        int            `json:"priority"`
+	ExpiresAt       *time.Time     `json:"expires_at,omitempty"`
+	Status          string         `json:"status"`
+	ExpiredAt       *time.Time     `json:"expired_at,omitempty"`
+	Metadata        map[string]any `json:"metadata,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// CurrencyBalance is the customer's available credit in one currency.
+type CurrencyBalance struct {
+	Currency     string     `json:"currency"`
+	Available    int64      `json:"available"`
+	ActiveGrants int        `json:"active_grants"`
+	NextExpiryAt *time.Time `json:"next_expiry_at,omitempty"`
+}
+
+type BalanceResponse struct {
+	CustomerID string            `json:"customer_id"`
+	Balances   []CurrencyBalance `json:"balances"`
+}
+
+type TransactionResponse struct {
+	ID          string    `json:"id"`
+	CustomerID  string    `json:"customer_id"`
+	GrantID     string    `json:"grant_id"`
+	Type        string    `json:"type"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
+	InvoiceID   *string   `json:"invoice_id,omitempty"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Service interface {
+	CreateGrant(ctx context.Context, customerID string, req CreateGrantRequest) (*GrantResponse, error)
+	ListGrants(ctx context.Context, customerID string, req ListGrantsRequest) ([]GrantResponse, error)
+	GetBalance(ctx context.Context, customerID string) (*BalanceResponse, error)
+	ListTransactions(ctx context.Context, customerID string, req ListTransactionsRequest) ([]TransactionResponse, error)
+
+	// ExpireDue expires the remaining balance of grants past their expiry.
+	ExpireDue(ctx context.Context, limit int) error
+}
+
+var (
+	ErrInvalidOrganization  = errors.New("invalid_organization")
+	ErrInvalidCustomer      = errors.New("invalid_customer")
+	ErrInvalidID            = errors.New("invalid_id")
+	ErrInvalidName          = errors.New("invalid_name")
+	ErrInvalidGrantType     = errors.New("invalid_grant_type")
+	ErrInvalidAmount        = errors.New("invalid_amount")
+	ErrInvalidCurrency      = errors.New("invalid_currency")
+	ErrInvalidExpiry        = errors.New("invalid_expires_at")
+	ErrInvalidStatus        = errors.New("invalid_status")
+	ErrCustomerNotFound     = errors.New("customer_not_found")
+	ErrLedgerAccountMissing = errors.New("ledger_account_missing")
+)
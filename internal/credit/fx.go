@@ -0,0 +1,12 @@
+package credit
+
+import (
+	"github.com/smallbiznis/railzway/internal/credit/repository"
+	"github.com/smallbiznis/railzway/internal/credit/service"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("credit.service",
+	fx.Provide(repository.Provide),
+	fx.Provide(service.NewService),
+)
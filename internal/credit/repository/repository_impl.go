@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	creditdomain "github.com/smallbiznis/railzway/internal/credit/domain"
+	"gorm.io/gorm"
+)
+
+type repo struct{}
+
+func Provide() creditdomain.Repository {
+	return &repo{}
+}
+
+const grantColumns = `id, org_id, customer_id, name, type, amount, remaining_amount, currency,
+	priority, expires_at, status, expired_at, metadata, created_at, updated_at`
+
+const transactionColumns = `id, org_id, customer_id, grant_id, type, amount, currency,
+	invoice_id, description, created_at`
+
+func (r *repo) CustomerExists(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID) (bool, error) {
+	var count int64
+	err := db.WithContext(ctx).Raw(
+		`SELECT COUNT(1) FROM customers WHERE org_id = ? AND id = ?`,
+		orgID,
+		customerID,
+	).Scan(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *repo) InsertGrant(ctx context.Context, db *gorm.DB, grant *creditdomain.CreditGrant) error {
+	return db.WithContext(ctx).Exec(
+		`INSERT INTO credit_grants (`+grantColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		grant.ID,
+		grant.OrgID,
+		grant.CustomerID,
+		grant.Name,
+		grant.Type,
+		grant.Amount,
+		grant.RemainingAmount,
+		grant.Currency,
+		grant.Priority,
+		grant.ExpiresAt,
+		grant.Status,
+		grant.ExpiredAt,
+		grant.Metadata,
+		grant.CreatedAt,
+		grant.UpdatedAt,
+	).Error
+}
+
+func (r *repo) FindGrantForUpdate(ctx context.Context, db *gorm.DB, id snowflake.ID) (*creditdomain.CreditGrant, error) {
+	query := `SELECT ` + grantColumns + `
+		 FROM credit_grants
+		 WHERE id = ?`
+	if db.Dialector.Name() != "sqlite" {
+		query += " FOR UPDATE"
+	}
+
+	var grant creditdomain.CreditGrant
+	if err := db.WithContext(ctx).Raw(query, id).Scan(&grant).Error; err != nil {
+		return nil, err
+	}
+	if grant.ID == 0 {
+		return nil, nil
+	}
+	return &grant, nil
+}
+
+func (r *repo) UpdateGrant(ctx context.Context, db *gorm.DB, grant *creditdomain.CreditGrant) error {
+	return db.WithContext(ctx).Exec(
+		`UPDATE credit_grants
+		 SET remaining_amount = ?, status = ?, expired_at = ?, updated_at = ?
+		 WHERE org_id = ? AND id = ?`,
+		grant.RemainingAmount,
+		grant.Status,
+		grant.ExpiredAt,
+		grant.UpdatedAt,
+		grant.OrgID,
+		grant.ID,
+	).Error
+}
+
+func (r *repo) ListGrants(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, filter creditdomain.GrantFilter) ([]creditdomain.CreditGrant, error) {
+	query := `SELECT ` + grantColumns + `
+		 FROM credit_grants
+		 WHERE org_id = ? AND customer_id = ?`
+	args := []any{orgID, customerID}
+	if filter.Status != nil {
+		query += ` AND status = ?`
+		args = append(args, *filter.Status)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, filter.Limit)
+
+	var grants []creditdomain.CreditGrant
+	if err := db.WithContext(ctx).Raw(query, args...).Scan(&grants).Error; err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+func (r *repo) ListAvailable(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, now time.Time) ([]creditdomain.CreditGrant, error) {
+	var grants []creditdomain.CreditGrant
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+grantColumns+`
+		 FROM credit_grants
+		 WHERE org_id = ? AND customer_id = ? AND status = ? AND remaining_amount > 0
+		   AND (expires_at IS NULL OR expires_at > ?)
+		 ORDER BY currency ASC, id ASC`,
+		orgID,
+		customerID,
+		creditdomain.GrantStatusActive,
+		now,
+	).Scan(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+func (r *repo) ListDueExpiry(ctx context.Context, db *gorm.DB, now time.Time, limit int) ([]creditdomain.CreditGrant, error) {
+	var grants []creditdomain.CreditGrant
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+grantColumns+`
+		 FROM credit_grants
+		 WHERE status = ? AND expires_at IS NOT NULL AND expires_at <= ?
+		 ORDER BY expires_at ASC, id ASC
+		 LIMIT ?`,
+		creditdomain.GrantStatusActive,
+		now,
+		limit,
+	).Scan(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+func (r *repo) InsertTransaction(ctx context.Context, db *gorm.DB, txn *creditdomain.CreditTransaction) error {
+	return db.WithContext(ctx).Exec(
+		`INSERT INTO credit_transactions (`+transactionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		txn.ID,
+		txn.OrgID,
+		txn.CustomerID,
+		txn.GrantID,
+		txn.Type,
+		txn.Amount,
+		txn.Currency,
+		txn.InvoiceID,
+		txn.Description,
+		txn.CreatedAt,
+	).Error
+}
+
+func (r *repo) ListTransactions(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, filter creditdomain.TransactionFilter) ([]creditdomain.CreditTransaction, error) {
+	query := `SELECT ` + transactionColumns + `
+		 FROM credit_transactions
+		 WHERE org_id = ? AND customer_id = ?`
+	args := []any{orgID, customerID}
+	if filter.GrantID != nil {
+		query += ` AND grant_id = ?`
+		args = append(args, *filter.GrantID)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, filter.Limit)
+
+	var txns []creditdomain.CreditTransaction
+	if err := db.WithContext(ctx).Raw(query, args...).Scan(&txns).Error; err != nil {
+		return nil, err
+	}
+	return txns, nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	creditdomain "github.com/smallbiznis/railzway/internal/credit/domain"
+	"github.com/smallbiznis/railzway/internal/events"
+	ledgerdomain "github.com/smallbiznis/railzway/internal/ledger/domain"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// postGrantToLedger books a new grant as a credit balance liability.
+//
+// Double-entry logic:
+//
+//	Debit:  Adjustment (promotional credit) or Cash (prepaid credit)
+//	Credit: Credit Balance (liability increases)
+func (s *Service) postGrantToLedger(ctx context.Context, tx *gorm.DB, grant *creditdomain.CreditGrant) error {
+	funding := ledgerdomain.AccountCodeAdjustment
+	if grant.Type == creditdomain.GrantTypePrepaid {
+		funding = ledgerdomain.AccountCodeCash
+	}
+	return s.postEntry(ctx, tx, grant, ledgerdomain.SourceTypeCreditGrant, funding, ledgerdomain.AccountCodeCreditBalance, grant.Amount, grant.CreatedAt)
+}
+
+// postExpiryToLedger writes off the unused part of an expired grant.
+//
+// Double-entry logic:
+//
+//	Debit:  Credit Balance (liability decreases)
+//	Credit: Adjustment (promotional credit) or Revenue (prepaid breakage)
+func (s *Service) postExpiryToLedger(ctx context.Context, tx *gorm.DB, grant *creditdomain.CreditGrant, amount int64, occurredAt time.Time) error {
+	target := ledgerdomain.AccountCodeAdjustment
+	if grant.Type == creditdomain.GrantTypePrepaid {
+		target = ledgerdomain.AccountCodeRevenueUsage
+	}
+	return s.postEntry(ctx, tx, grant, ledgerdomain.SourceTypeCreditExpiry, ledgerdomain.AccountCodeCreditBalance, target, amount, occurredAt)
+}
+
+// postEntry inserts a two-line entry sourced from the grant. Each grant is
+// posted at most once per source type.
+func (s *Service) postEntry(
+	ctx context.Context,
+	tx *gorm.DB,
+	grant *creditdomain.CreditGrant,
+	sourceType ledgerdomain.LedgerSourceType,
+	debit ledgerdomain.LedgerAccountCode,
+	credit ledgerdomain.LedgerAccountCode,
+	amount int64,
+	occurredAt time.Time,
+) error {
+	accounts, err := s.loadLedgerAccounts(ctx, tx, grant.OrgID, []ledgerdomain.LedgerAccountCode{debit, credit})
+	if err != nil {
+		return fmt.Errorf("failed to load ledger accounts: %w", err)
+	}
+	debitAccount, ok := accounts[debit]
+	if !ok {
+		return creditdomain.ErrLedgerAccountMissing
+	}
+	creditAccount, ok := accounts[credit]
+	if !ok {
+		return creditdomain.ErrLedgerAccountMissing
+	}
+
+	lines := []ledgerdomain.LedgerEntryLine{
+		{
+			AccountID: debitAccount.ID,
+			Direction: ledgerdomain.LedgerEntryDirectionDebit,
+			Currency:  grant.Currency,
+			Amount:    amount,
+		},
+		{
+			AccountID: creditAccount.ID,
+			Direction: ledgerdomain.LedgerEntryDirectionCredit,
+			Currency:  grant.Currency,
+			Amount:    amount,
+		},
+	}
+	if err := ledgerdomain.ValidateBalanced(lines); err != nil {
+		return fmt.Errorf("ledger entry not balanced: %w", err)
+	}
+
+	entryID := s.genID.Generate()
+	now := time.Now().UTC()
+	result := tx.WithContext(ctx).Exec(
+		`INSERT INTO ledger_entries (
+			id, org_id, source_type, source_id, currency, occurred_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (org_id, source_type, source_id) DO NOTHING`,
+		entryID,
+		grant.OrgID,
+		string(sourceType),
+		grant.ID,
+		grant.Currency,
+		occurredAt,
+		now,
+	)
+	if result.Error != nil {
+		return fmt.Errorf("failed to insert ledger entry: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	for _, line := range lines {
+		if err := tx.WithContext(ctx).Exec(
+			`INSERT INTO ledger_entry_lines (
+				id, ledger_entry_id, account_id, direction, currency, amount, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			s.genID.Generate(),
+			entryID,
+			line.AccountID,
+			string(line.Direction),
+			line.Currency,
+			line.Amount,
+			now,
+		).Error; err != nil {
+			return fmt.Errorf("failed to insert ledger entry line: %w", err)
+		}
+	}
+
+	if s.outbox != nil {
+		if err := s.outbox.PublishTx(ctx, tx, events.Event{
+			OrgID: grant.OrgID,
+			Type:  events.EventLedgerEntryCreated,
+			Payload: map[string]any{
+				"ledger_entry_id": entryID.String(),
+				"source_type":     string(sourceType),
+				"source_id":       grant.ID.String(),
+			},
+			DedupeKey: "ledger_entry:" + entryID.String(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	s.log.Info("posted credit grant to ledger",
+		zap.String("credit_grant_id", grant.ID.String()),
+		zap.String("source_type", string(sourceType)),
+		zap.String("ledger_entry_id", entryID.String()),
+		zap.Int64("amount", amount),
+	)
+	return nil
+}
+
+func (s *Service) loadLedgerAccounts(ctx context.Context, tx *gorm.DB, orgID snowflake.ID, codes []ledgerdomain.LedgerAccountCode) (map[ledgerdomain.LedgerAccountCode]ledgerdomain.LedgerAccount, error) {
+	var accounts []ledgerdomain.LedgerAccount
+	if err := tx.WithContext(ctx).
+		Where("org_id = ? AND code IN ?", orgID, codes).
+		Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[ledgerdomain.LedgerAccountCode]ledgerdomain.LedgerAccount, len(accounts))
+	for _, acc := range accounts {
+		result[acc.Code] = acc
+	}
+	return result, nil
+}
@@ -0,0 +1,407 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	auditdomain "github.com/smallbiznis/railzway/internal/audit/domain"
+	"github.com/smallbiznis/railzway/internal/clock"
+	creditdomain "github.com/smallbiznis/railzway/internal/credit/domain"
+	"github.com/smallbiznis/railzway/internal/events"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+type Params struct {
+	fx.In
+
+	DB       *gorm.DB
+	Log      *zap.Logger
+	GenID    *snowflake.Node
+	Clock    clock.Clock
+	Repo     creditdomain.Repository
+	Outbox   *events.Outbox      `optional:"true"`
+	AuditSvc auditdomain.Service `optional:"true"`
+}
+
+type Service struct {
+	db       *gorm.DB
+	log      *zap.Logger
+	genID    *snowflake.Node
+	clock    clock.Clock
+	repo     creditdomain.Repository
+	outbox   *events.Outbox
+	auditSvc auditdomain.Service
+}
+
+func NewService(p Params) creditdomain.Service {
+	return &Service{
+		db:       p.DB,
+		log:      p.Log.Named("credit.service"),
+		genID:    p.GenID,
+		clock:    p.Clock,
+		repo:     p.Repo,
+		outbox:   p.Outbox,
+		auditSvc: p.AuditSvc,
+	}
+}
+
+// CreateGrant adds credit to a customer's wallet and books it against the
+// credit balance liability in the same transaction.
+func (s *Service) CreateGrant(ctx context.Context, customerID string, req creditdomain.CreateGrantRequest) (*creditdomain.GrantResponse, error) {
+	orgID, custID, err := s.resolveCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, creditdomain.ErrInvalidName
+	}
+	grantType := creditdomain.GrantType(strings.ToLower(strings.TrimSpace(req.Type)))
+	if grantType == "" {
+		grantType = creditdomain.GrantTypePromotional
+	}
+	if grantType != creditdomain.GrantTypePromotional && grantType != creditdomain.GrantTypePrepaid {
+		return nil, creditdomain.ErrInvalidGrantType
+	}
+	if req.Amount <= 0 {
+		return nil, creditdomain.ErrInvalidAmount
+	}
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if len(currency) != 3 {
+		return nil, creditdomain.ErrInvalidCurrency
+	}
+	now := s.clock.Now().UTC()
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		value := req.ExpiresAt.UTC()
+		if !value.After(now) {
+			return nil, creditdomain.ErrInvalidExpiry
+		}
+		expiresAt = &value
+	}
+	metadata := datatypes.JSONMap{}
+	for key, value := range req.Metadata {
+		metadata[key] = value
+	}
+
+	grant := &creditdomain.CreditGrant{
+		ID:              s.genID.Generate(),
+		OrgID:           orgID,
+		CustomerID:      custID,
+		Name:            name,
+		Type:            grantType,
+		Amount:          req.Amount,
+		RemainingAmount: req.Amount,
+		Currency:        currency,
+		Priority:        req.Priority,
+		ExpiresAt:       expiresAt,
+		Status:          creditdomain.GrantStatusActive,
+		Metadata:        metadata,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.InsertGrant(ctx, tx, grant); err != nil {
+			return err
+		}
+		if err := s.repo.InsertTransaction(ctx, tx, &creditdomain.CreditTransaction{
+			ID:          s.genID.Generate(),
+			OrgID:       orgID,
+			CustomerID:  custID,
+			GrantID:     grant.ID,
+			Type:        creditdomain.TransactionTypeGrant,
+			Amount:      grant.Amount,
+			Currency:    grant.Currency,
+			Description: grant.Name,
+			CreatedAt:   now,
+		}); err != nil {
+			return err
+		}
+		return s.postGrantToLedger(ctx, tx, grant)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitAudit(ctx, orgID, "credit_grant.create", grant.ID, map[string]any{
+		"customer_id": custID.String(),
+		"type":        string(grant.Type),
+		"amount":      grant.Amount,
+		"currency":    grant.Currency,
+	})
+	return toGrantResponse(grant), nil
+}
+
+func (s *Service) ListGrants(ctx context.Context, customerID string, req creditdomain.ListGrantsRequest) ([]creditdomain.GrantResponse, error) {
+	orgID, custID, err := s.resolveCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := creditdomain.GrantFilter{Limit: normalizeLimit(req.Limit)}
+	if value := strings.ToLower(strings.TrimSpace(req.Status)); value != "" {
+		status := creditdomain.GrantStatus(value)
+		switch status {
+		case creditdomain.GrantStatusPending, creditdomain.GrantStatusActive, creditdomain.GrantStatusDepleted, creditdomain.GrantStatusExpired:
+		default:
+			return nil, creditdomain.ErrInvalidStatus
+		}
+		filter.Status = &status
+	}
+
+	grants, err := s.repo.ListGrants(ctx, s.db, orgID, custID, filter)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]creditdomain.GrantResponse, 0, len(grants))
+	for i := range grants {
+		resp = append(resp, *toGrantResponse(&grants[i]))
+	}
+	return resp, nil
+}
+
+// GetBalance sums the unexpired remaining credit of the customer per currency.
+func (s *Service) GetBalance(ctx context.Context, customerID string) (*creditdomain.BalanceResponse, error) {
+	orgID, custID, err := s.resolveCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := s.repo.ListAvailable(ctx, s.db, orgID, custID, s.clock.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	byCurrency := make(map[string]*creditdomain.CurrencyBalance)
+	for _, grant := range grants {
+		balance, ok := byCurrency[grant.Currency]
+		if !ok {
+			balance = &creditdomain.CurrencyBalance{Currency: grant.Currency}
+			byCurrency[grant.Currency] = balance
+		}
+		balance.Available += grant.RemainingAmount
+		balance.ActiveGrants++
+		if grant.ExpiresAt != nil && (balance.NextExpiryAt == nil || grant.ExpiresAt.Before(*balance.NextExpiryAt)) {
+			expiresAt := *grant.ExpiresAt
+			balance.NextExpiryAt = &expiresAt
+		}
+	}
+
+	resp := &creditdomain.BalanceResponse{
+		CustomerID: custID.String(),
+		Balances:   make([]creditdomain.CurrencyBalance, 0, len(byCurrency)),
+	}
+	for _, balance := range byCurrency {
+		resp.Balances = append(resp.Balances, *balance)
+	}
+	sort.Slice(resp.Balances, func(i, j int) bool {
+		return resp.Balances[i].Currency < resp.Balances[j].Currency
+	})
+	return resp, nil
+}
+
+func (s *Service) ListTransactions(ctx context.Context, customerID string, req creditdomain.ListTransactionsRequest) ([]creditdomain.TransactionResponse, error) {
+	orgID, custID, err := s.resolveCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := creditdomain.TransactionFilter{Limit: normalizeLimit(req.Limit)}
+	if value := strings.TrimSpace(req.GrantID); value != "" {
+		id, err := parseID(value, creditdomain.ErrInvalidID)
+		if err != nil {
+			return nil, err
+		}
+		filter.GrantID = &id
+	}
+
+	txns, err := s.repo.ListTransactions(ctx, s.db, orgID, custID, filter)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]creditdomain.TransactionResponse, 0, len(txns))
+	for i := range txns {
+		resp = append(resp, toTransactionResponse(&txns[i]))
+	}
+	return resp, nil
+}
+
+// ExpireDue expires grants whose expiry has passed. Each grant is expired in
+// its own transaction; the remaining balance is written off in the ledger and
+// recorded as an expiry transaction.
+func (s *Service) ExpireDue(ctx context.Context, limit int) error {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	now := s.clock.Now().UTC()
+	grants, err := s.repo.ListDueExpiry(ctx, s.db, now, limit)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range grants {
+		if err := s.expireGrant(ctx, grants[i].ID, now); err != nil {
+			errs = append(errs, err)
+			s.log.Warn("failed to expire credit grant", zap.String("credit_grant_id", grants[i].ID.String()), zap.Error(err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Service) expireGrant(ctx context.Context, grantID snowflake.ID, now time.Time) error {
+	var expired *creditdomain.CreditGrant
+	var remaining int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		grant, err := s.repo.FindGrantForUpdate(ctx, tx, grantID)
+		if err != nil {
+			return err
+		}
+		// Drawn down or already expired by a concurrent run.
+		if grant == nil || grant.Status != creditdomain.GrantStatusActive {
+			return nil
+		}
+
+		remaining = grant.RemainingAmount
+		grant.RemainingAmount = 0
+		grant.Status = creditdomain.GrantStatusExpired
+		grant.ExpiredAt = &now
+		grant.UpdatedAt = now
+		if err := s.repo.UpdateGrant(ctx, tx, grant); err != nil {
+			return err
+		}
+		if remaining > 0 {
+			if err := s.repo.InsertTransaction(ctx, tx, &creditdomain.CreditTransaction{
+				ID:          s.genID.Generate(),
+				OrgID:       grant.OrgID,
+				CustomerID:  grant.CustomerID,
+				GrantID:     grant.ID,
+				Type:        creditdomain.TransactionTypeExpiry,
+				Amount:      -remaining,
+				Currency:    grant.Currency,
+				Description: grant.Name,
+				CreatedAt:   now,
+			}); err != nil {
+				return err
+			}
+			if err := s.postExpiryToLedger(ctx, tx, grant, remaining, now); err != nil {
+				return err
+			}
+		}
+		expired = grant
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if expired != nil {
+		s.emitAudit(ctx, expired.OrgID, "credit_grant.expire", expired.ID, map[string]any{
+			"customer_id":    expired.CustomerID.String(),
+			"expired_amount": remaining,
+			"currency":       expired.Currency,
+		})
+	}
+	return nil
+}
+
+func (s *Service) resolveCustomer(ctx context.Context, customerID string) (snowflake.ID, snowflake.ID, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return 0, 0, creditdomain.ErrInvalidOrganization
+	}
+	custID, err := parseID(customerID, creditdomain.ErrInvalidCustomer)
+	if err != nil {
+		return 0, 0, err
+	}
+	exists, err := s.repo.CustomerExists(ctx, s.db, orgID, custID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !exists {
+		return 0, 0, creditdomain.ErrCustomerNotFound
+	}
+	return orgID, custID, nil
+}
+
+func (s *Service) emitAudit(ctx context.Context, orgID snowflake.ID, action string, targetID snowflake.ID, metadata map[string]any) {
+	if s.auditSvc == nil {
+		return
+	}
+	target := targetID.String()
+	_ = s.auditSvc.AuditLog(ctx, &orgID, "", nil, action, "credit_grant", &target, metadata)
+}
+
+func normalizeLimit(limit int) int {
+	if limit <= 0 {
+		return defaultListLimit
+	}
+	if limit > maxListLimit {
+		return maxListLimit
+	}
+	return limit
+}
+
+func parseID(value string, invalidErr error) (snowflake.ID, error) {
+	id, err := snowflake.ParseString(strings.TrimSpace(value))
+	if err != nil || id == 0 {
+		return 0, invalidErr
+	}
+	return id, nil
+}
+
+func toGrantResponse(grant *creditdomain.CreditGrant) *creditdomain.GrantResponse {
+	resp := &creditdomain.GrantResponse{
+		ID:              grant.ID.String(),
+		OrgID:           grant.OrgID.String(),
+		CustomerID:      grant.CustomerID.String(),
+		Name:            grant.Name,
+		Type:            string(grant.Type),
+		Amount:          grant.Amount,
+		RemainingAmount: grant.RemainingAmount,
+		Currency:        grant.Currency,
+		Priority:        grant.Priority,
+		ExpiresAt:       grant.ExpiresAt,
+		Status:          string(grant.Status),
+		ExpiredAt:       grant.ExpiredAt,
+		CreatedAt:       grant.CreatedAt,
+		UpdatedAt:       grant.UpdatedAt,
+	}
+	if len(grant.Metadata) > 0 {
+		resp.Metadata = map[string]any(grant.Metadata)
+	}
+	return resp
+}
+
+func toTransactionResponse(txn *creditdomain.CreditTransaction) creditdomain.TransactionResponse {
+	resp := creditdomain.TransactionResponse{
+		ID:          txn.ID.String(),
+		CustomerID:  txn.CustomerID.String(),
+		GrantID:     txn.GrantID.String(),
+		Type:        string(txn.Type),
+		Amount:      txn.Amount,
+		Currency:    txn.Currency,
+		Description: txn.Description,
+		CreatedAt:   txn.CreatedAt,
+	}
+	if txn.InvoiceID != nil {
+		id := txn.InvoiceID.String()
+		resp.InvoiceID = &id
+	}
+	return resp
+}
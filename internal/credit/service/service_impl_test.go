@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/glebarez/sqlite"
+	"github.com/smallbiznis/railzway/internal/clock"
+	creditdomain "github.com/smallbiznis/railzway/internal/credit/domain"
+	"github.com/smallbiznis/railzway/internal/credit/repository"
+	customerdomain "github.com/smallbiznis/railzway/internal/customer/domain"
+	ledgerdomain "github.com/smallbiznis/railzway/internal/ledger/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type creditFixture struct {
+	db         *gorm.DB
+	clock      *clock.FakeClock
+	svc        creditdomain.Service
+	ctx        context.Context
+	customerID snowflake.ID
+	accounts   map[ledgerdomain.LedgerAccountCode]snowflake.ID
+}
+
+func setupCreditTest(t *testing.T) *creditFixture {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&customerdomain.Customer{},
+		&creditdomain.CreditGrant{},
+		&creditdomain.CreditTransaction{},
+		&ledgerdomain.LedgerAccount{},
+		&ledgerdomain.LedgerEntry{},
+		&ledgerdomain.LedgerEntryLine{},
+	))
+	require.NoError(t, db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS ux_ledger_entries_source ON ledger_entries(org_id, source_type, source_id)").Error)
+	db.Exec("DROP INDEX IF EXISTS ux_ledger_accounts_org_type")
+
+	node, _ := snowflake.NewNode(1)
+	orgID := node.Generate()
+	f := &creditFixture{
+		db:         db,
+		clock:      clock.NewFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)),
+		customerID: node.Generate(),
+		accounts:   make(map[ledgerdomain.LedgerAccountCode]snowflake.ID),
+	}
+	f.ctx = orgcontext.WithOrgID(context.Background(), int64(orgID))
+	f.svc = NewService(Params{
+		DB:    db,
+		Log:   zap.NewNop(),
+		GenID: node,
+		Clock: f.clock,
+		Repo:  repository.Provide(),
+	})
+
+	require.NoError(t, db.Create(&customerdomain.Customer{ID: f.customerID, OrgID: orgID, Name: "Acme", Email: "ap@acme.test"}).Error)
+	for _, code := range []ledgerdomain.LedgerAccountCode{
+		ledgerdomain.AccountCodeCash,
+		ledgerdomain.AccountCodeAdjustment,
+		ledgerdomain.AccountCodeCreditBalance,
+		ledgerdomain.AccountCodeRevenueUsage,
+	} {
+		id := node.Generate()
+		require.NoError(t, db.Create(&ledgerdomain.LedgerAccount{ID: id, OrgID: orgID, Code: code, Name: string(code), Type: ledgerdomain.Liability}).Error)
+		f.accounts[code] = id
+	}
+	return f
+}
+
+// accountBalance returns credits minus debits posted to the account.
+func (f *creditFixture) accountBalance(t *testing.T, code ledgerdomain.LedgerAccountCode) int64 {
+	var balance int64
+	require.NoError(t, f.db.Raw(
+		`SELECT COALESCE(SUM(CASE direction WHEN 'credit' THEN amount ELSE -amount END), 0)
+		 FROM ledger_entry_lines WHERE account_id = ?`,
+		f.accounts[code],
+	).Scan(&balance).Error)
+	return balance
+}
+
+func TestCreateGrantValidation(t *testing.T) {
+	f := setupCreditTest(t)
+	customerID := f.customerID.String()
+
+	_, err := f.svc.CreateGrant(f.ctx, customerID, creditdomain.CreateGrantRequest{Name: "Promo", Amount: 0, Currency: "USD"})
+	assert.ErrorIs(t, err, creditdomain.ErrInvalidAmount)
+	_, err = f.svc.CreateGrant(f.ctx, customerID, creditdomain.CreateGrantRequest{Name: "Promo", Amount: 100, Currency: "US"})
+	assert.ErrorIs(t, err, creditdomain.ErrInvalidCurrency)
+	_, err = f.svc.CreateGrant(f.ctx, customerID, creditdomain.CreateGrantRequest{Name: "Promo", Type: "gift", Amount: 100, Currency: "USD"})
+	assert.ErrorIs(t, err, creditdomain.ErrInvalidGrantType)
+	past := f.clock.Now().Add(-time.Hour)
+	_, err = f.svc.CreateGrant(f.ctx, customerID, creditdomain.CreateGrantRequest{Name: "Promo", Amount: 100, Currency: "USD", ExpiresAt: &past})
+	assert.ErrorIs(t, err, creditdomain.ErrInvalidExpiry)
+	_, err = f.svc.CreateGrant(f.ctx, "1", creditdomain.CreateGrantRequest{Name: "Promo", Amount: 100, Currency: "USD"})
+	assert.ErrorIs(t, err, creditdomain.ErrCustomerNotFound)
+}
+
+func TestGrantBalanceAndExpiry(t *testing.T) {
+	f := setupCreditTest(t)
+	customerID := f.customerID.String()
+	expiresAt := f.clock.Now().AddDate(0, 1, 0)
+
+	promo, err := f.svc.CreateGrant(f.ctx, customerID, creditdomain.CreateGrantRequest{
+		Name: "Goodwill", Amount: 2000, Currency: "usd", ExpiresAt: &expiresAt,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "promotional", promo.Type)
+	assert.Equal(t, "USD", promo.Currency)
+
+	_, err = f.svc.CreateGrant(f.ctx, customerID, creditdomain.CreateGrantRequest{
+		Name: "Prepaid pack", Type: "prepaid", Amount: 10000, Currency: "USD", Priority: 1,
+	})
+	require.NoError(t, err)
+
+	balance, err := f.svc.GetBalance(f.ctx, customerID)
+	require.NoError(t, err)
+	require.Len(t, balance.Balances, 1)
+	assert.Equal(t, int64(12000), balance.Balances[0].Available)
+	assert.Equal(t, 2, balance.Balances[0].ActiveGrants)
+	require.NotNil(t, balance.Balances[0].NextExpiryAt)
+	assert.True(t, expiresAt.Equal(*balance.Balances[0].NextExpiryAt))
+
+	assert.Equal(t, int64(12000), f.accountBalance(t, ledgerdomain.AccountCodeCreditBalance))
+	assert.Equal(t, int64(-2000), f.accountBalance(t, ledgerdomain.AccountCodeAdjustment))
+	assert.Equal(t, int64(-10000), f.accountBalance(t, ledgerdomain.AccountCodeCash))
+
+	// Not yet due.
+	require.NoError(t, f.svc.ExpireDue(context.Background(), 10))
+	grants, err := f.svc.ListGrants(f.ctx, customerID, creditdomain.ListGrantsRequest{Status: "expired"})
+	require.NoError(t, err)
+	assert.Empty(t, grants)
+
+	f.clock.Advance(31 * 24 * time.Hour)
+	require.NoError(t, f.svc.ExpireDue(context.Background(), 10))
+	require.NoError(t, f.svc.ExpireDue(context.Background(), 10))
+
+	grants, err = f.svc.ListGrants(f.ctx, customerID, creditdomain.ListGrantsRequest{Status: "expired"})
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	assert.Equal(t, promo.ID, grants[0].ID)
+	assert.Equal(t, int64(0), grants[0].RemainingAmount)
+
+	balance, err = f.svc.GetBalance(f.ctx, customerID)
+	require.NoError(t, err)
+	require.Len(t, balance.Balances, 1)
+	assert.Equal(t, int64(10000), balance.Balances[0].Available)
+	assert.Nil(t, balance.Balances[0].NextExpiryAt)
+
+	assert.Equal(t, int64(10000), f.accountBalance(t, ledgerdomain.AccountCodeCreditBalance))
+	assert.Equal(t, int64(0), f.accountBalance(t, ledgerdomain.AccountCodeAdjustment))
+
+	txns, err := f.svc.ListTransactions(f.ctx, customerID, creditdomain.ListTransactionsRequest{GrantID: promo.ID})
+	require.NoError(t, err)
+	require.Len(t, txns, 2)
+	assert.Equal(t, "expiry", txns[0].Type)
+	assert.Equal(t, int64(-2000), txns[0].Amount)
+	assert.Equal(t, "grant", txns[1].Type)
+}
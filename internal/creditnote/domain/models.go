@@ -0,0 +1,96 @@
+// Package domain contains persistence models for credit notes.
+package domain
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/datatypes"
+)
+
+// CreditNoteStatus represents credit note lifecycle states.
+type CreditNoteStatus string
+
+const (
+	CreditNoteStatusIssued CreditNoteStatus = "ISSUED"
+)
+
+// CreditNoteReason explains why an invoice was credited.
+type CreditNoteReason string
+
+const (
+	ReasonDuplicate             CreditNoteReason = "duplicate"
+	ReasonFraudulent            CreditNoteReason = "fraudulent"
+	ReasonOrderChange           CreditNoteReason = "order_change"
+	ReasonProductUnsatisfactory CreditNoteReason = "product_unsatisfactory"
+	ReasonBillingError          CreditNoteReason = "billing_error"
+	ReasonOther                 CreditNoteReason = "other"
+)
+
+// CreditNoteDestination is where the credited amount is posted in the ledger.
+type CreditNoteDestination string
+
+const (
+	// DestinationReceivable reduces the outstanding balance of an unpaid invoice.
+	DestinationReceivable CreditNoteDestination = "accounts_receivable"
+	// DestinationCreditBalance keeps the amount as customer credit.
+	DestinationCreditBalance CreditNoteDestination = "credit_balance"
+)
+
+// CreditNote is an immutable correction issued against a finalized invoice.
+type CreditNote struct {
+	ID               snowflake.ID          `gorm:"primaryKey"`
+	OrgID            snowflake.ID          `gorm:"not null;index;uniqueIndex:ux_credit_note_seq,priority:1"`
+	InvoiceID        snowflake.ID          `gorm:"not null;index"`
+	CustomerID       snowflake.ID          `gorm:"not null;index"`
+	CreditNoteSeq    int64                 `gorm:"not null;uniqueIndex:ux_credit_note_seq,priority:2"`
+	CreditNoteNumber string                `gorm:"type:text;not null"`
+	Status           CreditNoteStatus      `gorm:"type:text;not null;default:'ISSUED'"`
+	Reason           CreditNoteReason      `gorm:"type:text;not null"`
+	Memo             string                `gorm:"type:text"`
+	Destination      CreditNoteDestination `gorm:"type:text;not null"`
+	SubtotalAmount   int64                 `gorm:"not null;default:0"`
+	TaxAmount        int64                 `gorm:"not null;default:0"`
+	TotalAmount      int64                 `gorm:"not null;default:0"`
+	Currency         string                `gorm:"type:text;not null"`
+	IssuedAt         time.Time             `gorm:"not null"`
+	RenderedHTML     *string               `gorm:"column:rendered_html;type:text"`
+	Metadata         datatypes.JSONMap     `gorm:"type:jsonb;not null;default:'{}'"`
+	CreatedAt        time.Time             `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt        time.Time             `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName sets the database table name.
+func (CreditNote) TableName() string { return "credit_notes" }
+
+// CreditNoteItem credits part or all of a single invoice item.
+type CreditNoteItem struct {
+	ID            snowflake.ID `gorm:"primaryKey"`
+	OrgID         snowflake.ID `gorm:"not null;index"`
+	CreditNoteID  snowflake.ID `gorm:"not null;index"`
+	InvoiceItemID snowflake.ID `gorm:"not null;index"`
+	Description   string       `gorm:"type:text"`
+	Quantity      float64      `gorm:"not null"`
+	UnitPrice     int64        `gorm:"not null"`
+	Amount        int64        `gorm:"not null"`
+	CreatedAt     time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName sets the database table name.
+func (CreditNoteItem) TableName() string { return "credit_note_items" }
+
+// CreditNoteSequence tracks the next credit note number per organization.
+type CreditNoteSequence struct {
+	OrgID      snowflake.ID `gorm:"primaryKey"`
+	NextNumber int64        `gorm:"not null;default:1"`
+	UpdatedAt  time.Time    `gorm:"not null"`
+}
+
+// TableName sets the database table name.
+func (CreditNoteSequence) TableName() string { return "credit_note_sequences" }
+
+// InvoiceItemCredit is the amount already credited against an invoice item.
+type InvoiceItemCredit struct {
+	InvoiceItemID snowflake.ID
+	Amount        int64
+}
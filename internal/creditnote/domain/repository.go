@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/gorm"
+)
+
+// ListFilter narrows credit note listings.
+type ListFilter struct {
+	InvoiceID  *snowflake.ID
+	CustomerID *snowflake.ID
+}
+
+type Repository interface {
+	Insert(ctx context.Context, db *gorm.DB, note *CreditNote) error
+	InsertItem(ctx context.Context, db *gorm.DB, item *CreditNoteItem) error
+	FindByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*CreditNote, error)
+	List(ctx context.Context, db *gorm.DB, orgID snowflake.ID, filter ListFilter) ([]CreditNote, error)
+	ListItems(ctx context.Context, db *gorm.DB, orgID, creditNoteID snowflake.ID) ([]CreditNoteItem, error)
+	ListCreditedByInvoice(ctx context.Context, db *gorm.DB, orgID, invoiceID snowflake.ID) ([]InvoiceItemCredit, error)
+	SumTaxByInvoice(ctx context.Context, db *gorm.DB, orgID, invoiceID snowflake.ID) (int64, error)
+	NextNumber(ctx context.Context, db *gorm.DB, orgID snowflake.ID) (int64, error)
+}
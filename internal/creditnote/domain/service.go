@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+type ListRequest struct {
+	InvoiceID  string `form:"invoice_id"`
+	CustomerID string `form:"customer_id"`
+}
+
+// CreateItemRequest credits a single invoice item. A nil Amount credits
+// everything not yet credited on that item.
+type CreateItemRequest struct {
+	InvoiceItemID string `json:"invoice_item_id"`
+	Amount        *int64 `json:"amount,omitempty"`
+}
+
+// CreateRequest issues a credit note against a finalized invoice.
+// Leaving Items empty credits the full remaining invoice amount.
+type CreateRequest struct {
+	InvoiceID   string              `json:"invoice_id"`
+	Reason      string              `json:"reason"`
+	Memo        string              `json:"memo,omitempty"`
+	Destination string              `json:"destination,omitempty"`
+	Items       []CreateItemRequest `json:"items,omitempty"`
+}
+
+type ItemResponse struct {
+	ID            string  `json:"id"`
+	InvoiceItemID string  `json:"invoice_item_id"`
+	Description   string  `json:"description"`
+	Quantity      float64 `json:"quantity"`
+	UnitPrice     int64   `json:"unit_price"`
+	Amount        int64   `json:"amount"`
+}
+
+type Response struct {
+	ID               string         `json:"id"`
+	OrgID            string         `json:"organization_id"`
+	InvoiceID        string         `json:"invoice_id"`
+	CustomerID       string         `json:"customer_id"`
+	CreditNoteNumber string         `json:"credit_note_number"`
+	Status           string         `json:"status"`
+	Reason           string         `json:"reason"`
+	Memo             string         `json:"memo,omitempty"`
+	Destination      string         `json:"destination"`
+	SubtotalAmount   int64          `json:"subtotal_amount"`
+	TaxAmount        int64          `json:"tax_amount"`
+	TotalAmount      int64          `json:"total_amount"`
+	Currency         string         `json:"currency"`
+	IssuedAt         time.Time      `json:"issued_at"`
+	Items            []ItemResponse `json:"items,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+}
+
+type RenderResponse struct {
+	RenderedHTML string `json:"rendered_html"`
+}
+
+type Service interface {
+	Create(ctx context.Context, req CreateRequest) (*Response, error)
+	List(ctx context.Context, req ListRequest) ([]Response, error)
+	GetByID(ctx context.Context, id string) (*Response, error)
+	Render(ctx context.Context, id string) (*RenderResponse, error)
+	RenderPDF(ctx context.Context, id string) ([]byte, string, error)
+}
+
+func ParseID(raw string) (snowflake.ID, error) {
+	return snowflake.ParseString(strings.TrimSpace(raw))
+}
+
+// ParseReason validates a credit note reason code.
+func ParseReason(value string) (CreditNoteReason, bool) {
+	reason := CreditNoteReason(strings.ToLower(strings.TrimSpace(value)))
+	switch reason {
+	case ReasonDuplicate,
+		ReasonFraudulent,
+		ReasonOrderChange,
+		ReasonProductUnsatisfactory,
+		ReasonBillingError,
+		ReasonOther:
+		return reason, true
+	default:
+		return "", false
+	}
+}
+
+var (
+	ErrInvalidOrganization  = errors.New("invalid_organization")
+	ErrInvalidID            = errors.New("invalid_id")
+	ErrInvalidInvoiceID     = errors.New("invalid_invoice_id")
+	ErrInvalidCustomerID    = errors.New("invalid_customer_id")
+	ErrInvalidReason        = errors.New("invalid_reason")
+	ErrInvalidDestination   = errors.New("invalid_destination")
+	ErrInvalidItems         = errors.New("invalid_items")
+	ErrInvalidAmount        = errors.New("invalid_amount")
+	ErrInvoiceNotFound      = errors.New("invoice_not_found")
+	ErrInvoiceNotFinalized  = errors.New("invoice_not_finalized")
+	ErrAmountExceedsInvoice = errors.New("credit_amount_exceeds_invoice")
+	ErrNothingToCredit      = errors.New("nothing_to_credit")
+	ErrLedgerAccountMissing = errors.New("ledger_account_missing")
+	ErrRenderUnavailable    = errors.New("credit_note_render_unavailable")
+	ErrNotFound             = errors.New("not_found")
+)
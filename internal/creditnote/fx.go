@@ -0,0 +1,12 @@
+package creditnote
+
+import (
+	"github.com/smallbiznis/railzway/internal/creditnote/repository"
+	"github.com/smallbiznis/railzway/internal/creditnote/service"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("creditnote.service",
+	fx.Provide(repository.Provide),
+	fx.Provide(service.NewService),
+)
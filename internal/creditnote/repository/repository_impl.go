@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bwmarrin/snowflake"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
+	"gorm.io/gorm"
+)
+
+type repo struct{}
+
+func Provide() creditnotedomain.Repository {
+	return &repo{}
+}
+
+func (r *repo) Insert(ctx context.Context, db *gorm.DB, note *creditnotedomain.CreditNote) error {
+	return db.WithContext(ctx).Exec(
+		`INSERT INTO credit_notes (
+			id, org_id, invoice_id, customer_id, credit_note_seq, credit_note_number,
+			status, reason, memo, destination, subtotal_amount, tax_amount, total_amount,
+			currency, issued_at, rendered_html, metadata, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		note.ID,
+		note.OrgID,
+		note.InvoiceID,
+		note.CustomerID,
+		note.CreditNoteSeq,
+		note.CreditNoteNumber,
+		note.Status,
+		note.Reason,
+		note.Memo,
+		note.Destination,
+		note.SubtotalAmount,
+		note.TaxAmount,
+		note.TotalAmount,
+		note.Currency,
+		note.IssuedAt,
+		note.RenderedHTML,
+		note.Metadata,
+		note.CreatedAt,
+		note.UpdatedAt,
+	).Error
+}
+
+func (r *repo) InsertItem(ctx context.Context, db *gorm.DB, item *creditnotedomain.CreditNoteItem) error {
+	return db.WithContext(ctx).Exec(
+		`INSERT INTO credit_note_items (
+			id, org_id, credit_note_id, invoice_item_id, description, quantity, unit_price, amount, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		item.ID,
+		item.OrgID,
+		item.CreditNoteID,
+		item.InvoiceItemID,
+		item.Description,
+		item.Quantity,
+		item.UnitPrice,
+		item.Amount,
+		item.CreatedAt,
+	).Error
+}
+
+func (r *repo) FindByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*creditnotedomain.CreditNote, error) {
+	var note creditnotedomain.CreditNote
+	err := db.WithContext(ctx).Raw(
+		`SELECT id, org_id, invoice_id, customer_id, credit_note_seq, credit_note_number,
+		        status, reason, memo, destination, subtotal_amount, tax_amount, total_amount,
+		        currency, issued_at, rendered_html, metadata, created_at, updated_at
+		 FROM credit_notes
+		 WHERE org_id = ? AND id = ?`,
+		orgID,
+		id,
+	).Scan(&note).Error
+	if err != nil {
+		return nil, err
+	}
+	if note.ID == 0 {
+		return nil, nil
+	}
+	return &note, nil
+}
+
+func (r *repo) List(ctx context.Context, db *gorm.DB, orgID snowflake.ID, filter creditnotedomain.ListFilter) ([]creditnotedomain.CreditNote, error) {
+	var items []creditnotedomain.CreditNote
+	stmt := db.WithContext(ctx).Model(&creditnotedomain.CreditNote{}).Where("org_id = ?", orgID)
+
+	if filter.InvoiceID != nil {
+		stmt = stmt.Where("invoice_id = ?", *filter.InvoiceID)
+	}
+	if filter.CustomerID != nil {
+		stmt = stmt.Where("customer_id = ?", *filter.CustomerID)
+	}
+
+	stmt = stmt.Order("credit_note_seq DESC")
+
+	if err := stmt.Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *repo) ListItems(ctx context.Context, db *gorm.DB, orgID, creditNoteID snowflake.ID) ([]creditnotedomain.CreditNoteItem, error) {
+	var items []creditnotedomain.CreditNoteItem
+	err := db.WithContext(ctx).Raw(
+		`SELECT id, org_id, credit_note_id, invoice_item_id, description, quantity, unit_price, amount, created_at
+		 FROM credit_note_items
+		 WHERE org_id = ? AND credit_note_id = ?
+		 ORDER BY id ASC`,
+		orgID,
+		creditNoteID,
+	).Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *repo) ListCreditedByInvoice(ctx context.Context, db *gorm.DB, orgID, invoiceID snowflake.ID) ([]creditnotedomain.InvoiceItemCredit, error) {
+	var rows []creditnotedomain.InvoiceItemCredit
+	err := db.WithContext(ctx).Raw(
+		`SELECT i.invoice_item_id, SUM(i.amount) AS amount
+		 FROM credit_note_items i
+		 JOIN credit_notes n ON n.id = i.credit_note_id
+		 WHERE n.org_id = ? AND n.invoice_id = ? AND n.status = ?
+		 GROUP BY i.invoice_item_id`,
+		orgID,
+		invoiceID,
+		creditnotedomain.CreditNoteStatusIssued,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *repo) SumTaxByInvoice(ctx context.Context, db *gorm.DB, orgID, invoiceID snowflake.ID) (int64, error) {
+	var total int64
+	err := db.WithContext(ctx).Raw(
+		`SELECT COALESCE(SUM(tax_amount), 0)
+		 FROM credit_notes
+		 WHERE org_id = ? AND invoice_id = ? AND status = ?`,
+		orgID,
+		invoiceID,
+		creditnotedomain.CreditNoteStatusIssued,
+	).Scan(&total).Error
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// NextNumber allocates the next credit note sequence for the organization.
+// The sequence row is created lazily on first use.
+func (r *repo) NextNumber(ctx context.Context, db *gorm.DB, orgID snowflake.ID) (int64, error) {
+	var next int64
+	err := db.WithContext(ctx).Raw(
+		`INSERT INTO credit_note_sequences (org_id, next_number, updated_at)
+		 VALUES (?, 2, CURRENT_TIMESTAMP)
+		 ON CONFLICT (org_id) DO UPDATE
+		 SET next_number = credit_note_sequences.next_number + 1,
+		     updated_at = CURRENT_TIMESTAMP
+		 RETURNING next_number - 1`,
+		orgID,
+	).Scan(&next).Error
+	return next, err
+}
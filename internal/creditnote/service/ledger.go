@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
+	"github.com/smallbiznis/railzway/internal/events"
+	ledgerdomain "github.com/smallbiznis/railzway/internal/ledger/domain"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// postCreditNoteToLedger reverses the credited part of the invoice posting
+// inside the credit note transaction.
+//
+// Double-entry logic:
+//
+//	Debit:  Revenue (income decreases)
+//	Debit:  Tax Payable (liability decreases, if tax > 0)
+//	Credit: Accounts Receivable (unpaid invoice) or Credit Balance (paid invoice)
+func (s *Service) postCreditNoteToLedger(ctx context.Context, tx *gorm.DB, note *creditnotedomain.CreditNote) error {
+	target := ledgerdomain.AccountCodeAccountsReceivable
+	if note.Destination == creditnotedomain.DestinationCreditBalance {
+		target = ledgerdomain.AccountCodeCreditBalance
+	}
+
+	accounts, err := s.loadLedgerAccounts(ctx, tx, note.OrgID, []ledgerdomain.LedgerAccountCode{
+		ledgerdomain.AccountCodeRevenueUsage,
+		ledgerdomain.AccountCodeTaxPayable,
+		target,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load ledger accounts: %w", err)
+	}
+
+	revenueAccount, ok := accounts[ledgerdomain.AccountCodeRevenueUsage]
+	if !ok {
+		return creditnotedomain.ErrLedgerAccountMissing
+	}
+	targetAccount, ok := accounts[target]
+	if !ok {
+		return creditnotedomain.ErrLedgerAccountMissing
+	}
+
+	lines := []ledgerdomain.LedgerEntryLine{
+		{
+			AccountID: revenueAccount.ID,
+			Direction: ledgerdomain.LedgerEntryDirectionDebit,
+			Currency:  note.Currency,
+			Amount:    note.SubtotalAmount,
+		},
+		{
+			AccountID: targetAccount.ID,
+			Direction: ledgerdomain.LedgerEntryDirectionCredit,
+			Currency:  note.Currency,
+			Amount:    note.TotalAmount,
+		},
+	}
+	if note.TaxAmount > 0 {
+		taxAccount, ok := accounts[ledgerdomain.AccountCodeTaxPayable]
+		if !ok {
+			return creditnotedomain.ErrLedgerAccountMissing
+		}
+		lines = append(lines, ledgerdomain.LedgerEntryLine{
+			AccountID: taxAccount.ID,
+			Direction: ledgerdomain.LedgerEntryDirectionDebit,
+			Currency:  note.Currency,
+			Amount:    note.TaxAmount,
+		})
+	}
+
+	if err := ledgerdomain.ValidateBalanced(lines); err != nil {
+		return fmt.Errorf("ledger entry not balanced: %w", err)
+	}
+
+	entryID := s.genID.Generate()
+	now := time.Now().UTC()
+	result := tx.WithContext(ctx).Exec(
+		`INSERT INTO ledger_entries (
+			id, org_id, source_type, source_id, currency, occurred_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (org_id, source_type, source_id) DO NOTHING`,
+		entryID,
+		note.OrgID,
+		string(ledgerdomain.SourceTypeCreditNote),
+		note.ID,
+		note.Currency,
+		note.IssuedAt,
+		now,
+	)
+	if result.Error != nil {
+		return fmt.Errorf("failed to insert ledger entry: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	for _, line := range lines {
+		if err := tx.WithContext(ctx).Exec(
+			`INSERT INTO ledger_entry_lines (
+				id, ledger_entry_id, account_id, direction, currency, amount, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			s.genID.Generate(),
+			entryID,
+			line.AccountID,
+			string(line.Direction),
+			line.Currency,
+			line.Amount,
+			now,
+		).Error; err != nil {
+			return fmt.Errorf("failed to insert ledger entry line: %w", err)
+		}
+	}
+
+	if s.outbox != nil {
+		if err := s.outbox.PublishTx(ctx, tx, events.Event{
+			OrgID: note.OrgID,
+			Type:  events.EventLedgerEntryCreated,
+			Payload: map[string]any{
+				"ledger_entry_id": entryID.String(),
+				"source_type":     string(ledgerdomain.SourceTypeCreditNote),
+				"source_id":       note.ID.String(),
+			},
+			DedupeKey: "ledger_entry:" + entryID.String(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	s.log.Info("posted credit note to ledger",
+		zap.String("credit_note_id", note.ID.String()),
+		zap.String("ledger_entry_id", entryID.String()),
+		zap.Int64("total_amount", note.TotalAmount),
+	)
+	return nil
+}
+
+func (s *Service) loadLedgerAccounts(ctx context.Context, tx *gorm.DB, orgID snowflake.ID, codes []ledgerdomain.LedgerAccountCode) (map[ledgerdomain.LedgerAccountCode]ledgerdomain.LedgerAccount, error) {
+	var accounts []ledgerdomain.LedgerAccount
+	if err := tx.WithContext(ctx).
+		Where("org_id = ? AND code IN ?", orgID, codes).
+		Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[ledgerdomain.LedgerAccountCode]ledgerdomain.LedgerAccount, len(accounts))
+	for _, acc := range accounts {
+		result[acc.Code] = acc
+	}
+	return result, nil
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bwmarrin/snowflake"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
+	customerdomain "github.com/smallbiznis/railzway/internal/customer/domain"
+	"github.com/smallbiznis/railzway/internal/invoice/render"
+	templatedomain "github.com/smallbiznis/railzway/internal/invoicetemplate/domain"
+	"github.com/smallbiznis/railzway/internal/money"
+	"github.com/smallbiznis/railzway/internal/providers/pdf"
+	"gorm.io/gorm"
+)
+
+// Render returns the HTML snapshot captured when the credit note was issued.
+func (s *Service) Render(ctx context.Context, id string) (*creditnotedomain.RenderResponse, error) {
+	note, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if note.RenderedHTML != nil {
+		return &creditnotedomain.RenderResponse{RenderedHTML: *note.RenderedHTML}, nil
+	}
+
+	items, err := s.repo.ListItems(ctx, s.db, note.OrgID, note.ID)
+	if err != nil {
+		return nil, err
+	}
+	invoice, err := s.loadInvoice(ctx, s.db, note.OrgID, note.InvoiceID, false)
+	if err != nil {
+		return nil, err
+	}
+	html, err := s.renderCreditNoteHTML(ctx, s.db, note, items, invoice)
+	if err != nil {
+		return nil, err
+	}
+	if html == "" {
+		return nil, creditnotedomain.ErrRenderUnavailable
+	}
+	return &creditnotedomain.RenderResponse{RenderedHTML: html}, nil
+}
+
+// RenderPDF generates a PDF document for the credit note and returns it with
+// a suggested file name.
+func (s *Service) RenderPDF(ctx context.Context, id string) ([]byte, string, error) {
+	note, err := s.load(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if s.pdfProvider == nil {
+		return nil, "", creditnotedomain.ErrRenderUnavailable
+	}
+
+	items, err := s.repo.ListItems(ctx, s.db, note.OrgID, note.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	invoice, err := s.loadInvoice(ctx, s.db, note.OrgID, note.InvoiceID, false)
+	if err != nil {
+		return nil, "", err
+	}
+	customer, err := s.loadCustomer(ctx, s.db, note.OrgID, note.CustomerID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var orgName string
+	if err := s.db.WithContext(ctx).Raw(
+		`SELECT name FROM organizations WHERE id = ?`,
+		note.OrgID,
+	).Scan(&orgName).Error; err != nil {
+		return nil, "", err
+	}
+
+	data := pdf.CreditNoteData{
+		OrgName:          orgName,
+		CreditNoteNumber: note.CreditNoteNumber,
+		IssueDate:        note.IssuedAt.Format("January 2, 2006"),
+		Reason:           string(note.Reason),
+		Memo:             note.Memo,
+		CustomerName:     customer.Name,
+		CustomerEmail:    customer.Email,
+		Subtotal:         formatAmount(note.SubtotalAmount, note.Currency),
+		Total:            formatAmount(note.TotalAmount, note.Currency),
+	}
+	if invoice != nil {
+		data.InvoiceNumber = invoice.InvoiceNumber
+	}
+	if note.TaxAmount > 0 {
+		data.Tax = formatAmount(note.TaxAmount, note.Currency)
+	}
+	for _, item := range items {
+		data.Items = append(data.Items, pdf.InvoiceItem{
+			Description: item.Description,
+			Qty:         int(item.Quantity),
+			UnitPrice:   formatAmount(item.UnitPrice, note.Currency),
+			Amount:      formatAmount(item.Amount, note.Currency),
+		})
+	}
+
+	reader, err := s.pdfProvider.GenerateCreditNote(ctx, data)
+	if err != nil {
+		return nil, "", err
+	}
+	if reader == nil {
+		return nil, "", creditnotedomain.ErrRenderUnavailable
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, reader); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), fmt.Sprintf("credit-note-%s.pdf", note.CreditNoteNumber), nil
+}
+
+// renderCreditNoteHTML renders the credit note with the template of the
+// original invoice. It returns an empty string when no renderer is configured.
+func (s *Service) renderCreditNoteHTML(
+	ctx context.Context,
+	db *gorm.DB,
+	note *creditnotedomain.CreditNote,
+	items []creditnotedomain.CreditNoteItem,
+	invoice *invoiceRow,
+) (string, error) {
+	if s.renderer == nil || note == nil {
+		return "", nil
+	}
+
+	input := render.CreditNoteRenderInput{
+		CreditNote: render.CreditNoteView{
+			ID:             note.ID.String(),
+			Number:         note.CreditNoteNumber,
+			Reason:         string(note.Reason),
+			Memo:           note.Memo,
+			IssuedAt:       &note.IssuedAt,
+			SubtotalAmount: note.SubtotalAmount,
+			TaxAmount:      note.TaxAmount,
+			TotalAmount:    note.TotalAmount,
+			Currency:       note.Currency,
+		},
+	}
+
+	var templateID *snowflake.ID
+	if invoice != nil {
+		templateID = invoice.InvoiceTemplateID
+		input.Invoice = render.InvoiceView{
+			ID:             invoice.ID.String(),
+			Number:         invoice.InvoiceNumber,
+			Status:         string(invoice.Status),
+			IssuedAt:       invoice.IssuedAt,
+			SubtotalAmount: invoice.SubtotalAmount,
+			Currency:       invoice.Currency,
+		}
+	}
+
+	tmpl, err := s.resolveTemplate(ctx, db, note.OrgID, templateID)
+	if err != nil {
+		return "", err
+	}
+	input.Template = buildTemplateView(tmpl)
+
+	customer, err := s.loadCustomer(ctx, db, note.OrgID, note.CustomerID)
+	if err != nil {
+		return "", err
+	}
+	input.Customer = render.CustomerView{Name: customer.Name, Email: customer.Email}
+
+	for _, item := range items {
+		input.Items = append(input.Items, render.LineItemView{
+			Title:     item.Description,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Amount:    item.Amount,
+		})
+	}
+
+	return s.renderer.RenderCreditNoteHTML(input)
+}
+
+// resolveTemplate prefers the invoice's template and falls back to the
+// organization default. A missing template renders with defaults.
+func (s *Service) resolveTemplate(ctx context.Context, db *gorm.DB, orgID snowflake.ID, templateID *snowflake.ID) (*templatedomain.InvoiceTemplate, error) {
+	if s.templateRepo == nil {
+		return nil, nil
+	}
+	if templateID != nil && *templateID != 0 {
+		tmpl, err := s.templateRepo.FindByID(ctx, db, orgID, *templateID)
+		if err != nil || tmpl != nil {
+			return tmpl, err
+		}
+	}
+	return s.templateRepo.FindDefault(ctx, db, orgID)
+}
+
+type customerRow struct {
+	ID    snowflake.ID
+	Name  string
+	Email string
+}
+
+func (s *Service) loadCustomer(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID) (*customerRow, error) {
+	var customer customerRow
+	err := db.WithContext(ctx).Raw(
+		`SELECT id, name, email
+		 FROM customers
+		 WHERE org_id = ? AND id = ?`,
+		orgID,
+		customerID,
+	).Scan(&customer).Error
+	if err != nil {
+		return nil, err
+	}
+	if customer.ID == 0 {
+		return nil, customerdomain.ErrNotFound
+	}
+	return &customer, nil
+}
+
+func buildTemplateView(tmpl *templatedomain.InvoiceTemplate) render.TemplateView {
+	if tmpl == nil {
+		return render.TemplateView{}
+	}
+	return render.TemplateView{
+		Name:         tmpl.Name,
+		Locale:       tmpl.Locale,
+		Currency:     tmpl.Currency,
+		LogoURL:      templateValue(map[string]any(tmpl.Header), "logo_url"),
+		CompanyName:  templateValue(map[string]any(tmpl.Header), "company_name"),
+		FooterNotes:  templateValue(map[string]any(tmpl.Footer), "notes"),
+		FooterLegal:  templateValue(map[string]any(tmpl.Footer), "legal"),
+		PrimaryColor: templateValue(map[string]any(tmpl.Style), "primary_color"),
+		FontFamily:   templateValue(map[string]any(tmpl.Style), "font"),
+	}
+}
+
+func templateValue(data map[string]any, key string) string {
+	value, ok := data[key].(string)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
+func formatAmount(amount int64, currency string) string {
+	return money.Format(amount, currency)
+}
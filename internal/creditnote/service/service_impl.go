@@ -0,0 +1,510 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	auditdomain "github.com/smallbiznis/railzway/internal/audit/domain"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
+	"github.com/smallbiznis/railzway/internal/events"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	invoiceformat "github.com/smallbiznis/railzway/internal/invoice/format"
+	"github.com/smallbiznis/railzway/internal/invoice/render"
+	templatedomain "github.com/smallbiznis/railzway/internal/invoicetemplate/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	"github.com/smallbiznis/railzway/internal/providers/pdf"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type Params struct {
+	fx.In
+
+	DB           *gorm.DB
+	Log          *zap.Logger
+	GenID        *snowflake.Node
+	Repo         creditnotedomain.Repository
+	AuditSvc     auditdomain.Service       `optional:"true"`
+	TemplateRepo templatedomain.Repository `optional:"true"`
+	Renderer     render.Renderer           `optional:"true"`
+	PDFProvider  pdf.Provider              `optional:"true"`
+	Outbox       *events.Outbox            `optional:"true"`
+}
+
+type Service struct {
+	db           *gorm.DB
+	log          *zap.Logger
+	genID        *snowflake.Node
+	repo         creditnotedomain.Repository
+	auditSvc     auditdomain.Service
+	templateRepo templatedomain.Repository
+	renderer     render.Renderer
+	pdfProvider  pdf.Provider
+	outbox       *events.Outbox
+}
+
+func NewService(p Params) creditnotedomain.Service {
+	return &Service{
+		db:           p.DB,
+		log:          p.Log.Named("creditnote.service"),
+		genID:        p.GenID,
+		repo:         p.Repo,
+		auditSvc:     p.AuditSvc,
+		templateRepo: p.TemplateRepo,
+		renderer:     p.Renderer,
+		pdfProvider:  p.PDFProvider,
+		outbox:       p.Outbox,
+	}
+}
+
+type invoiceRow struct {
+	ID                snowflake.ID
+	OrgID             snowflake.ID
+	CustomerID        snowflake.ID
+	InvoiceNumber     string
+	InvoiceTemplateID *snowflake.ID
+	Status            invoicedomain.InvoiceStatus
+	SubtotalAmount    int64
+	TaxAmount         int64
+	TotalAmount       int64
+	Currency          string
+	IssuedAt          *time.Time
+	PaidAt            *time.Time
+}
+
+func (s *Service) Create(ctx context.Context, req creditnotedomain.CreateRequest) (*creditnotedomain.Response, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, creditnotedomain.ErrInvalidOrganization
+	}
+
+	invoiceID, err := creditnotedomain.ParseID(req.InvoiceID)
+	if err != nil {
+		return nil, creditnotedomain.ErrInvalidInvoiceID
+	}
+
+	reason, ok := creditnotedomain.ParseReason(req.Reason)
+	if !ok {
+		return nil, creditnotedomain.ErrInvalidReason
+	}
+
+	destination, err := parseDestination(req.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		note  *creditnotedomain.CreditNote
+		items []creditnotedomain.CreditNoteItem
+	)
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		invoice, err := s.loadInvoice(ctx, tx, orgID, invoiceID, true)
+		if err != nil {
+			return err
+		}
+		if invoice == nil {
+			return creditnotedomain.ErrInvoiceNotFound
+		}
+		if invoice.Status != invoicedomain.InvoiceStatusFinalized {
+			return creditnotedomain.ErrInvoiceNotFinalized
+		}
+
+		invoiceItems, err := s.listInvoiceItems(ctx, tx, orgID, invoiceID)
+		if err != nil {
+			return err
+		}
+		credits, err := s.repo.ListCreditedByInvoice(ctx, tx, orgID, invoiceID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		noteID := s.genID.Generate()
+		items, err = s.buildItems(orgID, noteID, now, req.Items, invoiceItems, credits)
+		if err != nil {
+			return err
+		}
+
+		var subtotal, previouslyCredited int64
+		for _, item := range items {
+			subtotal += item.Amount
+		}
+		for _, credit := range credits {
+			previouslyCredited += credit.Amount
+		}
+		// Discounted invoices bill less than the sum of their charges.
+		if previouslyCredited+subtotal > invoice.SubtotalAmount {
+			return creditnotedomain.ErrAmountExceedsInvoice
+		}
+
+		creditedTax, err := s.repo.SumTaxByInvoice(ctx, tx, orgID, invoiceID)
+		if err != nil {
+			return err
+		}
+		tax := creditNoteTax(invoice, subtotal, previouslyCredited, creditedTax)
+
+		if destination == "" {
+			destination = creditnotedomain.DestinationReceivable
+			if invoice.PaidAt != nil {
+				destination = creditnotedomain.DestinationCreditBalance
+			}
+		}
+
+		seq, err := s.repo.NextNumber(ctx, tx, orgID)
+		if err != nil {
+			return err
+		}
+		number, err := invoiceformat.FormatInvoiceNumber(invoiceformat.DefaultCreditNoteNumberTemplate, now, seq)
+		if err != nil {
+			return err
+		}
+
+		note = &creditnotedomain.CreditNote{
+			ID:               noteID,
+			OrgID:            orgID,
+			InvoiceID:        invoice.ID,
+			CustomerID:       invoice.CustomerID,
+			CreditNoteSeq:    seq,
+			CreditNoteNumber: number,
+			Status:           creditnotedomain.CreditNoteStatusIssued,
+			Reason:           reason,
+			Memo:             strings.TrimSpace(req.Memo),
+			Destination:      destination,
+			SubtotalAmount:   subtotal,
+			TaxAmount:        tax,
+			TotalAmount:      subtotal + tax,
+			Currency:         invoice.Currency,
+			IssuedAt:         now,
+			Metadata:         datatypes.JSONMap{"invoice_number": invoice.InvoiceNumber},
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
+
+		html, err := s.renderCreditNoteHTML(ctx, tx, note, items, invoice)
+		if err != nil {
+			return err
+		}
+		if html != "" {
+			note.RenderedHTML = &html
+		}
+
+		if err := s.repo.Insert(ctx, tx, note); err != nil {
+			return err
+		}
+		for i := range items {
+			if err := s.repo.InsertItem(ctx, tx, &items[i]); err != nil {
+				return err
+			}
+		}
+
+		if err := s.postCreditNoteToLedger(ctx, tx, note); err != nil {
+			return err
+		}
+
+		if s.outbox != nil {
+			if err := s.outbox.PublishTx(ctx, tx, events.Event{
+				OrgID: orgID,
+				Type:  events.EventCreditNoteIssued,
+				Payload: map[string]any{
+					"credit_note_id": note.ID.String(),
+					"invoice_id":     invoice.ID.String(),
+					"customer_id":    invoice.CustomerID.String(),
+					"total_amount":   note.TotalAmount,
+					"currency":       note.Currency,
+				},
+				DedupeKey: "credit_note_issued:" + note.ID.String(),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitAudit(ctx, "credit_note.issued", note)
+	return toResponse(note, items), nil
+}
+
+func (s *Service) List(ctx context.Context, req creditnotedomain.ListRequest) ([]creditnotedomain.Response, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, creditnotedomain.ErrInvalidOrganization
+	}
+
+	var filter creditnotedomain.ListFilter
+	if strings.TrimSpace(req.InvoiceID) != "" {
+		id, err := creditnotedomain.ParseID(req.InvoiceID)
+		if err != nil {
+			return nil, creditnotedomain.ErrInvalidInvoiceID
+		}
+		filter.InvoiceID = &id
+	}
+	if strings.TrimSpace(req.CustomerID) != "" {
+		id, err := creditnotedomain.ParseID(req.CustomerID)
+		if err != nil {
+			return nil, creditnotedomain.ErrInvalidCustomerID
+		}
+		filter.CustomerID = &id
+	}
+
+	notes, err := s.repo.List(ctx, s.db, orgID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]creditnotedomain.Response, 0, len(notes))
+	for i := range notes {
+		resp = append(resp, *toResponse(&notes[i], nil))
+	}
+	return resp, nil
+}
+
+func (s *Service) GetByID(ctx context.Context, id string) (*creditnotedomain.Response, error) {
+	note, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.ListItems(ctx, s.db, note.OrgID, note.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(note, items), nil
+}
+
+func (s *Service) load(ctx context.Context, id string) (*creditnotedomain.CreditNote, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, creditnotedomain.ErrInvalidOrganization
+	}
+
+	noteID, err := creditnotedomain.ParseID(id)
+	if err != nil {
+		return nil, creditnotedomain.ErrInvalidID
+	}
+
+	note, err := s.repo.FindByID(ctx, s.db, orgID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if note == nil {
+		return nil, creditnotedomain.ErrNotFound
+	}
+	return note, nil
+}
+
+// buildItems resolves the requested credit lines against the invoice.
+// An empty request credits whatever is left on every invoice item, including
+// discount lines.
+func (s *Service) buildItems(
+	orgID, noteID snowflake.ID,
+	now time.Time,
+	requested []creditnotedomain.CreateItemRequest,
+	invoiceItems []invoicedomain.InvoiceItem,
+	credits []creditnotedomain.InvoiceItemCredit,
+) ([]creditnotedomain.CreditNoteItem, error) {
+	credited := make(map[snowflake.ID]int64, len(credits))
+	for _, credit := range credits {
+		credited[credit.InvoiceItemID] += credit.Amount
+	}
+	byID := make(map[snowflake.ID]invoicedomain.InvoiceItem, len(invoiceItems))
+	for _, item := range invoiceItems {
+		byID[item.ID] = item
+	}
+
+	newItem := func(source invoicedomain.InvoiceItem, amount int64) creditnotedomain.CreditNoteItem {
+		quantity := source.Quantity
+		if amount != source.Amount && source.UnitPrice > 0 {
+			quantity = float64(amount) / float64(source.UnitPrice)
+		}
+		return creditnotedomain.CreditNoteItem{
+			ID:            s.genID.Generate(),
+			OrgID:         orgID,
+			CreditNoteID:  noteID,
+			InvoiceItemID: source.ID,
+			Description:   source.Description,
+			Quantity:      quantity,
+			UnitPrice:     source.UnitPrice,
+			Amount:        amount,
+			CreatedAt:     now,
+		}
+	}
+
+	items := make([]creditnotedomain.CreditNoteItem, 0, len(invoiceItems))
+	if len(requested) == 0 {
+		var total int64
+		for _, source := range invoiceItems {
+			remaining := source.Amount - credited[source.ID]
+			// Discount lines are negative; crediting them alongside the
+			// charges keeps a full credit equal to what was actually billed.
+			if remaining == 0 || (source.Amount > 0 && remaining < 0) {
+				continue
+			}
+			items = append(items, newItem(source, remaining))
+			total += remaining
+		}
+		if len(items) == 0 || total <= 0 {
+			return nil, creditnotedomain.ErrNothingToCredit
+		}
+		return items, nil
+	}
+
+	seen := make(map[snowflake.ID]struct{}, len(requested))
+	for _, line := range requested {
+		itemID, err := creditnotedomain.ParseID(line.InvoiceItemID)
+		if err != nil {
+			return nil, creditnotedomain.ErrInvalidItems
+		}
+		source, ok := byID[itemID]
+		if !ok || source.Amount <= 0 {
+			return nil, creditnotedomain.ErrInvalidItems
+		}
+		if _, dup := seen[itemID]; dup {
+			return nil, creditnotedomain.ErrInvalidItems
+		}
+		seen[itemID] = struct{}{}
+
+		remaining := source.Amount - credited[itemID]
+		amount := remaining
+		if line.Amount != nil {
+			amount = *line.Amount
+			if amount <= 0 {
+				return nil, creditnotedomain.ErrInvalidAmount
+			}
+		}
+		if remaining <= 0 {
+			return nil, creditnotedomain.ErrNothingToCredit
+		}
+		if amount > remaining {
+			return nil, creditnotedomain.ErrAmountExceedsInvoice
+		}
+		items = append(items, newItem(source, amount))
+	}
+	return items, nil
+}
+
+// creditNoteTax prorates the invoice tax over the credited subtotal. The
+// credit note that brings the invoice to fully credited takes whatever tax is
+// left so rounding never leaves a residue.
+func creditNoteTax(invoice *invoiceRow, subtotal, previouslyCredited, creditedTax int64) int64 {
+	remainingTax := invoice.TaxAmount - creditedTax
+	if remainingTax <= 0 || invoice.SubtotalAmount <= 0 {
+		return 0
+	}
+	if previouslyCredited+subtotal >= invoice.SubtotalAmount {
+		return remainingTax
+	}
+	tax := int64(math.Round(float64(subtotal) * float64(invoice.TaxAmount) / float64(invoice.SubtotalAmount)))
+	if tax > remainingTax {
+		tax = remainingTax
+	}
+	return tax
+}
+
+func parseDestination(value string) (creditnotedomain.CreditNoteDestination, error) {
+	switch creditnotedomain.CreditNoteDestination(strings.ToLower(strings.TrimSpace(value))) {
+	case "":
+		return "", nil
+	case creditnotedomain.DestinationReceivable:
+		return creditnotedomain.DestinationReceivable, nil
+	case creditnotedomain.DestinationCreditBalance:
+		return creditnotedomain.DestinationCreditBalance, nil
+	default:
+		return "", creditnotedomain.ErrInvalidDestination
+	}
+}
+
+func (s *Service) loadInvoice(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID, forUpdate bool) (*invoiceRow, error) {
+	var invoice invoiceRow
+	query := `SELECT id, org_id, customer_id, invoice_number, invoice_template_id, status,
+		        subtotal_amount, tax_amount, total_amount, currency, issued_at, paid_at
+		 FROM invoices
+		 WHERE org_id = ? AND id = ?`
+	if forUpdate && !strings.EqualFold(db.Dialector.Name(), "sqlite") {
+		query += " FOR UPDATE"
+	}
+
+	if err := db.WithContext(ctx).Raw(query, orgID, id).Scan(&invoice).Error; err != nil {
+		return nil, err
+	}
+	if invoice.ID == 0 {
+		return nil, nil
+	}
+	return &invoice, nil
+}
+
+func (s *Service) listInvoiceItems(ctx context.Context, db *gorm.DB, orgID, invoiceID snowflake.ID) ([]invoicedomain.InvoiceItem, error) {
+	var items []invoicedomain.InvoiceItem
+	err := db.WithContext(ctx).Raw(
+		`SELECT id, org_id, invoice_id, line_type, description, quantity, unit_price, amount, created_at
+		 FROM invoice_items
+		 WHERE org_id = ? AND invoice_id = ?
+		 ORDER BY created_at ASC, id ASC`,
+		orgID,
+		invoiceID,
+	).Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *Service) emitAudit(ctx context.Context, action string, note *creditnotedomain.CreditNote) {
+	if s.auditSvc == nil || note == nil {
+		return
+	}
+	metadata := map[string]any{
+		"invoice_id":         note.InvoiceID.String(),
+		"customer_id":        note.CustomerID.String(),
+		"credit_note_number": note.CreditNoteNumber,
+		"reason":             string(note.Reason),
+		"destination":        string(note.Destination),
+		"total_amount":       note.TotalAmount,
+		"currency":           note.Currency,
+	}
+
+	targetID := note.ID.String()
+	orgID := note.OrgID
+	_ = s.auditSvc.AuditLog(ctx, &orgID, "", nil, action, "credit_note", &targetID, metadata)
+}
+
+func toResponse(note *creditnotedomain.CreditNote, items []creditnotedomain.CreditNoteItem) *creditnotedomain.Response {
+	if note == nil {
+		return nil
+	}
+	resp := &creditnotedomain.Response{
+		ID:               note.ID.String(),
+		OrgID:            note.OrgID.String(),
+		InvoiceID:        note.InvoiceID.String(),
+		CustomerID:       note.CustomerID.String(),
+		CreditNoteNumber: note.CreditNoteNumber,
+		Status:           string(note.Status),
+		Reason:           string(note.Reason),
+		Memo:             note.Memo,
+		Destination:      string(note.Destination),
+		SubtotalAmount:   note.SubtotalAmount,
+		TaxAmount:        note.TaxAmount,
+		TotalAmount:      note.TotalAmount,
+		Currency:         note.Currency,
+		IssuedAt:         note.IssuedAt,
+		CreatedAt:        note.CreatedAt,
+	}
+	for _, item := range items {
+		resp.Items = append(resp.Items, creditnotedomain.ItemResponse{
+			ID:            item.ID.String(),
+			InvoiceItemID: item.InvoiceItemID.String(),
+			Description:   item.Description,
+			Quantity:      item.Quantity,
+			UnitPrice:     item.UnitPrice,
+			Amount:        item.Amount,
+		})
+	}
+	return resp
+}
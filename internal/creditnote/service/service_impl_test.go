@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/glebarez/sqlite"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
+	"github.com/smallbiznis/railzway/internal/creditnote/repository"
+	customerdomain "github.com/smallbiznis/railzway/internal/customer/domain"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	"github.com/smallbiznis/railzway/internal/invoice/render"
+	ledgerdomain "github.com/smallbiznis/railzway/internal/ledger/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type creditNoteFixture struct {
+	db        *gorm.DB
+	svc       creditnotedomain.Service
+	ctx       context.Context
+	orgID     snowflake.ID
+	invoiceID snowflake.ID
+	itemIDs   []snowflake.ID
+	accounts  map[ledgerdomain.LedgerAccountCode]snowflake.ID
+}
+
+func setupCreditNoteTest(t *testing.T, paid bool) *creditNoteFixture {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&invoicedomain.Invoice{},
+		&invoicedomain.InvoiceItem{},
+		&customerdomain.Customer{},
+		&creditnotedomain.CreditNote{},
+		&creditnotedomain.CreditNoteItem{},
+		&creditnotedomain.CreditNoteSequence{},
+		&ledgerdomain.LedgerAccount{},
+		&ledgerdomain.LedgerEntry{},
+		&ledgerdomain.LedgerEntryLine{},
+	))
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS ux_ledger_entries_source ON ledger_entries(org_id, source_type, source_id)")
+	db.Exec("DROP INDEX IF EXISTS ux_ledger_accounts_org_type")
+
+	node, _ := snowflake.NewNode(1)
+	f := &creditNoteFixture{
+		db:        db,
+		orgID:     node.Generate(),
+		invoiceID: node.Generate(),
+		accounts:  map[ledgerdomain.LedgerAccountCode]snowflake.ID{},
+	}
+	f.ctx = orgcontext.WithOrgID(context.Background(), int64(f.orgID))
+	f.svc = NewService(Params{
+		DB:       db,
+		Log:      zap.NewNop(),
+		GenID:    node,
+		Repo:     repository.Provide(),
+		Renderer: render.NewRenderer(),
+	})
+
+	for code, typ := range map[ledgerdomain.LedgerAccountCode]ledgerdomain.LedgerAccountType{
+		ledgerdomain.AccountCodeAccountsReceivable: ledgerdomain.Assets,
+		ledgerdomain.AccountCodeRevenueUsage:       ledgerdomain.Income,
+		ledgerdomain.AccountCodeTaxPayable:         ledgerdomain.Liability,
+		ledgerdomain.AccountCodeCreditBalance:      ledgerdomain.Liability,
+	} {
+		id := node.Generate()
+		f.accounts[code] = id
+		require.NoError(t, db.Create(&ledgerdomain.LedgerAccount{ID: id, OrgID: f.orgID, Code: code, Type: typ, Name: string(code)}).Error)
+	}
+
+	customerID := node.Generate()
+	require.NoError(t, db.Create(&customerdomain.Customer{ID: customerID, OrgID: f.orgID, Name: "Acme", Email: "billing@acme.test"}).Error)
+
+	now := time.Now().UTC()
+	invoice := &invoicedomain.Invoice{
+		ID:             f.invoiceID,
+		OrgID:          f.orgID,
+		InvoiceNumber:  "INV-20260301-000001",
+		BillingCycleID: node.Generate(),
+		SubscriptionID: node.Generate(),
+		CustomerID:     customerID,
+		Status:         invoicedomain.InvoiceStatusFinalized,
+		SubtotalAmount: 10000,
+		TaxAmount:      1000,
+		TotalAmount:    11000,
+		Currency:       "USD",
+		IssuedAt:       &now,
+		FinalizedAt:    &now,
+	}
+	if paid {
+		invoice.PaidAt = &now
+	}
+	require.NoError(t, db.Create(invoice).Error)
+
+	for i, amount := range []int64{7000, 3000} {
+		id := node.Generate()
+		f.itemIDs = append(f.itemIDs, id)
+		require.NoError(t, db.Create(&invoicedomain.InvoiceItem{
+			ID:          id,
+			OrgID:       f.orgID,
+			InvoiceID:   f.invoiceID,
+			Description: []string{"API calls", "Seats"}[i],
+			Quantity:    float64(amount / 100),
+			UnitPrice:   100,
+			Amount:      amount,
+			CreatedAt:   now.Add(time.Duration(i) * time.Second),
+		}).Error)
+	}
+	return f
+}
+
+func (f *creditNoteFixture) ledgerAmounts(t *testing.T, noteID string) map[snowflake.ID]int64 {
+	id, err := snowflake.ParseString(noteID)
+	require.NoError(t, err)
+
+	var entry ledgerdomain.LedgerEntry
+	require.NoError(t, f.db.First(&entry, "source_type = ? AND source_id = ?", ledgerdomain.SourceTypeCreditNote, id).Error)
+
+	var lines []ledgerdomain.LedgerEntryLine
+	require.NoError(t, f.db.Find(&lines, "ledger_entry_id = ?", entry.ID).Error)
+
+	amounts := make(map[snowflake.ID]int64, len(lines))
+	for _, line := range lines {
+		if line.Direction == ledgerdomain.LedgerEntryDirectionDebit {
+			amounts[line.AccountID] += line.Amount
+		} else {
+			amounts[line.AccountID] -= line.Amount
+		}
+	}
+	return amounts
+}
+
+func TestCreateCreditNote_PartialThenFull(t *testing.T) {
+	f := setupCreditNoteTest(t, false)
+
+	partial := int64(1500)
+	first, err := f.svc.Create(f.ctx, creditnotedomain.CreateRequest{
+		InvoiceID: f.invoiceID.String(),
+		Reason:    "product_unsatisfactory",
+		Items: []creditnotedomain.CreateItemRequest{
+			{InvoiceItemID: f.itemIDs[1].String(), Amount: &partial},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), first.SubtotalAmount)
+	assert.Equal(t, int64(150), first.TaxAmount)
+	assert.Equal(t, int64(1650), first.TotalAmount)
+	assert.Equal(t, string(creditnotedomain.DestinationReceivable), first.Destination)
+	assert.Regexp(t, `^CN-\d{8}-000001$`, first.CreditNoteNumber)
+	require.Len(t, first.Items, 1)
+	assert.Equal(t, 15.0, first.Items[0].Quantity)
+
+	amounts := f.ledgerAmounts(t, first.ID)
+	assert.Equal(t, int64(1500), amounts[f.accounts[ledgerdomain.AccountCodeRevenueUsage]])
+	assert.Equal(t, int64(150), amounts[f.accounts[ledgerdomain.AccountCodeTaxPayable]])
+	assert.Equal(t, int64(-1650), amounts[f.accounts[ledgerdomain.AccountCodeAccountsReceivable]])
+
+	// Crediting more than what is left on the item is rejected.
+	tooMuch := int64(1501)
+	_, err = f.svc.Create(f.ctx, creditnotedomain.CreateRequest{
+		InvoiceID: f.invoiceID.String(),
+		Reason:    "billing_error",
+		Items: []creditnotedomain.CreateItemRequest{
+			{InvoiceItemID: f.itemIDs[1].String(), Amount: &tooMuch},
+		},
+	})
+	assert.ErrorIs(t, err, creditnotedomain.ErrAmountExceedsInvoice)
+
+	// A full credit note takes the remainder, including the residual tax.
+	rest, err := f.svc.Create(f.ctx, creditnotedomain.CreateRequest{
+		InvoiceID: f.invoiceID.String(),
+		Reason:    "duplicate",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(8500), rest.SubtotalAmount)
+	assert.Equal(t, int64(850), rest.TaxAmount)
+	assert.Regexp(t, `^CN-\d{8}-000002$`, rest.CreditNoteNumber)
+	assert.Len(t, rest.Items, 2)
+
+	_, err = f.svc.Create(f.ctx, creditnotedomain.CreateRequest{
+		InvoiceID: f.invoiceID.String(),
+		Reason:    "duplicate",
+	})
+	assert.ErrorIs(t, err, creditnotedomain.ErrNothingToCredit)
+
+	list, err := f.svc.List(f.ctx, creditnotedomain.ListRequest{InvoiceID: f.invoiceID.String()})
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	rendered, err := f.svc.Render(f.ctx, first.ID)
+	require.NoError(t, err)
+	assert.Contains(t, rendered.RenderedHTML, first.CreditNoteNumber)
+	assert.Contains(t, rendered.RenderedHTML, "INV-20260301-000001")
+}
+
+func TestCreateCreditNote_PaidInvoiceMovesToCreditBalance(t *testing.T) {
+	f := setupCreditNoteTest(t, true)
+
+	note, err := f.svc.Create(f.ctx, creditnotedomain.CreateRequest{
+		InvoiceID: f.invoiceID.String(),
+		Reason:    "order_change",
+		Items: []creditnotedomain.CreateItemRequest{
+			{InvoiceItemID: f.itemIDs[0].String()},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, string(creditnotedomain.DestinationCreditBalance), note.Destination)
+	assert.Equal(t, int64(7700), note.TotalAmount)
+
+	amounts := f.ledgerAmounts(t, note.ID)
+	assert.Equal(t, int64(-7700), amounts[f.accounts[ledgerdomain.AccountCodeCreditBalance]])
+	assert.Zero(t, amounts[f.accounts[ledgerdomain.AccountCodeAccountsReceivable]])
+}
+
+func TestCreateCreditNote_Validation(t *testing.T) {
+	f := setupCreditNoteTest(t, false)
+
+	_, err := f.svc.Create(f.ctx, creditnotedomain.CreateRequest{InvoiceID: f.invoiceID.String(), Reason: "because"})
+	assert.ErrorIs(t, err, creditnotedomain.ErrInvalidReason)
+
+	_, err = f.svc.Create(f.ctx, creditnotedomain.CreateRequest{
+		InvoiceID:   f.invoiceID.String(),
+		Reason:      "other",
+		Destination: "cash",
+	})
+	assert.ErrorIs(t, err, creditnotedomain.ErrInvalidDestination)
+
+	_, err = f.svc.Create(f.ctx, creditnotedomain.CreateRequest{
+		InvoiceID: f.invoiceID.String(),
+		Reason:    "other",
+		Items:     []creditnotedomain.CreateItemRequest{{InvoiceItemID: "123"}},
+	})
+	assert.ErrorIs(t, err, creditnotedomain.ErrInvalidItems)
+
+	require.NoError(t, f.db.Model(&invoicedomain.Invoice{}).Where("id = ?", f.invoiceID).Update("status", invoicedomain.InvoiceStatusVoid).Error)
+	_, err = f.svc.Create(f.ctx, creditnotedomain.CreateRequest{InvoiceID: f.invoiceID.String(), Reason: "other"})
+	assert.ErrorIs(t, err, creditnotedomain.ErrInvoiceNotFinalized)
+}
+
+func TestCreateCreditNote_DiscountedInvoice(t *testing.T) {
+	f := setupCreditNoteTest(t, false)
+
+	node, _ := snowflake.NewNode(2)
+	require.NoError(t, f.db.Create(&invoicedomain.InvoiceItem{
+		ID:          node.Generate(),
+		OrgID:       f.orgID,
+		InvoiceID:   f.invoiceID,
+		LineType:    invoicedomain.InvoiceItemLineTypeCredit,
+		Description: "Discount: Spring (20% off)",
+		Quantity:    1,
+		UnitPrice:   -2000,
+		Amount:      -2000,
+		CreatedAt:   time.Now().UTC().Add(time.Minute),
+	}).Error)
+	require.NoError(t, f.db.Model(&invoicedomain.Invoice{}).Where("id = ?", f.invoiceID).Updates(map[string]any{
+		"subtotal_amount": 8000,
+		"discount_amount": 2000,
+		"tax_amount":      800,
+		"total_amount":    8800,
+	}).Error)
+
+	// Crediting a full charge line would refund more than was billed.
+	_, err := f.svc.Create(f.ctx, creditnotedomain.CreateRequest{
+		InvoiceID: f.invoiceID.String(),
+		Reason:    "billing_error",
+		Items: []creditnotedomain.CreateItemRequest{
+			{InvoiceItemID: f.itemIDs[0].String()},
+			{InvoiceItemID: f.itemIDs[1].String()},
+		},
+	})
+	assert.ErrorIs(t, err, creditnotedomain.ErrAmountExceedsInvoice)
+
+	note, err := f.svc.Create(f.ctx, creditnotedomain.CreateRequest{
+		InvoiceID: f.invoiceID.String(),
+		Reason:    "duplicate",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(8000), note.SubtotalAmount)
+	assert.Equal(t, int64(800), note.TaxAmount)
+	assert.Len(t, note.Items, 3)
+}
@@ -0,0 +1,68 @@
+// Package domain contains persistence models for dunning.
+package domain
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/datatypes"
+)
+
+// FinalAction is applied to the subscription once the retry schedule is exhausted.
+type FinalAction string
+
+const (
+	FinalActionCancel      FinalAction = "cancel"
+	FinalActionPause       FinalAction = "pause"
+	FinalActionLeaveUnpaid FinalAction = "leave_unpaid"
+)
+
+// CaseStatus represents the lifecycle of a dunning case.
+type CaseStatus string
+
+const (
+	CaseStatusOpen      CaseStatus = "open"
+	CaseStatusRecovered CaseStatus = "recovered"
+	CaseStatusExhausted CaseStatus = "exhausted"
+	CaseStatusClosed    CaseStatus = "closed"
+)
+
+// Policy configures dunning for an organization. RetryScheduleDays lists the
+// days after the payment failure at which the customer is reminded again; the
+// final action runs when the last entry elapses.
+type Policy struct {
+	ID                snowflake.ID             `gorm:"primaryKey"`
+	OrgID             snowflake.ID             `gorm:"not null;uniqueIndex"`
+	Enabled           bool                     `gorm:"not null;default:false"`
+	RetryScheduleDays datatypes.JSONSlice[int] `gorm:"type:jsonb;not null"`
+	SendReminders     bool                     `gorm:"not null;default:true"`
+	FinalAction       FinalAction              `gorm:"type:text;not null"`
+	CreatedAt         time.Time                `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt         time.Time                `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName sets the database table name.
+func (Policy) TableName() string { return "dunning_policies" }
+
+// Case tracks the collection of one unpaid invoice. Step is the number of
+// retry schedule entries already processed and TriggeredAt is the moment the
+// schedule is measured from.
+type Case struct {
+	ID             snowflake.ID  `gorm:"primaryKey"`
+	OrgID          snowflake.ID  `gorm:"not null;index"`
+	InvoiceID      snowflake.ID  `gorm:"not null;uniqueIndex"`
+	CustomerID     snowflake.ID  `gorm:"not null;index"`
+	SubscriptionID *snowflake.ID `gorm:"index"`
+	Status         CaseStatus    `gorm:"type:text;not null"`
+	Step           int           `gorm:"not null;default:0"`
+	FinalAction    *FinalAction  `gorm:"type:text"`
+	TriggeredAt    time.Time     `gorm:"not null"`
+	NextActionAt   *time.Time    `gorm:"index"`
+	LastActionAt   *time.Time
+	ResolvedAt     *time.Time
+	CreatedAt      time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt      time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName sets the database table name.
+func (Case) TableName() string { return "dunning_cases" }
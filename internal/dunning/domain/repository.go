@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// CaseFilter narrows case listings.
+type CaseFilter struct {
+	Status     CaseStatus
+	CustomerID *snowflake.ID
+	InvoiceID  *snowflake.ID
+	Limit      int
+}
+
+// InvoiceState is the subset of an invoice dunning decisions depend on.
+type InvoiceState struct {
+	ID             snowflake.ID      `gorm:"column:id"`
+	OrgID          snowflake.ID      `gorm:"column:org_id"`
+	CustomerID     snowflake.ID      `gorm:"column:customer_id"`
+	SubscriptionID snowflake.ID      `gorm:"column:subscription_id"`
+	InvoiceNumber  string            `gorm:"column:invoice_number"`
+	Status         string            `gorm:"column:status"`
+	TotalAmount    int64             `gorm:"column:total_amount"`
+	Currency       string            `gorm:"column:currency"`
+	DueAt          *time.Time        `gorm:"column:due_at"`
+	PaidAt         *time.Time        `gorm:"column:paid_at"`
+	VoidedAt       *time.Time        `gorm:"column:voided_at"`
+	Metadata       datatypes.JSONMap `gorm:"column:metadata"`
+}
+
+// Contact holds the names and addresses used for reminder emails.
+type Contact struct {
+	OrgName       string `gorm:"column:org_name"`
+	SupportEmail  string `gorm:"column:support_email"`
+	CustomerName  string `gorm:"column:customer_name"`
+	CustomerEmail string `gorm:"column:customer_email"`
+}
+
+type Repository interface {
+	FindPolicy(ctx context.Context, db *gorm.DB, orgID snowflake.ID) (*Policy, error)
+	UpsertPolicy(ctx context.Context, db *gorm.DB, policy *Policy) error
+
+	// ListDelinquentInvoices returns finalized, unpaid invoices of organizations
+	// with an enabled policy that failed payment or are past due and have no case yet.
+	ListDelinquentInvoices(ctx context.Context, db *gorm.DB, now time.Time, limit int) ([]InvoiceState, error)
+	FindInvoice(ctx context.Context, db *gorm.DB, orgID, invoiceID snowflake.ID) (*InvoiceState, error)
+	FindContact(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID) (*Contact, error)
+
+	// InsertCase returns false when the invoice already has a case.
+	InsertCase(ctx context.Context, db *gorm.DB, c *Case) (bool, error)
+	FindCaseByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*Case, error)
+	LockCase(ctx context.Context, db *gorm.DB, id snowflake.ID) (*Case, error)
+	ListCases(ctx context.Context, db *gorm.DB, orgID snowflake.ID, filter CaseFilter) ([]Case, error)
+	// ListDueCases returns open cases whose next step is due, skipping organizations with dunning disabled.
+	ListDueCases(ctx context.Context, db *gorm.DB, now time.Time, limit int) ([]Case, error)
+	// ListSettledCases returns open or exhausted cases whose invoice has since been paid or voided.
+	ListSettledCases(ctx context.Context, db *gorm.DB, limit int) ([]Case, error)
+	// CountUnresolvedCases counts open or exhausted cases of a subscription other than excludeID.
+	CountUnresolvedCases(ctx context.Context, db *gorm.DB, orgID, subscriptionID, excludeID snowflake.ID) (int64, error)
+	UpdateCase(ctx context.Context, db *gorm.DB, c *Case) error
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultRetryScheduleDays is returned for organizations without a stored policy.
+var DefaultRetryScheduleDays = []int{3, 5, 7}
+
+const (
+	// MaxRetrySteps bounds the number of entries in a retry schedule.
+	MaxRetrySteps = 10
+	// MaxRetryDays bounds how far after the failure a schedule may reach.
+	MaxRetryDays = 90
+)
+
+// UpsertPolicyRequest replaces the organization's dunning policy.
+type UpsertPolicyRequest struct {
+	Enabled           bool   `json:"enabled"`
+	RetryScheduleDays []int  `json:"retry_schedule_days"`
+	SendReminders     bool   `json:"send_reminders"`
+	FinalAction       string `json:"final_action"`
+}
+
+type PolicyResponse struct {
+	OrgID             string     `json:"organization_id"`
+	Enabled           bool       `json:"enabled"`
+	RetryScheduleDays []int      `json:"retry_schedule_days"`
+	SendReminders     bool       `json:"send_reminders"`
+	FinalAction       string     `json:"final_action"`
+	CreatedAt         *time.Time `json:"created_at,omitempty"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+}
+
+type ListCasesRequest struct {
+	Status     string `form:"status"`
+	CustomerID string `form:"customer_id"`
+	InvoiceID  string `form:"invoice_id"`
+	Limit      int    `form:"limit"`
+}
+
+type CaseResponse struct {
+	ID             string     `json:"id"`
+	OrgID          string     `json:"organization_id"`
+	InvoiceID      string     `json:"invoice_id"`
+	CustomerID     string     `json:"customer_id"`
+	SubscriptionID *string    `json:"subscription_id,omitempty"`
+	Status         string     `json:"status"`
+	Step           int        `json:"step"`
+	FinalAction    *string    `json:"final_action,omitempty"`
+	TriggeredAt    time.Time  `json:"triggered_at"`
+	NextActionAt   *time.Time `json:"next_action_at,omitempty"`
+	LastActionAt   *time.Time `json:"last_action_at,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type Service interface {
+	GetPolicy(ctx context.Context) (*PolicyResponse, error)
+	UpsertPolicy(ctx context.Context, req UpsertPolicyRequest) (*PolicyResponse, error)
+	ListCases(ctx context.Context, req ListCasesRequest) ([]CaseResponse, error)
+	GetCase(ctx context.Context, id string) (*CaseResponse, error)
+
+	// ProcessDue opens cases for newly delinquent invoices, runs due retry
+	// steps and resolves cases whose invoice was paid or voided.
+	ProcessDue(ctx context.Context, limit int) error
+}
+
+var (
+	ErrInvalidOrganization  = errors.New("invalid_organization")
+	ErrInvalidID            = errors.New("invalid_id")
+	ErrInvalidRetrySchedule = errors.New("invalid_retry_schedule")
+	ErrInvalidFinalAction   = errors.New("invalid_final_action")
+	ErrInvalidStatus        = errors.New("invalid_status")
+	ErrInvalidCustomer      = errors.New("invalid_customer")
+	ErrInvalidInvoice       = errors.New("invalid_invoice")
+	ErrCaseNotFound         = errors.New("dunning_case_not_found")
+)
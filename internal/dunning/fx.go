@@ -0,0 +1,12 @@
+package dunning
+
+import (
+	"github.com/smallbiznis/railzway/internal/dunning/repository"
+	"github.com/smallbiznis/railzway/internal/dunning/service"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("dunning.service",
+	fx.Provide(repository.Provide),
+	fx.Provide(service.NewService),
+)
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	dunningdomain "github.com/smallbiznis/railzway/internal/dunning/domain"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	"gorm.io/gorm"
+)
+
+type repo struct{}
+
+func Provide() dunningdomain.Repository {
+	return &repo{}
+}
+
+const policyColumns = `id, org_id, enabled, retry_schedule_days, send_reminders, final_action, created_at, updated_at`
+
+const caseColumns = `id, org_id, invoice_id, customer_id, subscription_id, status, step, final_action,
+	triggered_at, next_action_at, last_action_at, resolved_at, created_at, updated_at`
+
+const invoiceColumns = `i.id, i.org_id, i.customer_id, i.subscription_id, i.invoice_number, i.status,
+	i.total_amount, i.currency, i.due_at, i.paid_at, i.voided_at, i.metadata`
+
+func (r *repo) FindPolicy(ctx context.Context, db *gorm.DB, orgID snowflake.ID) (*dunningdomain.Policy, error) {
+	var policy dunningdomain.Policy
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+policyColumns+`
+		 FROM dunning_policies
+		 WHERE org_id = ?`,
+		orgID,
+	).Scan(&policy).Error
+	if err != nil {
+		return nil, err
+	}
+	if policy.ID == 0 {
+		return nil, nil
+	}
+	return &policy, nil
+}
+
+func (r *repo) UpsertPolicy(ctx context.Context, db *gorm.DB, policy *dunningdomain.Policy) error {
+	return db.WithContext(ctx).Exec(
+		`INSERT INTO dunning_policies (`+policyColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (org_id) DO UPDATE
+		SET enabled = EXCLUDED.enabled,
+		    retry_schedule_days = EXCLUDED.retry_schedule_days,
+		    send_reminders = EXCLUDED.send_reminders,
+		    final_action = EXCLUDED.final_action,
+		    updated_at = EXCLUDED.updated_at`,
+		policy.ID,
+		policy.OrgID,
+		policy.Enabled,
+		policy.RetryScheduleDays,
+		policy.SendReminders,
+		policy.FinalAction,
+		policy.CreatedAt,
+		policy.UpdatedAt,
+	).Error
+}
+
+func (r *repo) ListDelinquentInvoices(ctx context.Context, db *gorm.DB, now time.Time, limit int) ([]dunningdomain.InvoiceState, error) {
+	var invoices []dunningdomain.InvoiceState
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+invoiceColumns+`
+		 FROM invoices i
+		 JOIN dunning_policies p ON p.org_id = i.org_id AND p.enabled = TRUE
+		 WHERE i.status = ?
+		   AND i.paid_at IS NULL
+		   AND i.voided_at IS NULL
+		   AND i.total_amount > 0
+		   AND ((i.due_at IS NOT NULL AND i.due_at <= ?) OR i.metadata ->> 'payment_failed_at' IS NOT NULL)
+		   AND NOT EXISTS (SELECT 1 FROM dunning_cases dc WHERE dc.invoice_id = i.id)
+		 ORDER BY i.id ASC
+		 LIMIT ?`,
+		invoicedomain.InvoiceStatusFinalized,
+		now,
+		limit,
+	).Scan(&invoices).Error
+	if err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+func (r *repo) FindInvoice(ctx context.Context, db *gorm.DB, orgID, invoiceID snowflake.ID) (*dunningdomain.InvoiceState, error) {
+	var invoice dunningdomain.InvoiceState
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+invoiceColumns+`
+		 FROM invoices i
+		 WHERE i.org_id = ? AND i.id = ?`,
+		orgID,
+		invoiceID,
+	).Scan(&invoice).Error
+	if err != nil {
+		return nil, err
+	}
+	if invoice.ID == 0 {
+		return nil, nil
+	}
+	return &invoice, nil
+}
+
+func (r *repo) FindContact(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID) (*dunningdomain.Contact, error) {
+	var contact dunningdomain.Contact
+	err := db.WithContext(ctx).Raw(
+		`SELECT o.name AS org_name, COALESCE(o.support_email, '') AS support_email,
+		        COALESCE(c.name, '') AS customer_name, COALESCE(c.email, '') AS customer_email
+		 FROM organizations o
+		 LEFT JOIN customers c ON c.org_id = o.id AND c.id = ?
+		 WHERE o.id = ?`,
+		customerID,
+		orgID,
+	).Scan(&contact).Error
+	if err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+func (r *repo) InsertCase(ctx context.Context, db *gorm.DB, c *dunningdomain.Case) (bool, error) {
+	result := db.WithContext(ctx).Exec(
+		`INSERT INTO dunning_cases (`+caseColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (invoice_id) DO NOTHING`,
+		c.ID,
+		c.OrgID,
+		c.InvoiceID,
+		c.CustomerID,
+		c.SubscriptionID,
+		c.Status,
+		c.Step,
+		c.FinalAction,
+		c.TriggeredAt,
+		c.NextActionAt,
+		c.LastActionAt,
+		c.ResolvedAt,
+		c.CreatedAt,
+		c.UpdatedAt,
+	)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repo) FindCaseByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*dunningdomain.Case, error) {
+	var c dunningdomain.Case
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+caseColumns+`
+		 FROM dunning_cases
+		 WHERE org_id = ? AND id = ?`,
+		orgID,
+		id,
+	).Scan(&c).Error
+	if err != nil {
+		return nil, err
+	}
+	if c.ID == 0 {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+func (r *repo) LockCase(ctx context.Context, db *gorm.DB, id snowflake.ID) (*dunningdomain.Case, error) {
+	query := `SELECT ` + caseColumns + `
+		 FROM dunning_cases
+		 WHERE id = ?`
+	if db.Dialector.Name() != "sqlite" {
+		query += " FOR UPDATE"
+	}
+
+	var c dunningdomain.Case
+	if err := db.WithContext(ctx).Raw(query, id).Scan(&c).Error; err != nil {
+		return nil, err
+	}
+	if c.ID == 0 {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+func (r *repo) ListCases(ctx context.Context, db *gorm.DB, orgID snowflake.ID, filter dunningdomain.CaseFilter) ([]dunningdomain.Case, error) {
+	query := `SELECT ` + caseColumns + `
+		 FROM dunning_cases
+		 WHERE org_id = ?`
+	args := []any{orgID}
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.CustomerID != nil {
+		query += " AND customer_id = ?"
+		args = append(args, *filter.CustomerID)
+	}
+	if filter.InvoiceID != nil {
+		query += " AND invoice_id = ?"
+		args = append(args, *filter.InvoiceID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	var cases []dunningdomain.Case
+	if err := db.WithContext(ctx).Raw(query, args...).Scan(&cases).Error; err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+func (r *repo) ListDueCases(ctx context.Context, db *gorm.DB, now time.Time, limit int) ([]dunningdomain.Case, error) {
+	var cases []dunningdomain.Case
+	err := db.WithContext(ctx).Raw(
+		`SELECT dc.id, dc.org_id, dc.invoice_id, dc.customer_id, dc.subscription_id, dc.status, dc.step,
+		        dc.final_action, dc.triggered_at, dc.next_action_at, dc.last_action_at, dc.resolved_at,
+		        dc.created_at, dc.updated_at
+		 FROM dunning_cases dc
+		 JOIN dunning_policies p ON p.org_id = dc.org_id AND p.enabled = TRUE
+		 WHERE dc.status = ? AND dc.next_action_at IS NOT NULL AND dc.next_action_at <= ?
+		 ORDER BY dc.next_action_at ASC, dc.id ASC
+		 LIMIT ?`,
+		dunningdomain.CaseStatusOpen,
+		now,
+		limit,
+	).Scan(&cases).Error
+	if err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+func (r *repo) ListSettledCases(ctx context.Context, db *gorm.DB, limit int) ([]dunningdomain.Case, error) {
+	var cases []dunningdomain.Case
+	err := db.WithContext(ctx).Raw(
+		`SELECT dc.id, dc.org_id, dc.invoice_id, dc.customer_id, dc.subscription_id, dc.status, dc.step,
+		        dc.final_action, dc.triggered_at, dc.next_action_at, dc.last_action_at, dc.resolved_at,
+		        dc.created_at, dc.updated_at
+		 FROM dunning_cases dc
+		 JOIN invoices i ON i.id = dc.invoice_id AND i.org_id = dc.org_id
+		 WHERE dc.status IN (?, ?)
+		   AND (i.paid_at IS NOT NULL OR i.voided_at IS NOT NULL OR i.status = ?)
+		 ORDER BY dc.id ASC
+		 LIMIT ?`,
+		dunningdomain.CaseStatusOpen,
+		dunningdomain.CaseStatusExhausted,
+		invoicedomain.InvoiceStatusVoid,
+		limit,
+	).Scan(&cases).Error
+	if err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+func (r *repo) CountUnresolvedCases(ctx context.Context, db *gorm.DB, orgID, subscriptionID, excludeID snowflake.ID) (int64, error) {
+	var count int64
+	err := db.WithContext(ctx).Raw(
+		`SELECT COUNT(1)
+		 FROM dunning_cases
+		 WHERE org_id = ? AND subscription_id = ? AND id <> ? AND status IN (?, ?)`,
+		orgID,
+		subscriptionID,
+		excludeID,
+		dunningdomain.CaseStatusOpen,
+		dunningdomain.CaseStatusExhausted,
+	).Scan(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *repo) UpdateCase(ctx context.Context, db *gorm.DB, c *dunningdomain.Case) error {
+	return db.WithContext(ctx).Exec(
+		`UPDATE dunning_cases
+		 SET status = ?, step = ?, final_action = ?, next_action_at = ?, last_action_at = ?,
+		     resolved_at = ?, updated_at = ?
+		 WHERE id = ?`,
+		c.Status,
+		c.Step,
+		c.FinalAction,
+		c.NextActionAt,
+		c.LastActionAt,
+		c.ResolvedAt,
+		c.UpdatedAt,
+		c.ID,
+	).Error
+}
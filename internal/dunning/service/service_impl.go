@@ -0,0 +1,715 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	auditdomain "github.com/smallbiznis/railzway/internal/audit/domain"
+	"github.com/smallbiznis/railzway/internal/clock"
+	dunningdomain "github.com/smallbiznis/railzway/internal/dunning/domain"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	"github.com/smallbiznis/railzway/internal/money"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	"github.com/smallbiznis/railzway/internal/providers/email"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+
+	reminderTemplate = "dunning_reminder"
+	transitionReason = subscriptiondomain.TransitionReason("dunning")
+)
+
+type Params struct {
+	fx.In
+
+	DB              *gorm.DB
+	Log             *zap.Logger
+	GenID           *snowflake.Node
+	Clock           clock.Clock
+	Repo            dunningdomain.Repository
+	SubscriptionSvc subscriptiondomain.Service
+	AuditSvc        auditdomain.Service `optional:"true"`
+	EmailProvider   email.Provider      `optional:"true"`
+}
+
+type Service struct {
+	db              *gorm.DB
+	log             *zap.Logger
+	genID           *snowflake.Node
+	clock           clock.Clock
+	repo            dunningdomain.Repository
+	subscriptionSvc subscriptiondomain.Service
+	auditSvc        auditdomain.Service
+	emailProvider   email.Provider
+}
+
+func NewService(p Params) dunningdomain.Service {
+	return &Service{
+		db:              p.DB,
+		log:             p.Log.Named("dunning.service"),
+		genID:           p.GenID,
+		clock:           p.Clock,
+		repo:            p.Repo,
+		subscriptionSvc: p.SubscriptionSvc,
+		auditSvc:        p.AuditSvc,
+		emailProvider:   p.EmailProvider,
+	}
+}
+
+func (s *Service) GetPolicy(ctx context.Context) (*dunningdomain.PolicyResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, dunningdomain.ErrInvalidOrganization
+	}
+
+	policy, err := s.repo.FindPolicy(ctx, s.db, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return &dunningdomain.PolicyResponse{
+			OrgID:             orgID.String(),
+			Enabled:           false,
+			RetryScheduleDays: append([]int(nil), dunningdomain.DefaultRetryScheduleDays...),
+			SendReminders:     true,
+			FinalAction:       string(dunningdomain.FinalActionLeaveUnpaid),
+		}, nil
+	}
+	return toPolicyResponse(policy), nil
+}
+
+func (s *Service) UpsertPolicy(ctx context.Context, req dunningdomain.UpsertPolicyRequest) (*dunningdomain.PolicyResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, dunningdomain.ErrInvalidOrganization
+	}
+
+	schedule, err := normalizeRetrySchedule(req.RetryScheduleDays)
+	if err != nil {
+		return nil, err
+	}
+	finalAction, err := parseFinalAction(req.FinalAction)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
+	var policy *dunningdomain.Policy
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := s.repo.FindPolicy(ctx, tx, orgID)
+		if err != nil {
+			return err
+		}
+		policy = &dunningdomain.Policy{
+			ID:        s.genID.Generate(),
+			OrgID:     orgID,
+			CreatedAt: now,
+		}
+		if existing != nil {
+			policy.ID = existing.ID
+			policy.CreatedAt = existing.CreatedAt
+		}
+		policy.Enabled = req.Enabled
+		policy.RetryScheduleDays = datatypes.JSONSlice[int](schedule)
+		policy.SendReminders = req.SendReminders
+		policy.FinalAction = finalAction
+		policy.UpdatedAt = now
+		return s.repo.UpsertPolicy(ctx, tx, policy)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitAudit(ctx, orgID, "dunning.policy.update", "dunning_policy", policy.ID, map[string]any{
+		"enabled":             policy.Enabled,
+		"retry_schedule_days": schedule,
+		"send_reminders":      policy.SendReminders,
+		"final_action":        string(policy.FinalAction),
+	})
+	return toPolicyResponse(policy), nil
+}
+
+func (s *Service) ListCases(ctx context.Context, req dunningdomain.ListCasesRequest) ([]dunningdomain.CaseResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, dunningdomain.ErrInvalidOrganization
+	}
+
+	filter := dunningdomain.CaseFilter{Limit: req.Limit}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+	if status := strings.TrimSpace(req.Status); status != "" {
+		parsed, err := parseCaseStatus(status)
+		if err != nil {
+			return nil, err
+		}
+		filter.Status = parsed
+	}
+	if value := strings.TrimSpace(req.CustomerID); value != "" {
+		id, err := parseID(value, dunningdomain.ErrInvalidCustomer)
+		if err != nil {
+			return nil, err
+		}
+		filter.CustomerID = &id
+	}
+	if value := strings.TrimSpace(req.InvoiceID); value != "" {
+		id, err := parseID(value, dunningdomain.ErrInvalidInvoice)
+		if err != nil {
+			return nil, err
+		}
+		filter.InvoiceID = &id
+	}
+
+	cases, err := s.repo.ListCases(ctx, s.db, orgID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dunningdomain.CaseResponse, 0, len(cases))
+	for i := range cases {
+		resp = append(resp, *toCaseResponse(&cases[i]))
+	}
+	return resp, nil
+}
+
+func (s *Service) GetCase(ctx context.Context, id string) (*dunningdomain.CaseResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, dunningdomain.ErrInvalidOrganization
+	}
+
+	caseID, err := parseID(id, dunningdomain.ErrInvalidID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.repo.FindCaseByID(ctx, s.db, orgID, caseID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, dunningdomain.ErrCaseNotFound
+	}
+	return toCaseResponse(c), nil
+}
+
+// ProcessDue runs one dunning pass: settled cases are resolved first so a
+// payment that arrived since the last pass never triggers another step.
+func (s *Service) ProcessDue(ctx context.Context, limit int) error {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	var errs []error
+	if err := s.resolveSettledCases(ctx, limit); err != nil {
+		errs = append(errs, err)
+	}
+	if err := s.openCases(ctx, limit); err != nil {
+		errs = append(errs, err)
+	}
+	if err := s.advanceDueCases(ctx, limit); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Service) resolveSettledCases(ctx context.Context, limit int) error {
+	cases, err := s.repo.ListSettledCases(ctx, s.db, limit)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range cases {
+		if err := s.resolveCase(ctx, cases[i].ID); err != nil {
+			errs = append(errs, err)
+			s.log.Warn("failed to resolve dunning case", zap.String("case_id", cases[i].ID.String()), zap.Error(err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Service) resolveCase(ctx context.Context, caseID snowflake.ID) error {
+	now := s.clock.Now().UTC()
+	var resolved *dunningdomain.Case
+	var invoice *dunningdomain.InvoiceState
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		c, err := s.repo.LockCase(ctx, tx, caseID)
+		if err != nil {
+			return err
+		}
+		if c == nil || (c.Status != dunningdomain.CaseStatusOpen && c.Status != dunningdomain.CaseStatusExhausted) {
+			return nil
+		}
+		invoice, err = s.repo.FindInvoice(ctx, tx, c.OrgID, c.InvoiceID)
+		if err != nil {
+			return err
+		}
+		if invoice != nil && !invoiceSettled(invoice) {
+			return nil
+		}
+
+		c.Status = dunningdomain.CaseStatusClosed
+		if invoice != nil && invoice.PaidAt != nil {
+			c.Status = dunningdomain.CaseStatusRecovered
+		}
+		c.NextActionAt = nil
+		c.ResolvedAt = &now
+		c.LastActionAt = &now
+		c.UpdatedAt = now
+		if err := s.repo.UpdateCase(ctx, tx, c); err != nil {
+			return err
+		}
+		resolved = c
+		return nil
+	})
+	if err != nil || resolved == nil {
+		return err
+	}
+
+	orgCtx := orgcontext.WithOrgID(ctx, int64(resolved.OrgID))
+	metadata := map[string]any{
+		"invoice_id": resolved.InvoiceID.String(),
+		"status":     string(resolved.Status),
+		"step":       resolved.Step,
+	}
+
+	if resolved.SubscriptionID != nil {
+		restored, err := s.restoreSubscription(orgCtx, resolved)
+		if err != nil {
+			return err
+		}
+		metadata["subscription_restored"] = restored
+	}
+
+	action := "dunning.case_recovered"
+	if resolved.Status == dunningdomain.CaseStatusClosed {
+		action = "dunning.case_closed"
+	}
+	s.emitAudit(orgCtx, resolved.OrgID, action, "dunning_case", resolved.ID, metadata)
+	return nil
+}
+
+// restoreSubscription moves a past-due subscription back to active once none
+// of its other invoices are still being dunned.
+func (s *Service) restoreSubscription(ctx context.Context, c *dunningdomain.Case) (bool, error) {
+	remaining, err := s.repo.CountUnresolvedCases(ctx, s.db, c.OrgID, *c.SubscriptionID, c.ID)
+	if err != nil {
+		return false, err
+	}
+	if remaining > 0 {
+		return false, nil
+	}
+
+	subscription, err := s.subscriptionSvc.GetByID(ctx, c.SubscriptionID.String())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if subscription.Status != subscriptiondomain.SubscriptionStatusPastDue {
+		return false, nil
+	}
+
+	if err := s.subscriptionSvc.TransitionSubscription(ctx, c.SubscriptionID.String(), subscriptiondomain.SubscriptionStatusActive, transitionReason); err != nil {
+		return false, err
+	}
+	s.emitAudit(ctx, c.OrgID, "subscription.activate", "subscription", *c.SubscriptionID, map[string]any{
+		"reason":          "dunning_recovered",
+		"dunning_case_id": c.ID.String(),
+	})
+	return true, nil
+}
+
+func (s *Service) openCases(ctx context.Context, limit int) error {
+	now := s.clock.Now().UTC()
+	invoices, err := s.repo.ListDelinquentInvoices(ctx, s.db, now, limit)
+	if err != nil {
+		return err
+	}
+
+	policies := map[snowflake.ID]*dunningdomain.Policy{}
+	var errs []error
+	for i := range invoices {
+		invoice := invoices[i]
+		policy, ok := policies[invoice.OrgID]
+		if !ok {
+			policy, err = s.repo.FindPolicy(ctx, s.db, invoice.OrgID)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			policies[invoice.OrgID] = policy
+		}
+		if policy == nil || !policy.Enabled || len(policy.RetryScheduleDays) == 0 {
+			continue
+		}
+		if err := s.openCase(ctx, policy, &invoice, now); err != nil {
+			errs = append(errs, err)
+			s.log.Warn("failed to open dunning case", zap.String("invoice_id", invoice.ID.String()), zap.Error(err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Service) openCase(ctx context.Context, policy *dunningdomain.Policy, invoice *dunningdomain.InvoiceState, now time.Time) error {
+	triggeredAt := failureTime(invoice, now)
+	next := scheduleAt(triggeredAt, policy.RetryScheduleDays[0])
+	c := &dunningdomain.Case{
+		ID:           s.genID.Generate(),
+		OrgID:        invoice.OrgID,
+		InvoiceID:    invoice.ID,
+		CustomerID:   invoice.CustomerID,
+		Status:       dunningdomain.CaseStatusOpen,
+		TriggeredAt:  triggeredAt,
+		NextActionAt: &next,
+		LastActionAt: &now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if invoice.SubscriptionID != 0 {
+		subscriptionID := invoice.SubscriptionID
+		c.SubscriptionID = &subscriptionID
+	}
+
+	inserted, err := s.repo.InsertCase(ctx, s.db, c)
+	if err != nil || !inserted {
+		return err
+	}
+
+	orgCtx := orgcontext.WithOrgID(ctx, int64(c.OrgID))
+	metadata := map[string]any{
+		"invoice_id":     invoice.ID.String(),
+		"invoice_number": invoice.InvoiceNumber,
+		"amount_due":     invoice.TotalAmount,
+		"currency":       invoice.Currency,
+		"triggered_at":   triggeredAt.Format(time.RFC3339),
+		"next_action_at": next.Format(time.RFC3339),
+	}
+	if c.SubscriptionID != nil {
+		metadata["subscription_status"] = s.transitionSubscription(orgCtx, c, subscriptiondomain.SubscriptionStatusPastDue, "subscription.past_due")
+	}
+	metadata["email_sent"] = s.sendReminder(orgCtx, policy, c, invoice, false)
+
+	s.emitAudit(orgCtx, c.OrgID, "dunning.case_opened", "dunning_case", c.ID, metadata)
+	return nil
+}
+
+func (s *Service) advanceDueCases(ctx context.Context, limit int) error {
+	now := s.clock.Now().UTC()
+	cases, err := s.repo.ListDueCases(ctx, s.db, now, limit)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range cases {
+		if err := s.advanceCase(ctx, cases[i].ID, now); err != nil {
+			errs = append(errs, err)
+			s.log.Warn("failed to advance dunning case", zap.String("case_id", cases[i].ID.String()), zap.Error(err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Service) advanceCase(ctx context.Context, caseID snowflake.ID, now time.Time) error {
+	var (
+		c       *dunningdomain.Case
+		policy  *dunningdomain.Policy
+		invoice *dunningdomain.InvoiceState
+		final   bool
+		settled bool
+	)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		c, err = s.repo.LockCase(ctx, tx, caseID)
+		if err != nil {
+			return err
+		}
+		if c == nil || c.Status != dunningdomain.CaseStatusOpen || c.NextActionAt == nil || c.NextActionAt.After(now) {
+			c = nil
+			return nil
+		}
+		invoice, err = s.repo.FindInvoice(ctx, tx, c.OrgID, c.InvoiceID)
+		if err != nil {
+			return err
+		}
+		if invoice == nil || invoiceSettled(invoice) {
+			settled = true
+			return nil
+		}
+		policy, err = s.repo.FindPolicy(ctx, tx, c.OrgID)
+		if err != nil {
+			return err
+		}
+		if policy == nil || !policy.Enabled || len(policy.RetryScheduleDays) == 0 {
+			c = nil
+			return nil
+		}
+
+		schedule := policy.RetryScheduleDays
+		final = c.Step >= len(schedule)-1
+		c.Step++
+		c.LastActionAt = &now
+		c.UpdatedAt = now
+		if final {
+			action := policy.FinalAction
+			c.Status = dunningdomain.CaseStatusExhausted
+			c.FinalAction = &action
+			c.NextActionAt = nil
+		} else {
+			next := scheduleAt(c.TriggeredAt, schedule[c.Step])
+			if next.Before(now) {
+				next = now
+			}
+			c.NextActionAt = &next
+		}
+		return s.repo.UpdateCase(ctx, tx, c)
+	})
+	if err != nil {
+		return err
+	}
+	if settled {
+		return s.resolveCase(ctx, caseID)
+	}
+	if c == nil {
+		return nil
+	}
+
+	orgCtx := orgcontext.WithOrgID(ctx, int64(c.OrgID))
+	metadata := map[string]any{
+		"invoice_id": c.InvoiceID.String(),
+		"step":       c.Step,
+	}
+	if !final {
+		metadata["next_action_at"] = c.NextActionAt.Format(time.RFC3339)
+		metadata["email_sent"] = s.sendReminder(orgCtx, policy, c, invoice, false)
+		s.emitAudit(orgCtx, c.OrgID, "dunning.retry", "dunning_case", c.ID, metadata)
+		return nil
+	}
+
+	metadata["final_action"] = string(*c.FinalAction)
+	if c.SubscriptionID != nil {
+		switch *c.FinalAction {
+		case dunningdomain.FinalActionCancel:
+			metadata["subscription_status"] = s.transitionSubscription(orgCtx, c, subscriptiondomain.SubscriptionStatusCanceled, "subscription.cancel")
+		case dunningdomain.FinalActionPause:
+			metadata["subscription_status"] = s.transitionSubscription(orgCtx, c, subscriptiondomain.SubscriptionStatusPaused, "subscription.pause")
+		}
+	}
+	metadata["email_sent"] = s.sendReminder(orgCtx, policy, c, invoice, true)
+	s.emitAudit(orgCtx, c.OrgID, "dunning.final_action", "dunning_case", c.ID, metadata)
+	return nil
+}
+
+// transitionSubscription applies a dunning-driven status change and reports
+// the outcome for the audit trail. Transitions the subscription's current
+// state does not allow, e.g. pausing a canceled subscription, are skipped.
+func (s *Service) transitionSubscription(ctx context.Context, c *dunningdomain.Case, target subscriptiondomain.SubscriptionStatus, auditAction string) string {
+	err := s.subscriptionSvc.TransitionSubscription(ctx, c.SubscriptionID.String(), target, transitionReason)
+	switch {
+	case err == nil:
+		s.emitAudit(ctx, c.OrgID, auditAction, "subscription", *c.SubscriptionID, map[string]any{
+			"reason":          "dunning",
+			"dunning_case_id": c.ID.String(),
+		})
+		return string(target)
+	case errors.Is(err, subscriptiondomain.ErrInvalidTransition), errors.Is(err, subscriptiondomain.ErrSubscriptionNotFound):
+		return "skipped"
+	default:
+		s.log.Warn("failed to transition subscription for dunning",
+			zap.String("subscription_id", c.SubscriptionID.String()),
+			zap.String("target_status", string(target)),
+			zap.Error(err),
+		)
+		return "failed"
+	}
+}
+
+func (s *Service) sendReminder(ctx context.Context, policy *dunningdomain.Policy, c *dunningdomain.Case, invoice *dunningdomain.InvoiceState, finalNotice bool) bool {
+	if s.emailProvider == nil || policy == nil || !policy.SendReminders || invoice == nil {
+		return false
+	}
+
+	contact, err := s.repo.FindContact(ctx, s.db, c.OrgID, c.CustomerID)
+	if err != nil {
+		s.log.Warn("failed to load dunning contact", zap.String("case_id", c.ID.String()), zap.Error(err))
+		return false
+	}
+	if contact == nil || strings.TrimSpace(contact.CustomerEmail) == "" {
+		return false
+	}
+
+	dueDate := ""
+	if invoice.DueAt != nil {
+		dueDate = invoice.DueAt.Format("January 2, 2006")
+	}
+	nextAttempt := ""
+	if !finalNotice && c.NextActionAt != nil {
+		nextAttempt = c.NextActionAt.Format("January 2, 2006")
+	}
+
+	data := struct {
+		OrgName         string
+		CustomerName    string
+		InvoiceNumber   string
+		AmountDue       string
+		DueDate         string
+		NextAttempt     string
+		FinalNotice     bool
+		OrgContactEmail string
+	}{
+		OrgName:         contact.OrgName,
+		CustomerName:    contact.CustomerName,
+		InvoiceNumber:   invoice.InvoiceNumber,
+		AmountDue:       money.Format(invoice.TotalAmount, invoice.Currency),
+		DueDate:         dueDate,
+		NextAttempt:     nextAttempt,
+		FinalNotice:     finalNotice,
+		OrgContactEmail: contact.SupportEmail,
+	}
+
+	subject := fmt.Sprintf("Payment for invoice #%s from %s is past due", invoice.InvoiceNumber, contact.OrgName)
+	if finalNotice {
+		subject = fmt.Sprintf("Final notice: invoice #%s from %s", invoice.InvoiceNumber, contact.OrgName)
+	}
+	msg := email.EmailMessage{
+		To:         []string{contact.CustomerEmail},
+		SenderName: contact.OrgName,
+		ReplyTo:    contact.SupportEmail,
+		Subject:    subject,
+	}
+	if err := s.emailProvider.SendTemplate(ctx, msg, reminderTemplate, data); err != nil {
+		s.log.Warn("failed to send dunning reminder", zap.String("case_id", c.ID.String()), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+func (s *Service) emitAudit(ctx context.Context, orgID snowflake.ID, action, targetType string, targetID snowflake.ID, metadata map[string]any) {
+	if s.auditSvc == nil {
+		return
+	}
+	target := targetID.String()
+	_ = s.auditSvc.AuditLog(ctx, &orgID, "", nil, action, targetType, &target, metadata)
+}
+
+func invoiceSettled(invoice *dunningdomain.InvoiceState) bool {
+	return invoice.PaidAt != nil || invoice.VoidedAt != nil || invoice.Status == string(invoicedomain.InvoiceStatusVoid)
+}
+
+// failureTime anchors the retry schedule on the recorded payment failure, or
+// on the due date when the invoice simply went unpaid.
+func failureTime(invoice *dunningdomain.InvoiceState, now time.Time) time.Time {
+	if raw, ok := invoice.Metadata["payment_failed_at"].(string); ok {
+		if parsed, err := time.Parse(time.RFC3339, raw); err == nil {
+			return parsed.UTC()
+		}
+	}
+	if invoice.DueAt != nil {
+		return invoice.DueAt.UTC()
+	}
+	return now
+}
+
+func scheduleAt(triggeredAt time.Time, days int) time.Time {
+	return triggeredAt.AddDate(0, 0, days)
+}
+
+func normalizeRetrySchedule(days []int) ([]int, error) {
+	if len(days) == 0 || len(days) > dunningdomain.MaxRetrySteps {
+		return nil, dunningdomain.ErrInvalidRetrySchedule
+	}
+	out := make([]int, 0, len(days))
+	prev := 0
+	for _, d := range days {
+		if d <= prev || d > dunningdomain.MaxRetryDays {
+			return nil, dunningdomain.ErrInvalidRetrySchedule
+		}
+		out = append(out, d)
+		prev = d
+	}
+	return out, nil
+}
+
+func parseFinalAction(value string) (dunningdomain.FinalAction, error) {
+	switch action := dunningdomain.FinalAction(strings.ToLower(strings.TrimSpace(value))); action {
+	case dunningdomain.FinalActionCancel, dunningdomain.FinalActionPause, dunningdomain.FinalActionLeaveUnpaid:
+		return action, nil
+	default:
+		return "", dunningdomain.ErrInvalidFinalAction
+	}
+}
+
+func parseCaseStatus(value string) (dunningdomain.CaseStatus, error) {
+	switch status := dunningdomain.CaseStatus(strings.ToLower(value)); status {
+	case dunningdomain.CaseStatusOpen, dunningdomain.CaseStatusRecovered, dunningdomain.CaseStatusExhausted, dunningdomain.CaseStatusClosed:
+		return status, nil
+	default:
+		return "", dunningdomain.ErrInvalidStatus
+	}
+}
+
+func parseID(value string, invalidErr error) (snowflake.ID, error) {
+	id, err := snowflake.ParseString(strings.TrimSpace(value))
+	if err != nil || id == 0 {
+		return 0, invalidErr
+	}
+	return id, nil
+}
+
+func toPolicyResponse(policy *dunningdomain.Policy) *dunningdomain.PolicyResponse {
+	createdAt := policy.CreatedAt
+	updatedAt := policy.UpdatedAt
+	return &dunningdomain.PolicyResponse{
+		OrgID:             policy.OrgID.String(),
+		Enabled:           policy.Enabled,
+		RetryScheduleDays: append([]int(nil), policy.RetryScheduleDays...),
+		SendReminders:     policy.SendReminders,
+		FinalAction:       string(policy.FinalAction),
+		CreatedAt:         &createdAt,
+		UpdatedAt:         &updatedAt,
+	}
+}
+
+func toCaseResponse(c *dunningdomain.Case) *dunningdomain.CaseResponse {
+	resp := &dunningdomain.CaseResponse{
+		ID:           c.ID.String(),
+		OrgID:        c.OrgID.String(),
+		InvoiceID:    c.InvoiceID.String(),
+		CustomerID:   c.CustomerID.String(),
+		Status:       string(c.Status),
+		Step:         c.Step,
+		TriggeredAt:  c.TriggeredAt,
+		NextActionAt: c.NextActionAt,
+		LastActionAt: c.LastActionAt,
+		ResolvedAt:   c.ResolvedAt,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+	}
+	if c.SubscriptionID != nil {
+		id := c.SubscriptionID.String()
+		resp.SubscriptionID = &id
+	}
+	if c.FinalAction != nil {
+		action := string(*c.FinalAction)
+		resp.FinalAction = &action
+	}
+	return resp
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/glebarez/sqlite"
+	"github.com/smallbiznis/railzway/internal/clock"
+	customerdomain "github.com/smallbiznis/railzway/internal/customer/domain"
+	dunningdomain "github.com/smallbiznis/railzway/internal/dunning/domain"
+	"github.com/smallbiznis/railzway/internal/dunning/repository"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	"github.com/smallbiznis/railzway/internal/providers/email"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type fakeSubscriptions struct {
+	subscriptiondomain.Service
+
+	mu       sync.Mutex
+	statuses map[string]subscriptiondomain.SubscriptionStatus
+}
+
+func (f *fakeSubscriptions) GetByID(_ context.Context, id string) (subscriptiondomain.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status, ok := f.statuses[id]
+	if !ok {
+		return subscriptiondomain.Subscription{}, gorm.ErrRecordNotFound
+	}
+	return subscriptiondomain.Subscription{Status: status}, nil
+}
+
+func (f *fakeSubscriptions) TransitionSubscription(_ context.Context, id string, target subscriptiondomain.SubscriptionStatus, _ subscriptiondomain.TransitionReason) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.statuses[id] == subscriptiondomain.SubscriptionStatusCanceled {
+		return subscriptiondomain.ErrInvalidTransition
+	}
+	f.statuses[id] = target
+	return nil
+}
+
+func (f *fakeSubscriptions) status(id snowflake.ID) subscriptiondomain.SubscriptionStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.statuses[id.String()]
+}
+
+type sentEmail struct {
+	msg      email.EmailMessage
+	template string
+}
+
+type recordingEmail struct {
+	mu   sync.Mutex
+	sent []sentEmail
+}
+
+func (r *recordingEmail) Send(context.Context, email.EmailMessage) error { return nil }
+
+func (r *recordingEmail) SendTemplate(_ context.Context, msg email.EmailMessage, templateName string, _ interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, sentEmail{msg: msg, template: templateName})
+	return nil
+}
+
+func (r *recordingEmail) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sent)
+}
+
+type dunningFixture struct {
+	db         *gorm.DB
+	node       *snowflake.Node
+	clock      *clock.FakeClock
+	svc        dunningdomain.Service
+	subs       *fakeSubscriptions
+	mail       *recordingEmail
+	ctx        context.Context
+	orgID      snowflake.ID
+	customerID snowflake.ID
+	subID      snowflake.ID
+}
+
+func setupDunningTest(t *testing.T) *dunningFixture {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&customerdomain.Customer{},
+		&invoicedomain.Invoice{},
+		&dunningdomain.Policy{},
+		&dunningdomain.Case{},
+	))
+	require.NoError(t, db.Exec("CREATE TABLE organizations (id BIGINT PRIMARY KEY, name TEXT, support_email TEXT)").Error)
+
+	node, _ := snowflake.NewNode(1)
+	f := &dunningFixture{
+		db:         db,
+		node:       node,
+		clock:      clock.NewFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)),
+		mail:       &recordingEmail{},
+		orgID:      node.Generate(),
+		customerID: node.Generate(),
+		subID:      node.Generate(),
+	}
+	f.subs = &fakeSubscriptions{statuses: map[string]subscriptiondomain.SubscriptionStatus{
+		f.subID.String(): subscriptiondomain.SubscriptionStatusActive,
+	}}
+	f.ctx = orgcontext.WithOrgID(context.Background(), int64(f.orgID))
+	f.svc = NewService(Params{
+		DB:              db,
+		Log:             zap.NewNop(),
+		GenID:           node,
+		Clock:           f.clock,
+		Repo:            repository.Provide(),
+		SubscriptionSvc: f.subs,
+		EmailProvider:   f.mail,
+	})
+
+	require.NoError(t, db.Exec("INSERT INTO organizations (id, name, support_email) VALUES (?, ?, ?)", f.orgID, "Railzway Test", "billing@railzway.test").Error)
+	require.NoError(t, db.Create(&customerdomain.Customer{ID: f.customerID, OrgID: f.orgID, Name: "Acme", Email: "ap@acme.test"}).Error)
+	return f
+}
+
+func (f *dunningFixture) createInvoice(t *testing.T, metadata datatypes.JSONMap, dueAt time.Time) *invoicedomain.Invoice {
+	now := f.clock.Now()
+	invoice := &invoicedomain.Invoice{
+		ID:             f.node.Generate(),
+		OrgID:          f.orgID,
+		InvoiceNumber:  "INV-0001",
+		BillingCycleID: f.node.Generate(),
+		SubscriptionID: f.subID,
+		CustomerID:     f.customerID,
+		Status:         invoicedomain.InvoiceStatusFinalized,
+		TotalAmount:    12000,
+		Currency:       "USD",
+		DueAt:          &dueAt,
+		FinalizedAt:    &now,
+		Metadata:       metadata,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	require.NoError(t, f.db.Create(invoice).Error)
+	return invoice
+}
+
+func (f *dunningFixture) onlyCase(t *testing.T) dunningdomain.CaseResponse {
+	cases, err := f.svc.ListCases(f.ctx, dunningdomain.ListCasesRequest{})
+	require.NoError(t, err)
+	require.Len(t, cases, 1)
+	return cases[0]
+}
+
+func TestUpsertPolicy_Validation(t *testing.T) {
+	f := setupDunningTest(t)
+
+	policy, err := f.svc.GetPolicy(f.ctx)
+	require.NoError(t, err)
+	assert.False(t, policy.Enabled)
+	assert.Equal(t, dunningdomain.DefaultRetryScheduleDays, policy.RetryScheduleDays)
+
+	_, err = f.svc.UpsertPolicy(f.ctx, dunningdomain.UpsertPolicyRequest{Enabled: true, RetryScheduleDays: []int{3, 3}, FinalAction: "cancel"})
+	assert.ErrorIs(t, err, dunningdomain.ErrInvalidRetrySchedule)
+	_, err = f.svc.UpsertPolicy(f.ctx, dunningdomain.UpsertPolicyRequest{Enabled: true, FinalAction: "cancel"})
+	assert.ErrorIs(t, err, dunningdomain.ErrInvalidRetrySchedule)
+	_, err = f.svc.UpsertPolicy(f.ctx, dunningdomain.UpsertPolicyRequest{Enabled: true, RetryScheduleDays: []int{1}, FinalAction: "delete"})
+	assert.ErrorIs(t, err, dunningdomain.ErrInvalidFinalAction)
+
+	_, err = f.svc.UpsertPolicy(f.ctx, dunningdomain.UpsertPolicyRequest{Enabled: true, RetryScheduleDays: []int{1, 4}, SendReminders: true, FinalAction: "pause"})
+	require.NoError(t, err)
+	policy, err = f.svc.UpsertPolicy(f.ctx, dunningdomain.UpsertPolicyRequest{Enabled: true, RetryScheduleDays: []int{2, 5, 9}, FinalAction: "Cancel"})
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 5, 9}, policy.RetryScheduleDays)
+	assert.Equal(t, "cancel", policy.FinalAction)
+	assert.False(t, policy.SendReminders)
+
+	var count int64
+	require.NoError(t, f.db.Model(&dunningdomain.Policy{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestProcessDue_RetriesThenAppliesFinalAction(t *testing.T) {
+	f := setupDunningTest(t)
+	_, err := f.svc.UpsertPolicy(f.ctx, dunningdomain.UpsertPolicyRequest{Enabled: true, RetryScheduleDays: []int{1, 3}, SendReminders: true, FinalAction: "cancel"})
+	require.NoError(t, err)
+
+	failedAt := f.clock.Now()
+	f.createInvoice(t, datatypes.JSONMap{"payment_failed_at": failedAt.Format(time.RFC3339)}, failedAt.AddDate(0, 0, 14))
+	f.clock.Advance(time.Hour)
+
+	require.NoError(t, f.svc.ProcessDue(context.Background(), 10))
+	c := f.onlyCase(t)
+	assert.Equal(t, string(dunningdomain.CaseStatusOpen), c.Status)
+	assert.Equal(t, 0, c.Step)
+	require.NotNil(t, c.NextActionAt)
+	assert.True(t, c.NextActionAt.Equal(failedAt.AddDate(0, 0, 1)))
+	assert.Equal(t, subscriptiondomain.SubscriptionStatusPastDue, f.subs.status(f.subID))
+	assert.Equal(t, 1, f.mail.count())
+	assert.Equal(t, []string{"ap@acme.test"}, f.mail.sent[0].msg.To)
+	assert.Equal(t, reminderTemplate, f.mail.sent[0].template)
+
+	// Running again before the next step neither reopens nor advances the case.
+	require.NoError(t, f.svc.ProcessDue(context.Background(), 10))
+	assert.Equal(t, 0, f.onlyCase(t).Step)
+	assert.Equal(t, 1, f.mail.count())
+
+	f.clock.Advance(24 * time.Hour)
+	require.NoError(t, f.svc.ProcessDue(context.Background(), 10))
+	c = f.onlyCase(t)
+	assert.Equal(t, 1, c.Step)
+	require.NotNil(t, c.NextActionAt)
+	assert.True(t, c.NextActionAt.Equal(failedAt.AddDate(0, 0, 3)))
+	assert.Equal(t, 2, f.mail.count())
+
+	f.clock.Advance(2 * 24 * time.Hour)
+	require.NoError(t, f.svc.ProcessDue(context.Background(), 10))
+	c = f.onlyCase(t)
+	assert.Equal(t, string(dunningdomain.CaseStatusExhausted), c.Status)
+	require.NotNil(t, c.FinalAction)
+	assert.Equal(t, "cancel", *c.FinalAction)
+	assert.Nil(t, c.NextActionAt)
+	assert.Equal(t, subscriptiondomain.SubscriptionStatusCanceled, f.subs.status(f.subID))
+	assert.Equal(t, 3, f.mail.count())
+	assert.Contains(t, f.mail.sent[2].msg.Subject, "Final notice")
+
+	f.clock.Advance(30 * 24 * time.Hour)
+	require.NoError(t, f.svc.ProcessDue(context.Background(), 10))
+	assert.Equal(t, 3, f.mail.count())
+}
+
+func TestProcessDue_PaymentRecoversSubscription(t *testing.T) {
+	f := setupDunningTest(t)
+	_, err := f.svc.UpsertPolicy(f.ctx, dunningdomain.UpsertPolicyRequest{Enabled: true, RetryScheduleDays: []int{2, 4}, FinalAction: "leave_unpaid"})
+	require.NoError(t, err)
+
+	// An invoice that is simply past due opens a case anchored on its due date.
+	dueAt := f.clock.Now().Add(-time.Hour)
+	invoice := f.createInvoice(t, datatypes.JSONMap{}, dueAt)
+
+	require.NoError(t, f.svc.ProcessDue(context.Background(), 10))
+	c := f.onlyCase(t)
+	assert.True(t, c.TriggeredAt.Equal(dueAt))
+	assert.Equal(t, subscriptiondomain.SubscriptionStatusPastDue, f.subs.status(f.subID))
+	assert.Equal(t, 0, f.mail.count(), "reminders are disabled by the policy")
+
+	paidAt := f.clock.Now()
+	require.NoError(t, f.db.Model(&invoicedomain.Invoice{}).Where("id = ?", invoice.ID).Update("paid_at", paidAt).Error)
+	f.clock.Advance(time.Minute)
+
+	require.NoError(t, f.svc.ProcessDue(context.Background(), 10))
+	c = f.onlyCase(t)
+	assert.Equal(t, string(dunningdomain.CaseStatusRecovered), c.Status)
+	assert.NotNil(t, c.ResolvedAt)
+	assert.Equal(t, subscriptiondomain.SubscriptionStatusActive, f.subs.status(f.subID))
+}
+
+func TestProcessDue_SkipsOrganizationsWithoutEnabledPolicy(t *testing.T) {
+	f := setupDunningTest(t)
+	f.createInvoice(t, datatypes.JSONMap{}, f.clock.Now().Add(-48*time.Hour))
+
+	require.NoError(t, f.svc.ProcessDue(context.Background(), 10))
+	cases, err := f.svc.ListCases(f.ctx, dunningdomain.ListCasesRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, cases)
+	assert.Equal(t, subscriptiondomain.SubscriptionStatusActive, f.subs.status(f.subID))
+}
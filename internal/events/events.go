@@ -10,6 +10,11 @@ const (
 	EventDisputeWithdrawn   = "dispute_withdrawn"
 	EventDisputeReinstated  = "dispute_reinstated"
 	EventUsageIngested      = "usage.ingested"
+	EventUsageCorrected     = "usage.corrected"
+	EventUsageLate          = "usage.late"
+	EventUsageLimitExceeded = "usage.limit_exceeded"
+	EventCreditNoteIssued   = "credit_note.issued"
+	EventAlertTriggered     = "alert.triggered"
 )
 
 // LedgerEntryPayload captures the minimal data needed to roll up a ledger entry.
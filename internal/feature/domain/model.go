@@ -14,6 +14,15 @@ const (
 	FeatureTypeMetered FeatureType = "metered"
 )
 
+// LimitEnforcement decides what happens to usage over a feature's limit:
+// soft limits flag the usage, hard limits reject it.
+type LimitEnforcement string
+
+const (
+	LimitEnforcementSoft LimitEnforcement = "soft"
+	LimitEnforcementHard LimitEnforcement = "hard"
+)
+
 type Feature struct {
 	ID    snowflake.ID `gorm:"primaryKey"`
 	OrgID snowflake.ID `gorm:"column:org_id;not null;index:ux_features_org_code,priority:1"`
@@ -29,6 +29,8 @@ type Invoice struct {
 	InvoiceTemplateID *snowflake.ID     `gorm:"column:invoice_template_id;index"`
 	Status            InvoiceStatus     `gorm:"type:text;not null;default:'DRAFT'"`
 	SubtotalAmount    int64             `gorm:"not null;default:0"`
+	DiscountAmount    int64             `gorm:"not null;default:0"`
+	CreditAmount      int64             `gorm:"not null;default:0"`
 	TaxRate           *float64          `gorm:"column:tax_rate"`
 	TaxCode           *string           `gorm:"column:tax_code"`
 	TaxAmount         int64             `gorm:"not null;default:0"`
@@ -72,11 +74,14 @@ const (
 
 	// Tax line (VAT, GST, sales tax)
 	InvoiceItemLineTypeTax InvoiceItemLineType = "tax"
+
+	// Correction of an already closed billing cycle (positive or negative)
+	InvoiceItemLineTypeAdjustment InvoiceItemLineType = "adjustment"
 )
 
 func (t InvoiceItemLineType) String() string {
 	switch t {
-	case InvoiceItemLineTypeSubscription, InvoiceItemLineTypeUsage, InvoiceItemLineTypeCredit, InvoiceItemLineTypeOneOff, InvoiceItemLineTypeTax:
+	case InvoiceItemLineTypeSubscription, InvoiceItemLineTypeUsage, InvoiceItemLineTypeCredit, InvoiceItemLineTypeOneOff, InvoiceItemLineTypeTax, InvoiceItemLineTypeAdjustment:
 		return string(t)
 	default:
 		return ""
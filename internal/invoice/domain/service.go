@@ -38,6 +38,51 @@ type RenderInvoiceResponse struct {
 	IsSnapshot        bool    `json:"is_snapshot"`
 }
 
+// Payment methods accepted when an invoice is settled outside the payment providers.
+const (
+	PaymentMethodWireTransfer = "wire_transfer"
+	PaymentMethodCheque       = "cheque"
+	PaymentMethodCash         = "cash"
+	PaymentMethodOther        = "other"
+)
+
+// MarkPaidRequest records a payment received out of band, e.g. by wire transfer or cheque.
+type MarkPaidRequest struct {
+	PaymentMethod string     `json:"payment_method"`
+	Reference     string     `json:"reference"`
+	PaidAt        *time.Time `json:"paid_at"`
+}
+
+// UpcomingInvoice is a dry-run of the invoice the customer's current OPEN
+// billing cycle will produce at close, rated against usage ingested so far.
+// Nothing in it is persisted.
+type UpcomingInvoice struct {
+	CustomerID     string                `json:"customer_id"`
+	SubscriptionID string                `json:"subscription_id"`
+	BillingCycleID string                `json:"billing_cycle_id"`
+	Currency       string                `json:"currency"`
+	PeriodStart    time.Time             `json:"period_start"`
+	PeriodEnd      time.Time             `json:"period_end"`
+	Lines          []UpcomingInvoiceLine `json:"lines"`
+	SubtotalAmount int64                 `json:"subtotal_amount"`
+	DiscountAmount int64                 `json:"discount_amount"`
+	CreditAmount   int64                 `json:"credit_amount"`
+	TaxCode        *string               `json:"tax_code,omitempty"`
+	TaxRate        *float64              `json:"tax_rate,omitempty"`
+	TaxAmount      int64                 `json:"tax_amount"`
+	TotalAmount    int64                 `json:"total_amount"`
+	PreviewedAt    time.Time             `json:"previewed_at"`
+}
+
+type UpcomingInvoiceLine struct {
+	LineType    InvoiceItemLineType `json:"line_type"`
+	Description string              `json:"description"`
+	Quantity    float64             `json:"quantity"`
+	UnitPrice   int64               `json:"unit_price"`
+	Amount      int64               `json:"amount"`
+	Metadata    map[string]any      `json:"metadata,omitempty"`
+}
+
 type Service interface {
 	List(context.Context, ListInvoiceRequest) (ListInvoiceResponse, error)
 	GetByID(ctx context.Context, id string) (Invoice, error)
@@ -45,6 +90,11 @@ type Service interface {
 	GenerateInvoice(ctx context.Context, billingCycleID string) (*Invoice, error)
 	FinalizeInvoice(ctx context.Context, invoiceID string) error
 	VoidInvoice(ctx context.Context, invoiceID string, reason string) error
+	// MarkInvoicePaid settles a finalized invoice with a payment received out of band.
+	MarkInvoicePaid(ctx context.Context, invoiceID string, req MarkPaidRequest) error
+	// PreviewUpcomingInvoice builds the customer's next invoice without persisting
+	// anything. subscriptionID picks the subscription when the customer has several.
+	PreviewUpcomingInvoice(ctx context.Context, customerID string, subscriptionID string) (*UpcomingInvoice, error)
 }
 
 var (
@@ -62,4 +112,13 @@ var (
 	ErrInvoiceNotFinalized     = errors.New("invoice_not_finalized")
 	ErrInvoiceTemplateNotFound = errors.New("invoice_template_not_found")
 	ErrInvoiceRenderMissing    = errors.New("invoice_render_missing")
+	ErrInvalidCustomer         = errors.New("invalid_customer")
+	ErrInvalidSubscription     = errors.New("invalid_subscription")
+	ErrAmbiguousSubscription   = errors.New("ambiguous_subscription")
+	ErrNoUpcomingInvoice       = errors.New("upcoming_invoice_not_found")
+	ErrInvoiceAlreadyPaid      = errors.New("invoice_already_paid")
+	ErrInvoiceHasCreditNotes   = errors.New("invoice_has_credit_notes")
+	ErrCarriedCreditUsed       = errors.New("carried_credit_used")
+	ErrInvalidPaymentMethod    = errors.New("invalid_payment_method")
+	ErrInvalidPaidAt           = errors.New("invalid_paid_at")
 )
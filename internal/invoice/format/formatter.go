@@ -12,7 +12,10 @@ var (
 	seqPadRe = regexp.MustCompile(`\{SEQ(\d+)\}`)
 )
 
-const DefaultInvoiceNumberTemplate = "INV-{YYYY}{MM}{DD}-{SEQ6}"
+const (
+	DefaultInvoiceNumberTemplate    = "INV-{YYYY}{MM}{DD}-{SEQ6}"
+	DefaultCreditNoteNumberTemplate = "CN-{YYYY}{MM}{DD}-{SEQ6}"
+)
 
 // FormatInvoiceNumber formats a human-readable invoice number
 // based on a template, invoice issue time, and monotonic sequence.
@@ -0,0 +1,206 @@
+package render
+
+const creditNoteHTMLTemplate = `<!doctype html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>Credit Note {{.CreditNote.Number}}</title>
+  <style>
+    :root {
+      --primary: {{.Template.PrimaryColor}};
+      --font: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, Helvetica, Arial, sans-serif;
+    }
+    * { box-sizing: border-box; }
+    body {
+      margin: 0;
+      padding: 40px;
+      font-family: var(--font);
+      color: #1a1f36;
+      background: #f7f9fc;
+      -webkit-font-smoothing: antialiased;
+    }
+    .credit-note-card {
+      background: #ffffff;
+      max-width: 760px;
+      margin: 0 auto;
+      padding: 60px;
+      box-shadow: 0 2px 5px rgba(0,0,0,0.04);
+      border-radius: 4px;
+    }
+    .header {
+      display: flex;
+      justify-content: space-between;
+      margin-bottom: 40px;
+    }
+    .header-left h1 {
+      margin: 0;
+      font-size: 24px;
+      font-weight: 700;
+    }
+    .header-right {
+      text-align: right;
+      font-weight: 600;
+      color: #8792a2;
+      font-size: 16px;
+    }
+    .meta-grid {
+      display: flex;
+      justify-content: space-between;
+      margin-bottom: 40px;
+    }
+    .col { flex: 1; }
+    .label {
+      font-size: 11px;
+      text-transform: uppercase;
+      color: #8792a2;
+      margin-bottom: 6px;
+      font-weight: 600;
+      letter-spacing: 0.3px;
+    }
+    .value {
+      font-size: 14px;
+      line-height: 1.5;
+    }
+    .amount-large {
+      font-size: 32px;
+      font-weight: 700;
+      margin-bottom: 40px;
+    }
+    table {
+      width: 100%;
+      border-collapse: collapse;
+      margin-bottom: 30px;
+    }
+    th {
+      text-align: left;
+      text-transform: uppercase;
+      font-size: 11px;
+      color: #8792a2;
+      border-bottom: 1px solid #e3e8ee;
+      padding: 10px 0;
+      font-weight: 600;
+    }
+    td {
+      padding: 16px 0;
+      border-bottom: 1px solid #e3e8ee;
+      font-size: 14px;
+      vertical-align: top;
+    }
+    .td-right { text-align: right; }
+    .totals {
+      display: flex;
+      flex-direction: column;
+      align-items: flex-end;
+    }
+    .total-row {
+      display: flex;
+      justify-content: space-between;
+      width: 250px;
+      padding: 6px 0;
+      font-size: 14px;
+    }
+    .total-label { color: #697386; }
+    .total-final {
+      border-top: 1px solid #e3e8ee;
+      margin-top: 10px;
+      padding-top: 10px;
+      font-weight: 700;
+      font-size: 16px;
+    }
+    .footer {
+      margin-top: 60px;
+      font-size: 12px;
+      color: #8792a2;
+      border-top: 1px solid #e3e8ee;
+      padding-top: 20px;
+    }
+  </style>
+</head>
+<body>
+  <div class="credit-note-card">
+    <div class="header">
+      <div class="header-left">
+        <h1>Credit Note</h1>
+        <div class="label" style="margin-top: 12px;">Credit note number</div>
+        <div class="value">{{.CreditNote.Number}}</div>
+      </div>
+      <div class="header-right">
+        {{if .Template.LogoURL}}
+          <img src="{{.Template.LogoURL}}" style="max-height: 40px;" alt="{{.Template.CompanyName}}">
+        {{else}}
+          {{.Template.CompanyName}}
+        {{end}}
+      </div>
+    </div>
+
+    <div class="meta-grid">
+      <div class="col">
+        <div class="label">Credit to</div>
+        <div class="value">
+          <strong>{{.Customer.Name}}</strong><br>
+          {{.Customer.Email}}
+        </div>
+      </div>
+      <div class="col" style="flex: 0 0 200px;">
+        <div class="label">Original invoice</div>
+        <div class="value">{{.Invoice.Number}}</div>
+
+        <div class="label" style="margin-top: 16px;">Date issued</div>
+        <div class="value">{{formatDate .CreditNote.IssuedAt}}</div>
+
+        <div class="label" style="margin-top: 16px;">Reason</div>
+        <div class="value">{{.CreditNote.Reason}}</div>
+      </div>
+    </div>
+
+    <div class="amount-large">{{formatMoney .CreditNote.TotalAmount .CreditNote.Currency}} credited</div>
+
+    <table>
+      <thead>
+        <tr>
+          <th style="width: 50%;">Description</th>
+          <th class="td-right">Qty</th>
+          <th class="td-right">Unit Price</th>
+          <th class="td-right">Amount</th>
+        </tr>
+      </thead>
+      <tbody>
+        {{range .Items}}
+        <tr>
+          <td>{{.Title}}</td>
+          <td class="td-right">{{formatQuantity .Quantity}}</td>
+          <td class="td-right">{{formatMoney .UnitPrice $.CreditNote.Currency}}</td>
+          <td class="td-right" style="font-weight: 500;">{{formatMoney .Amount $.CreditNote.Currency}}</td>
+        </tr>
+        {{end}}
+      </tbody>
+    </table>
+
+    <div class="totals">
+      <div class="total-row">
+        <span class="total-label">Subtotal</span>
+        <span>{{formatMoney .CreditNote.SubtotalAmount .CreditNote.Currency}}</span>
+      </div>
+      {{if .CreditNote.TaxAmount}}
+      <div class="total-row">
+        <span class="total-label">Tax</span>
+        <span>{{formatMoney .CreditNote.TaxAmount .CreditNote.Currency}}</span>
+      </div>
+      {{end}}
+      <div class="total-row total-final">
+        <span>Total credited</span>
+        <span>{{formatMoney .CreditNote.TotalAmount .CreditNote.Currency}}</span>
+      </div>
+    </div>
+
+    {{if or .CreditNote.Memo .Template.FooterNotes}}
+    <div class="footer">
+      {{if .CreditNote.Memo}}{{.CreditNote.Memo}}<br><br>{{end}}
+      {{.Template.FooterNotes}}
+      {{if .Template.FooterLegal}}<br><br>{{.Template.FooterLegal}}{{end}}
+    </div>
+    {{end}}
+  </div>
+</body>
+</html>
+`
@@ -7,6 +7,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/smallbiznis/railzway/internal/money"
 )
 
 const invoiceHTMLTemplate = `<!doctype html>
@@ -260,7 +262,8 @@ var (
 )
 
 type HTMLRenderer struct {
-	tpl *template.Template
+	tpl           *template.Template
+	creditNoteTpl *template.Template
 }
 
 func NewRenderer() Renderer {
@@ -270,7 +273,8 @@ func NewRenderer() Renderer {
 		"formatQuantity": formatQuantity,
 	}
 	return &HTMLRenderer{
-		tpl: template.Must(template.New("invoice").Funcs(funcs).Parse(invoiceHTMLTemplate)),
+		tpl:           template.Must(template.New("invoice").Funcs(funcs).Parse(invoiceHTMLTemplate)),
+		creditNoteTpl: template.Must(template.New("credit_note").Funcs(funcs).Parse(creditNoteHTMLTemplate)),
 	}
 }
 
@@ -289,13 +293,23 @@ func (r *HTMLRenderer) RenderHTML(input RenderInput) (string, error) {
 	return buf.String(), nil
 }
 
-func formatMoney(amount int64, currency string) string {
-	currency = strings.ToUpper(strings.TrimSpace(currency))
-	if currency == "" {
-		currency = "USD"
+func (r *HTMLRenderer) RenderCreditNoteHTML(input CreditNoteRenderInput) (string, error) {
+	input.Template.PrimaryColor = sanitizeColor(input.Template.PrimaryColor)
+	input.Template.FontFamily = sanitizeFont(input.Template.FontFamily)
+	if input.Template.CompanyName == "" {
+		input.Template.CompanyName = "Credit Note"
+	}
+
+	var buf bytes.Buffer
+	if err := r.creditNoteTpl.Execute(&buf, input); err != nil {
+		return "", err
 	}
-	value := float64(amount) / 100.0
-	return fmt.Sprintf("%s %.2f", currency, value)
+
+	return buf.String(), nil
+}
+
+func formatMoney(amount int64, currency string) string {
+	return money.Format(amount, currency)
 }
 
 func formatDate(value *time.Time) string {
@@ -0,0 +1,39 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/smallbiznis/railzway/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderHTML_CurrencyMinorUnits(t *testing.T) {
+	money.SetMinorUnits(map[string]int32{"USD": 2, "JPY": 0, "KWD": 3})
+	t.Cleanup(func() { money.SetMinorUnits(nil) })
+
+	cases := []struct {
+		currency string
+		subtotal int64
+		price    int64
+		want     []string
+	}{
+		{currency: "USD", subtotal: 123456, price: 1999, want: []string{"USD 1234.56", "USD 19.99"}},
+		{currency: "JPY", subtotal: 123456, price: 1999, want: []string{"JPY 123456", "JPY 1999"}},
+		{currency: "KWD", subtotal: 123456, price: 1999, want: []string{"KWD 123.456", "KWD 1.999"}},
+	}
+
+	renderer := NewRenderer()
+	for _, tc := range cases {
+		t.Run(tc.currency, func(t *testing.T) {
+			html, err := renderer.RenderHTML(RenderInput{
+				Invoice: InvoiceView{Number: "INV-1", SubtotalAmount: tc.subtotal, Currency: tc.currency},
+				Items:   []LineItemView{{Title: "Seats", Quantity: 1, UnitPrice: tc.price, Amount: tc.price}},
+			})
+			require.NoError(t, err)
+			for _, want := range tc.want {
+				assert.Contains(t, html, want)
+			}
+		})
+	}
+}
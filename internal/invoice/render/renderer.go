@@ -48,6 +48,28 @@ type LineItemView struct {
 	Amount    int64
 }
 
+// CreditNoteRenderInput is the deterministic input used for credit note rendering.
+type CreditNoteRenderInput struct {
+	Template   TemplateView
+	CreditNote CreditNoteView
+	Invoice    InvoiceView
+	Customer   CustomerView
+	Items      []LineItemView
+}
+
+type CreditNoteView struct {
+	ID             string
+	Number         string
+	Reason         string
+	Memo           string
+	IssuedAt       *time.Time
+	SubtotalAmount int64
+	TaxAmount      int64
+	TotalAmount    int64
+	Currency       string
+}
+
 type Renderer interface {
 	RenderHTML(input RenderInput) (string, error)
+	RenderCreditNoteHTML(input CreditNoteRenderInput) (string, error)
 }
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// adjustmentRow is a PENDING billing adjustment with the period it corrects.
+type adjustmentRow struct {
+	ID                snowflake.ID
+	OrgID             snowflake.ID
+	BillingCycleID    snowflake.ID
+	UsageEventID      snowflake.ID
+	CorrectionEventID snowflake.ID
+	Reason            string
+	Amount            int64
+	PeriodStart       time.Time
+	PeriodEnd         time.Time
+}
+
+// resolveAdjustments returns the pending adjustments of the cycle's
+// subscription that go on its next invoice, oldest first, and their total.
+// Negative adjustments are only taken while the subtotal stays non-negative;
+// the rest stays pending for a later invoice.
+func (s *Service) resolveAdjustments(
+	ctx context.Context,
+	tx *gorm.DB,
+	cycle billingCycleRow,
+	currency string,
+	subtotal int64,
+) ([]adjustmentRow, int64, error) {
+	var rows []adjustmentRow
+	if err := tx.WithContext(ctx).Raw(
+		`SELECT a.id, a.org_id, a.billing_cycle_id, a.usage_event_id, a.correction_event_id,
+		        a.reason, a.amount, bc.period_start, bc.period_end
+		 FROM billing_adjustments a
+		 JOIN billing_cycles bc ON bc.id = a.billing_cycle_id
+		 WHERE a.org_id = ?
+		   AND a.subscription_id = ?
+		   AND a.status = ?
+		   AND a.currency = ?
+		 ORDER BY a.created_at ASC, a.id ASC`,
+		cycle.OrgID,
+		cycle.SubscriptionID,
+		adjustmentdomain.AdjustmentStatusPending,
+		currency,
+	).Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	applied := make([]adjustmentRow, 0, len(rows))
+	var total int64
+	for _, row := range rows {
+		if subtotal+total+row.Amount < 0 {
+			continue
+		}
+		total += row.Amount
+		applied = append(applied, row)
+	}
+	return applied, total, nil
+}
+
+// buildAdjustmentInvoiceItems renders one adjustment line per delta rating
+// result of the adjustment, dated with the period it corrects.
+func (s *Service) buildAdjustmentInvoiceItems(
+	ctx context.Context,
+	tx *gorm.DB,
+	adjustment adjustmentRow,
+	invoiceID snowflake.ID,
+	currency string,
+	now time.Time,
+) ([]invoicedomain.InvoiceItem, error) {
+	var rows []ratingRow
+	if err := tx.WithContext(ctx).
+		Table("rating_results").
+		Select(`id, org_id, meter_id, price_id, feature_code, quantity, unit_price, amount, currency, source`).
+		Where("org_id = ? AND adjustment_id = ? AND amount <> 0", adjustment.OrgID, adjustment.ID).
+		Order("id ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		rows = append(rows, ratingRow{OrgID: adjustment.OrgID, Quantity: 1, Amount: adjustment.Amount, Currency: currency})
+	}
+
+	period := billingCycleRow{PeriodStart: adjustment.PeriodStart, PeriodEnd: adjustment.PeriodEnd}
+	items := make([]invoicedomain.InvoiceItem, 0, len(rows))
+	for _, r := range rows {
+		part := invoiceItemPart{
+			Type:        invoicedomain.InvoiceItemLineTypeAdjustment,
+			DisplayName: "Usage adjustment: " + adjustment.Reason,
+			Quantity:    r.Quantity,
+			RateAmount:  r.UnitPrice,
+			Amount:      r.Amount,
+			Currency:    currency,
+		}
+		item := invoicedomain.InvoiceItem{
+			ID:          s.genID.Generate(),
+			OrgID:       adjustment.OrgID,
+			InvoiceID:   invoiceID,
+			LineType:    invoicedomain.InvoiceItemLineTypeAdjustment,
+			Description: s.formatInvoiceItemDescription(part, period),
+			Quantity:    r.Quantity,
+			UnitPrice:   r.UnitPrice,
+			Amount:      r.Amount,
+			Metadata: datatypes.JSONMap{
+				"adjustment_id":       adjustment.ID.String(),
+				"billing_cycle_id":    adjustment.BillingCycleID.String(),
+				"usage_event_id":      adjustment.UsageEventID.String(),
+				"correction_event_id": adjustment.CorrectionEventID.String(),
+			},
+			CreatedAt: now,
+		}
+		if r.ID != 0 {
+			id := r.ID
+			item.RatingResultID = &id
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// recordAdjustments writes the adjustment lines and marks the adjustments as
+// INVOICED on invoiceID.
+func (s *Service) recordAdjustments(
+	ctx context.Context,
+	tx *gorm.DB,
+	invoiceID snowflake.ID,
+	currency string,
+	adjustments []adjustmentRow,
+	now time.Time,
+) error {
+	for _, adjustment := range adjustments {
+		items, err := s.buildAdjustmentInvoiceItems(ctx, tx, adjustment, invoiceID, currency, now)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := s.insertInvoiceItem(ctx, tx, item); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.WithContext(ctx).Exec(
+			`UPDATE billing_adjustments
+			 SET status = ?, invoice_id = ?, invoiced_at = ?, updated_at = ?
+			 WHERE org_id = ? AND id = ? AND status = ?`,
+			adjustmentdomain.AdjustmentStatusInvoiced,
+			invoiceID,
+			now,
+			now,
+			adjustment.OrgID,
+			adjustment.ID,
+			adjustmentdomain.AdjustmentStatusPending,
+		).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseAdjustments returns the adjustments billed on a voided invoice to
+// PENDING, so the next invoice picks the corrections up again.
+func (s *Service) releaseAdjustments(ctx context.Context, tx *gorm.DB, invoice *invoicedomain.Invoice, now time.Time) error {
+	return tx.WithContext(ctx).Exec(
+		`UPDATE billing_adjustments
+		 SET status = ?, invoice_id = NULL, invoiced_at = NULL, updated_at = ?
+		 WHERE org_id = ? AND invoice_id = ? AND status = ?`,
+		adjustmentdomain.AdjustmentStatusPending,
+		now,
+		invoice.OrgID,
+		invoice.ID,
+		adjustmentdomain.AdjustmentStatusInvoiced,
+	).Error
+}
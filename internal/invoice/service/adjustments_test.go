@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/glebarez/sqlite"
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestAdjustments_BilledOnNextInvoice(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&invoicedomain.Invoice{},
+		&invoicedomain.InvoiceItem{},
+		&ratingdomain.RatingResult{},
+		&adjustmentdomain.Adjustment{},
+		&creditnotedomain.CreditNote{},
+	))
+	require.NoError(t, db.Exec("CREATE TABLE billing_cycles (id BIGINT, org_id BIGINT, subscription_id BIGINT, period_start DATETIME, period_end DATETIME, status TEXT)").Error)
+
+	node, _ := snowflake.NewNode(1)
+	svc := NewService(ServiceParam{DB: db, Log: zap.NewNop(), GenID: node}).(*Service)
+
+	orgID := node.Generate()
+	customerID := node.Generate()
+	subID := node.Generate()
+	meterID := node.Generate()
+	now := time.Now().UTC()
+	closedID := node.Generate()
+	closedStart := now.AddDate(0, -2, 0)
+	closedEnd := now.AddDate(0, -1, 0)
+	require.NoError(t, db.Exec("INSERT INTO billing_cycles (id, org_id, subscription_id, period_start, period_end, status) VALUES (?, ?, ?, ?, ?, ?)",
+		closedID, orgID, subID, closedStart, closedEnd, "CLOSED").Error)
+
+	newAdjustment := func(amount int64, currency string, createdAt time.Time) adjustmentdomain.Adjustment {
+		a := adjustmentdomain.Adjustment{
+			ID:                node.Generate(),
+			OrgID:             orgID,
+			CustomerID:        customerID,
+			SubscriptionID:    subID,
+			BillingCycleID:    closedID,
+			UsageEventID:      node.Generate(),
+			CorrectionEventID: node.Generate(),
+			Reason:            "duplicate event",
+			Amount:            amount,
+			Currency:          currency,
+			Status:            adjustmentdomain.AdjustmentStatusPending,
+			CreatedAt:         createdAt,
+			UpdatedAt:         createdAt,
+		}
+		require.NoError(t, db.Create(&a).Error)
+		return a
+	}
+	credit := newAdjustment(-300, "USD", now.Add(-3*time.Hour))
+	tooLarge := newAdjustment(-5000, "USD", now.Add(-2*time.Hour))
+	other := newAdjustment(200, "EUR", now.Add(-time.Hour))
+
+	delta := ratingdomain.RatingResult{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		SubscriptionID: subID,
+		BillingCycleID: closedID,
+		PriceID:        node.Generate(),
+		MeterID:        &meterID,
+		Quantity:       -3,
+		UnitPrice:      100,
+		Amount:         -300,
+		Currency:       "USD",
+		PeriodStart:    closedStart,
+		PeriodEnd:      closedEnd,
+		Source:         "adjustment",
+		Checksum:       "adjustment-delta",
+		AdjustmentID:   &credit.ID,
+		CreatedAt:      now,
+	}
+	require.NoError(t, db.Create(&delta).Error)
+
+	cycle := billingCycleRow{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		SubscriptionID: subID,
+		PeriodStart:    closedEnd,
+		PeriodEnd:      now,
+		Status:         billingcycledomain.BillingCycleStatusClosed,
+	}
+	invoiceID := node.Generate()
+
+	var (
+		applied []adjustmentRow
+		total   int64
+	)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		applied, total, err = svc.resolveAdjustments(context.Background(), tx, cycle, "USD", 1000)
+		if err != nil {
+			return err
+		}
+		return svc.recordAdjustments(context.Background(), tx, invoiceID, "USD", applied, now)
+	})
+	require.NoError(t, err)
+
+	// The credit larger than the subtotal and the EUR adjustment wait.
+	require.Len(t, applied, 1)
+	assert.Equal(t, credit.ID, applied[0].ID)
+	assert.Equal(t, int64(-300), total)
+
+	var items []invoicedomain.InvoiceItem
+	require.NoError(t, db.Where("invoice_id = ?", invoiceID).Find(&items).Error)
+	require.Len(t, items, 1)
+	assert.Equal(t, invoicedomain.InvoiceItemLineTypeAdjustment, items[0].LineType)
+	assert.Equal(t, int64(-300), items[0].Amount)
+	require.NotNil(t, items[0].RatingResultID)
+	assert.Equal(t, delta.ID, *items[0].RatingResultID)
+	assert.Contains(t, items[0].Description, "Usage adjustment: duplicate event")
+	assert.Equal(t, credit.ID.String(), items[0].Metadata["adjustment_id"])
+
+	var reloaded []adjustmentdomain.Adjustment
+	require.NoError(t, db.Order("created_at ASC").Find(&reloaded).Error)
+	require.Len(t, reloaded, 3)
+	assert.Equal(t, adjustmentdomain.AdjustmentStatusInvoiced, reloaded[0].Status)
+	require.NotNil(t, reloaded[0].InvoiceID)
+	assert.Equal(t, invoiceID, *reloaded[0].InvoiceID)
+	assert.Equal(t, tooLarge.ID, reloaded[1].ID)
+	assert.Equal(t, adjustmentdomain.AdjustmentStatusPending, reloaded[1].Status)
+	assert.Equal(t, other.ID, reloaded[2].ID)
+	assert.Equal(t, adjustmentdomain.AdjustmentStatusPending, reloaded[2].Status)
+
+	// Voiding the invoice puts the correction back in line for the next one.
+	require.NoError(t, db.Create(&invoicedomain.Invoice{
+		ID: invoiceID, OrgID: orgID, BillingCycleID: cycle.ID, SubscriptionID: subID, CustomerID: customerID,
+		Status: invoicedomain.InvoiceStatusFinalized, SubtotalAmount: 700, Currency: "USD", CreatedAt: now, UpdatedAt: now,
+	}).Error)
+	require.NoError(t, svc.VoidInvoice(context.Background(), invoiceID.String(), ""))
+
+	var released adjustmentdomain.Adjustment
+	require.NoError(t, db.First(&released, "id = ?", credit.ID).Error)
+	assert.Equal(t, adjustmentdomain.AdjustmentStatusPending, released.Status)
+	assert.Nil(t, released.InvoiceID)
+	assert.Nil(t, released.InvoicedAt)
+}
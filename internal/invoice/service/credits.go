@@ -0,0 +1,576 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	creditdomain "github.com/smallbiznis/railzway/internal/credit/domain"
+	"github.com/smallbiznis/railzway/internal/events"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	ledgerdomain "github.com/smallbiznis/railzway/internal/ledger/domain"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type creditGrantRow struct {
+	ID              snowflake.ID
+	Name            string
+	RemainingAmount int64
+}
+
+// appliedCredit is a credit grant drawn down for one invoice, before it is
+// persisted.
+type appliedCredit struct {
+	grant  creditGrantRow
+	amount int64
+}
+
+// resolveCredits draws the customer's available credit grants down against
+// subtotal. Grants are consumed by ascending priority, then earliest expiry
+// (grants without expiry last), then age. Only grants in the invoice currency
+// apply. Set lock when the drawdown is going to be recorded.
+func (s *Service) resolveCredits(
+	ctx context.Context,
+	tx *gorm.DB,
+	orgID snowflake.ID,
+	customerID snowflake.ID,
+	currency string,
+	subtotal int64,
+	now time.Time,
+	lock bool,
+) ([]appliedCredit, error) {
+	if subtotal <= 0 {
+		return nil, nil
+	}
+
+	query := `SELECT id, name, remaining_amount
+		 FROM credit_grants
+		 WHERE org_id = ?
+		   AND customer_id = ?
+		   AND status = ?
+		   AND currency = ?
+		   AND remaining_amount > 0
+		   AND (expires_at IS NULL OR expires_at > ?)
+		 ORDER BY priority ASC,
+		          CASE WHEN expires_at IS NULL THEN 1 ELSE 0 END,
+		          expires_at ASC,
+		          created_at ASC,
+		          id ASC`
+	if lock && tx.Dialector.Name() != "sqlite" {
+		query += " FOR UPDATE"
+	}
+
+	var rows []creditGrantRow
+	if err := tx.WithContext(ctx).Raw(
+		query,
+		orgID,
+		customerID,
+		creditdomain.GrantStatusActive,
+		strings.ToUpper(currency),
+		now,
+	).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	remaining := subtotal
+	applied := make([]appliedCredit, 0, len(rows))
+	for _, row := range rows {
+		if remaining <= 0 {
+			break
+		}
+		amount := min(row.RemainingAmount, remaining)
+		remaining -= amount
+		applied = append(applied, appliedCredit{grant: row, amount: amount})
+	}
+	return applied, nil
+}
+
+// recordCredits writes one credit line per drawn grant, reduces the grants'
+// remaining balance and records the use in the credit transaction history.
+func (s *Service) recordCredits(
+	ctx context.Context,
+	tx *gorm.DB,
+	invoice *invoicedomain.Invoice,
+	credits []appliedCredit,
+	now time.Time,
+) error {
+	cycle := invoiceCycle(invoice)
+	for _, applied := range credits {
+		item := s.buildCreditInvoiceItem(cycle, invoice.ID, invoice.Currency, applied, now)
+		if err := s.insertInvoiceItem(ctx, tx, item); err != nil {
+			return err
+		}
+
+		remaining := applied.grant.RemainingAmount - applied.amount
+		status := creditdomain.GrantStatusActive
+		if remaining == 0 {
+			status = creditdomain.GrantStatusDepleted
+		}
+		if err := tx.WithContext(ctx).Exec(
+			`UPDATE credit_grants
+			 SET remaining_amount = ?, status = ?, updated_at = ?
+			 WHERE org_id = ? AND id = ?`,
+			remaining,
+			status,
+			now,
+			invoice.OrgID,
+			applied.grant.ID,
+		).Error; err != nil {
+			return err
+		}
+
+		invoiceID := invoice.ID
+		if err := tx.WithContext(ctx).Exec(
+			`INSERT INTO credit_transactions (
+				id, org_id, customer_id, grant_id, type, amount, currency, invoice_id, description, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			s.genID.Generate(),
+			invoice.OrgID,
+			invoice.CustomerID,
+			applied.grant.ID,
+			creditdomain.TransactionTypeUse,
+			-applied.amount,
+			invoice.Currency,
+			&invoiceID,
+			"Applied to invoice "+invoice.InvoiceNumber,
+			now,
+		).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreCredits gives back the credit a voided invoice drew down, records
+// the reversal in the credit transaction history and reverses its credit use
+// in the ledger. Restored grants become active again; grants that expired in
+// the meantime are left for the expiry job to write off.
+func (s *Service) restoreCredits(ctx context.Context, tx *gorm.DB, invoice *invoicedomain.Invoice, now time.Time) error {
+	refs, err := s.listInvoiceItemRefs(ctx, tx, invoice.ID, invoicedomain.InvoiceItemLineTypeCredit, "credit_grant_id")
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		amount := -ref.Amount
+		if amount <= 0 {
+			continue
+		}
+		if err := tx.WithContext(ctx).Exec(
+			`UPDATE credit_grants
+			 SET remaining_amount = remaining_amount + ?, status = ?, expired_at = NULL, updated_at = ?
+			 WHERE org_id = ? AND id = ?`,
+			amount,
+			creditdomain.GrantStatusActive,
+			now,
+			invoice.OrgID,
+			ref.ID,
+		).Error; err != nil {
+			return err
+		}
+
+		invoiceID := invoice.ID
+		if err := tx.WithContext(ctx).Exec(
+			`INSERT INTO credit_transactions (
+				id, org_id, customer_id, grant_id, type, amount, currency, invoice_id, description, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			s.genID.Generate(),
+			invoice.OrgID,
+			invoice.CustomerID,
+			ref.ID,
+			creditdomain.TransactionTypeReversal,
+			amount,
+			invoice.Currency,
+			&invoiceID,
+			"Restored from voided invoice "+invoice.InvoiceNumber,
+			now,
+		).Error; err != nil {
+			return err
+		}
+	}
+	return s.reverseCreditUseInLedger(ctx, tx, invoice, now)
+}
+
+// carryCredit issues a pending credit grant for the net credit of a draft
+// invoice and adds the line that moves it off the invoice, so the lines still
+// sum to the subtotal. The grant becomes available when the invoice is
+// finalized. The billing cycle's ledger entry has already booked the amount to
+// the credit balance account, so the grant is not posted again.
+func (s *Service) carryCredit(ctx context.Context, tx *gorm.DB, invoice *invoicedomain.Invoice, amount int64, now time.Time) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	grantID := s.genID.Generate()
+	if err := tx.WithContext(ctx).Exec(
+		`INSERT INTO credit_grants (
+			id, org_id, customer_id, name, type, amount, remaining_amount, currency,
+			priority, status, metadata, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		grantID,
+		invoice.OrgID,
+		invoice.CustomerID,
+		"Credit from invoice "+invoice.InvoiceNumber,
+		creditdomain.GrantTypePrepaid,
+		amount,
+		amount,
+		strings.ToUpper(invoice.Currency),
+		0,
+		creditdomain.GrantStatusPending,
+		datatypes.JSONMap{
+			"invoice_id":       invoice.ID.String(),
+			"billing_cycle_id": invoice.BillingCycleID.String(),
+		},
+		now,
+		now,
+	).Error; err != nil {
+		return err
+	}
+
+	cycle := invoiceCycle(invoice)
+	part := invoiceItemPart{
+		Type:        invoicedomain.InvoiceItemLineTypeCredit,
+		DisplayName: "Credit carried to balance",
+		Quantity:    1,
+		Amount:      amount,
+		Currency:    invoice.Currency,
+	}
+	return s.insertInvoiceItem(ctx, tx, invoicedomain.InvoiceItem{
+		ID:          s.genID.Generate(),
+		OrgID:       invoice.OrgID,
+		InvoiceID:   invoice.ID,
+		LineType:    invoicedomain.InvoiceItemLineTypeCredit,
+		Description: s.formatInvoiceItemDescription(part, cycle),
+		Quantity:    1,
+		UnitPrice:   amount,
+		Amount:      amount,
+		Metadata: datatypes.JSONMap{
+			"credit_grant_id": grantID.String(),
+		},
+		CreatedAt: now,
+	})
+}
+
+// activateCarriedCredit makes the credit carried by a finalized invoice
+// available and records the grant in the credit transaction history.
+func (s *Service) activateCarriedCredit(ctx context.Context, tx *gorm.DB, invoice *invoicedomain.Invoice, now time.Time) error {
+	refs, err := s.listInvoiceItemRefs(ctx, tx, invoice.ID, invoicedomain.InvoiceItemLineTypeCredit, "credit_grant_id")
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ref.Amount <= 0 {
+			continue
+		}
+		result := tx.WithContext(ctx).Exec(
+			`UPDATE credit_grants
+			 SET status = ?, updated_at = ?
+			 WHERE org_id = ? AND id = ? AND status = ?`,
+			creditdomain.GrantStatusActive,
+			now,
+			invoice.OrgID,
+			ref.ID,
+			creditdomain.GrantStatusPending,
+		)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		invoiceID := invoice.ID
+		if err := tx.WithContext(ctx).Exec(
+			`INSERT INTO credit_transactions (
+				id, org_id, customer_id, grant_id, type, amount, currency, invoice_id, description, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			s.genID.Generate(),
+			invoice.OrgID,
+			invoice.CustomerID,
+			ref.ID,
+			creditdomain.TransactionTypeGrant,
+			ref.Amount,
+			strings.ToUpper(invoice.Currency),
+			&invoiceID,
+			"Carried from invoice "+invoice.InvoiceNumber,
+			now,
+		).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeCarriedCredit takes back the credit a voided invoice carried to the
+// customer's balance and records the reversal in the credit transaction
+// history. Credit already drawn by another invoice cannot be taken back, so
+// the void is refused.
+func (s *Service) revokeCarriedCredit(ctx context.Context, tx *gorm.DB, invoice *invoicedomain.Invoice, now time.Time) error {
+	refs, err := s.listInvoiceItemRefs(ctx, tx, invoice.ID, invoicedomain.InvoiceItemLineTypeCredit, "credit_grant_id")
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ref.Amount <= 0 {
+			continue
+		}
+
+		query := `SELECT id, name, remaining_amount FROM credit_grants WHERE org_id = ? AND id = ?`
+		if tx.Dialector.Name() != "sqlite" {
+			query += " FOR UPDATE"
+		}
+		var grant creditGrantRow
+		if err := tx.WithContext(ctx).Raw(query, invoice.OrgID, ref.ID).Scan(&grant).Error; err != nil {
+			return err
+		}
+		if grant.ID == 0 {
+			continue
+		}
+		if grant.RemainingAmount < ref.Amount {
+			return invoicedomain.ErrCarriedCreditUsed
+		}
+
+		if err := tx.WithContext(ctx).Exec(
+			`UPDATE credit_grants
+			 SET remaining_amount = 0, status = ?, expired_at = ?, updated_at = ?
+			 WHERE org_id = ? AND id = ?`,
+			creditdomain.GrantStatusExpired,
+			now,
+			now,
+			invoice.OrgID,
+			ref.ID,
+		).Error; err != nil {
+			return err
+		}
+
+		invoiceID := invoice.ID
+		if err := tx.WithContext(ctx).Exec(
+			`INSERT INTO credit_transactions (
+				id, org_id, customer_id, grant_id, type, amount, currency, invoice_id, description, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			s.genID.Generate(),
+			invoice.OrgID,
+			invoice.CustomerID,
+			ref.ID,
+			creditdomain.TransactionTypeReversal,
+			-ref.Amount,
+			strings.ToUpper(invoice.Currency),
+			&invoiceID,
+			"Revoked with voided invoice "+invoice.InvoiceNumber,
+			now,
+		).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildCreditInvoiceItem renders a credit drawdown as a credit line.
+func (s *Service) buildCreditInvoiceItem(
+	cycle billingCycleRow,
+	invoiceID snowflake.ID,
+	currency string,
+	applied appliedCredit,
+	now time.Time,
+) invoicedomain.InvoiceItem {
+	part := invoiceItemPart{
+		Type:        invoicedomain.InvoiceItemLineTypeCredit,
+		DisplayName: "Credit applied: " + applied.grant.Name,
+		Quantity:    1,
+		Amount:      -applied.amount,
+		Currency:    currency,
+	}
+	return invoicedomain.InvoiceItem{
+		ID:          s.genID.Generate(),
+		OrgID:       cycle.OrgID,
+		InvoiceID:   invoiceID,
+		LineType:    invoicedomain.InvoiceItemLineTypeCredit,
+		Description: s.formatInvoiceItemDescription(part, cycle),
+		Quantity:    1,
+		UnitPrice:   -applied.amount,
+		Amount:      -applied.amount,
+		Metadata: datatypes.JSONMap{
+			"credit_grant_id": applied.grant.ID.String(),
+		},
+		CreatedAt: now,
+	}
+}
+
+// postCreditUseToLedger moves the credit applied to a finalized invoice from
+// the customer's credit balance to accounts receivable. The invoice posting
+// books receivables gross of credit, so the two entries together leave AR at
+// the amount the customer still owes.
+//
+// Double-entry logic:
+//
+//	Debit:  Credit Balance (liability decreases)
+//	Credit: Accounts Receivable (asset decreases)
+func (s *Service) postCreditUseToLedger(ctx context.Context, tx *gorm.DB, invoice *invoicedomain.Invoice) error {
+	if invoice.CreditAmount <= 0 {
+		return nil
+	}
+	return s.postCreditEntry(ctx, tx, invoice, ledgerdomain.SourceTypeCreditUse,
+		ledgerdomain.AccountCodeCreditBalance, ledgerdomain.AccountCodeAccountsReceivable, invoice.FinalizedAt.UTC())
+}
+
+// reverseCreditUseInLedger gives the credit applied to a voided invoice back
+// to the customer's credit balance. Invoices finalized without a credit use
+// entry have nothing to reverse.
+//
+// Double-entry logic:
+//
+//	Debit:  Accounts Receivable (asset increases)
+//	Credit: Credit Balance (liability increases)
+func (s *Service) reverseCreditUseInLedger(ctx context.Context, tx *gorm.DB, invoice *invoicedomain.Invoice, now time.Time) error {
+	if invoice.CreditAmount <= 0 {
+		return nil
+	}
+	var posted int64
+	if err := tx.WithContext(ctx).Raw(
+		`SELECT COUNT(1) FROM ledger_entries
+		 WHERE org_id = ? AND source_type = ? AND source_id = ?`,
+		invoice.OrgID,
+		string(ledgerdomain.SourceTypeCreditUse),
+		invoice.ID,
+	).Scan(&posted).Error; err != nil {
+		return err
+	}
+	if posted == 0 {
+		return nil
+	}
+	return s.postCreditEntry(ctx, tx, invoice, ledgerdomain.SourceTypeCreditUseReversal,
+		ledgerdomain.AccountCodeAccountsReceivable, ledgerdomain.AccountCodeCreditBalance, now)
+}
+
+// postCreditEntry inserts a two-line entry for the invoice's credit amount.
+// Each invoice is posted at most once per source type.
+func (s *Service) postCreditEntry(
+	ctx context.Context,
+	tx *gorm.DB,
+	invoice *invoicedomain.Invoice,
+	sourceType ledgerdomain.LedgerSourceType,
+	debit ledgerdomain.LedgerAccountCode,
+	credit ledgerdomain.LedgerAccountCode,
+	occurredAt time.Time,
+) error {
+	accounts, err := s.loadLedgerAccounts(ctx, tx, invoice.OrgID, []ledgerdomain.LedgerAccountCode{debit, credit})
+	if err != nil {
+		return fmt.Errorf("failed to load ledger accounts: %w", err)
+	}
+	debitAccount, ok := accounts[debit]
+	if !ok {
+		return fmt.Errorf("%s account not found for org %s", debit, invoice.OrgID)
+	}
+	creditAccount, ok := accounts[credit]
+	if !ok {
+		return fmt.Errorf("%s account not found for org %s", credit, invoice.OrgID)
+	}
+
+	lines := []ledgerdomain.LedgerEntryLine{
+		{
+			AccountID: debitAccount.ID,
+			Direction: ledgerdomain.LedgerEntryDirectionDebit,
+			Currency:  invoice.Currency,
+			Amount:    invoice.CreditAmount,
+		},
+		{
+			AccountID: creditAccount.ID,
+			Direction: ledgerdomain.LedgerEntryDirectionCredit,
+			Currency:  invoice.Currency,
+			Amount:    invoice.CreditAmount,
+		},
+	}
+	if err := ledgerdomain.ValidateBalanced(lines); err != nil {
+		return fmt.Errorf("ledger entry not balanced: %w", err)
+	}
+
+	entryID := s.genID.Generate()
+	now := time.Now().UTC()
+	result := tx.WithContext(ctx).Exec(
+		`INSERT INTO ledger_entries (
+			id, org_id, source_type, source_id, currency, occurred_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (org_id, source_type, source_id) DO NOTHING`,
+		entryID,
+		invoice.OrgID,
+		string(sourceType),
+		invoice.ID,
+		invoice.Currency,
+		occurredAt,
+		now,
+	)
+	if result.Error != nil {
+		return fmt.Errorf("failed to insert ledger entry: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	for _, line := range lines {
+		if err := tx.WithContext(ctx).Exec(
+			`INSERT INTO ledger_entry_lines (
+				id, ledger_entry_id, account_id, direction, currency, amount, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			s.genID.Generate(),
+			entryID,
+			line.AccountID,
+			string(line.Direction),
+			line.Currency,
+			line.Amount,
+			now,
+		).Error; err != nil {
+			return fmt.Errorf("failed to insert ledger entry line: %w", err)
+		}
+	}
+
+	if s.outbox != nil {
+		if err := s.outbox.PublishTx(ctx, tx, events.Event{
+			OrgID: invoice.OrgID,
+			Type:  events.EventLedgerEntryCreated,
+			Payload: map[string]any{
+				"ledger_entry_id": entryID.String(),
+				"source_type":     string(sourceType),
+				"source_id":       invoice.ID.String(),
+			},
+			DedupeKey: "ledger_entry:" + entryID.String(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	s.log.Info("posted invoice credit to ledger",
+		zap.String("invoice_id", invoice.ID.String()),
+		zap.String("source_type", string(sourceType)),
+		zap.String("ledger_entry_id", entryID.String()),
+		zap.Int64("credit_amount", invoice.CreditAmount),
+	)
+	return nil
+}
+
+// invoiceCycle rebuilds the billing cycle fields line descriptions need from
+// the invoice snapshot.
+func invoiceCycle(invoice *invoicedomain.Invoice) billingCycleRow {
+	cycle := billingCycleRow{
+		ID:             invoice.BillingCycleID,
+		OrgID:          invoice.OrgID,
+		SubscriptionID: invoice.SubscriptionID,
+	}
+	if invoice.PeriodStart != nil {
+		cycle.PeriodStart = *invoice.PeriodStart
+	}
+	if invoice.PeriodEnd != nil {
+		cycle.PeriodEnd = *invoice.PeriodEnd
+	}
+	return cycle
+}
+
+func sumCredits(credits []appliedCredit) int64 {
+	var total int64
+	for _, applied := range credits {
+		total += applied.amount
+	}
+	return total
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/glebarez/sqlite"
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
+	creditdomain "github.com/smallbiznis/railzway/internal/credit/domain"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	ledgerdomain "github.com/smallbiznis/railzway/internal/ledger/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestCreditsDrawDownByPriorityThenExpiry(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&invoicedomain.Invoice{},
+		&invoicedomain.InvoiceItem{},
+		&creditdomain.CreditGrant{},
+		&creditdomain.CreditTransaction{},
+		&ledgerdomain.LedgerEntry{},
+		&ledgerdomain.LedgerEntryLine{},
+		&ledgerdomain.LedgerAccount{},
+		&adjustmentdomain.Adjustment{},
+		&creditnotedomain.CreditNote{},
+	))
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS ux_ledger_entries_source ON ledger_entries(org_id, source_type, source_id)")
+	db.Exec("DROP INDEX IF EXISTS ux_ledger_accounts_org_type")
+
+	node, _ := snowflake.NewNode(1)
+	svc := NewService(ServiceParam{DB: db, Log: zap.NewNop(), GenID: node}).(*Service)
+
+	orgID := node.Generate()
+	customerID := node.Generate()
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	soon := now.AddDate(0, 0, 10)
+	later := now.AddDate(0, 2, 0)
+	past := now.AddDate(0, 0, -1)
+
+	grant := func(name string, remaining int64, currency string, priority int, expiresAt *time.Time) snowflake.ID {
+		id := node.Generate()
+		require.NoError(t, db.Create(&creditdomain.CreditGrant{
+			ID: id, OrgID: orgID, CustomerID: customerID, Name: name, Type: creditdomain.GrantTypePromotional,
+			Amount: remaining, RemainingAmount: remaining, Currency: currency, Priority: priority,
+			ExpiresAt: expiresAt, Status: creditdomain.GrantStatusActive, CreatedAt: now, UpdatedAt: now,
+		}).Error)
+		return id
+	}
+	noExpiry := grant("No expiry", 5000, "USD", 0, nil)
+	expiresLater := grant("Expires later", 1000, "USD", 0, &later)
+	expiresSoon := grant("Expires soon", 1500, "USD", 0, &soon)
+	lowPriority := grant("Low priority", 9000, "USD", 1, &soon)
+	grant("Euro", 9000, "EUR", 0, nil)
+	grant("Expired", 9000, "USD", 0, &past)
+
+	arID := node.Generate()
+	creditBalanceID := node.Generate()
+	revenueID := node.Generate()
+	require.NoError(t, db.Create(&ledgerdomain.LedgerAccount{ID: arID, OrgID: orgID, Code: ledgerdomain.AccountCodeAccountsReceivable, Name: "AR", Type: ledgerdomain.Assets}).Error)
+	require.NoError(t, db.Create(&ledgerdomain.LedgerAccount{ID: creditBalanceID, OrgID: orgID, Code: ledgerdomain.AccountCodeCreditBalance, Name: "Credit Balance", Type: ledgerdomain.Liability}).Error)
+	require.NoError(t, db.Create(&ledgerdomain.LedgerAccount{ID: revenueID, OrgID: orgID, Code: ledgerdomain.AccountCodeRevenueUsage, Name: "Revenue", Type: ledgerdomain.Income}).Error)
+
+	invoice := &invoicedomain.Invoice{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		CustomerID:     customerID,
+		InvoiceNumber:  "INV-1",
+		Status:         invoicedomain.InvoiceStatusFinalized,
+		SubtotalAmount: 5000,
+		Currency:       "USD",
+		PeriodStart:    &now,
+		PeriodEnd:      &later,
+		FinalizedAt:    &now,
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		credits, err := svc.resolveCredits(context.Background(), tx, orgID, customerID, invoice.Currency, invoice.SubtotalAmount, now, true)
+		if err != nil {
+			return err
+		}
+		if err := svc.recordCredits(context.Background(), tx, invoice, credits, now); err != nil {
+			return err
+		}
+		invoice.CreditAmount = sumCredits(credits)
+		invoice.SubtotalAmount -= invoice.CreditAmount
+		invoice.TotalAmount = invoice.SubtotalAmount
+		if err := svc.postInvoiceToLedger(context.Background(), tx, invoice); err != nil {
+			return err
+		}
+		return svc.postCreditUseToLedger(context.Background(), tx, invoice)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5000), invoice.CreditAmount)
+	assert.Equal(t, int64(0), invoice.TotalAmount)
+
+	remaining := func(id snowflake.ID) (int64, creditdomain.GrantStatus) {
+		var g creditdomain.CreditGrant
+		require.NoError(t, db.First(&g, "id = ?", id).Error)
+		return g.RemainingAmount, g.Status
+	}
+	amount, status := remaining(expiresSoon)
+	assert.Equal(t, int64(0), amount)
+	assert.Equal(t, creditdomain.GrantStatusDepleted, status)
+	amount, status = remaining(expiresLater)
+	assert.Equal(t, int64(0), amount)
+	assert.Equal(t, creditdomain.GrantStatusDepleted, status)
+	amount, status = remaining(noExpiry)
+	assert.Equal(t, int64(2500), amount)
+	assert.Equal(t, creditdomain.GrantStatusActive, status)
+	amount, _ = remaining(lowPriority)
+	assert.Equal(t, int64(9000), amount)
+
+	var items []invoicedomain.InvoiceItem
+	require.NoError(t, db.Order("id ASC").Find(&items, "invoice_id = ?", invoice.ID).Error)
+	require.Len(t, items, 3)
+	assert.Equal(t, invoicedomain.InvoiceItemLineTypeCredit, items[0].LineType)
+	assert.Equal(t, int64(-1500), items[0].Amount)
+	assert.Equal(t, expiresSoon.String(), items[0].Metadata["credit_grant_id"])
+
+	var used int64
+	require.NoError(t, db.Raw("SELECT COALESCE(SUM(amount), 0) FROM credit_transactions WHERE invoice_id = ? AND type = ?", invoice.ID, creditdomain.TransactionTypeUse).Scan(&used).Error)
+	assert.Equal(t, int64(-5000), used)
+
+	var entry ledgerdomain.LedgerEntry
+	require.NoError(t, db.First(&entry, "source_type = ? AND source_id = ?", ledgerdomain.SourceTypeCreditUse, invoice.ID).Error)
+
+	var ar int64
+	require.NoError(t, db.Raw(
+		`SELECT COALESCE(SUM(CASE l.direction WHEN 'debit' THEN l.amount ELSE -l.amount END), 0)
+		 FROM ledger_entry_lines l WHERE l.account_id = ?`, arID).Scan(&ar).Error)
+	assert.Equal(t, int64(0), ar, "credit fully settles the receivable")
+
+	var revenue int64
+	require.NoError(t, db.Raw("SELECT COALESCE(SUM(amount), 0) FROM ledger_entry_lines WHERE account_id = ? AND direction = 'credit'", revenueID).Scan(&revenue).Error)
+	assert.Equal(t, int64(5000), revenue, "revenue is booked gross of credit")
+
+	// Voiding the invoice gives the drawn credit back.
+	require.NoError(t, db.Create(invoice).Error)
+	require.NoError(t, svc.VoidInvoice(context.Background(), invoice.ID.String(), ""))
+	amount, status = remaining(expiresSoon)
+	assert.Equal(t, int64(1500), amount)
+	assert.Equal(t, creditdomain.GrantStatusActive, status)
+	amount, status = remaining(expiresLater)
+	assert.Equal(t, int64(1000), amount)
+	assert.Equal(t, creditdomain.GrantStatusActive, status)
+	amount, _ = remaining(noExpiry)
+	assert.Equal(t, int64(5000), amount)
+
+	var reversed int64
+	require.NoError(t, db.Raw("SELECT COALESCE(SUM(amount), 0) FROM credit_transactions WHERE invoice_id = ? AND type = ?", invoice.ID, creditdomain.TransactionTypeReversal).Scan(&reversed).Error)
+	assert.Equal(t, int64(5000), reversed)
+
+	var reversal ledgerdomain.LedgerEntry
+	require.NoError(t, db.First(&reversal, "source_type = ? AND source_id = ?", ledgerdomain.SourceTypeCreditUseReversal, invoice.ID).Error)
+	var reversalLines []ledgerdomain.LedgerEntryLine
+	require.NoError(t, db.Find(&reversalLines, "ledger_entry_id = ?", reversal.ID).Error)
+	require.Len(t, reversalLines, 2)
+	for _, line := range reversalLines {
+		assert.Equal(t, int64(5000), line.Amount)
+		switch line.AccountID {
+		case arID:
+			assert.Equal(t, ledgerdomain.LedgerEntryDirectionDebit, line.Direction)
+		case creditBalanceID:
+			assert.Equal(t, ledgerdomain.LedgerEntryDirectionCredit, line.Direction)
+		default:
+			t.Fatalf("unexpected account %s", line.AccountID)
+		}
+	}
+
+	var creditBalance int64
+	require.NoError(t, db.Raw(
+		`SELECT COALESCE(SUM(CASE l.direction WHEN 'credit' THEN l.amount ELSE -l.amount END), 0)
+		 FROM ledger_entry_lines l WHERE l.account_id = ?`, creditBalanceID).Scan(&creditBalance).Error)
+	assert.Equal(t, int64(0), creditBalance, "the credit use is reversed with the grants")
+}
+
+func TestCarryCreditIssuesGrantForNetCredit(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&invoicedomain.Invoice{},
+		&invoicedomain.InvoiceItem{},
+		&creditdomain.CreditGrant{},
+		&creditdomain.CreditTransaction{},
+		&ledgerdomain.LedgerEntry{},
+		&adjustmentdomain.Adjustment{},
+		&creditnotedomain.CreditNote{},
+	))
+
+	node, _ := snowflake.NewNode(1)
+	svc := NewService(ServiceParam{DB: db, Log: zap.NewNop(), GenID: node}).(*Service)
+
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	invoice := &invoicedomain.Invoice{
+		ID:            node.Generate(),
+		OrgID:         node.Generate(),
+		CustomerID:    node.Generate(),
+		InvoiceNumber: "INV-2",
+		Status:        invoicedomain.InvoiceStatusDraft,
+		Currency:      "usd",
+		PeriodStart:   &now,
+		PeriodEnd:     &now,
+	}
+	require.NoError(t, svc.carryCredit(context.Background(), db, invoice, 5000, now))
+
+	var grant creditdomain.CreditGrant
+	require.NoError(t, db.First(&grant, "customer_id = ?", invoice.CustomerID).Error)
+	assert.Equal(t, int64(5000), grant.Amount)
+	assert.Equal(t, int64(5000), grant.RemainingAmount)
+	assert.Equal(t, "USD", grant.Currency)
+	assert.Equal(t, creditdomain.GrantStatusPending, grant.Status)
+
+	// Other invoices cannot draw the credit while this one is a draft.
+	credits, err := svc.resolveCredits(context.Background(), db, invoice.OrgID, invoice.CustomerID, "USD", 1000, now, false)
+	require.NoError(t, err)
+	assert.Empty(t, credits)
+
+	var items []invoicedomain.InvoiceItem
+	require.NoError(t, db.Find(&items, "invoice_id = ?", invoice.ID).Error)
+	require.Len(t, items, 1)
+	assert.Equal(t, int64(5000), items[0].Amount, "the line offsets the net credit of the rated lines")
+	assert.Equal(t, grant.ID.String(), items[0].Metadata["credit_grant_id"])
+
+	invoice.Status = invoicedomain.InvoiceStatusFinalized
+	invoice.FinalizedAt = &now
+	require.NoError(t, db.Create(invoice).Error)
+	require.NoError(t, svc.activateCarriedCredit(context.Background(), db, invoice, now))
+	require.NoError(t, db.First(&grant, "id = ?", grant.ID).Error)
+	assert.Equal(t, creditdomain.GrantStatusActive, grant.Status)
+
+	transactions := func(kind creditdomain.TransactionType) int64 {
+		var total int64
+		require.NoError(t, db.Raw("SELECT COALESCE(SUM(amount), 0) FROM credit_transactions WHERE grant_id = ? AND type = ?", grant.ID, kind).Scan(&total).Error)
+		return total
+	}
+	assert.Equal(t, int64(5000), transactions(creditdomain.TransactionTypeGrant))
+
+	// Credit another invoice has drawn cannot be taken back.
+	require.NoError(t, db.Model(&grant).Update("remaining_amount", 4000).Error)
+	err = svc.VoidInvoice(context.Background(), invoice.ID.String(), "")
+	assert.ErrorIs(t, err, invoicedomain.ErrCarriedCreditUsed)
+	var stored invoicedomain.Invoice
+	require.NoError(t, db.First(&stored, "id = ?", invoice.ID).Error)
+	assert.Equal(t, invoicedomain.InvoiceStatusFinalized, stored.Status)
+
+	require.NoError(t, db.Model(&grant).Update("remaining_amount", 5000).Error)
+	require.NoError(t, svc.VoidInvoice(context.Background(), invoice.ID.String(), ""))
+	require.NoError(t, db.First(&grant, "id = ?", grant.ID).Error)
+	assert.Equal(t, creditdomain.GrantStatusExpired, grant.Status)
+	assert.Equal(t, int64(0), grant.RemainingAmount)
+	assert.NotNil(t, grant.ExpiredAt)
+	assert.Equal(t, int64(-5000), transactions(creditdomain.TransactionTypeReversal))
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	coupondomain "github.com/smallbiznis/railzway/internal/coupon/domain"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type discountRow struct {
+	ID               snowflake.ID
+	CouponID         snowflake.ID
+	CyclesApplied    int
+	Code             string
+	Name             string
+	DiscountType     coupondomain.DiscountType
+	PercentOff       *float64
+	AmountOff        *int64
+	Currency         *string
+	Duration         coupondomain.CouponDuration
+	DurationInCycles *int
+}
+
+func (r discountRow) coupon() coupondomain.Coupon {
+	return coupondomain.Coupon{
+		ID:               r.CouponID,
+		Code:             r.Code,
+		Name:             r.Name,
+		DiscountType:     r.DiscountType,
+		PercentOff:       r.PercentOff,
+		AmountOff:        r.AmountOff,
+		Currency:         r.Currency,
+		Duration:         r.Duration,
+		DurationInCycles: r.DurationInCycles,
+	}
+}
+
+// appliedDiscount is a discount resolved for one invoice, before it is persisted.
+type appliedDiscount struct {
+	discount discountRow
+	amount   int64
+}
+
+// resolveDiscounts computes the discounts that apply to a billing cycle.
+// Subscription discounts and customer-wide discounts both apply, in the order
+// they were attached; each one is computed on what is left after the previous
+// ones so the subtotal never goes below zero.
+func (s *Service) resolveDiscounts(
+	ctx context.Context,
+	tx *gorm.DB,
+	cycle billingCycleRow,
+	customerID snowflake.ID,
+	currency string,
+	subtotal int64,
+) ([]appliedDiscount, error) {
+	if subtotal <= 0 {
+		return nil, nil
+	}
+
+	var rows []discountRow
+	if err := tx.WithContext(ctx).Raw(
+		`SELECT d.id, d.coupon_id, d.cycles_applied,
+		        c.code, c.name, c.discount_type, c.percent_off, c.amount_off, c.currency,
+		        c.duration, c.duration_in_cycles
+		 FROM discounts d
+		 JOIN coupons c ON c.id = d.coupon_id AND c.org_id = d.org_id
+		 WHERE d.org_id = ?
+		   AND d.status = ?
+		   AND d.customer_id = ?
+		   AND (d.subscription_id = ? OR d.subscription_id IS NULL)
+		   AND d.started_at < ?
+		 ORDER BY d.created_at ASC, d.id ASC`,
+		cycle.OrgID,
+		coupondomain.DiscountStatusActive,
+		customerID,
+		cycle.SubscriptionID,
+		cycle.PeriodEnd,
+	).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	remaining := subtotal
+	applied := make([]appliedDiscount, 0, len(rows))
+	for _, row := range rows {
+		coupon := row.coupon()
+		if coupon.DiscountType == coupondomain.DiscountTypeAmount &&
+			(coupon.Currency == nil || !strings.EqualFold(*coupon.Currency, currency)) {
+			s.log.Warn("skipping discount with mismatched currency",
+				zap.String("discount_id", row.ID.String()),
+				zap.String("billing_cycle_id", cycle.ID.String()),
+				zap.String("invoice_currency", currency),
+			)
+			continue
+		}
+
+		amount := coupon.DiscountFor(remaining)
+		if amount <= 0 {
+			continue
+		}
+		remaining -= amount
+		applied = append(applied, appliedDiscount{discount: row, amount: amount})
+	}
+	return applied, nil
+}
+
+// recordDiscounts writes one credit line per applied discount and consumes a
+// cycle on each discount, ending it once its duration is used up.
+func (s *Service) recordDiscounts(
+	ctx context.Context,
+	tx *gorm.DB,
+	cycle billingCycleRow,
+	invoiceID snowflake.ID,
+	currency string,
+	discounts []appliedDiscount,
+	now time.Time,
+) error {
+	for _, applied := range discounts {
+		row := applied.discount
+		coupon := row.coupon()
+
+		item := s.buildDiscountInvoiceItem(cycle, invoiceID, currency, applied, now)
+		if err := s.insertInvoiceItem(ctx, tx, item); err != nil {
+			return err
+		}
+
+		cyclesApplied := row.CyclesApplied + 1
+		status := coupondomain.DiscountStatusActive
+		var endedAt *time.Time
+		if coupon.ExhaustedAfter(cyclesApplied) {
+			status = coupondomain.DiscountStatusEnded
+			endedAt = &now
+		}
+		if err := tx.WithContext(ctx).Exec(
+			`UPDATE discounts
+			 SET cycles_applied = ?, status = ?, ended_at = ?, updated_at = ?
+			 WHERE org_id = ? AND id = ?`,
+			cyclesApplied,
+			status,
+			endedAt,
+			now,
+			cycle.OrgID,
+			row.ID,
+		).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseDiscounts gives back the cycle each discount on a voided invoice
+// consumed, reopening the discounts the invoice used up. Removed discounts
+// get the cycle back but stay removed.
+func (s *Service) releaseDiscounts(ctx context.Context, tx *gorm.DB, invoice *invoicedomain.Invoice, now time.Time) error {
+	refs, err := s.listInvoiceItemRefs(ctx, tx, invoice.ID, invoicedomain.InvoiceItemLineTypeCredit, "discount_id")
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if err := tx.WithContext(ctx).Exec(
+			`UPDATE discounts
+			 SET cycles_applied = CASE WHEN cycles_applied > 0 THEN cycles_applied - 1 ELSE 0 END,
+			     ended_at = CASE WHEN status = ? THEN NULL ELSE ended_at END,
+			     status = CASE WHEN status = ? THEN ? ELSE status END,
+			     updated_at = ?
+			 WHERE org_id = ? AND id = ?`,
+			coupondomain.DiscountStatusEnded,
+			coupondomain.DiscountStatusEnded,
+			coupondomain.DiscountStatusActive,
+			now,
+			invoice.OrgID,
+			ref.ID,
+		).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildDiscountInvoiceItem renders an applied discount as a credit line.
+func (s *Service) buildDiscountInvoiceItem(
+	cycle billingCycleRow,
+	invoiceID snowflake.ID,
+	currency string,
+	applied appliedDiscount,
+	now time.Time,
+) invoicedomain.InvoiceItem {
+	row := applied.discount
+	part := invoiceItemPart{
+		Type:        invoicedomain.InvoiceItemLineTypeCredit,
+		DisplayName: discountDisplayName(row.coupon(), currency),
+		Quantity:    1,
+		Amount:      -applied.amount,
+		Currency:    currency,
+	}
+	return invoicedomain.InvoiceItem{
+		ID:          s.genID.Generate(),
+		OrgID:       cycle.OrgID,
+		InvoiceID:   invoiceID,
+		LineType:    invoicedomain.InvoiceItemLineTypeCredit,
+		Description: s.formatInvoiceItemDescription(part, cycle),
+		Quantity:    1,
+		UnitPrice:   -applied.amount,
+		Amount:      -applied.amount,
+		Metadata: datatypes.JSONMap{
+			"discount_id": row.ID.String(),
+			"coupon_id":   row.CouponID.String(),
+			"coupon_code": row.Code,
+		},
+		CreatedAt: now,
+	}
+}
+
+func discountDisplayName(coupon coupondomain.Coupon, currency string) string {
+	name := strings.TrimSpace(coupon.Name)
+	if name == "" {
+		name = coupon.Code
+	}
+
+	switch coupon.DiscountType {
+	case coupondomain.DiscountTypePercent:
+		if coupon.PercentOff != nil {
+			return fmt.Sprintf("Discount: %s (%s%% off)", name, strconv.FormatFloat(*coupon.PercentOff, 'f', -1, 64))
+		}
+	case coupondomain.DiscountTypeAmount:
+		if coupon.AmountOff != nil {
+			return fmt.Sprintf("Discount: %s (%s off)", name, formatMoney(*coupon.AmountOff, currency))
+		}
+	}
+	return "Discount: " + name
+}
+
+func sumDiscounts(discounts []appliedDiscount) int64 {
+	var total int64
+	for _, applied := range discounts {
+		total += applied.amount
+	}
+	return total
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/glebarez/sqlite"
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	coupondomain "github.com/smallbiznis/railzway/internal/coupon/domain"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	ledgerdomain "github.com/smallbiznis/railzway/internal/ledger/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestDiscounts_AppliedBeforeTaxAndConsumed(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&invoicedomain.Invoice{},
+		&invoicedomain.InvoiceItem{},
+		&coupondomain.Coupon{},
+		&coupondomain.Discount{},
+	))
+
+	node, _ := snowflake.NewNode(1)
+	svc := NewService(ServiceParam{DB: db, Log: zap.NewNop(), GenID: node}).(*Service)
+
+	orgID := node.Generate()
+	customerID := node.Generate()
+	subID := node.Generate()
+	otherSubID := node.Generate()
+	now := time.Now().UTC()
+
+	percent := 20.0
+	cycles := 2
+	amountOff := int64(500)
+	usd := "USD"
+	eur := "EUR"
+	percentCoupon := coupondomain.Coupon{ID: node.Generate(), OrgID: orgID, Code: "SPRING20", Name: "Spring", DiscountType: coupondomain.DiscountTypePercent, PercentOff: &percent, Duration: coupondomain.DurationRepeating, DurationInCycles: &cycles, Active: true}
+	amountCoupon := coupondomain.Coupon{ID: node.Generate(), OrgID: orgID, Code: "WELCOME", Name: "Welcome", DiscountType: coupondomain.DiscountTypeAmount, AmountOff: &amountOff, Currency: &usd, Duration: coupondomain.DurationOnce, Active: true}
+	euroCoupon := coupondomain.Coupon{ID: node.Generate(), OrgID: orgID, Code: "EURO", Name: "Euro", DiscountType: coupondomain.DiscountTypeAmount, AmountOff: &amountOff, Currency: &eur, Duration: coupondomain.DurationForever, Active: true}
+	for _, c := range []*coupondomain.Coupon{&percentCoupon, &amountCoupon, &euroCoupon} {
+		require.NoError(t, db.Create(c).Error)
+	}
+
+	subDiscount := coupondomain.Discount{ID: node.Generate(), OrgID: orgID, CouponID: percentCoupon.ID, CustomerID: customerID, SubscriptionID: &subID, Status: coupondomain.DiscountStatusActive, StartedAt: now.Add(-48 * time.Hour), CreatedAt: now.Add(-48 * time.Hour)}
+	customerDiscount := coupondomain.Discount{ID: node.Generate(), OrgID: orgID, CouponID: amountCoupon.ID, CustomerID: customerID, Status: coupondomain.DiscountStatusActive, StartedAt: now.Add(-24 * time.Hour), CreatedAt: now.Add(-24 * time.Hour)}
+	mismatched := coupondomain.Discount{ID: node.Generate(), OrgID: orgID, CouponID: euroCoupon.ID, CustomerID: customerID, Status: coupondomain.DiscountStatusActive, StartedAt: now.Add(-24 * time.Hour), CreatedAt: now.Add(-23 * time.Hour)}
+	otherSub := coupondomain.Discount{ID: node.Generate(), OrgID: orgID, CouponID: percentCoupon.ID, CustomerID: customerID, SubscriptionID: &otherSubID, Status: coupondomain.DiscountStatusActive, StartedAt: now.Add(-24 * time.Hour), CreatedAt: now.Add(-22 * time.Hour)}
+	for _, d := range []*coupondomain.Discount{&subDiscount, &customerDiscount, &mismatched, &otherSub} {
+		require.NoError(t, db.Create(d).Error)
+	}
+
+	cycle := billingCycleRow{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		SubscriptionID: subID,
+		PeriodStart:    now.AddDate(0, -1, 0),
+		PeriodEnd:      now,
+		Status:         billingcycledomain.BillingCycleStatusClosed,
+	}
+	invoiceID := node.Generate()
+
+	var applied []appliedDiscount
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		applied, err = svc.resolveDiscounts(context.Background(), tx, cycle, customerID, "USD", 10000)
+		if err != nil {
+			return err
+		}
+		return svc.recordDiscounts(context.Background(), tx, cycle, invoiceID, "USD", applied, now)
+	})
+	require.NoError(t, err)
+
+	// 20% of 10000, then 500 off the remaining 8000.
+	require.Len(t, applied, 2)
+	assert.Equal(t, int64(2000), applied[0].amount)
+	assert.Equal(t, int64(500), applied[1].amount)
+	assert.Equal(t, int64(2500), sumDiscounts(applied))
+
+	var items []invoicedomain.InvoiceItem
+	require.NoError(t, db.Where("invoice_id = ?", invoiceID).Order("amount ASC").Find(&items).Error)
+	require.Len(t, items, 2)
+	assert.Equal(t, invoicedomain.InvoiceItemLineTypeCredit, items[0].LineType)
+	assert.Equal(t, int64(-2000), items[0].Amount)
+	assert.Contains(t, items[0].Description, "Spring (20% off)")
+	assert.Equal(t, subDiscount.ID.String(), items[0].Metadata["discount_id"])
+	assert.Contains(t, items[1].Description, "Welcome (USD 5.00 off)")
+
+	var reloaded []coupondomain.Discount
+	require.NoError(t, db.Order("created_at ASC").Find(&reloaded).Error)
+	assert.Equal(t, 1, reloaded[0].CyclesApplied)
+	assert.Equal(t, coupondomain.DiscountStatusActive, reloaded[0].Status)
+	assert.Equal(t, coupondomain.DiscountStatusEnded, reloaded[1].Status)
+	assert.NotNil(t, reloaded[1].EndedAt)
+	assert.Zero(t, reloaded[2].CyclesApplied)
+	assert.Zero(t, reloaded[3].CyclesApplied)
+
+	// The second cycle uses up the repeating coupon.
+	err = db.Transaction(func(tx *gorm.DB) error {
+		applied, err = svc.resolveDiscounts(context.Background(), tx, cycle, customerID, "USD", 400)
+		if err != nil {
+			return err
+		}
+		return svc.recordDiscounts(context.Background(), tx, cycle, node.Generate(), "USD", applied, now)
+	})
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, int64(80), applied[0].amount)
+
+	var exhausted coupondomain.Discount
+	require.NoError(t, db.First(&exhausted, "id = ?", subDiscount.ID).Error)
+	assert.Equal(t, 2, exhausted.CyclesApplied)
+	assert.Equal(t, coupondomain.DiscountStatusEnded, exhausted.Status)
+}
+
+func TestPostInvoiceToLedger_DiscountBookedAsContraRevenue(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, db.AutoMigrate(
+		&invoicedomain.Invoice{},
+		&ledgerdomain.LedgerEntry{},
+		&ledgerdomain.LedgerEntryLine{},
+		&ledgerdomain.LedgerAccount{},
+	))
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS ux_ledger_entries_source ON ledger_entries(org_id, source_type, source_id)")
+	db.Exec("DROP INDEX IF EXISTS ux_ledger_accounts_org_type")
+
+	node, _ := snowflake.NewNode(1)
+	svc := NewService(ServiceParam{DB: db, Log: zap.NewNop(), GenID: node}).(*Service)
+
+	orgID := node.Generate()
+	accounts := map[ledgerdomain.LedgerAccountCode]snowflake.ID{}
+	for code, typ := range map[ledgerdomain.LedgerAccountCode]ledgerdomain.LedgerAccountType{
+		ledgerdomain.AccountCodeAccountsReceivable: ledgerdomain.Assets,
+		ledgerdomain.AccountCodeRevenueUsage:       ledgerdomain.Income,
+		ledgerdomain.AccountCodeTaxPayable:         ledgerdomain.Liability,
+		ledgerdomain.AccountCodeDiscount:           ledgerdomain.Income,
+	} {
+		id := node.Generate()
+		accounts[code] = id
+		require.NoError(t, db.Create(&ledgerdomain.LedgerAccount{ID: id, OrgID: orgID, Code: code, Type: typ, Name: string(code)}).Error)
+	}
+
+	now := time.Now().UTC()
+	invoice := &invoicedomain.Invoice{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		Status:         invoicedomain.InvoiceStatusFinalized,
+		SubtotalAmount: 7500,
+		DiscountAmount: 2500,
+		TaxAmount:      750,
+		TotalAmount:    8250,
+		Currency:       "USD",
+		FinalizedAt:    &now,
+	}
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		return svc.postInvoiceToLedger(context.Background(), tx, invoice)
+	}))
+
+	var entry ledgerdomain.LedgerEntry
+	require.NoError(t, db.First(&entry, "source_id = ?", invoice.ID).Error)
+	var lines []ledgerdomain.LedgerEntryLine
+	require.NoError(t, db.Find(&lines, "ledger_entry_id = ?", entry.ID).Error)
+	require.Len(t, lines, 4)
+
+	amounts := make(map[snowflake.ID]int64)
+	for _, l := range lines {
+		if l.Direction == ledgerdomain.LedgerEntryDirectionDebit {
+			amounts[l.AccountID] += l.Amount
+		} else {
+			amounts[l.AccountID] -= l.Amount
+		}
+	}
+	assert.Equal(t, int64(8250), amounts[accounts[ledgerdomain.AccountCodeAccountsReceivable]])
+	assert.Equal(t, int64(2500), amounts[accounts[ledgerdomain.AccountCodeDiscount]])
+	assert.Equal(t, int64(-10000), amounts[accounts[ledgerdomain.AccountCodeRevenueUsage]])
+	assert.Equal(t, int64(-750), amounts[accounts[ledgerdomain.AccountCodeTaxPayable]])
+}
+
+func TestVoidInvoice_ReleasesDiscountCycle(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&invoicedomain.Invoice{},
+		&invoicedomain.InvoiceItem{},
+		&coupondomain.Coupon{},
+		&coupondomain.Discount{},
+		&adjustmentdomain.Adjustment{},
+		&creditnotedomain.CreditNote{},
+	))
+
+	node, _ := snowflake.NewNode(1)
+	svc := NewService(ServiceParam{DB: db, Log: zap.NewNop(), GenID: node}).(*Service)
+
+	orgID := node.Generate()
+	customerID := node.Generate()
+	now := time.Now().UTC()
+
+	amountOff := int64(500)
+	usd := "USD"
+	coupon := coupondomain.Coupon{ID: node.Generate(), OrgID: orgID, Code: "WELCOME", Name: "Welcome", DiscountType: coupondomain.DiscountTypeAmount, AmountOff: &amountOff, Currency: &usd, Duration: coupondomain.DurationOnce, Active: true}
+	require.NoError(t, db.Create(&coupon).Error)
+	discount := coupondomain.Discount{ID: node.Generate(), OrgID: orgID, CouponID: coupon.ID, CustomerID: customerID, Status: coupondomain.DiscountStatusActive, StartedAt: now.Add(-24 * time.Hour), CreatedAt: now.Add(-24 * time.Hour)}
+	require.NoError(t, db.Create(&discount).Error)
+
+	cycle := billingCycleRow{ID: node.Generate(), OrgID: orgID, SubscriptionID: node.Generate(), PeriodStart: now.AddDate(0, -1, 0), PeriodEnd: now}
+	invoice := invoicedomain.Invoice{
+		ID: node.Generate(), OrgID: orgID, BillingCycleID: cycle.ID, SubscriptionID: cycle.SubscriptionID, CustomerID: customerID,
+		Status: invoicedomain.InvoiceStatusFinalized, SubtotalAmount: 10000, Currency: "USD", CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, db.Create(&invoice).Error)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		applied, err := svc.resolveDiscounts(context.Background(), tx, cycle, customerID, "USD", 10000)
+		if err != nil {
+			return err
+		}
+		return svc.recordDiscounts(context.Background(), tx, cycle, invoice.ID, "USD", applied, now)
+	})
+	require.NoError(t, err)
+
+	var used coupondomain.Discount
+	require.NoError(t, db.First(&used, "id = ?", discount.ID).Error)
+	require.Equal(t, coupondomain.DiscountStatusEnded, used.Status)
+
+	require.NoError(t, svc.VoidInvoice(context.Background(), invoice.ID.String(), "wrong amount"))
+
+	var released coupondomain.Discount
+	require.NoError(t, db.First(&released, "id = ?", discount.ID).Error)
+	assert.Equal(t, 0, released.CyclesApplied)
+	assert.Equal(t, coupondomain.DiscountStatusActive, released.Status)
+	assert.Nil(t, released.EndedAt)
+}
@@ -19,9 +19,13 @@ import (
 // Double-entry logic:
 //
 //	Debit:  Accounts Receivable (asset increases)
-//	Credit: Revenue (income increases)
+//	Debit:  Discount (contra-revenue, if the invoice carries discount lines)
+//	Credit: Revenue (income increases, gross of discounts and credits)
 //	Credit: Tax Payable (liability increases, if tax > 0)
 //
+// Receivables are booked gross of applied credit; postCreditUseToLedger then
+// settles the credited part from the customer's credit balance.
+//
 // Idempotency: The ledger service has ON CONFLICT DO NOTHING, so re-posting
 // the same invoice will not create duplicate entries.
 func (s *Service) postInvoiceToLedger(ctx context.Context, tx *gorm.DB, invoice *invoicedomain.Invoice) error {
@@ -40,6 +44,7 @@ func (s *Service) postInvoiceToLedger(ctx context.Context, tx *gorm.DB, invoice
 		ledgerdomain.AccountCodeAccountsReceivable,
 		ledgerdomain.AccountCodeRevenueUsage, // Using usage revenue for all revenue
 		ledgerdomain.AccountCodeTaxPayable,
+		ledgerdomain.AccountCodeDiscount,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to load ledger accounts: %w", err)
@@ -60,15 +65,29 @@ func (s *Service) postInvoiceToLedger(ctx context.Context, tx *gorm.DB, invoice
 			AccountID: arAccount.ID,
 			Direction: ledgerdomain.LedgerEntryDirectionDebit,
 			Currency:  invoice.Currency,
-			Amount:    invoice.TotalAmount, // Total AR = Revenue + Tax
+			Amount:    invoice.TotalAmount + invoice.CreditAmount, // Total AR = Revenue + Tax
 		},
-		{
-			AccountID: revenueAccount.ID,
-			Direction: ledgerdomain.LedgerEntryDirectionCredit,
+	}
+
+	// Discounts are booked against a contra-revenue account so gross revenue
+	// stays visible. Orgs created before the discount account existed fall
+	// back to posting revenue net of discounts.
+	revenueAmount := invoice.SubtotalAmount + invoice.CreditAmount // Revenue = Subtotal (before tax and credits)
+	if discountAccount, ok := accounts[ledgerdomain.AccountCodeDiscount]; ok && invoice.DiscountAmount > 0 {
+		revenueAmount += invoice.DiscountAmount
+		lines = append(lines, ledgerdomain.LedgerEntryLine{
+			AccountID: discountAccount.ID,
+			Direction: ledgerdomain.LedgerEntryDirectionDebit,
 			Currency:  invoice.Currency,
-			Amount:    invoice.SubtotalAmount, // Revenue = Subtotal (before tax)
-		},
+			Amount:    invoice.DiscountAmount,
+		})
 	}
+	lines = append(lines, ledgerdomain.LedgerEntryLine{
+		AccountID: revenueAccount.ID,
+		Direction: ledgerdomain.LedgerEntryDirectionCredit,
+		Currency:  invoice.Currency,
+		Amount:    revenueAmount,
+	})
 
 	// Add tax payable if applicable
 	if invoice.TaxAmount > 0 {
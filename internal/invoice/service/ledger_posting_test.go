@@ -40,6 +40,11 @@ func (m *mockRenderer) RenderHTML(input render.RenderInput) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+func (m *mockRenderer) RenderCreditNoteHTML(input render.CreditNoteRenderInput) (string, error) {
+	args := m.Called(input)
+	return args.String(0), args.Error(1)
+}
+
 type mockPublicTokenSvc struct {
 	mock.Mock
 }
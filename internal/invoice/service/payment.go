@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
+	"github.com/smallbiznis/railzway/internal/events"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	ledgerdomain "github.com/smallbiznis/railzway/internal/ledger/domain"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// outOfBandProvider is the payment_events provider for payments recorded by
+// hand. Payment-sourced ledger entries resolve their customer through
+// payment_events, so out-of-band payments get a row there as well.
+const outOfBandProvider = "manual"
+
+// MarkInvoicePaid settles a finalized invoice with a payment received outside
+// the payment providers. The outstanding balance (total less earlier partial
+// payments and receivable credit notes) is posted as cash against accounts
+// receivable and announced with payment_settled, atomically with paid_at.
+func (s *Service) MarkInvoicePaid(ctx context.Context, invoiceID string, req invoicedomain.MarkPaidRequest) error {
+	id, err := parseID(strings.TrimSpace(invoiceID))
+	if err != nil {
+		return invoicedomain.ErrInvalidInvoiceID
+	}
+	method := strings.ToLower(strings.TrimSpace(req.PaymentMethod))
+	if !isOutOfBandPaymentMethod(method) {
+		return invoicedomain.ErrInvalidPaymentMethod
+	}
+	now := time.Now().UTC()
+	paidAt := now
+	if req.PaidAt != nil {
+		if req.PaidAt.IsZero() || req.PaidAt.After(now) {
+			return invoicedomain.ErrInvalidPaidAt
+		}
+		paidAt = req.PaidAt.UTC()
+	}
+	reference := strings.TrimSpace(req.Reference)
+
+	var (
+		paidInvoice *invoicedomain.Invoice
+		amount      int64
+	)
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		invoice, err := s.loadInvoiceForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if invoice == nil || !invoiceInCallerOrg(ctx, invoice) {
+			return invoicedomain.ErrInvoiceNotFound
+		}
+		if invoice.Status != invoicedomain.InvoiceStatusFinalized {
+			return invoicedomain.ErrInvoiceNotFinalized
+		}
+		if invoice.PaidAt != nil {
+			return invoicedomain.ErrInvoiceAlreadyPaid
+		}
+
+		amount, err = s.outstandingAmount(ctx, tx, invoice)
+		if err != nil {
+			return err
+		}
+
+		if invoice.Metadata == nil {
+			invoice.Metadata = datatypes.JSONMap{}
+		}
+		if amount > 0 {
+			paymentID, err := s.recordOutOfBandPayment(ctx, tx, invoice, amount, method, reference, paidAt)
+			if err != nil {
+				return err
+			}
+			invoice.Metadata["amount_paid"] = metadataAmount(invoice.Metadata, "amount_paid") + amount
+			invoice.Metadata["out_of_band_payment_id"] = paymentID.String()
+		}
+		invoice.Metadata["payment_method"] = method
+		invoice.Metadata["paid_out_of_band"] = true
+		if reference != "" {
+			invoice.Metadata["payment_reference"] = reference
+		}
+		delete(invoice.Metadata, "payment_failed_at")
+
+		if err := tx.WithContext(ctx).Exec(
+			`UPDATE invoices
+			 SET paid_at = ?, metadata = ?, updated_at = ?
+			 WHERE id = ?`,
+			paidAt,
+			invoice.Metadata,
+			now,
+			invoice.ID,
+		).Error; err != nil {
+			return err
+		}
+		invoice.PaidAt = &paidAt
+		paidInvoice = invoice
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	metadata := map[string]any{
+		"payment_method": method,
+		"amount":         amount,
+		"paid_at":        paidAt.Format(time.RFC3339),
+	}
+	if reference != "" {
+		metadata["payment_reference"] = reference
+	}
+	s.emitAudit(ctx, "invoice.mark_paid", paidInvoice, metadata)
+	return nil
+}
+
+// outstandingAmount is what the customer still owes on the invoice.
+func (s *Service) outstandingAmount(ctx context.Context, tx *gorm.DB, invoice *invoicedomain.Invoice) (int64, error) {
+	var credited int64
+	if err := tx.WithContext(ctx).Raw(
+		`SELECT COALESCE(SUM(total_amount), 0)
+		 FROM credit_notes
+		 WHERE org_id = ? AND invoice_id = ? AND status = ? AND destination = ?`,
+		invoice.OrgID,
+		invoice.ID,
+		creditnotedomain.CreditNoteStatusIssued,
+		creditnotedomain.DestinationReceivable,
+	).Scan(&credited).Error; err != nil {
+		return 0, err
+	}
+
+	outstanding := invoice.TotalAmount - credited - metadataAmount(invoice.Metadata, "amount_paid")
+	if outstanding < 0 {
+		return 0, nil
+	}
+	return outstanding, nil
+}
+
+// recordOutOfBandPayment stores the payment event, posts Debit cash / Credit
+// accounts receivable and publishes payment_settled, all within tx.
+func (s *Service) recordOutOfBandPayment(
+	ctx context.Context,
+	tx *gorm.DB,
+	invoice *invoicedomain.Invoice,
+	amount int64,
+	method string,
+	reference string,
+	paidAt time.Time,
+) (snowflake.ID, error) {
+	accounts, err := s.loadLedgerAccounts(ctx, tx, invoice.OrgID, []ledgerdomain.LedgerAccountCode{
+		ledgerdomain.AccountCodeCash,
+		ledgerdomain.AccountCodeAccountsReceivable,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to load ledger accounts: %w", err)
+	}
+	cashAccount, ok := accounts[ledgerdomain.AccountCodeCash]
+	if !ok {
+		return 0, fmt.Errorf("cash account not found for org %s", invoice.OrgID)
+	}
+	arAccount, ok := accounts[ledgerdomain.AccountCodeAccountsReceivable]
+	if !ok {
+		return 0, fmt.Errorf("accounts_receivable account not found for org %s", invoice.OrgID)
+	}
+
+	now := time.Now().UTC()
+	paymentID := s.genID.Generate()
+	payload, err := json.Marshal(map[string]any{
+		"invoice_id":     invoice.ID.String(),
+		"amount":         amount,
+		"currency":       invoice.Currency,
+		"payment_method": method,
+		"reference":      reference,
+		"paid_at":        paidAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.WithContext(ctx).Exec(
+		`INSERT INTO payment_events (
+			id, org_id, provider, provider_event_id, event_type, customer_id,
+			payload, received_at, processed_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		paymentID,
+		invoice.OrgID,
+		outOfBandProvider,
+		"invoice:"+invoice.ID.String(),
+		"payment_succeeded",
+		invoice.CustomerID,
+		datatypes.JSON(payload),
+		now,
+		now,
+	).Error; err != nil {
+		return 0, err
+	}
+
+	entryID := s.genID.Generate()
+	if err := tx.WithContext(ctx).Exec(
+		`INSERT INTO ledger_entries (
+			id, org_id, source_type, source_id, currency, occurred_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entryID,
+		invoice.OrgID,
+		string(ledgerdomain.SourceTypePayment),
+		paymentID,
+		invoice.Currency,
+		paidAt,
+		now,
+	).Error; err != nil {
+		return 0, fmt.Errorf("failed to insert ledger entry: %w", err)
+	}
+
+	lines := []ledgerdomain.LedgerEntryLine{
+		{AccountID: cashAccount.ID, Direction: ledgerdomain.LedgerEntryDirectionDebit, Currency: invoice.Currency, Amount: amount},
+		{AccountID: arAccount.ID, Direction: ledgerdomain.LedgerEntryDirectionCredit, Currency: invoice.Currency, Amount: amount},
+	}
+	for _, line := range lines {
+		if err := tx.WithContext(ctx).Exec(
+			`INSERT INTO ledger_entry_lines (
+				id, ledger_entry_id, account_id, direction, currency, amount, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			s.genID.Generate(),
+			entryID,
+			line.AccountID,
+			string(line.Direction),
+			line.Currency,
+			line.Amount,
+			now,
+		).Error; err != nil {
+			return 0, fmt.Errorf("failed to insert ledger entry line: %w", err)
+		}
+	}
+
+	// payment_settled carries the ledger entry, so it is published instead of
+	// ledger_entry_created; the rollup would otherwise apply the entry twice.
+	if s.outbox != nil {
+		if err := s.outbox.PublishTx(ctx, tx, events.Event{
+			OrgID: invoice.OrgID,
+			Type:  events.EventPaymentSettled,
+			Payload: map[string]any{
+				"ledger_entry_id": entryID.String(),
+				"source_type":     string(ledgerdomain.SourceTypePayment),
+				"source_id":       paymentID.String(),
+				"invoice_id":      invoice.ID.String(),
+				"customer_id":     invoice.CustomerID.String(),
+				"amount":          amount,
+				"currency":        invoice.Currency,
+				"payment_method":  method,
+			},
+			DedupeKey: "payment_settled:" + paymentID.String(),
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	s.log.Info("recorded out-of-band payment",
+		zap.String("invoice_id", invoice.ID.String()),
+		zap.String("payment_id", paymentID.String()),
+		zap.String("ledger_entry_id", entryID.String()),
+		zap.Int64("amount", amount),
+	)
+	return paymentID, nil
+}
+
+func isOutOfBandPaymentMethod(method string) bool {
+	switch method {
+	case invoicedomain.PaymentMethodWireTransfer,
+		invoicedomain.PaymentMethodCheque,
+		invoicedomain.PaymentMethodCash,
+		invoicedomain.PaymentMethodOther:
+		return true
+	default:
+		return false
+	}
+}
+
+func metadataAmount(metadata datatypes.JSONMap, key string) int64 {
+	switch typed := metadata[key].(type) {
+	case float64:
+		return int64(typed)
+	case int64:
+		return typed
+	case int:
+		return int64(typed)
+	case json.Number:
+		parsed, _ := typed.Int64()
+		return parsed
+	case string:
+		parsed, _ := strconv.ParseInt(strings.TrimSpace(typed), 10, 64)
+		return parsed
+	default:
+		return 0
+	}
+}
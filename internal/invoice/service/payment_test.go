@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/glebarez/sqlite"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	ledgerdomain "github.com/smallbiznis/railzway/internal/ledger/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+func TestMarkInvoicePaid_PostsOutstandingBalanceToCash(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&invoicedomain.Invoice{},
+		&ledgerdomain.LedgerEntry{},
+		&ledgerdomain.LedgerEntryLine{},
+		&ledgerdomain.LedgerAccount{},
+		&creditnotedomain.CreditNote{},
+	))
+	require.NoError(t, db.Exec(`CREATE TABLE payment_events (
+		id BIGINT PRIMARY KEY,
+		org_id BIGINT NOT NULL,
+		provider TEXT NOT NULL,
+		provider_event_id TEXT NOT NULL,
+		event_type TEXT NOT NULL,
+		customer_id BIGINT NOT NULL,
+		payload TEXT NOT NULL,
+		received_at TIMESTAMP NOT NULL,
+		processed_at TIMESTAMP
+	)`).Error)
+	db.Exec("DROP INDEX IF EXISTS ux_ledger_accounts_org_type")
+
+	node, _ := snowflake.NewNode(1)
+	svc := NewService(ServiceParam{DB: db, Log: zap.NewNop(), GenID: node}).(*Service)
+
+	orgID := node.Generate()
+	customerID := node.Generate()
+	invoiceID := node.Generate()
+	cashAccountID := node.Generate()
+	arAccountID := node.Generate()
+	require.NoError(t, db.Create(&ledgerdomain.LedgerAccount{ID: cashAccountID, OrgID: orgID, Code: ledgerdomain.AccountCodeCash, Name: "Cash", Type: ledgerdomain.Assets}).Error)
+	require.NoError(t, db.Create(&ledgerdomain.LedgerAccount{ID: arAccountID, OrgID: orgID, Code: ledgerdomain.AccountCodeAccountsReceivable, Name: "AR", Type: ledgerdomain.Assets}).Error)
+
+	now := time.Now().UTC()
+	require.NoError(t, db.Create(&invoicedomain.Invoice{
+		ID:             invoiceID,
+		OrgID:          orgID,
+		CustomerID:     customerID,
+		Status:         invoicedomain.InvoiceStatusFinalized,
+		SubtotalAmount: 10000,
+		TaxAmount:      2000,
+		TotalAmount:    12000,
+		Currency:       "USD",
+		FinalizedAt:    &now,
+		Metadata:       datatypes.JSONMap{"amount_paid": 2000, "payment_failed_at": now.Format(time.RFC3339)},
+	}).Error)
+	require.NoError(t, db.Create(&creditnotedomain.CreditNote{
+		ID:               node.Generate(),
+		OrgID:            orgID,
+		InvoiceID:        invoiceID,
+		CustomerID:       customerID,
+		CreditNoteSeq:    1,
+		CreditNoteNumber: "CN-1",
+		Status:           creditnotedomain.CreditNoteStatusIssued,
+		Reason:           "other",
+		Destination:      creditnotedomain.DestinationReceivable,
+		TotalAmount:      1000,
+		Currency:         "USD",
+		IssuedAt:         now,
+	}).Error)
+
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+	paidAt := now.Add(-24 * time.Hour).Truncate(time.Second)
+
+	err = svc.MarkInvoicePaid(ctx, invoiceID.String(), invoicedomain.MarkPaidRequest{PaymentMethod: "wire"})
+	assert.ErrorIs(t, err, invoicedomain.ErrInvalidPaymentMethod)
+
+	err = svc.MarkInvoicePaid(orgcontext.WithOrgID(context.Background(), int64(node.Generate())), invoiceID.String(), invoicedomain.MarkPaidRequest{PaymentMethod: invoicedomain.PaymentMethodCheque})
+	assert.ErrorIs(t, err, invoicedomain.ErrInvoiceNotFound)
+
+	err = svc.MarkInvoicePaid(ctx, invoiceID.String(), invoicedomain.MarkPaidRequest{
+		PaymentMethod: invoicedomain.PaymentMethodWireTransfer,
+		Reference:     "WT-4711",
+		PaidAt:        &paidAt,
+	})
+	require.NoError(t, err)
+
+	var entry ledgerdomain.LedgerEntry
+	require.NoError(t, db.First(&entry, "org_id = ? AND source_type = ?", orgID, ledgerdomain.SourceTypePayment).Error)
+	assert.True(t, entry.OccurredAt.Equal(paidAt))
+
+	var lines []ledgerdomain.LedgerEntryLine
+	require.NoError(t, db.Find(&lines, "ledger_entry_id = ?", entry.ID).Error)
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Equal(t, int64(9000), line.Amount)
+		switch line.AccountID {
+		case cashAccountID:
+			assert.Equal(t, ledgerdomain.LedgerEntryDirectionDebit, line.Direction)
+		case arAccountID:
+			assert.Equal(t, ledgerdomain.LedgerEntryDirectionCredit, line.Direction)
+		default:
+			t.Fatalf("unexpected account %s", line.AccountID)
+		}
+	}
+
+	var customer snowflake.ID
+	require.NoError(t, db.Raw(`SELECT customer_id FROM payment_events WHERE id = ?`, entry.SourceID).Scan(&customer).Error)
+	assert.Equal(t, customerID, customer)
+
+	var invoice invoicedomain.Invoice
+	require.NoError(t, db.First(&invoice, "id = ?", invoiceID).Error)
+	require.NotNil(t, invoice.PaidAt)
+	assert.True(t, invoice.PaidAt.Equal(paidAt))
+	assert.Equal(t, int64(11000), metadataAmount(invoice.Metadata, "amount_paid"))
+	assert.Equal(t, "WT-4711", invoice.Metadata["payment_reference"])
+	assert.NotContains(t, invoice.Metadata, "payment_failed_at")
+
+	err = svc.MarkInvoicePaid(ctx, invoiceID.String(), invoicedomain.MarkPaidRequest{PaymentMethod: invoicedomain.PaymentMethodCheque})
+	assert.ErrorIs(t, err, invoicedomain.ErrInvoiceAlreadyPaid)
+	assert.ErrorIs(t, svc.VoidInvoice(ctx, invoiceID.String(), ""), invoicedomain.ErrInvoiceAlreadyPaid)
+}
+
+func TestVoidInvoice_RefusesInvoiceWithIssuedCreditNotes(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&invoicedomain.Invoice{},
+		&creditnotedomain.CreditNote{},
+	))
+
+	node, _ := snowflake.NewNode(1)
+	svc := NewService(ServiceParam{DB: db, Log: zap.NewNop(), GenID: node}).(*Service)
+
+	orgID := node.Generate()
+	customerID := node.Generate()
+	invoiceID := node.Generate()
+	now := time.Now().UTC()
+	require.NoError(t, db.Create(&invoicedomain.Invoice{
+		ID:             invoiceID,
+		OrgID:          orgID,
+		CustomerID:     customerID,
+		Status:         invoicedomain.InvoiceStatusFinalized,
+		SubtotalAmount: 10000,
+		TotalAmount:    10000,
+		Currency:       "USD",
+		FinalizedAt:    &now,
+	}).Error)
+	require.NoError(t, db.Create(&creditnotedomain.CreditNote{
+		ID:               node.Generate(),
+		OrgID:            orgID,
+		InvoiceID:        invoiceID,
+		CustomerID:       customerID,
+		CreditNoteSeq:    1,
+		CreditNoteNumber: "CN-1",
+		Status:           creditnotedomain.CreditNoteStatusIssued,
+		Reason:           "other",
+		Destination:      creditnotedomain.DestinationReceivable,
+		TotalAmount:      1000,
+		Currency:         "USD",
+		IssuedAt:         now,
+	}).Error)
+
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+	assert.ErrorIs(t, svc.VoidInvoice(ctx, invoiceID.String(), ""), invoicedomain.ErrInvoiceHasCreditNotes)
+
+	var invoice invoicedomain.Invoice
+	require.NoError(t, db.First(&invoice, "id = ?", invoiceID).Error)
+	assert.Equal(t, invoicedomain.InvoiceStatusFinalized, invoice.Status)
+	assert.Nil(t, invoice.VoidedAt)
+}
@@ -7,13 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"math"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
 	auditdomain "github.com/smallbiznis/railzway/internal/audit/domain"
 	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
 	"github.com/smallbiznis/railzway/internal/events"
 	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
 	invoiceformat "github.com/smallbiznis/railzway/internal/invoice/format"
@@ -21,6 +21,7 @@ import (
 	templatedomain "github.com/smallbiznis/railzway/internal/invoicetemplate/domain"
 	ledgerdomain "github.com/smallbiznis/railzway/internal/ledger/domain"
 	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	"github.com/smallbiznis/railzway/internal/money"
 	"github.com/smallbiznis/railzway/internal/orgcontext"
 	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
 	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
@@ -35,6 +36,7 @@ import (
 	"github.com/smallbiznis/railzway/pkg/repository"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -107,6 +109,7 @@ type ServiceParam struct {
 	PublicTokenSvc publicinvoicedomain.PublicInvoiceTokenService
 	TaxResolver    taxdomain.TaxResolver
 	LedgerSvc      ledgerdomain.Service
+	RatingSvc      ratingdomain.Service
 	Outbox         *events.Outbox `optional:"true"`
 	EmailProvider  email.Provider
 	PDFProvider    pdf.Provider
@@ -124,6 +127,7 @@ type Service struct {
 	publicTokenSvc publicinvoicedomain.PublicInvoiceTokenService
 	taxResolver    taxdomain.TaxResolver
 	ledgerSvc      ledgerdomain.Service
+	ratingSvc      ratingdomain.Service
 	outbox         *events.Outbox
 	emailProvider  email.Provider
 	pdfProvider    pdf.Provider
@@ -142,6 +146,7 @@ func NewService(p ServiceParam) invoicedomain.Service {
 		publicTokenSvc: p.PublicTokenSvc,
 		taxResolver:    p.TaxResolver,
 		ledgerSvc:      p.LedgerSvc,
+		ratingSvc:      p.RatingSvc,
 		outbox:         p.Outbox,
 		emailProvider:  p.EmailProvider,
 		pdfProvider:    p.PDFProvider,
@@ -325,7 +330,7 @@ func (s *Service) GenerateInvoice(ctx context.Context, billingCycleID string) (*
 		if cycle.Status != billingcycledomain.BillingCycleStatusClosed {
 			return invoicedomain.ErrBillingCycleNotClosed
 		}
-		if !cycle.PeriodEnd.After(cycle.PeriodStart) {
+		if cycle.PeriodEnd.Before(cycle.PeriodStart) {
 			return invoicedomain.ErrInvalidBillingCycle
 		}
 
@@ -377,14 +382,29 @@ func (s *Service) GenerateInvoice(ctx context.Context, billingCycleID string) (*
 		creditLines := make([]ledgerEntryLineRow, 0, len(lines))
 		for _, line := range lines {
 			if line.Direction != ledgerdomain.LedgerEntryDirectionCredit {
+				// Revenue reversed by credits, e.g. for the unused time
+				// of a fee billed in advance.
+				if line.AccountCode != string(ledgerdomain.AccountCodeAccountsReceivable) {
+					subtotal -= line.Amount
+				}
 				continue
 			}
-			subtotal += line.Amount
+			if line.AccountCode != string(ledgerdomain.AccountCodeCreditBalance) {
+				subtotal += line.Amount
+			}
 			creditLines = append(creditLines, line)
 		}
 		if len(creditLines) == 0 {
 			return invoicedomain.ErrMissingLedgerEntry
 		}
+		// A net credit, e.g. a cancellation settling a fee billed in advance,
+		// is carried to the customer's credit balance as a grant once the
+		// invoice is finalized.
+		var carried int64
+		if subtotal < 0 {
+			carried = -subtotal
+			subtotal = 0
+		}
 
 		invoiceNumber, err := s.nextInvoiceNumber(ctx, tx, cycle.OrgID)
 		if err != nil {
@@ -396,6 +416,23 @@ func (s *Service) GenerateInvoice(ctx context.Context, billingCycleID string) (*
 		if err != nil {
 			return err
 		}
+		// Discounts reduce the subtotal before tax, which is computed on the
+		// discounted amount at finalize-time.
+		discounts, err := s.resolveDiscounts(ctx, tx, *cycle, subscription.CustomerID, entry.Currency, subtotal)
+		if err != nil {
+			return err
+		}
+		discountAmount := sumDiscounts(discounts)
+		subtotal -= discountAmount
+
+		// Adjustments from usage corrections of earlier, already closed cycles
+		// are billed on the next invoice of the subscription.
+		adjustments, adjustmentAmount, err := s.resolveAdjustments(ctx, tx, *cycle, entry.Currency, subtotal)
+		if err != nil {
+			return err
+		}
+		subtotal += adjustmentAmount
+
 		invoiceID := s.genID.Generate()
 		invoice := invoicedomain.Invoice{
 			ID:             invoiceID,
@@ -407,6 +444,7 @@ func (s *Service) GenerateInvoice(ctx context.Context, billingCycleID string) (*
 			CustomerID:     subscription.CustomerID,
 			Status:         invoicedomain.InvoiceStatusDraft,
 			SubtotalAmount: subtotal,
+			DiscountAmount: discountAmount,
 			Currency:       entry.Currency,
 			PeriodStart:    &cycle.PeriodStart,
 			PeriodEnd:      &cycle.PeriodEnd,
@@ -425,6 +463,15 @@ func (s *Service) GenerateInvoice(ctx context.Context, billingCycleID string) (*
 		if err := s.listInvoiceItemPartsFromRating(ctx, tx, *cycle, invoiceID); err != nil {
 			return err
 		}
+		if err := s.recordDiscounts(ctx, tx, *cycle, invoiceID, entry.Currency, discounts, now); err != nil {
+			return err
+		}
+		if err := s.recordAdjustments(ctx, tx, invoiceID, entry.Currency, adjustments, now); err != nil {
+			return err
+		}
+		if err := s.carryCredit(ctx, tx, &invoice, carried, now); err != nil {
+			return err
+		}
 
 		return nil
 	})
@@ -459,19 +506,7 @@ func (s *Service) listInvoiceItemPartsFromRating(
 		entitlementMap[e.FeatureCode] = e
 	}
 
-	var rows []struct {
-		ID          snowflake.ID
-		OrgID       snowflake.ID
-		MeterID     snowflake.ID
-		PriceID     snowflake.ID
-		FeatureCode string
-		Quantity    float64
-		UnitPrice   int64
-		Amount      int64
-		Currency    string
-		Source      string
-	}
-
+	var rows []ratingRow
 	if err := tx.WithContext(ctx).
 		Table("rating_results").
 		Select(`
@@ -484,54 +519,19 @@ func (s *Service) listInvoiceItemPartsFromRating(
 		unit_price,
 		amount,
 		currency,
+		period_start,
+		period_end,
 		source
 	`).
-		Where("billing_cycle_id = ?", cycle.ID).
+		Where("billing_cycle_id = ? AND adjustment_id IS NULL", cycle.ID).
 		Scan(&rows).Error; err != nil {
 		return err
 	}
 
 	now := time.Now().UTC()
 	for _, r := range rows {
-		// Strict Join Rule: WAS Must match entitlement
-		// Now: Optional.
-		ent, ok := entitlementMap[r.FeatureCode]
-		description := "Usage"
-		if ok {
-			description = ent.FeatureName
-		} else if r.MeterID == 0 {
-			description = "Subscription"
-		}
-
-		invoiceItem := invoicedomain.InvoiceItem{
-			ID:             s.genID.Generate(),
-			OrgID:          r.OrgID,
-			InvoiceID:      invoiceID,
-			RatingResultID: &r.ID,
-			Quantity:       float64(r.Quantity),
-			UnitPrice:      r.UnitPrice,
-			Amount:         r.Amount,
-			Description:    description, // Use snapshot data or fallback
-			LineType:       invoicedomain.InvoiceItemLineTypeUsage,
-			CreatedAt:      now,
-		}
-
-		// Refine Line Type based on Entitlement or Rating?
-		// If MeterID is nil, likely Subscription/Flat
-		if r.MeterID == 0 {
-			invoiceItem.LineType = invoicedomain.InvoiceItemLineTypeSubscription
-		}
-
-		// Enrich description (e.g. usage dates, rate)
-		part := invoiceItemPart{
-			Type:        invoiceItem.LineType,
-			DisplayName: invoiceItem.Description,
-			Quantity:    r.Quantity,
-			RateAmount:  r.UnitPrice,
-			Currency:    r.Currency,
-			UnitLabel:   "unit", // Default, could be enriched from entitlement metadata if available
-		}
-		invoiceItem.Description = s.formatInvoiceItemDescription(part, cycle)
+		invoiceItem := s.buildRatingInvoiceItem(r, entitlementMap, cycle, invoiceID, now)
+		invoiceItem.RatingResultID = &r.ID
 
 		if err := s.insertInvoiceItem(ctx, tx, invoiceItem); err != nil {
 			return err
@@ -541,6 +541,84 @@ func (s *Service) listInvoiceItemPartsFromRating(
 	return nil
 }
 
+type ratingRow struct {
+	ID          snowflake.ID
+	OrgID       snowflake.ID
+	MeterID     snowflake.ID
+	PriceID     snowflake.ID
+	FeatureCode string
+	Quantity    float64
+	UnitPrice   int64
+	Amount      int64
+	Currency    string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Source      string
+}
+
+// buildRatingInvoiceItem turns one rating result into an invoice line.
+func (s *Service) buildRatingInvoiceItem(
+	r ratingRow,
+	entitlementMap map[string]invoicedomain.SubscriptionEntitlement,
+	cycle billingCycleRow,
+	invoiceID snowflake.ID,
+	now time.Time,
+) invoicedomain.InvoiceItem {
+	// Strict Join Rule: WAS Must match entitlement
+	// Now: Optional.
+	ent, ok := entitlementMap[r.FeatureCode]
+	description := "Usage"
+	if ok {
+		description = ent.FeatureName
+	} else if r.MeterID == 0 {
+		description = "Subscription"
+	}
+	// Proration rows come from a mid-cycle plan change; advance adjustments
+	// settle a fee billed in advance with the time actually used.
+	if r.Source == "proration" || r.Source == "advance_adjustment" {
+		if r.Amount < 0 {
+			description = "Unused time on " + description
+		} else {
+			description = "Remaining time on " + description
+		}
+	}
+
+	invoiceItem := invoicedomain.InvoiceItem{
+		ID:          s.genID.Generate(),
+		OrgID:       r.OrgID,
+		InvoiceID:   invoiceID,
+		Quantity:    float64(r.Quantity),
+		UnitPrice:   r.UnitPrice,
+		Amount:      r.Amount,
+		Description: description, // Use snapshot data or fallback
+		LineType:    invoicedomain.InvoiceItemLineTypeUsage,
+		CreatedAt:   now,
+	}
+
+	// Refine Line Type based on Entitlement or Rating?
+	// If MeterID is nil, likely Subscription/Flat
+	if r.MeterID == 0 {
+		invoiceItem.LineType = invoicedomain.InvoiceItemLineTypeSubscription
+	}
+
+	// Enrich description (e.g. usage dates, rate)
+	part := invoiceItemPart{
+		Type:        invoiceItem.LineType,
+		DisplayName: invoiceItem.Description,
+		Quantity:    r.Quantity,
+		RateAmount:  r.UnitPrice,
+		Currency:    r.Currency,
+		UnitLabel:   "unit", // Default, could be enriched from entitlement metadata if available
+	}
+	// Advance charges bill the period after the cycle.
+	period := cycle
+	if r.Source == "advance" && r.PeriodEnd.After(r.PeriodStart) {
+		period.PeriodStart, period.PeriodEnd = r.PeriodStart, r.PeriodEnd
+	}
+	invoiceItem.Description = s.formatInvoiceItemDescription(part, period)
+	return invoiceItem
+}
+
 func (s *Service) listEntitlementsForCycle(
 	ctx context.Context,
 	tx *gorm.DB,
@@ -640,17 +718,10 @@ func (s *Service) formatInvoiceItemDescription(
 		if p.RateAmount >= 0 {
 			// High precision rate for description
 			c := strings.ToUpper(p.Currency)
-			decimals, ok := currencyDecimals[c]
-			if !ok {
-				decimals = 2
-			}
-			divisor := math.Pow(10, float64(decimals))
-			val := float64(p.RateAmount) / divisor
-
 			rate := fmt.Sprintf(
-				"%s %.6f / %s",
+				"%s %s / %s",
 				c,
-				val,
+				money.ToMajor(p.RateAmount, c).Fixed(6),
 				unitOrDefault(p.UnitLabel),
 			)
 			parts = append(parts, fmt.Sprintf("Rate: %s", rate))
@@ -688,7 +759,7 @@ func (s *Service) FinalizeInvoice(ctx context.Context, invoiceID string) error {
 		if err != nil {
 			return err
 		}
-		if invoice == nil {
+		if invoice == nil || !invoiceInCallerOrg(ctx, invoice) {
 			return invoicedomain.ErrInvoiceNotFound
 		}
 		if invoice.Status == invoicedomain.InvoiceStatusFinalized {
@@ -702,6 +773,21 @@ func (s *Service) FinalizeInvoice(ctx context.Context, invoiceID string) error {
 			return invoicedomain.ErrInvalidSubtotal
 		}
 
+		now := time.Now().UTC()
+		dueAt := now.AddDate(0, 0, 30)
+
+		// Credit grants are drawn down before tax, so tax applies only to
+		// what the customer still owes.
+		credits, err := s.resolveCredits(ctx, tx, invoice.OrgID, invoice.CustomerID, invoice.Currency, invoice.SubtotalAmount, now, true)
+		if err != nil {
+			return err
+		}
+		if err := s.recordCredits(ctx, tx, invoice, credits, now); err != nil {
+			return err
+		}
+		invoice.CreditAmount = sumCredits(credits)
+		invoice.SubtotalAmount -= invoice.CreditAmount
+
 		// Tax is resolved and frozen at finalize-time.
 		taxDef, err := s.taxResolver.ResolveForInvoice(ctx, invoice.OrgID, invoice.CustomerID)
 		if err != nil {
@@ -711,18 +797,8 @@ func (s *Service) FinalizeInvoice(ctx context.Context, invoiceID string) error {
 		invoice.TaxCode = nil
 		invoice.TaxAmount = 0
 
-		now := time.Now().UTC()
-		dueAt := now.AddDate(0, 0, 30)
-
 		if taxDef != nil {
-			switch taxDef.TaxMode {
-			case taxdomain.TaxModeExclusive:
-				invoice.TaxAmount = taxservice.ComputeTaxExclusive(invoice.SubtotalAmount, taxDef.Rate)
-			case taxdomain.TaxModeInclusive:
-				invoice.TaxAmount = taxservice.ComputeTaxInclusive(invoice.SubtotalAmount, taxDef.Rate)
-			default:
-				invoice.TaxAmount = 0
-			}
+			invoice.TaxAmount = computeTaxAmount(taxDef, invoice.SubtotalAmount)
 			invoice.TaxRate = taxDef.Rate
 			invoice.TaxCode = &taxDef.Code
 
@@ -763,7 +839,7 @@ func (s *Service) FinalizeInvoice(ctx context.Context, invoiceID string) error {
 
 		if err := tx.WithContext(ctx).Exec(
 			`UPDATE invoices
-			 SET status = ?, finalized_at = ?, issued_at = ?, due_at = ?, invoice_template_id = ?, rendered_html = ?, rendered_pdf_url = ?, tax_rate = ?, tax_code = ?, tax_amount = ?, total_amount = ?, updated_at = ?
+			 SET status = ?, finalized_at = ?, issued_at = ?, due_at = ?, invoice_template_id = ?, rendered_html = ?, rendered_pdf_url = ?, subtotal_amount = ?, credit_amount = ?, tax_rate = ?, tax_code = ?, tax_amount = ?, total_amount = ?, updated_at = ?
 			 WHERE id = ?`,
 			invoice.Status,
 			invoice.FinalizedAt,
@@ -772,6 +848,8 @@ func (s *Service) FinalizeInvoice(ctx context.Context, invoiceID string) error {
 			invoice.InvoiceTemplateID,
 			invoice.RenderedHTML,
 			invoice.RenderedPDFURL,
+			invoice.SubtotalAmount,
+			invoice.CreditAmount,
 			invoice.TaxRate,
 			invoice.TaxCode,
 			invoice.TaxAmount,
@@ -781,6 +859,9 @@ func (s *Service) FinalizeInvoice(ctx context.Context, invoiceID string) error {
 		).Error; err != nil {
 			return err
 		}
+		if err := s.activateCarriedCredit(ctx, tx, invoice, now); err != nil {
+			return err
+		}
 		finalizedInvoice = invoice
 
 		if publicToken, err = s.publicTokenSvc.EnsureForInvoice(ctx, *finalizedInvoice); err != nil {
@@ -807,6 +888,9 @@ func (s *Service) FinalizeInvoice(ctx context.Context, invoiceID string) error {
 		if err := s.postInvoiceToLedger(ctx, tx, invoice); err != nil {
 			return err
 		}
+		if err := s.postCreditUseToLedger(ctx, tx, invoice); err != nil {
+			return err
+		}
 
 		return nil
 	})
@@ -852,12 +936,28 @@ func (s *Service) VoidInvoice(ctx context.Context, invoiceID string, reason stri
 		if err != nil {
 			return err
 		}
-		if invoice == nil {
+		if invoice == nil || !invoiceInCallerOrg(ctx, invoice) {
 			return invoicedomain.ErrInvoiceNotFound
 		}
 		if invoice.Status != invoicedomain.InvoiceStatusFinalized {
 			return invoicedomain.ErrInvoiceNotFinalized
 		}
+		if invoice.PaidAt != nil {
+			return invoicedomain.ErrInvoiceAlreadyPaid
+		}
+		var creditNotes int64
+		if err := tx.WithContext(ctx).Raw(
+			`SELECT COUNT(1) FROM credit_notes
+			 WHERE org_id = ? AND invoice_id = ? AND status = ?`,
+			invoice.OrgID,
+			invoice.ID,
+			creditnotedomain.CreditNoteStatusIssued,
+		).Scan(&creditNotes).Error; err != nil {
+			return err
+		}
+		if creditNotes > 0 {
+			return invoicedomain.ErrInvoiceHasCreditNotes
+		}
 
 		now := time.Now().UTC()
 		if err := tx.WithContext(ctx).Exec(
@@ -871,6 +971,18 @@ func (s *Service) VoidInvoice(ctx context.Context, invoiceID string, reason stri
 		).Error; err != nil {
 			return err
 		}
+		if err := s.releaseDiscounts(ctx, tx, invoice, now); err != nil {
+			return err
+		}
+		if err := s.releaseAdjustments(ctx, tx, invoice, now); err != nil {
+			return err
+		}
+		if err := s.restoreCredits(ctx, tx, invoice, now); err != nil {
+			return err
+		}
+		if err := s.revokeCarriedCredit(ctx, tx, invoice, now); err != nil {
+			return err
+		}
 		voidedInvoice = invoice
 
 		if s.outbox != nil {
@@ -1166,9 +1278,9 @@ func (s *Service) insertInvoice(ctx context.Context, tx *gorm.DB, invoice invoic
 	result := tx.WithContext(ctx).Exec(
 		`INSERT INTO invoices (
 			id, org_id, invoice_seq, invoice_number, billing_cycle_id, subscription_id, customer_id,
-			invoice_template_id, status, subtotal_amount, total_amount, currency, period_start, period_end,
+			invoice_template_id, status, subtotal_amount, discount_amount, total_amount, currency, period_start, period_end,
 			issued_at, due_at, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT (billing_cycle_id) DO NOTHING`,
 		invoice.ID,
 		invoice.OrgID,
@@ -1180,6 +1292,7 @@ func (s *Service) insertInvoice(ctx context.Context, tx *gorm.DB, invoice invoic
 		invoice.InvoiceTemplateID,
 		invoice.Status,
 		invoice.SubtotalAmount,
+		invoice.DiscountAmount,
 		invoice.TotalAmount,
 		invoice.Currency,
 		invoice.PeriodStart,
@@ -1199,19 +1312,25 @@ func (s *Service) insertInvoice(ctx context.Context, tx *gorm.DB, invoice invoic
 }
 
 func (s *Service) insertInvoiceItem(ctx context.Context, tx *gorm.DB, item invoicedomain.InvoiceItem) error {
+	metadata := item.Metadata
+	if metadata == nil {
+		metadata = datatypes.JSONMap{}
+	}
 	return tx.WithContext(ctx).Exec(
 		`INSERT INTO invoice_items (
-			id, org_id, invoice_id, rating_result_id,
-			description, quantity, unit_price, amount, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, org_id, invoice_id, rating_result_id, line_type,
+			description, quantity, unit_price, amount, metadata, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		item.ID,
 		item.OrgID,
 		item.InvoiceID,
 		item.RatingResultID,
+		item.LineType,
 		item.Description,
 		item.Quantity,
 		item.UnitPrice,
 		item.Amount,
+		metadata,
 		item.CreatedAt,
 	).Error
 }
@@ -1219,9 +1338,9 @@ func (s *Service) insertInvoiceItem(ctx context.Context, tx *gorm.DB, item invoi
 func (s *Service) loadInvoiceForUpdate(ctx context.Context, tx *gorm.DB, id snowflake.ID) (*invoicedomain.Invoice, error) {
 	var invoice invoicedomain.Invoice
 	query := `SELECT id, org_id, invoice_number, billing_cycle_id, subscription_id, customer_id,
-		        invoice_template_id, status, subtotal_amount, tax_rate, tax_code, tax_amount, total_amount, currency, period_start, period_end,
-		        issued_at, due_at, finalized_at, voided_at, rendered_html, rendered_pdf_url,
-		        created_at, updated_at
+		        invoice_template_id, status, subtotal_amount, discount_amount, credit_amount, tax_rate, tax_code, tax_amount, total_amount, currency, period_start, period_end,
+		        issued_at, due_at, finalized_at, paid_at, voided_at, rendered_html, rendered_pdf_url,
+		        metadata, created_at, updated_at
 		 FROM invoices
 		 WHERE id = ?`
 
@@ -1242,6 +1361,70 @@ func (s *Service) loadInvoiceForUpdate(ctx context.Context, tx *gorm.DB, id snow
 	return &invoice, nil
 }
 
+// invoiceInCallerOrg reports whether the invoice belongs to the organization
+// carried by ctx. Calls without an organization (scheduler jobs) are trusted.
+// invoiceItemRef is an invoice line that points back, through a metadata key,
+// at the record it was drawn from.
+type invoiceItemRef struct {
+	ID     snowflake.ID
+	Amount int64
+}
+
+// listInvoiceItemRefs returns the records the invoice's lines of lineType
+// reference under key. Lines without the key are skipped.
+func (s *Service) listInvoiceItemRefs(
+	ctx context.Context,
+	tx *gorm.DB,
+	invoiceID snowflake.ID,
+	lineType invoicedomain.InvoiceItemLineType,
+	key string,
+) ([]invoiceItemRef, error) {
+	var rows []struct {
+		Amount   int64
+		Metadata datatypes.JSONMap
+	}
+	if err := tx.WithContext(ctx).Raw(
+		`SELECT amount, metadata
+		 FROM invoice_items
+		 WHERE invoice_id = ? AND line_type = ?
+		 ORDER BY id ASC`,
+		invoiceID,
+		lineType,
+	).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	refs := make([]invoiceItemRef, 0, len(rows))
+	for _, row := range rows {
+		raw, _ := row.Metadata[key].(string)
+		id, err := snowflake.ParseString(raw)
+		if err != nil || id == 0 {
+			continue
+		}
+		refs = append(refs, invoiceItemRef{ID: id, Amount: row.Amount})
+	}
+	return refs, nil
+}
+
+func invoiceInCallerOrg(ctx context.Context, invoice *invoicedomain.Invoice) bool {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return true
+	}
+	return invoice.OrgID == orgID
+}
+
+func computeTaxAmount(taxDef *taxdomain.TaxDefinition, subtotal int64) int64 {
+	switch taxDef.TaxMode {
+	case taxdomain.TaxModeExclusive:
+		return taxservice.ComputeTaxExclusive(subtotal, taxDef.Rate)
+	case taxdomain.TaxModeInclusive:
+		return taxservice.ComputeTaxInclusive(subtotal, taxDef.Rate)
+	default:
+		return 0
+	}
+}
+
 func parseID(raw string) (snowflake.ID, error) {
 	return snowflake.ParseString(raw)
 }
@@ -1272,48 +1455,8 @@ func formatQty(v float64) string {
 	return fmt.Sprintf("%.2f", v)
 }
 
-var currencyDecimals = map[string]int{
-	"USD": 2,
-	"EUR": 2,
-	"SGD": 2,
-	"CNY": 2,
-	"IDR": 0,
-}
-
 func formatMoney(amount int64, currency string) string {
-	c := strings.ToUpper(currency)
-	decimals, ok := currencyDecimals[c]
-	if !ok {
-		// fallback: assume 2 decimals
-		decimals = 2
-	}
-
-	sign := ""
-	if amount < 0 {
-		sign = "-"
-		amount = -amount
-	}
-
-	if decimals == 0 {
-		return fmt.Sprintf("%s%s %d", sign, c, amount)
-	}
-
-	divisor := int64(1)
-	for i := 0; i < decimals; i++ {
-		divisor *= 10
-	}
-
-	major := amount / divisor
-	minor := amount % divisor
-
-	return fmt.Sprintf(
-		"%s%s %d.%0*d",
-		sign,
-		c,
-		major,
-		decimals,
-		minor,
-	)
+	return money.Format(amount, currency)
 }
 
 // sendInvoiceNotification generates PDF and sends email
@@ -1351,8 +1494,8 @@ func (s *Service) sendInvoiceNotification(ctx context.Context, invoice *invoiced
 		InvoiceNumber: invoice.ID.String(),
 		IssueDate:     invoice.IssuedAt.Format("January 2, 2006"),
 		DueDate:       invoice.DueAt.Format("January 2, 2006"),
-		TotalDue:      fmt.Sprintf("$%.2f", float64(invoice.TotalAmount)/100.0), // TODO: Currency formatting
-		Total:         fmt.Sprintf("$%.2f", float64(invoice.TotalAmount)/100.0),
+		TotalDue:      formatMoney(invoice.TotalAmount, invoice.Currency),
+		Total:         formatMoney(invoice.TotalAmount, invoice.Currency),
 		OrgName:       org.Name,
 		// Populate other fields as needed
 	}
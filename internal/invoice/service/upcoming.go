@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	"gorm.io/gorm"
+)
+
+// PreviewUpcomingInvoice runs rating and invoice building in dry-run mode
+// against the current OPEN billing cycle of the customer's subscription. When
+// subscriptionID is empty the customer must have a single subscription with an
+// open cycle. It follows the same path
+// the cycle takes at close and finalization (rating, ledger subtotal, discounts,
+// credits, tax) but never writes rating results, ledger entries, invoices,
+// discount usage or credit drawdowns.
+func (s *Service) PreviewUpcomingInvoice(ctx context.Context, customerID string, subscriptionID string) (*invoicedomain.UpcomingInvoice, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, invoicedomain.ErrInvalidOrganization
+	}
+	custID, err := parseID(strings.TrimSpace(customerID))
+	if err != nil || custID == 0 {
+		return nil, invoicedomain.ErrInvalidCustomer
+	}
+	var subID snowflake.ID
+	if strings.TrimSpace(subscriptionID) != "" {
+		subID, err = parseID(strings.TrimSpace(subscriptionID))
+		if err != nil || subID == 0 {
+			return nil, invoicedomain.ErrInvalidSubscription
+		}
+	}
+
+	db := s.db.WithContext(ctx)
+	cycle, err := s.findOpenCycleForCustomer(ctx, db, orgID, custID, subID)
+	if err != nil {
+		return nil, err
+	}
+	if cycle == nil {
+		return nil, invoicedomain.ErrNoUpcomingInvoice
+	}
+
+	results, err := s.ratingSvc.PreviewRating(ctx, cycle.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	subtotal, currency, err := ratedSubtotal(results)
+	if err != nil {
+		return nil, err
+	}
+
+	entitlements, err := s.listEntitlementsForCycle(ctx, db, cycle.OrgID, cycle.SubscriptionID, cycle.PeriodStart, cycle.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	entitlementMap := make(map[string]invoicedomain.SubscriptionEntitlement, len(entitlements))
+	for _, e := range entitlements {
+		entitlementMap[e.FeatureCode] = e
+	}
+
+	now := time.Now().UTC()
+	lines := make([]invoicedomain.UpcomingInvoiceLine, 0, len(results))
+	for _, r := range results {
+		row := ratingRow{
+			OrgID:       r.OrgID,
+			PriceID:     r.PriceID,
+			FeatureCode: r.FeatureCode,
+			Quantity:    r.Quantity,
+			UnitPrice:   r.UnitPrice,
+			Amount:      r.Amount,
+			Currency:    r.Currency,
+			PeriodStart: r.PeriodStart,
+			PeriodEnd:   r.PeriodEnd,
+			Source:      r.Source,
+		}
+		if r.MeterID != nil {
+			row.MeterID = *r.MeterID
+		}
+		lines = append(lines, upcomingLine(s.buildRatingInvoiceItem(row, entitlementMap, *cycle, 0, now)))
+	}
+
+	discounts, err := s.resolveDiscounts(ctx, db, *cycle, custID, currency, subtotal)
+	if err != nil {
+		return nil, err
+	}
+	discountAmount := sumDiscounts(discounts)
+	subtotal -= discountAmount
+	for _, applied := range discounts {
+		lines = append(lines, upcomingLine(s.buildDiscountInvoiceItem(*cycle, 0, currency, applied, now)))
+	}
+
+	adjustments, adjustmentAmount, err := s.resolveAdjustments(ctx, db, *cycle, currency, subtotal)
+	if err != nil {
+		return nil, err
+	}
+	subtotal += adjustmentAmount
+	for _, adjustment := range adjustments {
+		items, err := s.buildAdjustmentInvoiceItems(ctx, db, adjustment, 0, currency, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			lines = append(lines, upcomingLine(item))
+		}
+	}
+
+	// Credits are only drawn down at finalization; the preview shows what the
+	// customer's current balance would cover without consuming it.
+	credits, err := s.resolveCredits(ctx, db, orgID, custID, currency, subtotal, now, false)
+	if err != nil {
+		return nil, err
+	}
+	creditAmount := sumCredits(credits)
+	subtotal -= creditAmount
+	for _, applied := range credits {
+		lines = append(lines, upcomingLine(s.buildCreditInvoiceItem(*cycle, 0, currency, applied, now)))
+	}
+
+	preview := &invoicedomain.UpcomingInvoice{
+		CustomerID:     custID.String(),
+		SubscriptionID: cycle.SubscriptionID.String(),
+		BillingCycleID: cycle.ID.String(),
+		Currency:       currency,
+		PeriodStart:    cycle.PeriodStart,
+		PeriodEnd:      cycle.PeriodEnd,
+		Lines:          lines,
+		SubtotalAmount: subtotal,
+		DiscountAmount: discountAmount,
+		CreditAmount:   creditAmount,
+		PreviewedAt:    now,
+	}
+
+	taxDef, err := s.taxResolver.ResolveForInvoice(ctx, orgID, custID)
+	if err != nil {
+		return nil, err
+	}
+	if taxDef != nil {
+		preview.TaxAmount = computeTaxAmount(taxDef, subtotal)
+		preview.TaxRate = taxDef.Rate
+		preview.TaxCode = &taxDef.Code
+	}
+	preview.TotalAmount = preview.SubtotalAmount + preview.TaxAmount
+
+	return preview, nil
+}
+
+// findOpenCycleForCustomer returns the most recent OPEN billing cycle of the
+// customer's subscription. Without subscriptionID the customer's open cycles
+// must all belong to one subscription.
+func (s *Service) findOpenCycleForCustomer(ctx context.Context, db *gorm.DB, orgID, customerID, subscriptionID snowflake.ID) (*billingCycleRow, error) {
+	query := `SELECT bc.id, bc.org_id, bc.subscription_id, bc.period_start, bc.period_end, bc.status
+		 FROM billing_cycles bc
+		 JOIN subscriptions s ON s.id = bc.subscription_id AND s.org_id = bc.org_id
+		 WHERE bc.org_id = ? AND s.customer_id = ? AND bc.status = ?`
+	args := []any{orgID, customerID, billingcycledomain.BillingCycleStatusOpen}
+	if subscriptionID != 0 {
+		query += ` AND bc.subscription_id = ?`
+		args = append(args, subscriptionID)
+	}
+	query += ` ORDER BY bc.period_start DESC, bc.id DESC`
+
+	var rows []billingCycleRow
+	if err := db.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	for _, row := range rows[1:] {
+		if row.SubscriptionID != rows[0].SubscriptionID {
+			return nil, invoicedomain.ErrAmbiguousSubscription
+		}
+	}
+	return &rows[0], nil
+}
+
+// ratedSubtotal mirrors the billing-cycle ledger entry posted at close: flat
+// and usage revenue are summed per currency, non-positive groups are dropped
+// and the invoice must end up in a single currency.
+func ratedSubtotal(results []ratingdomain.RatingResult) (int64, string, error) {
+	type group struct {
+		flat     bool
+		currency string
+	}
+	totals := make(map[group]int64)
+	order := make([]group, 0, 2)
+	for _, r := range results {
+		key := group{flat: r.MeterID == nil, currency: r.Currency}
+		if _, ok := totals[key]; !ok {
+			order = append(order, key)
+		}
+		totals[key] += r.Amount
+	}
+
+	var (
+		subtotal int64
+		currency string
+	)
+	for _, key := range order {
+		if totals[key] <= 0 {
+			continue
+		}
+		if currency == "" {
+			currency = key.currency
+		} else if currency != key.currency {
+			return 0, "", invoicedomain.ErrCurrencyMismatch
+		}
+		subtotal += totals[key]
+	}
+	if currency == "" && len(results) > 0 {
+		currency = results[0].Currency
+	}
+	return subtotal, currency, nil
+}
+
+func upcomingLine(item invoicedomain.InvoiceItem) invoicedomain.UpcomingInvoiceLine {
+	line := invoicedomain.UpcomingInvoiceLine{
+		LineType:    item.LineType,
+		Description: item.Description,
+		Quantity:    item.Quantity,
+		UnitPrice:   item.UnitPrice,
+		Amount:      item.Amount,
+	}
+	if len(item.Metadata) > 0 {
+		line.Metadata = item.Metadata
+	}
+	return line
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/glebarez/sqlite"
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
+	coupondomain "github.com/smallbiznis/railzway/internal/coupon/domain"
+	creditdomain "github.com/smallbiznis/railzway/internal/credit/domain"
+	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	taxdomain "github.com/smallbiznis/railzway/internal/tax/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type previewRatingSvc struct {
+	cycleID string
+	results []ratingdomain.RatingResult
+}
+
+func (p *previewRatingSvc) RunRating(context.Context, string) error { return nil }
+
+func (p *previewRatingSvc) PreviewRating(_ context.Context, cycleID string) ([]ratingdomain.RatingResult, error) {
+	p.cycleID = cycleID
+	return p.results, nil
+}
+
+func (p *previewRatingSvc) RerateCycle(context.Context, string) ([]ratingdomain.RatingResult, error) {
+	return nil, nil
+}
+
+func TestPreviewUpcomingInvoice_DiscountAndTaxWithoutPersisting(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&invoicedomain.Invoice{},
+		&invoicedomain.InvoiceItem{},
+		&invoicedomain.SubscriptionEntitlement{},
+		&coupondomain.Coupon{},
+		&coupondomain.Discount{},
+		&adjustmentdomain.Adjustment{},
+		&creditdomain.CreditGrant{},
+	))
+	require.NoError(t, db.Exec("CREATE TABLE billing_cycles (id BIGINT, org_id BIGINT, subscription_id BIGINT, period_start DATETIME, period_end DATETIME, status TEXT)").Error)
+	require.NoError(t, db.Exec("CREATE TABLE subscriptions (id BIGINT, org_id BIGINT, customer_id BIGINT, status TEXT)").Error)
+
+	node, _ := snowflake.NewNode(1)
+	orgID := node.Generate()
+	customerID := node.Generate()
+	subID := node.Generate()
+	cycleID := node.Generate()
+	meterID := node.Generate()
+	now := time.Now().UTC()
+
+	require.NoError(t, db.Exec("INSERT INTO subscriptions (id, org_id, customer_id, status) VALUES (?, ?, ?, ?)", subID, orgID, customerID, "ACTIVE").Error)
+	require.NoError(t, db.Exec("INSERT INTO billing_cycles (id, org_id, subscription_id, period_start, period_end, status) VALUES (?, ?, ?, ?, ?, ?)",
+		cycleID, orgID, subID, now.AddDate(0, 0, -10), now.AddDate(0, 0, 20), "OPEN").Error)
+	require.NoError(t, db.Create(&invoicedomain.SubscriptionEntitlement{
+		ID: node.Generate(), OrgID: orgID, SubscriptionID: subID, FeatureCode: "api_calls", FeatureName: "API Calls", EffectiveFrom: now.AddDate(0, -1, 0),
+	}).Error)
+
+	percent := 10.0
+	coupon := coupondomain.Coupon{ID: node.Generate(), OrgID: orgID, Code: "TEN", Name: "Ten", DiscountType: coupondomain.DiscountTypePercent, PercentOff: &percent, Duration: coupondomain.DurationForever, Active: true}
+	require.NoError(t, db.Create(&coupon).Error)
+	discount := coupondomain.Discount{ID: node.Generate(), OrgID: orgID, CouponID: coupon.ID, CustomerID: customerID, Status: coupondomain.DiscountStatusActive, StartedAt: now.AddDate(0, 0, -30), CreatedAt: now.AddDate(0, 0, -30)}
+	require.NoError(t, db.Create(&discount).Error)
+
+	rating := &previewRatingSvc{results: []ratingdomain.RatingResult{
+		{OrgID: orgID, BillingCycleID: cycleID, Quantity: 1, UnitPrice: 5000, Amount: 5000, Currency: "USD", Source: "flat_rate"},
+		{OrgID: orgID, BillingCycleID: cycleID, MeterID: &meterID, FeatureCode: "api_calls", Quantity: 250, UnitPrice: 20, Amount: 5000, Currency: "USD", Source: "usage_events"},
+	}}
+	rate := 0.1
+	taxResolver := new(mockTaxResolver)
+	taxResolver.On("ResolveForInvoice", mock.Anything, orgID, customerID).
+		Return(&taxdomain.TaxDefinition{Code: "VAT", Name: "VAT", TaxMode: taxdomain.TaxModeExclusive, Rate: &rate}, nil)
+
+	svc := NewService(ServiceParam{DB: db, Log: zap.NewNop(), GenID: node, RatingSvc: rating, TaxResolver: taxResolver})
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	preview, err := svc.PreviewUpcomingInvoice(ctx, customerID.String(), "")
+	require.NoError(t, err)
+	assert.Equal(t, cycleID.String(), rating.cycleID)
+	assert.Equal(t, subID.String(), preview.SubscriptionID)
+	assert.Equal(t, "USD", preview.Currency)
+
+	require.Len(t, preview.Lines, 3)
+	assert.Equal(t, invoicedomain.InvoiceItemLineTypeSubscription, preview.Lines[0].LineType)
+	assert.Equal(t, invoicedomain.InvoiceItemLineTypeUsage, preview.Lines[1].LineType)
+	assert.Contains(t, preview.Lines[1].Description, "API Calls")
+	assert.Equal(t, invoicedomain.InvoiceItemLineTypeCredit, preview.Lines[2].LineType)
+	assert.Equal(t, int64(-1000), preview.Lines[2].Amount)
+
+	assert.Equal(t, int64(1000), preview.DiscountAmount)
+	assert.Equal(t, int64(9000), preview.SubtotalAmount)
+	assert.Equal(t, int64(900), preview.TaxAmount)
+	assert.Equal(t, int64(9900), preview.TotalAmount)
+	require.NotNil(t, preview.TaxCode)
+	assert.Equal(t, "VAT", *preview.TaxCode)
+
+	// Nothing is written and the discount is not consumed.
+	var invoices, items int64
+	require.NoError(t, db.Model(&invoicedomain.Invoice{}).Count(&invoices).Error)
+	require.NoError(t, db.Model(&invoicedomain.InvoiceItem{}).Count(&items).Error)
+	assert.Zero(t, invoices)
+	assert.Zero(t, items)
+	var reloaded coupondomain.Discount
+	require.NoError(t, db.First(&reloaded, "id = ?", discount.ID).Error)
+	assert.Zero(t, reloaded.CyclesApplied)
+
+	_, err = svc.PreviewUpcomingInvoice(ctx, node.Generate().String(), "")
+	assert.ErrorIs(t, err, invoicedomain.ErrNoUpcomingInvoice)
+
+	// A second subscription with an open cycle needs the caller to pick one.
+	otherSubID := node.Generate()
+	require.NoError(t, db.Exec("INSERT INTO subscriptions (id, org_id, customer_id, status) VALUES (?, ?, ?, ?)", otherSubID, orgID, customerID, "ACTIVE").Error)
+	require.NoError(t, db.Exec("INSERT INTO billing_cycles (id, org_id, subscription_id, period_start, period_end, status) VALUES (?, ?, ?, ?, ?, ?)",
+		node.Generate(), orgID, otherSubID, now.AddDate(0, 0, -5), now.AddDate(0, 0, 25), "OPEN").Error)
+	_, err = svc.PreviewUpcomingInvoice(ctx, customerID.String(), "")
+	assert.ErrorIs(t, err, invoicedomain.ErrAmbiguousSubscription)
+	preview, err = svc.PreviewUpcomingInvoice(ctx, customerID.String(), subID.String())
+	require.NoError(t, err)
+	assert.Equal(t, cycleID.String(), rating.cycleID)
+	assert.Equal(t, subID.String(), preview.SubscriptionID)
+}
@@ -22,6 +22,7 @@ const (
 	// ======================
 	SourceTypeBillingCycle LedgerSourceType = "billing_cycle" // invoice charge (usage / flat)
 	SourceTypeAdjustment   LedgerSourceType = "adjustment"    // late usage / correction
+	SourceTypeCreditNote   LedgerSourceType = "credit_note"   // invoice correction via credit note
 
 	// ======================
 	// Payments
@@ -32,9 +33,11 @@ const (
 	// ======================
 	// Credits & Refunds
 	// ======================
-	SourceTypeCreditGrant LedgerSourceType = "credit_grant" // promo / goodwill credit
-	SourceTypeCreditUse   LedgerSourceType = "credit_use"   // credit applied to invoice
-	SourceTypeRefund      LedgerSourceType = "refund"       // money returned to customer
+	SourceTypeCreditGrant       LedgerSourceType = "credit_grant"        // promo / goodwill / prepaid credit
+	SourceTypeCreditUse         LedgerSourceType = "credit_use"          // credit applied to invoice
+	SourceTypeCreditUseReversal LedgerSourceType = "credit_use_reversal" // credit given back by a voided invoice
+	SourceTypeCreditExpiry      LedgerSourceType = "credit_expiry"       // unused credit expired
+	SourceTypeRefund            LedgerSourceType = "refund"              // money returned to customer
 
 	// ======================
 	// Disputes (economic impact only)
@@ -55,6 +58,9 @@ const (
 	AccountCodeRevenueFlat  LedgerAccountCode = "revenue_flat"
 	AccountCodeRevenueUsage LedgerAccountCode = "revenue_usage"
 
+	// Contra-revenue: coupons and promotions granted on invoices
+	AccountCodeDiscount LedgerAccountCode = "discount"
+
 	// Liabilities
 	AccountCodeTaxPayable    LedgerAccountCode = "tax_payable"
 	AccountCodeCreditBalance LedgerAccountCode = "credit_balance"
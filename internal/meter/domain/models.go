@@ -1,22 +1,60 @@
 package domain
 
 import (
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
 )
 
-// Meter defines a usage measurement unit.
+// Supported meter aggregations. They decide how usage events inside a rating
+// window collapse into a single billable quantity.
+const (
+	AggregationSum         = "SUM"
+	AggregationMax         = "MAX"
+	AggregationMin         = "MIN"
+	AggregationAvg         = "AVG"
+	AggregationLast        = "LAST"
+	AggregationCount       = "COUNT"
+	AggregationUniqueCount = "UNIQUE_COUNT"
+)
+
+// NormalizeAggregation returns the canonical aggregation name, or false when
+// the value is not supported.
+func NormalizeAggregation(value string) (string, bool) {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case AggregationSum:
+		return AggregationSum, true
+	case AggregationMax:
+		return AggregationMax, true
+	case AggregationMin:
+		return AggregationMin, true
+	case AggregationAvg, "AVERAGE":
+		return AggregationAvg, true
+	case AggregationLast:
+		return AggregationLast, true
+	case AggregationCount:
+		return AggregationCount, true
+	case AggregationUniqueCount, "COUNT_DISTINCT":
+		return AggregationUniqueCount, true
+	default:
+		return "", false
+	}
+}
+
+// Meter defines a usage measurement unit. AggregationKey names the usage
+// metadata key counted by UNIQUE_COUNT meters.
 type Meter struct {
-	ID          snowflake.ID `json:"id" gorm:"primaryKey"`
-	OrgID       snowflake.ID `json:"organization_id" gorm:"column:org_id;not null;index:ux_meters_org_code,priority:1"`
-	Code        string       `json:"code" gorm:"type:text;not null;index:ux_meters_org_code,priority:2"`
-	Name        string       `json:"name" gorm:"type:text;not null"`
-	Aggregation string       `json:"aggregation" gorm:"type:text;not null"`
-	Unit        string       `json:"unit" gorm:"type:text;not null"`
-	Active      bool         `json:"active" gorm:"not null;default:true"`
-	CreatedAt   time.Time    `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
-	UpdatedAt   time.Time    `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	ID             snowflake.ID `json:"id" gorm:"primaryKey"`
+	OrgID          snowflake.ID `json:"organization_id" gorm:"column:org_id;not null;index:ux_meters_org_code,priority:1"`
+	Code           string       `json:"code" gorm:"type:text;not null;index:ux_meters_org_code,priority:2"`
+	Name           string       `json:"name" gorm:"type:text;not null"`
+	Aggregation    string       `json:"aggregation" gorm:"type:text;not null"`
+	AggregationKey string       `json:"aggregation_key,omitempty" gorm:"type:text"`
+	Unit           string       `json:"unit" gorm:"type:text;not null"`
+	Active         bool         `json:"active" gorm:"not null;default:true"`
+	CreatedAt      time.Time    `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt      time.Time    `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
 }
 
 // TableName sets the database table name.
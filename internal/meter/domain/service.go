@@ -26,19 +26,21 @@ type ListRequest struct {
 }
 
 type CreateRequest struct {
-	Code        string `json:"code"`
-	Name        string `json:"name"`
-	Aggregation string `json:"aggregation_type"`
-	Unit        string `json:"unit"`
-	Active      *bool  `json:"active"`
+	Code           string `json:"code"`
+	Name           string `json:"name"`
+	Aggregation    string `json:"aggregation_type"`
+	AggregationKey string `json:"aggregation_key"`
+	Unit           string `json:"unit"`
+	Active         *bool  `json:"active"`
 }
 
 type UpdateRequest struct {
-	ID          string  `json:"id"`
-	Name        *string `json:"name,omitempty"`
-	Aggregation *string `json:"aggregation_type,omitempty"`
-	Unit        *string `json:"unit,omitempty"`
-	Active      *bool   `json:"active,omitempty"`
+	ID             string  `json:"id"`
+	Name           *string `json:"name,omitempty"`
+	Aggregation    *string `json:"aggregation_type,omitempty"`
+	AggregationKey *string `json:"aggregation_key,omitempty"`
+	Unit           *string `json:"unit,omitempty"`
+	Active         *bool   `json:"active,omitempty"`
 }
 
 type Response struct {
@@ -47,6 +49,7 @@ type Response struct {
 	Code           string    `json:"code"`
 	Name           string    `json:"name"`
 	Aggregation    string    `json:"aggregation"`
+	AggregationKey string    `json:"aggregation_key,omitempty"`
 	Unit           string    `json:"unit"`
 	Active         bool      `json:"active"`
 	CreatedAt      time.Time `json:"created_at"`
@@ -54,13 +57,14 @@ type Response struct {
 }
 
 var (
-	ErrInvalidOrganization = errors.New("invalid_organization")
-	ErrMeterNotFound       = errors.New("meter_not_found")
-	ErrInvalidCode         = errors.New("invalid_code")
-	ErrInvalidName         = errors.New("invalid_name")
-	ErrInvalidAggregation  = errors.New("invalid_aggregation_type")
-	ErrInvalidUnit         = errors.New("invalid_unit")
-	ErrInvalidID           = errors.New("invalid_id")
+	ErrInvalidOrganization   = errors.New("invalid_organization")
+	ErrMeterNotFound         = errors.New("meter_not_found")
+	ErrInvalidCode           = errors.New("invalid_code")
+	ErrInvalidName           = errors.New("invalid_name")
+	ErrInvalidAggregation    = errors.New("invalid_aggregation_type")
+	ErrInvalidAggregationKey = errors.New("invalid_aggregation_key")
+	ErrInvalidUnit           = errors.New("invalid_unit")
+	ErrInvalidID             = errors.New("invalid_id")
 )
 
 func ParseID(value string) (snowflake.ID, error) {
@@ -17,13 +17,14 @@ func Provide() meterdomain.Repository {
 
 func (r *repo) Insert(ctx context.Context, db *gorm.DB, m *meterdomain.Meter) error {
 	return db.WithContext(ctx).Exec(
-		`INSERT INTO meters (id, org_id, code, name, aggregation, unit, active, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO meters (id, org_id, code, name, aggregation, aggregation_key, unit, active, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		m.ID,
 		m.OrgID,
 		m.Code,
 		m.Name,
 		m.Aggregation,
+		m.AggregationKey,
 		m.Unit,
 		m.Active,
 		m.CreatedAt,
@@ -34,10 +35,11 @@ func (r *repo) Insert(ctx context.Context, db *gorm.DB, m *meterdomain.Meter) er
 func (r *repo) Update(ctx context.Context, db *gorm.DB, m *meterdomain.Meter) error {
 	return db.WithContext(ctx).Exec(
 		`UPDATE meters
-		 SET name = ?, aggregation = ?, unit = ?, active = ?, updated_at = ?
+		 SET name = ?, aggregation = ?, aggregation_key = ?, unit = ?, active = ?, updated_at = ?
 		 WHERE org_id = ? AND id = ?`,
 		m.Name,
 		m.Aggregation,
+		m.AggregationKey,
 		m.Unit,
 		m.Active,
 		m.UpdatedAt,
@@ -57,7 +59,7 @@ func (r *repo) Delete(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID)
 func (r *repo) FindByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*meterdomain.Meter, error) {
 	var meter meterdomain.Meter
 	err := db.WithContext(ctx).Raw(
-		`SELECT id, org_id, code, name, aggregation, unit, active, created_at, updated_at
+		`SELECT id, org_id, code, name, aggregation, aggregation_key, unit, active, created_at, updated_at
 		 FROM meters WHERE org_id = ? AND id = ?`,
 		orgID,
 		id,
@@ -74,7 +76,7 @@ func (r *repo) FindByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID
 func (r *repo) FindByCode(ctx context.Context, db *gorm.DB, orgID snowflake.ID, code string) (*meterdomain.Meter, error) {
 	var meter meterdomain.Meter
 	err := db.WithContext(ctx).Raw(
-		`SELECT id, org_id, code, name, aggregation, unit, active, created_at, updated_at
+		`SELECT id, org_id, code, name, aggregation, aggregation_key, unit, active, created_at, updated_at
 		 FROM meters WHERE org_id = ? AND code = ?`,
 		orgID,
 		code,
@@ -54,9 +54,9 @@ func (s *Service) Create(ctx context.Context, req meterdomain.CreateRequest) (*m
 		return nil, meterdomain.ErrInvalidName
 	}
 
-	aggregation := strings.TrimSpace(req.Aggregation)
-	if aggregation == "" {
-		return nil, meterdomain.ErrInvalidAggregation
+	aggregation, aggregationKey, err := parseAggregation(req.Aggregation, req.AggregationKey)
+	if err != nil {
+		return nil, err
 	}
 
 	unit := strings.TrimSpace(req.Unit)
@@ -71,15 +71,16 @@ func (s *Service) Create(ctx context.Context, req meterdomain.CreateRequest) (*m
 
 	now := time.Now().UTC()
 	m := &meterdomain.Meter{
-		ID:          s.genID.Generate(),
-		OrgID:       orgID,
-		Code:        code,
-		Name:        name,
-		Aggregation: aggregation,
-		Unit:        unit,
-		Active:      active,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:             s.genID.Generate(),
+		OrgID:          orgID,
+		Code:           code,
+		Name:           name,
+		Aggregation:    aggregation,
+		AggregationKey: aggregationKey,
+		Unit:           unit,
+		Active:         active,
+		CreatedAt:      now,
+		UpdatedAt:      now,
 	}
 
 	if err := s.repo.Insert(ctx, s.db, m); err != nil {
@@ -143,12 +144,21 @@ func (s *Service) Update(ctx context.Context, req meterdomain.UpdateRequest) (*m
 		item.Name = name
 	}
 
-	if req.Aggregation != nil {
-		aggregation := strings.TrimSpace(*req.Aggregation)
-		if aggregation == "" {
-			return nil, meterdomain.ErrInvalidAggregation
+	if req.Aggregation != nil || req.AggregationKey != nil {
+		aggregation := item.Aggregation
+		if req.Aggregation != nil {
+			aggregation = *req.Aggregation
+		}
+		aggregationKey := item.AggregationKey
+		if req.AggregationKey != nil {
+			aggregationKey = *req.AggregationKey
+		}
+		aggregation, aggregationKey, err = parseAggregation(aggregation, aggregationKey)
+		if err != nil {
+			return nil, err
 		}
 		item.Aggregation = aggregation
+		item.AggregationKey = aggregationKey
 	}
 
 	if req.Unit != nil {
@@ -239,9 +249,28 @@ func (s *Service) toResponse(m *meterdomain.Meter) *meterdomain.Response {
 		Code:           m.Code,
 		Name:           m.Name,
 		Aggregation:    m.Aggregation,
+		AggregationKey: m.AggregationKey,
 		Unit:           m.Unit,
 		Active:         m.Active,
 		CreatedAt:      m.CreatedAt,
 		UpdatedAt:      m.UpdatedAt,
 	}
 }
+
+// parseAggregation validates the aggregation against the supported set.
+// UNIQUE_COUNT requires a metadata key; other aggregations ignore it.
+func parseAggregation(value, key string) (string, string, error) {
+	aggregation, ok := meterdomain.NormalizeAggregation(value)
+	if !ok {
+		return "", "", meterdomain.ErrInvalidAggregation
+	}
+
+	key = strings.TrimSpace(key)
+	if aggregation != meterdomain.AggregationUniqueCount {
+		return aggregation, "", nil
+	}
+	if key == "" {
+		return "", "", meterdomain.ErrInvalidAggregationKey
+	}
+	return aggregation, key, nil
+}
@@ -0,0 +1,15 @@
+ALTER TABLE meters ADD COLUMN IF NOT EXISTS aggregation_key TEXT;
+
+-- Meters used to accept any aggregation and were always summed. Keep the
+-- supported ones, in their canonical spelling, and sum the rest. Unique
+-- counts need an aggregation key no existing meter has yet.
+UPDATE meters
+SET aggregation = CASE UPPER(TRIM(aggregation))
+    WHEN 'MAX' THEN 'MAX'
+    WHEN 'MIN' THEN 'MIN'
+    WHEN 'AVG' THEN 'AVG'
+    WHEN 'AVERAGE' THEN 'AVG'
+    WHEN 'LAST' THEN 'LAST'
+    WHEN 'COUNT' THEN 'COUNT'
+    ELSE 'SUM'
+  END;
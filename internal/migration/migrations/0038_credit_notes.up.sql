@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS credit_notes (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    invoice_id BIGINT NOT NULL,
+    customer_id BIGINT NOT NULL,
+    credit_note_seq BIGINT NOT NULL,
+    credit_note_number TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'ISSUED',
+    reason TEXT NOT NULL,
+    memo TEXT,
+    destination TEXT NOT NULL,
+    subtotal_amount BIGINT NOT NULL DEFAULT 0,
+    tax_amount BIGINT NOT NULL DEFAULT 0,
+    total_amount BIGINT NOT NULL DEFAULT 0,
+    currency TEXT NOT NULL,
+    issued_at TIMESTAMPTZ NOT NULL,
+    rendered_html TEXT,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_credit_notes_seq ON credit_notes(org_id, credit_note_seq);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_credit_notes_number ON credit_notes(org_id, credit_note_number);
+CREATE INDEX IF NOT EXISTS idx_credit_notes_invoice_id ON credit_notes(org_id, invoice_id);
+CREATE INDEX IF NOT EXISTS idx_credit_notes_customer_id ON credit_notes(org_id, customer_id);
+
+CREATE TABLE IF NOT EXISTS credit_note_items (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    credit_note_id BIGINT NOT NULL REFERENCES credit_notes(id),
+    invoice_item_id BIGINT NOT NULL,
+    description TEXT,
+    quantity DOUBLE PRECISION NOT NULL DEFAULT 0,
+    unit_price BIGINT NOT NULL DEFAULT 0,
+    amount BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_note_items_credit_note_id ON credit_note_items(credit_note_id);
+CREATE INDEX IF NOT EXISTS idx_credit_note_items_invoice_item_id ON credit_note_items(invoice_item_id);
+
+CREATE TABLE IF NOT EXISTS credit_note_sequences (
+    org_id BIGINT PRIMARY KEY,
+    next_number BIGINT NOT NULL DEFAULT 1,
+    updated_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,60 @@
+CREATE TABLE IF NOT EXISTS coupons (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    code TEXT NOT NULL,
+    name TEXT NOT NULL,
+    discount_type TEXT NOT NULL,
+    percent_off DOUBLE PRECISION,
+    amount_off BIGINT,
+    currency TEXT,
+    duration TEXT NOT NULL,
+    duration_in_cycles INT,
+    max_redemptions INT,
+    times_redeemed INT NOT NULL DEFAULT 0,
+    redeem_by TIMESTAMPTZ,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_coupons_discount_type CHECK (discount_type IN ('percent', 'amount')),
+    CONSTRAINT chk_coupons_duration CHECK (duration IN ('once', 'repeating', 'forever'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_coupons_org_code ON coupons(org_id, code);
+
+CREATE TABLE IF NOT EXISTS promotion_codes (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    coupon_id BIGINT NOT NULL REFERENCES coupons(id),
+    code TEXT NOT NULL,
+    max_redemptions INT,
+    times_redeemed INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_promotion_codes_org_code ON promotion_codes(org_id, code);
+CREATE INDEX IF NOT EXISTS idx_promotion_codes_coupon_id ON promotion_codes(coupon_id);
+
+CREATE TABLE IF NOT EXISTS discounts (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    coupon_id BIGINT NOT NULL REFERENCES coupons(id),
+    promotion_code_id BIGINT REFERENCES promotion_codes(id),
+    customer_id BIGINT NOT NULL,
+    subscription_id BIGINT,
+    status TEXT NOT NULL DEFAULT 'ACTIVE',
+    cycles_applied INT NOT NULL DEFAULT 0,
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_discounts_customer ON discounts(org_id, customer_id, status);
+CREATE INDEX IF NOT EXISTS idx_discounts_subscription ON discounts(org_id, subscription_id, status);
+
+ALTER TABLE invoices
+    ADD COLUMN IF NOT EXISTS discount_amount BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS dunning_policies (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    retry_schedule_days JSONB NOT NULL DEFAULT '[]',
+    send_reminders BOOLEAN NOT NULL DEFAULT TRUE,
+    final_action TEXT NOT NULL DEFAULT 'leave_unpaid',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_dunning_policies_final_action CHECK (final_action IN ('cancel', 'pause', 'leave_unpaid'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_dunning_policies_org_id ON dunning_policies(org_id);
+
+CREATE TABLE IF NOT EXISTS dunning_cases (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    invoice_id BIGINT NOT NULL REFERENCES invoices(id),
+    customer_id BIGINT NOT NULL,
+    subscription_id BIGINT,
+    status TEXT NOT NULL DEFAULT 'open',
+    step INT NOT NULL DEFAULT 0,
+    final_action TEXT,
+    triggered_at TIMESTAMPTZ NOT NULL,
+    next_action_at TIMESTAMPTZ,
+    last_action_at TIMESTAMPTZ,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_dunning_cases_status CHECK (status IN ('open', 'recovered', 'exhausted', 'closed'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_dunning_cases_invoice_id ON dunning_cases(invoice_id);
+CREATE INDEX IF NOT EXISTS idx_dunning_cases_org_id ON dunning_cases(org_id);
+CREATE INDEX IF NOT EXISTS idx_dunning_cases_subscription_id ON dunning_cases(subscription_id);
+CREATE INDEX IF NOT EXISTS idx_dunning_cases_next_action_at ON dunning_cases(next_action_at) WHERE status = 'open';
@@ -0,0 +1,33 @@
+ALTER TABLE usage_events ADD COLUMN IF NOT EXISTS corrects_event_id BIGINT;
+ALTER TABLE usage_events ADD COLUMN IF NOT EXISTS correction_type TEXT;
+ALTER TABLE usage_events ADD COLUMN IF NOT EXISTS correction_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_usage_events_corrects_event_id ON usage_events(org_id, corrects_event_id) WHERE corrects_event_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS billing_adjustments (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    customer_id BIGINT NOT NULL,
+    subscription_id BIGINT NOT NULL,
+    billing_cycle_id BIGINT NOT NULL REFERENCES billing_cycles(id),
+    usage_event_id BIGINT NOT NULL,
+    correction_event_id BIGINT NOT NULL,
+    reason TEXT NOT NULL,
+    amount BIGINT NOT NULL DEFAULT 0,
+    currency TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING',
+    ledger_entry_id BIGINT,
+    invoice_id BIGINT,
+    invoiced_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_billing_adjustments_status CHECK (status IN ('PENDING', 'INVOICED', 'NO_CHANGE'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_billing_adjustments_correction ON billing_adjustments(org_id, correction_event_id);
+CREATE INDEX IF NOT EXISTS idx_billing_adjustments_pending ON billing_adjustments(org_id, subscription_id, status);
+CREATE INDEX IF NOT EXISTS idx_billing_adjustments_billing_cycle_id ON billing_adjustments(billing_cycle_id);
+
+ALTER TABLE rating_results ADD COLUMN IF NOT EXISTS adjustment_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_rating_results_adjustment_id ON rating_results(adjustment_id) WHERE adjustment_id IS NOT NULL;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS usage_late_policies (
+    org_id BIGINT PRIMARY KEY,
+    policy TEXT NOT NULL DEFAULT 'carry_forward',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_usage_late_policies_policy CHECK (policy IN ('reject', 'carry_forward', 'adjustment'))
+);
+
+ALTER TABLE usage_events ADD COLUMN IF NOT EXISTS late_policy TEXT;
+ALTER TABLE usage_events ADD COLUMN IF NOT EXISTS billable_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_usage_events_late_adjustment ON usage_events(org_id, status) WHERE late_policy = 'adjustment';
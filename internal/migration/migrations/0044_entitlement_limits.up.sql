@@ -0,0 +1,12 @@
+ALTER TABLE product_features ADD COLUMN IF NOT EXISTS usage_limit DOUBLE PRECISION;
+ALTER TABLE product_features ADD COLUMN IF NOT EXISTS enforcement TEXT;
+ALTER TABLE product_features ADD CONSTRAINT chk_product_features_enforcement
+    CHECK (enforcement IS NULL OR enforcement IN ('soft', 'hard'));
+
+ALTER TABLE subscription_entitlements ADD COLUMN IF NOT EXISTS usage_limit DOUBLE PRECISION;
+ALTER TABLE subscription_entitlements ADD COLUMN IF NOT EXISTS enforcement TEXT;
+
+ALTER TABLE usage_events ADD COLUMN IF NOT EXISTS over_limit BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_subscription_entitlements_sub_feature
+    ON subscription_entitlements(subscription_id, feature_code);
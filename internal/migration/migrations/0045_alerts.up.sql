@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS alerts (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    meter_id BIGINT NOT NULL REFERENCES meters(id),
+    threshold DOUBLE PRECISION NOT NULL,
+    frequency TEXT NOT NULL DEFAULT 'per_customer',
+    apply_to TEXT NOT NULL DEFAULT 'all_customers',
+    customer_id BIGINT,
+    notify_emails JSONB NOT NULL DEFAULT '[]',
+    slack_channel TEXT,
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_alerts_threshold CHECK (threshold > 0),
+    CONSTRAINT chk_alerts_frequency CHECK (frequency IN ('per_customer')),
+    CONSTRAINT chk_alerts_apply_to CHECK (
+        (apply_to = 'all_customers' AND customer_id IS NULL)
+        OR (apply_to = 'customer' AND customer_id IS NOT NULL)
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_org_id ON alerts(org_id);
+CREATE INDEX IF NOT EXISTS idx_alerts_active ON alerts(org_id) WHERE active = TRUE;
+
+CREATE TABLE IF NOT EXISTS alert_events (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    alert_id BIGINT NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    customer_id BIGINT NOT NULL,
+    subscription_id BIGINT NOT NULL,
+    billing_cycle_id BIGINT NOT NULL,
+    meter_id BIGINT NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    channels JSONB NOT NULL DEFAULT '[]',
+    fired_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_alert_events_cycle ON alert_events(alert_id, customer_id, billing_cycle_id);
+CREATE INDEX IF NOT EXISTS idx_alert_events_org_fired_at ON alert_events(org_id, fired_at DESC);
@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS credit_grants (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    customer_id BIGINT NOT NULL REFERENCES customers(id),
+    name TEXT NOT NULL,
+    type TEXT NOT NULL DEFAULT 'promotional',
+    amount BIGINT NOT NULL,
+    remaining_amount BIGINT NOT NULL,
+    currency TEXT NOT NULL,
+    priority INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    status TEXT NOT NULL DEFAULT 'active',
+    expired_at TIMESTAMPTZ,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_credit_grants_type CHECK (type IN ('promotional', 'prepaid')),
+    CONSTRAINT chk_credit_grants_status CHECK (status IN ('pending', 'active', 'depleted', 'expired')),
+    CONSTRAINT chk_credit_grants_amount CHECK (amount > 0),
+    CONSTRAINT chk_credit_grants_remaining CHECK (remaining_amount >= 0 AND remaining_amount <= amount)
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_grants_customer ON credit_grants(org_id, customer_id, status);
+CREATE INDEX IF NOT EXISTS idx_credit_grants_expiry ON credit_grants(expires_at) WHERE status = 'active' AND expires_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS credit_transactions (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    customer_id BIGINT NOT NULL,
+    grant_id BIGINT NOT NULL REFERENCES credit_grants(id),
+    type TEXT NOT NULL,
+    amount BIGINT NOT NULL,
+    currency TEXT NOT NULL,
+    invoice_id BIGINT REFERENCES invoices(id),
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_credit_transactions_type CHECK (type IN ('grant', 'use', 'expiry', 'reversal'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_transactions_customer ON credit_transactions(org_id, customer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_credit_transactions_grant ON credit_transactions(grant_id);
+CREATE INDEX IF NOT EXISTS idx_credit_transactions_invoice ON credit_transactions(invoice_id) WHERE invoice_id IS NOT NULL;
+
+ALTER TABLE invoices
+    ADD COLUMN IF NOT EXISTS credit_amount BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS subscription_plan_changes (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    subscription_id BIGINT NOT NULL REFERENCES subscriptions(id),
+    from_product_id BIGINT,
+    to_product_id BIGINT NOT NULL,
+    to_price_id BIGINT NOT NULL,
+    quantity SMALLINT NOT NULL DEFAULT 1,
+    direction TEXT NOT NULL,
+    proration_behavior TEXT NOT NULL,
+    status TEXT NOT NULL,
+    effective_at TIMESTAMPTZ NOT NULL,
+    applied_at TIMESTAMPTZ,
+    canceled_at TIMESTAMPTZ,
+    previous_items JSONB NOT NULL DEFAULT '[]',
+    items JSONB NOT NULL DEFAULT '[]',
+    credit_amount BIGINT NOT NULL DEFAULT 0,
+    charge_amount BIGINT NOT NULL DEFAULT 0,
+    currency TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_subscription_plan_changes_direction CHECK (direction IN ('UPGRADE', 'DOWNGRADE')),
+    CONSTRAINT chk_subscription_plan_changes_status CHECK (status IN ('SCHEDULED', 'APPLIED', 'CANCELED')),
+    CONSTRAINT chk_subscription_plan_changes_proration CHECK (proration_behavior IN ('NONE', 'CREATE_PRORATION', 'DEFERRED', 'INVOICE_IMMEDIATELY'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_plan_changes_subscription ON subscription_plan_changes(org_id, subscription_id, effective_at);
+CREATE INDEX IF NOT EXISTS idx_subscription_plan_changes_due ON subscription_plan_changes(effective_at) WHERE status = 'SCHEDULED';
+CREATE UNIQUE INDEX IF NOT EXISTS ux_subscription_plan_changes_scheduled ON subscription_plan_changes(subscription_id) WHERE status = 'SCHEDULED';
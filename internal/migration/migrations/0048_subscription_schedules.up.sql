@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS subscription_schedules (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    subscription_id BIGINT NOT NULL REFERENCES subscriptions(id),
+    status TEXT NOT NULL,
+    end_behavior TEXT NOT NULL DEFAULT 'RELEASE',
+    next_transition_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    canceled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_subscription_schedules_status CHECK (status IN ('ACTIVE', 'COMPLETED', 'CANCELED')),
+    CONSTRAINT chk_subscription_schedules_end_behavior CHECK (end_behavior IN ('RELEASE', 'CANCEL'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_schedules_subscription ON subscription_schedules(org_id, subscription_id);
+CREATE INDEX IF NOT EXISTS idx_subscription_schedules_due ON subscription_schedules(next_transition_at) WHERE status = 'ACTIVE';
+CREATE UNIQUE INDEX IF NOT EXISTS ux_subscription_schedules_active ON subscription_schedules(subscription_id) WHERE status = 'ACTIVE';
+
+CREATE TABLE IF NOT EXISTS subscription_schedule_phases (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    schedule_id BIGINT NOT NULL REFERENCES subscription_schedules(id),
+    position SMALLINT NOT NULL,
+    status TEXT NOT NULL,
+    start_at TIMESTAMPTZ NOT NULL,
+    end_at TIMESTAMPTZ,
+    iterations INT,
+    items JSONB NOT NULL DEFAULT '[]',
+    trial BOOLEAN NOT NULL DEFAULT FALSE,
+    coupon_id BIGINT,
+    discount_id BIGINT,
+    proration_behavior TEXT NOT NULL,
+    applied_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_subscription_schedule_phases_status CHECK (status IN ('PENDING', 'ACTIVE', 'COMPLETED', 'CANCELED')),
+    CONSTRAINT chk_subscription_schedule_phases_proration CHECK (proration_behavior IN ('NONE', 'CREATE_PRORATION', 'DEFERRED', 'INVOICE_IMMEDIATELY')),
+    CONSTRAINT chk_subscription_schedule_phases_window CHECK (end_at IS NULL OR end_at > start_at)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_subscription_schedule_phases_position ON subscription_schedule_phases(schedule_id, position);
@@ -0,0 +1,10 @@
+ALTER TABLE prices ADD COLUMN IF NOT EXISTS billing_timing TEXT NOT NULL DEFAULT 'IN_ARREARS';
+ALTER TABLE prices ADD CONSTRAINT chk_prices_billing_timing
+    CHECK (billing_timing IN ('IN_ADVANCE', 'IN_ARREARS'));
+
+ALTER TABLE subscription_items ADD COLUMN IF NOT EXISTS billing_timing TEXT;
+ALTER TABLE subscription_items ADD CONSTRAINT chk_subscription_items_billing_timing
+    CHECK (billing_timing IS NULL OR billing_timing IN ('IN_ADVANCE', 'IN_ARREARS'));
+
+CREATE INDEX IF NOT EXISTS idx_rating_results_advance
+    ON rating_results(subscription_id, period_start) WHERE source = 'advance';
@@ -0,0 +1,23 @@
+ALTER TABLE subscription_items ALTER COLUMN quantity TYPE INTEGER;
+ALTER TABLE subscription_plan_changes ALTER COLUMN quantity TYPE INTEGER;
+
+CREATE TABLE IF NOT EXISTS subscription_item_quantity_changes (
+    id BIGINT PRIMARY KEY,
+    org_id BIGINT NOT NULL,
+    subscription_id BIGINT NOT NULL REFERENCES subscriptions(id),
+    subscription_item_id BIGINT NOT NULL,
+    price_id BIGINT NOT NULL,
+    previous_quantity INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    status TEXT NOT NULL,
+    effective_at TIMESTAMPTZ NOT NULL,
+    applied_at TIMESTAMPTZ,
+    canceled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_subscription_item_quantity_changes_quantity CHECK (quantity > 0 AND previous_quantity > 0),
+    CONSTRAINT chk_subscription_item_quantity_changes_status CHECK (status IN ('SCHEDULED', 'APPLIED', 'CANCELED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_item_quantity_changes_item ON subscription_item_quantity_changes(org_id, subscription_item_id, effective_at);
+CREATE INDEX IF NOT EXISTS idx_subscription_item_quantity_changes_due ON subscription_item_quantity_changes(effective_at) WHERE status = 'SCHEDULED';
@@ -0,0 +1,10 @@
+ALTER TABLE price_amounts ADD COLUMN IF NOT EXISTS unit_amount_decimal NUMERIC(30, 12);
+ALTER TABLE price_tiers ADD COLUMN IF NOT EXISTS unit_amount_decimal NUMERIC(30, 12);
+ALTER TABLE rating_results ADD COLUMN IF NOT EXISTS unit_price_decimal NUMERIC(30, 12);
+
+ALTER TABLE organization_billing_preferences
+    ADD COLUMN IF NOT EXISTS rounding_mode TEXT NOT NULL DEFAULT 'HALF_UP';
+
+ALTER TABLE organization_billing_preferences
+    ADD CONSTRAINT chk_organization_billing_preferences_rounding_mode
+    CHECK (rounding_mode IN ('HALF_UP', 'HALF_EVEN', 'FLOOR'));
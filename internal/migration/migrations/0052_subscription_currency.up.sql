@@ -0,0 +1,50 @@
+-- Lock existing subscriptions to the currency they were billed in, so rating
+-- keeps picking the matching price amount.
+UPDATE subscriptions s
+SET default_currency = r.currency
+FROM (
+  SELECT subscription_id, MIN(UPPER(currency)) AS currency
+  FROM rating_results
+  WHERE currency <> ''
+  GROUP BY subscription_id
+  HAVING COUNT(DISTINCT UPPER(currency)) = 1
+) r
+WHERE r.subscription_id = s.id
+  AND s.default_currency IS NULL;
+
+UPDATE subscriptions s
+SET default_currency = i.currency
+FROM (
+  SELECT subscription_id, MIN(UPPER(currency)) AS currency
+  FROM invoices
+  WHERE currency <> ''
+  GROUP BY subscription_id
+  HAVING COUNT(DISTINCT UPPER(currency)) = 1
+) i
+WHERE i.subscription_id = s.id
+  AND s.default_currency IS NULL;
+
+-- Subscriptions never billed take the customer's currency, but only when
+-- every item's price has a current amount in it. The rest are locked by
+-- rating the first time it runs.
+UPDATE subscriptions s
+SET default_currency = UPPER(c.currency)
+FROM customers c
+WHERE c.id = s.customer_id
+  AND c.org_id = s.org_id
+  AND s.default_currency IS NULL
+  AND COALESCE(c.currency, '') <> ''
+  AND NOT EXISTS (
+    SELECT 1
+    FROM subscription_items si
+    WHERE si.subscription_id = s.id
+      AND NOT EXISTS (
+        SELECT 1
+        FROM price_amounts pa
+        WHERE pa.org_id = si.org_id
+          AND pa.price_id = si.price_id
+          AND UPPER(pa.currency) = UPPER(c.currency)
+          AND pa.revoked_at IS NULL
+          AND (pa.effective_to IS NULL OR pa.effective_to > NOW())
+      )
+  );
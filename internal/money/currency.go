@@ -0,0 +1,93 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+var ErrInvalidCurrency = errors.New("invalid_currency")
+
+// minorUnits caches the minor units of the reference currencies, which are
+// loaded once at startup by SetMinorUnits.
+var minorUnits struct {
+	sync.RWMutex
+	units map[string]int32
+}
+
+// SetMinorUnits replaces the cached minor units, keyed by currency code.
+func SetMinorUnits(units map[string]int32) {
+	cached := make(map[string]int32, len(units))
+	for code, unit := range units {
+		cached[strings.ToUpper(strings.TrimSpace(code))] = unit
+	}
+	minorUnits.Lock()
+	minorUnits.units = cached
+	minorUnits.Unlock()
+}
+
+// NormalizeCurrency upper-cases a currency code and checks it is three
+// letters, so lookups such as MinorUnits never miss on "jpy".
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", ErrInvalidCurrency
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", ErrInvalidCurrency
+		}
+	}
+	return code, nil
+}
+
+// MinorUnits returns the number of decimal places of the currency's major
+// unit as the reference data defines it: 2 for USD, 0 for JPY, 3 for KWD.
+// Currencies missing from the reference data use two.
+func MinorUnits(currency string) int32 {
+	minorUnits.RLock()
+	defer minorUnits.RUnlock()
+	if units, ok := minorUnits.units[strings.ToUpper(strings.TrimSpace(currency))]; ok {
+		return units
+	}
+	return 2
+}
+
+// ToMajor converts an amount in minor units to major units, e.g. 1234 USD
+// cents to 12.34 and 1234 fils to 1.234 KWD.
+func ToMajor(amount int64, currency string) Decimal {
+	return Decimal{rat: new(big.Rat).SetFrac(big.NewInt(amount), pow10(MinorUnits(currency)))}
+}
+
+// FromMajor converts a major-unit amount such as "10.50", as payment
+// providers report it, to minor units. Digits beyond the currency's minor
+// unit are rounded half up.
+func FromMajor(value string, currency string) (int64, error) {
+	amount, err := Parse(value)
+	if err != nil {
+		return 0, err
+	}
+	scale := Decimal{rat: new(big.Rat).SetInt(pow10(MinorUnits(currency)))}
+	return amount.Mul(scale).Round(RoundHalfUp), nil
+}
+
+// Format renders an amount in minor units with the currency's decimals,
+// e.g. "USD 12.34", "JPY 1500" or "-KWD 1.250".
+func Format(amount int64, currency string) string {
+	code := strings.ToUpper(strings.TrimSpace(currency))
+	if code == "" {
+		code = "USD"
+	}
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%s %s", sign, code, ToMajor(amount, code).Fixed(MinorUnits(code)))
+}
+
+func pow10(places int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+}
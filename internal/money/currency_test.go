@@ -0,0 +1,81 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedMinorUnits caches the minor units the reference data seeds for the
+// currencies under test.
+func seedMinorUnits(t *testing.T) {
+	SetMinorUnits(map[string]int32{"USD": 2, "JPY": 0, "IDR": 0, "BHD": 3, "KWD": 3})
+	t.Cleanup(func() { SetMinorUnits(nil) })
+}
+
+func TestMinorUnits(t *testing.T) {
+	seedMinorUnits(t)
+	assert.Equal(t, int32(2), MinorUnits("USD"))
+	assert.Equal(t, int32(0), MinorUnits("JPY"))
+	assert.Equal(t, int32(0), MinorUnits("idr"))
+	assert.Equal(t, int32(3), MinorUnits("BHD"))
+	assert.Equal(t, int32(3), MinorUnits(" kwd "))
+	assert.Equal(t, int32(2), MinorUnits("XYZ"))
+}
+
+func TestNormalizeCurrency(t *testing.T) {
+	code, err := NormalizeCurrency(" jpy ")
+	require.NoError(t, err)
+	assert.Equal(t, "JPY", code)
+
+	for _, invalid := range []string{"", "US", "USDT", "U5D"} {
+		_, err := NormalizeCurrency(invalid)
+		assert.ErrorIs(t, err, ErrInvalidCurrency, invalid)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	seedMinorUnits(t)
+	cases := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{amount: 1234, currency: "USD", want: "USD 12.34"},
+		{amount: 5, currency: "usd", want: "USD 0.05"},
+		{amount: 1500, currency: "JPY", want: "JPY 1500"},
+		{amount: 150000, currency: "IDR", want: "IDR 150000"},
+		{amount: 1250, currency: "KWD", want: "KWD 1.250"},
+		{amount: 7, currency: "BHD", want: "BHD 0.007"},
+		{amount: -1250, currency: "KWD", want: "-KWD 1.250"},
+		{amount: 100, currency: "", want: "USD 1.00"},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, Format(tc.amount, tc.currency))
+	}
+}
+
+func TestMajorUnits(t *testing.T) {
+	seedMinorUnits(t)
+	assert.Equal(t, "12.34", ToMajor(1234, "USD").String())
+	assert.Equal(t, "1234", ToMajor(1234, "JPY").String())
+	assert.Equal(t, "1.234", ToMajor(1234, "KWD").String())
+
+	cases := []struct {
+		value    string
+		currency string
+		want     int64
+	}{
+		{value: "10.00", currency: "USD", want: 1000},
+		{value: "0.29", currency: "USD", want: 29},
+		{value: "1500", currency: "JPY", want: 1500},
+		{value: "1.234", currency: "KWD", want: 1234},
+		{value: "1.2345", currency: "BHD", want: 1235},
+	}
+	for _, tc := range cases {
+		got, err := FromMajor(tc.value, tc.currency)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got, tc.value+" "+tc.currency)
+	}
+}
@@ -0,0 +1,248 @@
+// Package money holds exact decimal arithmetic for amounts expressed in a
+// currency's minor units, e.g. a unit price of 0.04 cents.
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxScale is the number of fractional digits kept when a decimal is
+// written out. Prices are accepted with at most this many digits, so
+// storing them never rounds.
+const maxScale = 12
+
+var ErrInvalidDecimal = errors.New("invalid_decimal")
+
+// Decimal is an exact decimal number. The zero value is 0. Values are
+// immutable: every operation returns a new Decimal.
+type Decimal struct {
+	rat *big.Rat
+}
+
+// NewFromInt returns value as a Decimal.
+func NewFromInt(value int64) Decimal {
+	return Decimal{rat: new(big.Rat).SetInt64(value)}
+}
+
+// NewFromFloat returns the shortest decimal that reads back as value, so
+// 0.1 is 1/10 and not the nearest binary fraction. It is deterministic for a
+// given float64. NaN and infinities are 0.
+func NewFromFloat(value float64) Decimal {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return Decimal{}
+	}
+	// Not Parse: prorated quantities carry more digits than a price may.
+	rat, _ := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	return Decimal{rat: rat}
+}
+
+// Parse reads a plain decimal string such as "12", "-0.5" or "0.0004".
+func Parse(value string) (Decimal, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Decimal{}, ErrInvalidDecimal
+	}
+	digits := strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+	whole, frac, _ := strings.Cut(digits, ".")
+	if whole == "" && frac == "" {
+		return Decimal{}, ErrInvalidDecimal
+	}
+	if len(frac) > maxScale || !isDigits(whole) || !isDigits(frac) {
+		return Decimal{}, ErrInvalidDecimal
+	}
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return Decimal{}, ErrInvalidDecimal
+	}
+	return Decimal{rat: rat}, nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (d Decimal) value() *big.Rat {
+	if d.rat == nil {
+		return new(big.Rat)
+	}
+	return d.rat
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Add(d.value(), other.value())}
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Sub(d.value(), other.value())}
+}
+
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Mul(d.value(), other.value())}
+}
+
+// Quo returns d / other. other must not be zero.
+func (d Decimal) Quo(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Quo(d.value(), other.value())}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{rat: new(big.Rat).Neg(d.value())}
+}
+
+// Cmp compares d and other and returns -1, 0 or +1.
+func (d Decimal) Cmp(other Decimal) int {
+	return d.value().Cmp(other.value())
+}
+
+func (d Decimal) Sign() int {
+	return d.value().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// IsInteger reports whether d has no fractional part.
+func (d Decimal) IsInteger() bool {
+	return d.value().IsInt()
+}
+
+// Round rounds d to a whole number of minor units.
+func (d Decimal) Round(mode RoundingMode) int64 {
+	return d.RoundTo(0, mode).IntPart()
+}
+
+// RoundTo rounds d to the given number of fractional digits.
+func (d Decimal) RoundTo(places int32, mode RoundingMode) Decimal {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	scaled := new(big.Rat).Mul(d.value(), new(big.Rat).SetInt(scale))
+
+	// floor and the fraction left over, in [0, 1).
+	floor := new(big.Int).Div(scaled.Num(), scaled.Denom())
+	frac := new(big.Rat).Sub(scaled, new(big.Rat).SetInt(floor))
+
+	up := false
+	switch mode.normalize() {
+	case RoundFloor:
+	case RoundHalfEven:
+		switch frac.Cmp(half) {
+		case 1:
+			up = true
+		case 0:
+			up = floor.Bit(0) == 1
+		}
+	default:
+		switch frac.Cmp(half) {
+		case 1:
+			up = true
+		case 0:
+			// Half away from zero: up for positive values only, since
+			// floor already moved negative ones away from zero.
+			up = scaled.Sign() > 0
+		}
+	}
+	if up {
+		floor.Add(floor, big.NewInt(1))
+	}
+	return Decimal{rat: new(big.Rat).SetFrac(floor, scale)}
+}
+
+var half = big.NewRat(1, 2)
+
+// IntPart returns the integer part of d, truncated toward zero.
+func (d Decimal) IntPart() int64 {
+	r := d.value()
+	return new(big.Int).Quo(r.Num(), r.Denom()).Int64()
+}
+
+// Float64 returns the nearest float64, for display and storage of
+// quantities only; amounts are rounded with Round.
+func (d Decimal) Float64() float64 {
+	f, _ := d.value().Float64()
+	return f
+}
+
+// String writes d without trailing zeros, e.g. "0.0004" or "12".
+func (d Decimal) String() string {
+	s := d.value().FloatString(maxScale)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// Fixed writes d with exactly places fractional digits, rounding half away
+// from zero.
+func (d Decimal) Fixed(places int32) string {
+	return d.value().FloatString(int(places))
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts a string, which keeps every digit, or a number.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	raw := strings.TrimSpace(string(data))
+	if raw == "null" {
+		return nil
+	}
+	raw = strings.Trim(raw, `"`)
+	parsed, err := Parse(raw)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value stores d as a NUMERIC string.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d *Decimal) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case int64:
+		*d = NewFromInt(v)
+		return nil
+	case float64:
+		*d = NewFromFloat(v)
+		return nil
+	case []byte:
+		return d.scanString(string(v))
+	case string:
+		return d.scanString(v)
+	default:
+		return fmt.Errorf("money: cannot scan %T into Decimal", src)
+	}
+}
+
+func (d *Decimal) scanString(value string) error {
+	// NUMERIC columns come back with the column's scale, which may exceed
+	// what Parse accepts from callers.
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return ErrInvalidDecimal
+	}
+	*d = Decimal{rat: rat}
+	return nil
+}
@@ -0,0 +1,71 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for _, value := range []string{"0", "12", "-0.5", "0.0004", ".25", "0.000000000001"} {
+		_, err := Parse(value)
+		assert.NoError(t, err, value)
+	}
+	for _, value := range []string{"", "-", ".", "1e3", "1/3", "abc", "0.0000000000001"} {
+		_, err := Parse(value)
+		assert.ErrorIs(t, err, ErrInvalidDecimal, value)
+	}
+}
+
+func TestRound(t *testing.T) {
+	cases := []struct {
+		value string
+		mode  RoundingMode
+		want  int64
+	}{
+		{"2.5", RoundHalfUp, 3},
+		{"-2.5", RoundHalfUp, -3},
+		{"2.4999", RoundHalfUp, 2},
+		{"2.5", RoundHalfEven, 2},
+		{"3.5", RoundHalfEven, 4},
+		{"-2.5", RoundHalfEven, -2},
+		{"2.6", RoundHalfEven, 3},
+		{"2.9", RoundFloor, 2},
+		{"-2.1", RoundFloor, -3},
+		{"7", RoundFloor, 7},
+	}
+	for _, tc := range cases {
+		d, err := Parse(tc.value)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, d.Round(tc.mode), "%s %s", tc.value, tc.mode)
+	}
+}
+
+func TestSubCentTotalsDoNotDrift(t *testing.T) {
+	unit, err := Parse("0.04") // $0.0004 in cents
+	require.NoError(t, err)
+
+	// A million calls priced one at a time, summed exactly.
+	total := Decimal{}
+	for i := 0; i < 1000; i++ {
+		total = total.Add(unit.Mul(NewFromInt(1000)))
+	}
+	assert.Equal(t, int64(40000), total.Round(RoundHalfUp))
+	assert.Equal(t, "40000", total.String())
+
+	assert.Equal(t, "0.3", NewFromFloat(0.1).Add(NewFromFloat(0.2)).String())
+}
+
+func TestJSON(t *testing.T) {
+	var d Decimal
+	require.NoError(t, json.Unmarshal([]byte(`"0.0004"`), &d))
+	assert.Equal(t, "0.0004", d.String())
+	require.NoError(t, json.Unmarshal([]byte(`12.5`), &d))
+	assert.Equal(t, "12.5", d.String())
+
+	encoded, err := json.Marshal(d)
+	require.NoError(t, err)
+	assert.JSONEq(t, `"12.5"`, string(encoded))
+}
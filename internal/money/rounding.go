@@ -0,0 +1,41 @@
+package money
+
+import (
+	"errors"
+	"strings"
+)
+
+// RoundingMode decides how an amount with fractional minor units is
+// rounded to a whole one.
+type RoundingMode string
+
+const (
+	// RoundHalfUp rounds halves away from zero: 2.5 -> 3, -2.5 -> -3.
+	RoundHalfUp RoundingMode = "HALF_UP"
+	// RoundHalfEven rounds halves to the even neighbour: 2.5 -> 2, 3.5 -> 4.
+	RoundHalfEven RoundingMode = "HALF_EVEN"
+	// RoundFloor rounds toward negative infinity: 2.9 -> 2, -2.1 -> -3.
+	RoundFloor RoundingMode = "FLOOR"
+)
+
+var ErrInvalidRoundingMode = errors.New("invalid_rounding_mode")
+
+// ParseRoundingMode validates a rounding mode. An empty value is HALF_UP.
+func ParseRoundingMode(value string) (RoundingMode, error) {
+	mode := RoundingMode(strings.ToUpper(strings.TrimSpace(value)))
+	switch mode {
+	case "":
+		return RoundHalfUp, nil
+	case RoundHalfUp, RoundHalfEven, RoundFloor:
+		return mode, nil
+	default:
+		return "", ErrInvalidRoundingMode
+	}
+}
+
+func (m RoundingMode) normalize() RoundingMode {
+	if mode, err := ParseRoundingMode(string(m)); err == nil {
+		return mode
+	}
+	return RoundHalfUp
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/money"
 	"gorm.io/datatypes"
 )
 
@@ -53,11 +54,13 @@ func (OrganizationInvite) TableName() string { return "organization_invites" }
 
 // OrganizationBillingPreferences stores billing defaults for an organization.
 type OrganizationBillingPreferences struct {
-	OrgID     snowflake.ID `gorm:"primaryKey" json:"org_id"`
-	Currency  string       `gorm:"type:text;not null" json:"currency"`
-	Timezone  string       `gorm:"type:text;not null" json:"timezone"`
-	CreatedAt time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	OrgID    snowflake.ID `gorm:"primaryKey" json:"org_id"`
+	Currency string       `gorm:"type:text;not null" json:"currency"`
+	Timezone string       `gorm:"type:text;not null" json:"timezone"`
+	// RoundingMode rounds rated amounts to whole minor units.
+	RoundingMode money.RoundingMode `gorm:"type:text;not null;default:'HALF_UP'" json:"rounding_mode"`
+	CreatedAt    time.Time          `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt    time.Time          `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
 
 // TableName sets the database table name.
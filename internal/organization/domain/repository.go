@@ -36,4 +36,5 @@ type Repository interface {
 	GetInvite(ctx context.Context, inviteID snowflake.ID) (*OrganizationInvite, error)
 	UpdateInvite(ctx context.Context, invite OrganizationInvite) error
 	UpsertBillingPreferences(ctx context.Context, prefs OrganizationBillingPreferences) error
+	GetBillingPreferences(ctx context.Context, orgID snowflake.ID) (*OrganizationBillingPreferences, error)
 }
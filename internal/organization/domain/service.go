@@ -27,6 +27,7 @@ type Service interface {
 	AcceptInvite(ctx context.Context, userID snowflake.ID, inviteID string) error
 	GetInvite(ctx context.Context, inviteID string) (*PublicInviteInfo, error)
 	SetBillingPreferences(ctx context.Context, userID snowflake.ID, orgID string, req BillingPreferencesRequest) error
+	GetBillingPreferences(ctx context.Context, userID snowflake.ID, orgID string) (*BillingPreferencesResponse, error)
 }
 
 type PublicInviteInfo struct {
@@ -57,6 +58,15 @@ type InviteRequest struct {
 type BillingPreferencesRequest struct {
 	Currency string
 	Timezone string
+	// RoundingMode is HALF_UP, HALF_EVEN or FLOOR; empty keeps the current
+	// mode, which is HALF_UP for new preferences.
+	RoundingMode string
+}
+
+type BillingPreferencesResponse struct {
+	Currency     string `json:"currency"`
+	Timezone     string `json:"timezone"`
+	RoundingMode string `json:"rounding_mode"`
 }
 
 type OrganizationResponse struct {
@@ -79,6 +89,7 @@ var (
 	ErrInvalidCountry      = errors.New("invalid_country")
 	ErrInvalidTimezone     = errors.New("invalid_timezone")
 	ErrInvalidCurrency     = errors.New("invalid_currency")
+	ErrInvalidRoundingMode = errors.New("invalid_rounding_mode")
 	ErrInvalidUser         = errors.New("invalid_user")
 	ErrInvalidOrganization = errors.New("invalid_organization")
 	ErrInvalidEmail        = errors.New("invalid_email")
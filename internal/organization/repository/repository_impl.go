@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/money"
 	"github.com/smallbiznis/railzway/internal/organization/domain"
 	"gorm.io/gorm"
 )
@@ -90,22 +91,44 @@ func (r *repository) CreateInvites(ctx context.Context, invites []domain.Organiz
 	return r.db.WithContext(ctx).Create(&invites).Error
 }
 
+// UpsertBillingPreferences stores the preferences. An empty rounding mode
+// keeps the current one, or HALF_UP for a new row.
 func (r *repository) UpsertBillingPreferences(ctx context.Context, prefs domain.OrganizationBillingPreferences) error {
+	roundingMode := prefs.RoundingMode
+	updateRounding := "rounding_mode = EXCLUDED.rounding_mode,"
+	if roundingMode == "" {
+		roundingMode = money.RoundHalfUp
+		updateRounding = ""
+	}
 	return r.db.WithContext(ctx).Exec(
-		`INSERT INTO organization_billing_preferences (org_id, currency, timezone, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?)
+		`INSERT INTO organization_billing_preferences (org_id, currency, timezone, rounding_mode, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?)
 		 ON CONFLICT (org_id)
 		 DO UPDATE SET currency = EXCLUDED.currency,
 		               timezone = EXCLUDED.timezone,
+		               `+updateRounding+`
 		               updated_at = EXCLUDED.updated_at`,
 		prefs.OrgID,
 		prefs.Currency,
 		prefs.Timezone,
+		roundingMode,
 		prefs.CreatedAt,
 		prefs.UpdatedAt,
 	).Error
 }
 
+func (r *repository) GetBillingPreferences(ctx context.Context, orgID snowflake.ID) (*domain.OrganizationBillingPreferences, error) {
+	var prefs domain.OrganizationBillingPreferences
+	err := r.db.WithContext(ctx).First(&prefs, "org_id = ?", orgID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &prefs, nil
+}
+
 func (r *repository) GetInvite(ctx context.Context, inviteID snowflake.ID) (*domain.OrganizationInvite, error) {
 	var invite domain.OrganizationInvite
 	err := r.db.WithContext(ctx).First(&invite, "id = ?", inviteID).Error
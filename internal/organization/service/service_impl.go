@@ -10,6 +10,7 @@ import (
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/gosimple/slug"
+	"github.com/smallbiznis/railzway/internal/money"
 	"github.com/smallbiznis/railzway/internal/organization/domain"
 	"github.com/smallbiznis/railzway/internal/organization/event"
 	"github.com/smallbiznis/railzway/internal/providers/email"
@@ -459,16 +460,63 @@ func (s *service) SetBillingPreferences(ctx context.Context, userID snowflake.ID
 		return domain.ErrInvalidTimezone
 	}
 
+	// An empty rounding mode keeps the current one.
+	var roundingMode money.RoundingMode
+	if strings.TrimSpace(req.RoundingMode) != "" {
+		roundingMode, err = money.ParseRoundingMode(req.RoundingMode)
+		if err != nil {
+			return domain.ErrInvalidRoundingMode
+		}
+	}
+
 	now := time.Now().UTC()
 	return s.repo.UpsertBillingPreferences(ctx, domain.OrganizationBillingPreferences{
-		OrgID:     org.ID,
-		Currency:  currency,
-		Timezone:  timezone,
-		CreatedAt: now,
-		UpdatedAt: now,
+		OrgID:        org.ID,
+		Currency:     currency,
+		Timezone:     timezone,
+		RoundingMode: roundingMode,
+		CreatedAt:    now,
+		UpdatedAt:    now,
 	})
 }
 
+func (s *service) GetBillingPreferences(ctx context.Context, userID snowflake.ID, orgID string) (*domain.BillingPreferencesResponse, error) {
+	if userID == 0 {
+		return nil, domain.ErrInvalidUser
+	}
+
+	rawOrgID := strings.TrimSpace(orgID)
+	if rawOrgID == "" {
+		return nil, domain.ErrInvalidOrganization
+	}
+	parsedOrgID, err := snowflake.ParseString(rawOrgID)
+	if err != nil {
+		return nil, domain.ErrInvalidOrganization
+	}
+
+	isMember, err := s.repo.IsMember(ctx, parsedOrgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, domain.ErrForbidden
+	}
+
+	prefs, err := s.repo.GetBillingPreferences(ctx, parsedOrgID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &domain.BillingPreferencesResponse{RoundingMode: string(money.RoundHalfUp)}
+	if prefs != nil {
+		resp.Currency = prefs.Currency
+		resp.Timezone = prefs.Timezone
+		if prefs.RoundingMode != "" {
+			resp.RoundingMode = string(prefs.RoundingMode)
+		}
+	}
+	return resp, nil
+}
+
 func (s *service) countryExists(ctx context.Context, code string) (bool, error) {
 	countries, err := s.ref.ListCountries(ctx)
 	if err != nil {
@@ -8,11 +8,11 @@ import (
 	"encoding/hex"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/money"
 	paymentdomain "github.com/smallbiznis/railzway/internal/payment/domain"
 )
 
@@ -146,8 +146,6 @@ func (a *Adapter) Parse(ctx context.Context, payload []byte) (*paymentdomain.Pay
 
 	// Amount extraction (simplified, assuming <amount>10.00</amount>)
 	amountStr := extractXMLTag(sXml, "amount")
-	amountFloat, _ := strconv.ParseFloat(amountStr, 64)
-	amount := int64(amountFloat * 100) // Convert standard units to cents (minor units)
 
 	// Currency
 	currency := "USD" // Default if not found
@@ -156,6 +154,9 @@ func (a *Adapter) Parse(ctx context.Context, payload []byte) (*paymentdomain.Pay
 	if foundCurr := extractXMLTag(sXml, "currency-iso-code"); foundCurr != "" {
 		currency = foundCurr
 	}
+
+	// Convert standard units to the currency's minor units
+	amount, _ := money.FromMajor(amountStr, currency)
 	
 	// Timestamp defaults to now as Braintree XML is heavy to parse actual event time without struct
 	occurredAt := time.Now().UTC()
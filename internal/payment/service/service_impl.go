@@ -591,8 +591,11 @@ func (s *Service) customerBalance(
 		JOIN ledger_accounts a ON a.id = l.account_id
 
 		-- billing & adjustment → subscription scoped
+		LEFT JOIN billing_adjustments ba
+			ON ba.id = le.source_id
+		   AND le.source_type = ?
 		LEFT JOIN billing_cycles bc
-			ON bc.id = le.source_id
+			ON bc.id = COALESCE(ba.billing_cycle_id, le.source_id)
 		   AND le.source_type IN (?, ?)
 		LEFT JOIN subscriptions s
 			ON s.id = bc.subscription_id
@@ -617,6 +620,7 @@ func (s *Service) customerBalance(
 		  )
 		`,
 		// joins
+		ledgerdomain.SourceTypeAdjustment,
 		ledgerdomain.SourceTypeBillingCycle,
 		ledgerdomain.SourceTypeAdjustment,
 
@@ -348,6 +348,11 @@ func setupTestDB(t *testing.T) *gorm.DB {
 			org_id BIGINT NOT NULL,
 			subscription_id BIGINT NOT NULL
 		)`,
+		`CREATE TABLE billing_adjustments (
+			id BIGINT PRIMARY KEY,
+			org_id BIGINT NOT NULL,
+			billing_cycle_id BIGINT NOT NULL
+		)`,
 	}
 
 	for _, stmt := range schema {
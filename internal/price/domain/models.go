@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/bwmarrin/snowflake"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
 	"gorm.io/datatypes"
 )
 
@@ -37,6 +38,16 @@ var (
 	Metered  BillingMode = "METERED"
 )
 
+// BillingTiming decides when a licensed price is charged: IN_ADVANCE bills
+// the period when it starts, IN_ARREARS once it has ended. Metered prices are
+// always billed in arrears.
+type BillingTiming string
+
+var (
+	InAdvance BillingTiming = "IN_ADVANCE"
+	InArrears BillingTiming = "IN_ARREARS"
+)
+
 type BillingInterval string
 
 var (
@@ -54,6 +65,27 @@ var (
 	LAST AggregateUsage = "LAST"
 )
 
+// ResolveAggregation picks how usage of a meter is aggregated for a price.
+//
+// The meter is the source of truth. A price may only narrow it with an
+// explicit MAX or LAST; SUM is the price default and never overrides the meter.
+// It returns false when the meter's aggregation is not supported.
+func ResolveAggregation(meterAggregation string, aggregateUsage *AggregateUsage) (string, bool) {
+	aggregation, ok := meterdomain.NormalizeAggregation(meterAggregation)
+	if !ok {
+		return "", false
+	}
+	if aggregateUsage != nil {
+		switch *aggregateUsage {
+		case MAX:
+			aggregation = meterdomain.AggregationMax
+		case LAST:
+			aggregation = meterdomain.AggregationLast
+		}
+	}
+	return aggregation, true
+}
+
 type ProrationBehavior string
 
 var (
@@ -80,6 +112,7 @@ type Price struct {
 	Description          string            `json:"description,omitempty" gorm:"type:text"`
 	PricingModel         PricingModel      `json:"pricing_model" gorm:"type:text;not null;default:0"`
 	BillingMode          BillingMode       `json:"billing_mode" gorm:"type:text;not null;default:0"`
+	BillingTiming        BillingTiming     `json:"billing_timing" gorm:"type:text;not null;default:'IN_ARREARS'"`
 	BillingInterval      BillingInterval   `json:"billing_interval" gorm:"type:text;not null;default:0"`
 	BillingIntervalCount int32             `json:"billing_interval_count" gorm:"not null;default:1"`
 	AggregateUsage       *AggregateUsage   `json:"aggregate_usage,omitempty" gorm:"type:text"`
@@ -22,6 +22,7 @@ type CreateRequest struct {
 	Description          string          `json:"description"`
 	PricingModel         PricingModel    `json:"pricing_model"`
 	BillingMode          BillingMode     `json:"billing_mode"`
+	BillingTiming        BillingTiming   `json:"billing_timing"`
 	BillingInterval      BillingInterval `json:"billing_interval"`
 	BillingIntervalCount int32           `json:"billing_interval_count"`
 	AggregateUsage       *AggregateUsage `json:"aggregate_usage"`
@@ -46,6 +47,7 @@ type Response struct {
 	Description          string          `json:"description,omitempty"`
 	PricingModel         PricingModel    `json:"pricing_model"`
 	BillingMode          BillingMode     `json:"billing_mode"`
+	BillingTiming        BillingTiming   `json:"billing_timing"`
 	BillingInterval      BillingInterval `json:"billing_interval"`
 	BillingIntervalCount int32           `json:"billing_interval_count"`
 	AggregateUsage       *AggregateUsage `json:"aggregate_usage,omitempty"`
@@ -67,6 +69,7 @@ var (
 	ErrInvalidCode                 = errors.New("invalid_code")
 	ErrInvalidPricingModel         = errors.New("invalid_pricing_model")
 	ErrInvalidBillingMode          = errors.New("invalid_billing_mode")
+	ErrInvalidBillingTiming        = errors.New("invalid_billing_timing")
 	ErrInvalidBillingInterval      = errors.New("invalid_billing_interval")
 	ErrInvalidBillingIntervalCount = errors.New("invalid_billing_interval_count")
 	ErrUnsupportedPricingModel     = errors.New("unsupported_pricing_model")
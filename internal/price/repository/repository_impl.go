@@ -18,10 +18,10 @@ func (r *repo) Insert(ctx context.Context, db *gorm.DB, p *pricedomain.Price) er
 	return db.WithContext(ctx).Exec(
 		`INSERT INTO prices (
 			id, org_id, product_id, code, name, description,
-			pricing_model, billing_mode, billing_interval, billing_interval_count,
+			pricing_model, billing_mode, billing_timing, billing_interval, billing_interval_count,
 			aggregate_usage, billing_unit, billing_threshold, tax_behavior, tax_code,
 			version, is_default, active, retired_at, metadata, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		p.ID,
 		p.OrgID,
 		p.ProductID,
@@ -30,6 +30,7 @@ func (r *repo) Insert(ctx context.Context, db *gorm.DB, p *pricedomain.Price) er
 		p.Description,
 		p.PricingModel,
 		p.BillingMode,
+		p.BillingTiming,
 		p.BillingInterval,
 		p.BillingIntervalCount,
 		p.AggregateUsage,
@@ -51,7 +52,7 @@ func (r *repo) FindByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID
 	var p pricedomain.Price
 	err := db.WithContext(ctx).Raw(
 		`SELECT id, org_id, product_id, code, name, description,
-		 pricing_model, billing_mode, billing_interval, billing_interval_count,
+		 pricing_model, billing_mode, billing_timing, billing_interval, billing_interval_count,
 		 aggregate_usage, billing_unit, billing_threshold, tax_behavior, tax_code,
 		 version, is_default, active, retired_at, metadata, created_at, updated_at
 		 FROM prices WHERE org_id = ? AND id = ?`,
@@ -71,7 +72,7 @@ func (r *repo) List(ctx context.Context, db *gorm.DB, orgID snowflake.ID) ([]pri
 	var items []pricedomain.Price
 	err := db.WithContext(ctx).Raw(
 		`SELECT id, org_id, product_id, code, name, description,
-		 pricing_model, billing_mode, billing_interval, billing_interval_count,
+		 pricing_model, billing_mode, billing_timing, billing_interval, billing_interval_count,
 		 aggregate_usage, billing_unit, billing_threshold, tax_behavior, tax_code,
 		 version, is_default, active, retired_at, metadata, created_at, updated_at
 		 FROM prices WHERE org_id = ? ORDER BY created_at ASC`,
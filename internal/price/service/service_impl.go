@@ -54,6 +54,11 @@ func (s *Service) Create(ctx context.Context, req pricedomain.CreateRequest) (*p
 		return nil, err
 	}
 
+	billingTiming, err := parseBillingTiming(req.BillingTiming, billingMode)
+	if err != nil {
+		return nil, err
+	}
+
 	taxCodePtr, version, isDefault, active, err := parseCreateFlags(req)
 	if err != nil {
 		return nil, err
@@ -69,6 +74,7 @@ func (s *Service) Create(ctx context.Context, req pricedomain.CreateRequest) (*p
 		Description:          req.Description,
 		PricingModel:         pricingModel,
 		BillingMode:          billingMode,
+		BillingTiming:        billingTiming,
 		BillingInterval:      billingInterval,
 		BillingIntervalCount: req.BillingIntervalCount,
 		AggregateUsage:       aggregateUsagePtr,
@@ -153,6 +159,7 @@ func (s *Service) toResponse(p *pricedomain.Price) *pricedomain.Response {
 		Description:          p.Description,
 		PricingModel:         p.PricingModel,
 		BillingMode:          p.BillingMode,
+		BillingTiming:        p.BillingTiming,
 		BillingInterval:      p.BillingInterval,
 		BillingIntervalCount: p.BillingIntervalCount,
 		AggregateUsage:       p.AggregateUsage,
@@ -206,6 +213,22 @@ func parseBillingMode(value pricedomain.BillingMode) (pricedomain.BillingMode, e
 	}
 }
 
+// parseBillingTiming defaults to IN_ARREARS. Usage is only known once the
+// period is over, so metered prices cannot be billed in advance.
+func parseBillingTiming(value pricedomain.BillingTiming, billingMode pricedomain.BillingMode) (pricedomain.BillingTiming, error) {
+	switch strings.ToUpper(strings.TrimSpace(string(value))) {
+	case "", string(pricedomain.InArrears):
+		return pricedomain.InArrears, nil
+	case string(pricedomain.InAdvance):
+		if billingMode == pricedomain.Metered {
+			return "", pricedomain.ErrInvalidBillingTiming
+		}
+		return pricedomain.InAdvance, nil
+	default:
+		return "", pricedomain.ErrInvalidBillingTiming
+	}
+}
+
 func parseBillingInterval(value pricedomain.BillingInterval) (pricedomain.BillingInterval, error) {
 	switch strings.ToUpper(strings.TrimSpace(string(value))) {
 	case string(pricedomain.Day):
@@ -435,7 +458,8 @@ func validateMeteredPricing(billingMode pricedomain.BillingMode, aggregateUsage
 	if billingUnit == nil {
 		return pricedomain.ErrInvalidBillingUnit
 	}
-	if aggregateUsage == nil || *aggregateUsage != pricedomain.SUM {
+	// SUM defers to the meter's aggregation; MAX and LAST override it at rating.
+	if aggregateUsage == nil {
 		return pricedomain.ErrInvalidAggregateUsage
 	}
 	return nil
@@ -4,20 +4,22 @@ import (
 	"time"
 
 	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/money"
 	"gorm.io/datatypes"
 )
 
 type PriceAmount struct {
-	ID                 snowflake.ID  `json:"id" gorm:"primaryKey"`
-	OrgID              snowflake.ID  `json:"organization_id" gorm:"column:org_id;not null;index"`
-	PriceID            snowflake.ID  `json:"price_id" gorm:"column:price_id;not null;index"`
-	MeterID            *snowflake.ID `gorm:"column:meter_id;index"`
-	Currency           string        `json:"currency" gorm:"type:text;not null"`
-	UnitAmountCents    int64         `json:"unit_amount_cents" gorm:"not null"`
-	MinimumAmountCents *int64        `json:"minimum_amount_cents,omitempty" gorm:""`
-	MaximumAmountCents *int64        `json:"maximum_amount_cents,omitempty" gorm:""`
-	EffectiveFrom      time.Time     `json:"effective_from" gorm:"not null;default:CURRENT_TIMESTAMP"`
-	EffectiveTo        *time.Time    `json:"effective_to,omitempty" gorm:""`
+	ID                 snowflake.ID   `json:"id" gorm:"primaryKey"`
+	OrgID              snowflake.ID   `json:"organization_id" gorm:"column:org_id;not null;index"`
+	PriceID            snowflake.ID   `json:"price_id" gorm:"column:price_id;not null;index"`
+	MeterID            *snowflake.ID  `gorm:"column:meter_id;index"`
+	Currency           string         `json:"currency" gorm:"type:text;not null"`
+	UnitAmountCents    int64          `json:"unit_amount_cents" gorm:"not null"`
+	UnitAmountDecimal  *money.Decimal `json:"unit_amount_decimal,omitempty" gorm:"type:numeric"`
+	MinimumAmountCents *int64         `json:"minimum_amount_cents,omitempty" gorm:""`
+	MaximumAmountCents *int64         `json:"maximum_amount_cents,omitempty" gorm:""`
+	EffectiveFrom      time.Time      `json:"effective_from" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	EffectiveTo        *time.Time     `json:"effective_to,omitempty" gorm:""`
 
 	RevokedAt     *time.Time
 	RevokedReason *string
@@ -28,3 +30,12 @@ type PriceAmount struct {
 }
 
 func (PriceAmount) TableName() string { return "price_amounts" }
+
+// UnitAmount is the unit price in minor units. UnitAmountDecimal, when set,
+// carries fractions of a minor unit that UnitAmountCents cannot.
+func (p PriceAmount) UnitAmount() money.Decimal {
+	if p.UnitAmountDecimal != nil {
+		return *p.UnitAmountDecimal
+	}
+	return money.NewFromInt(p.UnitAmountCents)
+}
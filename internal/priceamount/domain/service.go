@@ -6,10 +6,12 @@ import (
 	"time"
 
 	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/money"
 )
 
 type ListPriceAmountRequest struct {
 	PriceID       string     `url:"price_id" form:"price_id"`
+	Currency      string     `url:"currency" form:"currency"`
 	EffectiveFrom *time.Time `url:"effective_from" form:"effective_from"`
 	EffectiveTo   *time.Time `url:"effective_to" form:"effective_to"`
 }
@@ -24,11 +26,15 @@ type Service interface {
 	Get(ctx context.Context, req GetPriceAmountByID) (*Response, error)
 }
 
+// CreateRequest adds a price amount. UnitAmountDecimal prices in fractions
+// of a minor unit, e.g. "0.04" cents per call; when set it takes precedence
+// and UnitAmountCents is derived from it.
 type CreateRequest struct {
 	PriceID            string         `json:"price_id"`
 	MeterID            *string        `json:"meter_id"`
 	Currency           string         `json:"currency"`
 	UnitAmountCents    int64          `json:"unit_amount_cents"`
+	UnitAmountDecimal  *money.Decimal `json:"unit_amount_decimal,omitempty"`
 	MinimumAmountCents *int64         `json:"minimum_amount_cents"`
 	MaximumAmountCents *int64         `json:"maximum_amount_cents"`
 	EffectiveFrom      *time.Time     `json:"effective_from,omitempty"`
@@ -37,21 +43,27 @@ type CreateRequest struct {
 }
 
 type Response struct {
-	ID                 snowflake.ID  `json:"id"`
-	OrganizationID     snowflake.ID  `json:"organization_id"`
-	PriceID            snowflake.ID  `json:"price_id"`
-	MeterID            *snowflake.ID `json:"meter_id,omitempty"`
-	Currency           string        `json:"currency"`
-	UnitAmountCents    int64         `json:"unit_amount_cents"`
-	MinimumAmountCents *int64        `json:"minimum_amount_cents,omitempty"`
-	MaximumAmountCents *int64        `json:"maximum_amount_cents,omitempty"`
-	EffectiveFrom      time.Time     `json:"effective_from"`
-	EffectiveTo        *time.Time    `json:"effective_to,omitempty"`
-	RevokedAt          *time.Time    `json:"revoked_at"`
-	RevokedReason      *string       `json:"revoked_reason"`
-	Status             string        `json:"status"`
-	CreatedAt          time.Time     `json:"created_at"`
-	UpdatedAt          time.Time     `json:"updated_at"`
+	ID                 snowflake.ID   `json:"id"`
+	OrganizationID     snowflake.ID   `json:"organization_id"`
+	PriceID            snowflake.ID   `json:"price_id"`
+	MeterID            *snowflake.ID  `json:"meter_id,omitempty"`
+	Currency           string         `json:"currency"`
+	UnitAmountCents    int64          `json:"unit_amount_cents"`
+	UnitAmountDecimal  *money.Decimal `json:"unit_amount_decimal,omitempty"`
+	MinimumAmountCents *int64         `json:"minimum_amount_cents,omitempty"`
+	MaximumAmountCents *int64         `json:"maximum_amount_cents,omitempty"`
+	EffectiveFrom      time.Time      `json:"effective_from"`
+	EffectiveTo        *time.Time     `json:"effective_to,omitempty"`
+	RevokedAt          *time.Time     `json:"revoked_at"`
+	RevokedReason      *string        `json:"revoked_reason"`
+	Status             string         `json:"status"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+}
+
+// UnitAmount is the unit price in minor units, see PriceAmount.UnitAmount.
+func (r Response) UnitAmount() money.Decimal {
+	return PriceAmount{UnitAmountCents: r.UnitAmountCents, UnitAmountDecimal: r.UnitAmountDecimal}.UnitAmount()
 }
 
 var (
@@ -20,15 +20,16 @@ func Provide() priceamountdomain.Repository {
 func (r *repo) Insert(ctx context.Context, db *gorm.DB, amount *priceamountdomain.PriceAmount) error {
 	return db.WithContext(ctx).Exec(
 		`INSERT INTO price_amounts (
-			id, org_id, price_id, meter_id, currency, unit_amount_cents, minimum_amount_cents, maximum_amount_cents, effective_from, effective_to,
+			id, org_id, price_id, meter_id, currency, unit_amount_cents, unit_amount_decimal, minimum_amount_cents, maximum_amount_cents, effective_from, effective_to,
 			metadata, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		amount.ID,
 		amount.OrgID,
 		amount.PriceID,
 		amount.MeterID,
 		amount.Currency,
 		amount.UnitAmountCents,
+		amount.UnitAmountDecimal,
 		amount.MinimumAmountCents,
 		amount.MaximumAmountCents,
 		amount.EffectiveFrom,
@@ -43,7 +44,7 @@ func (r *repo) FindByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID
 	var amount priceamountdomain.PriceAmount
 	err := db.WithContext(ctx).Raw(
 		`SELECT id, org_id, price_id, meter_id, currency,
-		        unit_amount_cents, minimum_amount_cents, maximum_amount_cents,
+		        unit_amount_cents, unit_amount_decimal, minimum_amount_cents, maximum_amount_cents,
 		        effective_from, effective_to,
 		        revoked_at, revoked_reason,
 		        metadata, created_at, updated_at
@@ -113,7 +114,7 @@ func (r *repo) FindEffectiveAt(
 ) (*priceamountdomain.PriceAmount, error) {
 	var amount priceamountdomain.PriceAmount
 	query := `
-		SELECT id, org_id, price_id, meter_id, currency, unit_amount_cents, minimum_amount_cents, maximum_amount_cents,
+		SELECT id, org_id, price_id, meter_id, currency, unit_amount_cents, unit_amount_decimal, minimum_amount_cents, maximum_amount_cents,
 		       effective_from, effective_to, revoked_at, revoked_reason, metadata, created_at, updated_at
 		FROM price_amounts
 		WHERE org_id = ? AND price_id = ?
@@ -144,7 +145,7 @@ func (r *repo) FindPrevious(
 ) (*priceamountdomain.PriceAmount, error) {
 	var amount priceamountdomain.PriceAmount
 	query := `
-		SELECT id, org_id, price_id, meter_id, currency, unit_amount_cents, minimum_amount_cents, maximum_amount_cents,
+		SELECT id, org_id, price_id, meter_id, currency, unit_amount_cents, unit_amount_decimal, minimum_amount_cents, maximum_amount_cents,
 		       effective_from, effective_to, metadata, created_at, updated_at
 		FROM price_amounts
 		WHERE org_id = ? AND price_id = ?
@@ -174,7 +175,7 @@ func (r *repo) FindNext(
 ) (*priceamountdomain.PriceAmount, error) {
 	var amount priceamountdomain.PriceAmount
 	query := `
-		SELECT id, org_id, price_id, meter_id, currency, unit_amount_cents, minimum_amount_cents, maximum_amount_cents,
+		SELECT id, org_id, price_id, meter_id, currency, unit_amount_cents, unit_amount_decimal, minimum_amount_cents, maximum_amount_cents,
 		       effective_from, effective_to, metadata, created_at, updated_at
 		FROM price_amounts
 		WHERE org_id = ? AND price_id = ?
@@ -205,7 +206,7 @@ func (r *repo) ListOverlapping(
 ) ([]priceamountdomain.PriceAmount, error) {
 	var items []priceamountdomain.PriceAmount
 	query := `
-		SELECT id, org_id, price_id, meter_id, currency, unit_amount_cents, minimum_amount_cents, maximum_amount_cents,
+		SELECT id, org_id, price_id, meter_id, currency, unit_amount_cents, unit_amount_decimal, minimum_amount_cents, maximum_amount_cents,
 		       effective_from, effective_to, metadata, created_at, updated_at
 		FROM price_amounts
 		WHERE org_id = ? AND price_id = ?
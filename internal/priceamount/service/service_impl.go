@@ -7,6 +7,7 @@ import (
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/smallbiznis/railzway/internal/clock"
+	"github.com/smallbiznis/railzway/internal/money"
 	"github.com/smallbiznis/railzway/internal/orgcontext"
 	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
 	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
@@ -172,6 +173,7 @@ func (s *Service) Create(ctx context.Context, req priceamountdomain.CreateReques
 			MeterID:            meterID, // Use resolved dimension, not request
 			Currency:           currency,
 			UnitAmountCents:    req.UnitAmountCents,
+			UnitAmountDecimal:  req.UnitAmountDecimal,
 			MinimumAmountCents: req.MinimumAmountCents,
 			MaximumAmountCents: req.MaximumAmountCents,
 			EffectiveFrom:      effectiveFrom,
@@ -179,6 +181,9 @@ func (s *Service) Create(ctx context.Context, req priceamountdomain.CreateReques
 			CreatedAt:          now,
 			UpdatedAt:          now,
 		}
+		if req.UnitAmountDecimal != nil {
+			entity.UnitAmountCents = req.UnitAmountDecimal.Round(money.RoundHalfUp)
+		}
 		if req.Metadata != nil {
 			entity.Metadata = datatypes.JSONMap(req.Metadata)
 		}
@@ -331,8 +336,10 @@ func (s *Service) parseAmountIdentifiers(req priceamountdomain.CreateRequest) (s
 		meterID = &parsedMeterID
 	}
 
-	currency := strings.TrimSpace(req.Currency)
-	if currency == "" {
+	// Amounts are in the currency's minor units, so the code must resolve
+	// to one: "jpy" is JPY with no decimals, not an unknown two-decimal one.
+	currency, err := money.NormalizeCurrency(req.Currency)
+	if err != nil {
 		return 0, nil, "", priceamountdomain.ErrInvalidCurrency
 	}
 
@@ -343,6 +350,9 @@ func validateAmountValues(req priceamountdomain.CreateRequest) error {
 	if req.UnitAmountCents < 0 {
 		return priceamountdomain.ErrInvalidUnitAmount
 	}
+	if req.UnitAmountDecimal != nil && req.UnitAmountDecimal.Sign() < 0 {
+		return priceamountdomain.ErrInvalidUnitAmount
+	}
 
 	if req.MinimumAmountCents != nil && *req.MinimumAmountCents < 0 {
 		return priceamountdomain.ErrInvalidMinAmount
@@ -373,6 +383,7 @@ func (s *Service) toResponse(a *priceamountdomain.PriceAmount) *priceamountdomai
 		MeterID:            meterID,
 		Currency:           a.Currency,
 		UnitAmountCents:    a.UnitAmountCents,
+		UnitAmountDecimal:  a.UnitAmountDecimal,
 		MinimumAmountCents: a.MinimumAmountCents,
 		MaximumAmountCents: a.MaximumAmountCents,
 		EffectiveFrom:      a.EffectiveFrom,
@@ -403,6 +414,13 @@ func (s *Service) List(ctx context.Context, req priceamountdomain.ListPriceAmoun
 		}
 		filter.PriceID = priceID
 	}
+	if strings.TrimSpace(req.Currency) != "" {
+		currency, err := money.NormalizeCurrency(req.Currency)
+		if err != nil {
+			return nil, priceamountdomain.ErrInvalidCurrency
+		}
+		filter.Currency = currency
+	}
 
 	opts := []option.QueryOption{}
 
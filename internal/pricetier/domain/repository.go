@@ -11,4 +11,5 @@ type Repository interface {
 	Insert(ctx context.Context, db *gorm.DB, tier *PriceTier) error
 	FindByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*PriceTier, error)
 	List(ctx context.Context, db *gorm.DB, orgID snowflake.ID) ([]PriceTier, error)
+	ListByPriceID(ctx context.Context, db *gorm.DB, orgID, priceID snowflake.ID) ([]PriceTier, error)
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"time"
+
+	"github.com/smallbiznis/railzway/internal/money"
 )
 
 type Service interface {
@@ -12,29 +14,34 @@ type Service interface {
 	Get(ctx context.Context, id string) (*Response, error)
 }
 
+// CreateRequest adds a tier. UnitAmountDecimal prices in fractions of a
+// minor unit; when set it takes precedence and UnitAmountCents is derived
+// from it.
 type CreateRequest struct {
-	PriceID         string         `json:"price_id"`
-	TierMode        int16          `json:"tier_mode"`
-	StartQuantity   float64        `json:"start_quantity"`
-	EndQuantity     *float64       `json:"end_quantity"`
-	UnitAmountCents *int64         `json:"unit_amount_cents"`
-	FlatAmountCents *int64         `json:"flat_amount_cents"`
-	Unit            string         `json:"unit"`
-	Metadata        map[string]any `json:"metadata"`
+	PriceID           string         `json:"price_id"`
+	TierMode          int16          `json:"tier_mode"`
+	StartQuantity     float64        `json:"start_quantity"`
+	EndQuantity       *float64       `json:"end_quantity"`
+	UnitAmountCents   *int64         `json:"unit_amount_cents"`
+	UnitAmountDecimal *money.Decimal `json:"unit_amount_decimal,omitempty"`
+	FlatAmountCents   *int64         `json:"flat_amount_cents"`
+	Unit              string         `json:"unit"`
+	Metadata          map[string]any `json:"metadata"`
 }
 
 type Response struct {
-	ID              string    `json:"id"`
-	OrganizationID  string    `json:"organization_id"`
-	PriceID         string    `json:"price_id"`
-	TierMode        int16     `json:"tier_mode"`
-	StartQuantity   float64   `json:"start_quantity"`
-	EndQuantity     *float64  `json:"end_quantity,omitempty"`
-	UnitAmountCents *int64    `json:"unit_amount_cents,omitempty"`
-	FlatAmountCents *int64    `json:"flat_amount_cents,omitempty"`
-	Unit            string    `json:"unit"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID                string         `json:"id"`
+	OrganizationID    string         `json:"organization_id"`
+	PriceID           string         `json:"price_id"`
+	TierMode          int16          `json:"tier_mode"`
+	StartQuantity     float64        `json:"start_quantity"`
+	EndQuantity       *float64       `json:"end_quantity,omitempty"`
+	UnitAmountCents   *int64         `json:"unit_amount_cents,omitempty"`
+	UnitAmountDecimal *money.Decimal `json:"unit_amount_decimal,omitempty"`
+	FlatAmountCents   *int64         `json:"flat_amount_cents,omitempty"`
+	Unit              string         `json:"unit"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
 }
 
 var (
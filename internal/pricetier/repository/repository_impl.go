@@ -18,8 +18,8 @@ func (r *repo) Insert(ctx context.Context, db *gorm.DB, tier *pricetierdomain.Pr
 	return db.WithContext(ctx).Exec(
 		`INSERT INTO price_tiers (
 			id, org_id, price_id, tier_mode, start_quantity, end_quantity, unit_amount_cents,
-			flat_amount_cents, unit, metadata, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			unit_amount_decimal, flat_amount_cents, unit, metadata, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		tier.ID,
 		tier.OrgID,
 		tier.PriceID,
@@ -27,6 +27,7 @@ func (r *repo) Insert(ctx context.Context, db *gorm.DB, tier *pricetierdomain.Pr
 		tier.StartQuantity,
 		tier.EndQuantity,
 		tier.UnitAmountCents,
+		tier.UnitAmountDecimal,
 		tier.FlatAmountCents,
 		tier.Unit,
 		tier.Metadata,
@@ -39,7 +40,7 @@ func (r *repo) FindByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID
 	var tier pricetierdomain.PriceTier
 	err := db.WithContext(ctx).Raw(
 		`SELECT id, org_id, price_id, tier_mode, start_quantity, end_quantity, unit_amount_cents,
-		 flat_amount_cents, unit, metadata, created_at, updated_at
+		 unit_amount_decimal, flat_amount_cents, unit, metadata, created_at, updated_at
 		 FROM price_tiers WHERE org_id = ? AND id = ?`,
 		orgID,
 		id,
@@ -57,7 +58,7 @@ func (r *repo) List(ctx context.Context, db *gorm.DB, orgID snowflake.ID) ([]pri
 	var items []pricetierdomain.PriceTier
 	err := db.WithContext(ctx).Raw(
 		`SELECT id, org_id, price_id, tier_mode, start_quantity, end_quantity, unit_amount_cents,
-		 flat_amount_cents, unit, metadata, created_at, updated_at
+		 unit_amount_decimal, flat_amount_cents, unit, metadata, created_at, updated_at
 		 FROM price_tiers WHERE org_id = ? ORDER BY created_at ASC`,
 		orgID,
 	).Scan(&items).Error
@@ -66,3 +67,19 @@ func (r *repo) List(ctx context.Context, db *gorm.DB, orgID snowflake.ID) ([]pri
 	}
 	return items, nil
 }
+
+func (r *repo) ListByPriceID(ctx context.Context, db *gorm.DB, orgID, priceID snowflake.ID) ([]pricetierdomain.PriceTier, error) {
+	var items []pricetierdomain.PriceTier
+	err := db.WithContext(ctx).Raw(
+		`SELECT id, org_id, price_id, tier_mode, start_quantity, end_quantity, unit_amount_cents,
+		 unit_amount_decimal, flat_amount_cents, unit, metadata, created_at, updated_at
+		 FROM price_tiers WHERE org_id = ? AND price_id = ?
+		 ORDER BY start_quantity ASC, id ASC`,
+		orgID,
+		priceID,
+	).Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
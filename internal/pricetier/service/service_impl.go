@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/money"
 	"github.com/smallbiznis/railzway/internal/orgcontext"
 	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
 	pricetierdomain "github.com/smallbiznis/railzway/internal/pricetier/domain"
@@ -64,17 +65,22 @@ func (s *Service) Create(ctx context.Context, req pricetierdomain.CreateRequest)
 
 	now := time.Now().UTC()
 	entity := &pricetierdomain.PriceTier{
-		ID:              s.genID.Generate(),
-		OrgID:           orgID,
-		PriceID:         priceID,
-		TierMode:        req.TierMode,
-		StartQuantity:   req.StartQuantity,
-		EndQuantity:     req.EndQuantity,
-		UnitAmountCents: req.UnitAmountCents,
-		FlatAmountCents: req.FlatAmountCents,
-		Unit:            unit,
-		CreatedAt:       now,
-		UpdatedAt:       now,
+		ID:                s.genID.Generate(),
+		OrgID:             orgID,
+		PriceID:           priceID,
+		TierMode:          req.TierMode,
+		StartQuantity:     req.StartQuantity,
+		EndQuantity:       req.EndQuantity,
+		UnitAmountCents:   req.UnitAmountCents,
+		UnitAmountDecimal: req.UnitAmountDecimal,
+		FlatAmountCents:   req.FlatAmountCents,
+		Unit:              unit,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if req.UnitAmountDecimal != nil {
+		cents := req.UnitAmountDecimal.Round(money.RoundHalfUp)
+		entity.UnitAmountCents = &cents
 	}
 	if req.Metadata != nil {
 		entity.Metadata = datatypes.JSONMap(req.Metadata)
@@ -149,17 +155,18 @@ func (s *Service) ensurePriceExists(ctx context.Context, orgID, priceID snowflak
 
 func (s *Service) toResponse(t *pricetierdomain.PriceTier) *pricetierdomain.Response {
 	return &pricetierdomain.Response{
-		ID:              t.ID.String(),
-		OrganizationID:  t.OrgID.String(),
-		PriceID:         t.PriceID.String(),
-		TierMode:        t.TierMode,
-		StartQuantity:   t.StartQuantity,
-		EndQuantity:     t.EndQuantity,
-		UnitAmountCents: t.UnitAmountCents,
-		FlatAmountCents: t.FlatAmountCents,
-		Unit:            t.Unit,
-		CreatedAt:       t.CreatedAt,
-		UpdatedAt:       t.UpdatedAt,
+		ID:                t.ID.String(),
+		OrganizationID:    t.OrgID.String(),
+		PriceID:           t.PriceID.String(),
+		TierMode:          t.TierMode,
+		StartQuantity:     t.StartQuantity,
+		EndQuantity:       t.EndQuantity,
+		UnitAmountCents:   t.UnitAmountCents,
+		UnitAmountDecimal: t.UnitAmountDecimal,
+		FlatAmountCents:   t.FlatAmountCents,
+		Unit:              t.Unit,
+		CreatedAt:         t.CreatedAt,
+		UpdatedAt:         t.UpdatedAt,
 	}
 }
 
@@ -198,11 +205,15 @@ func validateTierValues(req pricetierdomain.CreateRequest) error {
 		return pricetierdomain.ErrInvalidUnitAmount
 	}
 
+	if req.UnitAmountDecimal != nil && req.UnitAmountDecimal.Sign() < 0 {
+		return pricetierdomain.ErrInvalidUnitAmount
+	}
+
 	if req.FlatAmountCents != nil && *req.FlatAmountCents < 0 {
 		return pricetierdomain.ErrInvalidFlatAmount
 	}
 
-	if req.UnitAmountCents == nil && req.FlatAmountCents == nil {
+	if req.UnitAmountCents == nil && req.UnitAmountDecimal == nil && req.FlatAmountCents == nil {
 		return pricetierdomain.ErrInvalidUnitAmount
 	}
 
@@ -15,5 +15,13 @@ type FeatureAssignment struct {
 	FeatureType featuredomain.FeatureType
 	MeterID     *snowflake.ID
 	Active      bool
+	UsageLimit  *float64
+	Enforcement *string
 	CreatedAt   time.Time
 }
+
+// Limit caps the usage of a metered feature per billing cycle.
+type Limit struct {
+	UsageLimit  float64
+	Enforcement featuredomain.LimitEnforcement
+}
@@ -18,6 +18,16 @@ type ListRequest struct {
 type ReplaceRequest struct {
 	ProductID  string
 	FeatureIDs []string
+	// Limits optionally caps metered features of FeatureIDs per billing cycle.
+	Limits []FeatureLimit
+}
+
+// FeatureLimit limits a metered feature to UsageLimit per billing cycle.
+// Enforcement defaults to soft.
+type FeatureLimit struct {
+	FeatureID   string
+	UsageLimit  float64
+	Enforcement string
 }
 
 type ListForProductsRequest struct {
@@ -25,12 +35,14 @@ type ListForProductsRequest struct {
 }
 
 type Response struct {
-	ID          string  `json:"id"`
-	Code        string  `json:"code"`
-	Name        string  `json:"name"`
-	FeatureType string  `json:"feature_type"`
-	MeterID     *string `json:"meter_id,omitempty"`
-	Active      bool    `json:"active"`
+	ID          string   `json:"id"`
+	Code        string   `json:"code"`
+	Name        string   `json:"name"`
+	FeatureType string   `json:"feature_type"`
+	MeterID     *string  `json:"meter_id,omitempty"`
+	Active      bool     `json:"active"`
+	UsageLimit  *float64 `json:"usage_limit,omitempty"`
+	Enforcement *string  `json:"enforcement,omitempty"`
 }
 
 type Snapshot struct {
@@ -40,6 +52,8 @@ type Snapshot struct {
 	FeatureType string
 	MeterID     *string
 	Active      bool
+	UsageLimit  *float64
+	Enforcement *string
 }
 
 var (
@@ -51,4 +65,7 @@ var (
 	ErrFeatureNotFound     = errors.New("feature_not_found")
 	ErrFeatureInactive     = errors.New("feature_inactive")
 	ErrMeterNotFound       = errors.New("meter_not_found")
+	ErrInvalidUsageLimit   = errors.New("invalid_usage_limit")
+	ErrInvalidEnforcement  = errors.New("invalid_enforcement")
+	ErrLimitNotMetered     = errors.New("usage_limit_requires_metered_feature")
 )
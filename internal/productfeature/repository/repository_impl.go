@@ -18,7 +18,7 @@ func Provide() domain.Repository {
 func (r *repo) ListByProduct(ctx context.Context, db *gorm.DB, orgID, productID snowflake.ID) ([]domain.FeatureAssignment, error) {
 	var items []domain.FeatureAssignment
 	err := db.WithContext(ctx).Raw(
-		`SELECT pf.product_id, pf.feature_id, pf.created_at,
+		`SELECT pf.product_id, pf.feature_id, pf.usage_limit, pf.enforcement, pf.created_at,
 				f.code, f.name, f.feature_type, f.meter_id, f.active
 		   FROM product_features pf
 		   JOIN products p ON p.id = pf.product_id AND p.org_id = ?
@@ -41,7 +41,7 @@ func (r *repo) ListByProducts(ctx context.Context, db *gorm.DB, orgID snowflake.
 	}
 	var items []domain.FeatureAssignment
 	err := db.WithContext(ctx).Raw(
-		`SELECT pf.product_id, pf.feature_id, pf.created_at,
+		`SELECT pf.product_id, pf.feature_id, pf.usage_limit, pf.enforcement, pf.created_at,
 				f.code, f.name, f.feature_type, f.meter_id, f.active
 		   FROM product_features pf
 		   JOIN products p ON p.id = pf.product_id AND p.org_id = ?
@@ -58,7 +58,7 @@ func (r *repo) ListByProducts(ctx context.Context, db *gorm.DB, orgID snowflake.
 	return items, nil
 }
 
-func (r *repo) Replace(ctx context.Context, db *gorm.DB, productID snowflake.ID, featureIDs []snowflake.ID, now time.Time) error {
+func (r *repo) Replace(ctx context.Context, db *gorm.DB, productID snowflake.ID, featureIDs []snowflake.ID, limits map[snowflake.ID]domain.Limit, now time.Time) error {
 	if err := db.WithContext(ctx).Exec(
 		`DELETE FROM product_features WHERE product_id = ?`,
 		productID,
@@ -67,11 +67,21 @@ func (r *repo) Replace(ctx context.Context, db *gorm.DB, productID snowflake.ID,
 	}
 
 	for _, featureID := range featureIDs {
+		var usageLimit *float64
+		var enforcement *string
+		if limit, ok := limits[featureID]; ok {
+			value := limit.UsageLimit
+			mode := string(limit.Enforcement)
+			usageLimit = &value
+			enforcement = &mode
+		}
 		if err := db.WithContext(ctx).Exec(
-			`INSERT INTO product_features (product_id, feature_id, created_at)
-			 VALUES (?, ?, ?)`,
+			`INSERT INTO product_features (product_id, feature_id, usage_limit, enforcement, created_at)
+			 VALUES (?, ?, ?, ?, ?)`,
 			productID,
 			featureID,
+			usageLimit,
+			enforcement,
 			now,
 		).Error; err != nil {
 			return err
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"math"
 	"strings"
 	"time"
 
@@ -103,13 +104,18 @@ func (s *Service) Replace(ctx context.Context, req productfeaturedomain.ReplaceR
 		return nil, err
 	}
 
-	if err := s.validateFeatures(ctx, orgID, featureIDs); err != nil {
+	limits, err := parseLimits(req.Limits, featureIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateFeatures(ctx, orgID, featureIDs, limits); err != nil {
 		return nil, err
 	}
 
 	now := time.Now().UTC()
 	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		return s.repo.Replace(ctx, tx, productID, featureIDs, now)
+		return s.repo.Replace(ctx, tx, productID, featureIDs, limits, now)
 	}); err != nil {
 		return nil, err
 	}
@@ -196,7 +202,52 @@ func parseFeatureIDs(values []string) ([]snowflake.ID, error) {
 	return ids, nil
 }
 
-func (s *Service) validateFeatures(ctx context.Context, orgID snowflake.ID, featureIDs []snowflake.ID) error {
+// parseLimits keys the requested limits by feature. Limits may only target
+// features that are being assigned.
+func parseLimits(values []productfeaturedomain.FeatureLimit, featureIDs []snowflake.ID) (map[snowflake.ID]productfeaturedomain.Limit, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	assigned := make(map[snowflake.ID]struct{}, len(featureIDs))
+	for _, id := range featureIDs {
+		assigned[id] = struct{}{}
+	}
+
+	limits := make(map[snowflake.ID]productfeaturedomain.Limit, len(values))
+	for _, value := range values {
+		featureID, err := snowflake.ParseString(strings.TrimSpace(value.FeatureID))
+		if err != nil {
+			return nil, productfeaturedomain.ErrInvalidFeatureID
+		}
+		if _, ok := assigned[featureID]; !ok {
+			return nil, productfeaturedomain.ErrInvalidFeatureID
+		}
+		if _, ok := limits[featureID]; ok {
+			return nil, productfeaturedomain.ErrInvalidUsageLimit
+		}
+		if value.UsageLimit < 0 || math.IsNaN(value.UsageLimit) || math.IsInf(value.UsageLimit, 0) {
+			return nil, productfeaturedomain.ErrInvalidUsageLimit
+		}
+
+		enforcement := featuredomain.LimitEnforcement(strings.ToLower(strings.TrimSpace(value.Enforcement)))
+		switch enforcement {
+		case "":
+			enforcement = featuredomain.LimitEnforcementSoft
+		case featuredomain.LimitEnforcementSoft, featuredomain.LimitEnforcementHard:
+		default:
+			return nil, productfeaturedomain.ErrInvalidEnforcement
+		}
+
+		limits[featureID] = productfeaturedomain.Limit{
+			UsageLimit:  value.UsageLimit,
+			Enforcement: enforcement,
+		}
+	}
+	return limits, nil
+}
+
+func (s *Service) validateFeatures(ctx context.Context, orgID snowflake.ID, featureIDs []snowflake.ID, limits map[snowflake.ID]productfeaturedomain.Limit) error {
 	if len(featureIDs) == 0 {
 		return nil
 	}
@@ -215,6 +266,9 @@ func (s *Service) validateFeatures(ctx context.Context, orgID snowflake.ID, feat
 		}
 
 		if item.Type != featuredomain.FeatureTypeMetered {
+			if _, ok := limits[item.ID]; ok {
+				return productfeaturedomain.ErrLimitNotMetered
+			}
 			continue
 		}
 
@@ -254,6 +308,8 @@ func toResponse(item productfeaturedomain.FeatureAssignment) productfeaturedomai
 		FeatureType: string(item.FeatureType),
 		MeterID:     meterID,
 		Active:      item.Active,
+		UsageLimit:  item.UsageLimit,
+		Enforcement: item.Enforcement,
 	}
 }
 
@@ -271,5 +327,7 @@ func toSnapshot(item productfeaturedomain.FeatureAssignment) productfeaturedomai
 		FeatureType: string(item.FeatureType),
 		MeterID:     meterID,
 		Active:      item.Active,
+		UsageLimit:  item.UsageLimit,
+		Enforcement: item.Enforcement,
 	}
 }
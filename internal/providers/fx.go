@@ -4,6 +4,7 @@ import (
 	"github.com/smallbiznis/railzway/internal/payment"
 	"github.com/smallbiznis/railzway/internal/providers/email"
 	"github.com/smallbiznis/railzway/internal/providers/pdf"
+	"github.com/smallbiznis/railzway/internal/providers/slack"
 	"go.uber.org/fx"
 )
 
@@ -11,4 +12,5 @@ var Module = fx.Module("providers",
 	email.Module,
 	payment.Module,
 	pdf.Module,
+	slack.Module,
 )
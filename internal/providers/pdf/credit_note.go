@@ -0,0 +1,129 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/johnfercher/maroto/v2"
+	"github.com/johnfercher/maroto/v2/pkg/components/col"
+	"github.com/johnfercher/maroto/v2/pkg/components/text"
+	"github.com/johnfercher/maroto/v2/pkg/config"
+	"github.com/johnfercher/maroto/v2/pkg/consts/align"
+	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
+	"github.com/johnfercher/maroto/v2/pkg/props"
+)
+
+type CreditNoteData struct {
+	OrgName          string
+	CreditNoteNumber string
+	InvoiceNumber    string
+	IssueDate        string
+	Reason           string
+	Memo             string
+
+	CustomerName  string
+	CustomerEmail string
+
+	Items []InvoiceItem
+
+	Subtotal string
+	Tax      string
+	Total    string
+}
+
+func (p *PDFProvider) GenerateCreditNote(ctx context.Context, data interface{}) (io.Reader, error) {
+	note, ok := data.(CreditNoteData)
+	if !ok {
+		return nil, fmt.Errorf("invalid data type for credit note PDF")
+	}
+
+	cfg := config.NewBuilder().
+		WithPageNumber(props.PageNumber{
+			Pattern: "Page {current} of {total}",
+			Place:   props.RightBottom,
+		}).
+		Build()
+
+	m := maroto.New(cfg)
+
+	m.AddRow(10,
+		text.NewCol(12, "Credit Note", props.Text{
+			Size:  20,
+			Style: fontstyle.Bold,
+			Align: align.Left,
+		}),
+	)
+
+	// Credit Note Meta
+	m.AddRow(20,
+		col.New(6).Add(
+			text.New("Credit note number: "+note.CreditNoteNumber, props.Text{Top: 0}),
+			text.New("Original invoice: "+note.InvoiceNumber, props.Text{Top: 4}),
+			text.New("Date of issue: "+note.IssueDate, props.Text{Top: 8}),
+			text.New("Reason: "+note.Reason, props.Text{Top: 12}),
+		),
+		col.New(6),
+	)
+
+	m.AddRow(25,
+		col.New(6).Add(
+			text.New(note.OrgName, props.Text{Style: fontstyle.Bold}),
+		),
+		col.New(6).Add(
+			text.New("Credit to", props.Text{Style: fontstyle.Bold}),
+			text.New(note.CustomerName, props.Text{Top: 5}),
+			text.New(note.CustomerEmail, props.Text{Top: 9}),
+		),
+	)
+
+	// Table Header
+	m.AddRow(10,
+		text.NewCol(6, "Description", props.Text{Style: fontstyle.Bold, Size: 9}),
+		text.NewCol(2, "Qty", props.Text{Style: fontstyle.Bold, Size: 9, Align: align.Right}),
+		text.NewCol(2, "Unit price", props.Text{Style: fontstyle.Bold, Size: 9, Align: align.Right}),
+		text.NewCol(2, "Amount", props.Text{Style: fontstyle.Bold, Size: 9, Align: align.Right}),
+	)
+
+	for _, item := range note.Items {
+		m.AddRow(15,
+			text.NewCol(6, item.Description, props.Text{Size: 9}),
+			text.NewCol(2, fmt.Sprintf("%d", item.Qty), props.Text{Size: 9, Align: align.Right}),
+			text.NewCol(2, item.UnitPrice, props.Text{Size: 9, Align: align.Right}),
+			text.NewCol(2, item.Amount, props.Text{Size: 9, Align: align.Right}),
+		)
+	}
+
+	// Footer Totals
+	m.AddRow(10,
+		col.New(8),
+		text.NewCol(2, "Subtotal", props.Text{Size: 9}),
+		text.NewCol(2, note.Subtotal, props.Text{Size: 9, Align: align.Right}),
+	)
+	if note.Tax != "" {
+		m.AddRow(10,
+			col.New(8),
+			text.NewCol(2, "Tax", props.Text{Size: 9}),
+			text.NewCol(2, note.Tax, props.Text{Size: 9, Align: align.Right}),
+		)
+	}
+	m.AddRow(10,
+		col.New(8),
+		text.NewCol(2, "Total credited", props.Text{Style: fontstyle.Bold, Size: 9}),
+		text.NewCol(2, note.Total, props.Text{Style: fontstyle.Bold, Size: 9, Align: align.Right}),
+	)
+
+	if note.Memo != "" {
+		m.AddRow(20,
+			text.NewCol(12, note.Memo, props.Text{Size: 9, Top: 5}),
+		)
+	}
+
+	doc, err := m.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(doc.GetBytes()), nil
+}
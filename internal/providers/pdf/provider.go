@@ -10,6 +10,7 @@ import (
 type Provider interface {
 	GenerateInvoice(ctx context.Context, data interface{}) (io.Reader, error)
 	GenerateReceipt(ctx context.Context, data interface{}) (io.Reader, error)
+	GenerateCreditNote(ctx context.Context, data interface{}) (io.Reader, error)
 }
 
 type NoOpProvider struct{}
@@ -21,3 +22,7 @@ func (p *NoOpProvider) GenerateInvoice(ctx context.Context, data interface{}) (i
 func (p *NoOpProvider) GenerateReceipt(ctx context.Context, data interface{}) (io.Reader, error) {
 	return nil, nil
 }
+
+func (p *NoOpProvider) GenerateCreditNote(ctx context.Context, data interface{}) (io.Reader, error) {
+	return nil, nil
+}
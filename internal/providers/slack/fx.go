@@ -0,0 +1,21 @@
+package slack
+
+import (
+	"strings"
+
+	"github.com/smallbiznis/railzway/internal/config"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("providers.slack",
+	fx.Provide(NewFromConfig),
+)
+
+// NewFromConfig returns a no-op provider when no bot token is configured.
+func NewFromConfig(cfg config.Config) Provider {
+	token := strings.TrimSpace(cfg.Slack.BotToken)
+	if token == "" {
+		return &NoOpProvider{}
+	}
+	return NewWebAPI(token)
+}
@@ -0,0 +1,66 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const postMessageURL = "https://slack.com/api/chat.postMessage"
+
+// WebAPIProvider posts messages with a bot token through the Slack Web API.
+type WebAPIProvider struct {
+	token  string
+	url    string
+	client *http.Client
+}
+
+func NewWebAPI(token string) *WebAPIProvider {
+	return &WebAPIProvider{
+		token:  token,
+		url:    postMessageURL,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *WebAPIProvider) PostMessage(ctx context.Context, channelID string, message string) error {
+	body, err := json.Marshal(map[string]string{
+		"channel": channelID,
+		"text":    message,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack: unexpected status %d", resp.StatusCode)
+	}
+	// Slack reports API failures with a 200 status and ok=false.
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if !result.OK {
+		return fmt.Errorf("slack: %s", result.Error)
+	}
+	return nil
+}
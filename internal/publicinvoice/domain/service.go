@@ -51,6 +51,7 @@ type PublicInvoiceView struct {
 	BillToName     string              `json:"bill_to_name"`
 	BillToEmail    string              `json:"bill_to_email"`
 	Currency       string              `json:"currency"`
+	MinorUnit      int32               `json:"minor_unit"`
 	AmountDue      int64               `json:"amount_due"`
 	SubtotalAmount int64               `json:"subtotal_amount"`
 	TaxAmount      int64               `json:"tax_amount"`
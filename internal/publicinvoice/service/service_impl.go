@@ -15,6 +15,7 @@ import (
 	"github.com/bwmarrin/snowflake"
 	"github.com/smallbiznis/railzway/internal/config"
 	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
+	"github.com/smallbiznis/railzway/internal/money"
 	paymentdomain "github.com/smallbiznis/railzway/internal/payment/domain"
 	paymentproviderdomain "github.com/smallbiznis/railzway/internal/providers/payment/domain"
 	publicinvoicedomain "github.com/smallbiznis/railzway/internal/publicinvoice/domain"
@@ -552,6 +553,7 @@ func (s *Service) buildPublicInvoiceView(
 		BillToName:     row.CustomerName,
 		BillToEmail:    row.CustomerEmail,
 		Currency:       row.Currency,
+		MinorUnit:      money.MinorUnits(row.Currency),
 		AmountDue:      amountDue,
 		SubtotalAmount: subtotalAmount,
 		TaxAmount:      taxAmount,
@@ -13,6 +13,7 @@ const tokenBucketScript = `
 local rate = tonumber(ARGV[1])
 local burst = tonumber(ARGV[2])
 local ttl = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
 
 local nowData = redis.call("TIME")
 local now = (nowData[1] * 1000) + math.floor(nowData[2] / 1000)
@@ -35,9 +36,9 @@ else
 end
 
 local allowed = 0
-if tokens >= 1 then
+if tokens >= cost then
   allowed = 1
-  tokens = tokens - 1
+  tokens = tokens - cost
 end
 
 redis.call("HMSET", KEYS[1], "tokens", tokens, "ts", ts)
@@ -71,6 +72,12 @@ func NewTokenBucket(client *redis.Client) *TokenBucket {
 }
 
 func (t *TokenBucket) Allow(ctx context.Context, key string, rate float64, burst int) (*RateLimitResult, error) {
+	return t.AllowN(ctx, key, rate, burst, 1)
+}
+
+// AllowN takes cost tokens at once. Costs above burst are capped so a large
+// request can still pass once the bucket is full.
+func (t *TokenBucket) AllowN(ctx context.Context, key string, rate float64, burst int, cost int) (*RateLimitResult, error) {
 	if t == nil || t.client == nil {
 		return &RateLimitResult{Allowed: false}, errors.New("rate limiter not configured")
 	}
@@ -83,6 +90,12 @@ func (t *TokenBucket) Allow(ctx context.Context, key string, rate float64, burst
 	if burst <= 0 {
 		return &RateLimitResult{Allowed: false}, errors.New("rate limiter burst must be positive")
 	}
+	if cost < 1 {
+		cost = 1
+	}
+	if cost > burst {
+		cost = burst
+	}
 
 	ttl := defaultBucketTTL(rate, burst)
 	
@@ -94,6 +107,7 @@ func (t *TokenBucket) Allow(ctx context.Context, key string, rate float64, burst
 		rate,
 		burst,
 		int64(ttl/time.Millisecond),
+		cost,
 	).Slice()
 	
 	if err != nil {
@@ -136,8 +150,8 @@ func (t *TokenBucket) Allow(ctx context.Context, key string, rate float64, burst
 	
 	retryAfter := time.Duration(0)
 	if !allowed {
-		// Calculate time to refill 1 token: (1 - tokens) / rate
-		needed := 1.0 - remainingTokens
+		// Calculate time to refill the cost: (cost - tokens) / rate
+		needed := float64(cost) - remainingTokens
 		if needed > 0 {
 			seconds := needed / rate
 			retryAfter = time.Duration(seconds * float64(time.Second))
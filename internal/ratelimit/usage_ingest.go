@@ -70,17 +70,27 @@ func (l *UsageIngestLimiter) Enabled() bool {
 }
 
 func (l *UsageIngestLimiter) AllowOrg(ctx context.Context, orgID string) (*RateLimitResult, error) {
+	return l.AllowOrgN(ctx, orgID, 1)
+}
+
+// AllowOrgN charges cost tokens against the org bucket, e.g. for batch ingestion.
+func (l *UsageIngestLimiter) AllowOrgN(ctx context.Context, orgID string, cost int) (*RateLimitResult, error) {
 	if !l.Enabled() {
 		return &RateLimitResult{Allowed: true}, nil
 	}
-	return l.bucket.Allow(ctx, fmt.Sprintf(keyUsageIngestOrg, strings.TrimSpace(orgID)), l.orgRate, l.orgBurst)
+	return l.bucket.AllowN(ctx, fmt.Sprintf(keyUsageIngestOrg, strings.TrimSpace(orgID)), l.orgRate, l.orgBurst, cost)
 }
 
 func (l *UsageIngestLimiter) AllowEndpoint(ctx context.Context, orgID string) (*RateLimitResult, error) {
+	return l.AllowEndpointN(ctx, orgID, 1)
+}
+
+// AllowEndpointN charges cost tokens against the endpoint bucket.
+func (l *UsageIngestLimiter) AllowEndpointN(ctx context.Context, orgID string, cost int) (*RateLimitResult, error) {
 	if !l.Enabled() {
 		return &RateLimitResult{Allowed: true}, nil
 	}
-	return l.bucket.Allow(ctx, fmt.Sprintf(keyUsageIngestEndpoint, strings.TrimSpace(orgID)), l.endpointRate, l.endpointBurst)
+	return l.bucket.AllowN(ctx, fmt.Sprintf(keyUsageIngestEndpoint, strings.TrimSpace(orgID)), l.endpointRate, l.endpointBurst, cost)
 }
 
 func (l *UsageIngestLimiter) TryLockCustomerMeter(ctx context.Context, orgID, customerID, meterCode string) (string, bool, error) {
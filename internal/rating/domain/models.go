@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/money"
 )
 
 // RatingResult captures the priced usage output for a billing cycle.
@@ -18,13 +19,17 @@ type RatingResult struct {
 	MeterID        *snowflake.ID `gorm:"index"`
 	Quantity       float64       `gorm:"not null"`
 	UnitPrice      int64         `gorm:"not null"`
-	Amount         int64         `gorm:"not null"`
-	Currency       string        `gorm:"type:text;not null"`
-	PeriodStart    time.Time     `gorm:"not null"`
-	PeriodEnd      time.Time     `gorm:"not null"`
-	Source         string        `gorm:"type:text;not null"`
-	Checksum       string        `gorm:"type:text;not null;uniqueIndex"`
-	CreatedAt      time.Time     `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	// UnitPriceDecimal holds the exact unit price when it has a fraction of
+	// a minor unit; UnitPrice then carries it rounded.
+	UnitPriceDecimal *money.Decimal `gorm:"type:numeric"`
+	Amount           int64          `gorm:"not null"`
+	Currency         string         `gorm:"type:text;not null"`
+	PeriodStart      time.Time      `gorm:"not null"`
+	PeriodEnd        time.Time      `gorm:"not null"`
+	Source           string         `gorm:"type:text;not null"`
+	Checksum         string         `gorm:"type:text;not null;uniqueIndex"`
+	AdjustmentID     *snowflake.ID  `gorm:"index"`
+	CreatedAt        time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP"`
 }
 
 // TableName sets the database table name.
@@ -7,16 +7,30 @@ import (
 
 type Service interface {
 	RunRating(context.Context, string) error
+	// PreviewRating rates an OPEN billing cycle without persisting results.
+	PreviewRating(context.Context, string) ([]RatingResult, error)
+	// RerateCycle rates a CLOSED billing cycle against its current usage
+	// without persisting results, so corrections can be priced as deltas.
+	RerateCycle(context.Context, string) ([]RatingResult, error)
 }
 
 var (
-	ErrInvalidBillingCycle    = errors.New("invalid_billing_cycle")
-	ErrBillingCycleNotFound   = errors.New("billing_cycle_not_found")
-	ErrBillingCycleNotClosing = errors.New("billing_cycle_not_closing")
-	ErrMissingUsage           = errors.New("missing_usage")
-	ErrMissingPriceAmount     = errors.New("missing_price_amount")
-	ErrMissingMeter           = errors.New("missing_meter")
-	ErrInvalidQuantity        = errors.New("invalid_quantity")
-	ErrNoSubscriptionItems    = errors.New("no_subscription_items")
-	ErrSubscriptionNotFound   = errors.New("subscription_not_found")
+	ErrInvalidBillingCycle     = errors.New("invalid_billing_cycle")
+	ErrBillingCycleNotFound    = errors.New("billing_cycle_not_found")
+	ErrBillingCycleNotClosing  = errors.New("billing_cycle_not_closing")
+	ErrBillingCycleNotOpen     = errors.New("billing_cycle_not_open")
+	ErrBillingCycleNotClosed   = errors.New("billing_cycle_not_closed")
+	ErrMissingUsage            = errors.New("missing_usage")
+	ErrMissingPriceAmount      = errors.New("missing_price_amount")
+	ErrPriceNotFound           = errors.New("price_not_found")
+	ErrMissingMeter            = errors.New("missing_meter")
+	ErrInvalidQuantity         = errors.New("invalid_quantity")
+	ErrNoSubscriptionItems     = errors.New("no_subscription_items")
+	ErrSubscriptionNotFound    = errors.New("subscription_not_found")
+	ErrMissingPriceTiers       = errors.New("missing_price_tiers")
+	ErrInvalidPriceTiers       = errors.New("invalid_price_tiers")
+	ErrTierQuantityExceeded    = errors.New("tier_quantity_exceeded")
+	ErrUnsupportedPricingModel = errors.New("unsupported_pricing_model")
+	ErrUnsupportedAggregation  = errors.New("unsupported_aggregation")
+	ErrCurrencyUnresolved      = errors.New("currency_unresolved")
 )
@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"gorm.io/gorm"
+)
+
+const (
+	// advanceSource marks the rows that charge a flat item for the period
+	// after the cycle, so the fee lands on the invoice issued when that
+	// period starts, next to the usage of the period that just ended. The
+	// first period is charged by the zero-length cycle opened at activation.
+	advanceSource = "advance"
+	// advanceAdjustmentSource marks the rows that settle an advance charge
+	// against what the period actually billed, e.g. the unused time after a
+	// cancellation or a plan change.
+	advanceAdjustmentSource = "advance_adjustment"
+)
+
+// advanceTotal sums the rows of one price.
+type advanceTotal struct {
+	FeatureCode string
+	Quantity    float64
+	UnitPrice   int64
+	Amount      int64
+	Currency    string
+}
+
+func (t *advanceTotal) add(featureCode string, quantity float64, unitPrice, amount int64, currency string) {
+	if t.Currency == "" {
+		t.FeatureCode = featureCode
+		t.UnitPrice = unitPrice
+		t.Currency = currency
+	}
+	t.Quantity += quantity
+	t.Amount += amount
+}
+
+// advanceBilling settles, within one cycle, the flat items billed in advance.
+// Their rows are held back while the cycle is rated and compared with what
+// was charged in advance for the cycle's period; only the difference is
+// billed. A price nothing was charged in advance for, like on the first
+// cycle of a subscription, keeps its rows.
+type advanceBilling struct {
+	prices map[snowflake.ID]struct{}
+	held   map[snowflake.ID][]ratingdomain.RatingResult
+	billed map[snowflake.ID]*advanceTotal
+}
+
+func (a *advanceBilling) hold(emit ratingSink) ratingSink {
+	return func(result ratingdomain.RatingResult) error {
+		if result.MeterID == nil {
+			if _, ok := a.prices[result.PriceID]; ok {
+				a.held[result.PriceID] = append(a.held[result.PriceID], result)
+				return nil
+			}
+		}
+		return emit(result)
+	}
+}
+
+// loadAdvanceBilling collects the advance prices of the items billed in the
+// cycle, including the items replaced by plan changes, and the charges billed
+// in advance for the cycle's period.
+func (s *Service) loadAdvanceBilling(
+	ctx context.Context,
+	tx *gorm.DB,
+	cycle *billingCycleRow,
+	items []subscriptionItemRow,
+	changes []subscriptiondomain.SubscriptionPlanChange,
+) (*advanceBilling, error) {
+	billing := &advanceBilling{
+		prices: make(map[snowflake.ID]struct{}),
+		held:   make(map[snowflake.ID][]ratingdomain.RatingResult),
+		billed: make(map[snowflake.ID]*advanceTotal),
+	}
+
+	candidates := append([]subscriptionItemRow(nil), items...)
+	for _, change := range changes {
+		previous, err := decodePlanItems(cycle, change.PreviousItems)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, previous...)
+	}
+	for _, item := range candidates {
+		timing, err := s.billingTiming(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+		if timing == pricedomain.InAdvance {
+			billing.prices[item.PriceID] = struct{}{}
+		}
+	}
+
+	var rows []struct {
+		PriceID     snowflake.ID
+		FeatureCode string
+		Quantity    float64
+		UnitPrice   int64
+		Amount      int64
+		Currency    string
+	}
+	err := tx.WithContext(ctx).Raw(
+		`SELECT price_id, feature_code, quantity, unit_price, amount, currency
+		 FROM rating_results
+		 WHERE org_id = ? AND subscription_id = ? AND source = ?
+		   AND billing_cycle_id <> ? AND adjustment_id IS NULL
+		   AND period_start >= ? AND period_start < ?
+		 ORDER BY period_start ASC, id ASC`,
+		cycle.OrgID,
+		cycle.SubscriptionID,
+		advanceSource,
+		cycle.ID,
+		cycle.PeriodStart,
+		cycle.PeriodEnd,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		total, ok := billing.billed[row.PriceID]
+		if !ok {
+			total = &advanceTotal{}
+			billing.billed[row.PriceID] = total
+		}
+		total.add(row.FeatureCode, row.Quantity, row.UnitPrice, row.Amount, row.Currency)
+		billing.prices[row.PriceID] = struct{}{}
+	}
+
+	return billing, nil
+}
+
+// settleAdvance emits the held rows of prices nothing was billed in advance
+// for, and one adjustment row for every price whose advance charge differs
+// from what the cycle billed.
+func (s *Service) settleAdvance(cycle *billingCycleRow, billing *advanceBilling, now time.Time, emit ratingSink) error {
+	priceIDs := make([]snowflake.ID, 0, len(billing.prices))
+	for priceID := range billing.prices {
+		priceIDs = append(priceIDs, priceID)
+	}
+	sort.Slice(priceIDs, func(i, j int) bool { return priceIDs[i] < priceIDs[j] })
+
+	for _, priceID := range priceIDs {
+		billed, ok := billing.billed[priceID]
+		if !ok {
+			for _, result := range billing.held[priceID] {
+				if err := emit(result); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		var used advanceTotal
+		for _, result := range billing.held[priceID] {
+			used.add(result.FeatureCode, result.Quantity, result.UnitPrice, result.Amount, result.Currency)
+		}
+		amount := used.Amount - billed.Amount
+		if amount == 0 {
+			continue
+		}
+
+		base := buildChecksum(cycle.ID, cycle.SubscriptionID, priceID, nil, billed.FeatureCode, cycle.PeriodStart, cycle.PeriodEnd)
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s", base, advanceAdjustmentSource)))
+		if err := emit(ratingdomain.RatingResult{
+			ID:             s.genID.Generate(),
+			OrgID:          cycle.OrgID,
+			SubscriptionID: cycle.SubscriptionID,
+			BillingCycleID: cycle.ID,
+			PriceID:        priceID,
+			FeatureCode:    billed.FeatureCode,
+			Quantity:       used.Quantity - billed.Quantity,
+			UnitPrice:      billed.UnitPrice,
+			Amount:         amount,
+			Currency:       billed.Currency,
+			PeriodStart:    cycle.PeriodStart,
+			PeriodEnd:      cycle.PeriodEnd,
+			Source:         advanceAdjustmentSource,
+			Checksum:       hex.EncodeToString(sum[:]),
+			CreatedAt:      now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rateAdvance charges the flat items billed in advance for the period that
+// follows the cycle. The charge is rated the way the next cycle will rate the
+// item, so a period that runs as planned settles to zero. Nothing is charged
+// once the subscription stops billing at the cycle end.
+func (s *Service) rateAdvance(
+	ctx context.Context,
+	tx *gorm.DB,
+	cycle *billingCycleRow,
+	subscription *subscriptiondomain.Subscription,
+	items []subscriptionItemRow,
+	entitlements []subscriptiondomain.SubscriptionEntitlement,
+	now time.Time,
+	emit ratingSink,
+) error {
+	if !billsAfter(subscription, cycle.PeriodEnd) {
+		return nil
+	}
+
+	next, err := s.nextCycle(ctx, tx, cycle, subscription)
+	if err != nil || next == nil {
+		return err
+	}
+	cycleDuration, err := s.prorationBasis(ctx, tx, next, subscription)
+	if err != nil {
+		return err
+	}
+	if cycleDuration <= 0 {
+		return ratingdomain.ErrInvalidBillingCycle
+	}
+
+	start := billableStart(subscription, next.PeriodStart)
+	if !next.PeriodEnd.After(start) {
+		return nil
+	}
+	factor := prorationFactor(start, next.PeriodEnd, cycleDuration)
+
+	for _, item := range items {
+		timing, err := s.billingTiming(ctx, item)
+		if err != nil {
+			return err
+		}
+		if timing != pricedomain.InAdvance {
+			continue
+		}
+		featureCode, _, err := s.resolveEntitlementWithWindow(ctx, tx, item, entitlements)
+		if err != nil {
+			return fmt.Errorf("rating failed for item %s: %w", item.ID, err)
+		}
+		priceAmount, err := s.resolvePriceAmountAt(ctx, tx, cycle.OrgID, item.PriceID, nil, cycle.Currency, start)
+		if err != nil {
+			return err
+		}
+		if priceAmount == nil {
+			return ratingdomain.ErrMissingPriceAmount
+		}
+
+		quantity := factor * item.units()
+		base := buildChecksum(cycle.ID, cycle.SubscriptionID, item.PriceID, nil, featureCode, start, next.PeriodEnd)
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s", base, advanceSource)))
+		if err := emit(ratingdomain.RatingResult{
+			ID:               s.genID.Generate(),
+			OrgID:            cycle.OrgID,
+			SubscriptionID:   cycle.SubscriptionID,
+			BillingCycleID:   cycle.ID,
+			PriceID:          item.PriceID,
+			FeatureCode:      featureCode,
+			Quantity:         quantity,
+			UnitPrice:        priceAmount.UnitAmountCents,
+			UnitPriceDecimal: fractionalUnitPrice(priceAmount.UnitAmount()),
+			Amount:           lineAmount(quantity, priceAmount.UnitAmount(), cycle.RoundingMode),
+			Currency:         priceAmount.Currency,
+			PeriodStart:      start,
+			PeriodEnd:        next.PeriodEnd,
+			Source:           advanceSource,
+			Checksum:         hex.EncodeToString(sum[:]),
+			CreatedAt:        now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rateActivation rates the zero-length cycle opened at activation. It only
+// charges the first period of the items billed in advance, so that charge is
+// invoiced when the subscription starts rather than when its first cycle ends.
+func (s *Service) rateActivation(
+	ctx context.Context,
+	tx *gorm.DB,
+	cycle *billingCycleRow,
+	subscription *subscriptiondomain.Subscription,
+	items []subscriptionItemRow,
+	emit ratingSink,
+) error {
+	next, err := s.nextCycle(ctx, tx, cycle, subscription)
+	if err != nil || next == nil {
+		return err
+	}
+	entitlements, err := s.loadEntitlements(ctx, tx, cycle.OrgID, cycle.SubscriptionID, next.PeriodStart, next.PeriodEnd)
+	if err != nil {
+		return err
+	}
+	return s.rateAdvance(ctx, tx, cycle, subscription, items, entitlements, time.Now().UTC(), emit)
+}
+
+// billingTiming resolves when a subscription item is billed. The item's
+// override wins over the price; metered items are always billed in arrears.
+func (s *Service) billingTiming(ctx context.Context, item subscriptionItemRow) (pricedomain.BillingTiming, error) {
+	if item.MeterID != nil {
+		return pricedomain.InArrears, nil
+	}
+	if item.BillingTiming != nil && *item.BillingTiming != "" {
+		return pricedomain.BillingTiming(*item.BillingTiming), nil
+	}
+	price, err := s.loadPrice(ctx, item)
+	if err != nil {
+		return "", err
+	}
+	if price.BillingTiming == "" {
+		return pricedomain.InArrears, nil
+	}
+	return price.BillingTiming, nil
+}
+
+// nextCycle returns the period the scheduler opens after the cycle, or nil
+// when the subscription has no billing cycle to follow it.
+func (s *Service) nextCycle(ctx context.Context, tx *gorm.DB, cycle *billingCycleRow, subscription *subscriptiondomain.Subscription) (*billingCycleRow, error) {
+	spec, ok := billingcycledomain.ParseCycleType(subscription.BillingCycleType)
+	if !ok || subscription.ActivatedAt == nil {
+		return nil, nil
+	}
+
+	start := cycle.PeriodEnd
+	end := spec.NextPeriodEnd(*subscription.ActivatedAt, start)
+	if subscription.BillingAnchorDay != nil && spec.SupportsAnchorDay() {
+		loc, err := billingcycledomain.LoadBillingLocation(ctx, tx, cycle.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		end = spec.NextAnchoredPeriodEnd(start, int(*subscription.BillingAnchorDay), loc)
+	}
+	if !end.After(start) {
+		return nil, nil
+	}
+	return &billingCycleRow{
+		OrgID:          cycle.OrgID,
+		SubscriptionID: cycle.SubscriptionID,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		Currency:       cycle.Currency,
+		RoundingMode:   cycle.RoundingMode,
+	}, nil
+}
+
+// billsAfter reports whether the subscription is still billed once at has
+// passed.
+func billsAfter(subscription *subscriptiondomain.Subscription, at time.Time) bool {
+	if subscription.Status != subscriptiondomain.SubscriptionStatusActive &&
+		subscription.Status != subscriptiondomain.SubscriptionStatusPastDue {
+		return false
+	}
+	if subscription.CanceledAt != nil && !subscription.CanceledAt.After(at) {
+		return false
+	}
+	if subscription.EndedAt != nil && !subscription.EndedAt.After(at) {
+		return false
+	}
+	return true
+}
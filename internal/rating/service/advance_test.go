@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestAdvanceBilling(t *testing.T) {
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// seed creates a monthly subscription on a $100 flat price. priceTiming
+	// is set on the price, itemTiming overrides it on the item.
+	seed := func(t *testing.T, priceTiming pricedomain.BillingTiming, itemTiming *string) (*gorm.DB, ratingdomain.Service, *snowflake.Node, snowflake.ID, snowflake.ID) {
+		db, svc, node := setupProrationTest(t)
+		orgID := node.Generate()
+		subID := node.Generate()
+		productID := node.Generate()
+		priceID := node.Generate()
+
+		priceRepo := svc.(*Service).priceRepo.(*priceRepoStub)
+		priceAmounts := svc.(*Service).priceAmountRepo.(*priceAmountStub)
+		priceRepo.Prices[priceID.String()] = pricedomain.Price{ID: priceID, ProductID: productID, BillingTiming: priceTiming}
+		priceAmounts.Amounts[priceID.String()] = priceamountdomain.PriceAmount{PriceID: priceID, UnitAmountCents: 10000, Currency: "USD"}
+
+		activatedAt := jan
+		require.NoError(t, db.Create(&subscriptiondomain.Subscription{
+			ID:               subID,
+			OrgID:            orgID,
+			CustomerID:       node.Generate(),
+			Status:           subscriptiondomain.SubscriptionStatusActive,
+			StartAt:          jan,
+			ActivatedAt:      &activatedAt,
+			BillingCycleType: "monthly",
+		}).Error)
+		require.NoError(t, db.Create(&subscriptiondomain.SubscriptionItem{
+			ID:             node.Generate(),
+			OrgID:          orgID,
+			SubscriptionID: subID,
+			PriceID:        priceID,
+			BillingMode:    "LICENSED",
+			Quantity:       1,
+			BillingTiming:  itemTiming,
+		}).Error)
+		require.NoError(t, db.Create(&subscriptiondomain.SubscriptionEntitlement{
+			ID: node.Generate(), OrgID: orgID, SubscriptionID: subID, ProductID: productID,
+			FeatureCode: "seats", EffectiveFrom: jan,
+		}).Error)
+		return db, svc, node, orgID, subID
+	}
+
+	rate := func(t *testing.T, db *gorm.DB, svc ratingdomain.Service, node *snowflake.Node, orgID, subID snowflake.ID, start, end time.Time) []ratingdomain.RatingResult {
+		cycleID := node.Generate()
+		require.NoError(t, db.Create(&billingcycledomain.BillingCycle{
+			ID:             cycleID,
+			OrgID:          orgID,
+			SubscriptionID: subID,
+			PeriodStart:    start,
+			PeriodEnd:      end,
+			Status:         billingcycledomain.BillingCycleStatusClosing,
+		}).Error)
+		require.NoError(t, svc.RunRating(context.Background(), cycleID.String()))
+		var results []ratingdomain.RatingResult
+		require.NoError(t, db.Where("billing_cycle_id = ?", cycleID).Order("period_start ASC").Find(&results).Error)
+		return results
+	}
+
+	t.Run("first cycle bills its period and the next one in advance", func(t *testing.T) {
+		db, svc, node, orgID, subID := seed(t, pricedomain.InAdvance, nil)
+		results := rate(t, db, svc, node, orgID, subID, jan, feb)
+		require.Len(t, results, 2)
+
+		assert.Equal(t, "flat_rate", results[0].Source)
+		assert.Equal(t, int64(10000), results[0].Amount)
+
+		assert.Equal(t, "advance", results[1].Source)
+		assert.Equal(t, int64(10000), results[1].Amount)
+		assert.Equal(t, "seats", results[1].FeatureCode)
+		assert.Equal(t, feb, results[1].PeriodStart.UTC())
+		assert.Equal(t, mar, results[1].PeriodEnd.UTC())
+	})
+
+	t.Run("activation bills the first period in advance", func(t *testing.T) {
+		db, svc, node, orgID, subID := seed(t, pricedomain.InAdvance, nil)
+		results := rate(t, db, svc, node, orgID, subID, jan, jan)
+		require.Len(t, results, 1)
+		assert.Equal(t, "advance", results[0].Source)
+		assert.Equal(t, int64(10000), results[0].Amount)
+		assert.Equal(t, jan, results[0].PeriodStart.UTC())
+		assert.Equal(t, feb, results[0].PeriodEnd.UTC())
+
+		results = rate(t, db, svc, node, orgID, subID, jan, feb)
+		require.Len(t, results, 1)
+		assert.Equal(t, "advance", results[0].Source)
+		assert.Equal(t, feb, results[0].PeriodStart.UTC())
+	})
+
+	t.Run("arrears items bill nothing at activation", func(t *testing.T) {
+		db, svc, node, orgID, subID := seed(t, pricedomain.InArrears, nil)
+		results := rate(t, db, svc, node, orgID, subID, jan, jan)
+		assert.Empty(t, results)
+	})
+
+	t.Run("period billed in advance is not billed again", func(t *testing.T) {
+		db, svc, node, orgID, subID := seed(t, pricedomain.InAdvance, nil)
+		rate(t, db, svc, node, orgID, subID, jan, feb)
+
+		results := rate(t, db, svc, node, orgID, subID, feb, mar)
+		require.Len(t, results, 1)
+		assert.Equal(t, "advance", results[0].Source)
+		assert.Equal(t, mar, results[0].PeriodStart.UTC())
+	})
+
+	t.Run("cancellation credits the unused part of the advance charge", func(t *testing.T) {
+		db, svc, node, orgID, subID := seed(t, pricedomain.InAdvance, nil)
+		rate(t, db, svc, node, orgID, subID, jan, feb)
+
+		canceledAt := time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC)
+		require.NoError(t, db.Model(&subscriptiondomain.Subscription{}).Where("id = ?", subID).Updates(map[string]any{
+			"status":      subscriptiondomain.SubscriptionStatusCanceled,
+			"canceled_at": canceledAt,
+		}).Error)
+
+		results := rate(t, db, svc, node, orgID, subID, feb, mar)
+		require.Len(t, results, 1)
+		assert.Equal(t, "advance_adjustment", results[0].Source)
+		assert.Equal(t, int64(-5000), results[0].Amount) // 14 of 28 days unused
+		assert.InDelta(t, -0.5, results[0].Quantity, 0.0001)
+	})
+
+	t.Run("item override bills an arrears price in advance", func(t *testing.T) {
+		timing := string(pricedomain.InAdvance)
+		db, svc, node, orgID, subID := seed(t, pricedomain.InArrears, &timing)
+		results := rate(t, db, svc, node, orgID, subID, jan, feb)
+		require.Len(t, results, 2)
+		assert.Equal(t, "advance", results[1].Source)
+	})
+
+	t.Run("arrears price bills nothing ahead", func(t *testing.T) {
+		db, svc, node, orgID, subID := seed(t, pricedomain.InArrears, nil)
+		results := rate(t, db, svc, node, orgID, subID, jan, feb)
+		require.Len(t, results, 1)
+		assert.Equal(t, "flat_rate", results[0].Source)
+	})
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"gorm.io/gorm"
+)
+
+// usageAggregation describes how usage events collapse into a quantity.
+type usageAggregation struct {
+	Type string
+	Key  string
+}
+
+type meterAggregationRow struct {
+	ID             snowflake.ID
+	Aggregation    string
+	AggregationKey *string
+}
+
+// resolveAggregation picks the aggregation for a metered item, as
+// pricedomain.ResolveAggregation resolves it from the meter and the price.
+func (s *Service) resolveAggregation(
+	ctx context.Context,
+	tx *gorm.DB,
+	orgID, meterID snowflake.ID,
+	price *pricedomain.Price,
+) (usageAggregation, error) {
+	var row meterAggregationRow
+	err := tx.WithContext(ctx).Raw(
+		`SELECT id, aggregation, aggregation_key
+		 FROM meters
+		 WHERE org_id = ? AND id = ?`,
+		orgID,
+		meterID,
+	).Scan(&row).Error
+	if err != nil {
+		return usageAggregation{}, err
+	}
+	if row.ID == 0 {
+		return usageAggregation{}, ratingdomain.ErrMissingMeter
+	}
+
+	var aggregateUsage *pricedomain.AggregateUsage
+	if price != nil {
+		aggregateUsage = price.AggregateUsage
+	}
+	aggregation, ok := pricedomain.ResolveAggregation(row.Aggregation, aggregateUsage)
+	if !ok {
+		return usageAggregation{}, ratingdomain.ErrUnsupportedAggregation
+	}
+	key := ""
+	if row.AggregationKey != nil {
+		key = strings.TrimSpace(*row.AggregationKey)
+	}
+
+	if aggregation == meterdomain.AggregationUniqueCount && key == "" {
+		return usageAggregation{}, ratingdomain.ErrUnsupportedAggregation
+	}
+
+	return usageAggregation{Type: aggregation, Key: key}, nil
+}
+
+func (s *Service) aggregateUsage(
+	tx *gorm.DB,
+	orgID, subscriptionID, meterID snowflake.ID,
+	aggregation usageAggregation,
+	periodStart, periodEnd time.Time,
+) (float64, error) {
+	// Carried-forward late events are billed at billable_at instead of the
+	// time they were recorded.
+	const window = `FROM usage_events
+		 WHERE org_id = ? AND subscription_id = ? AND meter_id = ?
+		 AND COALESCE(billable_at, recorded_at) >= ? AND COALESCE(billable_at, recorded_at) < ?
+		 AND status = ?`
+	args := []any{orgID, subscriptionID, meterID, periodStart, periodEnd, usagedomain.UsageStatusEnriched}
+
+	var query string
+	switch aggregation.Type {
+	case meterdomain.AggregationSum:
+		query = `SELECT COALESCE(SUM(value), 0) ` + window
+	case meterdomain.AggregationMax:
+		query = `SELECT COALESCE(MAX(value), 0) ` + window
+	case meterdomain.AggregationMin:
+		query = `SELECT COALESCE(MIN(value), 0) ` + window
+	case meterdomain.AggregationAvg:
+		query = `SELECT COALESCE(AVG(value), 0) ` + window
+	case meterdomain.AggregationCount:
+		query = `SELECT COUNT(*) ` + window
+	case meterdomain.AggregationLast:
+		// Ties on recorded_at are broken by id so reruns pick the same event.
+		query = `SELECT value ` + window + ` ORDER BY recorded_at DESC, id DESC LIMIT 1`
+	case meterdomain.AggregationUniqueCount:
+		query = `SELECT COUNT(DISTINCT ` + metadataValueExpr(tx) + `) ` + window
+		args = append([]any{aggregation.Key}, args...)
+	default:
+		return 0, ratingdomain.ErrUnsupportedAggregation
+	}
+
+	var quantity float64
+	if err := tx.Raw(query, args...).Scan(&quantity).Error; err != nil {
+		return 0, err
+	}
+	return quantity, nil
+}
+
+// metadataValueExpr extracts a top-level metadata value as text. Events
+// without the key yield NULL and are ignored by COUNT(DISTINCT ...).
+func metadataValueExpr(db *gorm.DB) string {
+	if db != nil && strings.EqualFold(db.Dialector.Name(), "sqlite") {
+		return `json_extract(metadata, '$.' || ?)`
+	}
+	return `metadata->>?`
+}
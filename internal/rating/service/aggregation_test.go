@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	"github.com/smallbiznis/railzway/internal/money"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// TestRating_MeterAggregations validates that every supported meter
+// aggregation produces the expected quantity and is stable across reruns.
+func TestRating_MeterAggregations(t *testing.T) {
+	cases := []struct {
+		name           string
+		aggregation    string
+		aggregationKey string
+		priceOverride  *pricedomain.AggregateUsage
+		expected       float64
+	}{
+		{name: "sum", aggregation: meterdomain.AggregationSum, expected: 27},
+		{name: "max", aggregation: meterdomain.AggregationMax, expected: 12},
+		{name: "min", aggregation: meterdomain.AggregationMin, expected: 3},
+		{name: "avg", aggregation: meterdomain.AggregationAvg, expected: 6.75},
+		{name: "last", aggregation: meterdomain.AggregationLast, expected: 7},
+		{name: "count", aggregation: meterdomain.AggregationCount, expected: 4},
+		{name: "unique_count", aggregation: meterdomain.AggregationUniqueCount, aggregationKey: "user_id", expected: 2},
+		{name: "lowercase_meter", aggregation: "max", expected: 12},
+		{name: "price_overrides_with_last", aggregation: meterdomain.AggregationSum, priceOverride: aggregatePtr(pricedomain.LAST), expected: 7},
+		{name: "price_sum_keeps_meter", aggregation: meterdomain.AggregationMax, priceOverride: aggregatePtr(pricedomain.SUM), expected: 12},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, svc, node := setupProrationTest(t)
+			cycleID := seedAggregationData(t, db, svc.(*Service), node, tc.aggregation, tc.aggregationKey, tc.priceOverride)
+
+			require.NoError(t, svc.RunRating(context.Background(), cycleID.String()))
+
+			var results []ratingdomain.RatingResult
+			db.Where("billing_cycle_id = ?", cycleID).Find(&results)
+			require.Len(t, results, 1)
+			assert.Equal(t, tc.expected, results[0].Quantity)
+			assert.Equal(t, lineAmount(tc.expected, money.NewFromInt(100), money.RoundHalfUp), results[0].Amount)
+
+			require.NoError(t, svc.RunRating(context.Background(), cycleID.String()))
+
+			var rerun []ratingdomain.RatingResult
+			db.Where("billing_cycle_id = ?", cycleID).Find(&rerun)
+			require.Len(t, rerun, 1)
+			assert.Equal(t, results[0].Quantity, rerun[0].Quantity)
+			assert.Equal(t, results[0].Checksum, rerun[0].Checksum)
+		})
+	}
+}
+
+func TestRating_UniqueCountRequiresKey(t *testing.T) {
+	db, svc, node := setupProrationTest(t)
+	cycleID := seedAggregationData(t, db, svc.(*Service), node, meterdomain.AggregationUniqueCount, "", nil)
+
+	err := svc.RunRating(context.Background(), cycleID.String())
+	assert.ErrorIs(t, err, ratingdomain.ErrUnsupportedAggregation)
+}
+
+func seedAggregationData(
+	t *testing.T,
+	db *gorm.DB,
+	svc *Service,
+	node *snowflake.Node,
+	aggregation, aggregationKey string,
+	priceOverride *pricedomain.AggregateUsage,
+) snowflake.ID {
+	t.Helper()
+
+	orgID := node.Generate()
+	subID := node.Generate()
+	cycleID := node.Generate()
+	productID := node.Generate()
+	priceID := node.Generate()
+	meterID := node.Generate()
+
+	cycleStart := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	cycleEnd := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	db.Create(&billingcycledomain.BillingCycle{
+		ID:             cycleID,
+		OrgID:          orgID,
+		SubscriptionID: subID,
+		PeriodStart:    cycleStart,
+		PeriodEnd:      cycleEnd,
+		Status:         billingcycledomain.BillingCycleStatusClosing,
+	})
+	db.Create(&subscriptiondomain.Subscription{
+		ID:         subID,
+		OrgID:      orgID,
+		CustomerID: node.Generate(),
+		Status:     subscriptiondomain.SubscriptionStatusActive,
+		StartAt:    cycleStart,
+	})
+	db.Create(&subscriptiondomain.SubscriptionItem{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		SubscriptionID: subID,
+		PriceID:        priceID,
+		MeterID:        &meterID,
+		BillingMode:    "METERED",
+	})
+	db.Create(&meterdomain.Meter{
+		ID:             meterID,
+		OrgID:          orgID,
+		Code:           "seats",
+		Name:           "Seats",
+		Aggregation:    aggregation,
+		AggregationKey: aggregationKey,
+		Unit:           "seat",
+	})
+	db.Create(&subscriptiondomain.SubscriptionEntitlement{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		SubscriptionID: subID,
+		ProductID:      productID,
+		FeatureCode:    "seats",
+		MeterID:        &meterID,
+		EffectiveFrom:  cycleStart,
+	})
+
+	svc.priceRepo.(*priceRepoStub).Prices[priceID.String()] = pricedomain.Price{
+		ID:             priceID,
+		ProductID:      productID,
+		PricingModel:   pricedomain.PerUnit,
+		AggregateUsage: priceOverride,
+	}
+	svc.priceAmountRepo.(*priceAmountStub).Amounts[priceID.String()] = priceamountdomain.PriceAmount{
+		PriceID:         priceID,
+		UnitAmountCents: 100,
+		Currency:        "USD",
+	}
+
+	events := []struct {
+		value  float64
+		user   string
+		offset time.Duration
+		status string
+	}{
+		{value: 5, user: "u1", offset: 1 * time.Hour, status: usagedomain.UsageStatusEnriched},
+		{value: 12, user: "u2", offset: 2 * time.Hour, status: usagedomain.UsageStatusEnriched},
+		{value: 3, user: "u1", offset: 3 * time.Hour, status: usagedomain.UsageStatusEnriched},
+		{value: 7, user: "u2", offset: 4 * time.Hour, status: usagedomain.UsageStatusEnriched},
+		// Not enriched yet: never counted by any aggregation.
+		{value: 100, user: "u3", offset: 5 * time.Hour, status: usagedomain.UsageStatusAccepted},
+	}
+	for _, e := range events {
+		db.Create(&usagedomain.UsageEvent{
+			ID:             node.Generate(),
+			OrgID:          orgID,
+			MeterID:        meterID,
+			SubscriptionID: subID,
+			Value:          e.value,
+			RecordedAt:     cycleStart.Add(e.offset),
+			Status:         e.status,
+			Metadata:       datatypes.JSONMap{"user_id": e.user},
+		})
+	}
+
+	return cycleID
+}
+
+func aggregatePtr(v pricedomain.AggregateUsage) *pricedomain.AggregateUsage { return &v }
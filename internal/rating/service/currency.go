@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/smallbiznis/railzway/internal/money"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	"gorm.io/gorm"
+)
+
+// resolveCurrency picks the currency of a subscription created before
+// currencies were locked: the one it was rated in before, else the
+// customer's, else the first item's, as long as every item has a price
+// amount in it. It returns "" when no item has an amount at all, which
+// rating rejects on its own.
+func (s *Service) resolveCurrency(ctx context.Context, tx *gorm.DB, cycle *billingCycleRow, items []subscriptionItemRow) (string, error) {
+	var rated string
+	if err := tx.WithContext(ctx).Raw(
+		`SELECT currency
+		 FROM rating_results
+		 WHERE org_id = ? AND subscription_id = ? AND billing_cycle_id <> ? AND currency <> ''
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT 1`,
+		cycle.OrgID,
+		cycle.SubscriptionID,
+		cycle.ID,
+	).Scan(&rated).Error; err != nil {
+		return "", err
+	}
+	if rated != "" {
+		return strings.ToUpper(rated), nil
+	}
+
+	customer, err := s.customerCurrency(ctx, tx, cycle)
+	if err != nil {
+		return "", err
+	}
+	candidates := []string{customer}
+	for _, item := range items {
+		amount, err := s.resolvePriceAmountAt(ctx, tx, cycle.OrgID, item.PriceID, item.MeterID, "", cycle.PeriodStart)
+		if err != nil {
+			return "", err
+		}
+		if amount != nil {
+			candidates = append(candidates, amount.Currency)
+			break
+		}
+	}
+	if len(candidates) == 1 {
+		return "", nil
+	}
+
+	for _, candidate := range candidates {
+		code, err := money.NormalizeCurrency(candidate)
+		if err != nil {
+			continue
+		}
+		ok, err := s.pricedIn(ctx, tx, cycle, items, code)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return code, nil
+		}
+	}
+	return "", ratingdomain.ErrCurrencyUnresolved
+}
+
+// pricedIn reports whether every item priced at the cycle start has an
+// amount in the currency.
+func (s *Service) pricedIn(ctx context.Context, tx *gorm.DB, cycle *billingCycleRow, items []subscriptionItemRow, currency string) (bool, error) {
+	for _, item := range items {
+		priced, err := s.resolvePriceAmountAt(ctx, tx, cycle.OrgID, item.PriceID, item.MeterID, "", cycle.PeriodStart)
+		if err != nil {
+			return false, err
+		}
+		if priced == nil {
+			continue
+		}
+		amount, err := s.resolvePriceAmountAt(ctx, tx, cycle.OrgID, item.PriceID, item.MeterID, currency, cycle.PeriodStart)
+		if err != nil {
+			return false, err
+		}
+		if amount == nil || !strings.EqualFold(amount.Currency, currency) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// lockCurrency stores the resolved currency on a subscription that has none,
+// so it is never rated in another one.
+func (s *Service) lockCurrency(ctx context.Context, tx *gorm.DB, cycle *billingCycleRow) error {
+	if cycle.Currency == "" {
+		return nil
+	}
+	return tx.WithContext(ctx).Exec(
+		`UPDATE subscriptions SET default_currency = ?
+		 WHERE org_id = ? AND id = ? AND default_currency IS NULL`,
+		cycle.Currency,
+		cycle.OrgID,
+		cycle.SubscriptionID,
+	).Error
+}
+
+// customerCurrency returns the customer's currency, or "" when it has none.
+func (s *Service) customerCurrency(ctx context.Context, tx *gorm.DB, cycle *billingCycleRow) (string, error) {
+	var currency *string
+	if err := tx.WithContext(ctx).Raw(
+		`SELECT c.currency
+		 FROM customers c
+		 JOIN subscriptions s ON s.customer_id = c.id AND s.org_id = c.org_id
+		 WHERE s.org_id = ? AND s.id = ?`,
+		cycle.OrgID,
+		cycle.SubscriptionID,
+	).Scan(&currency).Error; err != nil {
+		return "", err
+	}
+	if currency == nil {
+		return "", nil
+	}
+	return *currency, nil
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	customerdomain "github.com/smallbiznis/railzway/internal/customer/domain"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRating_UsesSubscriptionCurrency(t *testing.T) {
+	db, svc, node := setupProrationTest(t)
+	orgID := node.Generate()
+	subID := node.Generate()
+	cycleID := node.Generate()
+	productID := node.Generate()
+	priceID := node.Generate()
+	meterID := node.Generate()
+
+	cycleStart := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	cycleEnd := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	currency := "EUR"
+
+	require.NoError(t, db.Create(&billingcycledomain.BillingCycle{
+		ID:             cycleID,
+		OrgID:          orgID,
+		SubscriptionID: subID,
+		PeriodStart:    cycleStart,
+		PeriodEnd:      cycleEnd,
+		Status:         billingcycledomain.BillingCycleStatusClosing,
+	}).Error)
+	require.NoError(t, db.Create(&subscriptiondomain.Subscription{
+		ID:              subID,
+		OrgID:           orgID,
+		CustomerID:      node.Generate(),
+		Status:          subscriptiondomain.SubscriptionStatusActive,
+		StartAt:         cycleStart,
+		DefaultCurrency: &currency,
+	}).Error)
+	require.NoError(t, db.Create(&subscriptiondomain.SubscriptionItem{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		SubscriptionID: subID,
+		PriceID:        priceID,
+		MeterID:        &meterID,
+		BillingMode:    "METERED",
+	}).Error)
+	require.NoError(t, db.Create(&meterdomain.Meter{
+		ID:          meterID,
+		OrgID:       orgID,
+		Code:        "api_calls",
+		Name:        "API calls",
+		Aggregation: meterdomain.AggregationSum,
+		Unit:        "call",
+	}).Error)
+	require.NoError(t, db.Create(&subscriptiondomain.SubscriptionEntitlement{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		SubscriptionID: subID,
+		ProductID:      productID,
+		FeatureCode:    "api_calls",
+		MeterID:        &meterID,
+		EffectiveFrom:  cycleStart,
+	}).Error)
+	require.NoError(t, db.Create(&usagedomain.UsageEvent{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		MeterID:        meterID,
+		SubscriptionID: subID,
+		Value:          10,
+		RecordedAt:     cycleStart.Add(time.Hour),
+		Status:         usagedomain.UsageStatusEnriched,
+	}).Error)
+
+	svc.(*Service).priceRepo.(*priceRepoStub).Prices[priceID.String()] = pricedomain.Price{
+		ID:           priceID,
+		ProductID:    productID,
+		PricingModel: pricedomain.PerUnit,
+	}
+	amounts := svc.(*Service).priceAmountRepo.(*priceAmountStub).Amounts
+	amounts[priceID.String()] = priceamountdomain.PriceAmount{PriceID: priceID, UnitAmountCents: 100, Currency: "USD"}
+	amounts[priceID.String()+":EUR"] = priceamountdomain.PriceAmount{PriceID: priceID, UnitAmountCents: 90, Currency: "EUR"}
+
+	require.NoError(t, svc.RunRating(context.Background(), cycleID.String()))
+	var results []ratingdomain.RatingResult
+	require.NoError(t, db.Where("billing_cycle_id = ?", cycleID).Find(&results).Error)
+	require.Len(t, results, 1)
+	assert.Equal(t, "EUR", results[0].Currency)
+	assert.Equal(t, int64(900), results[0].Amount)
+}
+
+func TestRating_LocksUnsetCurrency(t *testing.T) {
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	rate := func(t *testing.T, customerCurrency string) (string, *subscriptiondomain.Subscription) {
+		db, svc, node := setupProrationTest(t)
+		orgID := node.Generate()
+		subID := node.Generate()
+		customerID := node.Generate()
+		cycleID := node.Generate()
+		productID := node.Generate()
+		priceID := node.Generate()
+
+		require.NoError(t, db.Create(&customerdomain.Customer{
+			ID: customerID, OrgID: orgID, Name: "Acme", Email: "billing@acme.test", Currency: customerCurrency,
+		}).Error)
+		require.NoError(t, db.Create(&subscriptiondomain.Subscription{
+			ID:               subID,
+			OrgID:            orgID,
+			CustomerID:       customerID,
+			Status:           subscriptiondomain.SubscriptionStatusActive,
+			StartAt:          jan,
+			BillingCycleType: "monthly",
+		}).Error)
+		require.NoError(t, db.Create(&subscriptiondomain.SubscriptionItem{
+			ID: node.Generate(), OrgID: orgID, SubscriptionID: subID, PriceID: priceID, BillingMode: "LICENSED", Quantity: 1,
+		}).Error)
+		require.NoError(t, db.Create(&subscriptiondomain.SubscriptionEntitlement{
+			ID: node.Generate(), OrgID: orgID, SubscriptionID: subID, ProductID: productID,
+			FeatureCode: "seats", EffectiveFrom: jan,
+		}).Error)
+		require.NoError(t, db.Create(&billingcycledomain.BillingCycle{
+			ID: cycleID, OrgID: orgID, SubscriptionID: subID, PeriodStart: jan, PeriodEnd: feb,
+			Status: billingcycledomain.BillingCycleStatusClosing,
+		}).Error)
+
+		svc.(*Service).priceRepo.(*priceRepoStub).Prices[priceID.String()] = pricedomain.Price{ID: priceID, ProductID: productID}
+		amounts := svc.(*Service).priceAmountRepo.(*priceAmountStub).Amounts
+		amounts[priceID.String()] = priceamountdomain.PriceAmount{PriceID: priceID, UnitAmountCents: 10000, Currency: "USD"}
+		amounts[priceID.String()+":EUR"] = priceamountdomain.PriceAmount{PriceID: priceID, UnitAmountCents: 9000, Currency: "EUR"}
+
+		require.NoError(t, svc.RunRating(context.Background(), cycleID.String()))
+		var results []ratingdomain.RatingResult
+		require.NoError(t, db.Where("billing_cycle_id = ?", cycleID).Find(&results).Error)
+		require.Len(t, results, 1)
+
+		var sub subscriptiondomain.Subscription
+		require.NoError(t, db.First(&sub, "id = ?", subID).Error)
+		return results[0].Currency, &sub
+	}
+
+	t.Run("customer currency the prices are offered in", func(t *testing.T) {
+		currency, sub := rate(t, "EUR")
+		assert.Equal(t, "EUR", currency)
+		require.NotNil(t, sub.DefaultCurrency)
+		assert.Equal(t, "EUR", *sub.DefaultCurrency)
+	})
+
+	t.Run("price currency when the customer's is not offered", func(t *testing.T) {
+		currency, sub := rate(t, "GBP")
+		assert.Equal(t, "USD", currency)
+		require.NotNil(t, sub.DefaultCurrency)
+		assert.Equal(t, "USD", *sub.DefaultCurrency)
+	})
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	"github.com/smallbiznis/railzway/internal/money"
+	organizationdomain "github.com/smallbiznis/railzway/internal/organization/domain"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRating_SubCentUnitAmount(t *testing.T) {
+	// rate bills calls API calls at unitAmount cents each and returns the
+	// single usage row.
+	rate := func(t *testing.T, unitAmount string, calls float64, mode money.RoundingMode) ratingdomain.RatingResult {
+		db, svc, node := setupProrationTest(t)
+		orgID := node.Generate()
+		subID := node.Generate()
+		cycleID := node.Generate()
+		productID := node.Generate()
+		priceID := node.Generate()
+		meterID := node.Generate()
+
+		cycleStart := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+		cycleEnd := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+		if mode != "" {
+			require.NoError(t, db.Create(&organizationdomain.OrganizationBillingPreferences{
+				OrgID:        orgID,
+				Currency:     "USD",
+				Timezone:     "UTC",
+				RoundingMode: mode,
+			}).Error)
+		}
+		require.NoError(t, db.Create(&billingcycledomain.BillingCycle{
+			ID:             cycleID,
+			OrgID:          orgID,
+			SubscriptionID: subID,
+			PeriodStart:    cycleStart,
+			PeriodEnd:      cycleEnd,
+			Status:         billingcycledomain.BillingCycleStatusClosing,
+		}).Error)
+		require.NoError(t, db.Create(&subscriptiondomain.Subscription{
+			ID:         subID,
+			OrgID:      orgID,
+			CustomerID: node.Generate(),
+			Status:     subscriptiondomain.SubscriptionStatusActive,
+			StartAt:    cycleStart,
+		}).Error)
+		require.NoError(t, db.Create(&subscriptiondomain.SubscriptionItem{
+			ID:             node.Generate(),
+			OrgID:          orgID,
+			SubscriptionID: subID,
+			PriceID:        priceID,
+			MeterID:        &meterID,
+			BillingMode:    "METERED",
+		}).Error)
+		require.NoError(t, db.Create(&meterdomain.Meter{
+			ID:          meterID,
+			OrgID:       orgID,
+			Code:        "api_calls",
+			Name:        "API calls",
+			Aggregation: meterdomain.AggregationSum,
+			Unit:        "call",
+		}).Error)
+		require.NoError(t, db.Create(&subscriptiondomain.SubscriptionEntitlement{
+			ID:             node.Generate(),
+			OrgID:          orgID,
+			SubscriptionID: subID,
+			ProductID:      productID,
+			FeatureCode:    "api_calls",
+			MeterID:        &meterID,
+			EffectiveFrom:  cycleStart,
+		}).Error)
+		require.NoError(t, db.Create(&usagedomain.UsageEvent{
+			ID:             node.Generate(),
+			OrgID:          orgID,
+			MeterID:        meterID,
+			SubscriptionID: subID,
+			Value:          calls,
+			RecordedAt:     cycleStart.Add(time.Hour),
+			Status:         usagedomain.UsageStatusEnriched,
+		}).Error)
+
+		decimal, err := money.Parse(unitAmount)
+		require.NoError(t, err)
+		svc.(*Service).priceRepo.(*priceRepoStub).Prices[priceID.String()] = pricedomain.Price{
+			ID:           priceID,
+			ProductID:    productID,
+			PricingModel: pricedomain.PerUnit,
+		}
+		svc.(*Service).priceAmountRepo.(*priceAmountStub).Amounts[priceID.String()] = priceamountdomain.PriceAmount{
+			PriceID:           priceID,
+			UnitAmountCents:   decimal.Round(money.RoundHalfUp),
+			UnitAmountDecimal: &decimal,
+			Currency:          "USD",
+		}
+
+		require.NoError(t, svc.RunRating(context.Background(), cycleID.String()))
+		var results []ratingdomain.RatingResult
+		require.NoError(t, db.Where("billing_cycle_id = ?", cycleID).Find(&results).Error)
+		require.Len(t, results, 1)
+		return results[0]
+	}
+
+	t.Run("fractional cents add up exactly", func(t *testing.T) {
+		result := rate(t, "0.04", 1000000, "")
+		assert.Equal(t, int64(40000), result.Amount)
+		assert.Equal(t, int64(0), result.UnitPrice)
+		require.NotNil(t, result.UnitPriceDecimal)
+		assert.Equal(t, "0.04", result.UnitPriceDecimal.String())
+	})
+
+	t.Run("rounding mode comes from the organization", func(t *testing.T) {
+		// 1000 calls at 0.0025 cents is exactly 2.5 cents.
+		assert.Equal(t, int64(3), rate(t, "0.0025", 1000, "").Amount)
+		assert.Equal(t, int64(3), rate(t, "0.0025", 1000, money.RoundHalfUp).Amount)
+		assert.Equal(t, int64(2), rate(t, "0.0025", 1000, money.RoundHalfEven).Amount)
+		assert.Equal(t, int64(2), rate(t, "0.0029", 1000, money.RoundFloor).Amount)
+	})
+
+	t.Run("whole cent prices keep no decimal", func(t *testing.T) {
+		result := rate(t, "3", 7, "")
+		assert.Equal(t, int64(21), result.Amount)
+		assert.Nil(t, result.UnitPriceDecimal)
+	})
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/bwmarrin/snowflake"
 	"github.com/glebarez/sqlite"
 	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	customerdomain "github.com/smallbiznis/railzway/internal/customer/domain"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	organizationdomain "github.com/smallbiznis/railzway/internal/organization/domain"
 	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
 	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
 	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
@@ -31,8 +34,13 @@ func TestRating_Deterministic_Idempotency(t *testing.T) {
 		&subscriptiondomain.SubscriptionItem{},
 		&subscriptiondomain.Subscription{},
 		&subscriptiondomain.SubscriptionEntitlement{},
+		&subscriptiondomain.SubscriptionPlanChange{},
+		&subscriptiondomain.SubscriptionItemQuantityChange{},
 		&billingcycledomain.BillingCycle{},
+		&organizationdomain.OrganizationBillingPreferences{},
 		&pricedomain.Price{},
+		&customerdomain.Customer{},
+		&meterdomain.Meter{},
 		// PriceAmount table not strictly needed if we stub repo, but good for consistency
 	)
 	assert.NoError(t, err)
@@ -78,6 +86,15 @@ func TestRating_Deterministic_Idempotency(t *testing.T) {
 		Status:         billingcycledomain.BillingCycleStatusClosing,
 	})
 
+	// Subscription
+	db.Create(&subscriptiondomain.Subscription{
+		ID:         subID,
+		OrgID:      orgID,
+		CustomerID: node.Generate(),
+		Status:     subscriptiondomain.SubscriptionStatusActive,
+		StartAt:    start,
+	})
+
 	// Price (Allowed Snapshot)
 	db.Create(&pricedomain.Price{
 		ID:        priceID,
@@ -91,8 +108,19 @@ func TestRating_Deterministic_Idempotency(t *testing.T) {
 	priceAmountStub.Amounts[priceID.String()] = priceamountdomain.PriceAmount{
 		PriceID:         priceID,
 		UnitAmountCents: 100, // $1.00
+		Currency:        "USD",
 	}
 
+	// Meter
+	db.Create(&meterdomain.Meter{
+		ID:          meterID,
+		OrgID:       orgID,
+		Code:        "api_calls",
+		Name:        "API Calls",
+		Aggregation: meterdomain.AggregationSum,
+		Unit:        "call",
+	})
+
 	// Subscription Item (Metered)
 	db.Create(&subscriptiondomain.SubscriptionItem{
 		ID:             node.Generate(),
@@ -189,7 +217,10 @@ func (s *priceAmountStub) Update(ctx context.Context, db *gorm.DB, amount *price
 }
 
 func (s *priceAmountStub) FindEffectiveAt(ctx context.Context, db *gorm.DB, orgID, priceID snowflake.ID, meterID *snowflake.ID, currency string, at time.Time) (*priceamountdomain.PriceAmount, error) {
-	// Simple mock lookup by PriceID
+	// Simple mock lookup by PriceID, preferring an amount keyed to the currency
+	if v, ok := s.Amounts[priceID.String()+":"+currency]; ok {
+		return &v, nil
+	}
 	if v, ok := s.Amounts[priceID.String()]; ok {
 		return &v, nil
 	}
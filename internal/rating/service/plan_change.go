@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/money"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"gorm.io/gorm"
+)
+
+// prorationSource marks the credit and charge rows a mid-cycle plan change
+// adds to a cycle.
+const prorationSource = "proration"
+
+// loadPlanChanges returns the plan changes applied inside the cycle, oldest
+// first. A change on the cycle start belongs to the previous cycle.
+func (s *Service) loadPlanChanges(ctx context.Context, tx *gorm.DB, cycle *billingCycleRow) ([]subscriptiondomain.SubscriptionPlanChange, error) {
+	var changes []subscriptiondomain.SubscriptionPlanChange
+	err := tx.WithContext(ctx).Raw(
+		`SELECT id, effective_at, proration_behavior, previous_items
+		 FROM subscription_plan_changes
+		 WHERE org_id = ? AND subscription_id = ? AND status = ?
+		   AND effective_at > ? AND effective_at <= ?
+		 ORDER BY effective_at ASC, id ASC`,
+		cycle.OrgID,
+		cycle.SubscriptionID,
+		subscriptiondomain.PlanChangeStatusApplied,
+		cycle.PeriodStart,
+		cycle.PeriodEnd,
+	).Scan(&changes).Error
+	return changes, err
+}
+
+type planSegment struct {
+	items      []subscriptionItemRow
+	start, end time.Time
+}
+
+// rateCycleWithPlanChanges rates a cycle in which the plan changed.
+//
+// Metered items are rated on the part of the cycle their plan was active.
+// Flat items bill the plan the cycle started on for the whole cycle; every
+// change that prorates then credits the unused part of the billed plan and
+// charges the rest of the new one. With NONE the billed plan is kept until
+// the cycle ends.
+func (s *Service) rateCycleWithPlanChanges(
+	ctx context.Context,
+	tx *gorm.DB,
+	cycle *billingCycleRow,
+	subscription *subscriptiondomain.Subscription,
+	items []subscriptionItemRow,
+	changes []subscriptiondomain.SubscriptionPlanChange,
+	entitlements []subscriptiondomain.SubscriptionEntitlement,
+	cycleDuration float64,
+	now time.Time,
+	emit ratingSink,
+) error {
+	segments := make([]planSegment, 0, len(changes)+1)
+	start := cycle.PeriodStart
+	for _, change := range changes {
+		previous, err := decodePlanItems(cycle, change.PreviousItems)
+		if err != nil {
+			return err
+		}
+		segments = append(segments, planSegment{items: previous, start: start, end: change.EffectiveAt})
+		start = change.EffectiveAt
+	}
+	segments = append(segments, planSegment{items: items, start: start, end: cycle.PeriodEnd})
+
+	for _, segment := range segments {
+		for _, item := range segment.items {
+			if item.MeterID == nil {
+				continue
+			}
+			featureCode, _, err := s.resolveEntitlementWithWindow(ctx, tx, item, entitlements)
+			if err != nil {
+				return fmt.Errorf("rating failed for item %s: %w", item.PriceID, err)
+			}
+			start, end := effectiveWindow(segment.start, segment.end, subscription, nil)
+			if !end.After(start) {
+				continue
+			}
+			if err := s.rateItem(ctx, tx, cycle, item, featureCode, start, end, cycleDuration, now, emit); err != nil {
+				return err
+			}
+		}
+	}
+
+	start, end := effectiveWindow(cycle.PeriodStart, cycle.PeriodEnd, subscription, nil)
+	if !end.After(start) {
+		return nil
+	}
+
+	billed := flatItems(segments[0].items)
+	for _, item := range billed {
+		featureCode, _, err := s.resolveEntitlementWithWindow(ctx, tx, item, entitlements)
+		if err != nil {
+			return fmt.Errorf("rating failed for item %s: %w", item.PriceID, err)
+		}
+		if err := s.rateItem(ctx, tx, cycle, item, featureCode, start, end, cycleDuration, now, emit); err != nil {
+			return err
+		}
+	}
+
+	// billedFrom is where the charge of the billed plan starts; a plan that
+	// starts on a trial is only charged, and credited, after the trial.
+	billedFrom := start
+	for i, change := range changes {
+		if change.ProrationBehavior == string(pricedomain.None) {
+			continue
+		}
+		at := change.EffectiveAt
+		if at.Before(start) {
+			at = start
+		}
+		if !end.After(at) {
+			continue
+		}
+
+		creditFrom := at
+		if billedFrom.After(creditFrom) {
+			creditFrom = billedFrom
+		}
+		if end.After(creditFrom) {
+			for _, item := range billed {
+				if err := s.rateProration(ctx, tx, cycle, item, entitlements, change.ID, "credit", creditFrom, end, -prorationFactor(creditFrom, end, cycleDuration), now, emit); err != nil {
+					return err
+				}
+			}
+		}
+
+		billed = flatItems(segments[i+1].items)
+		billedFrom = billableStart(subscription, at)
+		if !end.After(billedFrom) {
+			continue
+		}
+		for _, item := range billed {
+			if err := s.rateProration(ctx, tx, cycle, item, entitlements, change.ID, "charge", billedFrom, end, prorationFactor(billedFrom, end, cycleDuration), now, emit); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func prorationFactor(start, end time.Time, cycleDuration float64) float64 {
+	factor := end.Sub(start).Seconds() / cycleDuration
+	if factor > 1 {
+		return 1
+	}
+	return factor
+}
+
+// rateProration emits one proration row for a flat item over [start, end].
+// A negative factor credits the unused time of a plan that was billed in full.
+func (s *Service) rateProration(
+	ctx context.Context,
+	tx *gorm.DB,
+	cycle *billingCycleRow,
+	item subscriptionItemRow,
+	entitlements []subscriptiondomain.SubscriptionEntitlement,
+	changeID snowflake.ID,
+	kind string,
+	start, end time.Time,
+	factor float64,
+	now time.Time,
+	emit ratingSink,
+) error {
+	featureCode, _, err := s.resolveEntitlementWithWindow(ctx, tx, item, entitlements)
+	if err != nil {
+		return fmt.Errorf("rating failed for item %s: %w", item.PriceID, err)
+	}
+	priceAmount, err := s.resolvePriceAmountAt(ctx, tx, cycle.OrgID, item.PriceID, nil, cycle.Currency, start)
+	if err != nil {
+		return err
+	}
+	if priceAmount == nil {
+		return ratingdomain.ErrMissingPriceAmount
+	}
+
+	quantity := factor * item.units()
+	amount := money.NewFromFloat(quantity).Mul(priceAmount.UnitAmount())
+	rounded := roundMoney(amount, cycle.RoundingMode)
+	if amount.Sign() < 0 {
+		// Round credits by their magnitude so they mirror the charge.
+		rounded = -roundMoney(amount.Neg(), cycle.RoundingMode)
+	}
+
+	base := buildChecksum(cycle.ID, cycle.SubscriptionID, item.PriceID, nil, featureCode, start, end)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|proration|%s|%s", base, changeID.String(), kind)))
+
+	return emit(ratingdomain.RatingResult{
+		ID:               s.genID.Generate(),
+		OrgID:            cycle.OrgID,
+		SubscriptionID:   cycle.SubscriptionID,
+		BillingCycleID:   cycle.ID,
+		PriceID:          item.PriceID,
+		FeatureCode:      featureCode,
+		Quantity:         quantity,
+		UnitPrice:        priceAmount.UnitAmountCents,
+		UnitPriceDecimal: fractionalUnitPrice(priceAmount.UnitAmount()),
+		Amount:           rounded,
+		Currency:         priceAmount.Currency,
+		PeriodStart:      start,
+		PeriodEnd:        end,
+		Source:           prorationSource,
+		Checksum:         hex.EncodeToString(sum[:]),
+		CreatedAt:        now,
+	})
+}
+
+func decodePlanItems(cycle *billingCycleRow, raw []byte) ([]subscriptionItemRow, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var decoded []subscriptiondomain.PlanChangeItem
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+	items := make([]subscriptionItemRow, 0, len(decoded))
+	for _, item := range decoded {
+		items = append(items, subscriptionItemRow{
+			OrgID:          cycle.OrgID,
+			SubscriptionID: cycle.SubscriptionID,
+			PriceID:        item.PriceID,
+			MeterID:        item.MeterID,
+			Quantity:       item.Quantity,
+			BillingTiming:  item.BillingTiming,
+		})
+	}
+	return items, nil
+}
+
+func flatItems(items []subscriptionItemRow) []subscriptionItemRow {
+	out := make([]subscriptionItemRow, 0, len(items))
+	for _, item := range items {
+		if item.MeterID == nil {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestProration_PlanChangeRecords(t *testing.T) {
+	monthStart := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	changeAt := time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)
+
+	// seed creates a subscription that moved from a $100 plan to a $150 plan
+	// at changeAt and a closing cycle [cycleStart, cycleEnd].
+	seed := func(t *testing.T, behavior string, cycleStart, cycleEnd time.Time) (*gorm.DB, ratingdomain.Service, snowflake.ID, snowflake.ID, snowflake.ID) {
+		db, svc, node := setupProrationTest(t)
+		orgID := node.Generate()
+		subID := node.Generate()
+		cycleID := node.Generate()
+		basic, pro := node.Generate(), node.Generate()
+		basicPrice, proPrice := node.Generate(), node.Generate()
+
+		priceRepo := svc.(*Service).priceRepo.(*priceRepoStub)
+		priceAmounts := svc.(*Service).priceAmountRepo.(*priceAmountStub)
+		priceRepo.Prices[basicPrice.String()] = pricedomain.Price{ID: basicPrice, ProductID: basic}
+		priceRepo.Prices[proPrice.String()] = pricedomain.Price{ID: proPrice, ProductID: pro}
+		priceAmounts.Amounts[basicPrice.String()] = priceamountdomain.PriceAmount{PriceID: basicPrice, UnitAmountCents: 10000, Currency: "USD"}
+		priceAmounts.Amounts[proPrice.String()] = priceamountdomain.PriceAmount{PriceID: proPrice, UnitAmountCents: 15000, Currency: "USD"}
+
+		activatedAt := monthStart
+		require.NoError(t, db.Create(&subscriptiondomain.Subscription{
+			ID:               subID,
+			OrgID:            orgID,
+			CustomerID:       node.Generate(),
+			Status:           subscriptiondomain.SubscriptionStatusActive,
+			StartAt:          monthStart,
+			ActivatedAt:      &activatedAt,
+			BillingCycleType: "monthly",
+		}).Error)
+		require.NoError(t, db.Create(&subscriptiondomain.SubscriptionItem{
+			ID:             node.Generate(),
+			OrgID:          orgID,
+			SubscriptionID: subID,
+			PriceID:        proPrice,
+			BillingMode:    "FLAT",
+			Quantity:       1,
+		}).Error)
+		require.NoError(t, db.Create(&subscriptiondomain.SubscriptionEntitlement{
+			ID: node.Generate(), OrgID: orgID, SubscriptionID: subID, ProductID: basic,
+			FeatureCode: "basic", EffectiveFrom: monthStart, EffectiveTo: &changeAt,
+		}).Error)
+		require.NoError(t, db.Create(&subscriptiondomain.SubscriptionEntitlement{
+			ID: node.Generate(), OrgID: orgID, SubscriptionID: subID, ProductID: pro,
+			FeatureCode: "pro", EffectiveFrom: changeAt,
+		}).Error)
+
+		previous, err := json.Marshal([]subscriptiondomain.PlanChangeItem{{PriceID: basicPrice, Quantity: 1}})
+		require.NoError(t, err)
+		require.NoError(t, db.Create(&subscriptiondomain.SubscriptionPlanChange{
+			ID:                node.Generate(),
+			OrgID:             orgID,
+			SubscriptionID:    subID,
+			ToProductID:       pro,
+			ToPriceID:         proPrice,
+			Quantity:          1,
+			Direction:         subscriptiondomain.PlanChangeUpgrade,
+			ProrationBehavior: behavior,
+			Status:            subscriptiondomain.PlanChangeStatusApplied,
+			EffectiveAt:       changeAt,
+			PreviousItems:     previous,
+		}).Error)
+		require.NoError(t, db.Create(&billingcycledomain.BillingCycle{
+			ID:             cycleID,
+			OrgID:          orgID,
+			SubscriptionID: subID,
+			PeriodStart:    cycleStart,
+			PeriodEnd:      cycleEnd,
+			Status:         billingcycledomain.BillingCycleStatusClosing,
+		}).Error)
+		return db, svc, cycleID, basicPrice, proPrice
+	}
+
+	rate := func(t *testing.T, db *gorm.DB, svc ratingdomain.Service, cycleID snowflake.ID) []ratingdomain.RatingResult {
+		require.NoError(t, svc.RunRating(context.Background(), cycleID.String()))
+		var results []ratingdomain.RatingResult
+		require.NoError(t, db.Where("billing_cycle_id = ?", cycleID).Order("source ASC, amount ASC").Find(&results).Error)
+		return results
+	}
+
+	t.Run("create proration credits old plan and charges new one", func(t *testing.T) {
+		db, svc, cycleID, basicPrice, proPrice := seed(t, "CREATE_PRORATION", monthStart, monthEnd)
+		results := rate(t, db, svc, cycleID)
+		require.Len(t, results, 3)
+
+		assert.Equal(t, "flat_rate", results[0].Source)
+		assert.Equal(t, basicPrice, results[0].PriceID)
+		assert.Equal(t, int64(10000), results[0].Amount)
+
+		credit, charge := results[1], results[2]
+		assert.Equal(t, "proration", credit.Source)
+		assert.Equal(t, basicPrice, credit.PriceID)
+		assert.Equal(t, int64(-5161), credit.Amount) // 10000 * 16 / 31
+		assert.InDelta(t, -16.0/31.0, credit.Quantity, 0.0001)
+		assert.Equal(t, changeAt, credit.PeriodStart.UTC())
+
+		assert.Equal(t, "proration", charge.Source)
+		assert.Equal(t, proPrice, charge.PriceID)
+		assert.Equal(t, int64(7742), charge.Amount) // 15000 * 16 / 31
+		assert.Equal(t, "pro", charge.FeatureCode)
+	})
+
+	t.Run("trial plan is not charged before the paid plan starts", func(t *testing.T) {
+		db, svc, cycleID, _, proPrice := seed(t, "CREATE_PRORATION", monthStart, monthEnd)
+		require.NoError(t, db.Model(&subscriptiondomain.Subscription{}).Where("1 = 1").Updates(map[string]any{
+			"trial_starts_at": monthStart,
+			"trial_ends_at":   changeAt,
+		}).Error)
+
+		var total int64
+		for _, result := range rate(t, db, svc, cycleID) {
+			total += result.Amount
+		}
+		assert.Equal(t, int64(7742), total) // only 16/31 of the paid plan
+		var charge ratingdomain.RatingResult
+		require.NoError(t, db.Where("billing_cycle_id = ? AND amount > 0 AND source = ?", cycleID, "proration").First(&charge).Error)
+		assert.Equal(t, proPrice, charge.PriceID)
+	})
+
+	t.Run("none keeps billing the old plan", func(t *testing.T) {
+		db, svc, cycleID, basicPrice, _ := seed(t, "NONE", monthStart, monthEnd)
+		results := rate(t, db, svc, cycleID)
+		require.Len(t, results, 1)
+		assert.Equal(t, basicPrice, results[0].PriceID)
+		assert.Equal(t, int64(10000), results[0].Amount)
+	})
+
+	t.Run("cycle cut at the change bills the used part of the old plan", func(t *testing.T) {
+		db, svc, cycleID, basicPrice, _ := seed(t, "INVOICE_IMMEDIATELY", monthStart, changeAt)
+		results := rate(t, db, svc, cycleID)
+		require.Len(t, results, 1)
+		assert.Equal(t, basicPrice, results[0].PriceID)
+		assert.Equal(t, int64(4839), results[0].Amount) // 10000 * 15 / 31
+	})
+
+	t.Run("cycle after the cut bills the rest of the new plan", func(t *testing.T) {
+		db, svc, cycleID, _, proPrice := seed(t, "INVOICE_IMMEDIATELY", changeAt, monthEnd)
+		results := rate(t, db, svc, cycleID)
+		require.Len(t, results, 1)
+		assert.Equal(t, proPrice, results[0].PriceID)
+		assert.Equal(t, int64(7742), results[0].Amount) // 15000 * 16 / 31
+	})
+}
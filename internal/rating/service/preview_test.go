@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPreviewRating_MatchesRunRatingWithoutPersisting rates an open cycle in
+// dry-run mode and checks it yields what RunRating writes once the cycle closes.
+func TestPreviewRating_MatchesRunRatingWithoutPersisting(t *testing.T) {
+	db, svc, node := setupProrationTest(t)
+	cycleID := seedAggregationData(t, db, svc.(*Service), node, meterdomain.AggregationSum, "", nil)
+
+	_, err := svc.PreviewRating(context.Background(), cycleID.String())
+	assert.ErrorIs(t, err, ratingdomain.ErrBillingCycleNotOpen)
+
+	require.NoError(t, db.Model(&billingcycledomain.BillingCycle{}).
+		Where("id = ?", cycleID).
+		Update("status", billingcycledomain.BillingCycleStatusOpen).Error)
+
+	preview, err := svc.PreviewRating(context.Background(), cycleID.String())
+	require.NoError(t, err)
+	require.Len(t, preview, 1)
+	assert.Equal(t, float64(27), preview[0].Quantity)
+	assert.Equal(t, int64(2700), preview[0].Amount)
+
+	var count int64
+	require.NoError(t, db.Model(&ratingdomain.RatingResult{}).Where("billing_cycle_id = ?", cycleID).Count(&count).Error)
+	assert.Zero(t, count)
+
+	require.NoError(t, db.Model(&billingcycledomain.BillingCycle{}).
+		Where("id = ?", cycleID).
+		Update("status", billingcycledomain.BillingCycleStatusClosing).Error)
+	require.NoError(t, svc.RunRating(context.Background(), cycleID.String()))
+
+	var persisted []ratingdomain.RatingResult
+	require.NoError(t, db.Where("billing_cycle_id = ?", cycleID).Find(&persisted).Error)
+	require.Len(t, persisted, 1)
+	assert.Equal(t, preview[0].Checksum, persisted[0].Checksum)
+	assert.Equal(t, preview[0].Amount, persisted[0].Amount)
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/bwmarrin/snowflake"
 	"github.com/glebarez/sqlite"
 	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	customerdomain "github.com/smallbiznis/railzway/internal/customer/domain"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	organizationdomain "github.com/smallbiznis/railzway/internal/organization/domain"
 	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
 	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
 	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
@@ -126,6 +129,87 @@ func TestProration_MidCycleSubscriptionEnd(t *testing.T) {
 	assert.Equal(t, subEnd, result.PeriodEnd)
 }
 
+// TestProration_YearlyCycle validates proration against a full year cycle
+// (365 days in 2026) rather than a fixed month length.
+func TestProration_YearlyCycle(t *testing.T) {
+	db, svc, node := setupProrationTest(t)
+
+	orgID := node.Generate()
+	subID := node.Generate()
+	cycleID := node.Generate()
+	productID := node.Generate()
+	priceID := node.Generate()
+
+	cycleStart := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cycleEnd := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Subscription starts Oct 1: 92 days active out of 365
+	subStart := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	expectedFactor := 92.0 / 365.0
+
+	priceAmountStub := svc.(*Service).priceAmountRepo.(*priceAmountStub)
+	priceRepoStub := svc.(*Service).priceRepo.(*priceRepoStub)
+	seedProrationData(t, db, node, priceAmountStub, priceRepoStub, orgID, subID, cycleID, productID, priceID, cycleStart, cycleEnd, subStart, nil, 120000)
+
+	require.NoError(t, svc.RunRating(context.Background(), cycleID.String()))
+
+	var results []ratingdomain.RatingResult
+	db.Where("billing_cycle_id = ?", cycleID).Find(&results)
+	require.Len(t, results, 1)
+
+	assert.InDelta(t, expectedFactor, results[0].Quantity, 0.0001)
+	assert.Equal(t, int64(30247), results[0].Amount) // 120000 * 92 / 365
+	assert.Equal(t, subStart, results[0].PeriodStart)
+	assert.Equal(t, cycleEnd, results[0].PeriodEnd)
+}
+
+// TestProration_AnchoredFirstPeriod validates that the partial period opened
+// by a billing anchor day is prorated against the full anchored cycle, with
+// boundaries at local midnight in the organization's billing timezone.
+func TestProration_AnchoredFirstPeriod(t *testing.T) {
+	db, svc, node := setupProrationTest(t)
+
+	orgID := node.Generate()
+	subID := node.Generate()
+	cycleID := node.Generate()
+	productID := node.Generate()
+	priceID := node.Generate()
+
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	require.NoError(t, err)
+
+	// Activated Mar 10 (local), anchored to the 1st: partial cycle Mar 10 - Apr 1
+	// measured against the full Mar 1 - Apr 1 cycle (22 of 31 days).
+	cycleStart := time.Date(2026, 3, 10, 0, 0, 0, 0, jakarta).UTC()
+	cycleEnd := time.Date(2026, 4, 1, 0, 0, 0, 0, jakarta).UTC()
+	expectedFactor := 22.0 / 31.0
+
+	priceAmountStub := svc.(*Service).priceAmountRepo.(*priceAmountStub)
+	priceRepoStub := svc.(*Service).priceRepo.(*priceRepoStub)
+	seedProrationData(t, db, node, priceAmountStub, priceRepoStub, orgID, subID, cycleID, productID, priceID, cycleStart, cycleEnd, cycleStart, nil, 10000)
+
+	db.Model(&subscriptiondomain.Subscription{}).Where("id = ?", subID).Updates(map[string]any{
+		"billing_anchor_day": 1,
+		"billing_cycle_type": "monthly",
+	})
+	db.Create(&organizationdomain.OrganizationBillingPreferences{
+		OrgID:    orgID,
+		Currency: "USD",
+		Timezone: "Asia/Jakarta",
+	})
+
+	require.NoError(t, svc.RunRating(context.Background(), cycleID.String()))
+
+	var results []ratingdomain.RatingResult
+	db.Where("billing_cycle_id = ?", cycleID).Find(&results)
+	require.Len(t, results, 1)
+
+	assert.InDelta(t, expectedFactor, results[0].Quantity, 0.0001)
+	assert.Equal(t, int64(7097), results[0].Amount) // 10000 * 22 / 31
+	assert.Equal(t, cycleStart, results[0].PeriodStart.UTC())
+	assert.Equal(t, cycleEnd, results[0].PeriodEnd.UTC())
+}
+
 // TestProration_PlanChangeMidCycle validates PRORATION RULE 2:
 // Plan change creates MULTIPLE rating rows with different periods
 func TestProration_PlanChangeMidCycle(t *testing.T) {
@@ -321,6 +405,15 @@ func TestProration_MeteredUsageWithWindow(t *testing.T) {
 		BillingMode:    "METERED",
 	})
 
+	db.Create(&meterdomain.Meter{
+		ID:          meterID,
+		OrgID:       orgID,
+		Code:        "metered_feature",
+		Name:        "Metered Feature",
+		Aggregation: meterdomain.AggregationSum,
+		Unit:        "unit",
+	})
+
 	db.Create(&pricedomain.Price{
 		ID:        priceID,
 		OrgID:     orgID,
@@ -328,6 +421,12 @@ func TestProration_MeteredUsageWithWindow(t *testing.T) {
 		Active:    true,
 	})
 
+	priceRepoStub := svc.(*Service).priceRepo.(*priceRepoStub)
+	priceRepoStub.Prices[priceID.String()] = pricedomain.Price{
+		ID:        priceID,
+		ProductID: productID,
+	}
+
 	priceAmountStub := svc.(*Service).priceAmountRepo.(*priceAmountStub)
 	priceAmountStub.Amounts[priceID.String()] = priceamountdomain.PriceAmount{
 		PriceID:         priceID,
@@ -390,9 +489,14 @@ func setupProrationTest(t *testing.T) (*gorm.DB, ratingdomain.Service, *snowflak
 		&subscriptiondomain.SubscriptionItem{},
 		&subscriptiondomain.Subscription{},
 		&subscriptiondomain.SubscriptionEntitlement{},
+		&subscriptiondomain.SubscriptionPlanChange{},
+		&subscriptiondomain.SubscriptionItemQuantityChange{},
 		&billingcycledomain.BillingCycle{},
 		&pricedomain.Price{},
+		&meterdomain.Meter{},
 		&usagedomain.UsageEvent{},
+		&organizationdomain.OrganizationBillingPreferences{},
+		&customerdomain.Customer{},
 	)
 	require.NoError(t, err)
 
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"gorm.io/gorm"
+)
+
+// quantitySegment is a part of a flat item's window billed at one quantity.
+type quantitySegment struct {
+	Start    time.Time
+	End      time.Time
+	Quantity int32
+}
+
+// quantitySegments splits [start, end) at the item's applied quantity
+// changes. It returns nil when the quantity did not change after start, so
+// the window is billed at the item's quantity as is.
+func (s *Service) quantitySegments(
+	ctx context.Context,
+	tx *gorm.DB,
+	orgID snowflake.ID,
+	item subscriptionItemRow,
+	start, end time.Time,
+) ([]quantitySegment, error) {
+	if item.ID == 0 {
+		// Items kept on a plan change snapshot have no history.
+		return nil, nil
+	}
+
+	var changes []struct {
+		PreviousQuantity int32
+		Quantity         int32
+		EffectiveAt      time.Time
+	}
+	if err := tx.WithContext(ctx).Raw(
+		`SELECT previous_quantity, quantity, effective_at
+		 FROM subscription_item_quantity_changes
+		 WHERE org_id = ? AND subscription_item_id = ? AND status = ? AND effective_at > ?
+		 ORDER BY effective_at ASC, id ASC`,
+		orgID,
+		item.ID,
+		subscriptiondomain.QuantityChangeStatusApplied,
+		start,
+	).Scan(&changes).Error; err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	// The first change after start tells the quantity the window opened
+	// with; later changes, even past end, only matter up to end.
+	segments := make([]quantitySegment, 0, len(changes)+1)
+	cursor, quantity := start, changes[0].PreviousQuantity
+	for _, change := range changes {
+		if !change.EffectiveAt.Before(end) {
+			break
+		}
+		if change.EffectiveAt.After(cursor) {
+			segments = append(segments, quantitySegment{Start: cursor, End: change.EffectiveAt, Quantity: quantity})
+			cursor = change.EffectiveAt
+		}
+		quantity = change.Quantity
+	}
+	if end.After(cursor) {
+		segments = append(segments, quantitySegment{Start: cursor, End: end, Quantity: quantity})
+	}
+	return segments, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestQuantityChangeSegments(t *testing.T) {
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// seed creates a monthly subscription with 5 seats at $10 and records
+	// the given quantity changes of the seat item.
+	seed := func(t *testing.T, timing pricedomain.BillingTiming, changes ...subscriptiondomain.SubscriptionItemQuantityChange) (*gorm.DB, ratingdomain.Service, *snowflake.Node, snowflake.ID, snowflake.ID) {
+		db, svc, node := setupProrationTest(t)
+		orgID := node.Generate()
+		subID := node.Generate()
+		productID := node.Generate()
+		priceID := node.Generate()
+		itemID := node.Generate()
+
+		priceRepo := svc.(*Service).priceRepo.(*priceRepoStub)
+		priceAmounts := svc.(*Service).priceAmountRepo.(*priceAmountStub)
+		priceRepo.Prices[priceID.String()] = pricedomain.Price{ID: priceID, ProductID: productID, BillingTiming: timing}
+		priceAmounts.Amounts[priceID.String()] = priceamountdomain.PriceAmount{PriceID: priceID, UnitAmountCents: 1000, Currency: "USD"}
+
+		activatedAt := jan
+		require.NoError(t, db.Create(&subscriptiondomain.Subscription{
+			ID:               subID,
+			OrgID:            orgID,
+			CustomerID:       node.Generate(),
+			Status:           subscriptiondomain.SubscriptionStatusActive,
+			StartAt:          jan,
+			ActivatedAt:      &activatedAt,
+			BillingCycleType: "monthly",
+		}).Error)
+		quantity := int32(5)
+		if len(changes) > 0 {
+			quantity = changes[len(changes)-1].Quantity
+		}
+		require.NoError(t, db.Create(&subscriptiondomain.SubscriptionItem{
+			ID:             itemID,
+			OrgID:          orgID,
+			SubscriptionID: subID,
+			PriceID:        priceID,
+			BillingMode:    "LICENSED",
+			Quantity:       quantity,
+		}).Error)
+		require.NoError(t, db.Create(&subscriptiondomain.SubscriptionEntitlement{
+			ID: node.Generate(), OrgID: orgID, SubscriptionID: subID, ProductID: productID,
+			FeatureCode: "seats", EffectiveFrom: jan,
+		}).Error)
+		for _, change := range changes {
+			change.ID = node.Generate()
+			change.OrgID = orgID
+			change.SubscriptionID = subID
+			change.SubscriptionItemID = itemID
+			change.PriceID = priceID
+			change.Status = subscriptiondomain.QuantityChangeStatusApplied
+			require.NoError(t, db.Create(&change).Error)
+		}
+		return db, svc, node, orgID, subID
+	}
+
+	rate := func(t *testing.T, db *gorm.DB, svc ratingdomain.Service, node *snowflake.Node, orgID, subID snowflake.ID, start, end time.Time) []ratingdomain.RatingResult {
+		cycleID := node.Generate()
+		require.NoError(t, db.Create(&billingcycledomain.BillingCycle{
+			ID:             cycleID,
+			OrgID:          orgID,
+			SubscriptionID: subID,
+			PeriodStart:    start,
+			PeriodEnd:      end,
+			Status:         billingcycledomain.BillingCycleStatusClosing,
+		}).Error)
+		require.NoError(t, svc.RunRating(context.Background(), cycleID.String()))
+		var results []ratingdomain.RatingResult
+		require.NoError(t, db.Where("billing_cycle_id = ?", cycleID).Order("period_start ASC").Find(&results).Error)
+		return results
+	}
+
+	t.Run("each quantity is billed for its part of the cycle", func(t *testing.T) {
+		db, svc, node, orgID, subID := seed(t, pricedomain.InArrears, subscriptiondomain.SubscriptionItemQuantityChange{
+			PreviousQuantity: 5,
+			Quantity:         12,
+			EffectiveAt:      time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC),
+		})
+		results := rate(t, db, svc, node, orgID, subID, jan, feb)
+		require.Len(t, results, 2)
+
+		assert.Equal(t, jan, results[0].PeriodStart.UTC())
+		assert.InDelta(t, 5*9.0/31, results[0].Quantity, 0.0001)
+		assert.Equal(t, int64(1452), results[0].Amount) // 5 seats for 9 of 31 days
+
+		assert.Equal(t, time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC), results[1].PeriodStart.UTC())
+		assert.InDelta(t, 12*22.0/31, results[1].Quantity, 0.0001)
+		assert.Equal(t, int64(8516), results[1].Amount) // 12 seats for 22 of 31 days
+	})
+
+	t.Run("quantities above the old column limit", func(t *testing.T) {
+		db, svc, node, orgID, subID := seed(t, pricedomain.InArrears, subscriptiondomain.SubscriptionItemQuantityChange{
+			PreviousQuantity: 5,
+			Quantity:         500,
+			EffectiveAt:      jan,
+		})
+		results := rate(t, db, svc, node, orgID, subID, jan, feb)
+		require.Len(t, results, 1)
+		assert.Equal(t, int64(500000), results[0].Amount)
+	})
+
+	t.Run("later changes do not reach back into the cycle", func(t *testing.T) {
+		db, svc, node, orgID, subID := seed(t, pricedomain.InArrears, subscriptiondomain.SubscriptionItemQuantityChange{
+			PreviousQuantity: 5,
+			Quantity:         12,
+			EffectiveAt:      time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC),
+		})
+		results := rate(t, db, svc, node, orgID, subID, jan, feb)
+		require.Len(t, results, 1)
+		assert.Equal(t, int64(5000), results[0].Amount)
+	})
+
+	t.Run("advance charge is settled against the segments", func(t *testing.T) {
+		db, svc, node, orgID, subID := seed(t, pricedomain.InAdvance)
+		rate(t, db, svc, node, orgID, subID, jan, feb)
+
+		var item subscriptiondomain.SubscriptionItem
+		require.NoError(t, db.Where("subscription_id = ?", subID).First(&item).Error)
+		require.NoError(t, db.Model(&item).Update("quantity", 12).Error)
+		require.NoError(t, db.Create(&subscriptiondomain.SubscriptionItemQuantityChange{
+			ID:                 node.Generate(),
+			OrgID:              orgID,
+			SubscriptionID:     subID,
+			SubscriptionItemID: item.ID,
+			PriceID:            item.PriceID,
+			PreviousQuantity:   5,
+			Quantity:           12,
+			Status:             subscriptiondomain.QuantityChangeStatusApplied,
+			EffectiveAt:        time.Date(2026, 2, 8, 0, 0, 0, 0, time.UTC),
+		}).Error)
+
+		results := rate(t, db, svc, node, orgID, subID, feb, mar)
+		require.Len(t, results, 2)
+		assert.Equal(t, "advance_adjustment", results[0].Source)
+		// 5 seats for 7 of 28 days and 12 for 21, less the 5 billed ahead.
+		assert.Equal(t, int64(1250+9000-5000), results[0].Amount)
+		assert.Equal(t, "advance", results[1].Source)
+		assert.Equal(t, int64(12000), results[1].Amount)
+	})
+}
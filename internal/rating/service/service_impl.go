@@ -5,18 +5,18 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"math"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
 	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	"github.com/smallbiznis/railzway/internal/money"
 	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
 	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
+	pricetierdomain "github.com/smallbiznis/railzway/internal/pricetier/domain"
 	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
 	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
-	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
 	"github.com/smallbiznis/railzway/pkg/repository"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
@@ -31,6 +31,7 @@ type Service struct {
 	ratingrepo      repository.Repository[ratingdomain.RatingResult]
 	priceRepo       repository.Repository[pricedomain.Price]
 	priceAmountRepo priceamountdomain.Repository
+	priceTierRepo   pricetierdomain.Repository
 }
 
 type ServiceParam struct {
@@ -40,6 +41,7 @@ type ServiceParam struct {
 	Log             *zap.Logger
 	GenID           *snowflake.Node
 	PriceAmountRepo priceamountdomain.Repository
+	PriceTierRepo   pricetierdomain.Repository
 }
 
 func NewService(p ServiceParam) ratingdomain.Service {
@@ -51,6 +53,7 @@ func NewService(p ServiceParam) ratingdomain.Service {
 		ratingrepo:      repository.ProvideStore[ratingdomain.RatingResult](p.DB),
 		priceRepo:       repository.ProvideStore[pricedomain.Price](p.DB),
 		priceAmountRepo: p.PriceAmountRepo,
+		priceTierRepo:   p.PriceTierRepo,
 	}
 }
 
@@ -70,7 +73,7 @@ func (s *Service) RunRating(ctx context.Context, billingCycleID string) error {
 	if cycle.Status != billingcycledomain.BillingCycleStatusClosing {
 		return ratingdomain.ErrBillingCycleNotClosing
 	}
-	if !cycle.PeriodEnd.After(cycle.PeriodStart) {
+	if cycle.PeriodEnd.Before(cycle.PeriodStart) {
 		return ratingdomain.ErrInvalidBillingCycle
 	}
 
@@ -95,107 +98,330 @@ func (s *Service) RunRating(ctx context.Context, billingCycleID string) error {
 		if err := tx.Where("billing_cycle_id = ?", cycle.ID).Delete(&ratingdomain.RatingResult{}).Error; err != nil {
 			return err
 		}
+		if cycle.Currency == "" {
+			if cycle.Currency, err = s.resolveCurrency(ctx, tx, cycle, items); err != nil {
+				return err
+			}
+			if err := s.lockCurrency(ctx, tx, cycle); err != nil {
+				return err
+			}
+		}
 
-		// 2. Load SNAPSHOTTED Entitlements with MeterID/ProductID
-		entitlements, err := s.loadEntitlements(ctx, tx, cycle.OrgID, cycle.SubscriptionID, cycle.PeriodStart, cycle.PeriodEnd)
-		if err != nil {
+		return s.rateCycle(ctx, tx, cycle, subscription, items, func(result ratingdomain.RatingResult) error {
+			return s.insertRatingResult(tx, result)
+		})
+	})
+}
+
+// PreviewRating rates an OPEN billing cycle against the usage ingested so far
+// and returns the results without persisting them. The results are the ones
+// RunRating would write if the cycle closed now.
+func (s *Service) PreviewRating(ctx context.Context, billingCycleID string) ([]ratingdomain.RatingResult, error) {
+	return s.dryRunCycle(ctx, billingCycleID, billingcycledomain.BillingCycleStatusOpen, ratingdomain.ErrBillingCycleNotOpen)
+}
+
+// RerateCycle rates a CLOSED billing cycle against its current usage and
+// returns the results without persisting them. Comparing them with the stored
+// results yields the delta caused by usage corrected after the cycle closed.
+func (s *Service) RerateCycle(ctx context.Context, billingCycleID string) ([]ratingdomain.RatingResult, error) {
+	return s.dryRunCycle(ctx, billingCycleID, billingcycledomain.BillingCycleStatusClosed, ratingdomain.ErrBillingCycleNotClosed)
+}
+
+func (s *Service) dryRunCycle(
+	ctx context.Context,
+	billingCycleID string,
+	status billingcycledomain.BillingCycleStatus,
+	statusErr error,
+) ([]ratingdomain.RatingResult, error) {
+	cycleID, err := parseID(billingCycleID)
+	if err != nil {
+		return nil, ratingdomain.ErrInvalidBillingCycle
+	}
+
+	cycle, err := s.loadBillingCycle(ctx, cycleID)
+	if err != nil {
+		return nil, err
+	}
+	if cycle == nil {
+		return nil, ratingdomain.ErrBillingCycleNotFound
+	}
+	if cycle.Status != status {
+		return nil, statusErr
+	}
+	if cycle.PeriodEnd.Before(cycle.PeriodStart) {
+		return nil, ratingdomain.ErrInvalidBillingCycle
+	}
+
+	subscription, err := s.loadSubscription(ctx, cycle.OrgID, cycle.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil {
+		return nil, ratingdomain.ErrSubscriptionNotFound
+	}
+
+	items, err := s.listSubscriptionItems(ctx, cycle.OrgID, cycle.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ratingdomain.ErrNoSubscriptionItems
+	}
+
+	if cycle.Currency == "" {
+		if cycle.Currency, err = s.resolveCurrency(ctx, s.db, cycle, items); err != nil {
+			return nil, err
+		}
+	}
+
+	// Results are deduplicated by checksum, mirroring ON CONFLICT (checksum) DO NOTHING.
+	results := make([]ratingdomain.RatingResult, 0, len(items))
+	seen := make(map[string]struct{}, len(items))
+	err = s.rateCycle(ctx, s.db.WithContext(ctx), cycle, subscription, items, func(result ratingdomain.RatingResult) error {
+		if _, ok := seen[result.Checksum]; ok {
+			return nil
+		}
+		seen[result.Checksum] = struct{}{}
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ratingSink receives every rating result produced for a cycle.
+type ratingSink func(ratingdomain.RatingResult) error
+
+func (s *Service) rateCycle(
+	ctx context.Context,
+	tx *gorm.DB,
+	cycle *billingCycleRow,
+	subscription *subscriptiondomain.Subscription,
+	items []subscriptionItemRow,
+	emit ratingSink,
+) error {
+	var err error
+	if cycle.RoundingMode, err = s.loadRoundingMode(ctx, tx, cycle.OrgID); err != nil {
+		return err
+	}
+	if cycle.PeriodEnd.Equal(cycle.PeriodStart) {
+		return s.rateActivation(ctx, tx, cycle, subscription, items, emit)
+	}
+
+	// 2. Load SNAPSHOTTED Entitlements with MeterID/ProductID
+	entitlements, err := s.loadEntitlements(ctx, tx, cycle.OrgID, cycle.SubscriptionID, cycle.PeriodStart, cycle.PeriodEnd)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	// Cycle Duration for Proration
+	cycleDuration, err := s.prorationBasis(ctx, tx, cycle, subscription)
+	if err != nil {
+		return err
+	}
+	if cycleDuration <= 0 {
+		return ratingdomain.ErrInvalidBillingCycle
+	}
+
+	changes, err := s.loadPlanChanges(ctx, tx, cycle)
+	if err != nil {
+		return err
+	}
+
+	// Flat items billed in advance are rated like any other and settled
+	// against their advance charge afterwards.
+	advance, err := s.loadAdvanceBilling(ctx, tx, cycle, items, changes)
+	if err != nil {
+		return err
+	}
+	rate := advance.hold(emit)
+	if len(changes) > 0 {
+		if err := s.rateCycleWithPlanChanges(ctx, tx, cycle, subscription, items, changes, entitlements, cycleDuration, now, rate); err != nil {
 			return err
 		}
+	} else if err := s.rateItems(ctx, tx, cycle, subscription, items, entitlements, cycleDuration, now, rate); err != nil {
+		return err
+	}
+	if err := s.settleAdvance(cycle, advance, now, emit); err != nil {
+		return err
+	}
 
-		now := time.Now().UTC()
-		// Cycle Duration for Proration
-		cycleDuration := cycle.PeriodEnd.Sub(cycle.PeriodStart).Seconds()
-		if cycleDuration <= 0 {
-			return ratingdomain.ErrInvalidBillingCycle
+	return s.rateAdvance(ctx, tx, cycle, subscription, items, entitlements, now, emit)
+}
+
+// rateItems rates every item over its effective window in a cycle without
+// plan changes.
+func (s *Service) rateItems(
+	ctx context.Context,
+	tx *gorm.DB,
+	cycle *billingCycleRow,
+	subscription *subscriptiondomain.Subscription,
+	items []subscriptionItemRow,
+	entitlements []subscriptiondomain.SubscriptionEntitlement,
+	cycleDuration float64,
+	now time.Time,
+	emit ratingSink,
+) error {
+	for _, item := range items {
+		// Resolve Feature Code using Entitlements ONLY
+		// ALSO resolve Entitlement Validity Window for Plan Change splitting
+		featureCode, ent, err := s.resolveEntitlementWithWindow(ctx, tx, item, entitlements)
+		if err != nil {
+			return fmt.Errorf("rating failed for item %s: %w", item.ID, err)
 		}
 
-		for _, item := range items {
-			// Resolve Feature Code using Entitlements ONLY
-			// ALSO resolve Entitlement Validity Window for Plan Change splitting
-			featureCode, ent, err := s.resolveEntitlementWithWindow(ctx, tx, item, entitlements)
-			if err != nil {
-				return fmt.Errorf("rating failed for item %s: %w", item.ID, err)
-			}
+		start, end := effectiveWindow(cycle.PeriodStart, cycle.PeriodEnd, subscription, ent)
+		if !end.After(start) {
+			// Item not active in this window intersection
+			continue
+		}
 
-			// CALCULATE GLOBAL EFFECTIVE WINDOW
-			// Intersection of:
-			// 1. Billing Cycle [Start, End]
-			// 2. Subscription [StartAt, EndAt/CanceledAt]
-			// 3. Entitlement [EffectiveFrom, EffectiveTo] (Plan Change)
+		if err := s.rateItem(ctx, tx, cycle, item, featureCode, start, end, cycleDuration, now, emit); err != nil {
+			return err
+		}
+	}
 
-			start := cycle.PeriodStart
-			if subscription.StartAt.After(start) {
-				start = subscription.StartAt
-			}
-			if ent != nil && ent.EffectiveFrom.After(start) {
-				start = ent.EffectiveFrom
-			}
+	return nil
+}
 
-			end := cycle.PeriodEnd
-			if subscription.EndedAt != nil && subscription.EndedAt.Before(end) {
-				end = *subscription.EndedAt
-			}
-			if subscription.CanceledAt != nil && subscription.CanceledAt.Before(end) {
-				end = *subscription.CanceledAt
-			}
-			if ent != nil && ent.EffectiveTo != nil && ent.EffectiveTo.Before(end) {
-				end = *ent.EffectiveTo
-			}
+// effectiveWindow bounds [lower, upper] by the subscription's lifetime and,
+// when given, the entitlement's validity window.
+func effectiveWindow(
+	lower, upper time.Time,
+	subscription *subscriptiondomain.Subscription,
+	ent *subscriptiondomain.SubscriptionEntitlement,
+) (time.Time, time.Time) {
+	// CALCULATE GLOBAL EFFECTIVE WINDOW
+	// Intersection of:
+	// 1. Billing Cycle [Start, End]
+	// 2. Subscription [StartAt, EndAt/CanceledAt]
+	// 3. Entitlement [EffectiveFrom, EffectiveTo] (Plan Change)
+	// 4. Trial, when it covers the start of the window
+
+	start := lower
+	if subscription.StartAt.After(start) {
+		start = subscription.StartAt
+	}
+	start = billableStart(subscription, start)
+	if ent != nil && ent.EffectiveFrom.After(start) {
+		start = ent.EffectiveFrom
+	}
 
-			if !end.After(start) {
-				// Item not active in this window intersection
-				continue
-			}
+	end := upper
+	if subscription.EndedAt != nil && subscription.EndedAt.Before(end) {
+		end = *subscription.EndedAt
+	}
+	if subscription.CanceledAt != nil && subscription.CanceledAt.Before(end) {
+		end = *subscription.CanceledAt
+	}
+	if ent != nil && ent.EffectiveTo != nil && ent.EffectiveTo.Before(end) {
+		end = *ent.EffectiveTo
+	}
+	return start, end
+}
 
-			// Proration Data
-			activeSeconds := end.Sub(start).Seconds()
-			prorationFactor := activeSeconds / cycleDuration
-			// Clamp factor to 0..1 (floating point safety)
-			if prorationFactor > 1.0 {
-				prev := prorationFactor
-				prorationFactor = 1.0
-				s.log.Warn("clamped proration > 1", zap.Float64("prev", prev))
-			}
-			if prorationFactor < 0.0 {
-				prorationFactor = 0.0
-			} // Should be caught by end > start
+// billableStart moves start past the subscription's trial when the trial is
+// running at start. Nothing is billed during a trial.
+func billableStart(subscription *subscriptiondomain.Subscription, start time.Time) time.Time {
+	if subscription.TrialEndsAt == nil || !subscription.TrialEndsAt.After(start) {
+		return start
+	}
+	if subscription.TrialStartsAt != nil && subscription.TrialStartsAt.After(start) {
+		return start
+	}
+	return *subscription.TrialEndsAt
+}
 
-			// Pass 'start' and 'end' as the RATING WINDOW for this item
+// rateItem rates one subscription item over its effective window [start, end].
+func (s *Service) rateItem(
+	ctx context.Context,
+	tx *gorm.DB,
+	cycle *billingCycleRow,
+	item subscriptionItemRow,
+	featureCode string,
+	start, end time.Time,
+	cycleDuration float64,
+	now time.Time,
+	emit ratingSink,
+) error {
+	// Proration Data
+	activeSeconds := end.Sub(start).Seconds()
+	prorationFactor := activeSeconds / cycleDuration
+	// Clamp factor to 0..1 (floating point safety)
+	if prorationFactor > 1.0 {
+		prev := prorationFactor
+		prorationFactor = 1.0
+		s.log.Warn("clamped proration > 1", zap.Float64("prev", prev))
+	}
+	if prorationFactor < 0.0 {
+		prorationFactor = 0.0
+	} // Should be caught by end > start
 
-			if item.MeterID == nil {
-				if err := s.rateFlatItem(ctx, tx, cycle, item, featureCode, start, end, prorationFactor, now); err != nil {
-					return err
-				}
-				continue
-			}
+	// Pass 'start' and 'end' as the RATING WINDOW for this item
 
-			windows, err := s.buildPriceWindows(ctx, tx, cycle.OrgID, item.PriceID, item.MeterID, start, end)
-			if err != nil {
+	if item.MeterID == nil {
+		segments, err := s.quantitySegments(ctx, tx, cycle.OrgID, item, start, end)
+		if err != nil {
+			return err
+		}
+		if len(segments) == 0 {
+			return s.rateFlatItem(ctx, tx, cycle, item, featureCode, start, end, prorationFactor, now, emit)
+		}
+		// A quantity change splits the window; each part is prorated on
+		// its own at the quantity in force for it.
+		for _, segment := range segments {
+			segmentItem := item
+			segmentItem.Quantity = segment.Quantity
+			factor := segment.End.Sub(segment.Start).Seconds() / cycleDuration
+			if err := s.rateFlatItem(ctx, tx, cycle, segmentItem, featureCode, segment.Start, segment.End, factor, now, emit); err != nil {
 				return err
 			}
+		}
+		return nil
+	}
 
-			for _, window := range windows {
-				qty, err := s.aggregateUsage(tx, cycle.OrgID, cycle.SubscriptionID, *item.MeterID, window.Start, window.End)
-				if err != nil {
-					return err
-				}
+	price, err := s.loadPrice(ctx, item)
+	if err != nil {
+		return err
+	}
+	aggregation, err := s.resolveAggregation(ctx, tx, cycle.OrgID, *item.MeterID, price)
+	if err != nil {
+		return err
+	}
+	if isTieredPricingModel(price.PricingModel) {
+		// Tiers belong to the price, not to a price amount version, so
+		// the whole effective window is rated against one tier table.
+		return s.rateTieredItem(ctx, tx, cycle, item, price, aggregation, featureCode, start, end, now, emit)
+	}
 
-				if qty < 0 {
-					return ratingdomain.ErrInvalidQuantity
-				}
+	windows, err := s.buildPriceWindows(ctx, tx, cycle.OrgID, item.PriceID, item.MeterID, cycle.Currency, start, end)
+	if err != nil {
+		return err
+	}
 
-				// Only persist if there is quantity (optional optimization? Or explicit zero?)
-				// Stripe often rates even 0 usage to show line item.
-				// But we'll stick to logic provided.
+	for _, window := range windows {
+		qty, err := s.aggregateUsage(tx, cycle.OrgID, cycle.SubscriptionID, *item.MeterID, aggregation, window.Start, window.End)
+		if err != nil {
+			return err
+		}
 
-				if err := s.insertRatingWindow(tx, cycle, item, window, qty, "usage_events", featureCode, now); err != nil {
-					return err
-				}
-			}
+		if qty < 0 {
+			return ratingdomain.ErrInvalidQuantity
 		}
 
-		return nil
-	})
+		// Only persist if there is quantity (optional optimization? Or explicit zero?)
+		// Stripe often rates even 0 usage to show line item.
+		// But we'll stick to logic provided.
+
+		if err := s.rateWindow(cycle, item, window, qty, "usage_events", featureCode, now, emit); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (s *Service) loadEntitlements(
@@ -255,6 +481,20 @@ func (s *Service) resolveEntitlementWithWindow(
 
 }
 
+func (s *Service) loadPrice(ctx context.Context, item subscriptionItemRow) (*pricedomain.Price, error) {
+	price, err := s.priceRepo.FindOne(ctx, &pricedomain.Price{
+		ID:    item.PriceID,
+		OrgID: item.OrgID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if price == nil {
+		return nil, ratingdomain.ErrPriceNotFound
+	}
+	return price, nil
+}
+
 type billingCycleRow struct {
 	ID             snowflake.ID
 	OrgID          snowflake.ID
@@ -262,6 +502,13 @@ type billingCycleRow struct {
 	PeriodStart    time.Time
 	PeriodEnd      time.Time
 	Status         billingcycledomain.BillingCycleStatus
+	// Currency is the subscription's currency; every price is rated in it.
+	// Subscriptions created before currencies were locked have none until
+	// their first rating resolves and locks one.
+	Currency string
+	// RoundingMode rounds every rated line of the cycle; it is loaded
+	// from the organization's billing preferences when rating starts.
+	RoundingMode money.RoundingMode `gorm:"-"`
 }
 
 type subscriptionItemRow struct {
@@ -270,14 +517,26 @@ type subscriptionItemRow struct {
 	SubscriptionID snowflake.ID
 	PriceID        snowflake.ID
 	MeterID        *snowflake.ID
+	Quantity       int32
+	BillingTiming  *string
+}
+
+// units returns the number of licensed units a flat item bills for.
+func (i subscriptionItemRow) units() float64 {
+	if i.Quantity <= 0 {
+		return 1
+	}
+	return float64(i.Quantity)
 }
 
 func (s *Service) loadBillingCycle(ctx context.Context, id snowflake.ID) (*billingCycleRow, error) {
 	var row billingCycleRow
 	err := s.db.WithContext(ctx).Raw(
-		`SELECT id, org_id, subscription_id, period_start, period_end, status
-		 FROM billing_cycles
-		 WHERE id = ?`,
+		`SELECT bc.id, bc.org_id, bc.subscription_id, bc.period_start, bc.period_end, bc.status,
+		        COALESCE(s.default_currency, '') AS currency
+		 FROM billing_cycles bc
+		 LEFT JOIN subscriptions s ON s.id = bc.subscription_id
+		 WHERE bc.id = ?`,
 		id,
 	).Scan(&row).Error
 	if err != nil {
@@ -292,7 +551,7 @@ func (s *Service) loadBillingCycle(ctx context.Context, id snowflake.ID) (*billi
 func (s *Service) listSubscriptionItems(ctx context.Context, orgID, subscriptionID snowflake.ID) ([]subscriptionItemRow, error) {
 	var items []subscriptionItemRow
 	err := s.db.WithContext(ctx).Raw(
-		`SELECT id, org_id, subscription_id, price_id, meter_id
+		`SELECT id, org_id, subscription_id, price_id, meter_id, quantity, billing_timing
 		 FROM subscription_items
 		 WHERE org_id = ? AND subscription_id = ?`,
 		orgID,
@@ -318,24 +577,55 @@ func (s *Service) loadSubscription(ctx context.Context, orgID, subscriptionID sn
 	return &sub, nil
 }
 
-func (s *Service) aggregateUsage(tx *gorm.DB, orgID, subscriptionID, meterID snowflake.ID, periodStart, periodEnd time.Time) (float64, error) {
-	var quantity float64
-	err := tx.Raw(
-		`SELECT COALESCE(SUM(value), 0)
-		 FROM usage_events
-		 WHERE org_id = ? AND subscription_id = ? AND meter_id = ?
-		 AND recorded_at >= ? AND recorded_at < ? AND status = ?`,
+// prorationBasis returns the length, in seconds, of the full cycle that
+// proration factors are measured against. Subscriptions with a billing anchor
+// day open with a partial period, which is prorated against the full anchored
+// cycle that ends at the same boundary. A cycle cut short by a plan change is
+// prorated against the nominal cycle it belongs to.
+func (s *Service) prorationBasis(ctx context.Context, tx *gorm.DB, cycle *billingCycleRow, subscription *subscriptiondomain.Subscription) (float64, error) {
+	periodStart := cycle.PeriodStart
+	periodEnd := cycle.PeriodEnd
+	spec, ok := billingcycledomain.ParseCycleType(subscription.BillingCycleType)
+	switch {
+	case ok && subscription.BillingAnchorDay != nil && spec.SupportsAnchorDay():
+		loc, err := billingcycledomain.LoadBillingLocation(ctx, tx, cycle.OrgID)
+		if err != nil {
+			return 0, err
+		}
+		nominalEnd := spec.NextAnchoredPeriodEnd(cycle.PeriodStart, int(*subscription.BillingAnchorDay), loc)
+		if nominalEnd.After(periodEnd) {
+			periodEnd = nominalEnd
+		}
+		nominalStart := spec.AnchoredPeriodStart(periodEnd, int(*subscription.BillingAnchorDay), loc)
+		if nominalStart.Before(periodStart) {
+			periodStart = nominalStart
+		}
+	case ok && subscription.ActivatedAt != nil:
+		nominalStart, nominalEnd := spec.NominalPeriod(*subscription.ActivatedAt, cycle.PeriodStart)
+		// Only cycles inside their nominal period are widened; anything
+		// else keeps its own length as before.
+		if !nominalStart.After(cycle.PeriodStart) && !nominalEnd.Before(cycle.PeriodEnd) {
+			periodStart, periodEnd = nominalStart, nominalEnd
+		}
+	}
+	return periodEnd.Sub(periodStart).Seconds(), nil
+}
+
+// loadRoundingMode returns how the organization rounds rated amounts,
+// HALF_UP unless its billing preferences say otherwise.
+func (s *Service) loadRoundingMode(ctx context.Context, tx *gorm.DB, orgID snowflake.ID) (money.RoundingMode, error) {
+	var mode string
+	err := tx.WithContext(ctx).Raw(
+		`SELECT rounding_mode
+		 FROM organization_billing_preferences
+		 WHERE org_id = ?
+		 LIMIT 1`,
 		orgID,
-		subscriptionID,
-		meterID,
-		periodStart,
-		periodEnd,
-		usagedomain.UsageStatusEnriched,
-	).Scan(&quantity).Error
+	).Scan(&mode).Error
 	if err != nil {
-		return 0, err
+		return "", err
 	}
-	return quantity, nil
+	return money.ParseRoundingMode(mode)
 }
 
 type priceWindow struct {
@@ -353,9 +643,10 @@ func (s *Service) rateFlatItem(
 	periodStart, periodEnd time.Time,
 	prorationFactor float64,
 	now time.Time,
+	emit ratingSink,
 ) error {
 	// Resolve Base Price Amount at start of window
-	priceAmount, err := s.resolvePriceAmountAt(ctx, tx, cycle.OrgID, item.PriceID, nil, periodStart)
+	priceAmount, err := s.resolvePriceAmountAt(ctx, tx, cycle.OrgID, item.PriceID, nil, cycle.Currency, periodStart)
 	if err != nil {
 		return err
 	}
@@ -367,9 +658,8 @@ func (s *Service) rateFlatItem(
 	// Only apply if factor < 1.0 (to avoid rounding errors on full periods perhaps? or consistent application?)
 	// Strict: Always apply factor.
 
-	baseAmount := float64(priceAmount.UnitAmountCents)
-	proratedAmount := baseAmount * prorationFactor
-	finalAmount := int64(math.Floor(proratedAmount + 0.5)) // Round to nearest cent
+	quantity := prorationFactor * item.units()
+	finalAmount := lineAmount(quantity, priceAmount.UnitAmount(), cycle.RoundingMode)
 
 	window := priceWindow{
 		Start:  periodStart,
@@ -378,18 +668,18 @@ func (s *Service) rateFlatItem(
 	}
 
 	// Override amount in result logic?
-	// insertRatingWindow calculates amount from Qty * UnitPrice.
+	// rateWindow calculates amount from Qty * UnitPrice.
 	// For Flat Rate: Qty = 1, UnitPrice = Prorated Amount?
 	// Or Qty = ProrationFactor, UnitPrice = Base?
 	// Flat fees usually Qty=1.
 	// Let's pass the Computed Amount explicitly or adjust usage.
 	// insertRatingResult takes `quantity` and `unitPrice` and `amount`.
-	// Let's modify `insertRatingWindow` to accept override amount or handle flat logic?
+	// Let's modify `rateWindow` to accept override amount or handle flat logic?
 	// Or better: `insertRatingResult`
 
 	checksum := buildChecksum(cycle.ID, cycle.SubscriptionID, item.PriceID, item.MeterID, featureCode, window.Start, window.End)
 
-	return s.insertRatingResult(tx, ratingdomain.RatingResult{
+	return emit(ratingdomain.RatingResult{
 		ID:             s.genID.Generate(),
 		OrgID:          cycle.OrgID,
 		SubscriptionID: cycle.SubscriptionID,
@@ -400,14 +690,15 @@ func (s *Service) rateFlatItem(
 
 		Source: "flat_rate",
 
-		Quantity: prorationFactor, // Store factor as quantity for visibility? Or 1?
+		Quantity: quantity, // Store factor as quantity for visibility? Or 1?
 		// User Prompt: "Persist proration-adjusted values into: rating_results.quantity, rating_results.amount"
 		// If I set Quantity = Factor, and UnitPrice = Base, then Amount = Factor * Base.
 		// That works perfectly for explaining the calculation!
 
-		UnitPrice: priceAmount.UnitAmountCents,
-		Amount:    finalAmount, // Result of math
-		Currency:  priceAmount.Currency,
+		UnitPrice:        priceAmount.UnitAmountCents,
+		UnitPriceDecimal: fractionalUnitPrice(priceAmount.UnitAmount()),
+		Amount:           finalAmount, // Result of math
+		Currency:         priceAmount.Currency,
 
 		PeriodStart: window.Start,
 		PeriodEnd:   window.End,
@@ -422,17 +713,18 @@ func (s *Service) buildPriceWindows(
 	tx *gorm.DB,
 	orgID, priceID snowflake.ID,
 	meterID *snowflake.ID,
+	currency string,
 	periodStart, periodEnd time.Time,
 ) ([]priceWindow, error) {
 	boundaries := []time.Time{periodStart, periodEnd}
 
-	specific, err := s.priceAmountRepo.ListOverlapping(ctx, tx, orgID, priceID, meterID, "", periodStart, periodEnd)
+	specific, err := s.priceAmountRepo.ListOverlapping(ctx, tx, orgID, priceID, meterID, currency, periodStart, periodEnd)
 	if err != nil {
 		return nil, err
 	}
 	boundaries = appendEffectiveBoundaries(boundaries, specific, periodStart, periodEnd)
 
-	defaults, err := s.priceAmountRepo.ListOverlapping(ctx, tx, orgID, priceID, nil, "", periodStart, periodEnd)
+	defaults, err := s.priceAmountRepo.ListOverlapping(ctx, tx, orgID, priceID, nil, currency, periodStart, periodEnd)
 	if err != nil {
 		return nil, err
 	}
@@ -448,7 +740,7 @@ func (s *Service) buildPriceWindows(
 		}
 
 		// Resolve price by usage time to keep rating historically correct.
-		amount, err := s.resolvePriceAmountAt(ctx, tx, orgID, priceID, meterID, start)
+		amount, err := s.resolvePriceAmountAt(ctx, tx, orgID, priceID, meterID, currency, start)
 		if err != nil {
 			return nil, err
 		}
@@ -466,25 +758,28 @@ func (s *Service) buildPriceWindows(
 	return windows, nil
 }
 
+// resolvePriceAmountAt returns the price amount in effect at the given time
+// in the subscription's currency; a price without an amount in it is not
+// rated. An empty currency takes any amount.
 func (s *Service) resolvePriceAmountAt(
 	ctx context.Context,
 	tx *gorm.DB,
 	orgID, priceID snowflake.ID,
 	meterID *snowflake.ID,
+	currency string,
 	at time.Time,
 ) (*priceamountdomain.PriceAmount, error) {
-	amount, err := s.priceAmountRepo.FindEffectiveAt(ctx, tx, orgID, priceID, meterID, "", at)
+	amount, err := s.priceAmountRepo.FindEffectiveAt(ctx, tx, orgID, priceID, meterID, currency, at)
 	if err != nil {
 		return nil, err
 	}
 	if amount != nil || meterID == nil {
 		return amount, nil
 	}
-	return s.priceAmountRepo.FindEffectiveAt(ctx, tx, orgID, priceID, nil, "", at)
+	return s.priceAmountRepo.FindEffectiveAt(ctx, tx, orgID, priceID, nil, currency, at)
 }
 
-func (s *Service) insertRatingWindow(
-	tx *gorm.DB,
+func (s *Service) rateWindow(
 	cycle *billingCycleRow,
 	item subscriptionItemRow,
 	window priceWindow,
@@ -492,6 +787,7 @@ func (s *Service) insertRatingWindow(
 	source string,
 	featureCode string,
 	now time.Time,
+	emit ratingSink,
 ) error {
 	if quantity < 0 {
 		return ratingdomain.ErrInvalidQuantity
@@ -500,8 +796,7 @@ func (s *Service) insertRatingWindow(
 	unitPrice := window.Amount.UnitAmountCents
 
 	// Rating is windowed by price versions to keep historical invoices stable.
-	rawAmount := quantity * float64(unitPrice)
-	amount := roundMoney(rawAmount)
+	amount := lineAmount(quantity, window.Amount.UnitAmount(), cycle.RoundingMode)
 
 	if window.Amount.MinimumAmountCents != nil && *window.Amount.MinimumAmountCents > 0 {
 		amount = max(amount, *window.Amount.MinimumAmountCents)
@@ -512,26 +807,111 @@ func (s *Service) insertRatingWindow(
 
 	checksum := buildChecksum(cycle.ID, cycle.SubscriptionID, item.PriceID, item.MeterID, featureCode, window.Start, window.End)
 
-	return s.insertRatingResult(tx, ratingdomain.RatingResult{
-		ID:             s.genID.Generate(),
-		OrgID:          cycle.OrgID,
-		SubscriptionID: cycle.SubscriptionID,
-		BillingCycleID: cycle.ID,
-		MeterID:        item.MeterID,
-		PriceID:        item.PriceID,
-		FeatureCode:    featureCode,
-		Quantity:       quantity,
-		UnitPrice:      unitPrice,
-		Amount:         amount,
-		Currency:       window.Amount.Currency,
-		PeriodStart:    window.Start,
-		PeriodEnd:      window.End,
-		Source:         source,
-		Checksum:       checksum,
-		CreatedAt:      now,
+	return emit(ratingdomain.RatingResult{
+		ID:               s.genID.Generate(),
+		OrgID:            cycle.OrgID,
+		SubscriptionID:   cycle.SubscriptionID,
+		BillingCycleID:   cycle.ID,
+		MeterID:          item.MeterID,
+		PriceID:          item.PriceID,
+		FeatureCode:      featureCode,
+		Quantity:         quantity,
+		UnitPrice:        unitPrice,
+		UnitPriceDecimal: fractionalUnitPrice(window.Amount.UnitAmount()),
+		Amount:           amount,
+		Currency:         window.Amount.Currency,
+		PeriodStart:      window.Start,
+		PeriodEnd:        window.End,
+		Source:           source,
+		Checksum:         checksum,
+		CreatedAt:        now,
 	})
 }
 
+func (s *Service) rateTieredItem(
+	ctx context.Context,
+	tx *gorm.DB,
+	cycle *billingCycleRow,
+	item subscriptionItemRow,
+	price *pricedomain.Price,
+	aggregation usageAggregation,
+	featureCode string,
+	periodStart, periodEnd time.Time,
+	now time.Time,
+	emit ratingSink,
+) error {
+	// Currency still comes from the price amount effective at window start.
+	priceAmount, err := s.resolvePriceAmountAt(ctx, tx, cycle.OrgID, item.PriceID, item.MeterID, cycle.Currency, periodStart)
+	if err != nil {
+		return err
+	}
+	if priceAmount == nil {
+		return ratingdomain.ErrMissingPriceAmount
+	}
+
+	tiers, err := s.priceTierRepo.ListByPriceID(ctx, tx, cycle.OrgID, item.PriceID)
+	if err != nil {
+		return err
+	}
+
+	quantity, err := s.aggregateUsage(tx, cycle.OrgID, cycle.SubscriptionID, *item.MeterID, aggregation, periodStart, periodEnd)
+	if err != nil {
+		return err
+	}
+
+	charges, err := computeTierCharges(price.PricingModel, tiers, quantity)
+	if err != nil {
+		return err
+	}
+
+	source := "usage_events:" + strings.ToLower(string(price.PricingModel))
+	for _, charge := range charges {
+		result := ratingdomain.RatingResult{
+			OrgID:          cycle.OrgID,
+			SubscriptionID: cycle.SubscriptionID,
+			BillingCycleID: cycle.ID,
+			MeterID:        item.MeterID,
+			PriceID:        item.PriceID,
+			FeatureCode:    featureCode,
+			Currency:       priceAmount.Currency,
+			PeriodStart:    periodStart,
+			PeriodEnd:      periodEnd,
+			Source:         source,
+			CreatedAt:      now,
+		}
+
+		charge.Rounding = cycle.RoundingMode
+		usage := result
+		usage.ID = s.genID.Generate()
+		usage.Quantity = charge.Quantity
+		usage.UnitPrice = charge.UnitAmount.Round(money.RoundHalfUp)
+		usage.UnitPriceDecimal = fractionalUnitPrice(charge.UnitAmount)
+		usage.Amount = charge.UsageAmount()
+		usage.Checksum = buildTierChecksum(cycle.ID, cycle.SubscriptionID, item.PriceID, item.MeterID, featureCode, periodStart, periodEnd, charge.Tier.ID, "unit")
+		if err := emit(usage); err != nil {
+			return err
+		}
+
+		if charge.FlatAmount == 0 {
+			continue
+		}
+
+		// Flat fees are kept on their own row so each invoice line stays
+		// explainable as quantity x unit price.
+		flat := result
+		flat.ID = s.genID.Generate()
+		flat.Quantity = 1
+		flat.UnitPrice = charge.FlatAmount
+		flat.Amount = charge.FlatAmount
+		flat.Checksum = buildTierChecksum(cycle.ID, cycle.SubscriptionID, item.PriceID, item.MeterID, featureCode, periodStart, periodEnd, charge.Tier.ID, "flat")
+		if err := emit(flat); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func appendEffectiveBoundaries(
 	boundaries []time.Time,
 	amounts []priceamountdomain.PriceAmount,
@@ -567,9 +947,9 @@ func (s *Service) insertRatingResult(tx *gorm.DB, result ratingdomain.RatingResu
 	return tx.Exec(
 		`INSERT INTO rating_results (
 			id, org_id, subscription_id, billing_cycle_id, meter_id, price_id, feature_code,
-			quantity, unit_price, amount, currency, period_start, period_end,
+			quantity, unit_price, unit_price_decimal, amount, currency, period_start, period_end,
 			source, checksum, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT (checksum) DO NOTHING`,
 		result.ID,
 		result.OrgID,
@@ -580,6 +960,7 @@ func (s *Service) insertRatingResult(tx *gorm.DB, result ratingdomain.RatingResu
 		result.FeatureCode,
 		result.Quantity,
 		result.UnitPrice,
+		result.UnitPriceDecimal,
 		result.Amount,
 		result.Currency,
 		result.PeriodStart,
@@ -618,8 +999,42 @@ func buildChecksum(
 	return hex.EncodeToString(sum[:])
 }
 
-func roundMoney(raw float64) int64 {
-	return int64(math.Floor(raw + 0.5))
+// buildTierChecksum extends buildChecksum with the tier and the charge part so
+// every row of a tiered rating stays unique and reproducible.
+func buildTierChecksum(
+	billingCycleID snowflake.ID,
+	subscriptionID snowflake.ID,
+	priceID snowflake.ID,
+	meterID *snowflake.ID,
+	featureCode string,
+	periodStart, periodEnd time.Time,
+	tierID snowflake.ID,
+	part string,
+) string {
+	base := buildChecksum(billingCycleID, subscriptionID, priceID, meterID, featureCode, periodStart, periodEnd)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|tier|%s|%s", base, tierID.String(), part)))
+	return hex.EncodeToString(sum[:])
+}
+
+// roundMoney rounds an exact amount to whole minor units. Amounts are
+// rounded once per rated line, never on intermediate sums.
+func roundMoney(amount money.Decimal, mode money.RoundingMode) int64 {
+	return amount.Round(mode)
+}
+
+// lineAmount prices quantity at unitAmount. The quantity is taken at its
+// shortest decimal form, so the same inputs always give the same amount.
+func lineAmount(quantity float64, unitAmount money.Decimal, mode money.RoundingMode) int64 {
+	return roundMoney(money.NewFromFloat(quantity).Mul(unitAmount), mode)
+}
+
+// fractionalUnitPrice returns the exact unit price when it has a fraction of
+// a minor unit that unit_price cannot hold.
+func fractionalUnitPrice(unitAmount money.Decimal) *money.Decimal {
+	if unitAmount.IsInteger() {
+		return nil
+	}
+	return &unitAmount
 }
 
 func parseID(value string) (snowflake.ID, error) {
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"math"
+	"sort"
+
+	"github.com/smallbiznis/railzway/internal/money"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	pricetierdomain "github.com/smallbiznis/railzway/internal/pricetier/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+)
+
+// tierCharge is the priced portion of a single tier for one rating window.
+//
+// Each charge is persisted as its own rating result so invoices can explain
+// exactly how a tiered amount was reached.
+type tierCharge struct {
+	Tier       pricetierdomain.PriceTier
+	Index      int
+	Quantity   float64
+	UnitAmount money.Decimal
+	FlatAmount int64
+	// Rounding applies to UsageAmount; the zero value rounds half up.
+	Rounding money.RoundingMode
+}
+
+// UsageAmount is the variable part of the tier (quantity * unit amount).
+func (c tierCharge) UsageAmount() int64 {
+	return lineAmount(c.Quantity, c.UnitAmount, c.Rounding)
+}
+
+func isTieredPricingModel(model pricedomain.PricingModel) bool {
+	return model == pricedomain.TieredVolume || model == pricedomain.TieredGraduated
+}
+
+// sortTiers orders tiers by their starting quantity so bounds can be checked
+// against the previous tier.
+func sortTiers(tiers []pricetierdomain.PriceTier) []pricetierdomain.PriceTier {
+	out := make([]pricetierdomain.PriceTier, len(tiers))
+	copy(out, tiers)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].StartQuantity != out[j].StartQuantity {
+			return out[i].StartQuantity < out[j].StartQuantity
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// tierBounds returns the exclusive lower bound and inclusive upper bound of
+// each tier. StartQuantity is the first unit a tier charges, so "1-1000,
+// 1001+" covers every quantity while "1-1000, 1501+" leaves 1001-1500 to no
+// tier. A tier without a StartQuantity continues from the previous tier's
+// EndQuantity (or 0 for the first tier). A nil EndQuantity is open-ended.
+// Overlapping tiers are rejected.
+func tierBounds(tiers []pricetierdomain.PriceTier) ([]float64, []float64, error) {
+	lower := make([]float64, len(tiers))
+	upper := make([]float64, len(tiers))
+	prev := 0.0
+	for i, tier := range tiers {
+		lower[i] = prev
+		if tier.StartQuantity > 0 {
+			lower[i] = tier.StartQuantity - 1
+			if lower[i] < prev {
+				return nil, nil, ratingdomain.ErrInvalidPriceTiers
+			}
+		}
+		if tier.EndQuantity == nil {
+			if i != len(tiers)-1 {
+				return nil, nil, ratingdomain.ErrInvalidPriceTiers
+			}
+			upper[i] = math.Inf(1)
+		} else {
+			if *tier.EndQuantity <= lower[i] {
+				return nil, nil, ratingdomain.ErrInvalidPriceTiers
+			}
+			upper[i] = *tier.EndQuantity
+		}
+		prev = upper[i]
+	}
+	return lower, upper, nil
+}
+
+// computeTierCharges splits quantity across tiers according to the pricing
+// model. Volume pricing charges the whole quantity at the single tier it
+// lands in; graduated pricing charges each slice at its own tier.
+// Flat amounts apply once per tier that receives any quantity. Quantities no
+// tier covers, below the first tier or in a gap between two, are free.
+func computeTierCharges(
+	model pricedomain.PricingModel,
+	tiers []pricetierdomain.PriceTier,
+	quantity float64,
+) ([]tierCharge, error) {
+	if quantity < 0 {
+		return nil, ratingdomain.ErrInvalidQuantity
+	}
+	if len(tiers) == 0 {
+		return nil, ratingdomain.ErrMissingPriceTiers
+	}
+
+	sorted := sortTiers(tiers)
+	lower, upper, err := tierBounds(sorted)
+	if err != nil {
+		return nil, err
+	}
+	if quantity > upper[len(upper)-1] {
+		return nil, ratingdomain.ErrTierQuantityExceeded
+	}
+
+	switch model {
+	case pricedomain.TieredVolume:
+		for i, tier := range sorted {
+			if quantity > upper[i] {
+				continue
+			}
+			if quantity <= lower[i] && quantity > 0 {
+				// Not covered by any tier: rated free at the next one.
+				return []tierCharge{{Tier: tier, Index: i, Quantity: quantity}}, nil
+			}
+			charge := newTierCharge(tier, i, quantity)
+			if quantity <= lower[i] {
+				// Zero usage lands in the first tier without triggering its flat fee.
+				charge.FlatAmount = 0
+			}
+			return []tierCharge{charge}, nil
+		}
+	case pricedomain.TieredGraduated:
+		charges := make([]tierCharge, 0, len(sorted))
+		for i, tier := range sorted {
+			if quantity <= lower[i] {
+				break
+			}
+			slice := math.Min(quantity, upper[i]) - lower[i]
+			charges = append(charges, newTierCharge(tier, i, slice))
+		}
+		if len(charges) == 0 {
+			charges = append(charges, tierCharge{Tier: sorted[0], Index: 0, UnitAmount: tierUnitAmount(sorted[0])})
+		}
+		return charges, nil
+	}
+
+	return nil, ratingdomain.ErrUnsupportedPricingModel
+}
+
+func newTierCharge(tier pricetierdomain.PriceTier, index int, quantity float64) tierCharge {
+	charge := tierCharge{
+		Tier:       tier,
+		Index:      index,
+		Quantity:   quantity,
+		UnitAmount: tierUnitAmount(tier),
+	}
+	if tier.FlatAmountCents != nil {
+		charge.FlatAmount = *tier.FlatAmountCents
+	}
+	return charge
+}
+
+func tierUnitAmount(tier pricetierdomain.PriceTier) money.Decimal {
+	return tier.UnitAmount()
+}
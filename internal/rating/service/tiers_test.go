@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
+	pricetierdomain "github.com/smallbiznis/railzway/internal/pricetier/domain"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// Tiers: 1-1000 @ 10c (+$5 flat), 1001-5000 @ 8c, 5001+ @ 5c (+$20 flat)
+func apiTiers() []pricetierdomain.PriceTier {
+	return []pricetierdomain.PriceTier{
+		{ID: 3, StartQuantity: 5001, UnitAmountCents: int64Ptr(5), FlatAmountCents: int64Ptr(2000)},
+		{ID: 1, StartQuantity: 1, EndQuantity: float64Ptr(1000), UnitAmountCents: int64Ptr(10), FlatAmountCents: int64Ptr(500)},
+		{ID: 2, StartQuantity: 1001, EndQuantity: float64Ptr(5000), UnitAmountCents: int64Ptr(8)},
+	}
+}
+
+func TestComputeTierCharges_Volume(t *testing.T) {
+	charges, err := computeTierCharges(pricedomain.TieredVolume, apiTiers(), 1200)
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.Equal(t, snowflake.ID(2), charges[0].Tier.ID)
+	assert.Equal(t, 1200.0, charges[0].Quantity)
+	assert.Equal(t, int64(9600), charges[0].UsageAmount())
+	assert.Equal(t, int64(0), charges[0].FlatAmount)
+
+	// Tier upper bounds are inclusive.
+	charges, err = computeTierCharges(pricedomain.TieredVolume, apiTiers(), 1000)
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.Equal(t, snowflake.ID(1), charges[0].Tier.ID)
+	assert.Equal(t, int64(10000), charges[0].UsageAmount())
+	assert.Equal(t, int64(500), charges[0].FlatAmount)
+
+	// Open-ended last tier.
+	charges, err = computeTierCharges(pricedomain.TieredVolume, apiTiers(), 10000)
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.Equal(t, snowflake.ID(3), charges[0].Tier.ID)
+	assert.Equal(t, int64(50000), charges[0].UsageAmount())
+	assert.Equal(t, int64(2000), charges[0].FlatAmount)
+
+	// Zero usage does not trigger the first tier's flat fee.
+	charges, err = computeTierCharges(pricedomain.TieredVolume, apiTiers(), 0)
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.Equal(t, int64(0), charges[0].UsageAmount())
+	assert.Equal(t, int64(0), charges[0].FlatAmount)
+}
+
+func TestComputeTierCharges_Graduated(t *testing.T) {
+	charges, err := computeTierCharges(pricedomain.TieredGraduated, apiTiers(), 6000)
+	require.NoError(t, err)
+	require.Len(t, charges, 3)
+
+	assert.Equal(t, 1000.0, charges[0].Quantity)
+	assert.Equal(t, int64(10000), charges[0].UsageAmount())
+	assert.Equal(t, int64(500), charges[0].FlatAmount)
+
+	assert.Equal(t, 4000.0, charges[1].Quantity)
+	assert.Equal(t, int64(32000), charges[1].UsageAmount())
+	assert.Equal(t, int64(0), charges[1].FlatAmount)
+
+	assert.Equal(t, 1000.0, charges[2].Quantity)
+	assert.Equal(t, int64(5000), charges[2].UsageAmount())
+	assert.Equal(t, int64(2000), charges[2].FlatAmount)
+
+	charges, err = computeTierCharges(pricedomain.TieredGraduated, apiTiers(), 0)
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.Equal(t, 0.0, charges[0].Quantity)
+	assert.Equal(t, int64(0), charges[0].FlatAmount)
+}
+
+func TestComputeTierCharges_StartQuantity(t *testing.T) {
+	// 101-1000 @ 10c, 1501+ @ 5c: the first 100 units and 1001-1500 are free.
+	gapped := []pricetierdomain.PriceTier{
+		{ID: 1, StartQuantity: 101, EndQuantity: float64Ptr(1000), UnitAmountCents: int64Ptr(10), FlatAmountCents: int64Ptr(500)},
+		{ID: 2, StartQuantity: 1501, UnitAmountCents: int64Ptr(5)},
+	}
+
+	charges, err := computeTierCharges(pricedomain.TieredGraduated, gapped, 2000)
+	require.NoError(t, err)
+	require.Len(t, charges, 2)
+	assert.Equal(t, 900.0, charges[0].Quantity)
+	assert.Equal(t, int64(9000), charges[0].UsageAmount())
+	assert.Equal(t, 500.0, charges[1].Quantity)
+	assert.Equal(t, int64(2500), charges[1].UsageAmount())
+
+	charges, err = computeTierCharges(pricedomain.TieredGraduated, gapped, 50)
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.Equal(t, int64(0), charges[0].UsageAmount())
+	assert.Equal(t, int64(0), charges[0].FlatAmount)
+
+	charges, err = computeTierCharges(pricedomain.TieredVolume, gapped, 1200)
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.Equal(t, snowflake.ID(2), charges[0].Tier.ID)
+	assert.Equal(t, int64(0), charges[0].UsageAmount())
+	assert.Equal(t, int64(0), charges[0].FlatAmount)
+
+	charges, err = computeTierCharges(pricedomain.TieredVolume, gapped, 600)
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.Equal(t, int64(6000), charges[0].UsageAmount())
+	assert.Equal(t, int64(500), charges[0].FlatAmount)
+}
+
+func TestComputeTierCharges_Invalid(t *testing.T) {
+	_, err := computeTierCharges(pricedomain.TieredGraduated, nil, 10)
+	assert.ErrorIs(t, err, ratingdomain.ErrMissingPriceTiers)
+
+	gap := []pricetierdomain.PriceTier{
+		{ID: 1, StartQuantity: 1, UnitAmountCents: int64Ptr(10)},
+		{ID: 2, StartQuantity: 100, UnitAmountCents: int64Ptr(5)},
+	}
+	_, err = computeTierCharges(pricedomain.TieredGraduated, gap, 10)
+	assert.ErrorIs(t, err, ratingdomain.ErrInvalidPriceTiers)
+
+	overlap := []pricetierdomain.PriceTier{
+		{ID: 1, StartQuantity: 1, EndQuantity: float64Ptr(1000), UnitAmountCents: int64Ptr(10)},
+		{ID: 2, StartQuantity: 1000, UnitAmountCents: int64Ptr(5)},
+	}
+	_, err = computeTierCharges(pricedomain.TieredGraduated, overlap, 10)
+	assert.ErrorIs(t, err, ratingdomain.ErrInvalidPriceTiers)
+
+	inverted := []pricetierdomain.PriceTier{
+		{ID: 1, StartQuantity: 500, EndQuantity: float64Ptr(100), UnitAmountCents: int64Ptr(10)},
+	}
+	_, err = computeTierCharges(pricedomain.TieredVolume, inverted, 10)
+	assert.ErrorIs(t, err, ratingdomain.ErrInvalidPriceTiers)
+
+	capped := []pricetierdomain.PriceTier{
+		{ID: 1, StartQuantity: 1, EndQuantity: float64Ptr(100), UnitAmountCents: int64Ptr(10)},
+	}
+	_, err = computeTierCharges(pricedomain.TieredVolume, capped, 101)
+	assert.ErrorIs(t, err, ratingdomain.ErrTierQuantityExceeded)
+}
+
+func TestRating_TieredGraduated_PersistsPerTierResults(t *testing.T) {
+	db, svc, node := setupProrationTest(t)
+	tierStub := &priceTierStub{}
+	svc.(*Service).priceTierRepo = tierStub
+
+	orgID := node.Generate()
+	subID := node.Generate()
+	cycleID := node.Generate()
+	productID := node.Generate()
+	priceID := node.Generate()
+	meterID := node.Generate()
+
+	cycleStart := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	cycleEnd := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	db.Create(&billingcycledomain.BillingCycle{
+		ID:             cycleID,
+		OrgID:          orgID,
+		SubscriptionID: subID,
+		PeriodStart:    cycleStart,
+		PeriodEnd:      cycleEnd,
+		Status:         billingcycledomain.BillingCycleStatusClosing,
+	})
+	db.Create(&subscriptiondomain.Subscription{
+		ID:         subID,
+		OrgID:      orgID,
+		CustomerID: node.Generate(),
+		Status:     subscriptiondomain.SubscriptionStatusActive,
+		StartAt:    cycleStart,
+	})
+	db.Create(&subscriptiondomain.SubscriptionItem{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		SubscriptionID: subID,
+		PriceID:        priceID,
+		MeterID:        &meterID,
+		BillingMode:    "METERED",
+	})
+	db.Create(&meterdomain.Meter{
+		ID:          meterID,
+		OrgID:       orgID,
+		Code:        "api_calls",
+		Name:        "API Calls",
+		Aggregation: meterdomain.AggregationSum,
+		Unit:        "call",
+	})
+	db.Create(&subscriptiondomain.SubscriptionEntitlement{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		SubscriptionID: subID,
+		ProductID:      productID,
+		FeatureCode:    "api_calls",
+		MeterID:        &meterID,
+		EffectiveFrom:  cycleStart,
+	})
+
+	svc.(*Service).priceRepo.(*priceRepoStub).Prices[priceID.String()] = pricedomain.Price{
+		ID:           priceID,
+		ProductID:    productID,
+		PricingModel: pricedomain.TieredGraduated,
+	}
+	svc.(*Service).priceAmountRepo.(*priceAmountStub).Amounts[priceID.String()] = priceamountdomain.PriceAmount{
+		PriceID:  priceID,
+		Currency: "USD",
+	}
+	tierStub.Tiers = apiTiers()
+
+	db.Create(&usagedomain.UsageEvent{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		MeterID:        meterID,
+		SubscriptionID: subID,
+		Value:          6000,
+		RecordedAt:     cycleStart.Add(48 * time.Hour),
+		Status:         usagedomain.UsageStatusEnriched,
+	})
+
+	require.NoError(t, svc.RunRating(context.Background(), cycleID.String()))
+
+	var results []ratingdomain.RatingResult
+	db.Where("billing_cycle_id = ?", cycleID).Order("checksum").Find(&results)
+	// Three usage slices plus two flat fees.
+	require.Len(t, results, 5)
+
+	var total int64
+	checksums := make([]string, 0, len(results))
+	for _, r := range results {
+		assert.Equal(t, "usage_events:tiered_graduated", r.Source)
+		assert.Equal(t, "USD", r.Currency)
+		total += r.Amount
+		checksums = append(checksums, r.Checksum)
+	}
+	assert.Equal(t, int64(10000+500+32000+5000+2000), total)
+
+	require.NoError(t, svc.RunRating(context.Background(), cycleID.String()))
+
+	var rerun []ratingdomain.RatingResult
+	db.Where("billing_cycle_id = ?", cycleID).Order("checksum").Find(&rerun)
+	require.Len(t, rerun, 5)
+	for i, r := range rerun {
+		assert.Equal(t, checksums[i], r.Checksum)
+	}
+}
+
+type priceTierStub struct {
+	Tiers []pricetierdomain.PriceTier
+}
+
+func (s *priceTierStub) Insert(ctx context.Context, db *gorm.DB, tier *pricetierdomain.PriceTier) error {
+	return nil
+}
+
+func (s *priceTierStub) FindByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*pricetierdomain.PriceTier, error) {
+	return nil, nil
+}
+
+func (s *priceTierStub) List(ctx context.Context, db *gorm.DB, orgID snowflake.ID) ([]pricetierdomain.PriceTier, error) {
+	return s.Tiers, nil
+}
+
+func (s *priceTierStub) ListByPriceID(ctx context.Context, db *gorm.DB, orgID, priceID snowflake.ID) ([]pricetierdomain.PriceTier, error) {
+	return s.Tiers, nil
+}
+
+func int64Ptr(v int64) *int64 { return &v }
+
+func float64Ptr(v float64) *float64 { return &v }
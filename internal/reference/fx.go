@@ -1,7 +1,33 @@
 package reference
 
-import "go.uber.org/fx"
+import (
+	"context"
+
+	"github.com/smallbiznis/railzway/internal/money"
+	"github.com/smallbiznis/railzway/internal/reference/domain"
+	"go.uber.org/fx"
+)
 
 var Module = fx.Module("reference.repository",
 	fx.Provide(NewRepository),
+	fx.Invoke(loadMinorUnits),
 )
+
+// loadMinorUnits caches the minor unit of every active currency for the money
+// package before the application starts.
+func loadMinorUnits(lc fx.Lifecycle, repo domain.Repository) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			currencies, err := repo.ListCurrencies(ctx)
+			if err != nil {
+				return err
+			}
+			units := make(map[string]int32, len(currencies))
+			for _, currency := range currencies {
+				units[currency.Code] = int32(currency.MinorUnit)
+			}
+			money.SetMinorUnits(units)
+			return nil
+		},
+	})
+}
@@ -18,7 +18,8 @@ var (
 )
 
 func EnsureSubscriptionCanOpenBillingCycle(status subscriptiondomain.SubscriptionStatus, activatedAt *time.Time, cycleType string) error {
-	if status != subscriptiondomain.SubscriptionStatusActive {
+	// Past-due subscriptions keep billing while dunning runs.
+	if status != subscriptiondomain.SubscriptionStatusActive && status != subscriptiondomain.SubscriptionStatusPastDue {
 		return ErrSubscriptionNotActive
 	}
 	if activatedAt == nil {
@@ -59,7 +59,7 @@ func (s *Scheduler) ensureLedgerEntryForCycle(
 	)
 
 	for _, v := range summary {
-		if v.Total <= 0 {
+		if v.Total == 0 {
 			continue
 		}
 
@@ -87,11 +87,20 @@ func (s *Scheduler) ensureLedgerEntryForCycle(
 
 		total += v.Total
 
+		// A kind that nets to a credit, like the unused time of a flat fee
+		// billed in advance, reverses revenue.
+		direction := ledgerdomain.LedgerEntryDirectionCredit
+		amount := v.Total
+		if amount < 0 {
+			direction = ledgerdomain.LedgerEntryDirectionDebit
+			amount = -amount
+		}
+
 		lines = append(lines, ledgerdomain.LedgerEntryLine{
 			AccountID: revenueID,
-			Direction: ledgerdomain.LedgerEntryDirectionCredit,
+			Direction: direction,
 			Currency:  v.Currency,
-			Amount:    v.Total,
+			Amount:    amount,
 		})
 	}
 
@@ -99,21 +108,28 @@ func (s *Scheduler) ensureLedgerEntryForCycle(
 		return invoicedomain.ErrMissingRatingResults
 	}
 
-	arID, err := s.getLedgerAccountID(
-		ctx,
-		cycle.OrgID,
-		ledgerdomain.AccountCodeAccountsReceivable,
-	)
-	if err != nil {
-		return err
-	}
-
-	lines = append(lines, ledgerdomain.LedgerEntryLine{
-		AccountID: arID,
+	// The customer owes the net amount; a net credit is kept as customer
+	// credit balance.
+	counterpart := ledgerdomain.LedgerEntryLine{
 		Direction: ledgerdomain.LedgerEntryDirectionDebit,
 		Currency:  currency,
 		Amount:    total,
-	})
+	}
+	counterpartCode := ledgerdomain.AccountCodeAccountsReceivable
+	if total < 0 {
+		counterpart.Direction = ledgerdomain.LedgerEntryDirectionCredit
+		counterpart.Amount = -total
+		counterpartCode = ledgerdomain.AccountCodeCreditBalance
+	}
+
+	if total != 0 {
+		counterpartID, err := s.getLedgerAccountID(ctx, cycle.OrgID, counterpartCode)
+		if err != nil {
+			return err
+		}
+		counterpart.AccountID = counterpartID
+		lines = append(lines, counterpart)
+	}
 
 	return s.ledgerSvc.CreateEntry(
 		ctx,
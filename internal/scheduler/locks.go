@@ -9,6 +9,7 @@ import (
 	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
 	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
 	obsmetrics "github.com/smallbiznis/railzway/internal/observability/metrics"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
 	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -20,6 +21,7 @@ type WorkSubscription struct {
 	Status           subscriptiondomain.SubscriptionStatus
 	ActivatedAt      *time.Time
 	BillingCycleType string
+	BillingAnchorDay *int16
 }
 
 type WorkBillingCycle struct {
@@ -61,7 +63,7 @@ func (s *Scheduler) fetchSubscriptionsForWork(ctx context.Context, tx *gorm.DB,
 	schedMetrics := obsmetrics.Scheduler()
 	lockStart := time.Now()
 	err := tx.WithContext(ctx).Raw(
-		`SELECT id, org_id, status, activated_at, billing_cycle_type
+		`SELECT id, org_id, status, activated_at, billing_cycle_type, billing_anchor_day
 		 FROM subscriptions
 		 WHERE status = ?
 		 ORDER BY id
@@ -114,9 +116,9 @@ func (s *Scheduler) fetchSubscriptionsNeedingCycle(ctx context.Context, tx *gorm
 	// PostgreSQL: FOR UPDATE OF s SKIP LOCKED
 	// MySQL/SQLite: FOR UPDATE SKIP LOCKED works (or striped by test)
 	err := tx.WithContext(ctx).Raw(
-		`SELECT s.id, s.org_id, s.status, s.activated_at, s.billing_cycle_type
+		`SELECT s.id, s.org_id, s.status, s.activated_at, s.billing_cycle_type, s.billing_anchor_day
 		 FROM subscriptions s
-		 WHERE s.status = ?
+		 WHERE s.status IN (?, ?)
 		   AND NOT EXISTS (
 			   SELECT 1 FROM billing_cycles bc 
 			   WHERE bc.subscription_id = s.id 
@@ -126,6 +128,7 @@ func (s *Scheduler) fetchSubscriptionsNeedingCycle(ctx context.Context, tx *gorm
 		 LIMIT ?
 		 FOR UPDATE SKIP LOCKED`,
 		subscriptiondomain.SubscriptionStatusActive,
+		subscriptiondomain.SubscriptionStatusPastDue,
 		billingcycledomain.BillingCycleStatusOpen,
 		limit,
 	).Scan(&subscriptions).Error
@@ -221,6 +224,47 @@ func (s *Scheduler) insertCycle(ctx context.Context, tx *gorm.DB, cycleID, orgID
 	return s.upsertBillingCycleStats(ctx, tx, cycleID, orgID, periodStart, billingcycledomain.BillingCycleStatusOpen, now)
 }
 
+// insertActivationCycle records the zero-length cycle that bills the first
+// period in advance. It starts CLOSING, so the rating job picks it up at once.
+func (s *Scheduler) insertActivationCycle(ctx context.Context, tx *gorm.DB, cycleID, orgID, subscriptionID snowflake.ID, activatedAt, now time.Time) error {
+	if err := tx.WithContext(ctx).Exec(
+		`INSERT INTO billing_cycles (
+			id, org_id, subscription_id, period_start, period_end, status,
+			opened_at, closing_started_at, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		cycleID,
+		orgID,
+		subscriptionID,
+		activatedAt,
+		activatedAt,
+		billingcycledomain.BillingCycleStatusClosing,
+		now,
+		now,
+		now,
+		now,
+	).Error; err != nil {
+		return err
+	}
+	return s.upsertBillingCycleStats(ctx, tx, cycleID, orgID, activatedAt, billingcycledomain.BillingCycleStatusClosing, now)
+}
+
+// hasAdvanceItems reports whether any flat item of the subscription is billed
+// in advance, by its own override or by its price.
+func (s *Scheduler) hasAdvanceItems(ctx context.Context, tx *gorm.DB, orgID, subscriptionID snowflake.ID) (bool, error) {
+	var count int64
+	err := tx.WithContext(ctx).Raw(
+		`SELECT COUNT(*)
+		 FROM subscription_items si
+		 JOIN prices p ON p.id = si.price_id
+		 WHERE si.org_id = ? AND si.subscription_id = ? AND si.meter_id IS NULL
+		   AND COALESCE(NULLIF(si.billing_timing, ''), p.billing_timing) = ?`,
+		orgID,
+		subscriptionID,
+		pricedomain.InAdvance,
+	).Scan(&count).Error
+	return count > 0, err
+}
+
 func (s *Scheduler) lockCycleForUpdate(
 	ctx context.Context,
 	tx *gorm.DB,
@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextPeriodEnd_Intervals(t *testing.T) {
+	anchor := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		cycleType string
+		expected  time.Time
+	}{
+		{cycleType: "daily", expected: time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{cycleType: "weekly", expected: time.Date(2026, 1, 22, 0, 0, 0, 0, time.UTC)},
+		{cycleType: "monthly", expected: time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC)},
+		{cycleType: "quarterly", expected: time.Date(2026, 4, 15, 0, 0, 0, 0, time.UTC)},
+		{cycleType: "yearly", expected: time.Date(2027, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{cycleType: "month:6", expected: time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC)},
+		{cycleType: "WEEK:2", expected: time.Date(2026, 1, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.cycleType, func(t *testing.T) {
+			end, err := nextPeriodEnd(anchor, anchor, tc.cycleType, nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, end)
+		})
+	}
+
+	_, err := nextPeriodEnd(anchor, anchor, "fortnightly", nil, nil)
+	assert.ErrorIs(t, err, subscriptiondomain.ErrInvalidBillingCycleType)
+	_, err = nextPeriodEnd(anchor, anchor, "month:0", nil, nil)
+	assert.ErrorIs(t, err, subscriptiondomain.ErrInvalidBillingCycleType)
+}
+
+func TestNextPeriodEnd_ClampsToMonthEnd(t *testing.T) {
+	anchor := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	// Cycles chain from the previous period end but stay pinned to the anchor.
+	expected := []time.Time{
+		time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC),
+	}
+	start := anchor
+	for _, want := range expected {
+		end, err := nextPeriodEnd(anchor, start, "monthly", nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, want, end)
+		start = end
+	}
+
+	// Leap day anchors clamp in non-leap years and recover in leap years.
+	leap := time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)
+	end, err := nextPeriodEnd(leap, leap, "yearly", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2029, 2, 28, 0, 0, 0, 0, time.UTC), end)
+	end, err = nextPeriodEnd(leap, time.Date(2031, 2, 28, 0, 0, 0, 0, time.UTC), "yearly", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2032, 2, 29, 0, 0, 0, 0, time.UTC), end)
+
+	// Quarterly cycles anchored on the 31st.
+	end, err = nextPeriodEnd(anchor, time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC), "quarterly", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 7, 31, 0, 0, 0, 0, time.UTC), end)
+}
+
+func TestNextPeriodEnd_AnchorDay(t *testing.T) {
+	day := int16(1)
+	jakarta := time.FixedZone("WIB", 7*60*60)
+
+	// Activation mid-month opens a partial period up to local midnight on the 1st.
+	activatedAt := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+	end, err := nextPeriodEnd(activatedAt, activatedAt, "monthly", &day, jakarta)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, jakarta), end)
+	assert.Equal(t, time.Date(2026, 3, 31, 17, 0, 0, 0, time.UTC), end.UTC())
+
+	// Following cycles run boundary to boundary.
+	next, err := nextPeriodEnd(activatedAt, end, "monthly", &day, jakarta)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 5, 1, 0, 0, 0, 0, jakarta), next)
+
+	next, err = nextPeriodEnd(activatedAt, end, "yearly", &day, jakarta)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2027, 4, 1, 0, 0, 0, 0, jakarta), next)
+
+	// Anchor days past the end of the month clamp without drifting.
+	day = 31
+	start := time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC)
+	expected := []time.Time{
+		time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC),
+	}
+	for _, want := range expected {
+		end, err := nextPeriodEnd(start, start, "monthly", &day, time.UTC)
+		require.NoError(t, err)
+		assert.Equal(t, want, end)
+		start = end
+	}
+
+	_, err = nextPeriodEnd(activatedAt, activatedAt, "weekly", &day, time.UTC)
+	assert.ErrorIs(t, err, subscriptiondomain.ErrInvalidBillingAnchorDay)
+	day = 32
+	_, err = nextPeriodEnd(activatedAt, activatedAt, "monthly", &day, time.UTC)
+	assert.ErrorIs(t, err, subscriptiondomain.ErrInvalidBillingAnchorDay)
+}
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/bwmarrin/snowflake"
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
+	alertdomain "github.com/smallbiznis/railzway/internal/alert/domain"
 	auditdomain "github.com/smallbiznis/railzway/internal/audit/domain"
 	auditcontext "github.com/smallbiznis/railzway/internal/auditcontext"
 	"github.com/smallbiznis/railzway/internal/authorization"
@@ -16,6 +18,8 @@ import (
 	billingopsdomain "github.com/smallbiznis/railzway/internal/billingoperations/domain"
 	"github.com/smallbiznis/railzway/internal/clock"
 	"github.com/smallbiznis/railzway/internal/cloudmetrics"
+	creditdomain "github.com/smallbiznis/railzway/internal/credit/domain"
+	dunningdomain "github.com/smallbiznis/railzway/internal/dunning/domain"
 	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
 	ledgerdomain "github.com/smallbiznis/railzway/internal/ledger/domain"
 	obsmetrics "github.com/smallbiznis/railzway/internal/observability/metrics"
@@ -23,6 +27,7 @@ import (
 	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
 	"github.com/smallbiznis/railzway/internal/scheduler/guard"
 	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"github.com/smallbiznis/railzway/internal/webhook/dispatcher"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -41,7 +46,12 @@ type Params struct {
 
 	AuthzSvc             authorization.Service
 	BillingOperationsSvc billingopsdomain.Service
-	RollupSvc            *rollup.Service `optional:"true"`
+	RollupSvc            *rollup.Service          `optional:"true"`
+	WebhookDispatcher    *dispatcher.Dispatcher   `optional:"true"`
+	DunningSvc           dunningdomain.Service    `optional:"true"`
+	AdjustmentSvc        adjustmentdomain.Service `optional:"true"`
+	AlertSvc             alertdomain.Service      `optional:"true"`
+	CreditSvc            creditdomain.Service     `optional:"true"`
 	GenID                *snowflake.Node
 	Clock                clock.Clock
 	Config               Config                     `optional:"true"`
@@ -62,6 +72,11 @@ type Scheduler struct {
 	authzSvc             authorization.Service
 	billingOperationsSvc billingopsdomain.Service
 	rollupSvc            *rollup.Service
+	webhookDispatcher    *dispatcher.Dispatcher
+	dunningSvc           dunningdomain.Service
+	adjustmentSvc        adjustmentdomain.Service
+	alertSvc             alertdomain.Service
+	creditSvc            creditdomain.Service
 	cloudMetrics         *cloudmetrics.CloudMetrics
 }
 
@@ -94,6 +109,11 @@ func New(p Params) (*Scheduler, error) {
 		authzSvc:             p.AuthzSvc,
 		billingOperationsSvc: p.BillingOperationsSvc,
 		rollupSvc:            p.RollupSvc,
+		webhookDispatcher:    p.WebhookDispatcher,
+		dunningSvc:           p.DunningSvc,
+		adjustmentSvc:        p.AdjustmentSvc,
+		alertSvc:             p.AlertSvc,
+		creditSvc:            p.CreditSvc,
 		cloudMetrics:         p.CloudMetrics,
 	}, nil
 }
@@ -162,6 +182,21 @@ func (s *Scheduler) RunOnce(parent context.Context) error {
 		Enabled bool
 		Run     func(context.Context) error
 	}{
+		{"plan_changes", s.isJobEnabled("plan_changes"), func(ctx context.Context) error {
+			return s.runJob(ctx, "plan_changes", s.cfg.BatchSize, 30*time.Second, func(ctx context.Context) error {
+				return s.subscriptionSvc.ApplyScheduledPlanChanges(ctx, s.cfg.BatchSize)
+			})
+		}},
+		{"quantity_changes", s.isJobEnabled("quantity_changes"), func(ctx context.Context) error {
+			return s.runJob(ctx, "quantity_changes", s.cfg.BatchSize, 30*time.Second, func(ctx context.Context) error {
+				return s.subscriptionSvc.ApplyScheduledQuantityChanges(ctx, s.cfg.BatchSize)
+			})
+		}},
+		{"subscription_schedules", s.isJobEnabled("subscription_schedules"), func(ctx context.Context) error {
+			return s.runJob(ctx, "subscription_schedules", s.cfg.BatchSize, 30*time.Second, func(ctx context.Context) error {
+				return s.subscriptionSvc.AdvanceSchedules(ctx, s.cfg.BatchSize)
+			})
+		}},
 		{"ensure_cycles", s.isJobEnabled("ensure_cycles"), func(ctx context.Context) error {
 			return s.runJob(ctx, "ensure_cycles", s.cfg.BatchSize, 30*time.Second, s.EnsureBillingCyclesJob)
 		}},
@@ -198,6 +233,36 @@ func (s *Scheduler) RunOnce(parent context.Context) error {
 		}
 	}
 
+	if s.webhookDispatcher != nil && s.isJobEnabled("webhook_dispatch") {
+		err = errors.Join(err, s.runJob(parent, "webhook_dispatch", s.cfg.BatchSize, 5*time.Minute, func(ctx context.Context) error {
+			return s.webhookDispatcher.ProcessPending(ctx, s.cfg.BatchSize)
+		}))
+	}
+
+	if s.dunningSvc != nil && s.isJobEnabled("dunning") {
+		err = errors.Join(err, s.runJob(parent, "dunning", s.cfg.BatchSize, 5*time.Minute, func(ctx context.Context) error {
+			return s.dunningSvc.ProcessDue(ctx, s.cfg.BatchSize)
+		}))
+	}
+
+	if s.adjustmentSvc != nil && s.isJobEnabled("late_usage") {
+		err = errors.Join(err, s.runJob(parent, "late_usage", s.cfg.BatchSize, 5*time.Minute, func(ctx context.Context) error {
+			return s.adjustmentSvc.ProcessLateUsage(ctx, s.cfg.BatchSize)
+		}))
+	}
+
+	if s.alertSvc != nil && s.isJobEnabled("usage_alerts") {
+		err = errors.Join(err, s.runJob(parent, "usage_alerts", s.cfg.BatchSize, 5*time.Minute, func(ctx context.Context) error {
+			return s.alertSvc.Evaluate(ctx, s.cfg.BatchSize)
+		}))
+	}
+
+	if s.creditSvc != nil && s.isJobEnabled("credit_expiry") {
+		err = errors.Join(err, s.runJob(parent, "credit_expiry", s.cfg.BatchSize, 5*time.Minute, func(ctx context.Context) error {
+			return s.creditSvc.ExpireDue(ctx, s.cfg.BatchSize)
+		}))
+	}
+
 	otherJobs := []struct {
 		Name    string
 		Enabled bool
@@ -915,7 +980,21 @@ func (s *Scheduler) ensureSubscriptionCycle(ctx context.Context, tx *gorm.DB, su
 		return nil
 	}
 
-	periodEnd, err := nextPeriodEnd(periodStart, subscription.BillingCycleType)
+	if lastCycle == nil {
+		if err := s.openActivationCycle(ctx, tx, subscription, now, events); err != nil {
+			return err
+		}
+	}
+
+	var loc *time.Location
+	if subscription.BillingAnchorDay != nil {
+		loc, err = billingcycledomain.LoadBillingLocation(ctx, tx, subscription.OrgID)
+		if err != nil {
+			return err
+		}
+	}
+
+	periodEnd, err := nextPeriodEnd(*subscription.ActivatedAt, periodStart, subscription.BillingCycleType, subscription.BillingAnchorDay, loc)
 	if err != nil {
 		return err
 	}
@@ -942,17 +1021,51 @@ func (s *Scheduler) ensureSubscriptionCycle(ctx context.Context, tx *gorm.DB, su
 	return nil
 }
 
-func nextPeriodEnd(start time.Time, cycleType string) (time.Time, error) {
-	switch strings.ToLower(strings.TrimSpace(cycleType)) {
-	case "monthly":
-		return start.AddDate(0, 1, 0), nil
-	case "weekly":
-		return start.AddDate(0, 0, 7), nil
-	case "daily":
-		return start.AddDate(0, 0, 1), nil
-	default:
+// openActivationCycle charges the first period of the items billed in advance
+// when the subscription starts billing. The activation cycle spans no time
+// and goes straight to rating, so its invoice is issued at activation; the
+// first regular cycle then settles against it like any later period.
+func (s *Scheduler) openActivationCycle(ctx context.Context, tx *gorm.DB, subscription WorkSubscription, now time.Time, events *[]auditEvent) error {
+	advance, err := s.hasAdvanceItems(ctx, tx, subscription.OrgID, subscription.ID)
+	if err != nil || !advance {
+		return err
+	}
+
+	cycleID := s.genID.Generate()
+	activatedAt := *subscription.ActivatedAt
+	if err := s.insertActivationCycle(ctx, tx, cycleID, subscription.OrgID, subscription.ID, activatedAt, now); err != nil {
+		return err
+	}
+	*events = append(*events, auditEvent{
+		OrgID:          subscription.OrgID,
+		Action:         "billing_cycle.closing_started",
+		TargetType:     "billing_cycle",
+		TargetID:       cycleID.String(),
+		SubscriptionID: subscription.ID.String(),
+		BillingCycleID: cycleID.String(),
+		Metadata: map[string]any{
+			"period_end": activatedAt.Format(time.RFC3339),
+			"activation": true,
+		},
+	})
+	return nil
+}
+
+// nextPeriodEnd returns the end of the cycle starting at start. Without an
+// anchor day cycles repeat from the activation time; with one they are pinned
+// to local midnight of that day in loc.
+func nextPeriodEnd(activatedAt, start time.Time, cycleType string, anchorDay *int16, loc *time.Location) (time.Time, error) {
+	spec, ok := billingcycledomain.ParseCycleType(cycleType)
+	if !ok {
 		return time.Time{}, subscriptiondomain.ErrInvalidBillingCycleType
 	}
+	if anchorDay != nil {
+		if !spec.SupportsAnchorDay() || !billingcycledomain.ValidAnchorDay(int(*anchorDay)) {
+			return time.Time{}, subscriptiondomain.ErrInvalidBillingAnchorDay
+		}
+		return spec.NextAnchoredPeriodEnd(start, int(*anchorDay), loc), nil
+	}
+	return spec.NextPeriodEnd(activatedAt, start), nil
 }
 
 type invoiceRow struct {
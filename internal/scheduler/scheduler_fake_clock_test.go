@@ -15,6 +15,7 @@ import (
 	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
 	ledgerdomain "github.com/smallbiznis/railzway/internal/ledger/domain"
 	obsmetrics "github.com/smallbiznis/railzway/internal/observability/metrics"
+	ratingdomain "github.com/smallbiznis/railzway/internal/rating/domain"
 	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -34,6 +35,14 @@ func (m *mockRatingSvc) RunRating(ctx context.Context, cycleID string) error {
 	`, cycleID, 2010735548360036353, nil, "USD", 100.0).Error
 }
 
+func (m *mockRatingSvc) PreviewRating(ctx context.Context, cycleID string) ([]ratingdomain.RatingResult, error) {
+	return nil, nil
+}
+
+func (m *mockRatingSvc) RerateCycle(ctx context.Context, cycleID string) ([]ratingdomain.RatingResult, error) {
+	return nil, nil
+}
+
 type mockInvoiceSvc struct {
 	genFunc func(ctx context.Context, cycleID string) (*invoicedomain.Invoice, error)
 	finFunc func(ctx context.Context, invoiceID string) error
@@ -63,6 +72,12 @@ func (m *mockInvoiceSvc) FinalizeInvoice(ctx context.Context, invoiceID string)
 func (m *mockInvoiceSvc) VoidInvoice(ctx context.Context, invoiceID string, reason string) error {
 	return nil
 }
+func (m *mockInvoiceSvc) MarkInvoicePaid(ctx context.Context, invoiceID string, req invoicedomain.MarkPaidRequest) error {
+	return nil
+}
+func (m *mockInvoiceSvc) PreviewUpcomingInvoice(ctx context.Context, customerID string, subscriptionID string) (*invoicedomain.UpcomingInvoice, error) {
+	return nil, nil
+}
 
 type mockLedgerSvc struct{}
 
@@ -96,7 +111,47 @@ func (m *mockSubscriptionSvc) TransitionSubscription(ctx context.Context, id str
 func (m *mockSubscriptionSvc) ValidateUsageEntitlement(ctx context.Context, subscriptionID, meterID snowflake.ID, at time.Time) error {
 	return nil
 }
-func (m *mockSubscriptionSvc) ChangePlan(ctx context.Context, req subscriptiondomain.ChangePlanRequest) error {
+func (m *mockSubscriptionSvc) ChangePlan(ctx context.Context, req subscriptiondomain.ChangePlanRequest) (subscriptiondomain.SubscriptionPlanChange, error) {
+	return subscriptiondomain.SubscriptionPlanChange{}, nil
+}
+
+func (m *mockSubscriptionSvc) ListPlanChanges(ctx context.Context, subscriptionID string) ([]subscriptiondomain.SubscriptionPlanChange, error) {
+	return nil, nil
+}
+
+func (m *mockSubscriptionSvc) ApplyScheduledPlanChanges(ctx context.Context, limit int) error {
+	return nil
+}
+
+func (m *mockSubscriptionSvc) CreateSchedule(ctx context.Context, req subscriptiondomain.CreateScheduleRequest) (subscriptiondomain.SubscriptionSchedule, error) {
+	return subscriptiondomain.SubscriptionSchedule{}, nil
+}
+
+func (m *mockSubscriptionSvc) PreviewSchedule(ctx context.Context, req subscriptiondomain.CreateScheduleRequest) (subscriptiondomain.SchedulePreview, error) {
+	return subscriptiondomain.SchedulePreview{}, nil
+}
+
+func (m *mockSubscriptionSvc) GetSchedule(ctx context.Context, id string) (subscriptiondomain.SubscriptionSchedule, error) {
+	return subscriptiondomain.SubscriptionSchedule{}, nil
+}
+
+func (m *mockSubscriptionSvc) CancelSchedule(ctx context.Context, id string) (subscriptiondomain.SubscriptionSchedule, error) {
+	return subscriptiondomain.SubscriptionSchedule{}, nil
+}
+
+func (m *mockSubscriptionSvc) AdvanceSchedules(ctx context.Context, limit int) error {
+	return nil
+}
+
+func (m *mockSubscriptionSvc) UpdateItemQuantity(ctx context.Context, req subscriptiondomain.UpdateItemQuantityRequest) (subscriptiondomain.SubscriptionItemQuantityChange, error) {
+	return subscriptiondomain.SubscriptionItemQuantityChange{}, nil
+}
+
+func (m *mockSubscriptionSvc) ListItemQuantityChanges(ctx context.Context, subscriptionID, itemID string) ([]subscriptiondomain.SubscriptionItemQuantityChange, error) {
+	return nil, nil
+}
+
+func (m *mockSubscriptionSvc) ApplyScheduledQuantityChanges(ctx context.Context, limit int) error {
 	return nil
 }
 
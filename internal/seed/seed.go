@@ -291,6 +291,7 @@ func ensureLedgerAccounts(ctx context.Context, db *gorm.DB, node *snowflake.Node
 
 		{"revenue_usage", "income", "Usage Revenue"},
 		{"revenue_flat", "income", "Subscription Revenue"},
+		{"discount", "income", "Discounts"},
 
 		{"tax_payable", "liability", "Tax Payable"},
 		{"credit_balance", "liability", "Customer Credit Balance"},
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	alertdomain "github.com/smallbiznis/railzway/internal/alert/domain"
+)
+
+// @Summary      Create Alert
+// @Description  Create a usage alert that fires once per billing cycle when a customer's cycle-to-date usage of a meter reaches the threshold
+// @Tags         alerts
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body      alertdomain.CreateRequest  true  "Alert"
+// @Success      200      {object}  alertdomain.Response
+// @Router       /alerts [post]
+func (s *Server) CreateAlert(c *gin.Context) {
+	var req alertdomain.CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.alertSvc.Create(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      List Alerts
+// @Description  List usage alerts, newest first
+// @Tags         alerts
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  []alertdomain.Response
+// @Router       /alerts [get]
+func (s *Server) ListAlerts(c *gin.Context) {
+	resp, err := s.alertSvc.List(c.Request.Context())
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Get Alert
+// @Description  Get usage alert by ID
+// @Tags         alerts
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Alert ID"
+// @Success      200  {object}  alertdomain.Response
+// @Router       /alerts/{id} [get]
+func (s *Server) GetAlert(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+
+	resp, err := s.alertSvc.Get(c.Request.Context(), id)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Update Alert
+// @Description  Update the name, threshold, notification targets or active flag of a usage alert
+// @Tags         alerts
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                     true  "Alert ID"
+// @Param        request  body      alertdomain.UpdateRequest  true  "Changes"
+// @Success      200      {object}  alertdomain.Response
+// @Router       /alerts/{id} [patch]
+func (s *Server) UpdateAlert(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+
+	var req alertdomain.UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.alertSvc.Update(c.Request.Context(), id, req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Delete Alert
+// @Description  Delete a usage alert together with its history
+// @Tags         alerts
+// @Security     ApiKeyAuth
+// @Param        id   path  string  true  "Alert ID"
+// @Success      204
+// @Router       /alerts/{id} [delete]
+func (s *Server) DeleteAlert(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+
+	if err := s.alertSvc.Delete(c.Request.Context(), id); err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary      List Alert History
+// @Description  List fired alerts, most recent first
+// @Tags         alerts
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        alert_id     query     string  false  "Alert ID"
+// @Param        customer_id  query     string  false  "Customer ID"
+// @Param        limit        query     int     false  "Maximum number of events"
+// @Success      200          {object}  []alertdomain.EventResponse
+// @Router       /alerts/history [get]
+func (s *Server) ListAlertHistory(c *gin.Context) {
+	var req alertdomain.ListHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.alertSvc.ListHistory(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+func isAlertValidationError(err error) bool {
+	switch err {
+	case alertdomain.ErrInvalidOrganization,
+		alertdomain.ErrInvalidID,
+		alertdomain.ErrInvalidName,
+		alertdomain.ErrInvalidMeter,
+		alertdomain.ErrInvalidThreshold,
+		alertdomain.ErrInvalidApplyTo,
+		alertdomain.ErrInvalidCustomer,
+		alertdomain.ErrInvalidNotifyEmail,
+		alertdomain.ErrInvalidSlackChannel:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coupondomain "github.com/smallbiznis/railzway/internal/coupon/domain"
+)
+
+// @Summary      Create Coupon
+// @Description  Create a percent-off or amount-off coupon
+// @Tags         coupons
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body      coupondomain.CreateCouponRequest  true  "Coupon"
+// @Success      200      {object}  coupondomain.CouponResponse
+// @Router       /coupons [post]
+func (s *Server) CreateCoupon(c *gin.Context) {
+	var req coupondomain.CreateCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.couponSvc.CreateCoupon(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      List Coupons
+// @Description  List coupons
+// @Tags         coupons
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  []coupondomain.CouponResponse
+// @Router       /coupons [get]
+func (s *Server) ListCoupons(c *gin.Context) {
+	resp, err := s.couponSvc.ListCoupons(c.Request.Context())
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Get Coupon
+// @Description  Get coupon by ID
+// @Tags         coupons
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Coupon ID"
+// @Success      200  {object}  coupondomain.CouponResponse
+// @Router       /coupons/{id} [get]
+func (s *Server) GetCouponByID(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+
+	resp, err := s.couponSvc.GetCoupon(c.Request.Context(), id)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+func (s *Server) ArchiveCoupon(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+
+	resp, err := s.couponSvc.ArchiveCoupon(c.Request.Context(), id)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Create Promotion Code
+// @Description  Create a customer-facing code that redeems a coupon
+// @Tags         coupons
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body      coupondomain.CreatePromotionCodeRequest  true  "Promotion code"
+// @Success      200      {object}  coupondomain.PromotionCodeResponse
+// @Router       /promotion-codes [post]
+func (s *Server) CreatePromotionCode(c *gin.Context) {
+	var req coupondomain.CreatePromotionCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.couponSvc.CreatePromotionCode(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      List Promotion Codes
+// @Description  List promotion codes, optionally filtered by coupon
+// @Tags         coupons
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        coupon_id  query     string  false  "Coupon ID"
+// @Success      200        {object}  []coupondomain.PromotionCodeResponse
+// @Router       /promotion-codes [get]
+func (s *Server) ListPromotionCodes(c *gin.Context) {
+	var req coupondomain.ListPromotionCodesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.couponSvc.ListPromotionCodes(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Apply Discount
+// @Description  Attach a coupon or promotion code to a customer or subscription
+// @Tags         coupons
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body      coupondomain.ApplyDiscountRequest  true  "Discount"
+// @Success      200      {object}  coupondomain.DiscountResponse
+// @Router       /discounts [post]
+func (s *Server) ApplyDiscount(c *gin.Context) {
+	var req coupondomain.ApplyDiscountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.couponSvc.ApplyDiscount(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      List Discounts
+// @Description  List discounts attached to customers and subscriptions
+// @Tags         coupons
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        customer_id      query     string  false  "Customer ID"
+// @Param        subscription_id  query     string  false  "Subscription ID"
+// @Param        status           query     string  false  "Status"
+// @Success      200              {object}  []coupondomain.DiscountResponse
+// @Router       /discounts [get]
+func (s *Server) ListDiscounts(c *gin.Context) {
+	var req coupondomain.ListDiscountsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.couponSvc.ListDiscounts(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Remove Discount
+// @Description  Detach a discount so it no longer applies to future invoices
+// @Tags         coupons
+// @Security     ApiKeyAuth
+// @Param        id   path  string  true  "Discount ID"
+// @Success      204
+// @Router       /discounts/{id} [delete]
+func (s *Server) RemoveDiscount(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+
+	if err := s.couponSvc.RemoveDiscount(c.Request.Context(), id); err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func isCouponValidationError(err error) bool {
+	switch err {
+	case coupondomain.ErrInvalidOrganization,
+		coupondomain.ErrInvalidID,
+		coupondomain.ErrInvalidCode,
+		coupondomain.ErrInvalidName,
+		coupondomain.ErrInvalidDiscountType,
+		coupondomain.ErrInvalidPercentOff,
+		coupondomain.ErrInvalidAmountOff,
+		coupondomain.ErrInvalidCurrency,
+		coupondomain.ErrInvalidDuration,
+		coupondomain.ErrInvalidDurationInCycles,
+		coupondomain.ErrInvalidMaxRedemptions,
+		coupondomain.ErrInvalidRedeemBy,
+		coupondomain.ErrInvalidExpiresAt,
+		coupondomain.ErrInvalidCouponID,
+		coupondomain.ErrInvalidCustomerID,
+		coupondomain.ErrInvalidSubscriptionID,
+		coupondomain.ErrInvalidPromotionCode,
+		coupondomain.ErrInvalidStatus,
+		coupondomain.ErrCouponNotRedeemable,
+		coupondomain.ErrPromotionNotRedeemable,
+		coupondomain.ErrDiscountAlreadyApplied,
+		coupondomain.ErrDiscountNotActive:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/gin-gonic/gin"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
+)
+
+// @Summary      Create Credit Note
+// @Description  Issue a full or partial credit note against a finalized invoice
+// @Tags         credit_notes
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body      creditnotedomain.CreateRequest  true  "Credit note"
+// @Success      200      {object}  creditnotedomain.Response
+// @Router       /credit-notes [post]
+func (s *Server) CreateCreditNote(c *gin.Context) {
+	var req creditnotedomain.CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.creditNoteSvc.Create(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      List Credit Notes
+// @Description  List credit notes, optionally filtered by invoice or customer
+// @Tags         credit_notes
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        invoice_id   query     string  false  "Invoice ID"
+// @Param        customer_id  query     string  false  "Customer ID"
+// @Success      200          {object}  []creditnotedomain.Response
+// @Router       /credit-notes [get]
+func (s *Server) ListCreditNotes(c *gin.Context) {
+	var req creditnotedomain.ListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.creditNoteSvc.List(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Get Credit Note
+// @Description  Get credit note by ID
+// @Tags         credit_notes
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Credit Note ID"
+// @Success      200  {object}  creditnotedomain.Response
+// @Router       /credit-notes/{id} [get]
+func (s *Server) GetCreditNoteByID(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, err := snowflake.ParseString(id); err != nil {
+		AbortWithError(c, newValidationError("id", "invalid_id", "invalid id"))
+		return
+	}
+
+	resp, err := s.creditNoteSvc.GetByID(c.Request.Context(), id)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+func (s *Server) RenderCreditNote(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, err := snowflake.ParseString(id); err != nil {
+		AbortWithError(c, newValidationError("id", "invalid_id", "invalid id"))
+		return
+	}
+
+	resp, err := s.creditNoteSvc.Render(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, creditnotedomain.ErrRenderUnavailable) {
+			err = ErrServiceUnavailable
+		}
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+func (s *Server) DownloadCreditNotePDF(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, err := snowflake.ParseString(id); err != nil {
+		AbortWithError(c, newValidationError("id", "invalid_id", "invalid id"))
+		return
+	}
+
+	content, filename, err := s.creditNoteSvc.RenderPDF(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, creditnotedomain.ErrRenderUnavailable) {
+			err = ErrServiceUnavailable
+		}
+		AbortWithError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", content)
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	creditdomain "github.com/smallbiznis/railzway/internal/credit/domain"
+)
+
+// @Summary      Create Credit Grant
+// @Description  Grant prepaid or promotional credit to a customer. Credit is drawn down automatically when invoices in the same currency are finalized
+// @Tags         credits
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                           true  "Customer ID"
+// @Param        request  body      creditdomain.CreateGrantRequest  true  "Credit grant"
+// @Success      200      {object}  creditdomain.GrantResponse
+// @Router       /customers/{id}/credit_grants [post]
+func (s *Server) CreateCreditGrant(c *gin.Context) {
+	customerID := strings.TrimSpace(c.Param("id"))
+
+	var req creditdomain.CreateGrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.creditSvc.CreateGrant(c.Request.Context(), customerID, req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      List Credit Grants
+// @Description  List a customer's credit grants, newest first
+// @Tags         credits
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path      string  true   "Customer ID"
+// @Param        status  query     string  false  "Grant status (pending, active, depleted, expired)"
+// @Param        limit   query     int     false  "Maximum number of grants"
+// @Success      200     {object}  []creditdomain.GrantResponse
+// @Router       /customers/{id}/credit_grants [get]
+func (s *Server) ListCreditGrants(c *gin.Context) {
+	customerID := strings.TrimSpace(c.Param("id"))
+
+	var req creditdomain.ListGrantsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.creditSvc.ListGrants(c.Request.Context(), customerID, req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Get Credit Balance
+// @Description  Get the customer's available, unexpired credit per currency
+// @Tags         credits
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Customer ID"
+// @Success      200  {object}  creditdomain.BalanceResponse
+// @Router       /customers/{id}/credit_balance [get]
+func (s *Server) GetCreditBalance(c *gin.Context) {
+	customerID := strings.TrimSpace(c.Param("id"))
+
+	resp, err := s.creditSvc.GetBalance(c.Request.Context(), customerID)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      List Credit Transactions
+// @Description  List grants, drawdowns and expiries of a customer's credit, most recent first
+// @Tags         credits
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id        path      string  true   "Customer ID"
+// @Param        grant_id  query     string  false  "Credit grant ID"
+// @Param        limit     query     int     false  "Maximum number of transactions"
+// @Success      200       {object}  []creditdomain.TransactionResponse
+// @Router       /customers/{id}/credit_transactions [get]
+func (s *Server) ListCreditTransactions(c *gin.Context) {
+	customerID := strings.TrimSpace(c.Param("id"))
+
+	var req creditdomain.ListTransactionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.creditSvc.ListTransactions(c.Request.Context(), customerID, req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+func isCreditValidationError(err error) bool {
+	switch err {
+	case creditdomain.ErrInvalidOrganization,
+		creditdomain.ErrInvalidCustomer,
+		creditdomain.ErrInvalidID,
+		creditdomain.ErrInvalidName,
+		creditdomain.ErrInvalidGrantType,
+		creditdomain.ErrInvalidAmount,
+		creditdomain.ErrInvalidCurrency,
+		creditdomain.ErrInvalidExpiry,
+		creditdomain.ErrInvalidStatus:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	dunningdomain "github.com/smallbiznis/railzway/internal/dunning/domain"
+)
+
+// @Summary      Get Dunning Policy
+// @Description  Get the organization's dunning policy. Organizations without a stored policy get a disabled default.
+// @Tags         dunning
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  dunningdomain.PolicyResponse
+// @Router       /dunning/policy [get]
+func (s *Server) GetDunningPolicy(c *gin.Context) {
+	resp, err := s.dunningSvc.GetPolicy(c.Request.Context())
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Update Dunning Policy
+// @Description  Replace the retry schedule, reminder setting and final action used for unpaid invoices
+// @Tags         dunning
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body      dunningdomain.UpsertPolicyRequest  true  "Policy"
+// @Success      200      {object}  dunningdomain.PolicyResponse
+// @Router       /dunning/policy [put]
+func (s *Server) UpsertDunningPolicy(c *gin.Context) {
+	var req dunningdomain.UpsertPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.dunningSvc.UpsertPolicy(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      List Dunning Cases
+// @Description  List dunning cases, newest first
+// @Tags         dunning
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        status       query     string  false  "open, recovered, exhausted or closed"
+// @Param        customer_id  query     string  false  "Customer ID"
+// @Param        invoice_id   query     string  false  "Invoice ID"
+// @Param        limit        query     int     false  "Maximum number of cases"
+// @Success      200          {object}  []dunningdomain.CaseResponse
+// @Router       /dunning/cases [get]
+func (s *Server) ListDunningCases(c *gin.Context) {
+	var req dunningdomain.ListCasesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.dunningSvc.ListCases(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Get Dunning Case
+// @Description  Get dunning case by ID
+// @Tags         dunning
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Case ID"
+// @Success      200  {object}  dunningdomain.CaseResponse
+// @Router       /dunning/cases/{id} [get]
+func (s *Server) GetDunningCase(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+
+	resp, err := s.dunningSvc.GetCase(c.Request.Context(), id)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+func isDunningValidationError(err error) bool {
+	switch err {
+	case dunningdomain.ErrInvalidOrganization,
+		dunningdomain.ErrInvalidID,
+		dunningdomain.ErrInvalidRetrySchedule,
+		dunningdomain.ErrInvalidFinalAction,
+		dunningdomain.ErrInvalidStatus,
+		dunningdomain.ErrInvalidCustomer,
+		dunningdomain.ErrInvalidInvoice:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+)
+
+// @Summary      Check Entitlement
+// @Description  Check whether a customer may use a feature and how much of its limit is left in the current billing cycle
+// @Tags         entitlements
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        customer_id      query  string  true   "Customer ID"
+// @Param        feature_code     query  string  true   "Feature code"
+// @Param        quantity         query  number  false  "Usage about to be reported"
+// @Param        subscription_id  query  string  false  "Subscription ID, when the customer has several active subscriptions"
+// @Success      200  {object}  usagedomain.EntitlementCheckResponse
+// @Router       /entitlements/check [get]
+func (s *Server) CheckEntitlement(c *gin.Context) {
+	req := usagedomain.CheckEntitlementRequest{
+		CustomerID:     strings.TrimSpace(c.Query("customer_id")),
+		FeatureCode:    strings.TrimSpace(c.Query("feature_code")),
+		SubscriptionID: strings.TrimSpace(c.Query("subscription_id")),
+	}
+	if raw := strings.TrimSpace(c.Query("quantity")); raw != "" {
+		quantity, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			AbortWithError(c, newValidationError("quantity", "invalid_quantity", "invalid quantity"))
+			return
+		}
+		req.Quantity = quantity
+	}
+
+	resp, err := s.usagesvc.CheckEntitlement(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
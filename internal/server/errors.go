@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
+	alertdomain "github.com/smallbiznis/railzway/internal/alert/domain"
 	apikeydomain "github.com/smallbiznis/railzway/internal/apikey/domain"
 	auditdomain "github.com/smallbiznis/railzway/internal/audit/domain"
 	authdomain "github.com/smallbiznis/railzway/internal/auth/domain"
@@ -14,7 +16,11 @@ import (
 	billingdashboarddomain "github.com/smallbiznis/railzway/internal/billingdashboard/domain"
 	billingoperationsdomain "github.com/smallbiznis/railzway/internal/billingoperations/domain"
 	billingoverviewdomain "github.com/smallbiznis/railzway/internal/billingoverview/domain"
+	coupondomain "github.com/smallbiznis/railzway/internal/coupon/domain"
+	creditdomain "github.com/smallbiznis/railzway/internal/credit/domain"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
 	customerdomain "github.com/smallbiznis/railzway/internal/customer/domain"
+	dunningdomain "github.com/smallbiznis/railzway/internal/dunning/domain"
 	featuredomain "github.com/smallbiznis/railzway/internal/feature/domain"
 	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
 	invoicetemplatedomain "github.com/smallbiznis/railzway/internal/invoicetemplate/domain"
@@ -32,6 +38,7 @@ import (
 	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
 	taxdomain "github.com/smallbiznis/railzway/internal/tax/domain"
 	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	webhookdomain "github.com/smallbiznis/railzway/internal/webhook/domain"
 	"gorm.io/gorm"
 )
 
@@ -173,7 +180,9 @@ func mapError(err error) (int, errorPayload) {
 			Message: "forbidden",
 		}
 	case errors.Is(err, ErrConflict),
-		errors.Is(err, authdomain.ErrUserExists):
+		errors.Is(err, authdomain.ErrUserExists),
+		errors.Is(err, coupondomain.ErrCodeAlreadyExists),
+		errors.Is(err, webhookdomain.ErrDeliveryInProgress):
 		return http.StatusConflict, errorPayload{
 			Type:    "conflict",
 			Message: "conflict",
@@ -193,7 +202,8 @@ func mapError(err error) (int, errorPayload) {
 			Type:    "service_unavailable",
 			Message: "service unavailable",
 		}
-	case errors.Is(err, paymentproviderdomain.ErrEncryptionKeyMissing):
+	case errors.Is(err, paymentproviderdomain.ErrEncryptionKeyMissing),
+		errors.Is(err, usagedomain.ErrCorrectionUnavailable):
 		return http.StatusServiceUnavailable, errorPayload{
 			Type:    "service_unavailable",
 			Message: "service unavailable",
@@ -248,8 +258,15 @@ func isValidationError(err error) bool {
 		isBillingOverviewValidationError(err),
 		isInvoiceValidationError(err),
 		isInvoiceTemplateValidationError(err),
+		isCreditNoteValidationError(err),
+		isCouponValidationError(err),
+		isWebhookValidationError(err),
+		isDunningValidationError(err),
+		isAlertValidationError(err),
+		isCreditValidationError(err),
 		isRatingValidationError(err),
 		isUsageValidationError(err),
+		isAdjustmentValidationError(err),
 		isPaymentValidationError(err),
 		isProductValidationError(err),
 		isFeatureValidationError(err),
@@ -282,7 +299,38 @@ func isUsageValidationError(err error) bool {
 		usagedomain.ErrInvalidMeterCode,
 		usagedomain.ErrInvalidValue,
 		usagedomain.ErrInvalidRecordedAt,
-		usagedomain.ErrInvalidIdempotencyKey:
+		usagedomain.ErrInvalidIdempotencyKey,
+		usagedomain.ErrEmptyBatch,
+		usagedomain.ErrBatchTooLarge,
+		usagedomain.ErrInvalidUsageEvent,
+		usagedomain.ErrInvalidCorrectionReason,
+		usagedomain.ErrUsageAlreadyCorrected,
+		usagedomain.ErrUsageNotCorrectable,
+		usagedomain.ErrBillingCycleClosing,
+		usagedomain.ErrInvalidLatePolicy,
+		usagedomain.ErrLateUsageRejected,
+		usagedomain.ErrNoOpenBillingCycle,
+		usagedomain.ErrInvalidGranularity,
+		usagedomain.ErrInvalidSummaryRange,
+		usagedomain.ErrInvalidGroupBy,
+		usagedomain.ErrTooManyBuckets,
+		usagedomain.ErrInvalidFeatureCode,
+		usagedomain.ErrUsageLimitExceeded,
+		usagedomain.ErrAmbiguousSubscription:
+		return true
+	default:
+		return false
+	}
+}
+
+func isAdjustmentValidationError(err error) bool {
+	switch err {
+	case adjustmentdomain.ErrInvalidOrganization,
+		adjustmentdomain.ErrInvalidUsageEvent,
+		adjustmentdomain.ErrNotACorrection,
+		adjustmentdomain.ErrNotLateUsage,
+		adjustmentdomain.ErrBillingCycleClosing,
+		adjustmentdomain.ErrCurrencyMismatch:
 		return true
 	default:
 		return false
@@ -309,7 +357,10 @@ func isProductFeatureValidationError(err error) bool {
 		productfeaturedomain.ErrInvalidProductID,
 		productfeaturedomain.ErrInvalidFeatureID,
 		productfeaturedomain.ErrInvalidMeterID,
-		productfeaturedomain.ErrFeatureInactive:
+		productfeaturedomain.ErrFeatureInactive,
+		productfeaturedomain.ErrInvalidUsageLimit,
+		productfeaturedomain.ErrInvalidEnforcement,
+		productfeaturedomain.ErrLimitNotMetered:
 		return true
 	default:
 		return false
@@ -381,9 +432,25 @@ func isNotFoundError(err error) bool {
 		errors.Is(err, pricetierdomain.ErrNotFound),
 		errors.Is(err, invoicedomain.ErrBillingCycleNotFound),
 		errors.Is(err, invoicedomain.ErrInvoiceNotFound),
+		errors.Is(err, invoicedomain.ErrNoUpcomingInvoice),
+		errors.Is(err, creditnotedomain.ErrNotFound),
+		errors.Is(err, creditnotedomain.ErrInvoiceNotFound),
+		errors.Is(err, coupondomain.ErrNotFound),
+		errors.Is(err, coupondomain.ErrCouponNotFound),
+		errors.Is(err, coupondomain.ErrPromotionCodeNotFound),
+		errors.Is(err, coupondomain.ErrCustomerNotFound),
+		errors.Is(err, coupondomain.ErrSubscriptionNotFound),
+		errors.Is(err, webhookdomain.ErrEndpointNotFound),
+		errors.Is(err, webhookdomain.ErrDeliveryNotFound),
+		errors.Is(err, dunningdomain.ErrCaseNotFound),
+		errors.Is(err, alertdomain.ErrAlertNotFound),
+		errors.Is(err, creditdomain.ErrCustomerNotFound),
+		errors.Is(err, usagedomain.ErrUsageEventNotFound),
+		errors.Is(err, adjustmentdomain.ErrUsageEventNotFound),
 		errors.Is(err, ratingdomain.ErrBillingCycleNotFound),
 		errors.Is(err, subscriptiondomain.ErrSubscriptionNotFound),
 		errors.Is(err, subscriptiondomain.ErrSubscriptionItemNotFound),
+		errors.Is(err, subscriptiondomain.ErrScheduleNotFound),
 		errors.Is(err, paymentdomain.ErrProviderNotFound),
 		errors.Is(err, paymentproviderdomain.ErrNotFound),
 		errors.Is(err, taxdomain.ErrNotFound),
@@ -404,7 +471,15 @@ func isInvoiceValidationError(err error) bool {
 		invoicedomain.ErrCurrencyMismatch,
 		invoicedomain.ErrInvalidInvoiceID,
 		invoicedomain.ErrInvoiceNotDraft,
-		invoicedomain.ErrInvoiceNotFinalized:
+		invoicedomain.ErrInvoiceNotFinalized,
+		invoicedomain.ErrInvalidCustomer,
+		invoicedomain.ErrInvalidSubscription,
+		invoicedomain.ErrAmbiguousSubscription,
+		invoicedomain.ErrInvoiceAlreadyPaid,
+		invoicedomain.ErrInvoiceHasCreditNotes,
+		invoicedomain.ErrCarriedCreditUsed,
+		invoicedomain.ErrInvalidPaymentMethod,
+		invoicedomain.ErrInvalidPaidAt:
 		return true
 	default:
 		return false
@@ -424,6 +499,25 @@ func isInvoiceTemplateValidationError(err error) bool {
 	}
 }
 
+func isCreditNoteValidationError(err error) bool {
+	switch err {
+	case creditnotedomain.ErrInvalidOrganization,
+		creditnotedomain.ErrInvalidID,
+		creditnotedomain.ErrInvalidInvoiceID,
+		creditnotedomain.ErrInvalidCustomerID,
+		creditnotedomain.ErrInvalidReason,
+		creditnotedomain.ErrInvalidDestination,
+		creditnotedomain.ErrInvalidItems,
+		creditnotedomain.ErrInvalidAmount,
+		creditnotedomain.ErrInvoiceNotFinalized,
+		creditnotedomain.ErrAmountExceedsInvoice,
+		creditnotedomain.ErrNothingToCredit:
+		return true
+	default:
+		return false
+	}
+}
+
 func isAPIKeyValidationError(err error) bool {
 	switch err {
 	case apikeydomain.ErrInvalidOrganization,
@@ -498,8 +592,11 @@ func isRatingValidationError(err error) bool {
 	switch err {
 	case ratingdomain.ErrInvalidBillingCycle,
 		ratingdomain.ErrBillingCycleNotClosing,
+		ratingdomain.ErrBillingCycleNotOpen,
+		ratingdomain.ErrNoSubscriptionItems,
 		ratingdomain.ErrMissingUsage,
 		ratingdomain.ErrMissingPriceAmount,
+		ratingdomain.ErrPriceNotFound,
 		ratingdomain.ErrMissingMeter,
 		ratingdomain.ErrInvalidQuantity:
 		return true
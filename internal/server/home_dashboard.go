@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	auditdomain "github.com/smallbiznis/railzway/internal/audit/domain"
 	billingoverviewdomain "github.com/smallbiznis/railzway/internal/billingoverview/domain"
+	"github.com/smallbiznis/railzway/internal/money"
 	"github.com/smallbiznis/railzway/internal/orgcontext"
 	"github.com/smallbiznis/railzway/pkg/db/pagination"
 )
@@ -69,10 +70,10 @@ func (s *Server) GetHomeDashboard(c *gin.Context) {
 	previousRevenue := 0.0
 	if err == nil {
 		if revenue.Total != nil {
-			currentRevenue = float64(*revenue.Total) / 100.0 // Cents to Dollars
+			currentRevenue = money.ToMajor(*revenue.Total, revenue.Currency).Float64()
 		}
 		if revenue.Previous != nil {
-			previousRevenue = float64(*revenue.Previous) / 100.0
+			previousRevenue = money.ToMajor(*revenue.Previous, revenue.Currency).Float64()
 		}
 	}
 
@@ -2,6 +2,7 @@ package server
 
 import (
 	"errors"
+	"io"
 	"net/http"
 	"strings"
 
@@ -189,6 +190,124 @@ func (s *Server) RenderInvoice(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": resp})
 }
 
+// @Summary      Preview Upcoming Invoice
+// @Description  Preview the invoice the customer's open billing cycle will produce at close, without persisting anything
+// @Tags         invoices
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id               path      string  true   "Customer ID"
+// @Param        subscription_id  query     string  false  "Subscription ID, required when the customer has several subscriptions"
+// @Success      200  {object}  invoicedomain.UpcomingInvoice
+// @Router       /customers/{id}/upcoming_invoice [get]
+func (s *Server) GetUpcomingInvoice(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, err := snowflake.ParseString(id); err != nil {
+		AbortWithError(c, newValidationError("id", "invalid_id", "invalid id"))
+		return
+	}
+
+	subscriptionID := strings.TrimSpace(c.Query("subscription_id"))
+	if subscriptionID != "" {
+		if _, err := snowflake.ParseString(subscriptionID); err != nil {
+			AbortWithError(c, newValidationError("subscription_id", "invalid_id", "invalid subscription_id"))
+			return
+		}
+	}
+
+	resp, err := s.invoiceSvc.PreviewUpcomingInvoice(c.Request.Context(), id, subscriptionID)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+type voidInvoiceRequest struct {
+	Reason string `json:"reason"`
+}
+
+// @Summary      Finalize Invoice
+// @Description  Finalize a draft invoice, freezing tax and posting it to the ledger
+// @Tags         invoices
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Invoice ID"
+// @Success      200  {object}  invoicedomain.Invoice
+// @Router       /invoices/{id}/finalize [post]
+func (s *Server) FinalizeInvoice(c *gin.Context) {
+	s.transitionInvoice(c, func(id string) error {
+		return s.invoiceSvc.FinalizeInvoice(c.Request.Context(), id)
+	})
+}
+
+// @Summary      Void Invoice
+// @Description  Void a finalized, unpaid invoice
+// @Tags         invoices
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string              true   "Invoice ID"
+// @Param        request  body      voidInvoiceRequest  false  "Void reason"
+// @Success      200      {object}  invoicedomain.Invoice
+// @Router       /invoices/{id}/void [post]
+func (s *Server) VoidInvoice(c *gin.Context) {
+	var req voidInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	s.transitionInvoice(c, func(id string) error {
+		return s.invoiceSvc.VoidInvoice(c.Request.Context(), id, req.Reason)
+	})
+}
+
+// @Summary      Mark Invoice Paid
+// @Description  Settle a finalized invoice with a payment received out of band, e.g. wire transfer or cheque
+// @Tags         invoices
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                         true  "Invoice ID"
+// @Param        request  body      invoicedomain.MarkPaidRequest  true  "Payment details"
+// @Success      200      {object}  invoicedomain.Invoice
+// @Router       /invoices/{id}/mark_paid [post]
+func (s *Server) MarkInvoicePaid(c *gin.Context) {
+	var req invoicedomain.MarkPaidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	s.transitionInvoice(c, func(id string) error {
+		return s.invoiceSvc.MarkInvoicePaid(c.Request.Context(), id, req)
+	})
+}
+
+func (s *Server) transitionInvoice(c *gin.Context, apply func(id string) error) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, err := snowflake.ParseString(id); err != nil {
+		AbortWithError(c, newValidationError("id", "invalid_id", "invalid id"))
+		return
+	}
+
+	if err := apply(id); err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	item, err := s.invoiceSvc.GetByID(c.Request.Context(), id)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": item})
+}
+
 func parseInvoiceStatus(value string) (*invoicedomain.InvoiceStatus, error) {
 	status := strings.TrimSpace(value)
 	if status == "" {
@@ -12,6 +12,7 @@ type createMeterRequest struct {
 	Code            string  `json:"code"`
 	Name            string  `json:"name"`
 	AggregationType string  `json:"aggregation_type"`
+	AggregationKey  string  `json:"aggregation_key"`
 	Unit            string  `json:"unit"`
 	Description     *string `json:"description"`
 	Active          *bool   `json:"active"`
@@ -20,6 +21,7 @@ type createMeterRequest struct {
 type updateMeterRequest struct {
 	Name            *string `json:"name,omitempty"`
 	AggregationType *string `json:"aggregation_type,omitempty"`
+	AggregationKey  *string `json:"aggregation_key,omitempty"`
 	Unit            *string `json:"unit,omitempty"`
 	Active          *bool   `json:"active,omitempty"`
 }
@@ -41,11 +43,12 @@ func (s *Server) CreateMeter(c *gin.Context) {
 	}
 
 	resp, err := s.meterSvc.Create(c.Request.Context(), meterdomain.CreateRequest{
-		Code:        strings.TrimSpace(req.Code),
-		Name:        strings.TrimSpace(req.Name),
-		Aggregation: strings.TrimSpace(req.AggregationType),
-		Unit:        strings.TrimSpace(req.Unit),
-		Active:      req.Active,
+		Code:           strings.TrimSpace(req.Code),
+		Name:           strings.TrimSpace(req.Name),
+		Aggregation:    strings.TrimSpace(req.AggregationType),
+		AggregationKey: strings.TrimSpace(req.AggregationKey),
+		Unit:           strings.TrimSpace(req.Unit),
+		Active:         req.Active,
 	})
 	if err != nil {
 		AbortWithError(c, err)
@@ -139,11 +142,12 @@ func (s *Server) UpdateMeter(c *gin.Context) {
 	}
 
 	resp, err := s.meterSvc.Update(c.Request.Context(), meterdomain.UpdateRequest{
-		ID:          id,
-		Name:        trimStringPtr(req.Name),
-		Aggregation: trimStringPtr(req.AggregationType),
-		Unit:        trimStringPtr(req.Unit),
-		Active:      req.Active,
+		ID:             id,
+		Name:           trimStringPtr(req.Name),
+		Aggregation:    trimStringPtr(req.AggregationType),
+		AggregationKey: trimStringPtr(req.AggregationKey),
+		Unit:           trimStringPtr(req.Unit),
+		Active:         req.Active,
 	})
 	if err != nil {
 		AbortWithError(c, err)
@@ -178,6 +182,7 @@ func isMeterValidationError(err error) bool {
 		meterdomain.ErrInvalidCode,
 		meterdomain.ErrInvalidName,
 		meterdomain.ErrInvalidAggregation,
+		meterdomain.ErrInvalidAggregationKey,
 		meterdomain.ErrInvalidUnit,
 		meterdomain.ErrInvalidID:
 		return true
@@ -114,6 +114,7 @@ func isOrganizationValidationError(err error) bool {
 		organizationdomain.ErrInvalidCountry,
 		organizationdomain.ErrInvalidTimezone,
 		organizationdomain.ErrInvalidCurrency,
+		organizationdomain.ErrInvalidRoundingMode,
 		organizationdomain.ErrInvalidUser,
 		organizationdomain.ErrInvalidEmail,
 		organizationdomain.ErrInvalidRole:
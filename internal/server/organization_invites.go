@@ -19,8 +19,9 @@ type inviteMemberRequest struct {
 }
 
 type billingPreferencesRequest struct {
-	Currency string `json:"currency"`
-	Timezone string `json:"timezone"`
+	Currency     string `json:"currency"`
+	Timezone     string `json:"timezone"`
+	RoundingMode string `json:"rounding_mode"`
 }
 
 func (s *Server) InviteOrganizationMembers(c *gin.Context) {
@@ -83,8 +84,9 @@ func (s *Server) SetOrganizationBillingPreferences(c *gin.Context) {
 	}
 
 	if err := s.organizationSvc.SetBillingPreferences(c.Request.Context(), userID, orgID, organizationdomain.BillingPreferencesRequest{
-		Currency: req.Currency,
-		Timezone: req.Timezone,
+		Currency:     req.Currency,
+		Timezone:     req.Timezone,
+		RoundingMode: req.RoundingMode,
 	}); err != nil {
 		AbortWithError(c, err)
 		return
@@ -93,6 +95,28 @@ func (s *Server) SetOrganizationBillingPreferences(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+func (s *Server) GetOrganizationBillingPreferences(c *gin.Context) {
+	userID, ok := s.userIDFromSession(c)
+	if !ok {
+		AbortWithError(c, ErrUnauthorized)
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Param("id"))
+	if orgID == "" {
+		AbortWithError(c, organizationdomain.ErrInvalidOrganization)
+		return
+	}
+
+	resp, err := s.organizationSvc.GetBillingPreferences(c.Request.Context(), userID, orgID)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 func (s *Server) AcceptOrganizationInvite(c *gin.Context) {
 	userID, ok := s.userIDFromSession(c)
 	if !ok {
@@ -17,6 +17,7 @@ type createPriceRequest struct {
 	Description          string                      `json:"description"`
 	PricingModel         pricedomain.PricingModel    `json:"pricing_model"`
 	BillingMode          pricedomain.BillingMode     `json:"billing_mode"`
+	BillingTiming        pricedomain.BillingTiming   `json:"billing_timing"`
 	BillingInterval      pricedomain.BillingInterval `json:"billing_interval"`
 	BillingIntervalCount int32                       `json:"billing_interval_count"`
 	AggregateUsage       *pricedomain.AggregateUsage `json:"aggregate_usage"`
@@ -55,6 +56,7 @@ func (s *Server) CreatePrice(c *gin.Context) {
 		Description:          req.Description,
 		PricingModel:         req.PricingModel,
 		BillingMode:          req.BillingMode,
+		BillingTiming:        req.BillingTiming,
 		BillingInterval:      req.BillingInterval,
 		BillingIntervalCount: req.BillingIntervalCount,
 		AggregateUsage:       req.AggregateUsage,
@@ -76,12 +78,13 @@ func (s *Server) CreatePrice(c *gin.Context) {
 	if s.auditSvc != nil {
 		targetID := resp.ID.String()
 		_ = s.auditSvc.AuditLog(c.Request.Context(), nil, "", nil, "price.create", "price", &targetID, map[string]any{
-			"price_id":      resp.ID,
-			"product_id":    resp.ProductID,
-			"code":          resp.Code,
-			"pricing_model": resp.PricingModel,
-			"billing_mode":  resp.BillingMode,
-			"active":        resp.Active,
+			"price_id":       resp.ID,
+			"product_id":     resp.ProductID,
+			"code":           resp.Code,
+			"pricing_model":  resp.PricingModel,
+			"billing_mode":   resp.BillingMode,
+			"billing_timing": resp.BillingTiming,
+			"active":         resp.Active,
 		})
 	}
 
@@ -133,6 +136,7 @@ func isPriceValidationError(err error) bool {
 		pricedomain.ErrInvalidCode,
 		pricedomain.ErrInvalidPricingModel,
 		pricedomain.ErrInvalidBillingMode,
+		pricedomain.ErrInvalidBillingTiming,
 		pricedomain.ErrInvalidBillingInterval,
 		pricedomain.ErrInvalidBillingIntervalCount,
 		pricedomain.ErrInvalidAggregateUsage,
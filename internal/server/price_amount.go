@@ -38,6 +38,9 @@ func (s *Server) CreatePriceAmount(c *gin.Context) {
 			"currency":          resp.Currency,
 			"unit_amount_cents": resp.UnitAmountCents,
 		}
+		if resp.UnitAmountDecimal != nil {
+			metadata["unit_amount_decimal"] = resp.UnitAmountDecimal.String()
+		}
 		if resp.MinimumAmountCents != nil {
 			metadata["minimum_amount_cents"] = *resp.MinimumAmountCents
 		}
@@ -57,6 +60,7 @@ func (s *Server) CreatePriceAmount(c *gin.Context) {
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Param        price_id query string false "Price ID"
+// @Param        currency query string false "Currency"
 // @Success      200  {object}  []priceamountdomain.PriceAmount
 // @Router       /price_amounts [get]
 func (s *Server) ListPriceAmounts(c *gin.Context) {
@@ -82,6 +82,7 @@ func isPricingValidationError(err error) bool {
 		pricedomain.ErrInvalidCode,
 		pricedomain.ErrInvalidPricingModel,
 		pricedomain.ErrInvalidBillingMode,
+		pricedomain.ErrInvalidBillingTiming,
 		pricedomain.ErrInvalidBillingInterval,
 		pricedomain.ErrInvalidBillingIntervalCount,
 		pricedomain.ErrInvalidAggregateUsage,
@@ -9,7 +9,14 @@ import (
 )
 
 type replaceProductFeaturesRequest struct {
-	FeatureIDs []string `json:"feature_ids"`
+	FeatureIDs []string                     `json:"feature_ids"`
+	Limits     []productFeatureLimitRequest `json:"limits"`
+}
+
+type productFeatureLimitRequest struct {
+	FeatureID   string  `json:"feature_id"`
+	UsageLimit  float64 `json:"usage_limit"`
+	Enforcement string  `json:"enforcement"`
 }
 
 func (s *Server) ListProductFeatures(c *gin.Context) {
@@ -34,9 +41,19 @@ func (s *Server) ReplaceProductFeatures(c *gin.Context) {
 		return
 	}
 
+	limits := make([]productfeaturedomain.FeatureLimit, 0, len(req.Limits))
+	for _, limit := range req.Limits {
+		limits = append(limits, productfeaturedomain.FeatureLimit{
+			FeatureID:   limit.FeatureID,
+			UsageLimit:  limit.UsageLimit,
+			Enforcement: limit.Enforcement,
+		})
+	}
+
 	resp, err := s.productFeatureSvc.Replace(c.Request.Context(), productfeaturedomain.ReplaceRequest{
 		ProductID:  productID,
 		FeatureIDs: req.FeatureIDs,
+		Limits:     limits,
 	})
 	if err != nil {
 		AbortWithError(c, err)
@@ -48,6 +65,7 @@ func (s *Server) ReplaceProductFeatures(c *gin.Context) {
 		_ = s.auditSvc.AuditLog(c.Request.Context(), nil, "", nil, "product.features.replace", "product", &targetID, map[string]any{
 			"product_id":  productID,
 			"feature_ids": req.FeatureIDs,
+			"limits":      req.Limits,
 		})
 	}
 
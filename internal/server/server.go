@@ -10,6 +10,9 @@ import (
 	"github.com/bwmarrin/snowflake"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/smallbiznis/railzway/internal/adjustment"
+	"github.com/smallbiznis/railzway/internal/alert"
+	alertdomain "github.com/smallbiznis/railzway/internal/alert/domain"
 	"github.com/smallbiznis/railzway/internal/apikey"
 	apikeydomain "github.com/smallbiznis/railzway/internal/apikey/domain"
 	"github.com/smallbiznis/railzway/internal/audit"
@@ -30,8 +33,16 @@ import (
 	billingoverviewdomain "github.com/smallbiznis/railzway/internal/billingoverview/domain"
 	"github.com/smallbiznis/railzway/internal/cloudmetrics"
 	"github.com/smallbiznis/railzway/internal/config"
+	"github.com/smallbiznis/railzway/internal/coupon"
+	coupondomain "github.com/smallbiznis/railzway/internal/coupon/domain"
+	"github.com/smallbiznis/railzway/internal/credit"
+	creditdomain "github.com/smallbiznis/railzway/internal/credit/domain"
+	"github.com/smallbiznis/railzway/internal/creditnote"
+	creditnotedomain "github.com/smallbiznis/railzway/internal/creditnote/domain"
 	"github.com/smallbiznis/railzway/internal/customer"
 	customerdomain "github.com/smallbiznis/railzway/internal/customer/domain"
+	"github.com/smallbiznis/railzway/internal/dunning"
+	dunningdomain "github.com/smallbiznis/railzway/internal/dunning/domain"
 	"github.com/smallbiznis/railzway/internal/events"
 	"github.com/smallbiznis/railzway/internal/feature"
 	featuredomain "github.com/smallbiznis/railzway/internal/feature/domain"
@@ -65,6 +76,7 @@ import (
 	paymentprovider "github.com/smallbiznis/railzway/internal/providers/payment"
 	paymentproviderdomain "github.com/smallbiznis/railzway/internal/providers/payment/domain"
 	"github.com/smallbiznis/railzway/internal/providers/pdf"
+	"github.com/smallbiznis/railzway/internal/providers/slack"
 	"github.com/smallbiznis/railzway/internal/publicinvoice"
 	publicinvoicedomain "github.com/smallbiznis/railzway/internal/publicinvoice/domain"
 	"github.com/smallbiznis/railzway/internal/ratelimit"
@@ -80,6 +92,8 @@ import (
 	"github.com/smallbiznis/railzway/internal/usage"
 	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
 	"github.com/smallbiznis/railzway/internal/usage/liveevents"
+	"github.com/smallbiznis/railzway/internal/webhook"
+	webhookdomain "github.com/smallbiznis/railzway/internal/webhook/domain"
 	"go.uber.org/fx"
 	"gorm.io/gorm"
 )
@@ -101,9 +115,13 @@ var Module = fx.Module("http.server",
 	billingoperations.Module,
 	email.Module,
 	pdf.Module,
+	slack.Module,
 	billingoverview.Module,
 	invoice.Module,
 	invoicetemplate.Module,
+	creditnote.Module,
+	coupon.Module,
+	adjustment.Module,
 	ledger.Module,
 	meter.Module,
 	organization.Module,
@@ -121,6 +139,10 @@ var Module = fx.Module("http.server",
 	ratelimit.Module,
 	subscription.Module,
 	usage.Module,
+	webhook.Module,
+	dunning.Module,
+	alert.Module,
+	credit.Module,
 	fx.Provide(NewServer),
 	fx.Invoke(RegisterRoutes),
 	fx.Invoke(RunHTTP),
@@ -213,6 +235,12 @@ type Server struct {
 	paymentSvc                  paymentdomain.Service
 	paymentProviderSvc          paymentproviderdomain.Service
 	invoiceTemplateSvc          invoicetemplatedomain.Service
+	creditNoteSvc               creditnotedomain.Service
+	couponSvc                   coupondomain.Service
+	webhookSvc                  webhookdomain.Service
+	dunningSvc                  dunningdomain.Service
+	alertSvc                    alertdomain.Service
+	creditSvc                   creditdomain.Service
 	refrepo                     referencedomain.Repository
 	signupsvc                   signupdomain.Service
 	ratingSvc                   ratingdomain.Service
@@ -261,6 +289,12 @@ type ServerParams struct {
 	PaymentSvc           paymentdomain.Service           `optional:"true"`
 	PaymentProviderSvc   paymentproviderdomain.Service   `optional:"true"`
 	InvoiceTemplateSvc   invoicetemplatedomain.Service   `optional:"true"`
+	CreditNoteSvc        creditnotedomain.Service        `optional:"true"`
+	CouponSvc            coupondomain.Service            `optional:"true"`
+	WebhookSvc           webhookdomain.Service           `optional:"true"`
+	DunningSvc           dunningdomain.Service           `optional:"true"`
+	AlertSvc             alertdomain.Service             `optional:"true"`
+	CreditSvc            creditdomain.Service            `optional:"true"`
 	Refrepo              referencedomain.Repository      `optional:"true"`
 	RatingSvc            ratingdomain.Service            `optional:"true"`
 	SubscriptionSvc      subscriptiondomain.Service      `optional:"true"`
@@ -304,6 +338,12 @@ func NewServer(p ServerParams) *Server {
 		paymentSvc:                  p.PaymentSvc,
 		paymentProviderSvc:          p.PaymentProviderSvc,
 		invoiceTemplateSvc:          p.InvoiceTemplateSvc,
+		creditNoteSvc:               p.CreditNoteSvc,
+		couponSvc:                   p.CouponSvc,
+		webhookSvc:                  p.WebhookSvc,
+		dunningSvc:                  p.DunningSvc,
+		alertSvc:                    p.AlertSvc,
+		creditSvc:                   p.CreditSvc,
 		refrepo:                     p.Refrepo,
 		ratingSvc:                   p.RatingSvc,
 		subscriptionSvc:             p.SubscriptionSvc,
@@ -344,6 +384,7 @@ func (s *Server) RegisterAuthRoutes() {
 		user.POST("/orgs", s.CreateOrganization)
 		user.PATCH("/orgs/:id", s.UpdateOrganization)
 		user.POST("/orgs/:id/invites", s.InviteOrganizationMembers)
+		user.GET("/orgs/:id/billing-preferences", s.GetOrganizationBillingPreferences)
 		user.POST("/orgs/:id/billing-preferences", s.SetOrganizationBillingPreferences)
 	}
 
@@ -403,6 +444,14 @@ func (s *Server) RegisterAPIRoutes() {
 	api.POST("/subscriptions", s.APIKeyRequired(), s.CreateSubscription)
 	api.GET("/subscriptions/:id", s.APIKeyRequired(), s.GetSubscriptionByID)
 	api.PUT("/subscriptions/:id/items", s.APIKeyRequired(), s.ReplaceSubscriptionItems)
+	api.POST("/subscriptions/:id/plan", s.APIKeyRequired(), s.ChangeSubscriptionPlan)
+	api.GET("/subscriptions/:id/plan-changes", s.APIKeyRequired(), s.ListSubscriptionPlanChanges)
+	api.POST("/subscriptions/:id/items/:item_id/quantity", s.APIKeyRequired(), s.UpdateSubscriptionItemQuantity)
+	api.GET("/subscriptions/:id/items/:item_id/quantity-changes", s.APIKeyRequired(), s.ListSubscriptionItemQuantityChanges)
+	api.POST("/subscription-schedules", s.APIKeyRequired(), s.CreateSubscriptionSchedule)
+	api.POST("/subscription-schedules/preview", s.APIKeyRequired(), s.PreviewSubscriptionSchedule)
+	api.GET("/subscription-schedules/:id", s.APIKeyRequired(), s.GetSubscriptionSchedule)
+	api.POST("/subscription-schedules/:id/cancel", s.APIKeyRequired(), s.CancelSubscriptionSchedule)
 	api.POST("/subscriptions/:id/activate", s.APIKeyRequired(), s.ActivateSubscription)
 	api.POST("/subscriptions/:id/pause", s.APIKeyRequired(), s.PauseSubscription)
 	api.POST("/subscriptions/:id/resume", s.APIKeyRequired(), s.ResumeSubscription)
@@ -411,16 +460,73 @@ func (s *Server) RegisterAPIRoutes() {
 	// -------- Invoices --------
 	api.GET("/invoices", s.APIKeyRequired(), s.ListInvoices)
 	api.GET("/invoices/:id", s.APIKeyRequired(), s.GetInvoiceByID)
+	api.POST("/invoices/:id/finalize", s.APIKeyRequired(), s.authorizeOrgAction(authorization.ObjectInvoice, authorization.ActionInvoiceFinalize), s.FinalizeInvoice)
+	api.POST("/invoices/:id/void", s.APIKeyRequired(), s.authorizeOrgAction(authorization.ObjectInvoice, authorization.ActionInvoiceVoid), s.VoidInvoice)
+	api.POST("/invoices/:id/mark_paid", s.APIKeyRequired(), s.authorizeOrgAction(authorization.ObjectInvoice, authorization.ActionInvoiceMarkPaid), s.MarkInvoicePaid)
+
+	// -------- Credit Notes --------
+	api.GET("/credit-notes", s.APIKeyRequired(), s.ListCreditNotes)
+	api.POST("/credit-notes", s.APIKeyRequired(), s.CreateCreditNote)
+	api.GET("/credit-notes/:id", s.APIKeyRequired(), s.GetCreditNoteByID)
+
+	// -------- Coupons & Discounts --------
+	api.GET("/coupons", s.APIKeyRequired(), s.ListCoupons)
+	api.POST("/coupons", s.APIKeyRequired(), s.CreateCoupon)
+	api.GET("/coupons/:id", s.APIKeyRequired(), s.GetCouponByID)
+	api.GET("/promotion-codes", s.APIKeyRequired(), s.ListPromotionCodes)
+	api.POST("/promotion-codes", s.APIKeyRequired(), s.CreatePromotionCode)
+	api.GET("/discounts", s.APIKeyRequired(), s.ListDiscounts)
+	api.POST("/discounts", s.APIKeyRequired(), s.ApplyDiscount)
+	api.DELETE("/discounts/:id", s.APIKeyRequired(), s.RemoveDiscount)
+
+	// -------- Webhooks --------
+	api.GET("/webhook-endpoints", s.APIKeyRequired(), s.ListWebhookEndpoints)
+	api.POST("/webhook-endpoints", s.APIKeyRequired(), s.CreateWebhookEndpoint)
+	api.GET("/webhook-endpoints/:id", s.APIKeyRequired(), s.GetWebhookEndpoint)
+	api.PATCH("/webhook-endpoints/:id", s.APIKeyRequired(), s.UpdateWebhookEndpoint)
+	api.DELETE("/webhook-endpoints/:id", s.APIKeyRequired(), s.DeleteWebhookEndpoint)
+	api.POST("/webhook-endpoints/:id/rotate-secret", s.APIKeyRequired(), s.RotateWebhookSecret)
+	api.GET("/webhook-deliveries", s.APIKeyRequired(), s.ListWebhookDeliveries)
+	api.GET("/webhook-deliveries/:id", s.APIKeyRequired(), s.GetWebhookDelivery)
+	api.GET("/webhook-deliveries/:id/attempts", s.APIKeyRequired(), s.ListWebhookDeliveryAttempts)
+	api.POST("/webhook-deliveries/:id/redeliver", s.APIKeyRequired(), s.RedeliverWebhook)
+
+	// -------- Dunning --------
+	api.GET("/dunning/policy", s.APIKeyRequired(), s.GetDunningPolicy)
+	api.PUT("/dunning/policy", s.APIKeyRequired(), s.UpsertDunningPolicy)
+	api.GET("/dunning/cases", s.APIKeyRequired(), s.ListDunningCases)
+	api.GET("/dunning/cases/:id", s.APIKeyRequired(), s.GetDunningCase)
+
+	// -------- Alerts --------
+	api.GET("/alerts", s.APIKeyRequired(), s.ListAlerts)
+	api.POST("/alerts", s.APIKeyRequired(), s.CreateAlert)
+	api.GET("/alerts/history", s.APIKeyRequired(), s.ListAlertHistory)
+	api.GET("/alerts/:id", s.APIKeyRequired(), s.GetAlert)
+	api.PATCH("/alerts/:id", s.APIKeyRequired(), s.UpdateAlert)
+	api.DELETE("/alerts/:id", s.APIKeyRequired(), s.DeleteAlert)
 
 	// -------- Customers --------
 	api.GET("/customers", s.APIKeyRequired(), s.ListCustomers)
 	api.POST("/customers", s.APIKeyRequired(), s.CreateCustomer)
 	api.GET("/customers/:id", s.APIKeyRequired(), s.GetCustomerByID)
+	api.GET("/customers/:id/upcoming_invoice", s.APIKeyRequired(), s.GetUpcomingInvoice)
+	api.GET("/customers/:id/credit_grants", s.APIKeyRequired(), s.ListCreditGrants)
+	api.POST("/customers/:id/credit_grants", s.APIKeyRequired(), s.CreateCreditGrant)
+	api.GET("/customers/:id/credit_balance", s.APIKeyRequired(), s.GetCreditBalance)
+	api.GET("/customers/:id/credit_transactions", s.APIKeyRequired(), s.ListCreditTransactions)
+
+	api.GET("/entitlements/check", s.APIKeyRequired(), s.CheckEntitlement)
 
 	// -------- Payment Webhooks --------
 	api.POST("/payments/webhooks/:provider", s.HandlePaymentWebhook)
 
 	api.POST("/usage", s.APIKeyRequired(), s.UsageIngestRateLimit(), s.IngestUsage)
+	api.POST("/usage/batch", s.APIKeyRequired(), s.UsageBatchIngestRateLimit(), s.IngestUsageBatch)
+	api.GET("/usage/summary", s.APIKeyRequired(), s.GetUsageSummary)
+	api.GET("/usage/late_policy", s.APIKeyRequired(), s.GetUsageLatePolicy)
+	api.PUT("/usage/late_policy", s.APIKeyRequired(), s.UpsertUsageLatePolicy)
+	api.POST("/usage/:id/retract", s.APIKeyRequired(), s.authorizeOrgAction(authorization.ObjectUsage, authorization.ActionUsageCorrect), s.RetractUsage)
+	api.POST("/usage/:id/amend", s.APIKeyRequired(), s.authorizeOrgAction(authorization.ObjectUsage, authorization.ActionUsageCorrect), s.AmendUsage)
 
 	if s.cfg.Environment != "production" {
 		api.POST("/test/cleanup", s.TestCleanup)
@@ -494,6 +600,14 @@ func (s *Server) RegisterAdminRoutes() {
 	admin.POST("/subscriptions", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.CreateSubscription)
 	admin.GET("/subscriptions/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.GetSubscriptionByID)
 	admin.PUT("/subscriptions/:id/items", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.ReplaceSubscriptionItems)
+	admin.POST("/subscriptions/:id/plan", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.ChangeSubscriptionPlan)
+	admin.GET("/subscriptions/:id/plan-changes", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.ListSubscriptionPlanChanges)
+	admin.POST("/subscriptions/:id/items/:item_id/quantity", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.UpdateSubscriptionItemQuantity)
+	admin.GET("/subscriptions/:id/items/:item_id/quantity-changes", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.ListSubscriptionItemQuantityChanges)
+	admin.POST("/subscription-schedules", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.CreateSubscriptionSchedule)
+	admin.POST("/subscription-schedules/preview", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.PreviewSubscriptionSchedule)
+	admin.GET("/subscription-schedules/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.GetSubscriptionSchedule)
+	admin.POST("/subscription-schedules/:id/cancel", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.CancelSubscriptionSchedule)
 	admin.POST("/subscriptions/:id/activate", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.authorizeOrgAction(authorization.ObjectSubscription, authorization.ActionSubscriptionActivate), s.ActivateSubscription)
 	admin.POST("/subscriptions/:id/pause", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.authorizeOrgAction(authorization.ObjectSubscription, authorization.ActionSubscriptionPause), s.PauseSubscription)
 	admin.POST("/subscriptions/:id/resume", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.authorizeOrgAction(authorization.ObjectSubscription, authorization.ActionSubscriptionResume), s.ResumeSubscription)
@@ -503,6 +617,60 @@ func (s *Server) RegisterAdminRoutes() {
 	admin.GET("/invoices", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.ListInvoices)
 	admin.GET("/invoices/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.GetInvoiceByID)
 	admin.GET("/invoices/:id/render", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.RenderInvoice)
+	admin.POST("/invoices/:id/finalize", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.authorizeOrgAction(authorization.ObjectInvoice, authorization.ActionInvoiceFinalize), s.FinalizeInvoice)
+	admin.POST("/invoices/:id/void", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.authorizeOrgAction(authorization.ObjectInvoice, authorization.ActionInvoiceVoid), s.VoidInvoice)
+	admin.POST("/invoices/:id/mark_paid", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.authorizeOrgAction(authorization.ObjectInvoice, authorization.ActionInvoiceMarkPaid), s.MarkInvoicePaid)
+
+	// -------- Usage --------
+	admin.GET("/usage/summary", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.GetUsageSummary)
+	admin.GET("/usage/late_policy", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.GetUsageLatePolicy)
+	admin.PUT("/usage/late_policy", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.UpsertUsageLatePolicy)
+	admin.POST("/usage/:id/retract", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.authorizeOrgAction(authorization.ObjectUsage, authorization.ActionUsageCorrect), s.RetractUsage)
+	admin.POST("/usage/:id/amend", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.authorizeOrgAction(authorization.ObjectUsage, authorization.ActionUsageCorrect), s.AmendUsage)
+
+	// -------- Credit Notes --------
+	admin.GET("/credit-notes", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.ListCreditNotes)
+	admin.POST("/credit-notes", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.CreateCreditNote)
+	admin.GET("/credit-notes/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.GetCreditNoteByID)
+	admin.GET("/credit-notes/:id/render", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.RenderCreditNote)
+	admin.GET("/credit-notes/:id/pdf", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.DownloadCreditNotePDF)
+
+	// -------- Coupons & Discounts --------
+	admin.GET("/coupons", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.ListCoupons)
+	admin.POST("/coupons", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.CreateCoupon)
+	admin.GET("/coupons/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.GetCouponByID)
+	admin.POST("/coupons/:id/archive", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.ArchiveCoupon)
+	admin.GET("/promotion-codes", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.ListPromotionCodes)
+	admin.POST("/promotion-codes", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.CreatePromotionCode)
+	admin.GET("/discounts", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.ListDiscounts)
+	admin.POST("/discounts", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.ApplyDiscount)
+	admin.DELETE("/discounts/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.RemoveDiscount)
+
+	// -------- Webhooks --------
+	admin.GET("/webhook-endpoints", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.ListWebhookEndpoints)
+	admin.POST("/webhook-endpoints", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.CreateWebhookEndpoint)
+	admin.GET("/webhook-endpoints/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.GetWebhookEndpoint)
+	admin.PATCH("/webhook-endpoints/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.UpdateWebhookEndpoint)
+	admin.DELETE("/webhook-endpoints/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.DeleteWebhookEndpoint)
+	admin.POST("/webhook-endpoints/:id/rotate-secret", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.RotateWebhookSecret)
+	admin.GET("/webhook-deliveries", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.ListWebhookDeliveries)
+	admin.GET("/webhook-deliveries/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.GetWebhookDelivery)
+	admin.GET("/webhook-deliveries/:id/attempts", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.ListWebhookDeliveryAttempts)
+	admin.POST("/webhook-deliveries/:id/redeliver", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.RedeliverWebhook)
+
+	// -------- Dunning --------
+	admin.GET("/dunning/policy", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.GetDunningPolicy)
+	admin.PUT("/dunning/policy", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.UpsertDunningPolicy)
+	admin.GET("/dunning/cases", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.ListDunningCases)
+	admin.GET("/dunning/cases/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.GetDunningCase)
+
+	// -------- Alerts --------
+	admin.GET("/alerts", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.ListAlerts)
+	admin.POST("/alerts", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.CreateAlert)
+	admin.GET("/alerts/history", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.ListAlertHistory)
+	admin.GET("/alerts/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.GetAlert)
+	admin.PATCH("/alerts/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.UpdateAlert)
+	admin.DELETE("/alerts/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.DeleteAlert)
 
 	// -------- Billing Dashboard --------
 	admin.GET("/billing/customers", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.authorizeOrgAction(authorization.ObjectBillingDashboard, authorization.ActionBillingDashboardView), s.ListBillingCustomers)
@@ -561,6 +729,11 @@ func (s *Server) RegisterAdminRoutes() {
 	admin.GET("/customers", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.ListCustomers)
 	admin.POST("/customers", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.CreateCustomer)
 	admin.GET("/customers/:id", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.GetCustomerByID)
+	admin.GET("/customers/:id/upcoming_invoice", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.GetUpcomingInvoice)
+	admin.GET("/customers/:id/credit_grants", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.ListCreditGrants)
+	admin.POST("/customers/:id/credit_grants", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.CreateCreditGrant)
+	admin.GET("/customers/:id/credit_balance", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.GetCreditBalance)
+	admin.GET("/customers/:id/credit_transactions", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin, organizationdomain.RoleFinOps), s.ListCreditTransactions)
 
 	admin.GET("/audit-logs", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.authorizeOrgAction(authorization.ObjectAuditLog, authorization.ActionAuditLogView), s.ListAuditLogs)
 	admin.GET("/api-keys/scopes", s.RequireRole(organizationdomain.RoleOwner, organizationdomain.RoleAdmin), s.authorizeOrgAction(authorization.ObjectAPIKey, authorization.ActionAPIKeyView), s.ListAPIKeyScopes)
@@ -147,6 +147,13 @@ func (f *fakeOrgService) SetBillingPreferences(ctx context.Context, userID snowf
 	return nil
 }
 
+func (f *fakeOrgService) GetBillingPreferences(ctx context.Context, userID snowflake.ID, orgID string) (*orgdomain.BillingPreferencesResponse, error) {
+	_ = ctx
+	_ = userID
+	_ = orgID
+	return nil, nil
+}
+
 func TestSignupHandlerOSSModeReturns404(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -31,6 +31,8 @@ func (s *Server) CreateSubscription(c *gin.Context) {
 		CustomerID:       strings.TrimSpace(req.CustomerID),
 		CollectionMode:   req.CollectionMode,
 		BillingCycleType: strings.TrimSpace(req.BillingCycleType),
+		BillingAnchorDay: req.BillingAnchorDay,
+		DefaultCurrency:  strings.TrimSpace(req.DefaultCurrency),
 		Items:            normalizeSubscriptionItems(req.Items),
 		Metadata:         req.Metadata,
 	})
@@ -97,6 +99,160 @@ func (s *Server) ReplaceSubscriptionItems(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": resp})
 }
 
+// @Summary      Change Subscription Plan
+// @Description  Move a subscription to another product. Upgrades apply immediately and are prorated; downgrades are scheduled for the end of the current period.
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                                true  "Subscription ID"
+// @Param        request  body      subscriptiondomain.ChangePlanRequest  true  "Change Plan Request"
+// @Success      200  {object}  subscriptiondomain.SubscriptionPlanChange
+// @Router       /subscriptions/{id}/plan [post]
+func (s *Server) ChangeSubscriptionPlan(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, err := snowflake.ParseString(id); err != nil {
+		AbortWithError(c, newValidationError("id", "invalid_id", "invalid id"))
+		return
+	}
+
+	var req subscriptiondomain.ChangePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+	req.SubscriptionID = id
+
+	resp, err := s.subscriptionSvc.ChangePlan(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	if s.auditSvc != nil {
+		targetID := id
+		_ = s.auditSvc.AuditLog(c.Request.Context(), nil, "", nil, "subscription.plan.change", "subscription", &targetID, map[string]any{
+			"subscription_id":    id,
+			"plan_change_id":     resp.ID.String(),
+			"to_product_id":      resp.ToProductID.String(),
+			"direction":          string(resp.Direction),
+			"status":             string(resp.Status),
+			"proration_behavior": resp.ProrationBehavior,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      List Subscription Plan Changes
+// @Description  List applied, scheduled and canceled plan changes of a subscription
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Subscription ID"
+// @Success      200  {object}  []subscriptiondomain.SubscriptionPlanChange
+// @Router       /subscriptions/{id}/plan-changes [get]
+func (s *Server) ListSubscriptionPlanChanges(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, err := snowflake.ParseString(id); err != nil {
+		AbortWithError(c, newValidationError("id", "invalid_id", "invalid id"))
+		return
+	}
+
+	resp, err := s.subscriptionSvc.ListPlanChanges(c.Request.Context(), id)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Update Subscription Item Quantity
+// @Description  Change a licensed item's quantity from an effective time. The cycle is billed per quantity segment; future changes are scheduled.
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                                        true  "Subscription ID"
+// @Param        item_id  path      string                                        true  "Subscription Item ID"
+// @Param        request  body      subscriptiondomain.UpdateItemQuantityRequest  true  "Update Item Quantity Request"
+// @Success      200  {object}  subscriptiondomain.SubscriptionItemQuantityChange
+// @Router       /subscriptions/{id}/items/{item_id}/quantity [post]
+func (s *Server) UpdateSubscriptionItemQuantity(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, err := snowflake.ParseString(id); err != nil {
+		AbortWithError(c, newValidationError("id", "invalid_id", "invalid id"))
+		return
+	}
+	itemID := strings.TrimSpace(c.Param("item_id"))
+	if _, err := snowflake.ParseString(itemID); err != nil {
+		AbortWithError(c, newValidationError("item_id", "invalid_id", "invalid item id"))
+		return
+	}
+
+	var req subscriptiondomain.UpdateItemQuantityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+	req.SubscriptionID = id
+	req.ItemID = itemID
+
+	resp, err := s.subscriptionSvc.UpdateItemQuantity(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	if s.auditSvc != nil {
+		targetID := id
+		_ = s.auditSvc.AuditLog(c.Request.Context(), nil, "", nil, "subscription.item.quantity.update", "subscription", &targetID, map[string]any{
+			"subscription_id":      id,
+			"subscription_item_id": itemID,
+			"quantity_change_id":   resp.ID.String(),
+			"previous_quantity":    resp.PreviousQuantity,
+			"quantity":             resp.Quantity,
+			"effective_at":         resp.EffectiveAt,
+			"status":               string(resp.Status),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      List Subscription Item Quantity Changes
+// @Description  List the quantity history of a subscription item
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string  true  "Subscription ID"
+// @Param        item_id  path      string  true  "Subscription Item ID"
+// @Success      200  {object}  []subscriptiondomain.SubscriptionItemQuantityChange
+// @Router       /subscriptions/{id}/items/{item_id}/quantity-changes [get]
+func (s *Server) ListSubscriptionItemQuantityChanges(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, err := snowflake.ParseString(id); err != nil {
+		AbortWithError(c, newValidationError("id", "invalid_id", "invalid id"))
+		return
+	}
+	itemID := strings.TrimSpace(c.Param("item_id"))
+	if _, err := snowflake.ParseString(itemID); err != nil {
+		AbortWithError(c, newValidationError("item_id", "invalid_id", "invalid item id"))
+		return
+	}
+
+	resp, err := s.subscriptionSvc.ListItemQuantityChanges(c.Request.Context(), id, itemID)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
 // @Summary      List Subscriptions
 // @Description  List available subscriptions
 // @Tags         subscriptions
@@ -293,6 +449,8 @@ func isSubscriptionValidationError(err error) bool {
 	switch {
 	case errors.Is(err, subscriptiondomain.ErrInvalidOrganization),
 		errors.Is(err, subscriptiondomain.ErrInvalidCustomer),
+		errors.Is(err, subscriptiondomain.ErrInvalidCurrency),
+		errors.Is(err, subscriptiondomain.ErrCurrencyMismatch),
 		errors.Is(err, subscriptiondomain.ErrInvalidSubscription),
 		errors.Is(err, subscriptiondomain.ErrInvalidMeterID),
 		errors.Is(err, subscriptiondomain.ErrInvalidMeterCode),
@@ -306,6 +464,7 @@ func isSubscriptionValidationError(err error) bool {
 		errors.Is(err, subscriptiondomain.ErrInvoicesNotFinalized),
 		errors.Is(err, subscriptiondomain.ErrInvalidCollectionMode),
 		errors.Is(err, subscriptiondomain.ErrInvalidBillingCycleType),
+		errors.Is(err, subscriptiondomain.ErrInvalidBillingAnchorDay),
 		errors.Is(err, subscriptiondomain.ErrInvalidStartAt),
 		errors.Is(err, subscriptiondomain.ErrInvalidPeriod),
 		errors.Is(err, subscriptiondomain.ErrInvalidItems),
@@ -313,7 +472,21 @@ func isSubscriptionValidationError(err error) bool {
 		errors.Is(err, subscriptiondomain.ErrInvalidPrice),
 		errors.Is(err, subscriptiondomain.ErrInvalidProduct),
 		errors.Is(err, subscriptiondomain.ErrMultipleFlatPrices),
-		errors.Is(err, subscriptiondomain.ErrMissingEntitlements):
+		errors.Is(err, subscriptiondomain.ErrMissingEntitlements),
+		errors.Is(err, subscriptiondomain.ErrAmbiguousSubscription),
+		errors.Is(err, subscriptiondomain.ErrInvalidProrationBehavior),
+		errors.Is(err, subscriptiondomain.ErrPlanUnchanged),
+		errors.Is(err, subscriptiondomain.ErrInvalidSchedule),
+		errors.Is(err, subscriptiondomain.ErrInvalidSchedulePhases),
+		errors.Is(err, subscriptiondomain.ErrInvalidPhaseEnd),
+		errors.Is(err, subscriptiondomain.ErrInvalidEndBehavior),
+		errors.Is(err, subscriptiondomain.ErrInvalidCoupon),
+		errors.Is(err, subscriptiondomain.ErrScheduleAlreadyExists),
+		errors.Is(err, subscriptiondomain.ErrScheduleNotActive),
+		errors.Is(err, subscriptiondomain.ErrInvalidEffectiveAt),
+		errors.Is(err, subscriptiondomain.ErrQuantityUnchanged),
+		errors.Is(err, subscriptiondomain.ErrQuantityNotSupported),
+		errors.Is(err, subscriptiondomain.ErrInvalidSubscriptionStatus):
 		return true
 	default:
 		return false
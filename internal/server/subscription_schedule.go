@@ -0,0 +1,128 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/gin-gonic/gin"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+)
+
+// @Summary      Create Subscription Schedule
+// @Description  Put a subscription on a schedule of future-dated phases
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body      subscriptiondomain.CreateScheduleRequest  true  "Create Schedule Request"
+// @Success      200  {object}  subscriptiondomain.SubscriptionSchedule
+// @Router       /subscription-schedules [post]
+func (s *Server) CreateSubscriptionSchedule(c *gin.Context) {
+	var req subscriptiondomain.CreateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.subscriptionSvc.CreateSchedule(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	if s.auditSvc != nil {
+		targetID := resp.ID.String()
+		_ = s.auditSvc.AuditLog(c.Request.Context(), nil, "", nil, "subscription_schedule.create", "subscription_schedule", &targetID, map[string]any{
+			"schedule_id":     targetID,
+			"subscription_id": resp.SubscriptionID.String(),
+			"phases":          len(resp.Phases),
+			"end_behavior":    string(resp.EndBehavior),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Preview Subscription Schedule
+// @Description  Validate a schedule and show its phases without saving it
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body      subscriptiondomain.CreateScheduleRequest  true  "Create Schedule Request"
+// @Success      200  {object}  subscriptiondomain.SchedulePreview
+// @Router       /subscription-schedules/preview [post]
+func (s *Server) PreviewSubscriptionSchedule(c *gin.Context) {
+	var req subscriptiondomain.CreateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.subscriptionSvc.PreviewSchedule(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Get Subscription Schedule
+// @Description  Get a subscription schedule with its phases
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Schedule ID"
+// @Success      200  {object}  subscriptiondomain.SubscriptionSchedule
+// @Router       /subscription-schedules/{id} [get]
+func (s *Server) GetSubscriptionSchedule(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, err := snowflake.ParseString(id); err != nil {
+		AbortWithError(c, newValidationError("id", "invalid_id", "invalid id"))
+		return
+	}
+
+	resp, err := s.subscriptionSvc.GetSchedule(c.Request.Context(), id)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Cancel Subscription Schedule
+// @Description  Stop a schedule; the subscription stays on its current phase
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Schedule ID"
+// @Success      200  {object}  subscriptiondomain.SubscriptionSchedule
+// @Router       /subscription-schedules/{id}/cancel [post]
+func (s *Server) CancelSubscriptionSchedule(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, err := snowflake.ParseString(id); err != nil {
+		AbortWithError(c, newValidationError("id", "invalid_id", "invalid id"))
+		return
+	}
+
+	resp, err := s.subscriptionSvc.CancelSchedule(c.Request.Context(), id)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	if s.auditSvc != nil {
+		targetID := id
+		_ = s.auditSvc.AuditLog(c.Request.Context(), nil, "", nil, "subscription_schedule.cancel", "subscription_schedule", &targetID, map[string]any{
+			"schedule_id":     id,
+			"subscription_id": resp.SubscriptionID.String(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
@@ -1,6 +1,9 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"net/http"
 	"strings"
 
@@ -36,3 +39,223 @@ func (s *Server) IngestUsage(c *gin.Context) {
 
 	c.JSON(http.StatusOK, usage)
 }
+
+const usageBatchContextKey = "usage_batch"
+
+type usageBatchRequest struct {
+	Events []usagedomain.CreateIngestRequest `json:"events"`
+}
+
+// @Summary      Ingest Usage Batch
+// @Description  Ingest up to 1000 usage events at once, as {"events": [...]} or NDJSON (application/x-ndjson). Each event is deduplicated by its idempotency key and gets its own status.
+// @Tags         usage
+// @Accept       json
+// @Accept       x-ndjson
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body usageBatchRequest true "Usage events"
+// @Success      200  {object}  usagedomain.BatchIngestResponse
+// @Router       /usage/batch [post]
+func (s *Server) IngestUsageBatch(c *gin.Context) {
+	var events []usagedomain.CreateIngestRequest
+	if cached, ok := c.Get(usageBatchContextKey); ok {
+		events, _ = cached.([]usagedomain.CreateIngestRequest)
+	} else {
+		parsed, err := readUsageBatch(c)
+		if err != nil {
+			AbortWithError(c, err)
+			return
+		}
+		events = parsed
+	}
+
+	resp, err := s.usagesvc.IngestBatch(c.Request.Context(), events)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// readUsageBatch decodes a batch body, either a JSON object with an events
+// array or newline-delimited JSON with one event per line.
+func readUsageBatch(c *gin.Context) ([]usagedomain.CreateIngestRequest, error) {
+	if !strings.Contains(strings.ToLower(c.ContentType()), "ndjson") {
+		var req usageBatchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, invalidRequestError()
+		}
+		if len(req.Events) > usagedomain.MaxIngestBatchSize {
+			return nil, usagedomain.ErrBatchTooLarge
+		}
+		return req.Events, nil
+	}
+
+	events := make([]usagedomain.CreateIngestRequest, 0)
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if len(events) == usagedomain.MaxIngestBatchSize {
+			return nil, usagedomain.ErrBatchTooLarge
+		}
+		var event usagedomain.CreateIngestRequest
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, invalidRequestError()
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, invalidRequestError()
+	}
+	return events, nil
+}
+
+// @Summary      Retract Usage
+// @Description  Retract an accepted usage event. Events in a closed billing cycle produce an adjustment billed on the next invoice.
+// @Tags         usage
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path  string                          true  "Usage Event ID"
+// @Param        request  body  usagedomain.RetractUsageRequest  true  "Retract Usage Request"
+// @Success      200  {object}  usagedomain.CorrectionResponse
+// @Router       /usage/{id}/retract [post]
+func (s *Server) RetractUsage(c *gin.Context) {
+	var req usagedomain.RetractUsageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.usagesvc.Retract(c.Request.Context(), strings.TrimSpace(c.Param("id")), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary      Amend Usage
+// @Description  Replace the value of an accepted usage event. Events in a closed billing cycle produce an adjustment billed on the next invoice.
+// @Tags         usage
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path  string                        true  "Usage Event ID"
+// @Param        request  body  usagedomain.AmendUsageRequest  true  "Amend Usage Request"
+// @Success      200  {object}  usagedomain.CorrectionResponse
+// @Router       /usage/{id}/amend [post]
+func (s *Server) AmendUsage(c *gin.Context) {
+	var req usagedomain.AmendUsageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.usagesvc.Amend(c.Request.Context(), strings.TrimSpace(c.Param("id")), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary      Get Late Usage Policy
+// @Description  Return how usage arriving after its billing cycle closed is handled
+// @Tags         usage
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  usagedomain.LatePolicyResponse
+// @Router       /usage/late_policy [get]
+func (s *Server) GetUsageLatePolicy(c *gin.Context) {
+	resp, err := s.usagesvc.GetLatePolicy(c.Request.Context())
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Update Late Usage Policy
+// @Description  Choose whether late usage is rejected, carried forward into the open cycle or billed through an adjustment
+// @Tags         usage
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body      usagedomain.UpsertLatePolicyRequest  true  "Policy"
+// @Success      200      {object}  usagedomain.LatePolicyResponse
+// @Router       /usage/late_policy [put]
+func (s *Server) UpsertUsageLatePolicy(c *gin.Context) {
+	var req usagedomain.UpsertLatePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.usagesvc.UpsertLatePolicy(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Usage Summary
+// @Description  Aggregate a customer's enriched usage per meter and time bucket using each meter's aggregation
+// @Tags         usage
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        customer_id      query  string  true   "Customer ID"
+// @Param        subscription_id  query  string  false  "Subscription ID"
+// @Param        meter_code       query  string  false  "Meter codes, repeated or comma separated"
+// @Param        start            query  string  false  "Range start (RFC3339 or YYYY-MM-DD); defaults to the current cycle"
+// @Param        end              query  string  false  "Range end (RFC3339 or YYYY-MM-DD); defaults to the current cycle"
+// @Param        granularity      query  string  false  "hour, day, week or cycle"
+// @Param        group_by         query  string  false  "Metadata key to group by"
+// @Success      200  {object}  usagedomain.UsageSummaryResponse
+// @Router       /usage/summary [get]
+func (s *Server) GetUsageSummary(c *gin.Context) {
+	req := usagedomain.UsageSummaryRequest{
+		CustomerID:     strings.TrimSpace(c.Query("customer_id")),
+		SubscriptionID: strings.TrimSpace(c.Query("subscription_id")),
+		Granularity:    c.Query("granularity"),
+		GroupBy:        c.Query("group_by"),
+	}
+	for _, value := range c.QueryArray("meter_code") {
+		req.MeterCodes = append(req.MeterCodes, strings.Split(value, ",")...)
+	}
+
+	start, err := parseOptionalTime(c.Query("start"), false)
+	if err != nil {
+		AbortWithError(c, newValidationError("start", "invalid_time", "invalid start time"))
+		return
+	}
+	end, err := parseOptionalTime(c.Query("end"), true)
+	if err != nil {
+		AbortWithError(c, newValidationError("end", "invalid_time", "invalid end time"))
+		return
+	}
+	if start != nil {
+		req.Start = *start
+	}
+	if end != nil {
+		req.End = *end
+	}
+
+	resp, err := s.usagesvc.Summary(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
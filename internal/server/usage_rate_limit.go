@@ -20,6 +20,9 @@ const (
 	rateLimitReasonOrgRate                  = "org-rate"
 	rateLimitReasonEndpointRate             = "endpoint-rate"
 	rateLimitReasonCustomerMeterConcurrency = "customer-meter-concurrency"
+
+	// usageBatchEventsPerToken is how many batched usage events one rate-limit token covers.
+	usageBatchEventsPerToken = 100
 )
 
 type usageIngestRateLimitKey struct {
@@ -43,25 +46,7 @@ func (s *Server) UsageIngestRateLimit() gin.HandlerFunc {
 		endpoint := normalizeRateLimitEndpoint(c)
 		ctx := c.Request.Context()
 
-		allowedRes, err := s.usageLimiter.AllowOrg(ctx, orgID.String())
-		if err != nil {
-			logger.FromContext(ctx).Warn("usage ingest org rate limit check failed", zap.Error(err))
-			AbortWithError(c, ErrServiceUnavailable)
-			return
-		}
-		if !allowedRes.Allowed {
-			denyUsageIngestRateLimit(c, endpoint, orgID.String(), rateLimitReasonOrgRate, s.obsMetrics, allowedRes)
-			return
-		}
-
-		allowedRes, err = s.usageLimiter.AllowEndpoint(ctx, orgID.String())
-		if err != nil {
-			logger.FromContext(ctx).Warn("usage ingest endpoint rate limit check failed", zap.Error(err))
-			AbortWithError(c, ErrServiceUnavailable)
-			return
-		}
-		if !allowedRes.Allowed {
-			denyUsageIngestRateLimit(c, endpoint, orgID.String(), rateLimitReasonEndpointRate, s.obsMetrics, allowedRes)
+		if !s.allowUsageIngest(c, orgID.String(), endpoint, 1) {
 			return
 		}
 
@@ -96,6 +81,76 @@ func (s *Server) UsageIngestRateLimit() gin.HandlerFunc {
 	}
 }
 
+// UsageBatchIngestRateLimit charges a batch as one request scaled by its size:
+// every usageBatchEventsPerToken events cost one token. The per customer/meter
+// concurrency lock is skipped since a batch spans many pairs.
+func (s *Server) UsageBatchIngestRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.usageLimiter == nil || !s.usageLimiter.Enabled() {
+			c.Next()
+			return
+		}
+
+		orgID, ok := orgcontext.OrgIDFromContext(c.Request.Context())
+		if !ok || orgID == 0 {
+			AbortWithError(c, ErrOrgRequired)
+			return
+		}
+
+		events, err := readUsageBatch(c)
+		if err != nil {
+			AbortWithError(c, err)
+			return
+		}
+		c.Set(usageBatchContextKey, events)
+
+		endpoint := normalizeRateLimitEndpoint(c)
+		if !s.allowUsageIngest(c, orgID.String(), endpoint, usageBatchCost(len(events))) {
+			return
+		}
+
+		recordRateLimitAllowed(c.Request.Context(), endpoint, orgID.String(), s.obsMetrics)
+		c.Next()
+	}
+}
+
+// allowUsageIngest charges cost tokens against the org and endpoint buckets
+// and aborts the request when either is exhausted.
+func (s *Server) allowUsageIngest(c *gin.Context, orgID, endpoint string, cost int) bool {
+	ctx := c.Request.Context()
+
+	allowedRes, err := s.usageLimiter.AllowOrgN(ctx, orgID, cost)
+	if err != nil {
+		logger.FromContext(ctx).Warn("usage ingest org rate limit check failed", zap.Error(err))
+		AbortWithError(c, ErrServiceUnavailable)
+		return false
+	}
+	if !allowedRes.Allowed {
+		denyUsageIngestRateLimit(c, endpoint, orgID, rateLimitReasonOrgRate, s.obsMetrics, allowedRes)
+		return false
+	}
+
+	allowedRes, err = s.usageLimiter.AllowEndpointN(ctx, orgID, cost)
+	if err != nil {
+		logger.FromContext(ctx).Warn("usage ingest endpoint rate limit check failed", zap.Error(err))
+		AbortWithError(c, ErrServiceUnavailable)
+		return false
+	}
+	if !allowedRes.Allowed {
+		denyUsageIngestRateLimit(c, endpoint, orgID, rateLimitReasonEndpointRate, s.obsMetrics, allowedRes)
+		return false
+	}
+	return true
+}
+
+func usageBatchCost(events int) int {
+	cost := (events + usageBatchEventsPerToken - 1) / usageBatchEventsPerToken
+	if cost < 1 {
+		return 1
+	}
+	return cost
+}
+
 type usageLimitErrorResponse struct {
 	Error           string `json:"error"`
 	Resource        string `json:"resource"`
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	webhookdomain "github.com/smallbiznis/railzway/internal/webhook/domain"
+)
+
+// @Summary      Create Webhook Endpoint
+// @Description  Register a URL that receives signed billing events. The signing secret is only returned here and on rotation.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body      webhookdomain.CreateEndpointRequest  true  "Endpoint"
+// @Success      200      {object}  webhookdomain.EndpointResponse
+// @Router       /webhook-endpoints [post]
+func (s *Server) CreateWebhookEndpoint(c *gin.Context) {
+	var req webhookdomain.CreateEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.webhookSvc.CreateEndpoint(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      List Webhook Endpoints
+// @Description  List webhook endpoints
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  []webhookdomain.EndpointResponse
+// @Router       /webhook-endpoints [get]
+func (s *Server) ListWebhookEndpoints(c *gin.Context) {
+	resp, err := s.webhookSvc.ListEndpoints(c.Request.Context())
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Get Webhook Endpoint
+// @Description  Get webhook endpoint by ID
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Endpoint ID"
+// @Success      200  {object}  webhookdomain.EndpointResponse
+// @Router       /webhook-endpoints/{id} [get]
+func (s *Server) GetWebhookEndpoint(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+
+	resp, err := s.webhookSvc.GetEndpoint(c.Request.Context(), id)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Update Webhook Endpoint
+// @Description  Change the URL, description, event types or status of an endpoint
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                               true  "Endpoint ID"
+// @Param        request  body      webhookdomain.UpdateEndpointRequest  true  "Changes"
+// @Success      200      {object}  webhookdomain.EndpointResponse
+// @Router       /webhook-endpoints/{id} [patch]
+func (s *Server) UpdateWebhookEndpoint(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+
+	var req webhookdomain.UpdateEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.webhookSvc.UpdateEndpoint(c.Request.Context(), id, req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Rotate Webhook Secret
+// @Description  Replace the signing secret of an endpoint
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Endpoint ID"
+// @Success      200  {object}  webhookdomain.EndpointResponse
+// @Router       /webhook-endpoints/{id}/rotate-secret [post]
+func (s *Server) RotateWebhookSecret(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+
+	resp, err := s.webhookSvc.RotateSecret(c.Request.Context(), id)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Delete Webhook Endpoint
+// @Description  Delete an endpoint together with its delivery log
+// @Tags         webhooks
+// @Security     ApiKeyAuth
+// @Param        id   path  string  true  "Endpoint ID"
+// @Success      204
+// @Router       /webhook-endpoints/{id} [delete]
+func (s *Server) DeleteWebhookEndpoint(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+
+	if err := s.webhookSvc.DeleteEndpoint(c.Request.Context(), id); err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary      List Webhook Deliveries
+// @Description  List webhook deliveries, newest first
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        endpoint_id  query     string  false  "Endpoint ID"
+// @Param        event_id     query     string  false  "Billing event ID"
+// @Param        status       query     string  false  "pending, retrying, succeeded or dead"
+// @Param        limit        query     int     false  "Maximum number of deliveries"
+// @Success      200          {object}  []webhookdomain.DeliveryResponse
+// @Router       /webhook-deliveries [get]
+func (s *Server) ListWebhookDeliveries(c *gin.Context) {
+	var req webhookdomain.ListDeliveriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		AbortWithError(c, invalidRequestError())
+		return
+	}
+
+	resp, err := s.webhookSvc.ListDeliveries(c.Request.Context(), req)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Get Webhook Delivery
+// @Description  Get webhook delivery by ID
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Delivery ID"
+// @Success      200  {object}  webhookdomain.DeliveryResponse
+// @Router       /webhook-deliveries/{id} [get]
+func (s *Server) GetWebhookDelivery(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+
+	resp, err := s.webhookSvc.GetDelivery(c.Request.Context(), id)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      List Webhook Delivery Attempts
+// @Description  List the HTTP attempts made for a delivery
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Delivery ID"
+// @Success      200  {object}  []webhookdomain.DeliveryAttemptResponse
+// @Router       /webhook-deliveries/{id}/attempts [get]
+func (s *Server) ListWebhookDeliveryAttempts(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+
+	resp, err := s.webhookSvc.ListDeliveryAttempts(c.Request.Context(), id)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// @Summary      Redeliver Webhook
+// @Description  Send a delivery again immediately, including dead deliveries
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Delivery ID"
+// @Success      200  {object}  webhookdomain.DeliveryAttemptResponse
+// @Router       /webhook-deliveries/{id}/redeliver [post]
+func (s *Server) RedeliverWebhook(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+
+	resp, err := s.webhookSvc.Redeliver(c.Request.Context(), id)
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+func isWebhookValidationError(err error) bool {
+	switch err {
+	case webhookdomain.ErrInvalidOrganization,
+		webhookdomain.ErrInvalidID,
+		webhookdomain.ErrInvalidURL,
+		webhookdomain.ErrInvalidEventTypes,
+		webhookdomain.ErrInvalidStatus,
+		webhookdomain.ErrInvalidEndpointID,
+		webhookdomain.ErrInvalidEventID,
+		webhookdomain.ErrEndpointDisabled:
+		return true
+	default:
+		return false
+	}
+}
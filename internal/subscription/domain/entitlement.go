@@ -15,7 +15,11 @@ type SubscriptionEntitlement struct {
 	FeatureName    string
 	FeatureType    string
 	MeterID        *snowflake.ID
-	EffectiveFrom  time.Time
-	EffectiveTo    *time.Time
-	CreatedAt      time.Time
+	// UsageLimit caps metered usage per billing cycle; Enforcement is soft
+	// or hard. Both are copied from the product feature.
+	UsageLimit    *float64
+	Enforcement   *string
+	EffectiveFrom time.Time
+	EffectiveTo   *time.Time
+	CreatedAt     time.Time
 }
@@ -15,6 +15,7 @@ const (
 	SubscriptionStatusDraft    SubscriptionStatus = "DRAFT"
 	SubscriptionStatusActive   SubscriptionStatus = "ACTIVE"
 	SubscriptionStatusPaused   SubscriptionStatus = "PAUSED"
+	SubscriptionStatusPastDue  SubscriptionStatus = "PAST_DUE"
 	SubscriptionStatusCanceled SubscriptionStatus = "CANCELED"
 	SubscriptionStatusEnded    SubscriptionStatus = "ENDED"
 )
@@ -81,11 +82,12 @@ type SubscriptionItem struct {
 	PriceCode         *string           `gorm:"type:text"`
 	MeterID           *snowflake.ID     `gorm:"index"`
 	MeterCode         *string           `gorm:"type:text"`
-	Quantity          int8              `gorm:"column:quantity"`
+	Quantity          int32             `gorm:"column:quantity"`
 	BillingMode       string            `gorm:"type:text;not null"`
 	UsageBehavior     *string           `gorm:"type:text"`
 	BillingThreshold  *float64          `gorm:""`
 	ProrationBehavior *string           `gorm:"type:text"`
+	BillingTiming     *string           `gorm:"type:text"`
 	NextPeriodStart   *time.Time        `gorm:""`
 	NextPeriodEnd     *time.Time        `gorm:""`
 	Metadata          datatypes.JSONMap `gorm:"type:jsonb"`
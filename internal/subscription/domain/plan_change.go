@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/datatypes"
+)
+
+type PlanChangeDirection string
+
+const (
+	PlanChangeUpgrade   PlanChangeDirection = "UPGRADE"
+	PlanChangeDowngrade PlanChangeDirection = "DOWNGRADE"
+)
+
+type PlanChangeStatus string
+
+const (
+	PlanChangeStatusScheduled PlanChangeStatus = "SCHEDULED"
+	PlanChangeStatusApplied   PlanChangeStatus = "APPLIED"
+	PlanChangeStatusCanceled  PlanChangeStatus = "CANCELED"
+)
+
+// PlanChangeItem is the snapshot of a subscription item kept on a plan
+// change, so rating can bill the plan that was active before it.
+type PlanChangeItem struct {
+	PriceID       snowflake.ID  `json:"price_id"`
+	MeterID       *snowflake.ID `json:"meter_id,omitempty"`
+	Quantity      int32         `json:"quantity"`
+	BillingTiming *string       `json:"billing_timing,omitempty"`
+}
+
+// SubscriptionPlanChange records one change of a subscription's plan.
+//
+// Upgrades are applied right away; downgrades are scheduled for the end of
+// the current billing cycle. CreditAmount and ChargeAmount estimate the
+// proration at the time of the request; the billed amounts come from rating.
+type SubscriptionPlanChange struct {
+	ID                snowflake.ID        `gorm:"primaryKey" json:"id"`
+	OrgID             snowflake.ID        `gorm:"not null;index" json:"organization_id"`
+	SubscriptionID    snowflake.ID        `gorm:"not null;index" json:"subscription_id"`
+	FromProductID     *snowflake.ID       `json:"from_product_id,omitempty"`
+	ToProductID       snowflake.ID        `gorm:"not null" json:"to_product_id"`
+	ToPriceID         snowflake.ID        `gorm:"not null" json:"to_price_id"`
+	Quantity          int32               `gorm:"not null;default:1" json:"quantity"`
+	Direction         PlanChangeDirection `gorm:"type:text;not null" json:"direction"`
+	ProrationBehavior string              `gorm:"type:text;not null" json:"proration_behavior"`
+	Status            PlanChangeStatus    `gorm:"type:text;not null" json:"status"`
+	EffectiveAt       time.Time           `gorm:"not null" json:"effective_at"`
+	AppliedAt         *time.Time          `json:"applied_at,omitempty"`
+	CanceledAt        *time.Time          `json:"canceled_at,omitempty"`
+	PreviousItems     datatypes.JSON      `gorm:"type:jsonb;not null;default:'[]'" json:"previous_items"`
+	Items             datatypes.JSON      `gorm:"type:jsonb;not null;default:'[]'" json:"items"`
+	CreditAmount      int64               `gorm:"not null;default:0" json:"credit_amount"`
+	ChargeAmount      int64               `gorm:"not null;default:0" json:"charge_amount"`
+	Currency          *string             `gorm:"type:text" json:"currency,omitempty"`
+	CreatedAt         time.Time           `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt         time.Time           `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName sets the database table name.
+func (SubscriptionPlanChange) TableName() string { return "subscription_plan_changes" }
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+type QuantityChangeStatus string
+
+const (
+	QuantityChangeStatusScheduled QuantityChangeStatus = "SCHEDULED"
+	QuantityChangeStatusApplied   QuantityChangeStatus = "APPLIED"
+	QuantityChangeStatusCanceled  QuantityChangeStatus = "CANCELED"
+)
+
+// SubscriptionItemQuantityChange records one change of a licensed item's
+// quantity. Rating splits the item's period at EffectiveAt and bills each
+// part at the quantity in force for it.
+type SubscriptionItemQuantityChange struct {
+	ID                 snowflake.ID         `gorm:"primaryKey" json:"id"`
+	OrgID              snowflake.ID         `gorm:"not null;index" json:"organization_id"`
+	SubscriptionID     snowflake.ID         `gorm:"not null;index" json:"subscription_id"`
+	SubscriptionItemID snowflake.ID         `gorm:"not null;index" json:"subscription_item_id"`
+	PriceID            snowflake.ID         `gorm:"not null" json:"price_id"`
+	PreviousQuantity   int32                `gorm:"not null" json:"previous_quantity"`
+	Quantity           int32                `gorm:"not null" json:"quantity"`
+	Status             QuantityChangeStatus `gorm:"type:text;not null" json:"status"`
+	EffectiveAt        time.Time            `gorm:"not null" json:"effective_at"`
+	AppliedAt          *time.Time           `json:"applied_at,omitempty"`
+	CanceledAt         *time.Time           `json:"canceled_at,omitempty"`
+	CreatedAt          time.Time            `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt          time.Time            `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName sets the database table name.
+func (SubscriptionItemQuantityChange) TableName() string {
+	return "subscription_item_quantity_changes"
+}
@@ -0,0 +1,48 @@
+package domain
+
+import "github.com/bwmarrin/snowflake"
+
+// UsageRouteCandidate is one of a customer's active subscriptions considered
+// when routing a usage event. HasMeter reports whether the subscription has
+// an item for the event's meter.
+type UsageRouteCandidate struct {
+	Subscription Subscription
+	HasMeter     bool
+}
+
+// RouteUsage picks the subscription a usage event belongs to. Candidates must
+// be ordered most recent first.
+//
+// An explicit subscriptionID wins and must be one of the candidates.
+// Otherwise the single subscription with an item for the meter is used; when
+// several match the event is ambiguous and needs an explicit subscription.
+// When none match the most recent subscription is used, so entitlement gating
+// decides as it did for single-subscription customers.
+func RouteUsage(candidates []UsageRouteCandidate, subscriptionID snowflake.ID) (Subscription, error) {
+	if subscriptionID != 0 {
+		for _, candidate := range candidates {
+			if candidate.Subscription.ID == subscriptionID {
+				return candidate.Subscription, nil
+			}
+		}
+		return Subscription{}, ErrSubscriptionNotFound
+	}
+	if len(candidates) == 0 {
+		return Subscription{}, ErrSubscriptionNotFound
+	}
+
+	var matched []Subscription
+	for _, candidate := range candidates {
+		if candidate.HasMeter {
+			matched = append(matched, candidate.Subscription)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return candidates[0].Subscription, nil
+	case 1:
+		return matched[0], nil
+	default:
+		return Subscription{}, ErrAmbiguousSubscription
+	}
+}
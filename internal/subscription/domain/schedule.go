@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/datatypes"
+)
+
+type ScheduleStatus string
+
+const (
+	ScheduleStatusActive    ScheduleStatus = "ACTIVE"
+	ScheduleStatusCompleted ScheduleStatus = "COMPLETED"
+	ScheduleStatusCanceled  ScheduleStatus = "CANCELED"
+)
+
+// ScheduleEndBehavior decides what happens to the subscription once the last
+// phase ends: RELEASE keeps it running on the last phase's items, CANCEL
+// cancels it.
+type ScheduleEndBehavior string
+
+const (
+	ScheduleEndRelease ScheduleEndBehavior = "RELEASE"
+	ScheduleEndCancel  ScheduleEndBehavior = "CANCEL"
+)
+
+type SchedulePhaseStatus string
+
+const (
+	SchedulePhasePending   SchedulePhaseStatus = "PENDING"
+	SchedulePhaseActive    SchedulePhaseStatus = "ACTIVE"
+	SchedulePhaseCompleted SchedulePhaseStatus = "COMPLETED"
+	SchedulePhaseCanceled  SchedulePhaseStatus = "CANCELED"
+)
+
+// SubscriptionSchedule moves a subscription through ordered phases.
+// NextTransitionAt is the start of the next pending phase, or the end of the
+// last one; it is nil once nothing is left to do.
+type SubscriptionSchedule struct {
+	ID               snowflake.ID                `gorm:"primaryKey" json:"id"`
+	OrgID            snowflake.ID                `gorm:"not null;index" json:"organization_id"`
+	SubscriptionID   snowflake.ID                `gorm:"not null;index" json:"subscription_id"`
+	Status           ScheduleStatus              `gorm:"type:text;not null" json:"status"`
+	EndBehavior      ScheduleEndBehavior         `gorm:"type:text;not null" json:"end_behavior"`
+	NextTransitionAt *time.Time                  `json:"next_transition_at,omitempty"`
+	CompletedAt      *time.Time                  `json:"completed_at,omitempty"`
+	CanceledAt       *time.Time                  `json:"canceled_at,omitempty"`
+	CreatedAt        time.Time                   `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt        time.Time                   `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	Phases           []SubscriptionSchedulePhase `gorm:"-" json:"phases"`
+}
+
+// TableName sets the database table name.
+func (SubscriptionSchedule) TableName() string { return "subscription_schedules" }
+
+// SubscriptionSchedulePhase is one step of a schedule. A phase runs from
+// StartAt until EndAt, or indefinitely when it is the last phase and EndAt is
+// nil. Iterations keeps the number of billing cycles EndAt was derived from.
+// DiscountID is the discount created from CouponID while the phase is active.
+type SubscriptionSchedulePhase struct {
+	ID                snowflake.ID        `gorm:"primaryKey" json:"id"`
+	OrgID             snowflake.ID        `gorm:"not null;index" json:"organization_id"`
+	ScheduleID        snowflake.ID        `gorm:"not null;index" json:"schedule_id"`
+	Position          int16               `gorm:"type:smallint;not null" json:"position"`
+	Status            SchedulePhaseStatus `gorm:"type:text;not null" json:"status"`
+	StartAt           time.Time           `gorm:"not null" json:"start_at"`
+	EndAt             *time.Time          `json:"end_at,omitempty"`
+	Iterations        *int                `json:"iterations,omitempty"`
+	Items             datatypes.JSON      `gorm:"type:jsonb;not null;default:'[]'" json:"items"`
+	Trial             bool                `gorm:"not null;default:false" json:"trial"`
+	CouponID          *snowflake.ID       `json:"coupon_id,omitempty"`
+	DiscountID        *snowflake.ID       `json:"discount_id,omitempty"`
+	ProrationBehavior string              `gorm:"type:text;not null" json:"proration_behavior"`
+	AppliedAt         *time.Time          `json:"applied_at,omitempty"`
+	CompletedAt       *time.Time          `json:"completed_at,omitempty"`
+	CreatedAt         time.Time           `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt         time.Time           `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName sets the database table name.
+func (SubscriptionSchedulePhase) TableName() string { return "subscription_schedule_phases" }
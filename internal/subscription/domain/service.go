@@ -23,19 +23,26 @@ type ListSubscriptionResponse struct {
 	Subscriptions []Subscription `json:"subscriptions"`
 }
 
+// CreateSubscriptionItemRequest adds a price to a subscription. BillingTiming
+// overrides the price's billing timing for this item.
 type CreateSubscriptionItemRequest struct {
-	PriceID  string `json:"price_id"`
-	MeterID  string `json:"meter_id"`
-	Quantity int8   `json:"quantity,omitempty"`
+	PriceID       string `json:"price_id"`
+	MeterID       string `json:"meter_id"`
+	Quantity      int32  `json:"quantity,omitempty"`
+	BillingTiming string `json:"billing_timing,omitempty"`
 }
 
 type CreateSubscriptionRequest struct {
-	CustomerID       string                          `json:"customer_id"`
-	CollectionMode   SubscriptionCollectionMode      `json:"collection_mode"`
-	BillingCycleType string                          `json:"billing_cycle_type"`
-	Items            []CreateSubscriptionItemRequest `json:"items"`
-	TrialDays        *int                            `json:"trial_days,omitempty"`
-	Metadata         map[string]any                  `json:"metadata,omitempty"`
+	CustomerID       string                     `json:"customer_id"`
+	CollectionMode   SubscriptionCollectionMode `json:"collection_mode"`
+	BillingCycleType string                     `json:"billing_cycle_type"`
+	BillingAnchorDay *int16                     `json:"billing_anchor_day,omitempty"`
+	// DefaultCurrency locks the subscription to a currency. It defaults to
+	// the customer's and must match it when the customer has one.
+	DefaultCurrency string                          `json:"default_currency,omitempty"`
+	Items           []CreateSubscriptionItemRequest `json:"items"`
+	TrialDays       *int                            `json:"trial_days,omitempty"`
+	Metadata        map[string]any                  `json:"metadata,omitempty"`
 }
 
 type ReplaceSubscriptionItemsRequest struct {
@@ -43,8 +50,13 @@ type ReplaceSubscriptionItemsRequest struct {
 	Items          []CreateSubscriptionItemRequest `json:"items"`
 }
 
+// GetActiveByCustomerIDRequest resolves one of the customer's active
+// subscriptions. MeterCode routes to the subscription billing that meter;
+// SubscriptionID selects one explicitly. See RouteUsage.
 type GetActiveByCustomerIDRequest struct {
-	CustomerID string
+	CustomerID     string
+	MeterCode      string
+	SubscriptionID string
 }
 
 type GetSubscriptionItemRequest struct {
@@ -65,12 +77,80 @@ type Service interface {
 	GetSubscriptionItem(context.Context, GetSubscriptionItemRequest) (SubscriptionItem, error)
 	TransitionSubscription(ctx context.Context, subscriptionID string, targetStatus SubscriptionStatus, reason TransitionReason) error
 	ValidateUsageEntitlement(ctx context.Context, subscriptionID, meterID snowflake.ID, at time.Time) error
-	ChangePlan(ctx context.Context, req ChangePlanRequest) error
+	ChangePlan(ctx context.Context, req ChangePlanRequest) (SubscriptionPlanChange, error)
+	ListPlanChanges(ctx context.Context, subscriptionID string) ([]SubscriptionPlanChange, error)
+	ApplyScheduledPlanChanges(ctx context.Context, limit int) error
+	CreateSchedule(ctx context.Context, req CreateScheduleRequest) (SubscriptionSchedule, error)
+	PreviewSchedule(ctx context.Context, req CreateScheduleRequest) (SchedulePreview, error)
+	GetSchedule(ctx context.Context, id string) (SubscriptionSchedule, error)
+	CancelSchedule(ctx context.Context, id string) (SubscriptionSchedule, error)
+	AdvanceSchedules(ctx context.Context, limit int) error
+	UpdateItemQuantity(ctx context.Context, req UpdateItemQuantityRequest) (SubscriptionItemQuantityChange, error)
+	ListItemQuantityChanges(ctx context.Context, subscriptionID, itemID string) ([]SubscriptionItemQuantityChange, error)
+	ApplyScheduledQuantityChanges(ctx context.Context, limit int) error
 }
 
+// ChangePlanRequest moves a subscription to another product. PriceID picks one
+// of the product's prices instead of its default one. ProrationBehavior
+// overrides the behavior of the current items.
 type ChangePlanRequest struct {
-	SubscriptionID string
-	NewProductID   string
+	SubscriptionID    string `json:"-"`
+	NewProductID      string `json:"product_id"`
+	PriceID           string `json:"price_id,omitempty"`
+	Quantity          int32  `json:"quantity,omitempty"`
+	ProrationBehavior string `json:"proration_behavior,omitempty"`
+}
+
+// UpdateItemQuantityRequest sets a licensed item's quantity from EffectiveAt,
+// now when omitted. A time in the future is scheduled; a past one may not
+// reach back before the open billing cycle.
+type UpdateItemQuantityRequest struct {
+	SubscriptionID string     `json:"-"`
+	ItemID         string     `json:"-"`
+	Quantity       int32      `json:"quantity"`
+	EffectiveAt    *time.Time `json:"effective_at,omitempty"`
+}
+
+// CreateScheduleRequest puts an active subscription on a schedule. Phases run
+// back to back: only the first one takes a StartAt (now when omitted) and
+// every phase but the last needs an end, given as EndAt or as a number of
+// billing cycles in Iterations.
+type CreateScheduleRequest struct {
+	SubscriptionID string                 `json:"subscription_id"`
+	EndBehavior    string                 `json:"end_behavior,omitempty"`
+	Phases         []SchedulePhaseRequest `json:"phases"`
+}
+
+type SchedulePhaseRequest struct {
+	Items             []CreateSubscriptionItemRequest `json:"items"`
+	StartAt           *time.Time                      `json:"start_at,omitempty"`
+	EndAt             *time.Time                      `json:"end_at,omitempty"`
+	Iterations        *int                            `json:"iterations,omitempty"`
+	Trial             bool                            `json:"trial,omitempty"`
+	CouponID          string                          `json:"coupon_id,omitempty"`
+	ProrationBehavior string                          `json:"proration_behavior,omitempty"`
+}
+
+// SchedulePreview shows the phases a schedule would run and what each one
+// bills per cycle. RecurringAmount covers flat prices only; usage is billed on
+// top of it.
+type SchedulePreview struct {
+	SubscriptionID string                 `json:"subscription_id"`
+	EndBehavior    ScheduleEndBehavior    `json:"end_behavior"`
+	Phases         []SchedulePhasePreview `json:"phases"`
+}
+
+type SchedulePhasePreview struct {
+	Position          int16            `json:"position"`
+	StartAt           time.Time        `json:"start_at"`
+	EndAt             *time.Time       `json:"end_at,omitempty"`
+	Items             []PlanChangeItem `json:"items"`
+	Trial             bool             `json:"trial"`
+	CouponID          *string          `json:"coupon_id,omitempty"`
+	ProrationBehavior string           `json:"proration_behavior"`
+	RecurringAmount   int64            `json:"recurring_amount"`
+	DiscountAmount    int64            `json:"discount_amount"`
+	Currency          string           `json:"currency,omitempty"`
 }
 
 type CreateSubscriptionItemResponse struct {
@@ -79,11 +159,12 @@ type CreateSubscriptionItemResponse struct {
 	PriceCode         *string  `json:"price_code,omitempty"`
 	MeterID           *string  `json:"meter_id,omitempty"`
 	MeterCode         *string  `json:"meter_code,omitempty"`
-	Quantity          int8     `json:"quantity"`
+	Quantity          int32    `json:"quantity"`
 	BillingMode       string   `json:"billing_mode"`
 	UsageBehavior     *string  `json:"usage_behavior,omitempty"`
 	BillingThreshold  *float64 `json:"billing_threshold,omitempty"`
 	ProrationBehavior *string  `json:"proration_behavior,omitempty"`
+	BillingTiming     *string  `json:"billing_timing,omitempty"`
 }
 
 type CreateSubscriptionResponse struct {
@@ -93,6 +174,7 @@ type CreateSubscriptionResponse struct {
 	Status         SubscriptionStatus               `json:"status"`
 	CollectionMode SubscriptionCollectionMode       `json:"collection_mode"`
 	StartAt        time.Time                        `json:"start_at"`
+	Currency       string                           `json:"currency,omitempty"`
 	Items          []CreateSubscriptionItemResponse `json:"items"`
 	Metadata       map[string]any                   `json:"metadata,omitempty"`
 }
@@ -116,6 +198,7 @@ var (
 	ErrInvoicesNotFinalized      = errors.New("invoices_not_finalized")
 	ErrInvalidCollectionMode     = errors.New("invalid_collection_mode")
 	ErrInvalidBillingCycleType   = errors.New("invalid_billing_cycle_type")
+	ErrInvalidBillingAnchorDay   = errors.New("invalid_billing_anchor_day")
 	ErrInvalidStartAt            = errors.New("invalid_start_at")
 	ErrInvalidPeriod             = errors.New("invalid_period")
 	ErrInvalidItems              = errors.New("invalid_items")
@@ -127,4 +210,20 @@ var (
 	ErrSubscriptionItemNotFound  = errors.New("subscription_item_not_found")
 	ErrFeatureNotEntitled        = errors.New("feature_not_entitled")
 	ErrInvalidSubscriptionStatus = errors.New("invalid_subscription_status")
+	ErrAmbiguousSubscription     = errors.New("ambiguous_subscription")
+	ErrInvalidProrationBehavior  = errors.New("invalid_proration_behavior")
+	ErrPlanUnchanged             = errors.New("plan_unchanged")
+	ErrInvalidSchedule           = errors.New("invalid_schedule")
+	ErrInvalidSchedulePhases     = errors.New("invalid_schedule_phases")
+	ErrInvalidPhaseEnd           = errors.New("invalid_phase_end")
+	ErrInvalidEndBehavior        = errors.New("invalid_end_behavior")
+	ErrInvalidCoupon             = errors.New("invalid_coupon")
+	ErrScheduleAlreadyExists     = errors.New("schedule_already_exists")
+	ErrScheduleNotActive         = errors.New("schedule_not_active")
+	ErrScheduleNotFound          = errors.New("schedule_not_found")
+	ErrInvalidEffectiveAt        = errors.New("invalid_effective_at")
+	ErrQuantityUnchanged         = errors.New("quantity_unchanged")
+	ErrQuantityNotSupported      = errors.New("quantity_not_supported")
+	ErrInvalidCurrency           = errors.New("invalid_currency")
+	ErrCurrencyMismatch          = errors.New("currency_mismatch")
 )
@@ -57,9 +57,9 @@ func (r *repo) InsertItems(ctx context.Context, db *gorm.DB, items []subscriptio
 		if err := db.WithContext(ctx).Exec(
 			`INSERT INTO subscription_items (
 				id, org_id, subscription_id, price_id, price_code, meter_id, meter_code, quantity,
-				billing_mode, usage_behavior, billing_threshold, proration_behavior, next_period_start,
-				next_period_end, metadata, created_at, updated_at
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				billing_mode, usage_behavior, billing_threshold, proration_behavior, billing_timing,
+				next_period_start, next_period_end, metadata, created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			item.ID,
 			item.OrgID,
 			item.SubscriptionID,
@@ -72,6 +72,7 @@ func (r *repo) InsertItems(ctx context.Context, db *gorm.DB, items []subscriptio
 			item.UsageBehavior,
 			item.BillingThreshold,
 			item.ProrationBehavior,
+			item.BillingTiming,
 			item.NextPeriodStart,
 			item.NextPeriodEnd,
 			item.Metadata,
@@ -105,14 +106,16 @@ func (r *repo) InsertEntitlements(ctx context.Context, db *gorm.DB, entitlements
 		if err := db.WithContext(ctx).Exec(
 			`INSERT INTO subscription_entitlements (
 				id, subscription_id, feature_code, feature_name, feature_type, meter_id,
-				effective_from, effective_to, created_at
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				usage_limit, enforcement, effective_from, effective_to, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			item.ID,
 			item.SubscriptionID,
 			item.FeatureCode,
 			item.FeatureName,
 			item.FeatureType,
 			item.MeterID,
+			item.UsageLimit,
+			item.Enforcement,
 			item.EffectiveFrom,
 			item.EffectiveTo,
 			item.CreatedAt,
@@ -180,8 +183,8 @@ func (r *repo) List(ctx context.Context, db *gorm.DB, orgID snowflake.ID) ([]sub
 	return subscriptions, nil
 }
 
-func (r *repo) FindActiveByCustomerID(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, statuses []subscriptiondomain.SubscriptionStatus) (*subscriptiondomain.Subscription, error) {
-	var subscription subscriptiondomain.Subscription
+func (r *repo) ListActiveByCustomerID(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, statuses []subscriptiondomain.SubscriptionStatus) ([]subscriptiondomain.Subscription, error) {
+	var subscriptions []subscriptiondomain.Subscription
 	err := db.WithContext(ctx).Raw(
 		`SELECT id, org_id, customer_id, status, collection_mode, start_at, end_at, cancel_at,
 		 cancel_at_period_end, canceled_at, activated_at, paused_at, resumed_at, ended_at,
@@ -189,23 +192,19 @@ func (r *repo) FindActiveByCustomerID(ctx context.Context, db *gorm.DB, orgID, c
 		 default_tax_behavior, metadata, created_at, updated_at
 		 FROM subscriptions
 		 WHERE org_id = ? AND customer_id = ? AND status IN ?
-		 ORDER BY created_at DESC
-		 LIMIT 1`,
+		 ORDER BY created_at DESC, id DESC`,
 		orgID,
 		customerID,
 		statuses,
-	).Scan(&subscription).Error
+	).Scan(&subscriptions).Error
 	if err != nil {
 		return nil, err
 	}
-	if subscription.ID == 0 {
-		return nil, nil
-	}
-	return &subscription, nil
+	return subscriptions, nil
 }
 
-func (r *repo) FindActiveByCustomerIDAt(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, at time.Time) (*subscriptiondomain.Subscription, error) {
-	var subscription subscriptiondomain.Subscription
+func (r *repo) ListActiveByCustomerIDAt(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, at time.Time) ([]subscriptiondomain.Subscription, error) {
+	var subscriptions []subscriptiondomain.Subscription
 	err := db.WithContext(ctx).Raw(
 		`SELECT id, org_id, customer_id, status, collection_mode, start_at, end_at, cancel_at,
 		 cancel_at_period_end, canceled_at, activated_at, paused_at, resumed_at, ended_at,
@@ -220,8 +219,7 @@ func (r *repo) FindActiveByCustomerIDAt(ctx context.Context, db *gorm.DB, orgID,
 		   AND (canceled_at IS NULL OR canceled_at > ?)
 		   AND (ended_at IS NULL OR ended_at > ?)
 		   AND NOT (paused_at IS NOT NULL AND paused_at <= ? AND (resumed_at IS NULL OR resumed_at > ?))
-		 ORDER BY start_at DESC, created_at DESC
-		 LIMIT 1`,
+		 ORDER BY start_at DESC, created_at DESC, id DESC`,
 		orgID,
 		customerID,
 		subscriptiondomain.SubscriptionStatusDraft,
@@ -232,22 +230,19 @@ func (r *repo) FindActiveByCustomerIDAt(ctx context.Context, db *gorm.DB, orgID,
 		at,
 		at,
 		at,
-	).Scan(&subscription).Error
+	).Scan(&subscriptions).Error
 	if err != nil {
 		return nil, err
 	}
-	if subscription.ID == 0 {
-		return nil, nil
-	}
-	return &subscription, nil
+	return subscriptions, nil
 }
 
 func (r *repo) FindSubscriptionItemByMeterCode(ctx context.Context, db *gorm.DB, orgID, subscriptionID snowflake.ID, meterCode string) (*subscriptiondomain.SubscriptionItem, error) {
 	var item subscriptiondomain.SubscriptionItem
 	err := db.WithContext(ctx).Raw(
 		`SELECT id, org_id, subscription_id, price_id, price_code, meter_id, meter_code, quantity,
-		 billing_mode, usage_behavior, billing_threshold, proration_behavior, next_period_start,
-		 next_period_end, metadata, created_at, updated_at
+		 billing_mode, usage_behavior, billing_threshold, proration_behavior, billing_timing,
+		 next_period_start, next_period_end, metadata, created_at, updated_at
 		 FROM subscription_items
 		 WHERE org_id = ? AND subscription_id = ? AND meter_code = ?
 		 LIMIT 1`,
@@ -268,8 +263,8 @@ func (r *repo) FindSubscriptionItemByMeterID(ctx context.Context, db *gorm.DB, o
 	var item subscriptiondomain.SubscriptionItem
 	err := db.WithContext(ctx).Raw(
 		`SELECT id, org_id, subscription_id, price_id, price_code, meter_id, meter_code, quantity,
-		 billing_mode, usage_behavior, billing_threshold, proration_behavior, next_period_start,
-		 next_period_end, metadata, created_at, updated_at
+		 billing_mode, usage_behavior, billing_threshold, proration_behavior, billing_timing,
+		 next_period_start, next_period_end, metadata, created_at, updated_at
 		 FROM subscription_items
 		 WHERE org_id = ? AND subscription_id = ? AND meter_id = ?
 		 LIMIT 1`,
@@ -290,8 +285,8 @@ func (r *repo) FindSubscriptionItemByMeterIDAt(ctx context.Context, db *gorm.DB,
 	var item subscriptiondomain.SubscriptionItem
 	err := db.WithContext(ctx).Raw(
 		`SELECT id, org_id, subscription_id, price_id, price_code, meter_id, meter_code, quantity,
-		 billing_mode, usage_behavior, billing_threshold, proration_behavior, next_period_start,
-		 next_period_end, metadata, created_at, updated_at
+		 billing_mode, usage_behavior, billing_threshold, proration_behavior, billing_timing,
+		 next_period_start, next_period_end, metadata, created_at, updated_at
 		 FROM subscription_items
 		 WHERE org_id = ? AND subscription_id = ? AND meter_id = ?
 		   AND (next_period_start IS NULL OR next_period_start <= ?)
@@ -317,7 +312,7 @@ func (r *repo) FindEntitlement(ctx context.Context, db *gorm.DB, subscriptionID
 	var entitlement subscriptiondomain.SubscriptionEntitlement
 	err := db.WithContext(ctx).Raw(
 		`SELECT id, subscription_id, feature_code, feature_name, feature_type, meter_id,
-		 effective_from, effective_to, created_at
+		 usage_limit, enforcement, effective_from, effective_to, created_at
 		 FROM subscription_entitlements
 		 WHERE subscription_id = ? AND meter_id = ?
 		   AND effective_from <= ?
@@ -7,6 +7,7 @@ import (
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/glebarez/sqlite"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
 	"github.com/smallbiznis/railzway/internal/orgcontext"
 	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
 	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
@@ -55,7 +56,7 @@ func (m *mockProductFeatureRepo) ListByProducts(ctx context.Context, db *gorm.DB
 	}
 	return result, nil
 }
-func (m *mockProductFeatureRepo) Replace(ctx context.Context, tx *gorm.DB, productID snowflake.ID, featureIDs []snowflake.ID, now time.Time) error {
+func (m *mockProductFeatureRepo) Replace(ctx context.Context, tx *gorm.DB, productID snowflake.ID, featureIDs []snowflake.ID, limits map[snowflake.ID]productfeaturedomain.Limit, now time.Time) error {
 	return nil
 }
 
@@ -98,10 +99,10 @@ func (m *mockRepository) FindByIDForUpdate(ctx context.Context, db *gorm.DB, org
 func (m *mockRepository) List(ctx context.Context, db *gorm.DB, orgID snowflake.ID) ([]subscriptiondomain.Subscription, error) {
 	return nil, nil
 }
-func (m *mockRepository) FindActiveByCustomerID(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, statuses []subscriptiondomain.SubscriptionStatus) (*subscriptiondomain.Subscription, error) {
+func (m *mockRepository) ListActiveByCustomerID(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, statuses []subscriptiondomain.SubscriptionStatus) ([]subscriptiondomain.Subscription, error) {
 	return nil, nil
 }
-func (m *mockRepository) FindActiveByCustomerIDAt(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, at time.Time) (*subscriptiondomain.Subscription, error) {
+func (m *mockRepository) ListActiveByCustomerIDAt(ctx context.Context, db *gorm.DB, orgID, customerID snowflake.ID, at time.Time) ([]subscriptiondomain.Subscription, error) {
 	return nil, nil
 }
 func (m *mockRepository) FindSubscriptionItemByMeterCode(ctx context.Context, db *gorm.DB, orgID, subscriptionID snowflake.ID, meterCode string) (*subscriptiondomain.SubscriptionItem, error) {
@@ -127,6 +128,11 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		&subscriptiondomain.Subscription{},
 		&subscriptiondomain.SubscriptionItem{},
 		&subscriptiondomain.SubscriptionEntitlement{},
+		&subscriptiondomain.SubscriptionPlanChange{},
+		&subscriptiondomain.SubscriptionItemQuantityChange{},
+		&subscriptiondomain.SubscriptionSchedule{},
+		&subscriptiondomain.SubscriptionSchedulePhase{},
+		&billingcycledomain.BillingCycle{},
 	)
 	if err != nil {
 		t.Fatalf("failed to migrate database: %v", err)
@@ -236,7 +242,7 @@ func TestChangePlan(t *testing.T) {
 		NewProductID:   newProductID.String(),
 	}
 
-	err := svc.ChangePlan(ctx, req)
+	_, err := svc.ChangePlan(ctx, req)
 	if err != nil {
 		t.Fatalf("ChangePlan failed: %v", err)
 	}
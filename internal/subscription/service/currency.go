@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/money"
+	priceamount "github.com/smallbiznis/railzway/internal/priceamount/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+)
+
+// resolveSubscriptionCurrency picks the single currency a new subscription
+// bills in: the requested one, else the customer's, else the only currency
+// the first price is offered in. It returns "" when none can be told.
+func (s *Service) resolveSubscriptionCurrency(
+	ctx context.Context,
+	orgID, customerID snowflake.ID,
+	requested string,
+	items []subscriptiondomain.CreateSubscriptionItemRequest,
+) (string, error) {
+	if strings.TrimSpace(requested) != "" {
+		code, err := money.NormalizeCurrency(requested)
+		if err != nil {
+			return "", subscriptiondomain.ErrInvalidCurrency
+		}
+		requested = code
+	}
+
+	customer, err := s.customerCurrency(ctx, orgID, customerID)
+	if err != nil {
+		return "", err
+	}
+	if requested != "" && customer != "" && requested != customer {
+		return "", subscriptiondomain.ErrCurrencyMismatch
+	}
+	if requested != "" {
+		return requested, nil
+	}
+	if customer != "" {
+		return customer, nil
+	}
+
+	if len(items) == 0 {
+		return "", nil
+	}
+	amounts, err := s.loadPriceAmount(ctx, strings.TrimSpace(items[0].PriceID), "")
+	if err != nil {
+		return "", err
+	}
+	return singleCurrency(amounts)
+}
+
+// singleCurrency returns the one currency amounts share. A price offered in
+// several currencies needs the caller to choose.
+func singleCurrency(amounts []priceamount.Response) (string, error) {
+	currency := ""
+	for _, amount := range amounts {
+		code := strings.ToUpper(strings.TrimSpace(amount.Currency))
+		if code == "" {
+			continue
+		}
+		if currency != "" && currency != code {
+			return "", subscriptiondomain.ErrInvalidCurrency
+		}
+		currency = code
+	}
+	return currency, nil
+}
+
+func (s *Service) customerCurrency(ctx context.Context, orgID, customerID snowflake.ID) (string, error) {
+	var currency *string
+	if err := s.db.WithContext(ctx).Raw(
+		`SELECT currency FROM customers WHERE org_id = ? AND id = ?`,
+		orgID,
+		customerID,
+	).Scan(&currency).Error; err != nil {
+		return "", err
+	}
+	if currency == nil || strings.TrimSpace(*currency) == "" {
+		return "", nil
+	}
+	code, err := money.NormalizeCurrency(*currency)
+	if err != nil {
+		return "", nil
+	}
+	return code, nil
+}
+
+// subscriptionCurrency is the currency a subscription is locked to, or ""
+// for subscriptions created before currencies were locked.
+func subscriptionCurrency(sub *subscriptiondomain.Subscription) string {
+	if sub == nil || sub.DefaultCurrency == nil {
+		return ""
+	}
+	return strings.ToUpper(strings.TrimSpace(*sub.DefaultCurrency))
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/clock"
+	customerdomain "github.com/smallbiznis/railzway/internal/customer/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// currencyPriceAmounts serves a price's amounts, filtered by currency the way
+// the price amount service filters them.
+type currencyPriceAmounts struct {
+	mockPriceAmountService
+	amounts map[string][]priceamountdomain.Response
+}
+
+func (m *currencyPriceAmounts) List(ctx context.Context, req priceamountdomain.ListPriceAmountRequest) ([]priceamountdomain.Response, error) {
+	var out []priceamountdomain.Response
+	for _, amount := range m.amounts[req.PriceID] {
+		if req.Currency == "" || amount.Currency == req.Currency {
+			out = append(out, amount)
+		}
+	}
+	return out, nil
+}
+
+func TestCreateSubscriptionCurrency(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&customerdomain.Customer{}))
+	node, _ := snowflake.NewNode(1)
+	orgID := node.Generate()
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	usdOnly, multi := node.Generate(), node.Generate()
+	priceSvc := &mockPriceService{}
+	for _, priceID := range []snowflake.ID{usdOnly, multi} {
+		priceSvc.prices = append(priceSvc.prices, pricedomain.Response{
+			ID:              priceID,
+			OrganizationID:  orgID,
+			ProductID:       node.Generate(),
+			BillingInterval: pricedomain.Month,
+			Active:          true,
+			PricingModel:    pricedomain.Flat,
+			BillingMode:     pricedomain.Licensed,
+		})
+	}
+	amounts := &currencyPriceAmounts{amounts: map[string][]priceamountdomain.Response{
+		usdOnly.String(): {{UnitAmountCents: 1000, Currency: "USD"}},
+		multi.String():   {{UnitAmountCents: 1000, Currency: "USD"}, {UnitAmountCents: 900, Currency: "EUR"}},
+	}}
+	svc := NewService(ServiceParam{
+		DB:             db,
+		Log:            zap.NewNop(),
+		GenID:          node,
+		Clock:          clock.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Repo:           &mockRepository{subscriptions: make(map[string]*subscriptiondomain.Subscription)},
+		Pricesvc:       priceSvc,
+		PriceAmountsvc: amounts,
+	})
+
+	customer := func(currency string) string {
+		id := node.Generate()
+		require.NoError(t, db.Create(&customerdomain.Customer{
+			ID: id, OrgID: orgID, Name: "Acme", Email: "billing@acme.test", Currency: currency,
+		}).Error)
+		return id.String()
+	}
+	create := func(customerID, currency string, priceID snowflake.ID) error {
+		_, err := svc.Create(ctx, subscriptiondomain.CreateSubscriptionRequest{
+			CustomerID:       customerID,
+			BillingCycleType: "MONTHLY",
+			CollectionMode:   subscriptiondomain.SendInvoice,
+			DefaultCurrency:  currency,
+			Items:            []subscriptiondomain.CreateSubscriptionItemRequest{{PriceID: priceID.String(), Quantity: 1}},
+		})
+		return err
+	}
+
+	t.Run("requested currency must match the customer", func(t *testing.T) {
+		err := create(customer("EUR"), "USD", multi)
+		assert.ErrorIs(t, err, subscriptiondomain.ErrCurrencyMismatch)
+	})
+
+	t.Run("price must have an amount in the customer currency", func(t *testing.T) {
+		err := create(customer("EUR"), "", usdOnly)
+		assert.ErrorIs(t, err, subscriptiondomain.ErrCurrencyMismatch)
+	})
+
+	t.Run("price must have an amount in the requested currency", func(t *testing.T) {
+		err := create(customer(""), "eur", usdOnly)
+		assert.ErrorIs(t, err, subscriptiondomain.ErrCurrencyMismatch)
+	})
+
+	t.Run("invalid currency", func(t *testing.T) {
+		err := create(customer(""), "EURO", usdOnly)
+		assert.ErrorIs(t, err, subscriptiondomain.ErrInvalidCurrency)
+	})
+
+	t.Run("ambiguous price currency needs a choice", func(t *testing.T) {
+		err := create(customer(""), "", multi)
+		assert.ErrorIs(t, err, subscriptiondomain.ErrInvalidCurrency)
+	})
+
+	t.Run("resolution order", func(t *testing.T) {
+		impl := svc.(*Service)
+		items := []subscriptiondomain.CreateSubscriptionItemRequest{{PriceID: usdOnly.String()}}
+
+		currency, err := impl.resolveSubscriptionCurrency(ctx, orgID, snowflake.ParseInt64(0), "", items)
+		require.NoError(t, err)
+		assert.Equal(t, "USD", currency)
+
+		eurCustomer, _ := snowflake.ParseString(customer("eur"))
+		currency, err = impl.resolveSubscriptionCurrency(ctx, orgID, eurCustomer, "", items)
+		require.NoError(t, err)
+		assert.Equal(t, "EUR", currency)
+	})
+}
@@ -0,0 +1,608 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	"github.com/smallbiznis/railzway/internal/money"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ChangePlan moves a subscription to another product.
+//
+// A change that lowers the recurring amount is a downgrade and is scheduled
+// for the end of the open billing cycle. Any other change is applied right
+// away; rating then credits the unused part of the old flat price and charges
+// the rest of the new one, unless the proration behavior is NONE.
+// INVOICE_IMMEDIATELY also ends the open cycle at the change so it is
+// invoiced now instead of at period end.
+func (s *Service) ChangePlan(ctx context.Context, req subscriptiondomain.ChangePlanRequest) (subscriptiondomain.SubscriptionPlanChange, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return subscriptiondomain.SubscriptionPlanChange{}, subscriptiondomain.ErrInvalidOrganization
+	}
+	subscriptionID, err := snowflake.ParseString(strings.TrimSpace(req.SubscriptionID))
+	if err != nil {
+		return subscriptiondomain.SubscriptionPlanChange{}, subscriptiondomain.ErrInvalidSubscription
+	}
+	newProductID, err := snowflake.ParseString(strings.TrimSpace(req.NewProductID))
+	if err != nil {
+		return subscriptiondomain.SubscriptionPlanChange{}, subscriptiondomain.ErrInvalidProduct
+	}
+	if req.Quantity < 0 {
+		return subscriptiondomain.SubscriptionPlanChange{}, subscriptiondomain.ErrInvalidQuantity
+	}
+	behavior, err := parseProrationBehavior(req.ProrationBehavior)
+	if err != nil {
+		return subscriptiondomain.SubscriptionPlanChange{}, err
+	}
+
+	now := s.clock.Now().UTC()
+
+	var change subscriptiondomain.SubscriptionPlanChange
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sub, err := s.repo.FindByIDForUpdate(ctx, tx, orgID, subscriptionID)
+		if err != nil {
+			return err
+		}
+		if sub == nil {
+			return subscriptiondomain.ErrSubscriptionNotFound
+		}
+		if sub.Status != subscriptiondomain.SubscriptionStatusActive {
+			return subscriptiondomain.ErrInvalidSubscriptionStatus
+		}
+
+		newPrice, err := s.resolvePlanPrice(ctx, orgID, newProductID, req.PriceID)
+		if err != nil {
+			return err
+		}
+		cycleType, err := billingCycleTypeForInterval(newPrice.BillingInterval, newPrice.BillingIntervalCount)
+		if err != nil {
+			return err
+		}
+		if err := ensureSameCycle(sub, cycleType); err != nil {
+			return err
+		}
+
+		currentItems, err := s.listItemsForUpdate(ctx, tx, orgID, subscriptionID)
+		if err != nil {
+			return err
+		}
+		quantity := normalizeSubscriptionQuantity(req.Quantity)
+		if len(currentItems) == 1 && currentItems[0].PriceID == newPrice.ID && currentItems[0].Quantity == quantity {
+			return subscriptiondomain.ErrPlanUnchanged
+		}
+		if behavior == "" {
+			behavior = currentProrationBehavior(currentItems)
+		}
+
+		newItems, productIDs, err := s.buildPlanItems(ctx, orgID, subscriptionID, newPrice, quantity, behavior, cycleType, subscriptionCurrency(sub), now)
+		if err != nil {
+			return err
+		}
+
+		previous := planChangeItems(currentItems)
+		next := planChangeItems(newItems)
+		currentAmount, currency, err := s.recurringAmount(ctx, previous, subscriptionCurrency(sub))
+		if err != nil {
+			return err
+		}
+		newAmount, newCurrency, err := s.recurringAmount(ctx, next, subscriptionCurrency(sub))
+		if err != nil {
+			return err
+		}
+		if currency == "" {
+			currency = newCurrency
+		}
+
+		change = subscriptiondomain.SubscriptionPlanChange{
+			ID:                s.genID.Generate(),
+			OrgID:             orgID,
+			SubscriptionID:    subscriptionID,
+			FromProductID:     s.currentProductID(ctx, currentItems),
+			ToProductID:       newProductID,
+			ToPriceID:         newPrice.ID,
+			Quantity:          quantity,
+			Direction:         subscriptiondomain.PlanChangeUpgrade,
+			ProrationBehavior: behavior,
+			EffectiveAt:       now,
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		}
+		if currency != "" {
+			change.Currency = &currency
+		}
+		if change.PreviousItems, err = json.Marshal(previous); err != nil {
+			return err
+		}
+		if newAmount < currentAmount {
+			change.Direction = subscriptiondomain.PlanChangeDowngrade
+		}
+
+		// A newer request replaces any change still waiting for period end.
+		if err := s.cancelScheduledPlanChanges(ctx, tx, orgID, subscriptionID, now); err != nil {
+			return err
+		}
+
+		cycle, err := s.findOpenCycle(ctx, tx, orgID, subscriptionID)
+		if err != nil {
+			return err
+		}
+
+		if change.Direction == subscriptiondomain.PlanChangeDowngrade && cycle != nil && cycle.PeriodEnd.After(now) {
+			change.Status = subscriptiondomain.PlanChangeStatusScheduled
+			change.EffectiveAt = cycle.PeriodEnd
+			if change.Items, err = json.Marshal(next); err != nil {
+				return err
+			}
+			return s.insertPlanChange(ctx, tx, &change)
+		}
+
+		return s.applyPlanChangeAt(ctx, tx, &change, cycle, newItems, productIDs, currentAmount, newAmount, now)
+	})
+	if err != nil {
+		return subscriptiondomain.SubscriptionPlanChange{}, err
+	}
+	return change, nil
+}
+
+// ListPlanChanges returns the plan history of a subscription, newest first.
+func (s *Service) ListPlanChanges(ctx context.Context, subscriptionID string) ([]subscriptiondomain.SubscriptionPlanChange, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, subscriptiondomain.ErrInvalidOrganization
+	}
+	id, err := s.parseID(subscriptionID, subscriptiondomain.ErrInvalidSubscription)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := s.repo.FindByID(ctx, s.db, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, subscriptiondomain.ErrSubscriptionNotFound
+	}
+
+	var changes []subscriptiondomain.SubscriptionPlanChange
+	if err := s.db.WithContext(ctx).Raw(
+		`SELECT * FROM subscription_plan_changes
+		 WHERE org_id = ? AND subscription_id = ?
+		 ORDER BY effective_at DESC, id DESC`,
+		orgID,
+		id,
+	).Scan(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// ApplyScheduledPlanChanges applies scheduled downgrades whose effective time
+// has passed. The change takes effect at its scheduled time, not when the job
+// runs, so the cycle that just ended is still billed on the old plan.
+func (s *Service) ApplyScheduledPlanChanges(ctx context.Context, limit int) error {
+	if limit <= 0 {
+		limit = 100
+	}
+	now := s.clock.Now().UTC()
+
+	var due []subscriptiondomain.SubscriptionPlanChange
+	if err := s.db.WithContext(ctx).Raw(
+		`SELECT * FROM subscription_plan_changes
+		 WHERE status = ? AND effective_at <= ?
+		 ORDER BY effective_at ASC, id ASC
+		 LIMIT ?`,
+		subscriptiondomain.PlanChangeStatusScheduled,
+		now,
+		limit,
+	).Scan(&due).Error; err != nil {
+		return err
+	}
+
+	for _, change := range due {
+		orgCtx := orgcontext.WithOrgID(ctx, int64(change.OrgID))
+		if err := s.applyScheduledPlanChange(orgCtx, change, now); err != nil {
+			s.log.Warn("failed to apply scheduled plan change",
+				zap.String("plan_change_id", change.ID.String()),
+				zap.String("subscription_id", change.SubscriptionID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+	return nil
+}
+
+func (s *Service) applyScheduledPlanChange(ctx context.Context, change subscriptiondomain.SubscriptionPlanChange, now time.Time) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sub, err := s.repo.FindByIDForUpdate(ctx, tx, change.OrgID, change.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		if sub == nil || sub.Status != subscriptiondomain.SubscriptionStatusActive {
+			return s.cancelScheduledPlanChanges(ctx, tx, change.OrgID, change.SubscriptionID, now)
+		}
+
+		newPrice, err := s.loadPrice(ctx, change.ToPriceID.String(), map[string]*pricedomain.Response{})
+		if err != nil {
+			return err
+		}
+		cycleType, err := billingCycleTypeForInterval(newPrice.BillingInterval, newPrice.BillingIntervalCount)
+		if err != nil {
+			return err
+		}
+
+		// Items may have changed since the downgrade was requested.
+		currentItems, err := s.listItemsForUpdate(ctx, tx, change.OrgID, change.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		if change.PreviousItems, err = json.Marshal(planChangeItems(currentItems)); err != nil {
+			return err
+		}
+
+		newItems, productIDs, err := s.buildPlanItems(ctx, change.OrgID, change.SubscriptionID, newPrice, change.Quantity, change.ProrationBehavior, cycleType, subscriptionCurrency(sub), change.EffectiveAt)
+		if err != nil {
+			return err
+		}
+		if err := s.applyPlanChange(ctx, tx, &change, newItems, productIDs, change.EffectiveAt); err != nil {
+			return err
+		}
+		change.UpdatedAt = now
+
+		return tx.WithContext(ctx).Exec(
+			`UPDATE subscription_plan_changes
+			 SET status = ?, applied_at = ?, previous_items = ?, items = ?, updated_at = ?
+			 WHERE id = ? AND status = ?`,
+			change.Status,
+			change.AppliedAt,
+			change.PreviousItems,
+			change.Items,
+			change.UpdatedAt,
+			change.ID,
+			subscriptiondomain.PlanChangeStatusScheduled,
+		).Error
+	})
+}
+
+// applyPlanChangeAt applies and records a change that takes effect at the
+// given time. It estimates the proration against the open cycle and, for
+// INVOICE_IMMEDIATELY, ends that cycle at the change.
+func (s *Service) applyPlanChangeAt(
+	ctx context.Context,
+	tx *gorm.DB,
+	change *subscriptiondomain.SubscriptionPlanChange,
+	cycle *openCycleRow,
+	items []subscriptiondomain.SubscriptionItem,
+	productIDs []snowflake.ID,
+	currentAmount, newAmount int64,
+	at time.Time,
+) error {
+	if cycle != nil && change.ProrationBehavior != string(pricedomain.None) {
+		factor := remainingFraction(cycle.PeriodStart, cycle.PeriodEnd, at)
+		change.CreditAmount = roundAmount(float64(currentAmount) * factor)
+		change.ChargeAmount = roundAmount(float64(newAmount) * factor)
+	}
+
+	if err := s.applyPlanChange(ctx, tx, change, items, productIDs, at); err != nil {
+		return err
+	}
+
+	if change.ProrationBehavior == string(pricedomain.InvoiceImmediately) && cycle != nil &&
+		cycle.PeriodStart.Before(at) && cycle.PeriodEnd.After(at) {
+		// Ending the cycle at the change lets the scheduler rate and
+		// invoice it right away; the next cycle picks up at the change
+		// and ends on the original boundary.
+		if err := s.endOpenCycleAt(ctx, tx, cycle.ID, at); err != nil {
+			return err
+		}
+	}
+
+	return s.insertPlanChange(ctx, tx, change)
+}
+
+// applyPlanChange swaps the subscription's items and entitlements at the
+// given time and marks the change applied.
+func (s *Service) applyPlanChange(
+	ctx context.Context,
+	tx *gorm.DB,
+	change *subscriptiondomain.SubscriptionPlanChange,
+	items []subscriptiondomain.SubscriptionItem,
+	productIDs []snowflake.ID,
+	at time.Time,
+) error {
+	entitlements, err := s.buildSubscriptionEntitlements(ctx, tx, change.OrgID, change.SubscriptionID, productIDs, at)
+	if err != nil {
+		return err
+	}
+	if err := s.closeActiveEntitlements(ctx, tx, change.SubscriptionID, at); err != nil {
+		return err
+	}
+	if err := s.repo.ReplaceItems(ctx, tx, change.OrgID, change.SubscriptionID, items); err != nil {
+		return err
+	}
+	if err := s.repo.InsertEntitlements(ctx, tx, entitlements); err != nil {
+		return err
+	}
+	if err := tx.WithContext(ctx).Exec(
+		`UPDATE subscriptions
+		 SET plan_changed_at = ?, updated_at = ?
+		 WHERE org_id = ? AND id = ?`,
+		at,
+		s.clock.Now().UTC(),
+		change.OrgID,
+		change.SubscriptionID,
+	).Error; err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(planChangeItems(items))
+	if err != nil {
+		return err
+	}
+	change.Items = encoded
+	change.Status = subscriptiondomain.PlanChangeStatusApplied
+	appliedAt := s.clock.Now().UTC()
+	change.AppliedAt = &appliedAt
+	return nil
+}
+
+func (s *Service) resolvePlanPrice(ctx context.Context, orgID, productID snowflake.ID, priceID string) (*pricedomain.Response, error) {
+	priceID = strings.TrimSpace(priceID)
+	if priceID == "" {
+		return s.resolveProductPrice(ctx, orgID, productID)
+	}
+	if _, err := snowflake.ParseString(priceID); err != nil {
+		return nil, subscriptiondomain.ErrInvalidPrice
+	}
+
+	price, err := s.loadPrice(ctx, priceID, map[string]*pricedomain.Response{})
+	if err != nil {
+		return nil, err
+	}
+	if price == nil || price.ProductID != productID {
+		return nil, subscriptiondomain.ErrInvalidPrice
+	}
+	return price, nil
+}
+
+func (s *Service) buildPlanItems(
+	ctx context.Context,
+	orgID, subscriptionID snowflake.ID,
+	price *pricedomain.Response,
+	quantity int32,
+	behavior string,
+	cycleType string,
+	currency string,
+	now time.Time,
+) ([]subscriptiondomain.SubscriptionItem, []snowflake.ID, error) {
+	items, productIDs, err := s.buildSubscriptionItems(ctx, orgID, subscriptionID, []subscriptiondomain.CreateSubscriptionItemRequest{
+		{PriceID: price.ID.String(), Quantity: quantity},
+	}, cycleType, currency, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range items {
+		value := behavior
+		items[i].ProrationBehavior = &value
+	}
+	return items, productIDs, nil
+}
+
+// recurringAmount sums the flat, non-metered part of a plan in the given
+// currency, any when empty. It decides whether a change is an upgrade and
+// sizes the proration estimate.
+func (s *Service) recurringAmount(ctx context.Context, items []subscriptiondomain.PlanChangeItem, currency string) (int64, string, error) {
+	total := money.NewFromInt(0)
+	for _, item := range items {
+		if item.MeterID != nil {
+			continue
+		}
+		amounts, err := s.loadPriceAmount(ctx, item.PriceID.String(), currency)
+		if err != nil {
+			return 0, "", err
+		}
+		for _, amount := range amounts {
+			if amount.MeterID != nil {
+				continue
+			}
+			quantity := money.NewFromInt(int64(normalizeSubscriptionQuantity(item.Quantity)))
+			total = total.Add(amount.UnitAmount().Mul(quantity))
+			if currency == "" {
+				currency = amount.Currency
+			}
+			break
+		}
+	}
+	return total.Round(money.RoundHalfUp), currency, nil
+}
+
+func (s *Service) currentProductID(ctx context.Context, items []subscriptiondomain.SubscriptionItem) *snowflake.ID {
+	for _, item := range items {
+		price, err := s.pricesvc.Get(ctx, item.PriceID.String())
+		if err != nil || price == nil {
+			continue
+		}
+		productID := price.ProductID
+		return &productID
+	}
+	return nil
+}
+
+type openCycleRow struct {
+	ID          snowflake.ID
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+}
+
+func (s *Service) findOpenCycle(ctx context.Context, tx *gorm.DB, orgID, subscriptionID snowflake.ID) (*openCycleRow, error) {
+	var row openCycleRow
+	if err := tx.WithContext(ctx).Raw(
+		`SELECT id, period_start, period_end
+		 FROM billing_cycles
+		 WHERE org_id = ? AND subscription_id = ? AND status = ?
+		 ORDER BY period_start DESC
+		 LIMIT 1`,
+		orgID,
+		subscriptionID,
+		billingcycledomain.BillingCycleStatusOpen,
+	).Scan(&row).Error; err != nil {
+		return nil, err
+	}
+	if row.ID == 0 {
+		return nil, nil
+	}
+	return &row, nil
+}
+
+func (s *Service) endOpenCycleAt(ctx context.Context, tx *gorm.DB, cycleID snowflake.ID, at time.Time) error {
+	return tx.WithContext(ctx).Exec(
+		`UPDATE billing_cycles
+		 SET period_end = ?, updated_at = ?
+		 WHERE id = ? AND status = ?`,
+		at,
+		s.clock.Now().UTC(),
+		cycleID,
+		billingcycledomain.BillingCycleStatusOpen,
+	).Error
+}
+
+func (s *Service) listItemsForUpdate(ctx context.Context, tx *gorm.DB, orgID, subscriptionID snowflake.ID) ([]subscriptiondomain.SubscriptionItem, error) {
+	var items []subscriptiondomain.SubscriptionItem
+	if err := tx.WithContext(ctx).Raw(
+		`SELECT * FROM subscription_items
+		 WHERE org_id = ? AND subscription_id = ?
+		 ORDER BY created_at ASC, id ASC`,
+		orgID,
+		subscriptionID,
+	).Scan(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *Service) cancelScheduledPlanChanges(ctx context.Context, tx *gorm.DB, orgID, subscriptionID snowflake.ID, now time.Time) error {
+	return tx.WithContext(ctx).Exec(
+		`UPDATE subscription_plan_changes
+		 SET status = ?, canceled_at = ?, updated_at = ?
+		 WHERE org_id = ? AND subscription_id = ? AND status = ?`,
+		subscriptiondomain.PlanChangeStatusCanceled,
+		now,
+		now,
+		orgID,
+		subscriptionID,
+		subscriptiondomain.PlanChangeStatusScheduled,
+	).Error
+}
+
+func (s *Service) insertPlanChange(ctx context.Context, tx *gorm.DB, change *subscriptiondomain.SubscriptionPlanChange) error {
+	if change.Items == nil {
+		change.Items = datatypes.JSON("[]")
+	}
+	return tx.WithContext(ctx).Exec(
+		`INSERT INTO subscription_plan_changes (
+			id, org_id, subscription_id, from_product_id, to_product_id, to_price_id, quantity,
+			direction, proration_behavior, status, effective_at, applied_at, previous_items, items,
+			credit_amount, charge_amount, currency, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		change.ID,
+		change.OrgID,
+		change.SubscriptionID,
+		change.FromProductID,
+		change.ToProductID,
+		change.ToPriceID,
+		change.Quantity,
+		change.Direction,
+		change.ProrationBehavior,
+		change.Status,
+		change.EffectiveAt,
+		change.AppliedAt,
+		change.PreviousItems,
+		change.Items,
+		change.CreditAmount,
+		change.ChargeAmount,
+		change.Currency,
+		change.CreatedAt,
+		change.UpdatedAt,
+	).Error
+}
+
+func planChangeItems(items []subscriptiondomain.SubscriptionItem) []subscriptiondomain.PlanChangeItem {
+	out := make([]subscriptiondomain.PlanChangeItem, 0, len(items))
+	for _, item := range items {
+		out = append(out, subscriptiondomain.PlanChangeItem{
+			PriceID:       item.PriceID,
+			MeterID:       item.MeterID,
+			Quantity:      item.Quantity,
+			BillingTiming: item.BillingTiming,
+		})
+	}
+	return out
+}
+
+// ensureSameCycle rejects prices that do not bill on the subscription's
+// cycle. Proration is measured against the current cycle, so a new plan must
+// bill on the same interval.
+func ensureSameCycle(sub *subscriptiondomain.Subscription, cycleType string) error {
+	if current, ok := billingcycledomain.ParseCycleType(sub.BillingCycleType); ok {
+		if next, _ := billingcycledomain.ParseCycleType(cycleType); next != current {
+			return subscriptiondomain.ErrInvalidBillingCycleType
+		}
+	}
+	return nil
+}
+
+func parseProrationBehavior(value string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(value))
+	switch pricedomain.ProrationBehavior(normalized) {
+	case "":
+		return "", nil
+	case pricedomain.None,
+		pricedomain.CreateProration,
+		pricedomain.Deferred,
+		pricedomain.InvoiceImmediately:
+		return normalized, nil
+	default:
+		return "", subscriptiondomain.ErrInvalidProrationBehavior
+	}
+}
+
+// currentProrationBehavior returns the behavior configured on the current
+// items, defaulting to CREATE_PRORATION.
+func currentProrationBehavior(items []subscriptiondomain.SubscriptionItem) string {
+	for _, item := range items {
+		if item.ProrationBehavior == nil {
+			continue
+		}
+		if behavior, err := parseProrationBehavior(*item.ProrationBehavior); err == nil && behavior != "" {
+			return behavior
+		}
+	}
+	return string(pricedomain.CreateProration)
+}
+
+// remainingFraction is the share of [start, end) left after at.
+func remainingFraction(start, end, at time.Time) float64 {
+	total := end.Sub(start).Seconds()
+	if total <= 0 || !at.Before(end) {
+		return 0
+	}
+	if at.Before(start) {
+		return 1
+	}
+	return end.Sub(at).Seconds() / total
+}
+
+func roundAmount(value float64) int64 {
+	return int64(math.Floor(value + 0.5))
+}
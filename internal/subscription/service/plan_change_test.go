@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	"github.com/smallbiznis/railzway/internal/clock"
+	"github.com/smallbiznis/railzway/internal/money"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	priceamountdomain "github.com/smallbiznis/railzway/internal/priceamount/domain"
+	productfeaturedomain "github.com/smallbiznis/railzway/internal/productfeature/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// flatPriceAmounts serves one flat amount per price.
+type flatPriceAmounts struct {
+	mockPriceAmountService
+	amounts map[string]int64
+}
+
+func (m *flatPriceAmounts) List(ctx context.Context, req priceamountdomain.ListPriceAmountRequest) ([]priceamountdomain.Response, error) {
+	amount, ok := m.amounts[req.PriceID]
+	if !ok {
+		return nil, nil
+	}
+	return []priceamountdomain.Response{{UnitAmountCents: amount, Currency: "USD"}}, nil
+}
+
+func TestChangePlanProrationAndScheduling(t *testing.T) {
+	db := setupTestDB(t)
+	node, _ := snowflake.NewNode(1)
+	orgID := node.Generate()
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	basic, pro, lite := node.Generate(), node.Generate(), node.Generate()
+	prices := map[snowflake.ID]snowflake.ID{basic: node.Generate(), pro: node.Generate(), lite: node.Generate()}
+	priceSvc := &mockPriceService{}
+	features := &mockProductFeatureRepo{}
+	amounts := &flatPriceAmounts{amounts: map[string]int64{
+		prices[basic].String(): 10000,
+		prices[pro].String():   15000,
+		prices[lite].String():  5000,
+	}}
+	for productID, priceID := range prices {
+		priceSvc.prices = append(priceSvc.prices, pricedomain.Response{
+			ID:              priceID,
+			OrganizationID:  orgID,
+			ProductID:       productID,
+			BillingInterval: pricedomain.Month,
+			Active:          true,
+			IsDefault:       true,
+			PricingModel:    pricedomain.Flat,
+			BillingMode:     pricedomain.Licensed,
+		})
+		features.features = append(features.features, productfeaturedomain.FeatureAssignment{
+			FeatureID: node.Generate(), ProductID: productID, Code: productID.String(), FeatureType: "boolean", Active: true,
+		})
+	}
+
+	cycleStart := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cycleEnd := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC))
+	repo := &mockRepository{subscriptions: make(map[string]*subscriptiondomain.Subscription)}
+	svc := NewService(ServiceParam{
+		DB:                 db,
+		Log:                zap.NewNop(),
+		GenID:              node,
+		Clock:              fakeClock,
+		Repo:               repo,
+		Pricesvc:           priceSvc,
+		PriceAmountsvc:     amounts,
+		ProductFeatureRepo: features,
+	})
+
+	subID := node.Generate()
+	require.NoError(t, repo.Insert(ctx, db, &subscriptiondomain.Subscription{
+		ID:               subID,
+		OrgID:            orgID,
+		CustomerID:       node.Generate(),
+		Status:           subscriptiondomain.SubscriptionStatusActive,
+		StartAt:          cycleStart,
+		BillingCycleType: "monthly",
+	}))
+	require.NoError(t, repo.InsertItems(ctx, db, []subscriptiondomain.SubscriptionItem{{
+		ID: node.Generate(), OrgID: orgID, SubscriptionID: subID, PriceID: prices[basic], Quantity: 1, BillingMode: "LICENSED",
+	}}))
+	cycleID := node.Generate()
+	require.NoError(t, db.Create(&billingcycledomain.BillingCycle{
+		ID: cycleID, OrgID: orgID, SubscriptionID: subID, PeriodStart: cycleStart, PeriodEnd: cycleEnd,
+		Status: billingcycledomain.BillingCycleStatusOpen,
+	}).Error)
+
+	currentPrice := func() snowflake.ID {
+		var items []subscriptiondomain.SubscriptionItem
+		require.NoError(t, db.Where("subscription_id = ?", subID).Find(&items).Error)
+		require.Len(t, items, 1)
+		return items[0].PriceID
+	}
+
+	_, err := svc.ChangePlan(ctx, subscriptiondomain.ChangePlanRequest{
+		SubscriptionID: subID.String(), NewProductID: pro.String(), ProrationBehavior: "sometimes",
+	})
+	assert.ErrorIs(t, err, subscriptiondomain.ErrInvalidProrationBehavior)
+
+	// Upgrade: applied now, credit 16/31 of basic and charge 16/31 of pro.
+	upgrade, err := svc.ChangePlan(ctx, subscriptiondomain.ChangePlanRequest{
+		SubscriptionID: subID.String(), NewProductID: pro.String(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, subscriptiondomain.PlanChangeUpgrade, upgrade.Direction)
+	assert.Equal(t, subscriptiondomain.PlanChangeStatusApplied, upgrade.Status)
+	assert.Equal(t, "CREATE_PRORATION", upgrade.ProrationBehavior)
+	assert.Equal(t, int64(5161), upgrade.CreditAmount)
+	assert.Equal(t, int64(7742), upgrade.ChargeAmount)
+	assert.Equal(t, prices[pro], currentPrice())
+
+	var previous []subscriptiondomain.PlanChangeItem
+	require.NoError(t, json.Unmarshal(upgrade.PreviousItems, &previous))
+	require.Len(t, previous, 1)
+	assert.Equal(t, prices[basic], previous[0].PriceID)
+
+	_, err = svc.ChangePlan(ctx, subscriptiondomain.ChangePlanRequest{
+		SubscriptionID: subID.String(), NewProductID: pro.String(),
+	})
+	assert.ErrorIs(t, err, subscriptiondomain.ErrPlanUnchanged)
+
+	// Downgrade: scheduled for period end, items untouched.
+	downgrade, err := svc.ChangePlan(ctx, subscriptiondomain.ChangePlanRequest{
+		SubscriptionID: subID.String(), NewProductID: lite.String(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, subscriptiondomain.PlanChangeDowngrade, downgrade.Direction)
+	assert.Equal(t, subscriptiondomain.PlanChangeStatusScheduled, downgrade.Status)
+	assert.True(t, cycleEnd.Equal(downgrade.EffectiveAt))
+	assert.Equal(t, prices[pro], currentPrice())
+
+	require.NoError(t, svc.ApplyScheduledPlanChanges(context.Background(), 10))
+	assert.Equal(t, prices[pro], currentPrice(), "not due yet")
+
+	fakeClock.Advance(16*24*time.Hour + time.Hour)
+	require.NoError(t, svc.ApplyScheduledPlanChanges(context.Background(), 10))
+	assert.Equal(t, prices[lite], currentPrice())
+
+	history, err := svc.ListPlanChanges(ctx, subID.String())
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, downgrade.ID, history[0].ID)
+	assert.Equal(t, subscriptiondomain.PlanChangeStatusApplied, history[0].Status)
+	require.NotNil(t, history[0].AppliedAt)
+	assert.Equal(t, upgrade.ID, history[1].ID)
+
+	var lastEntitlement subscriptiondomain.SubscriptionEntitlement
+	require.NoError(t, db.Where("subscription_id = ? AND effective_to IS NULL", subID).First(&lastEntitlement).Error)
+	assert.Equal(t, lite, lastEntitlement.ProductID)
+	assert.True(t, cycleEnd.Equal(lastEntitlement.EffectiveFrom), "downgrade takes effect at period end")
+
+	// INVOICE_IMMEDIATELY ends the open cycle at the change.
+	nextCycleID := node.Generate()
+	require.NoError(t, db.Create(&billingcycledomain.BillingCycle{
+		ID: nextCycleID, OrgID: orgID, SubscriptionID: subID, PeriodStart: cycleEnd, PeriodEnd: cycleEnd.AddDate(0, 1, 0),
+		Status: billingcycledomain.BillingCycleStatusOpen,
+	}).Error)
+	require.NoError(t, db.Model(&billingcycledomain.BillingCycle{}).Where("id = ?", cycleID).Update("status", billingcycledomain.BillingCycleStatusClosed).Error)
+
+	_, err = svc.ChangePlan(ctx, subscriptiondomain.ChangePlanRequest{
+		SubscriptionID: subID.String(), NewProductID: basic.String(), ProrationBehavior: "invoice_immediately",
+	})
+	require.NoError(t, err)
+	var cut billingcycledomain.BillingCycle
+	require.NoError(t, db.First(&cut, "id = ?", nextCycleID).Error)
+	assert.True(t, fakeClock.Now().Equal(cut.PeriodEnd))
+}
+
+// decimalPriceAmounts serves a sub-minor-unit amount for every price.
+type decimalPriceAmounts struct {
+	mockPriceAmountService
+	amount money.Decimal
+}
+
+func (m *decimalPriceAmounts) List(ctx context.Context, req priceamountdomain.ListPriceAmountRequest) ([]priceamountdomain.Response, error) {
+	return []priceamountdomain.Response{{UnitAmountCents: m.amount.IntPart(), UnitAmountDecimal: &m.amount, Currency: "USD"}}, nil
+}
+
+func TestRecurringAmountUsesDecimalUnitAmount(t *testing.T) {
+	node, _ := snowflake.NewNode(1)
+	amount, err := money.Parse("0.25")
+	require.NoError(t, err)
+	svc := NewService(ServiceParam{
+		DB:             setupTestDB(t),
+		Log:            zap.NewNop(),
+		GenID:          node,
+		Clock:          clock.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Repo:           &mockRepository{subscriptions: make(map[string]*subscriptiondomain.Subscription)},
+		PriceAmountsvc: &decimalPriceAmounts{amount: amount},
+	}).(*Service)
+
+	total, currency, err := svc.recurringAmount(context.Background(), []subscriptiondomain.PlanChangeItem{
+		{PriceID: node.Generate(), Quantity: 10},
+	}, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total, "10 x 0.25 rounds half up")
+	assert.Equal(t, "USD", currency)
+}
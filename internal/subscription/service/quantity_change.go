@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// UpdateItemQuantity changes the quantity of a licensed subscription item.
+//
+// The change is recorded with its effective time so rating can bill each
+// part of the cycle at the quantity in force for it. A change effective now
+// or earlier is applied right away; a later one is scheduled and applied by
+// ApplyScheduledQuantityChanges. A newer request replaces any change still
+// scheduled for the item.
+func (s *Service) UpdateItemQuantity(ctx context.Context, req subscriptiondomain.UpdateItemQuantityRequest) (subscriptiondomain.SubscriptionItemQuantityChange, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return subscriptiondomain.SubscriptionItemQuantityChange{}, subscriptiondomain.ErrInvalidOrganization
+	}
+	subscriptionID, err := s.parseID(req.SubscriptionID, subscriptiondomain.ErrInvalidSubscription)
+	if err != nil {
+		return subscriptiondomain.SubscriptionItemQuantityChange{}, err
+	}
+	itemID, err := s.parseID(req.ItemID, subscriptiondomain.ErrInvalidItems)
+	if err != nil {
+		return subscriptiondomain.SubscriptionItemQuantityChange{}, err
+	}
+	if req.Quantity < 1 {
+		return subscriptiondomain.SubscriptionItemQuantityChange{}, subscriptiondomain.ErrInvalidQuantity
+	}
+
+	now := s.clock.Now().UTC()
+	effectiveAt := now
+	if req.EffectiveAt != nil && !req.EffectiveAt.IsZero() {
+		effectiveAt = req.EffectiveAt.UTC()
+	}
+
+	var change subscriptiondomain.SubscriptionItemQuantityChange
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sub, err := s.repo.FindByIDForUpdate(ctx, tx, orgID, subscriptionID)
+		if err != nil {
+			return err
+		}
+		if sub == nil {
+			return subscriptiondomain.ErrSubscriptionNotFound
+		}
+		if sub.Status != subscriptiondomain.SubscriptionStatusActive {
+			return subscriptiondomain.ErrInvalidSubscriptionStatus
+		}
+
+		item, err := s.findItemForUpdate(ctx, tx, orgID, subscriptionID, itemID)
+		if err != nil {
+			return err
+		}
+		if item.MeterID != nil {
+			return subscriptiondomain.ErrQuantityNotSupported
+		}
+
+		// Closed cycles are already rated, so a change may only reach back
+		// to the start of the open one, and never before the item's last
+		// applied change.
+		earliest := now
+		cycle, err := s.findOpenCycle(ctx, tx, orgID, subscriptionID)
+		if err != nil {
+			return err
+		}
+		if cycle != nil && cycle.PeriodStart.Before(earliest) {
+			earliest = cycle.PeriodStart
+		}
+		if last, err := s.lastAppliedQuantityChange(ctx, tx, orgID, itemID); err != nil {
+			return err
+		} else if last != nil && last.After(earliest) {
+			earliest = *last
+		}
+		if effectiveAt.Before(earliest) {
+			return subscriptiondomain.ErrInvalidEffectiveAt
+		}
+
+		if err := s.cancelScheduledQuantityChanges(ctx, tx, orgID, itemID, now); err != nil {
+			return err
+		}
+
+		previous := normalizeSubscriptionQuantity(item.Quantity)
+		if previous == req.Quantity {
+			return subscriptiondomain.ErrQuantityUnchanged
+		}
+
+		change = subscriptiondomain.SubscriptionItemQuantityChange{
+			ID:                 s.genID.Generate(),
+			OrgID:              orgID,
+			SubscriptionID:     subscriptionID,
+			SubscriptionItemID: itemID,
+			PriceID:            item.PriceID,
+			PreviousQuantity:   previous,
+			Quantity:           req.Quantity,
+			Status:             subscriptiondomain.QuantityChangeStatusScheduled,
+			EffectiveAt:        effectiveAt,
+			CreatedAt:          now,
+			UpdatedAt:          now,
+		}
+		if !effectiveAt.After(now) {
+			if err := s.setItemQuantity(ctx, tx, itemID, req.Quantity, now); err != nil {
+				return err
+			}
+			change.Status = subscriptiondomain.QuantityChangeStatusApplied
+			change.AppliedAt = &now
+		}
+		return s.insertQuantityChange(ctx, tx, &change)
+	})
+	if err != nil {
+		return subscriptiondomain.SubscriptionItemQuantityChange{}, err
+	}
+	return change, nil
+}
+
+// ListItemQuantityChanges returns the quantity history of a subscription
+// item, newest first.
+func (s *Service) ListItemQuantityChanges(ctx context.Context, subscriptionID, itemID string) ([]subscriptiondomain.SubscriptionItemQuantityChange, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, subscriptiondomain.ErrInvalidOrganization
+	}
+	subID, err := s.parseID(subscriptionID, subscriptiondomain.ErrInvalidSubscription)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.parseID(itemID, subscriptiondomain.ErrInvalidItems)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := s.repo.FindByID(ctx, s.db, orgID, subID)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, subscriptiondomain.ErrSubscriptionNotFound
+	}
+
+	var changes []subscriptiondomain.SubscriptionItemQuantityChange
+	if err := s.db.WithContext(ctx).Raw(
+		`SELECT * FROM subscription_item_quantity_changes
+		 WHERE org_id = ? AND subscription_id = ? AND subscription_item_id = ?
+		 ORDER BY effective_at DESC, id DESC`,
+		orgID,
+		subID,
+		id,
+	).Scan(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// ApplyScheduledQuantityChanges applies scheduled quantity changes whose
+// effective time has passed. Changes of items that no longer exist, e.g.
+// after a plan change, are canceled.
+func (s *Service) ApplyScheduledQuantityChanges(ctx context.Context, limit int) error {
+	if limit <= 0 {
+		limit = 100
+	}
+	now := s.clock.Now().UTC()
+
+	var due []subscriptiondomain.SubscriptionItemQuantityChange
+	if err := s.db.WithContext(ctx).Raw(
+		`SELECT * FROM subscription_item_quantity_changes
+		 WHERE status = ? AND effective_at <= ?
+		 ORDER BY effective_at ASC, id ASC
+		 LIMIT ?`,
+		subscriptiondomain.QuantityChangeStatusScheduled,
+		now,
+		limit,
+	).Scan(&due).Error; err != nil {
+		return err
+	}
+
+	for _, change := range due {
+		orgCtx := orgcontext.WithOrgID(ctx, int64(change.OrgID))
+		if err := s.applyScheduledQuantityChange(orgCtx, change, now); err != nil {
+			s.log.Warn("failed to apply scheduled quantity change",
+				zap.String("quantity_change_id", change.ID.String()),
+				zap.String("subscription_item_id", change.SubscriptionItemID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+	return nil
+}
+
+func (s *Service) applyScheduledQuantityChange(ctx context.Context, change subscriptiondomain.SubscriptionItemQuantityChange, now time.Time) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sub, err := s.repo.FindByIDForUpdate(ctx, tx, change.OrgID, change.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		if sub == nil || sub.Status != subscriptiondomain.SubscriptionStatusActive {
+			return s.cancelScheduledQuantityChanges(ctx, tx, change.OrgID, change.SubscriptionItemID, now)
+		}
+
+		item, err := s.findItemForUpdate(ctx, tx, change.OrgID, change.SubscriptionID, change.SubscriptionItemID)
+		if errors.Is(err, subscriptiondomain.ErrSubscriptionItemNotFound) {
+			return s.cancelScheduledQuantityChanges(ctx, tx, change.OrgID, change.SubscriptionItemID, now)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.setItemQuantity(ctx, tx, item.ID, change.Quantity, now); err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).Exec(
+			`UPDATE subscription_item_quantity_changes
+			 SET status = ?, previous_quantity = ?, applied_at = ?, updated_at = ?
+			 WHERE id = ? AND status = ?`,
+			subscriptiondomain.QuantityChangeStatusApplied,
+			normalizeSubscriptionQuantity(item.Quantity),
+			now,
+			now,
+			change.ID,
+			subscriptiondomain.QuantityChangeStatusScheduled,
+		).Error
+	})
+}
+
+func (s *Service) findItemForUpdate(ctx context.Context, tx *gorm.DB, orgID, subscriptionID, itemID snowflake.ID) (*subscriptiondomain.SubscriptionItem, error) {
+	var item subscriptiondomain.SubscriptionItem
+	if err := tx.WithContext(ctx).Raw(
+		`SELECT * FROM subscription_items
+		 WHERE org_id = ? AND subscription_id = ? AND id = ?`,
+		orgID,
+		subscriptionID,
+		itemID,
+	).Scan(&item).Error; err != nil {
+		return nil, err
+	}
+	if item.ID == 0 {
+		return nil, subscriptiondomain.ErrSubscriptionItemNotFound
+	}
+	return &item, nil
+}
+
+func (s *Service) lastAppliedQuantityChange(ctx context.Context, tx *gorm.DB, orgID, itemID snowflake.ID) (*time.Time, error) {
+	var row struct {
+		EffectiveAt time.Time
+	}
+	if err := tx.WithContext(ctx).Raw(
+		`SELECT effective_at FROM subscription_item_quantity_changes
+		 WHERE org_id = ? AND subscription_item_id = ? AND status = ?
+		 ORDER BY effective_at DESC
+		 LIMIT 1`,
+		orgID,
+		itemID,
+		subscriptiondomain.QuantityChangeStatusApplied,
+	).Scan(&row).Error; err != nil {
+		return nil, err
+	}
+	if row.EffectiveAt.IsZero() {
+		return nil, nil
+	}
+	return &row.EffectiveAt, nil
+}
+
+func (s *Service) setItemQuantity(ctx context.Context, tx *gorm.DB, itemID snowflake.ID, quantity int32, now time.Time) error {
+	return tx.WithContext(ctx).Exec(
+		`UPDATE subscription_items
+		 SET quantity = ?, updated_at = ?
+		 WHERE id = ?`,
+		quantity,
+		now,
+		itemID,
+	).Error
+}
+
+func (s *Service) cancelScheduledQuantityChanges(ctx context.Context, tx *gorm.DB, orgID, itemID snowflake.ID, now time.Time) error {
+	return tx.WithContext(ctx).Exec(
+		`UPDATE subscription_item_quantity_changes
+		 SET status = ?, canceled_at = ?, updated_at = ?
+		 WHERE org_id = ? AND subscription_item_id = ? AND status = ?`,
+		subscriptiondomain.QuantityChangeStatusCanceled,
+		now,
+		now,
+		orgID,
+		itemID,
+		subscriptiondomain.QuantityChangeStatusScheduled,
+	).Error
+}
+
+func (s *Service) insertQuantityChange(ctx context.Context, tx *gorm.DB, change *subscriptiondomain.SubscriptionItemQuantityChange) error {
+	return tx.WithContext(ctx).Exec(
+		`INSERT INTO subscription_item_quantity_changes (
+			id, org_id, subscription_id, subscription_item_id, price_id, previous_quantity, quantity,
+			status, effective_at, applied_at, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		change.ID,
+		change.OrgID,
+		change.SubscriptionID,
+		change.SubscriptionItemID,
+		change.PriceID,
+		change.PreviousQuantity,
+		change.Quantity,
+		change.Status,
+		change.EffectiveAt,
+		change.AppliedAt,
+		change.CreatedAt,
+		change.UpdatedAt,
+	).Error
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	"github.com/smallbiznis/railzway/internal/clock"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUpdateItemQuantity(t *testing.T) {
+	db := setupTestDB(t)
+	node, _ := snowflake.NewNode(1)
+	orgID := node.Generate()
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	cycleStart := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cycleEnd := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC))
+	repo := &mockRepository{subscriptions: make(map[string]*subscriptiondomain.Subscription)}
+	svc := NewService(ServiceParam{
+		DB:                 db,
+		Log:                zap.NewNop(),
+		GenID:              node,
+		Clock:              fakeClock,
+		Repo:               repo,
+		Pricesvc:           &mockPriceService{},
+		PriceAmountsvc:     &mockPriceAmountService{},
+		ProductFeatureRepo: &mockProductFeatureRepo{},
+	})
+
+	subID := node.Generate()
+	require.NoError(t, repo.Insert(ctx, db, &subscriptiondomain.Subscription{
+		ID:               subID,
+		OrgID:            orgID,
+		CustomerID:       node.Generate(),
+		Status:           subscriptiondomain.SubscriptionStatusActive,
+		StartAt:          cycleStart,
+		BillingCycleType: "monthly",
+	}))
+	require.NoError(t, db.Create(&billingcycledomain.BillingCycle{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		SubscriptionID: subID,
+		PeriodStart:    cycleStart,
+		PeriodEnd:      cycleEnd,
+		Status:         billingcycledomain.BillingCycleStatusOpen,
+	}).Error)
+
+	seatsID := node.Generate()
+	meterID := node.Generate()
+	require.NoError(t, repo.InsertItems(ctx, db, []subscriptiondomain.SubscriptionItem{
+		{ID: seatsID, OrgID: orgID, SubscriptionID: subID, PriceID: node.Generate(), Quantity: 5, BillingMode: "LICENSED"},
+		{ID: meterID, OrgID: orgID, SubscriptionID: subID, PriceID: node.Generate(), MeterID: &meterID, BillingMode: "METERED"},
+	}))
+
+	itemQuantity := func(t *testing.T) int32 {
+		var item subscriptiondomain.SubscriptionItem
+		require.NoError(t, db.Where("id = ?", seatsID).First(&item).Error)
+		return item.Quantity
+	}
+
+	t.Run("backdated change applies now and keeps the history", func(t *testing.T) {
+		effectiveAt := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+		change, err := svc.UpdateItemQuantity(ctx, subscriptiondomain.UpdateItemQuantityRequest{
+			SubscriptionID: subID.String(),
+			ItemID:         seatsID.String(),
+			Quantity:       200,
+			EffectiveAt:    &effectiveAt,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, subscriptiondomain.QuantityChangeStatusApplied, change.Status)
+		assert.Equal(t, int32(5), change.PreviousQuantity)
+		assert.Equal(t, int32(200), itemQuantity(t))
+
+		history, err := svc.ListItemQuantityChanges(ctx, subID.String(), seatsID.String())
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, effectiveAt, history[0].EffectiveAt.UTC())
+	})
+
+	t.Run("change may not reach back before the last one", func(t *testing.T) {
+		effectiveAt := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+		_, err := svc.UpdateItemQuantity(ctx, subscriptiondomain.UpdateItemQuantityRequest{
+			SubscriptionID: subID.String(),
+			ItemID:         seatsID.String(),
+			Quantity:       8,
+			EffectiveAt:    &effectiveAt,
+		})
+		assert.ErrorIs(t, err, subscriptiondomain.ErrInvalidEffectiveAt)
+	})
+
+	t.Run("future change is scheduled and applied when due", func(t *testing.T) {
+		effectiveAt := time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC)
+		change, err := svc.UpdateItemQuantity(ctx, subscriptiondomain.UpdateItemQuantityRequest{
+			SubscriptionID: subID.String(),
+			ItemID:         seatsID.String(),
+			Quantity:       12,
+			EffectiveAt:    &effectiveAt,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, subscriptiondomain.QuantityChangeStatusScheduled, change.Status)
+		assert.Equal(t, int32(200), itemQuantity(t))
+
+		require.NoError(t, svc.ApplyScheduledQuantityChanges(ctx, 10))
+		assert.Equal(t, int32(200), itemQuantity(t))
+
+		fakeClock.Advance(5 * 24 * time.Hour)
+		require.NoError(t, svc.ApplyScheduledQuantityChanges(ctx, 10))
+		assert.Equal(t, int32(12), itemQuantity(t))
+
+		history, err := svc.ListItemQuantityChanges(ctx, subID.String(), seatsID.String())
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, subscriptiondomain.QuantityChangeStatusApplied, history[0].Status)
+		assert.NotNil(t, history[0].AppliedAt)
+	})
+
+	t.Run("metered items have no quantity", func(t *testing.T) {
+		_, err := svc.UpdateItemQuantity(ctx, subscriptiondomain.UpdateItemQuantityRequest{
+			SubscriptionID: subID.String(),
+			ItemID:         meterID.String(),
+			Quantity:       3,
+		})
+		assert.ErrorIs(t, err, subscriptiondomain.ErrQuantityNotSupported)
+	})
+
+	t.Run("unknown item", func(t *testing.T) {
+		_, err := svc.UpdateItemQuantity(ctx, subscriptiondomain.UpdateItemQuantityRequest{
+			SubscriptionID: subID.String(),
+			ItemID:         node.Generate().String(),
+			Quantity:       3,
+		})
+		assert.ErrorIs(t, err, subscriptiondomain.ErrSubscriptionItemNotFound)
+	})
+}
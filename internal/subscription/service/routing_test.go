@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"github.com/smallbiznis/railzway/internal/subscription/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGetActiveByCustomerIDRoutesByMeter(t *testing.T) {
+	db := setupTestDB(t)
+	node, _ := snowflake.NewNode(1)
+	orgID := node.Generate()
+	customerID := node.Generate()
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	createSubscription := func(createdAt time.Time, meterCodes ...string) snowflake.ID {
+		id := node.Generate()
+		require.NoError(t, db.Create(&subscriptiondomain.Subscription{
+			ID:               id,
+			OrgID:            orgID,
+			CustomerID:       customerID,
+			Status:           subscriptiondomain.SubscriptionStatusActive,
+			CollectionMode:   subscriptiondomain.SendInvoice,
+			StartAt:          start,
+			BillingCycleType: "MONTHLY",
+			CreatedAt:        createdAt,
+			UpdatedAt:        createdAt,
+		}).Error)
+		for _, code := range meterCodes {
+			meterCode := code
+			meterID := node.Generate()
+			require.NoError(t, db.Create(&subscriptiondomain.SubscriptionItem{
+				ID:             node.Generate(),
+				OrgID:          orgID,
+				SubscriptionID: id,
+				PriceID:        node.Generate(),
+				MeterID:        &meterID,
+				MeterCode:      &meterCode,
+				BillingMode:    "METERED",
+			}).Error)
+		}
+		return id
+	}
+	apiCalls := createSubscription(start, "api_calls", "storage")
+	compute := createSubscription(start.Add(time.Hour), "compute")
+	projectB := createSubscription(start.Add(2*time.Hour), "storage")
+
+	svc := NewService(ServiceParam{
+		DB:    db,
+		Log:   zap.NewNop(),
+		GenID: node,
+		Clock: &mockClock{},
+		Repo:  repository.Provide(),
+	})
+	get := func(meterCode, subscriptionID string) (subscriptiondomain.Subscription, error) {
+		return svc.GetActiveByCustomerID(ctx, subscriptiondomain.GetActiveByCustomerIDRequest{
+			CustomerID:     customerID.String(),
+			MeterCode:      meterCode,
+			SubscriptionID: subscriptionID,
+		})
+	}
+
+	sub, err := get("api_calls", "")
+	require.NoError(t, err)
+	assert.Equal(t, apiCalls, sub.ID)
+
+	sub, err = get("compute", "")
+	require.NoError(t, err)
+	assert.Equal(t, compute, sub.ID)
+
+	_, err = get("storage", "")
+	assert.ErrorIs(t, err, subscriptiondomain.ErrAmbiguousSubscription)
+
+	sub, err = get("storage", apiCalls.String())
+	require.NoError(t, err)
+	assert.Equal(t, apiCalls, sub.ID)
+
+	// No subscription bills the meter: the most recent one is used.
+	sub, err = get("unknown", "")
+	require.NoError(t, err)
+	assert.Equal(t, projectB, sub.ID)
+
+	_, err = get("storage", node.Generate().String())
+	assert.ErrorIs(t, err, subscriptiondomain.ErrSubscriptionNotFound)
+
+	_, err = get("storage", "not-an-id")
+	assert.ErrorIs(t, err, subscriptiondomain.ErrInvalidSubscription)
+}
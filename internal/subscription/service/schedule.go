@@ -0,0 +1,929 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	coupondomain "github.com/smallbiznis/railzway/internal/coupon/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// CreateSchedule puts a subscription on a schedule. A first phase that starts
+// now is applied right away; later phases are applied by AdvanceSchedules.
+func (s *Service) CreateSchedule(ctx context.Context, req subscriptiondomain.CreateScheduleRequest) (subscriptiondomain.SubscriptionSchedule, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return subscriptiondomain.SubscriptionSchedule{}, subscriptiondomain.ErrInvalidOrganization
+	}
+
+	now := s.clock.Now().UTC()
+	sub, endBehavior, phases, err := s.resolveSchedule(ctx, orgID, req, now)
+	if err != nil {
+		return subscriptiondomain.SubscriptionSchedule{}, err
+	}
+
+	schedule := subscriptiondomain.SubscriptionSchedule{
+		ID:               s.genID.Generate(),
+		OrgID:            orgID,
+		SubscriptionID:   sub.ID,
+		Status:           subscriptiondomain.ScheduleStatusActive,
+		EndBehavior:      endBehavior,
+		NextTransitionAt: &phases[0].StartAt,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked, err := s.repo.FindByIDForUpdate(ctx, tx, orgID, sub.ID)
+		if err != nil {
+			return err
+		}
+		if locked == nil {
+			return subscriptiondomain.ErrSubscriptionNotFound
+		}
+
+		var active int64
+		if err := tx.WithContext(ctx).Raw(
+			`SELECT COUNT(1) FROM subscription_schedules
+			 WHERE org_id = ? AND subscription_id = ? AND status = ?`,
+			orgID,
+			sub.ID,
+			subscriptiondomain.ScheduleStatusActive,
+		).Scan(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return subscriptiondomain.ErrScheduleAlreadyExists
+		}
+
+		if err := tx.WithContext(ctx).Exec(
+			`INSERT INTO subscription_schedules (
+				id, org_id, subscription_id, status, end_behavior, next_transition_at, created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			schedule.ID,
+			schedule.OrgID,
+			schedule.SubscriptionID,
+			schedule.Status,
+			schedule.EndBehavior,
+			schedule.NextTransitionAt,
+			schedule.CreatedAt,
+			schedule.UpdatedAt,
+		).Error; err != nil {
+			return err
+		}
+
+		for i := range phases {
+			phase := &phases[i]
+			phase.ID = s.genID.Generate()
+			phase.ScheduleID = schedule.ID
+			phase.CreatedAt = now
+			phase.UpdatedAt = now
+			if err := tx.WithContext(ctx).Exec(
+				`INSERT INTO subscription_schedule_phases (
+					id, org_id, schedule_id, position, status, start_at, end_at, iterations, items,
+					trial, coupon_id, proration_behavior, created_at, updated_at
+				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				phase.ID,
+				phase.OrgID,
+				phase.ScheduleID,
+				phase.Position,
+				phase.Status,
+				phase.StartAt,
+				phase.EndAt,
+				phase.Iterations,
+				phase.Items,
+				phase.Trial,
+				phase.CouponID,
+				phase.ProrationBehavior,
+				phase.CreatedAt,
+				phase.UpdatedAt,
+			).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return subscriptiondomain.SubscriptionSchedule{}, err
+	}
+
+	if !phases[0].StartAt.After(now) {
+		if err := s.advanceSchedule(ctx, orgID, schedule.ID, now); err != nil {
+			return subscriptiondomain.SubscriptionSchedule{}, err
+		}
+	}
+
+	return s.loadSchedule(ctx, s.db, orgID, schedule.ID)
+}
+
+// PreviewSchedule validates a schedule request and returns the phases it
+// would run without saving anything.
+func (s *Service) PreviewSchedule(ctx context.Context, req subscriptiondomain.CreateScheduleRequest) (subscriptiondomain.SchedulePreview, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return subscriptiondomain.SchedulePreview{}, subscriptiondomain.ErrInvalidOrganization
+	}
+
+	sub, endBehavior, phases, err := s.resolveSchedule(ctx, orgID, req, s.clock.Now().UTC())
+	if err != nil {
+		return subscriptiondomain.SchedulePreview{}, err
+	}
+
+	preview := subscriptiondomain.SchedulePreview{
+		SubscriptionID: sub.ID.String(),
+		EndBehavior:    endBehavior,
+		Phases:         make([]subscriptiondomain.SchedulePhasePreview, 0, len(phases)),
+	}
+	for _, phase := range phases {
+		var items []subscriptiondomain.PlanChangeItem
+		if err := json.Unmarshal(phase.Items, &items); err != nil {
+			return subscriptiondomain.SchedulePreview{}, err
+		}
+		amount, currency, err := s.recurringAmount(ctx, items, subscriptionCurrency(sub))
+		if err != nil {
+			return subscriptiondomain.SchedulePreview{}, err
+		}
+
+		entry := subscriptiondomain.SchedulePhasePreview{
+			Position:          phase.Position,
+			StartAt:           phase.StartAt,
+			EndAt:             phase.EndAt,
+			Items:             items,
+			Trial:             phase.Trial,
+			ProrationBehavior: phase.ProrationBehavior,
+			RecurringAmount:   amount,
+			Currency:          currency,
+		}
+		if phase.CouponID != nil {
+			couponID := phase.CouponID.String()
+			entry.CouponID = &couponID
+			if entry.DiscountAmount, err = s.couponDiscount(ctx, couponID, amount); err != nil {
+				return subscriptiondomain.SchedulePreview{}, err
+			}
+		}
+		preview.Phases = append(preview.Phases, entry)
+	}
+	return preview, nil
+}
+
+// GetSchedule returns a schedule with its phases.
+func (s *Service) GetSchedule(ctx context.Context, id string) (subscriptiondomain.SubscriptionSchedule, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return subscriptiondomain.SubscriptionSchedule{}, subscriptiondomain.ErrInvalidOrganization
+	}
+	scheduleID, err := s.parseID(id, subscriptiondomain.ErrInvalidSchedule)
+	if err != nil {
+		return subscriptiondomain.SubscriptionSchedule{}, err
+	}
+	return s.loadSchedule(ctx, s.db, orgID, scheduleID)
+}
+
+// CancelSchedule stops a schedule. Pending phases are dropped and the
+// subscription stays on the items, and any discount, of the current phase.
+func (s *Service) CancelSchedule(ctx context.Context, id string) (subscriptiondomain.SubscriptionSchedule, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return subscriptiondomain.SubscriptionSchedule{}, subscriptiondomain.ErrInvalidOrganization
+	}
+	scheduleID, err := s.parseID(id, subscriptiondomain.ErrInvalidSchedule)
+	if err != nil {
+		return subscriptiondomain.SubscriptionSchedule{}, err
+	}
+
+	now := s.clock.Now().UTC()
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		schedule, err := s.loadSchedule(ctx, tx, orgID, scheduleID)
+		if err != nil {
+			return err
+		}
+		if schedule.Status != subscriptiondomain.ScheduleStatusActive {
+			return subscriptiondomain.ErrScheduleNotActive
+		}
+		return s.endSchedule(ctx, tx, &schedule, subscriptiondomain.ScheduleStatusCanceled, now)
+	})
+	if err != nil {
+		return subscriptiondomain.SubscriptionSchedule{}, err
+	}
+	return s.loadSchedule(ctx, s.db, orgID, scheduleID)
+}
+
+// AdvanceSchedules moves due schedules to their next phase, and completes
+// schedules whose last phase has ended. Every phase takes effect at its
+// boundary rather than when the job runs, so billing does not depend on how
+// late the job is.
+func (s *Service) AdvanceSchedules(ctx context.Context, limit int) error {
+	if limit <= 0 {
+		limit = 100
+	}
+	now := s.clock.Now().UTC()
+
+	var due []subscriptiondomain.SubscriptionSchedule
+	if err := s.db.WithContext(ctx).Raw(
+		`SELECT * FROM subscription_schedules
+		 WHERE status = ? AND next_transition_at <= ?
+		 ORDER BY next_transition_at ASC, id ASC
+		 LIMIT ?`,
+		subscriptiondomain.ScheduleStatusActive,
+		now,
+		limit,
+	).Scan(&due).Error; err != nil {
+		return err
+	}
+
+	for _, schedule := range due {
+		orgCtx := orgcontext.WithOrgID(ctx, int64(schedule.OrgID))
+		if err := s.advanceSchedule(orgCtx, schedule.OrgID, schedule.ID, now); err != nil {
+			s.log.Warn("failed to advance subscription schedule",
+				zap.String("schedule_id", schedule.ID.String()),
+				zap.String("subscription_id", schedule.SubscriptionID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+	return nil
+}
+
+// scheduleFollowUp holds the work of a schedule step that runs outside its
+// transaction: subscriptions are canceled through their own transition.
+type scheduleFollowUp struct {
+	cancel bool
+}
+
+// advanceSchedule applies every transition of the schedule that is due.
+func (s *Service) advanceSchedule(ctx context.Context, orgID, scheduleID snowflake.ID, now time.Time) error {
+	for {
+		var (
+			followUp scheduleFollowUp
+			done     bool
+		)
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var err error
+			done, err = s.advanceScheduleStep(ctx, tx, orgID, scheduleID, now, &followUp)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		s.runScheduleFollowUp(ctx, scheduleID, followUp)
+		if done {
+			return nil
+		}
+	}
+}
+
+func (s *Service) advanceScheduleStep(
+	ctx context.Context,
+	tx *gorm.DB,
+	orgID, scheduleID snowflake.ID,
+	now time.Time,
+	followUp *scheduleFollowUp,
+) (bool, error) {
+	schedule, err := s.loadSchedule(ctx, tx, orgID, scheduleID)
+	if err != nil {
+		return true, err
+	}
+	if schedule.Status != subscriptiondomain.ScheduleStatusActive {
+		return true, nil
+	}
+
+	sub, err := s.repo.FindByIDForUpdate(ctx, tx, orgID, schedule.SubscriptionID)
+	if err != nil {
+		return true, err
+	}
+	if sub == nil || (sub.Status != subscriptiondomain.SubscriptionStatusActive && sub.Status != subscriptiondomain.SubscriptionStatusPastDue) {
+		return true, s.endSchedule(ctx, tx, &schedule, subscriptiondomain.ScheduleStatusCanceled, now)
+	}
+
+	current, next := -1, -1
+	for i, phase := range schedule.Phases {
+		switch phase.Status {
+		case subscriptiondomain.SchedulePhaseActive:
+			current = i
+		case subscriptiondomain.SchedulePhasePending:
+			if next < 0 {
+				next = i
+			}
+		}
+	}
+
+	switch {
+	case next >= 0 && !schedule.Phases[next].StartAt.After(now):
+		if current >= 0 {
+			if err := s.removePhaseDiscount(ctx, tx, &schedule.Phases[current], now); err != nil {
+				return true, err
+			}
+			if err := s.completePhase(ctx, tx, &schedule.Phases[current], now); err != nil {
+				return true, err
+			}
+		}
+		phase := &schedule.Phases[next]
+		if err := s.applySchedulePhase(ctx, tx, sub, phase, now); err != nil {
+			return true, err
+		}
+		if err := s.applyPhaseCoupon(ctx, tx, sub, phase, now); err != nil {
+			return true, err
+		}
+		if next == len(schedule.Phases)-1 && phase.EndAt == nil {
+			// An open-ended last phase releases the subscription.
+			return true, s.endSchedule(ctx, tx, &schedule, subscriptiondomain.ScheduleStatusCompleted, now)
+		}
+		return false, s.updateNextTransition(ctx, tx, &schedule, now)
+
+	case next < 0 && current >= 0 && schedule.Phases[current].EndAt != nil && !schedule.Phases[current].EndAt.After(now):
+		if err := s.removePhaseDiscount(ctx, tx, &schedule.Phases[current], now); err != nil {
+			return true, err
+		}
+		followUp.cancel = schedule.EndBehavior == subscriptiondomain.ScheduleEndCancel
+		return true, s.endSchedule(ctx, tx, &schedule, subscriptiondomain.ScheduleStatusCompleted, now)
+
+	default:
+		return true, s.updateNextTransition(ctx, tx, &schedule, now)
+	}
+}
+
+// applySchedulePhase moves the subscription onto the phase's items at the
+// phase start. The move is recorded as a plan change so rating prorates it
+// like any other mid-cycle change.
+func (s *Service) applySchedulePhase(
+	ctx context.Context,
+	tx *gorm.DB,
+	sub *subscriptiondomain.Subscription,
+	phase *subscriptiondomain.SubscriptionSchedulePhase,
+	now time.Time,
+) error {
+	at := phase.StartAt
+
+	var planned []subscriptiondomain.PlanChangeItem
+	if err := json.Unmarshal(phase.Items, &planned); err != nil {
+		return err
+	}
+	requested := make([]subscriptiondomain.CreateSubscriptionItemRequest, 0, len(planned))
+	for _, item := range planned {
+		request := subscriptiondomain.CreateSubscriptionItemRequest{
+			PriceID:  item.PriceID.String(),
+			Quantity: item.Quantity,
+		}
+		if item.BillingTiming != nil {
+			request.BillingTiming = *item.BillingTiming
+		}
+		requested = append(requested, request)
+	}
+	newItems, productIDs, err := s.buildPhaseItems(ctx, sub, requested, phase.ProrationBehavior, at)
+	if err != nil {
+		return err
+	}
+
+	currentItems, err := s.listItemsForUpdate(ctx, tx, sub.OrgID, sub.ID)
+	if err != nil {
+		return err
+	}
+
+	if !samePlanItems(planChangeItems(currentItems), planChangeItems(newItems)) {
+		previous := planChangeItems(currentItems)
+		currentAmount, currency, err := s.recurringAmount(ctx, previous, subscriptionCurrency(sub))
+		if err != nil {
+			return err
+		}
+		newAmount, newCurrency, err := s.recurringAmount(ctx, planChangeItems(newItems), subscriptionCurrency(sub))
+		if err != nil {
+			return err
+		}
+		if currency == "" {
+			currency = newCurrency
+		}
+
+		change := subscriptiondomain.SubscriptionPlanChange{
+			ID:                s.genID.Generate(),
+			OrgID:             sub.OrgID,
+			SubscriptionID:    sub.ID,
+			FromProductID:     s.currentProductID(ctx, currentItems),
+			ToProductID:       productIDs[0],
+			ToPriceID:         newItems[0].PriceID,
+			Quantity:          newItems[0].Quantity,
+			Direction:         subscriptiondomain.PlanChangeUpgrade,
+			ProrationBehavior: phase.ProrationBehavior,
+			EffectiveAt:       at,
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		}
+		if currency != "" {
+			change.Currency = &currency
+		}
+		if change.PreviousItems, err = json.Marshal(previous); err != nil {
+			return err
+		}
+		if newAmount < currentAmount {
+			change.Direction = subscriptiondomain.PlanChangeDowngrade
+		}
+
+		// The schedule owns the plan from here on.
+		if err := s.cancelScheduledPlanChanges(ctx, tx, sub.OrgID, sub.ID, now); err != nil {
+			return err
+		}
+		cycle, err := s.findOpenCycle(ctx, tx, sub.OrgID, sub.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.applyPlanChangeAt(ctx, tx, &change, cycle, newItems, productIDs, currentAmount, newAmount, at); err != nil {
+			return err
+		}
+	}
+
+	if phase.Trial {
+		if err := tx.WithContext(ctx).Exec(
+			`UPDATE subscriptions
+			 SET trial_starts_at = ?, trial_ends_at = ?, updated_at = ?
+			 WHERE org_id = ? AND id = ?`,
+			phase.StartAt,
+			phase.EndAt,
+			now,
+			sub.OrgID,
+			sub.ID,
+		).Error; err != nil {
+			return err
+		}
+	}
+
+	phase.Status = subscriptiondomain.SchedulePhaseActive
+	phase.AppliedAt = &now
+	phase.UpdatedAt = now
+	return tx.WithContext(ctx).Exec(
+		`UPDATE subscription_schedule_phases
+		 SET status = ?, applied_at = ?, updated_at = ?
+		 WHERE id = ?`,
+		phase.Status,
+		phase.AppliedAt,
+		phase.UpdatedAt,
+		phase.ID,
+	).Error
+}
+
+func (s *Service) runScheduleFollowUp(ctx context.Context, scheduleID snowflake.ID, followUp scheduleFollowUp) {
+	if followUp.cancel {
+		var subscriptionID snowflake.ID
+		if err := s.db.WithContext(ctx).Raw(
+			`SELECT subscription_id FROM subscription_schedules WHERE id = ?`,
+			scheduleID,
+		).Scan(&subscriptionID).Error; err == nil {
+			err = s.TransitionSubscription(ctx, subscriptionID.String(), subscriptiondomain.SubscriptionStatusCanceled, "schedule_completed")
+			if err != nil {
+				s.log.Warn("failed to cancel subscription at schedule end",
+					zap.String("schedule_id", scheduleID.String()),
+					zap.String("subscription_id", subscriptionID.String()),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+}
+
+// applyPhaseCoupon applies the phase's coupon to the subscription in the
+// phase transaction, so a failed write rolls the phase back and the next run
+// retries it. A coupon that can no longer be redeemed does not hold the plan
+// change back; the phase runs without its discount.
+func (s *Service) applyPhaseCoupon(
+	ctx context.Context,
+	tx *gorm.DB,
+	sub *subscriptiondomain.Subscription,
+	phase *subscriptiondomain.SubscriptionSchedulePhase,
+	now time.Time,
+) error {
+	if s.couponRepo == nil || phase.CouponID == nil {
+		return nil
+	}
+
+	skip := func(reason string) error {
+		s.log.Warn("schedule phase coupon not applied",
+			zap.String("phase_id", phase.ID.String()),
+			zap.String("coupon_id", phase.CouponID.String()),
+			zap.String("reason", reason),
+		)
+		return nil
+	}
+	coupon, err := s.couponRepo.FindCouponByID(ctx, tx, sub.OrgID, *phase.CouponID)
+	if err != nil {
+		return err
+	}
+	if coupon == nil {
+		return skip(coupondomain.ErrCouponNotFound.Error())
+	}
+	if !coupon.Redeemable(now) {
+		return skip(coupondomain.ErrCouponNotRedeemable.Error())
+	}
+	subscriptionID := sub.ID
+	applied, err := s.couponRepo.HasActiveDiscount(ctx, tx, sub.OrgID, coupon.ID, sub.CustomerID, &subscriptionID)
+	if err != nil {
+		return err
+	}
+	if applied {
+		return skip(coupondomain.ErrDiscountAlreadyApplied.Error())
+	}
+	ok, err := s.couponRepo.IncrementCouponRedemptions(ctx, tx, sub.OrgID, coupon.ID, now)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return skip(coupondomain.ErrCouponNotRedeemable.Error())
+	}
+
+	discount := &coupondomain.Discount{
+		ID:             s.genID.Generate(),
+		OrgID:          sub.OrgID,
+		CouponID:       coupon.ID,
+		CustomerID:     sub.CustomerID,
+		SubscriptionID: &subscriptionID,
+		Status:         coupondomain.DiscountStatusActive,
+		StartedAt:      now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.couponRepo.InsertDiscount(ctx, tx, discount); err != nil {
+		return err
+	}
+	phase.DiscountID = &discount.ID
+	return tx.WithContext(ctx).Exec(
+		`UPDATE subscription_schedule_phases
+		 SET discount_id = ?, updated_at = ?
+		 WHERE id = ?`,
+		discount.ID,
+		now,
+		phase.ID,
+	).Error
+}
+
+// removePhaseDiscount ends the discount an outgoing phase applied, in the same
+// transaction that completes the phase.
+func (s *Service) removePhaseDiscount(ctx context.Context, tx *gorm.DB, phase *subscriptiondomain.SubscriptionSchedulePhase, now time.Time) error {
+	if s.couponRepo == nil || phase.DiscountID == nil {
+		return nil
+	}
+	discount, err := s.couponRepo.FindDiscountByID(ctx, tx, phase.OrgID, *phase.DiscountID)
+	if err != nil {
+		return err
+	}
+	if discount == nil || discount.Status != coupondomain.DiscountStatusActive {
+		return nil
+	}
+	return s.couponRepo.EndDiscount(ctx, tx, discount.OrgID, discount.ID, coupondomain.DiscountStatusRemoved, now)
+}
+
+func (s *Service) completePhase(ctx context.Context, tx *gorm.DB, phase *subscriptiondomain.SubscriptionSchedulePhase, now time.Time) error {
+	phase.Status = subscriptiondomain.SchedulePhaseCompleted
+	phase.CompletedAt = &now
+	phase.UpdatedAt = now
+	return tx.WithContext(ctx).Exec(
+		`UPDATE subscription_schedule_phases
+		 SET status = ?, completed_at = ?, updated_at = ?
+		 WHERE id = ?`,
+		phase.Status,
+		phase.CompletedAt,
+		phase.UpdatedAt,
+		phase.ID,
+	).Error
+}
+
+// endSchedule closes a schedule as completed or canceled. The active phase is
+// completed and pending phases are canceled.
+func (s *Service) endSchedule(
+	ctx context.Context,
+	tx *gorm.DB,
+	schedule *subscriptiondomain.SubscriptionSchedule,
+	status subscriptiondomain.ScheduleStatus,
+	now time.Time,
+) error {
+	if status == subscriptiondomain.ScheduleStatusCompleted {
+		for i := range schedule.Phases {
+			if schedule.Phases[i].Status != subscriptiondomain.SchedulePhaseActive {
+				continue
+			}
+			if err := s.completePhase(ctx, tx, &schedule.Phases[i], now); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tx.WithContext(ctx).Exec(
+		`UPDATE subscription_schedule_phases
+		 SET status = ?, updated_at = ?
+		 WHERE schedule_id = ? AND status = ?`,
+		subscriptiondomain.SchedulePhaseCanceled,
+		now,
+		schedule.ID,
+		subscriptiondomain.SchedulePhasePending,
+	).Error; err != nil {
+		return err
+	}
+
+	column := "completed_at"
+	if status == subscriptiondomain.ScheduleStatusCanceled {
+		column = "canceled_at"
+	}
+	return tx.WithContext(ctx).Exec(
+		`UPDATE subscription_schedules
+		 SET status = ?, next_transition_at = NULL, `+column+` = ?, updated_at = ?
+		 WHERE id = ? AND status = ?`,
+		status,
+		now,
+		now,
+		schedule.ID,
+		subscriptiondomain.ScheduleStatusActive,
+	).Error
+}
+
+func (s *Service) updateNextTransition(ctx context.Context, tx *gorm.DB, schedule *subscriptiondomain.SubscriptionSchedule, now time.Time) error {
+	next := nextScheduleTransition(schedule.Phases)
+	return tx.WithContext(ctx).Exec(
+		`UPDATE subscription_schedules
+		 SET next_transition_at = ?, updated_at = ?
+		 WHERE id = ?`,
+		next,
+		now,
+		schedule.ID,
+	).Error
+}
+
+func (s *Service) loadSchedule(ctx context.Context, db *gorm.DB, orgID, scheduleID snowflake.ID) (subscriptiondomain.SubscriptionSchedule, error) {
+	var schedule subscriptiondomain.SubscriptionSchedule
+	if err := db.WithContext(ctx).Raw(
+		`SELECT * FROM subscription_schedules WHERE org_id = ? AND id = ?`,
+		orgID,
+		scheduleID,
+	).Scan(&schedule).Error; err != nil {
+		return subscriptiondomain.SubscriptionSchedule{}, err
+	}
+	if schedule.ID == 0 {
+		return subscriptiondomain.SubscriptionSchedule{}, subscriptiondomain.ErrScheduleNotFound
+	}
+
+	if err := db.WithContext(ctx).Raw(
+		`SELECT * FROM subscription_schedule_phases
+		 WHERE schedule_id = ?
+		 ORDER BY position ASC`,
+		scheduleID,
+	).Scan(&schedule.Phases).Error; err != nil {
+		return subscriptiondomain.SubscriptionSchedule{}, err
+	}
+	return schedule, nil
+}
+
+// resolveSchedule validates a schedule request and lays its phases out back
+// to back from the first start.
+func (s *Service) resolveSchedule(
+	ctx context.Context,
+	orgID snowflake.ID,
+	req subscriptiondomain.CreateScheduleRequest,
+	now time.Time,
+) (*subscriptiondomain.Subscription, subscriptiondomain.ScheduleEndBehavior, []subscriptiondomain.SubscriptionSchedulePhase, error) {
+	subscriptionID, err := s.parseID(req.SubscriptionID, subscriptiondomain.ErrInvalidSubscription)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	endBehavior, err := parseScheduleEndBehavior(req.EndBehavior)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if len(req.Phases) == 0 {
+		return nil, "", nil, subscriptiondomain.ErrInvalidSchedulePhases
+	}
+
+	sub, err := s.repo.FindByID(ctx, s.db, orgID, subscriptionID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if sub == nil {
+		return nil, "", nil, subscriptiondomain.ErrSubscriptionNotFound
+	}
+	if sub.Status != subscriptiondomain.SubscriptionStatusActive {
+		return nil, "", nil, subscriptiondomain.ErrInvalidSubscriptionStatus
+	}
+	spec, ok := billingcycledomain.ParseCycleType(sub.BillingCycleType)
+	if !ok {
+		return nil, "", nil, subscriptiondomain.ErrInvalidBillingCycleType
+	}
+
+	start := now
+	if first := req.Phases[0].StartAt; first != nil {
+		if first.Before(now) {
+			return nil, "", nil, subscriptiondomain.ErrInvalidStartAt
+		}
+		start = first.UTC()
+	}
+
+	trials := 0
+	phases := make([]subscriptiondomain.SubscriptionSchedulePhase, 0, len(req.Phases))
+	for i, request := range req.Phases {
+		if i > 0 && request.StartAt != nil {
+			return nil, "", nil, subscriptiondomain.ErrInvalidSchedulePhases
+		}
+		if request.Trial {
+			// The subscription keeps a single trial window.
+			if trials++; trials > 1 {
+				return nil, "", nil, subscriptiondomain.ErrInvalidSchedulePhases
+			}
+		}
+
+		end, err := schedulePhaseEnd(spec, start, request, i == len(req.Phases)-1)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		behavior, err := parseProrationBehavior(request.ProrationBehavior)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if behavior == "" {
+			behavior = string(pricedomain.CreateProration)
+		}
+
+		items, _, err := s.buildPhaseItems(ctx, sub, request.Items, behavior, start)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		encoded, err := json.Marshal(planChangeItems(items))
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		phase := subscriptiondomain.SubscriptionSchedulePhase{
+			OrgID:             orgID,
+			Position:          int16(i),
+			Status:            subscriptiondomain.SchedulePhasePending,
+			StartAt:           start,
+			EndAt:             end,
+			Iterations:        request.Iterations,
+			Items:             encoded,
+			Trial:             request.Trial,
+			ProrationBehavior: behavior,
+		}
+		if phase.CouponID, err = s.resolvePhaseCoupon(ctx, request.CouponID); err != nil {
+			return nil, "", nil, err
+		}
+		phases = append(phases, phase)
+
+		if end != nil {
+			start = *end
+		}
+	}
+
+	return sub, endBehavior, phases, nil
+}
+
+// buildPhaseItems builds subscription items for a phase. Phases must bill on
+// the subscription's cycle, like plan changes.
+func (s *Service) buildPhaseItems(
+	ctx context.Context,
+	sub *subscriptiondomain.Subscription,
+	requested []subscriptiondomain.CreateSubscriptionItemRequest,
+	behavior string,
+	at time.Time,
+) ([]subscriptiondomain.SubscriptionItem, []snowflake.ID, error) {
+	if len(requested) == 0 {
+		return nil, nil, subscriptiondomain.ErrInvalidItems
+	}
+	price, err := s.loadPrice(ctx, requested[0].PriceID, map[string]*pricedomain.Response{})
+	if err != nil {
+		return nil, nil, err
+	}
+	cycleType, err := billingCycleTypeForInterval(price.BillingInterval, price.BillingIntervalCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ensureSameCycle(sub, cycleType); err != nil {
+		return nil, nil, err
+	}
+
+	items, productIDs, err := s.buildSubscriptionItems(ctx, sub.OrgID, sub.ID, requested, cycleType, subscriptionCurrency(sub), at)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range items {
+		value := behavior
+		items[i].ProrationBehavior = &value
+	}
+	return items, productIDs, nil
+}
+
+func (s *Service) resolvePhaseCoupon(ctx context.Context, raw string) (*snowflake.ID, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	couponID, err := snowflake.ParseString(raw)
+	if err != nil {
+		return nil, subscriptiondomain.ErrInvalidCoupon
+	}
+	if s.couponsvc == nil {
+		return nil, subscriptiondomain.ErrInvalidCoupon
+	}
+	coupon, err := s.couponsvc.GetCoupon(ctx, raw)
+	if errors.Is(err, coupondomain.ErrCouponNotFound) {
+		return nil, subscriptiondomain.ErrInvalidCoupon
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !coupon.Active {
+		return nil, subscriptiondomain.ErrInvalidCoupon
+	}
+	return &couponID, nil
+}
+
+// couponDiscount estimates what a coupon takes off one cycle of amount.
+func (s *Service) couponDiscount(ctx context.Context, couponID string, amount int64) (int64, error) {
+	coupon, err := s.couponsvc.GetCoupon(ctx, couponID)
+	if err != nil {
+		return 0, err
+	}
+	return coupondomain.Coupon{
+		DiscountType: coupondomain.DiscountType(coupon.DiscountType),
+		PercentOff:   coupon.PercentOff,
+		AmountOff:    coupon.AmountOff,
+	}.DiscountFor(amount), nil
+}
+
+// schedulePhaseEnd resolves the end condition of a phase. Only the last phase
+// may run open-ended, and a trial phase always needs an end.
+func schedulePhaseEnd(
+	spec billingcycledomain.CycleSpec,
+	start time.Time,
+	req subscriptiondomain.SchedulePhaseRequest,
+	last bool,
+) (*time.Time, error) {
+	switch {
+	case req.EndAt != nil && req.Iterations != nil:
+		return nil, subscriptiondomain.ErrInvalidPhaseEnd
+	case req.EndAt != nil:
+		end := req.EndAt.UTC()
+		if !end.After(start) {
+			return nil, subscriptiondomain.ErrInvalidPhaseEnd
+		}
+		return &end, nil
+	case req.Iterations != nil:
+		if *req.Iterations <= 0 {
+			return nil, subscriptiondomain.ErrInvalidPhaseEnd
+		}
+		end := spec.Boundary(start, *req.Iterations)
+		return &end, nil
+	case !last || req.Trial:
+		return nil, subscriptiondomain.ErrInvalidPhaseEnd
+	}
+	return nil, nil
+}
+
+// nextScheduleTransition returns the start of the first pending phase, or the
+// end of the active phase when none is left.
+func nextScheduleTransition(phases []subscriptiondomain.SubscriptionSchedulePhase) *time.Time {
+	var activeEnd *time.Time
+	for _, phase := range phases {
+		switch phase.Status {
+		case subscriptiondomain.SchedulePhasePending:
+			start := phase.StartAt
+			return &start
+		case subscriptiondomain.SchedulePhaseActive:
+			activeEnd = phase.EndAt
+		}
+	}
+	return activeEnd
+}
+
+func parseScheduleEndBehavior(value string) (subscriptiondomain.ScheduleEndBehavior, error) {
+	switch subscriptiondomain.ScheduleEndBehavior(strings.ToUpper(strings.TrimSpace(value))) {
+	case "", subscriptiondomain.ScheduleEndRelease:
+		return subscriptiondomain.ScheduleEndRelease, nil
+	case subscriptiondomain.ScheduleEndCancel:
+		return subscriptiondomain.ScheduleEndCancel, nil
+	default:
+		return "", subscriptiondomain.ErrInvalidEndBehavior
+	}
+}
+
+// samePlanItems reports whether two item sets bill the same prices and
+// quantities, regardless of order.
+func samePlanItems(a, b []subscriptiondomain.PlanChangeItem) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	key := func(items []subscriptiondomain.PlanChangeItem) []subscriptiondomain.PlanChangeItem {
+		sorted := append([]subscriptiondomain.PlanChangeItem(nil), items...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].PriceID < sorted[j].PriceID })
+		return sorted
+	}
+	left, right := key(a), key(b)
+	for i := range left {
+		if left[i].PriceID != right[i].PriceID ||
+			normalizeSubscriptionQuantity(left[i].Quantity) != normalizeSubscriptionQuantity(right[i].Quantity) {
+			return false
+		}
+	}
+	return true
+}
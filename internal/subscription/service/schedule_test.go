@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	"github.com/smallbiznis/railzway/internal/clock"
+	coupondomain "github.com/smallbiznis/railzway/internal/coupon/domain"
+	couponrepository "github.com/smallbiznis/railzway/internal/coupon/repository"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	productfeaturedomain "github.com/smallbiznis/railzway/internal/productfeature/domain"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// couponStub serves the coupons a schedule validates and previews.
+type couponStub struct {
+	coupondomain.Service
+	coupons map[string]coupondomain.CouponResponse
+}
+
+func (m *couponStub) GetCoupon(ctx context.Context, id string) (*coupondomain.CouponResponse, error) {
+	coupon, ok := m.coupons[id]
+	if !ok {
+		return nil, coupondomain.ErrCouponNotFound
+	}
+	return &coupon, nil
+}
+
+type scheduleFixture struct {
+	db       *gorm.DB
+	svc      subscriptiondomain.Service
+	clock    *clock.FakeClock
+	ctx      context.Context
+	subID    snowflake.ID
+	prices   map[string]snowflake.ID
+	couponID string
+}
+
+func setupScheduleTest(t *testing.T) scheduleFixture {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&coupondomain.Coupon{}, &coupondomain.Discount{}))
+	node, _ := snowflake.NewNode(1)
+	orgID := node.Generate()
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	priceSvc := &mockPriceService{}
+	features := &mockProductFeatureRepo{}
+	amounts := &flatPriceAmounts{amounts: map[string]int64{}}
+	prices := map[string]snowflake.ID{}
+	for name, amount := range map[string]int64{"lite": 5000, "basic": 10000, "pro": 15000} {
+		productID, priceID := node.Generate(), node.Generate()
+		prices[name] = priceID
+		amounts.amounts[priceID.String()] = amount
+		priceSvc.prices = append(priceSvc.prices, pricedomain.Response{
+			ID:              priceID,
+			OrganizationID:  orgID,
+			ProductID:       productID,
+			BillingInterval: pricedomain.Month,
+			Active:          true,
+			IsDefault:       true,
+			PricingModel:    pricedomain.Flat,
+			BillingMode:     pricedomain.Licensed,
+		})
+		features.features = append(features.features, productfeaturedomain.FeatureAssignment{
+			FeatureID: node.Generate(), ProductID: productID, Code: name, FeatureType: "boolean", Active: true,
+		})
+	}
+
+	percentOff := 20.0
+	coupon := coupondomain.Coupon{
+		ID: node.Generate(), OrgID: orgID, Code: "PHASE20", Name: "Phase 20", DiscountType: coupondomain.DiscountTypePercent,
+		PercentOff: &percentOff, Duration: coupondomain.DurationForever, Active: true,
+	}
+	require.NoError(t, db.Create(&coupon).Error)
+	couponID := coupon.ID.String()
+	coupons := &couponStub{
+		coupons: map[string]coupondomain.CouponResponse{
+			couponID: {ID: couponID, DiscountType: "percent", PercentOff: &percentOff, Active: true},
+		},
+	}
+
+	cycleStart := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(cycleStart)
+	repo := &mockRepository{subscriptions: make(map[string]*subscriptiondomain.Subscription)}
+	svc := NewService(ServiceParam{
+		DB:                 db,
+		Log:                zap.NewNop(),
+		GenID:              node,
+		Clock:              fakeClock,
+		Repo:               repo,
+		Pricesvc:           priceSvc,
+		PriceAmountsvc:     amounts,
+		ProductFeatureRepo: features,
+		Couponsvc:          coupons,
+		CouponRepo:         couponrepository.Provide(),
+	})
+
+	subID := node.Generate()
+	require.NoError(t, repo.Insert(ctx, db, &subscriptiondomain.Subscription{
+		ID:               subID,
+		OrgID:            orgID,
+		CustomerID:       node.Generate(),
+		Status:           subscriptiondomain.SubscriptionStatusActive,
+		StartAt:          cycleStart,
+		BillingCycleType: "monthly",
+	}))
+	require.NoError(t, repo.InsertItems(ctx, db, []subscriptiondomain.SubscriptionItem{{
+		ID: node.Generate(), OrgID: orgID, SubscriptionID: subID, PriceID: prices["basic"], Quantity: 1, BillingMode: "LICENSED",
+	}}))
+	require.NoError(t, db.Create(&billingcycledomain.BillingCycle{
+		ID: node.Generate(), OrgID: orgID, SubscriptionID: subID, PeriodStart: cycleStart, PeriodEnd: cycleStart.AddDate(0, 1, 0),
+		Status: billingcycledomain.BillingCycleStatusOpen,
+	}).Error)
+
+	return scheduleFixture{
+		db:       db,
+		svc:      svc,
+		clock:    fakeClock,
+		ctx:      ctx,
+		subID:    subID,
+		prices:   prices,
+		couponID: couponID,
+	}
+}
+
+func (f scheduleFixture) currentPrice(t *testing.T) snowflake.ID {
+	var items []subscriptiondomain.SubscriptionItem
+	require.NoError(t, f.db.Where("subscription_id = ?", f.subID).Find(&items).Error)
+	require.Len(t, items, 1)
+	return items[0].PriceID
+}
+
+func (f scheduleFixture) setNow(at time.Time) {
+	f.clock.Advance(at.Sub(f.clock.Now()))
+}
+
+func (f scheduleFixture) phase(price string, iterations int) subscriptiondomain.SchedulePhaseRequest {
+	phase := subscriptiondomain.SchedulePhaseRequest{
+		Items: []subscriptiondomain.CreateSubscriptionItemRequest{{PriceID: f.prices[price].String(), Quantity: 1}},
+	}
+	if iterations > 0 {
+		phase.Iterations = &iterations
+	}
+	return phase
+}
+
+func TestSubscriptionSchedulePhases(t *testing.T) {
+	f := setupScheduleTest(t)
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	discounted := f.phase("lite", 3)
+	discounted.CouponID = f.couponID
+	req := subscriptiondomain.CreateScheduleRequest{
+		SubscriptionID: f.subID.String(),
+		Phases:         []subscriptiondomain.SchedulePhaseRequest{discounted, f.phase("basic", 2), f.phase("pro", 0)},
+	}
+
+	preview, err := f.svc.PreviewSchedule(f.ctx, req)
+	require.NoError(t, err)
+	require.Len(t, preview.Phases, 3)
+	assert.Equal(t, subscriptiondomain.ScheduleEndRelease, preview.EndBehavior)
+	assert.True(t, jan.Equal(preview.Phases[0].StartAt))
+	assert.True(t, jan.AddDate(0, 3, 0).Equal(*preview.Phases[0].EndAt))
+	assert.Equal(t, int64(5000), preview.Phases[0].RecurringAmount)
+	assert.Equal(t, int64(1000), preview.Phases[0].DiscountAmount)
+	assert.True(t, jan.AddDate(0, 5, 0).Equal(preview.Phases[2].StartAt))
+	assert.Nil(t, preview.Phases[2].EndAt)
+	assert.Equal(t, int64(15000), preview.Phases[2].RecurringAmount)
+	var discounts int64
+	require.NoError(t, f.db.Model(&coupondomain.Discount{}).Count(&discounts).Error)
+	assert.Zero(t, discounts, "preview has no side effects")
+
+	schedule, err := f.svc.CreateSchedule(f.ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, subscriptiondomain.ScheduleStatusActive, schedule.Status)
+	require.Len(t, schedule.Phases, 3)
+	assert.Equal(t, subscriptiondomain.SchedulePhaseActive, schedule.Phases[0].Status)
+	require.NotNil(t, schedule.Phases[0].DiscountID)
+	require.NotNil(t, schedule.NextTransitionAt)
+	assert.True(t, jan.AddDate(0, 3, 0).Equal(*schedule.NextTransitionAt))
+	assert.Equal(t, f.prices["lite"], f.currentPrice(t))
+	var discount coupondomain.Discount
+	require.NoError(t, f.db.First(&discount, "id = ?", *schedule.Phases[0].DiscountID).Error)
+	require.NotNil(t, discount.SubscriptionID)
+	assert.Equal(t, f.subID, *discount.SubscriptionID)
+	assert.Equal(t, coupondomain.DiscountStatusActive, discount.Status)
+
+	_, err = f.svc.CreateSchedule(f.ctx, req)
+	assert.ErrorIs(t, err, subscriptiondomain.ErrScheduleAlreadyExists)
+
+	f.clock.Advance(40 * 24 * time.Hour)
+	require.NoError(t, f.svc.AdvanceSchedules(context.Background(), 10))
+	assert.Equal(t, f.prices["lite"], f.currentPrice(t), "first phase runs three cycles")
+
+	f.setNow(jan.AddDate(0, 3, 0).Add(time.Hour))
+	require.NoError(t, f.svc.AdvanceSchedules(context.Background(), 10))
+	assert.Equal(t, f.prices["basic"], f.currentPrice(t))
+	require.NoError(t, f.db.First(&discount, "id = ?", *schedule.Phases[0].DiscountID).Error)
+	assert.Equal(t, coupondomain.DiscountStatusRemoved, discount.Status, "the phase discount ends with the phase")
+
+	var change subscriptiondomain.SubscriptionPlanChange
+	require.NoError(t, f.db.Where("subscription_id = ? AND to_price_id = ?", f.subID, f.prices["basic"]).First(&change).Error)
+	assert.True(t, jan.AddDate(0, 3, 0).Equal(change.EffectiveAt), "phase takes effect on its boundary")
+	assert.Equal(t, subscriptiondomain.PlanChangeUpgrade, change.Direction)
+
+	// A late job applies every phase that came due, each at its own start.
+	f.setNow(jan.AddDate(0, 6, 0))
+	require.NoError(t, f.svc.AdvanceSchedules(context.Background(), 10))
+	assert.Equal(t, f.prices["pro"], f.currentPrice(t))
+
+	schedule, err = f.svc.GetSchedule(f.ctx, schedule.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, subscriptiondomain.ScheduleStatusCompleted, schedule.Status)
+	assert.Nil(t, schedule.NextTransitionAt)
+	assert.Equal(t, subscriptiondomain.SchedulePhaseCompleted, schedule.Phases[1].Status)
+	assert.Equal(t, subscriptiondomain.SchedulePhaseCompleted, schedule.Phases[2].Status, "released with the subscription on the last phase")
+}
+
+func TestSubscriptionScheduleValidationAndCancel(t *testing.T) {
+	f := setupScheduleTest(t)
+	start := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	openEnded := f.phase("lite", 0)
+	_, err := f.svc.PreviewSchedule(f.ctx, subscriptiondomain.CreateScheduleRequest{
+		SubscriptionID: f.subID.String(),
+		Phases:         []subscriptiondomain.SchedulePhaseRequest{openEnded, f.phase("pro", 0)},
+	})
+	assert.ErrorIs(t, err, subscriptiondomain.ErrInvalidPhaseEnd)
+
+	both := f.phase("lite", 1)
+	both.EndAt = &start
+	_, err = f.svc.PreviewSchedule(f.ctx, subscriptiondomain.CreateScheduleRequest{
+		SubscriptionID: f.subID.String(),
+		Phases:         []subscriptiondomain.SchedulePhaseRequest{both},
+	})
+	assert.ErrorIs(t, err, subscriptiondomain.ErrInvalidPhaseEnd)
+
+	second := f.phase("pro", 0)
+	second.StartAt = &start
+	_, err = f.svc.PreviewSchedule(f.ctx, subscriptiondomain.CreateScheduleRequest{
+		SubscriptionID: f.subID.String(),
+		Phases:         []subscriptiondomain.SchedulePhaseRequest{f.phase("lite", 1), second},
+	})
+	assert.ErrorIs(t, err, subscriptiondomain.ErrInvalidSchedulePhases)
+
+	unknownCoupon := f.phase("lite", 1)
+	unknownCoupon.CouponID = "123"
+	_, err = f.svc.PreviewSchedule(f.ctx, subscriptiondomain.CreateScheduleRequest{
+		SubscriptionID: f.subID.String(),
+		Phases:         []subscriptiondomain.SchedulePhaseRequest{unknownCoupon},
+	})
+	assert.ErrorIs(t, err, subscriptiondomain.ErrInvalidCoupon)
+
+	// A trial phase starting next month sets the subscription's trial window.
+	trial := f.phase("pro", 1)
+	trial.StartAt = &start
+	trial.Trial = true
+	schedule, err := f.svc.CreateSchedule(f.ctx, subscriptiondomain.CreateScheduleRequest{
+		SubscriptionID: f.subID.String(),
+		EndBehavior:    "cancel",
+		Phases:         []subscriptiondomain.SchedulePhaseRequest{trial, f.phase("basic", 1)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, subscriptiondomain.ScheduleEndCancel, schedule.EndBehavior)
+	assert.Equal(t, subscriptiondomain.SchedulePhasePending, schedule.Phases[0].Status)
+	assert.Equal(t, f.prices["basic"], f.currentPrice(t))
+
+	f.setNow(start)
+	require.NoError(t, f.svc.AdvanceSchedules(context.Background(), 10))
+	assert.Equal(t, f.prices["pro"], f.currentPrice(t))
+	var sub subscriptiondomain.Subscription
+	require.NoError(t, f.db.First(&sub, "id = ?", f.subID).Error)
+	require.NotNil(t, sub.TrialEndsAt)
+	assert.True(t, start.AddDate(0, 1, 0).Equal(*sub.TrialEndsAt))
+
+	canceled, err := f.svc.CancelSchedule(f.ctx, schedule.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, subscriptiondomain.ScheduleStatusCanceled, canceled.Status)
+	assert.NotNil(t, canceled.CanceledAt)
+	assert.Equal(t, subscriptiondomain.SchedulePhaseCanceled, canceled.Phases[1].Status)
+
+	_, err = f.svc.CancelSchedule(f.ctx, schedule.ID.String())
+	assert.ErrorIs(t, err, subscriptiondomain.ErrScheduleNotActive)
+
+	f.setNow(start.AddDate(0, 3, 0))
+	require.NoError(t, f.svc.AdvanceSchedules(context.Background(), 10))
+	assert.Equal(t, f.prices["pro"], f.currentPrice(t), "canceled schedule leaves the subscription on its current phase")
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/bwmarrin/snowflake"
 	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
 	"github.com/smallbiznis/railzway/internal/clock"
+	coupondomain "github.com/smallbiznis/railzway/internal/coupon/domain"
 	invoicedomain "github.com/smallbiznis/railzway/internal/invoice/domain"
 	"github.com/smallbiznis/railzway/internal/orgcontext"
 	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
@@ -36,6 +37,8 @@ type Service struct {
 	pricesvc           pricedomain.Service
 	priceamountsvc     priceamount.Service
 	productFeatureRepo productfeaturedomain.Repository
+	couponsvc          coupondomain.Service
+	couponRepo         coupondomain.Repository
 }
 
 type ServiceParam struct {
@@ -50,6 +53,8 @@ type ServiceParam struct {
 	Pricesvc           pricedomain.Service
 	PriceAmountsvc     priceamount.Service
 	ProductFeatureRepo productfeaturedomain.Repository
+	Couponsvc          coupondomain.Service    `optional:"true"`
+	CouponRepo         coupondomain.Repository `optional:"true"`
 }
 
 func NewService(p ServiceParam) subscriptiondomain.Service {
@@ -66,6 +71,8 @@ func NewService(p ServiceParam) subscriptiondomain.Service {
 		pricesvc:           p.Pricesvc,
 		priceamountsvc:     p.PriceAmountsvc,
 		productFeatureRepo: p.ProductFeatureRepo,
+		couponsvc:          p.Couponsvc,
+		couponRepo:         p.CouponRepo,
 	}
 }
 
@@ -83,17 +90,37 @@ func (s *Service) GetActiveByCustomerID(ctx context.Context, req subscriptiondom
 
 	statuses := []subscriptiondomain.SubscriptionStatus{
 		subscriptiondomain.SubscriptionStatusActive,
+		subscriptiondomain.SubscriptionStatusPastDue,
 	}
 
-	item, err := s.repo.FindActiveByCustomerID(ctx, s.db, orgID, customerID, statuses)
+	var subscriptionID snowflake.ID
+	if strings.TrimSpace(req.SubscriptionID) != "" {
+		subscriptionID, err = s.parseID(req.SubscriptionID, subscriptiondomain.ErrInvalidSubscription)
+		if err != nil {
+			return subscriptiondomain.Subscription{}, err
+		}
+	}
+
+	subscriptions, err := s.repo.ListActiveByCustomerID(ctx, s.db, orgID, customerID, statuses)
 	if err != nil {
 		return subscriptiondomain.Subscription{}, err
 	}
-	if item == nil {
-		return subscriptiondomain.Subscription{}, subscriptiondomain.ErrSubscriptionNotFound
+
+	meterCode := strings.TrimSpace(req.MeterCode)
+	candidates := make([]subscriptiondomain.UsageRouteCandidate, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		candidate := subscriptiondomain.UsageRouteCandidate{Subscription: subscription}
+		if meterCode != "" && subscriptionID == 0 && len(subscriptions) > 1 {
+			item, err := s.repo.FindSubscriptionItemByMeterCode(ctx, s.db, orgID, subscription.ID, meterCode)
+			if err != nil {
+				return subscriptiondomain.Subscription{}, err
+			}
+			candidate.HasMeter = item != nil
+		}
+		candidates = append(candidates, candidate)
 	}
 
-	return *item, nil
+	return subscriptiondomain.RouteUsage(candidates, subscriptionID)
 }
 
 // GetSubscriptionItem implements domain.Service.
@@ -249,6 +276,11 @@ func (s *Service) Create(ctx context.Context, req subscriptiondomain.CreateSubsc
 		return subscriptiondomain.CreateSubscriptionResponse{}, err
 	}
 
+	billingAnchorDay, err := normalizeBillingAnchorDay(req.BillingAnchorDay, billingCycleType)
+	if err != nil {
+		return subscriptiondomain.CreateSubscriptionResponse{}, err
+	}
+
 	if len(req.Items) == 0 {
 		return subscriptiondomain.CreateSubscriptionResponse{}, subscriptiondomain.ErrInvalidItems
 	}
@@ -258,6 +290,11 @@ func (s *Service) Create(ctx context.Context, req subscriptiondomain.CreateSubsc
 		return subscriptiondomain.CreateSubscriptionResponse{}, err
 	}
 
+	currency, err := s.resolveSubscriptionCurrency(ctx, orgID, customerID, req.DefaultCurrency, req.Items)
+	if err != nil {
+		return subscriptiondomain.CreateSubscriptionResponse{}, err
+	}
+
 	now := s.clock.Now()
 	subscription := subscriptiondomain.Subscription{
 		ID:               s.genID.Generate(),
@@ -266,15 +303,19 @@ func (s *Service) Create(ctx context.Context, req subscriptiondomain.CreateSubsc
 		Status:           subscriptiondomain.SubscriptionStatusDraft,
 		CollectionMode:   collectionMode,
 		StartAt:          now,
+		BillingAnchorDay: billingAnchorDay,
 		BillingCycleType: billingCycleType,
 		CreatedAt:        now,
 		UpdatedAt:        now,
 	}
+	if currency != "" {
+		subscription.DefaultCurrency = &currency
+	}
 	if req.Metadata != nil {
 		subscription.Metadata = datatypes.JSONMap(req.Metadata)
 	}
 
-	subscriptionItems, productIDs, err := s.buildSubscriptionItems(ctx, orgID, subscription.ID, req.Items, billingCycleType, now)
+	subscriptionItems, productIDs, err := s.buildSubscriptionItems(ctx, orgID, subscription.ID, req.Items, billingCycleType, currency, now)
 	if err != nil {
 		return subscriptiondomain.CreateSubscriptionResponse{}, err
 	}
@@ -335,7 +376,7 @@ func (s *Service) ReplaceItems(ctx context.Context, req subscriptiondomain.Repla
 	}
 
 	now := time.Now().UTC()
-	subscriptionItems, productIDs, err := s.buildSubscriptionItems(ctx, orgID, subscriptionID, req.Items, subscription.BillingCycleType, now)
+	subscriptionItems, productIDs, err := s.buildSubscriptionItems(ctx, orgID, subscriptionID, req.Items, subscription.BillingCycleType, subscriptionCurrency(subscription), now)
 	if err != nil {
 		return subscriptiondomain.CreateSubscriptionResponse{}, err
 	}
@@ -456,6 +497,8 @@ func (s *Service) TransitionSubscription(
 			}
 		case subscriptiondomain.SubscriptionStatusPaused:
 			subscription.PausedAt = &now
+		case subscriptiondomain.SubscriptionStatusPastDue:
+			// Billing continues while past due; dunning owns the way back to active.
 		case subscriptiondomain.SubscriptionStatusCanceled:
 			subscription.CanceledAt = &now
 		case subscriptiondomain.SubscriptionStatusEnded:
@@ -698,6 +741,7 @@ func isValidStatus(status subscriptiondomain.SubscriptionStatus) bool {
 	case subscriptiondomain.SubscriptionStatusDraft,
 		subscriptiondomain.SubscriptionStatusActive,
 		subscriptiondomain.SubscriptionStatusPaused,
+		subscriptiondomain.SubscriptionStatusPastDue,
 		subscriptiondomain.SubscriptionStatusCanceled,
 		subscriptiondomain.SubscriptionStatusEnded:
 		return true
@@ -711,7 +755,13 @@ func isTransitionAllowed(current, target subscriptiondomain.SubscriptionStatus)
 	case subscriptiondomain.SubscriptionStatusDraft:
 		return target == subscriptiondomain.SubscriptionStatusActive
 	case subscriptiondomain.SubscriptionStatusActive:
-		return target == subscriptiondomain.SubscriptionStatusPaused || target == subscriptiondomain.SubscriptionStatusCanceled
+		return target == subscriptiondomain.SubscriptionStatusPaused ||
+			target == subscriptiondomain.SubscriptionStatusPastDue ||
+			target == subscriptiondomain.SubscriptionStatusCanceled
+	case subscriptiondomain.SubscriptionStatusPastDue:
+		return target == subscriptiondomain.SubscriptionStatusActive ||
+			target == subscriptiondomain.SubscriptionStatusPaused ||
+			target == subscriptiondomain.SubscriptionStatusCanceled
 	case subscriptiondomain.SubscriptionStatusPaused:
 		return target == subscriptiondomain.SubscriptionStatusActive || target == subscriptiondomain.SubscriptionStatusCanceled
 	case subscriptiondomain.SubscriptionStatusCanceled:
@@ -733,6 +783,7 @@ func parseStatusFilter(value string) (*subscriptiondomain.SubscriptionStatus, er
 		subscriptiondomain.SubscriptionStatusActive,
 		subscriptiondomain.SubscriptionStatusCanceled,
 		subscriptiondomain.SubscriptionStatusPaused,
+		subscriptiondomain.SubscriptionStatusPastDue,
 		subscriptiondomain.SubscriptionStatusEnded:
 		parsed := subscriptiondomain.SubscriptionStatus(status)
 		return &parsed, nil
@@ -752,6 +803,7 @@ func parseStatus(value string) (subscriptiondomain.SubscriptionStatus, error) {
 		subscriptiondomain.SubscriptionStatusActive,
 		subscriptiondomain.SubscriptionStatusCanceled,
 		subscriptiondomain.SubscriptionStatusPaused,
+		subscriptiondomain.SubscriptionStatusPastDue,
 		subscriptiondomain.SubscriptionStatusEnded:
 		return subscriptiondomain.SubscriptionStatus(status), nil
 	default:
@@ -775,17 +827,25 @@ func parseCollectionMode(value string) (subscriptiondomain.SubscriptionCollectio
 }
 
 func normalizeBillingCycleType(value string) (string, error) {
-	cycle := strings.ToUpper(strings.TrimSpace(value))
-	switch cycle {
-	case "MONTHLY":
-		return "monthly", nil
-	case "WEEKLY":
-		return "weekly", nil
-	case "DAILY":
-		return "daily", nil
-	default:
+	spec, ok := billingcycledomain.ParseCycleType(value)
+	if !ok {
 		return "", subscriptiondomain.ErrInvalidBillingCycleType
 	}
+	return spec.String(), nil
+}
+
+// normalizeBillingAnchorDay validates the day of month cycles are pinned to.
+// Anchors only apply to month and year based cycles.
+func normalizeBillingAnchorDay(value *int16, cycleType string) (*int16, error) {
+	if value == nil {
+		return nil, nil
+	}
+	spec, ok := billingcycledomain.ParseCycleType(cycleType)
+	if !ok || !spec.SupportsAnchorDay() || !billingcycledomain.ValidAnchorDay(int(*value)) {
+		return nil, subscriptiondomain.ErrInvalidBillingAnchorDay
+	}
+	day := *value
+	return &day, nil
 }
 
 func (s *Service) buildSubscriptionItems(
@@ -794,6 +854,7 @@ func (s *Service) buildSubscriptionItems(
 	subscriptionID snowflake.ID,
 	items []subscriptiondomain.CreateSubscriptionItemRequest,
 	expectedCycleType string,
+	currency string,
 	now time.Time,
 ) ([]subscriptiondomain.SubscriptionItem, []snowflake.ID, error) {
 	priceCache := make(map[string]*pricedomain.Response, len(items))
@@ -826,12 +887,17 @@ func (s *Service) buildSubscriptionItems(
 			return nil, nil, err
 		}
 
+		billingTiming, err := parseItemBillingTiming(price, item.BillingTiming)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		parsedPriceID, err := s.parseID(price.ID.String(), subscriptiondomain.ErrInvalidPrice)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		cycleType, err := billingCycleTypeForInterval(price.BillingInterval)
+		cycleType, err := billingCycleTypeForInterval(price.BillingInterval, price.BillingIntervalCount)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -839,15 +905,24 @@ func (s *Service) buildSubscriptionItems(
 			return nil, nil, subscriptiondomain.ErrInvalidBillingCycleType
 		}
 
+		var priceAmounts []priceamount.Response
+		if currency != "" || price.PricingModel != pricedomain.Flat {
+			priceAmounts, err = s.loadPriceAmount(ctx, price.ID.String(), currency)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		// The subscription bills in one currency, so every price needs an
+		// amount in it.
+		if currency != "" && len(priceAmounts) == 0 {
+			return nil, nil, subscriptiondomain.ErrCurrencyMismatch
+		}
+
 		var (
 			meterID   *snowflake.ID
 			meterCode *string
 		)
 		if price.PricingModel != pricedomain.Flat {
-			priceAmounts, err := s.loadPriceAmount(ctx, price.ID.String())
-			if err != nil {
-				return nil, nil, err
-			}
 
 			if priceAmounts[0].MeterID == nil {
 				// Flat price → no meter
@@ -883,6 +958,7 @@ func (s *Service) buildSubscriptionItems(
 			Quantity:         quantity,
 			BillingMode:      string(price.BillingMode), // snapshot
 			BillingThreshold: price.BillingThreshold,    // snapshot
+			BillingTiming:    billingTiming,
 			CreatedAt:        now,
 			UpdatedAt:        now,
 		})
@@ -976,6 +1052,8 @@ func (s *Service) buildSubscriptionEntitlements(
 			FeatureName:    feature.Name,
 			FeatureType:    string(feature.FeatureType),
 			MeterID:        meterID,
+			UsageLimit:     feature.UsageLimit,
+			Enforcement:    feature.Enforcement,
 			EffectiveFrom:  now,
 			CreatedAt:      now,
 		})
@@ -984,10 +1062,13 @@ func (s *Service) buildSubscriptionEntitlements(
 	return entitlements, nil
 }
 
-func (s *Service) loadPriceAmount(ctx context.Context, priceID string) ([]priceamount.Response, error) {
+// loadPriceAmount lists the price's amounts in currency, or in any currency
+// when it is empty.
+func (s *Service) loadPriceAmount(ctx context.Context, priceID string, currency string) ([]priceamount.Response, error) {
 	now := s.clock.Now()
 	return s.priceamountsvc.List(ctx, priceamount.ListPriceAmountRequest{
 		PriceID:       priceID,
+		Currency:      currency,
 		EffectiveFrom: &now,
 	})
 }
@@ -1009,14 +1090,14 @@ func validateSubscriptionPricingModel(price *pricedomain.Response, flatCount *in
 	}
 }
 
-func normalizeSubscriptionQuantity(quantity int8) int8 {
+func normalizeSubscriptionQuantity(quantity int32) int32 {
 	if quantity <= 0 {
 		return 1
 	}
 	return quantity
 }
 
-func validateSubscriptionBillingMode(price *pricedomain.Response, quantity int8) error {
+func validateSubscriptionBillingMode(price *pricedomain.Response, quantity int32) error {
 	switch price.BillingMode {
 	case pricedomain.Licensed:
 		if quantity < 1 {
@@ -1030,6 +1111,25 @@ func validateSubscriptionBillingMode(price *pricedomain.Response, quantity int8)
 	}
 }
 
+// parseItemBillingTiming validates an item's billing timing override. An empty
+// value keeps the price's timing; metered prices are only billed in arrears.
+func parseItemBillingTiming(price *pricedomain.Response, value string) (*string, error) {
+	timing := pricedomain.BillingTiming(strings.ToUpper(strings.TrimSpace(value)))
+	switch timing {
+	case "":
+		return nil, nil
+	case pricedomain.InArrears:
+	case pricedomain.InAdvance:
+		if price.BillingMode == pricedomain.Metered {
+			return nil, pricedomain.ErrInvalidBillingTiming
+		}
+	default:
+		return nil, pricedomain.ErrInvalidBillingTiming
+	}
+	normalized := string(timing)
+	return &normalized, nil
+}
+
 func (s *Service) toCreateResponse(subscription *subscriptiondomain.Subscription, items []subscriptiondomain.SubscriptionItem) subscriptiondomain.CreateSubscriptionResponse {
 	respItems := make([]subscriptiondomain.CreateSubscriptionItemResponse, 0, len(items))
 	for _, item := range items {
@@ -1055,6 +1155,7 @@ func (s *Service) toCreateResponse(subscription *subscriptiondomain.Subscription
 			UsageBehavior:     item.UsageBehavior,
 			BillingThreshold:  item.BillingThreshold,
 			ProrationBehavior: item.ProrationBehavior,
+			BillingTiming:     item.BillingTiming,
 		})
 	}
 
@@ -1070,6 +1171,7 @@ func (s *Service) toCreateResponse(subscription *subscriptiondomain.Subscription
 		Status:         subscription.Status,
 		CollectionMode: subscription.CollectionMode,
 		StartAt:        subscription.StartAt,
+		Currency:       subscriptionCurrency(subscription),
 		Items:          respItems,
 		Metadata:       metadata,
 	}
@@ -1115,15 +1217,24 @@ func (s *Service) resolvePriceMeter(
 	return &resolvedMeterID, &meterCode, nil
 }
 
-func billingCycleTypeForInterval(interval pricedomain.BillingInterval) (string, error) {
+func billingCycleTypeForInterval(interval pricedomain.BillingInterval, count int32) (string, error) {
+	var unit billingcycledomain.CycleUnit
 	switch strings.ToUpper(strings.TrimSpace(string(interval))) {
 	case string(pricedomain.Day):
-		return "daily", nil
+		unit = billingcycledomain.CycleUnitDay
 	case string(pricedomain.Week):
-		return "weekly", nil
+		unit = billingcycledomain.CycleUnitWeek
 	case string(pricedomain.Month):
-		return "monthly", nil
+		unit = billingcycledomain.CycleUnitMonth
+	case string(pricedomain.Year):
+		unit = billingcycledomain.CycleUnitYear
 	default:
 		return "", subscriptiondomain.ErrInvalidBillingCycleType
 	}
+
+	spec, ok := billingcycledomain.NewCycleSpec(unit, int(count))
+	if !ok {
+		return "", subscriptiondomain.ErrInvalidBillingCycleType
+	}
+	return spec.String(), nil
 }
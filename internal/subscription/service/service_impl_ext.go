@@ -5,119 +5,10 @@ import (
 	"time"
 
 	"github.com/bwmarrin/snowflake"
-	"github.com/smallbiznis/railzway/internal/orgcontext"
 	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
 	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
-	"gorm.io/gorm"
 )
 
-func (s *Service) ChangePlan(ctx context.Context, req subscriptiondomain.ChangePlanRequest) error {
-	orgID, ok := orgcontext.OrgIDFromContext(ctx)
-	if !ok {
-		return subscriptiondomain.ErrInvalidOrganization
-	}
-	subscriptionID, err := snowflake.ParseString(req.SubscriptionID)
-	if err != nil {
-		return subscriptiondomain.ErrInvalidSubscription
-	}
-	newProductID, err := snowflake.ParseString(req.NewProductID)
-	if err != nil {
-		return subscriptiondomain.ErrInvalidProduct
-	}
-
-	if !isValidStatus(subscriptiondomain.SubscriptionStatusActive) {
-		return subscriptiondomain.ErrInvalidSubscriptionStatus
-	}
-
-	now := s.clock.Now().UTC()
-
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 1. Fetch Subscription
-		sub, err := s.repo.FindByIDForUpdate(ctx, tx, orgID, subscriptionID)
-		if err != nil {
-			return err
-		}
-		if sub == nil {
-			return subscriptiondomain.ErrSubscriptionNotFound
-		}
-		if sub.Status != subscriptiondomain.SubscriptionStatusActive {
-			return subscriptiondomain.ErrInvalidSubscriptionStatus
-		}
-
-		// 2. Resolve Price for New Product
-		// We need to find the default active price for the product.
-		newPrice, err := s.resolveProductPrice(ctx, orgID, newProductID)
-		if err != nil {
-			return err
-		}
-
-		// 3. Build New Items and Entitlements
-		// Re-calculate based on new product features and price
-		cycleType, err := billingCycleTypeForInterval(newPrice.BillingInterval)
-		if err != nil {
-			return err
-		}
-
-		// Construct request item (assuming quantity 1 for plan change for offered product)
-		itemReqs := []subscriptiondomain.CreateSubscriptionItemRequest{
-			{
-				PriceID:  newPrice.ID.String(),
-				Quantity: 1,
-			},
-		}
-
-		// buildSubscriptionItems does not take tx, uses service db/cache, which is safe for read-only static data (prices/meters)
-		subscriptionItems, _, err := s.buildSubscriptionItems(ctx, orgID, subscriptionID, itemReqs, cycleType, now)
-		if err != nil {
-			return err
-		}
-
-		entitlements, err := s.buildSubscriptionEntitlements(ctx, tx, orgID, subscriptionID, []snowflake.ID{newProductID}, now)
-		if err != nil {
-			return err
-		}
-
-		// 4. Update Database
-
-		// Close old entitlements
-		if err := s.closeActiveEntitlements(ctx, tx, subscriptionID, now); err != nil {
-			return err
-		}
-
-		// Replace items
-		if err := s.repo.ReplaceItems(ctx, tx, orgID, subscriptionID, subscriptionItems); err != nil {
-			return err
-		}
-
-		// Insert new entitlements
-		if err := s.repo.InsertEntitlements(ctx, tx, entitlements); err != nil {
-			return err
-		}
-
-		// Update subscription
-		newCycleType, err := billingCycleTypeForInterval(newPrice.BillingInterval)
-		if err != nil {
-			return err
-		}
-
-		// Optimization: Only update if changed, but PlanChangedAt must strictly update
-		if err := tx.Exec(
-			`UPDATE subscriptions
-             SET plan_changed_at = ?, billing_cycle_type = ?, updated_at = ?
-             WHERE org_id = ? AND id = ?`,
-			now,
-			newCycleType,
-			now,
-			orgID,
-			subscriptionID,
-		).Error; err != nil {
-			return err
-		}
-
-		return nil
-	})
-}
-
 func (s *Service) resolveProductPrice(ctx context.Context, orgID, productID snowflake.ID) (*pricedomain.Response, error) {
 	allPrices, err := s.pricesvc.List(ctx)
 	if err != nil {
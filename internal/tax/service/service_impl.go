@@ -2,9 +2,9 @@ package service
 
 import (
 	"context"
-	"math"
 
 	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/money"
 	taxdomain "github.com/smallbiznis/railzway/internal/tax/domain"
 	"go.uber.org/fx"
 )
@@ -47,17 +47,16 @@ func ComputeTaxInclusive(subtotal int64, rate *float64) int64 {
 	return computeTaxInclusive(subtotal, rate)
 }
 
+// Subtotals are in the currency's minor units, so rounding the exact tax to
+// a whole number of them keeps JPY in yen and KWD in fils.
 func computeTaxExclusive(subtotal int64, rate *float64) int64 {
 	if subtotal <= 0 || rate == nil || *rate <= 0 {
 		return 0
 	}
 
-	tax := float64(subtotal) * (*rate)
-	result := int64(math.Round(tax))
-	if result < 0 {
-		return 0
-	}
-	return result
+	r := money.NewFromFloat(*rate)
+	tax := money.NewFromInt(subtotal).Mul(r)
+	return tax.Round(money.RoundHalfUp)
 }
 
 func computeTaxInclusive(subtotal int64, rate *float64) int64 {
@@ -65,10 +64,7 @@ func computeTaxInclusive(subtotal int64, rate *float64) int64 {
 		return 0
 	}
 
-	tax := float64(subtotal) * (*rate / (1 + *rate))
-	result := int64(math.Round(tax))
-	if result < 0 {
-		return 0
-	}
-	return result
+	r := money.NewFromFloat(*rate)
+	tax := money.NewFromInt(subtotal).Mul(r).Quo(r.Add(money.NewFromInt(1)))
+	return tax.Round(money.RoundHalfUp)
 }
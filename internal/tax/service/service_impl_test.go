@@ -0,0 +1,22 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeTax_MinorUnits(t *testing.T) {
+	rate := func(v float64) *float64 { return &v }
+
+	// JPY subtotals are whole yen, so tax rounds to whole yen.
+	assert.Equal(t, int64(101), ComputeTaxExclusive(1005, rate(0.10)))
+	assert.Equal(t, int64(91), ComputeTaxInclusive(1001, rate(0.10)))
+
+	// KWD subtotals are fils, a thousandth of a dinar.
+	assert.Equal(t, int64(1235), ComputeTaxExclusive(24690, rate(0.05)))
+	assert.Equal(t, int64(1176), ComputeTaxInclusive(24690, rate(0.05)))
+
+	assert.Equal(t, int64(0), ComputeTaxExclusive(0, rate(0.10)))
+	assert.Equal(t, int64(0), ComputeTaxExclusive(1000, nil))
+}
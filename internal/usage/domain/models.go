@@ -15,8 +15,43 @@ const (
 	UsageStatusRated                 = "rated"
 	UsageStatusUnmatchedMeter        = "unmatched_meter"
 	UsageStatusUnmatchedSubscription = "unmatched_subscription"
+	UsageStatusRetracted             = "retracted"
+	UsageStatusAmended               = "amended"
 )
 
+// Correction types recorded on events that correct an earlier event.
+const (
+	CorrectionTypeRetraction = "retraction"
+	CorrectionTypeAmendment  = "amendment"
+)
+
+// Late usage policies decide what happens to an event whose RecordedAt falls
+// in a billing cycle that is no longer OPEN.
+const (
+	// LatePolicyReject refuses the event at ingest.
+	LatePolicyReject = "reject"
+	// LatePolicyCarryForward bills the event in the subscription's open cycle.
+	LatePolicyCarryForward = "carry_forward"
+	// LatePolicyAdjustment bills the event through an adjustment of the
+	// closed cycle, invoiced with the next invoice.
+	LatePolicyAdjustment = "adjustment"
+)
+
+// DefaultLatePolicy applies to organizations without a stored policy. Late
+// events keep being billed unless an organization opts into rejecting them.
+const DefaultLatePolicy = LatePolicyCarryForward
+
+// LatePolicy stores the late usage policy of an organization.
+type LatePolicy struct {
+	OrgID     snowflake.ID `gorm:"primaryKey" json:"organization_id"`
+	Policy    string       `gorm:"type:text;not null;default:carry_forward" json:"policy"`
+	CreatedAt time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName sets the database table name.
+func (LatePolicy) TableName() string { return "usage_late_policies" }
+
 // UsageEvent stores a single unit of metered activity.
 type UsageEvent struct {
 	ID         snowflake.ID `gorm:"primaryKey" json:"id"`
@@ -40,8 +75,23 @@ type UsageEvent struct {
 	IdempotencyKey string            `gorm:"type:text" json:"idempotency_key"`
 	Metadata       datatypes.JSONMap `gorm:"type:jsonb" json:"metadata"`
 	SnapshotAt     *time.Time        `gorm:"" json:"-"`
-	CreatedAt      time.Time         `gorm:"not null;default:CURRENT_TIMESTAMP" json:"-"`
-	UpdatedAt      time.Time         `gorm:"not null;default:CURRENT_TIMESTAMP" json:"-"`
+
+	// Set on retraction and amendment events; references the corrected event.
+	CorrectsEventID  *snowflake.ID `gorm:"index" json:"corrects_event_id,omitempty"`
+	CorrectionType   *string       `gorm:"type:text" json:"correction_type,omitempty"`
+	CorrectionReason *string       `gorm:"type:text" json:"correction_reason,omitempty"`
+
+	// Set when the event arrived after its billing cycle was no longer OPEN.
+	// BillableAt moves carried-forward events into the open cycle while
+	// RecordedAt keeps the original event time.
+	LatePolicy *string    `gorm:"type:text" json:"late_policy,omitempty"`
+	BillableAt *time.Time `gorm:"" json:"billable_at,omitempty"`
+
+	// Set when the event took usage over a soft entitlement limit.
+	OverLimit bool `gorm:"not null;default:false" json:"over_limit,omitempty"`
+
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"-"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"-"`
 }
 
 // TableName sets the database table name.
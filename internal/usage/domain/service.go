@@ -5,6 +5,7 @@ import (
 	"errors"
 	"time"
 
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
 	"github.com/smallbiznis/railzway/pkg/db/pagination"
 )
 
@@ -21,6 +22,169 @@ type CreateIngestRequest struct {
 	IdempotencyKey string `json:"idempotency_key" validate:"required,min=1"`
 
 	Metadata map[string]any `json:"metadata,omitempty"`
+
+	// Optional; routes the event to this subscription when the customer has
+	// several active subscriptions billing the meter.
+	SubscriptionID string `json:"subscription_id,omitempty"`
+}
+
+// MaxIngestBatchSize caps the number of events accepted by a single batch ingest call.
+const MaxIngestBatchSize = 1000
+
+// Per-event outcomes of a batch ingest.
+const (
+	BatchStatusAccepted     = "accepted"
+	BatchStatusDeduplicated = "deduplicated"
+	BatchStatusRejected     = "rejected"
+)
+
+// BatchIngestResult is the outcome of one event of a batch, in request order.
+type BatchIngestResult struct {
+	Index          int    `json:"index"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	Status         string `json:"status"`
+	UsageEventID   string `json:"usage_event_id,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+type BatchIngestSummary struct {
+	Total        int `json:"total"`
+	Accepted     int `json:"accepted"`
+	Deduplicated int `json:"deduplicated"`
+	Rejected     int `json:"rejected"`
+}
+
+type BatchIngestResponse struct {
+	Results []BatchIngestResult `json:"results"`
+	Summary BatchIngestSummary  `json:"summary"`
+}
+
+// MaxCorrectionReasonLength caps the free-text reason stored on a correction.
+const MaxCorrectionReasonLength = 500
+
+// RetractUsageRequest withdraws an accepted event from billing. The retraction
+// is stored as its own event that references the original.
+type RetractUsageRequest struct {
+	Reason         string `json:"reason"`
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+// AmendUsageRequest replaces the value of an accepted event. The amendment is
+// stored as a new event carrying the corrected value and referencing the original.
+type AmendUsageRequest struct {
+	Value          float64 `json:"value"`
+	Reason         string  `json:"reason"`
+	IdempotencyKey string  `json:"idempotency_key"`
+}
+
+// CorrectionResponse returns the correction event. Adjustment is set when the
+// corrected event belongs to an already CLOSED billing cycle.
+type CorrectionResponse struct {
+	UsageEvent *UsageEvent                  `json:"usage_event"`
+	Adjustment *adjustmentdomain.Adjustment `json:"adjustment,omitempty"`
+}
+
+// UpsertLatePolicyRequest replaces the organization's late usage policy.
+type UpsertLatePolicyRequest struct {
+	Policy string `json:"policy"`
+}
+
+type LatePolicyResponse struct {
+	OrgID     string     `json:"organization_id"`
+	Policy    string     `json:"policy"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Usage summary bucket granularities. Hour, day and week buckets are aligned
+// to UTC, weeks start on Monday; cycle buckets follow the billing cycles of
+// the subscription.
+const (
+	SummaryGranularityHour  = "hour"
+	SummaryGranularityDay   = "day"
+	SummaryGranularityWeek  = "week"
+	SummaryGranularityCycle = "cycle"
+)
+
+// MaxUsageSummaryBuckets caps the number of time buckets per meter.
+const MaxUsageSummaryBuckets = 1000
+
+// UsageSummaryRequest aggregates a customer's usage per meter and time
+// bucket. Without Start and End the subscription's current billing cycle is
+// summarized; without MeterCodes every meter with usage in the range is.
+type UsageSummaryRequest struct {
+	CustomerID     string    `json:"customer_id"`
+	SubscriptionID string    `json:"subscription_id"`
+	MeterCodes     []string  `json:"meter_codes"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	Granularity    string    `json:"granularity"`
+	GroupBy        string    `json:"group_by"`
+}
+
+// UsageSummaryBucket is the aggregated value of one time bucket, or of one
+// metadata group within it when the summary is grouped.
+type UsageSummaryBucket struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Group *string   `json:"group,omitempty"`
+	Value float64   `json:"value"`
+}
+
+// MeterUsageSummary holds the buckets of one meter in time order. Ungrouped
+// summaries include empty buckets; grouped ones only groups with usage.
+type MeterUsageSummary struct {
+	MeterID     string               `json:"meter_id"`
+	MeterCode   string               `json:"meter_code"`
+	Aggregation string               `json:"aggregation"`
+	Buckets     []UsageSummaryBucket `json:"buckets"`
+}
+
+type UsageSummaryResponse struct {
+	CustomerID     string              `json:"customer_id"`
+	SubscriptionID string              `json:"subscription_id,omitempty"`
+	Start          time.Time           `json:"start"`
+	End            time.Time           `json:"end"`
+	Granularity    string              `json:"granularity"`
+	GroupBy        string              `json:"group_by,omitempty"`
+	Meters         []MeterUsageSummary `json:"meters"`
+}
+
+// Entitlement check outcomes explaining a denied check.
+const (
+	EntitlementReasonNoSubscription = "no_active_subscription"
+	EntitlementReasonNotEntitled    = "not_entitled"
+	EntitlementReasonLimitExceeded  = "limit_exceeded"
+)
+
+// CheckEntitlementRequest asks whether a customer may use a feature.
+// Quantity is the usage about to be reported; zero checks that any usage is
+// left. SubscriptionID selects one of several active subscriptions; the most
+// recent one is checked otherwise.
+type CheckEntitlementRequest struct {
+	CustomerID     string  `json:"customer_id"`
+	FeatureCode    string  `json:"feature_code"`
+	Quantity       float64 `json:"quantity"`
+	SubscriptionID string  `json:"subscription_id"`
+}
+
+// EntitlementCheckResponse reports access to a feature. Limited features also
+// report the usage of the current billing cycle. Soft limits never deny
+// access; OverLimit tells the caller the limit has been reached.
+type EntitlementCheckResponse struct {
+	CustomerID     string     `json:"customer_id"`
+	SubscriptionID string     `json:"subscription_id,omitempty"`
+	FeatureCode    string     `json:"feature_code"`
+	FeatureType    string     `json:"feature_type,omitempty"`
+	Allowed        bool       `json:"allowed"`
+	Reason         string     `json:"reason,omitempty"`
+	Enforcement    *string    `json:"enforcement,omitempty"`
+	Limit          *float64   `json:"limit,omitempty"`
+	Used           *float64   `json:"used,omitempty"`
+	Remaining      *float64   `json:"remaining,omitempty"`
+	OverLimit      bool       `json:"over_limit"`
+	PeriodStart    *time.Time `json:"period_start,omitempty"`
+	PeriodEnd      *time.Time `json:"period_end,omitempty"`
 }
 
 type ListUsageRequest struct {
@@ -38,7 +202,25 @@ type ListUsageResponse struct {
 
 type Service interface {
 	Ingest(context.Context, CreateIngestRequest) (*UsageEvent, error)
+	// IngestBatch ingests each event independently; invalid events are
+	// reported in the response rather than failing the batch.
+	IngestBatch(context.Context, []CreateIngestRequest) (*BatchIngestResponse, error)
 	List(context.Context, ListUsageRequest) (ListUsageResponse, error)
+	// Summary aggregates enriched usage with each meter's aggregation, so the
+	// values match what rating produces for the same window.
+	Summary(context.Context, UsageSummaryRequest) (*UsageSummaryResponse, error)
+	// Retract and Amend correct an accepted event. In an OPEN cycle the
+	// change is picked up by aggregation; in a CLOSED cycle it produces a
+	// billing adjustment for the next invoice.
+	Retract(ctx context.Context, usageEventID string, req RetractUsageRequest) (*CorrectionResponse, error)
+	Amend(ctx context.Context, usageEventID string, req AmendUsageRequest) (*CorrectionResponse, error)
+	// GetLatePolicy and UpsertLatePolicy manage how events arriving after
+	// their billing cycle stopped being OPEN are handled.
+	GetLatePolicy(ctx context.Context) (*LatePolicyResponse, error)
+	UpsertLatePolicy(ctx context.Context, req UpsertLatePolicyRequest) (*LatePolicyResponse, error)
+	// CheckEntitlement answers whether a customer may use a feature and how
+	// much of its limit is left in the current billing cycle.
+	CheckEntitlement(ctx context.Context, req CheckEntitlementRequest) (*EntitlementCheckResponse, error)
 }
 
 var (
@@ -51,4 +233,25 @@ var (
 	ErrInvalidValue            = errors.New("invalid_value")
 	ErrInvalidRecordedAt       = errors.New("invalid_recorded_at")
 	ErrInvalidIdempotencyKey   = errors.New("invalid_idempotency_key")
+	ErrFeatureNotEntitled      = errors.New("usage_rejected_feature_not_entitled")
+	ErrGatingUnavailable       = errors.New("usage_ingestion_gating_unavailable")
+	ErrEmptyBatch              = errors.New("empty_batch")
+	ErrBatchTooLarge           = errors.New("batch_too_large")
+	ErrInvalidUsageEvent       = errors.New("invalid_usage_event")
+	ErrUsageEventNotFound      = errors.New("usage_event_not_found")
+	ErrInvalidCorrectionReason = errors.New("invalid_correction_reason")
+	ErrUsageAlreadyCorrected   = errors.New("usage_event_already_corrected")
+	ErrUsageNotCorrectable     = errors.New("usage_event_not_correctable")
+	ErrBillingCycleClosing     = errors.New("usage_billing_cycle_closing")
+	ErrCorrectionUnavailable   = errors.New("usage_correction_unavailable")
+	ErrInvalidLatePolicy       = errors.New("invalid_late_policy")
+	ErrLateUsageRejected       = errors.New("usage_rejected_late")
+	ErrNoOpenBillingCycle      = errors.New("usage_no_open_billing_cycle")
+	ErrInvalidGranularity      = errors.New("invalid_granularity")
+	ErrInvalidSummaryRange     = errors.New("invalid_summary_range")
+	ErrInvalidGroupBy          = errors.New("invalid_group_by")
+	ErrTooManyBuckets          = errors.New("too_many_buckets")
+	ErrInvalidFeatureCode      = errors.New("invalid_feature_code")
+	ErrUsageLimitExceeded      = errors.New("usage_rejected_limit_exceeded")
+	ErrAmbiguousSubscription   = errors.New("ambiguous_subscription")
 )
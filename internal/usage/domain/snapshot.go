@@ -7,12 +7,15 @@ import (
 )
 
 // SnapshotCandidate is a usage event eligible for async snapshot enrichment.
+// SubscriptionID is set when the event was ingested for an explicit
+// subscription.
 type SnapshotCandidate struct {
-	ID         snowflake.ID
-	OrgID      snowflake.ID
-	CustomerID snowflake.ID
-	MeterCode  string
-	RecordedAt time.Time
+	ID             snowflake.ID
+	OrgID          snowflake.ID
+	CustomerID     snowflake.ID
+	SubscriptionID snowflake.ID
+	MeterCode      string
+	RecordedAt     time.Time
 }
 
 // SnapshotUpdate contains resolved snapshot fields for a usage event.
@@ -9,6 +9,8 @@ import (
 const (
 	StatusAccepted     = "accepted"
 	StatusDeduplicated = "deduplicated"
+	StatusRetracted    = "retracted"
+	StatusAmended      = "amended"
 
 	SourceAPI      = "api"
 	SourceReplay   = "replay"
@@ -19,7 +19,7 @@ func (r *snapshotRepo) LockAccepted(ctx context.Context, db *gorm.DB, limit int)
 	}
 	var rows []usagedomain.SnapshotCandidate
 	err := db.WithContext(ctx).Raw(
-		`SELECT id, org_id, customer_id, meter_code, recorded_at
+		`SELECT id, org_id, customer_id, subscription_id, meter_code, recorded_at
 		 FROM usage_events
 		 WHERE status = ?
 		 ORDER BY recorded_at ASC
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"github.com/smallbiznis/railzway/internal/usage/liveevents"
+)
+
+// usageInsertChunkSize bounds the rows of one multi-row INSERT so the statement
+// stays well below driver placeholder limits.
+const usageInsertChunkSize = 500
+
+type pendingUsageEvent struct {
+	index          int
+	customerID     snowflake.ID
+	meterCode      string
+	idempotencyKey string
+}
+
+// IngestBatch ingests up to MaxIngestBatchSize events with a single
+// idempotency lookup and multi-row inserts. Idempotency stays per event:
+// events already stored, or repeated earlier in the same batch, are reported
+// as deduplicated and invalid events as rejected, without failing the batch.
+func (s *Service) IngestBatch(
+	ctx context.Context,
+	reqs []usagedomain.CreateIngestRequest,
+) (*usagedomain.BatchIngestResponse, error) {
+
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, usagedomain.ErrInvalidOrganization
+	}
+	if len(reqs) == 0 {
+		return nil, usagedomain.ErrEmptyBatch
+	}
+	if len(reqs) > usagedomain.MaxIngestBatchSize {
+		return nil, usagedomain.ErrBatchTooLarge
+	}
+
+	results := make([]usagedomain.BatchIngestResult, len(reqs))
+	valid := make([]pendingUsageEvent, 0, len(reqs))
+	keys := make([]string, 0, len(reqs))
+	for i, req := range reqs {
+		results[i] = usagedomain.BatchIngestResult{
+			Index:          i,
+			IdempotencyKey: strings.TrimSpace(req.IdempotencyKey),
+		}
+		customerID, meterCode, key, err := s.validateIngestRequest(req)
+		if err != nil {
+			rejectBatchResult(&results[i], err)
+			continue
+		}
+		valid = append(valid, pendingUsageEvent{
+			index:          i,
+			customerID:     customerID,
+			meterCode:      meterCode,
+			idempotencyKey: key,
+		})
+		keys = append(keys, key)
+	}
+
+	// Strict idempotency, as in Ingest: stored events are returned as-is
+	// before any subscription or entitlement resolution.
+	existing, err := s.findUsageEventsByIdempotencyKeys(ctx, orgID, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	firstByKey := make(map[string]int, len(valid))
+	duplicates := make(map[int]int)
+	records := make([]*usagedomain.UsageEvent, 0, len(valid))
+	recordIndex := make(map[snowflake.ID]int, len(valid))
+	tally := make(usageTally)
+	for _, p := range valid {
+		if record, ok := existing[p.idempotencyKey]; ok {
+			dedupeBatchResult(&results[p.index], record)
+			s.emitLiveUsageEvent(record, liveevents.StatusDeduplicated, liveevents.SourceAPI)
+			continue
+		}
+		if first, ok := firstByKey[p.idempotencyKey]; ok {
+			duplicates[p.index] = first
+			continue
+		}
+		firstByKey[p.idempotencyKey] = p.index
+
+		record, err := s.buildUsageEvent(ctx, orgID, p.customerID, p.meterCode, p.idempotencyKey, reqs[p.index], now, tally)
+		if err != nil {
+			if !isUsageRejection(err) {
+				return nil, err
+			}
+			rejectBatchResult(&results[p.index], err)
+			continue
+		}
+		records = append(records, record)
+		recordIndex[record.ID] = p.index
+	}
+
+	inserted, err := s.insertUsageEvents(ctx, orgID, records)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows skipped by the conflict clause lost a race with a concurrent
+	// request carrying the same key; report the stored event instead.
+	raced := make([]string, 0)
+	for _, record := range records {
+		if !inserted[record.ID] {
+			raced = append(raced, record.IdempotencyKey)
+		}
+	}
+	winners, err := s.findUsageEventsByIdempotencyKeys(ctx, orgID, raced)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		result := &results[recordIndex[record.ID]]
+		if inserted[record.ID] {
+			result.Status = usagedomain.BatchStatusAccepted
+			result.UsageEventID = record.ID.String()
+			s.publishAccepted(ctx, record)
+			continue
+		}
+		winner, ok := winners[record.IdempotencyKey]
+		if !ok {
+			return nil, errors.New("usage_event_not_found")
+		}
+		dedupeBatchResult(result, winner)
+		s.emitLiveUsageEvent(winner, liveevents.StatusDeduplicated, liveevents.SourceAPI)
+	}
+
+	for index, first := range duplicates {
+		result := &results[index]
+		if results[first].Status == usagedomain.BatchStatusRejected {
+			result.Status = usagedomain.BatchStatusRejected
+			result.Error = results[first].Error
+			continue
+		}
+		result.Status = usagedomain.BatchStatusDeduplicated
+		result.UsageEventID = results[first].UsageEventID
+	}
+
+	return &usagedomain.BatchIngestResponse{
+		Results: results,
+		Summary: summarizeBatch(results),
+	}, nil
+}
+
+func (s *Service) findUsageEventsByIdempotencyKeys(ctx context.Context, orgID snowflake.ID, keys []string) (map[string]*usagedomain.UsageEvent, error) {
+	found := make(map[string]*usagedomain.UsageEvent, len(keys))
+	if len(keys) == 0 {
+		return found, nil
+	}
+	if s.db == nil {
+		return nil, errors.New("missing_db")
+	}
+	var records []*usagedomain.UsageEvent
+	if err := s.db.WithContext(ctx).
+		Where("org_id = ? AND idempotency_key IN ?", orgID, keys).
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		found[record.IdempotencyKey] = record
+	}
+	return found, nil
+}
+
+// insertUsageEvents stores records with multi-row inserts, skipping idempotency
+// conflicts, and reports which record IDs were actually written.
+func (s *Service) insertUsageEvents(ctx context.Context, orgID snowflake.ID, records []*usagedomain.UsageEvent) (map[snowflake.ID]bool, error) {
+	inserted := make(map[snowflake.ID]bool, len(records))
+	if len(records) == 0 {
+		return inserted, nil
+	}
+	if s.db == nil {
+		return nil, errors.New("missing_db")
+	}
+
+	ids := make([]snowflake.ID, 0, len(records))
+	for start := 0; start < len(records); start += usageInsertChunkSize {
+		end := start + usageInsertChunkSize
+		if end > len(records) {
+			end = len(records)
+		}
+		chunk := records[start:end]
+		if strings.EqualFold(s.db.Dialector.Name(), "sqlite") {
+			if err := s.insertUsageEventsSQLite(ctx, chunk); err != nil {
+				return nil, err
+			}
+		} else if err := s.db.WithContext(ctx).
+			Clauses(buildIdempotencyConflictClause(s.db)).
+			Create(&chunk).Error; err != nil {
+			return nil, err
+		}
+		for _, record := range chunk {
+			ids = append(ids, record.ID)
+		}
+	}
+
+	var stored []snowflake.ID
+	if err := s.db.WithContext(ctx).Raw(
+		`SELECT id FROM usage_events WHERE org_id = ? AND id IN ?`,
+		orgID,
+		ids,
+	).Scan(&stored).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range stored {
+		inserted[id] = true
+	}
+	return inserted, nil
+}
+
+func (s *Service) insertUsageEventsSQLite(ctx context.Context, records []*usagedomain.UsageEvent) error {
+	var query strings.Builder
+	query.WriteString(`INSERT INTO usage_events (
+		id, org_id, customer_id, subscription_id, subscription_item_id,
+		meter_id, meter_code, value, recorded_at, status, error,
+		idempotency_key, metadata, late_policy, billable_at, over_limit,
+		created_at, updated_at
+	) VALUES `)
+	args := make([]any, 0, len(records)*18)
+	for i, record := range records {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		var subscriptionItemValue any
+		if record.SubscriptionItemID != 0 {
+			subscriptionItemValue = record.SubscriptionItemID
+		}
+		args = append(args,
+			record.ID,
+			record.OrgID,
+			record.CustomerID,
+			record.SubscriptionID,
+			subscriptionItemValue,
+			record.MeterID,
+			record.MeterCode,
+			record.Value,
+			record.RecordedAt,
+			record.Status,
+			record.Error,
+			record.IdempotencyKey,
+			record.Metadata,
+			record.LatePolicy,
+			record.BillableAt,
+			record.OverLimit,
+			record.CreatedAt,
+			record.UpdatedAt,
+		)
+	}
+	query.WriteString(" ON CONFLICT (org_id, idempotency_key) DO NOTHING")
+	return s.db.WithContext(ctx).Exec(query.String(), args...).Error
+}
+
+// isUsageRejection reports whether err rejects a single event rather than
+// signalling an infrastructure failure that should abort the whole batch.
+func isUsageRejection(err error) bool {
+	switch err {
+	case usagedomain.ErrInvalidCustomer,
+		usagedomain.ErrInvalidSubscription,
+		usagedomain.ErrInvalidSubscriptionItem,
+		usagedomain.ErrInvalidMeter,
+		usagedomain.ErrInvalidMeterCode,
+		usagedomain.ErrInvalidValue,
+		usagedomain.ErrInvalidRecordedAt,
+		usagedomain.ErrInvalidIdempotencyKey,
+		usagedomain.ErrFeatureNotEntitled,
+		usagedomain.ErrLateUsageRejected,
+		usagedomain.ErrNoOpenBillingCycle,
+		usagedomain.ErrUsageLimitExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+func rejectBatchResult(result *usagedomain.BatchIngestResult, err error) {
+	result.Status = usagedomain.BatchStatusRejected
+	result.Error = err.Error()
+}
+
+func dedupeBatchResult(result *usagedomain.BatchIngestResult, record *usagedomain.UsageEvent) {
+	result.Status = usagedomain.BatchStatusDeduplicated
+	result.UsageEventID = record.ID.String()
+}
+
+func summarizeBatch(results []usagedomain.BatchIngestResult) usagedomain.BatchIngestSummary {
+	summary := usagedomain.BatchIngestSummary{Total: len(results)}
+	for _, result := range results {
+		switch result.Status {
+		case usagedomain.BatchStatusAccepted:
+			summary.Accepted++
+		case usagedomain.BatchStatusDeduplicated:
+			summary.Deduplicated++
+		case usagedomain.BatchStatusRejected:
+			summary.Rejected++
+		}
+	}
+	return summary
+}
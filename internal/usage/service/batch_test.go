@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/smallbiznis/railzway/internal/cache"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestIngestBatchReportsPerEventStatus(t *testing.T) {
+	node := mustNode(t)
+	orgID := node.Generate()
+	customerID := node.Generate()
+	meterID := node.Generate()
+
+	meter := &meterStub{
+		response: &meterdomain.Response{
+			ID:   meterID.String(),
+			Code: "api_calls",
+		},
+	}
+	service, db := setupUsageService(t, node, meter, cache.NewUsageResolverCache(), orgID, customerID)
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	event := func(key string) usagedomain.CreateIngestRequest {
+		return usagedomain.CreateIngestRequest{
+			CustomerID:     customerID.String(),
+			MeterCode:      "api_calls",
+			Value:          1,
+			RecordedAt:     time.Now().UTC(),
+			IdempotencyKey: key,
+		}
+	}
+
+	stored, err := service.Ingest(ctx, event("already-stored"))
+	if err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+
+	invalid := event("bad-customer")
+	invalid.CustomerID = "not-an-id"
+
+	resp, err := service.IngestBatch(ctx, []usagedomain.CreateIngestRequest{
+		event("a"),
+		event("already-stored"),
+		invalid,
+		event("b"),
+		event("a"),
+	})
+	if err != nil {
+		t.Fatalf("ingest batch: %v", err)
+	}
+
+	expected := []string{
+		usagedomain.BatchStatusAccepted,
+		usagedomain.BatchStatusDeduplicated,
+		usagedomain.BatchStatusRejected,
+		usagedomain.BatchStatusAccepted,
+		usagedomain.BatchStatusDeduplicated,
+	}
+	for i, status := range expected {
+		if resp.Results[i].Index != i || resp.Results[i].Status != status {
+			t.Fatalf("result %d: expected %s, got %+v", i, status, resp.Results[i])
+		}
+	}
+	if resp.Results[1].UsageEventID != stored.ID.String() {
+		t.Fatalf("expected stored event %s, got %s", stored.ID, resp.Results[1].UsageEventID)
+	}
+	if resp.Results[2].Error != usagedomain.ErrInvalidCustomer.Error() {
+		t.Fatalf("expected invalid_customer, got %q", resp.Results[2].Error)
+	}
+	if resp.Results[4].UsageEventID != resp.Results[0].UsageEventID {
+		t.Fatalf("expected in-batch duplicate to reference %s, got %s", resp.Results[0].UsageEventID, resp.Results[4].UsageEventID)
+	}
+
+	summary := resp.Summary
+	if summary.Total != 5 || summary.Accepted != 2 || summary.Deduplicated != 2 || summary.Rejected != 1 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if count := countUsageEvents(t, db); count != 3 {
+		t.Fatalf("expected 3 usage events, got %d", count)
+	}
+
+	if _, err := service.IngestBatch(ctx, nil); err != usagedomain.ErrEmptyBatch {
+		t.Fatalf("expected empty_batch, got %v", err)
+	}
+}
+
+func TestIngestBatchCountsEarlierEventsAgainstHardLimit(t *testing.T) {
+	node := mustNode(t)
+	orgID := node.Generate()
+	customerID := node.Generate()
+	subID := node.Generate()
+	callsID := node.Generate()
+
+	calls := &meterdomain.Response{ID: callsID.String(), Code: "api_calls", Aggregation: meterdomain.AggregationSum}
+	_, db := setupUsageService(t, node, &meterStub{}, cache.NewUsageResolverCache(), orgID, customerID)
+	meters := new(meterMock)
+	meters.On("GetByCode", mock.Anything, "api_calls").Return(calls, nil)
+	sub := new(subscriptionMock)
+	sub.On("GetActiveByCustomerID", mock.Anything, mock.Anything).Return(subscriptiondomain.Subscription{ID: subID}, nil)
+	sub.On("ValidateUsageEntitlement", mock.Anything, subID, mock.Anything, mock.Anything).Return(nil)
+	service := NewService(ServiceParam{
+		DB:       db,
+		Log:      zap.NewNop(),
+		GenID:    node,
+		MeterSvc: &summaryMeters{meterMock: meters, byID: map[string]*meterdomain.Response{calls.ID: calls}},
+		SubSvc:   sub,
+	})
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	cycleStart := now.Add(-24 * time.Hour)
+	if err := db.Exec(
+		`INSERT INTO billing_cycles (id, org_id, subscription_id, period_start, period_end, status) VALUES (?, ?, ?, ?, ?, ?)`,
+		node.Generate(), orgID, subID, cycleStart, now.AddDate(0, 1, 0), "OPEN",
+	).Error; err != nil {
+		t.Fatalf("seed cycle: %v", err)
+	}
+	hard, limit := "hard", 10.0
+	if err := db.Create(&subscriptiondomain.SubscriptionEntitlement{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		SubscriptionID: subID,
+		FeatureCode:    "api",
+		FeatureName:    "api",
+		FeatureType:    "metered",
+		MeterID:        &callsID,
+		UsageLimit:     &limit,
+		Enforcement:    &hard,
+		EffectiveFrom:  cycleStart,
+		CreatedAt:      cycleStart,
+	}).Error; err != nil {
+		t.Fatalf("seed entitlement: %v", err)
+	}
+
+	event := func(key string, value float64) usagedomain.CreateIngestRequest {
+		return usagedomain.CreateIngestRequest{
+			CustomerID:     customerID.String(),
+			MeterCode:      "api_calls",
+			Value:          value,
+			RecordedAt:     now,
+			IdempotencyKey: key,
+		}
+	}
+	if _, err := service.Ingest(ctx, event("stored", 4)); err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+
+	// Each event is checked against the stored usage plus the events
+	// accepted before it in the batch.
+	resp, err := service.IngestBatch(ctx, []usagedomain.CreateIngestRequest{
+		event("a", 3),
+		event("b", 4),
+		event("c", 3),
+		event("d", 1),
+	})
+	if err != nil {
+		t.Fatalf("ingest batch: %v", err)
+	}
+	expected := []string{
+		usagedomain.BatchStatusAccepted,
+		usagedomain.BatchStatusRejected,
+		usagedomain.BatchStatusAccepted,
+		usagedomain.BatchStatusRejected,
+	}
+	for i, status := range expected {
+		if resp.Results[i].Status != status {
+			t.Fatalf("result %d: expected %s, got %+v", i, status, resp.Results[i])
+		}
+	}
+	if resp.Results[1].Error != usagedomain.ErrUsageLimitExceeded.Error() {
+		t.Fatalf("expected usage_limit_exceeded, got %q", resp.Results[1].Error)
+	}
+
+	used, err := service.(*Service).cycleUsage(ctx, orgID, subID, customerID, calls, cycleStart, now.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("cycle usage: %v", err)
+	}
+	if used != limit {
+		t.Fatalf("expected usage to stop at the limit, got %v", used)
+	}
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	"github.com/smallbiznis/railzway/internal/events"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"github.com/smallbiznis/railzway/internal/usage/liveevents"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type correctionSpec struct {
+	kind           string
+	value          float64
+	reason         string
+	idempotencyKey string
+}
+
+// Retract withdraws an accepted event. A retraction event referencing the
+// original is stored and the original stops counting towards aggregation.
+func (s *Service) Retract(ctx context.Context, usageEventID string, req usagedomain.RetractUsageRequest) (*usagedomain.CorrectionResponse, error) {
+	return s.correct(ctx, usageEventID, correctionSpec{
+		kind:           usagedomain.CorrectionTypeRetraction,
+		reason:         req.Reason,
+		idempotencyKey: req.IdempotencyKey,
+	})
+}
+
+// Amend replaces the value of an accepted event. The amendment is stored as a
+// new event with the corrected value, on the same subscription, meter and
+// timestamp as the original, which stops counting towards aggregation.
+func (s *Service) Amend(ctx context.Context, usageEventID string, req usagedomain.AmendUsageRequest) (*usagedomain.CorrectionResponse, error) {
+	if math.IsNaN(req.Value) || math.IsInf(req.Value, 0) || req.Value < 0 {
+		return nil, usagedomain.ErrInvalidValue
+	}
+	return s.correct(ctx, usageEventID, correctionSpec{
+		kind:           usagedomain.CorrectionTypeAmendment,
+		value:          req.Value,
+		reason:         req.Reason,
+		idempotencyKey: req.IdempotencyKey,
+	})
+}
+
+func (s *Service) correct(ctx context.Context, usageEventID string, spec correctionSpec) (*usagedomain.CorrectionResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, usagedomain.ErrInvalidOrganization
+	}
+	id, err := s.parseID(usageEventID, usagedomain.ErrInvalidUsageEvent)
+	if err != nil {
+		return nil, err
+	}
+	reason := strings.TrimSpace(spec.reason)
+	if reason == "" || len(reason) > usagedomain.MaxCorrectionReasonLength {
+		return nil, usagedomain.ErrInvalidCorrectionReason
+	}
+	key := normalizeIdempotencyKey(spec.idempotencyKey)
+	if key == "" {
+		return nil, usagedomain.ErrInvalidIdempotencyKey
+	}
+
+	// Retries with the same key return the stored correction and finish any
+	// adjustment a previous attempt did not get to.
+	existing, err := s.findUsageEventByIdempotencyKey(ctx, orgID, key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if existing.CorrectsEventID == nil || *existing.CorrectsEventID != id {
+			return nil, usagedomain.ErrInvalidIdempotencyKey
+		}
+		return s.completeCorrection(ctx, existing)
+	}
+
+	var record *usagedomain.UsageEvent
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		original, err := s.lockUsageEvent(ctx, tx, orgID, id)
+		if err != nil {
+			return err
+		}
+		if original == nil {
+			return usagedomain.ErrUsageEventNotFound
+		}
+		switch original.Status {
+		case usagedomain.UsageStatusAccepted, usagedomain.UsageStatusEnriched, usagedomain.UsageStatusRated:
+		case usagedomain.UsageStatusRetracted, usagedomain.UsageStatusAmended:
+			return usagedomain.ErrUsageAlreadyCorrected
+		default:
+			return usagedomain.ErrUsageNotCorrectable
+		}
+
+		cycleStatus, err := s.billingCycleStatusForEvent(ctx, tx, original)
+		if err != nil {
+			return err
+		}
+		switch cycleStatus {
+		case billingcycledomain.BillingCycleStatusClosing:
+			return usagedomain.ErrBillingCycleClosing
+		case billingcycledomain.BillingCycleStatusClosed:
+			if s.adjustmentSvc == nil {
+				return usagedomain.ErrCorrectionUnavailable
+			}
+		}
+
+		record = buildCorrectionEvent(s.genID.Generate(), original, spec.kind, spec.value, reason, key, time.Now().UTC())
+		inserted, err := s.insertCorrectionEvent(ctx, tx, record)
+		if err != nil {
+			return err
+		}
+		if !inserted {
+			return usagedomain.ErrInvalidIdempotencyKey
+		}
+
+		originalStatus := usagedomain.UsageStatusRetracted
+		if spec.kind == usagedomain.CorrectionTypeAmendment {
+			originalStatus = usagedomain.UsageStatusAmended
+		}
+		if err := tx.WithContext(ctx).Exec(
+			`UPDATE usage_events
+			 SET status = ?, updated_at = ?
+			 WHERE org_id = ? AND id = ?`,
+			originalStatus,
+			record.CreatedAt,
+			orgID,
+			original.ID,
+		).Error; err != nil {
+			return err
+		}
+
+		return s.publishCorrected(ctx, tx, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info("usage event corrected",
+		zap.String("usage_event_id", id.String()),
+		zap.String("correction_event_id", record.ID.String()),
+		zap.String("correction_type", spec.kind),
+	)
+	status := liveevents.StatusAmended
+	if spec.kind == usagedomain.CorrectionTypeRetraction {
+		status = liveevents.StatusRetracted
+	}
+	s.emitLiveUsageEvent(record, status, liveevents.SourceAPI)
+
+	return s.completeCorrection(ctx, record)
+}
+
+// completeCorrection applies the billing effect of a stored correction. Only
+// corrections of CLOSED cycles produce an adjustment; OPEN cycles pick the
+// change up through aggregation when they close.
+func (s *Service) completeCorrection(ctx context.Context, record *usagedomain.UsageEvent) (*usagedomain.CorrectionResponse, error) {
+	resp := &usagedomain.CorrectionResponse{UsageEvent: record}
+	if s.adjustmentSvc == nil {
+		return resp, nil
+	}
+	adjustment, err := s.adjustmentSvc.ApplyUsageCorrection(ctx, record.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	resp.Adjustment = adjustment
+	return resp, nil
+}
+
+// buildCorrectionEvent copies the snapshot of the original so the correction
+// is aggregated in the same cycle, for the same subscription item and meter.
+// Retractions carry no value and are never aggregated.
+func buildCorrectionEvent(
+	id snowflake.ID,
+	original *usagedomain.UsageEvent,
+	kind string,
+	value float64,
+	reason string,
+	idempotencyKey string,
+	now time.Time,
+) *usagedomain.UsageEvent {
+	status := original.Status
+	if kind == usagedomain.CorrectionTypeRetraction {
+		status = usagedomain.UsageStatusRetracted
+		value = 0
+	} else if status == usagedomain.UsageStatusRated {
+		status = usagedomain.UsageStatusEnriched
+	}
+	correctsEventID := original.ID
+	correctionType := kind
+
+	return &usagedomain.UsageEvent{
+		ID:                 id,
+		OrgID:              original.OrgID,
+		CustomerID:         original.CustomerID,
+		SubscriptionID:     original.SubscriptionID,
+		SubscriptionItemID: original.SubscriptionItemID,
+		MeterID:            original.MeterID,
+		MeterCode:          original.MeterCode,
+		Value:              value,
+		RecordedAt:         original.RecordedAt,
+		Status:             status,
+		IdempotencyKey:     idempotencyKey,
+		Metadata:           original.Metadata,
+		SnapshotAt:         original.SnapshotAt,
+		BillableAt:         original.BillableAt,
+		CorrectsEventID:    &correctsEventID,
+		CorrectionType:     &correctionType,
+		CorrectionReason:   &reason,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+}
+
+func (s *Service) lockUsageEvent(ctx context.Context, tx *gorm.DB, orgID, id snowflake.ID) (*usagedomain.UsageEvent, error) {
+	query := `SELECT * FROM usage_events WHERE org_id = ? AND id = ?`
+	if tx.Dialector.Name() != "sqlite" {
+		query += " FOR UPDATE"
+	}
+	var record usagedomain.UsageEvent
+	if err := tx.WithContext(ctx).Raw(query, orgID, id).Scan(&record).Error; err != nil {
+		return nil, err
+	}
+	if record.ID == 0 {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// billingCycleStatusForEvent returns the status of the cycle the event is
+// billed in, or "" when the event is not covered by a cycle yet.
+func (s *Service) billingCycleStatusForEvent(ctx context.Context, tx *gorm.DB, record *usagedomain.UsageEvent) (billingcycledomain.BillingCycleStatus, error) {
+	at := record.RecordedAt
+	if record.BillableAt != nil {
+		at = *record.BillableAt
+	}
+	cycle, err := s.findCycleAt(ctx, tx, record.OrgID, record.SubscriptionID, at)
+	if err != nil || cycle == nil {
+		return "", err
+	}
+	return billingcycledomain.BillingCycleStatus(strings.TrimSpace(string(cycle.Status))), nil
+}
+
+func (s *Service) insertCorrectionEvent(ctx context.Context, tx *gorm.DB, record *usagedomain.UsageEvent) (bool, error) {
+	var subscriptionItemValue any
+	if record.SubscriptionItemID != 0 {
+		subscriptionItemValue = record.SubscriptionItemID
+	}
+	query := `INSERT INTO usage_events (
+		id, org_id, customer_id, subscription_id, subscription_item_id,
+		meter_id, meter_code, value, recorded_at, status, error,
+		idempotency_key, metadata, snapshot_at, corrects_event_id,
+		correction_type, correction_reason, late_policy, billable_at,
+		created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (org_id, idempotency_key)`
+	if strings.EqualFold(tx.Dialector.Name(), "postgres") {
+		query += " WHERE idempotency_key IS NOT NULL"
+	}
+	query += " DO NOTHING"
+
+	result := tx.WithContext(ctx).Exec(
+		query,
+		record.ID,
+		record.OrgID,
+		record.CustomerID,
+		record.SubscriptionID,
+		subscriptionItemValue,
+		record.MeterID,
+		record.MeterCode,
+		record.Value,
+		record.RecordedAt,
+		record.Status,
+		record.Error,
+		record.IdempotencyKey,
+		record.Metadata,
+		record.SnapshotAt,
+		record.CorrectsEventID,
+		record.CorrectionType,
+		record.CorrectionReason,
+		record.LatePolicy,
+		record.BillableAt,
+		record.CreatedAt,
+		record.UpdatedAt,
+	)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (s *Service) publishCorrected(ctx context.Context, tx *gorm.DB, record *usagedomain.UsageEvent) error {
+	if s.outbox == nil {
+		return nil
+	}
+	return s.outbox.PublishTx(ctx, tx, events.Event{
+		OrgID: record.OrgID,
+		Type:  events.EventUsageCorrected,
+		Payload: map[string]any{
+			"usage_event_id":    record.ID.String(),
+			"corrects_event_id": record.CorrectsEventID.String(),
+			"correction_type":   *record.CorrectionType,
+			"reason":            *record.CorrectionReason,
+			"customer_id":       record.CustomerID.String(),
+			"meter_code":        record.MeterCode,
+			"value":             record.Value,
+		},
+		DedupeKey: "usage_corrected:" + record.ID.String(),
+	})
+}
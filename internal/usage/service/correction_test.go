@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smallbiznis/railzway/internal/cache"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+)
+
+func TestCorrectUsageInOpenCycle(t *testing.T) {
+	node := mustNode(t)
+	orgID := node.Generate()
+	customerID := node.Generate()
+	meterID := node.Generate()
+
+	meter := &meterStub{
+		response: &meterdomain.Response{
+			ID:   meterID.String(),
+			Code: "api_calls",
+		},
+	}
+	service, db := setupUsageService(t, node, meter, cache.NewUsageResolverCache(), orgID, customerID)
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	ingest := func(key string, value float64) *usagedomain.UsageEvent {
+		t.Helper()
+		event, err := service.Ingest(ctx, usagedomain.CreateIngestRequest{
+			CustomerID:     customerID.String(),
+			MeterCode:      "api_calls",
+			Value:          value,
+			RecordedAt:     time.Now().UTC(),
+			IdempotencyKey: key,
+		})
+		if err != nil {
+			t.Fatalf("ingest %s: %v", key, err)
+		}
+		return event
+	}
+	statusOf := func(event *usagedomain.UsageEvent) string {
+		t.Helper()
+		var status string
+		if err := db.Raw(`SELECT status FROM usage_events WHERE id = ?`, event.ID).Scan(&status).Error; err != nil {
+			t.Fatalf("load status: %v", err)
+		}
+		return status
+	}
+
+	retracted := ingest("evt-1", 10)
+	resp, err := service.Retract(ctx, retracted.ID.String(), usagedomain.RetractUsageRequest{
+		Reason:         "duplicate report",
+		IdempotencyKey: "retract-1",
+	})
+	if err != nil {
+		t.Fatalf("retract: %v", err)
+	}
+	if resp.Adjustment != nil {
+		t.Fatalf("expected no adjustment for an open cycle")
+	}
+	if resp.UsageEvent.CorrectsEventID == nil || *resp.UsageEvent.CorrectsEventID != retracted.ID {
+		t.Fatalf("expected retraction to reference %s", retracted.ID)
+	}
+	if resp.UsageEvent.Value != 0 || resp.UsageEvent.Status != usagedomain.UsageStatusRetracted {
+		t.Fatalf("unexpected retraction event: value=%v status=%s", resp.UsageEvent.Value, resp.UsageEvent.Status)
+	}
+	if status := statusOf(retracted); status != usagedomain.UsageStatusRetracted {
+		t.Fatalf("expected original to be retracted, got %s", status)
+	}
+
+	retry, err := service.Retract(ctx, retracted.ID.String(), usagedomain.RetractUsageRequest{
+		Reason:         "duplicate report",
+		IdempotencyKey: "retract-1",
+	})
+	if err != nil {
+		t.Fatalf("retry retract: %v", err)
+	}
+	if retry.UsageEvent.ID != resp.UsageEvent.ID {
+		t.Fatalf("expected idempotent retraction, got %s vs %s", retry.UsageEvent.ID, resp.UsageEvent.ID)
+	}
+	if _, err := service.Retract(ctx, retracted.ID.String(), usagedomain.RetractUsageRequest{
+		Reason:         "again",
+		IdempotencyKey: "retract-2",
+	}); !errors.Is(err, usagedomain.ErrUsageAlreadyCorrected) {
+		t.Fatalf("expected already corrected, got %v", err)
+	}
+
+	amended := ingest("evt-2", 10)
+	amendResp, err := service.Amend(ctx, amended.ID.String(), usagedomain.AmendUsageRequest{
+		Value:          4,
+		Reason:         "client sent bytes instead of kilobytes",
+		IdempotencyKey: "amend-1",
+	})
+	if err != nil {
+		t.Fatalf("amend: %v", err)
+	}
+	if amendResp.UsageEvent.Value != 4 || amendResp.UsageEvent.MeterID != amended.MeterID {
+		t.Fatalf("unexpected amendment event: value=%v meter=%s", amendResp.UsageEvent.Value, amendResp.UsageEvent.MeterID)
+	}
+	if !amendResp.UsageEvent.RecordedAt.Equal(amended.RecordedAt) {
+		t.Fatalf("expected amendment to keep the original timestamp")
+	}
+	if status := statusOf(amended); status != usagedomain.UsageStatusAmended {
+		t.Fatalf("expected original to be amended, got %s", status)
+	}
+
+	if _, err := service.Retract(ctx, ingest("evt-3", 1).ID.String(), usagedomain.RetractUsageRequest{
+		IdempotencyKey: "retract-3",
+	}); !errors.Is(err, usagedomain.ErrInvalidCorrectionReason) {
+		t.Fatalf("expected invalid reason, got %v", err)
+	}
+	if count := countUsageEvents(t, db); count != 5 {
+		t.Fatalf("expected 5 usage events, got %d", count)
+	}
+}
+
+func TestCorrectUsageInClosedCycleRequiresAdjustments(t *testing.T) {
+	node := mustNode(t)
+	orgID := node.Generate()
+	customerID := node.Generate()
+	meterID := node.Generate()
+
+	meter := &meterStub{
+		response: &meterdomain.Response{
+			ID:   meterID.String(),
+			Code: "api_calls",
+		},
+	}
+	service, db := setupUsageService(t, node, meter, cache.NewUsageResolverCache(), orgID, customerID)
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	recordedAt := time.Now().UTC().Add(-time.Hour)
+	event, err := service.Ingest(ctx, usagedomain.CreateIngestRequest{
+		CustomerID:     customerID.String(),
+		MeterCode:      "api_calls",
+		Value:          3,
+		RecordedAt:     recordedAt,
+		IdempotencyKey: "evt-closed",
+	})
+	if err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+
+	for _, status := range []string{"CLOSING", "CLOSED"} {
+		if err := db.Exec(`DELETE FROM billing_cycles`).Error; err != nil {
+			t.Fatalf("reset cycles: %v", err)
+		}
+		if err := db.Exec(
+			`INSERT INTO billing_cycles (id, org_id, subscription_id, period_start, period_end, status) VALUES (?, ?, ?, ?, ?, ?)`,
+			node.Generate(), orgID, event.SubscriptionID, recordedAt.Add(-time.Hour), recordedAt.Add(time.Hour), status,
+		).Error; err != nil {
+			t.Fatalf("seed cycle: %v", err)
+		}
+
+		_, err := service.Retract(ctx, event.ID.String(), usagedomain.RetractUsageRequest{
+			Reason:         "wrong customer",
+			IdempotencyKey: "retract-" + status,
+		})
+		want := usagedomain.ErrCorrectionUnavailable
+		if status == "CLOSING" {
+			want = usagedomain.ErrBillingCycleClosing
+		}
+		if !errors.Is(err, want) {
+			t.Fatalf("%s: expected %v, got %v", status, want, err)
+		}
+	}
+	if count := countUsageEvents(t, db); count != 1 {
+		t.Fatalf("expected only the original event, got %d", count)
+	}
+}
@@ -0,0 +1,429 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/events"
+	featuredomain "github.com/smallbiznis/railzway/internal/feature/domain"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// limitedUsageStatuses are the statuses counted against entitlement limits.
+// Accepted events are included so limits apply before enrichment catches up.
+var limitedUsageStatuses = []string{
+	usagedomain.UsageStatusAccepted,
+	usagedomain.UsageStatusEnriched,
+	usagedomain.UsageStatusRated,
+}
+
+type usageEntitlementRow struct {
+	ID          snowflake.ID
+	FeatureCode string
+	FeatureType string
+	MeterID     *snowflake.ID
+	UsageLimit  *float64
+	Enforcement *string
+}
+
+func (e *usageEntitlementRow) enforcement() featuredomain.LimitEnforcement {
+	if e.Enforcement != nil && featuredomain.LimitEnforcement(*e.Enforcement) == featuredomain.LimitEnforcementHard {
+		return featuredomain.LimitEnforcementHard
+	}
+	return featuredomain.LimitEnforcementSoft
+}
+
+func (s *Service) CheckEntitlement(ctx context.Context, req usagedomain.CheckEntitlementRequest) (*usagedomain.EntitlementCheckResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, usagedomain.ErrInvalidOrganization
+	}
+	customerID, err := s.parseID(req.CustomerID, usagedomain.ErrInvalidCustomer)
+	if err != nil {
+		return nil, err
+	}
+	featureCode := strings.TrimSpace(req.FeatureCode)
+	if featureCode == "" {
+		return nil, usagedomain.ErrInvalidFeatureCode
+	}
+	if req.Quantity < 0 || math.IsNaN(req.Quantity) || math.IsInf(req.Quantity, 0) {
+		return nil, usagedomain.ErrInvalidValue
+	}
+
+	resp := &usagedomain.EntitlementCheckResponse{
+		CustomerID:  customerID.String(),
+		FeatureCode: featureCode,
+	}
+	sub, err := s.resolveActiveSubscription(ctx, orgID, customerID.String(), "", req.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.ID == 0 {
+		resp.Reason = usagedomain.EntitlementReasonNoSubscription
+		return resp, nil
+	}
+	resp.SubscriptionID = sub.ID.String()
+
+	now := time.Now().UTC()
+	entitlement, err := s.findEntitlementByCode(ctx, sub.ID, featureCode, now)
+	if err != nil {
+		return nil, err
+	}
+	if entitlement == nil {
+		resp.Reason = usagedomain.EntitlementReasonNotEntitled
+		return resp, nil
+	}
+	resp.FeatureType = entitlement.FeatureType
+	resp.Allowed = true
+	if entitlement.UsageLimit == nil || entitlement.MeterID == nil {
+		return resp, nil
+	}
+
+	enforcement := string(entitlement.enforcement())
+	limit := *entitlement.UsageLimit
+	resp.Enforcement = &enforcement
+	resp.Limit = &limit
+
+	if s.metersvc == nil {
+		return nil, usagedomain.ErrInvalidMeter
+	}
+	meter, err := s.metersvc.GetByID(ctx, entitlement.MeterID.String())
+	if err != nil {
+		if errors.Is(err, meterdomain.ErrMeterNotFound) {
+			return nil, usagedomain.ErrInvalidMeter
+		}
+		return nil, err
+	}
+
+	// Without an open cycle there is no usage window to count against.
+	var used float64
+	cycle, err := s.findOpenCycle(ctx, s.db, orgID, sub.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cycle != nil {
+		used, err = s.cycleUsage(ctx, orgID, sub.ID, customerID, meter, cycle.PeriodStart, cycle.PeriodEnd)
+		if err != nil {
+			return nil, err
+		}
+		periodStart, periodEnd := cycle.PeriodStart.UTC(), cycle.PeriodEnd.UTC()
+		resp.PeriodStart = &periodStart
+		resp.PeriodEnd = &periodEnd
+	}
+	remaining := math.Max(limit-used, 0)
+	resp.Used = &used
+	resp.Remaining = &remaining
+
+	if req.Quantity > 0 {
+		resp.OverLimit = used+req.Quantity > limit
+	} else {
+		resp.OverLimit = used >= limit
+	}
+	if resp.OverLimit && entitlement.enforcement() == featuredomain.LimitEnforcementHard {
+		resp.Allowed = false
+		resp.Reason = usagedomain.EntitlementReasonLimitExceeded
+	}
+	return resp, nil
+}
+
+// cycleTally is the usage of a subscription's meter in one billing cycle,
+// seeded from stored events and advanced with each event accepted after.
+type cycleTally struct {
+	used  float64
+	count int64
+	seen  map[string]bool
+}
+
+// usageTally keeps the running cycle usage of a batch, so its events are
+// checked against each other and not only against stored usage.
+type usageTally map[string]*cycleTally
+
+func usageTallyKey(subscriptionID snowflake.ID, meterCode string, periodStart time.Time) string {
+	return subscriptionID.String() + "|" + meterCode + "|" + periodStart.UTC().Format(time.RFC3339Nano)
+}
+
+// applyUsageLimit checks the event against the limit of its meter's
+// entitlement, counted over the billing cycle the event is billed in. Hard
+// limits reject the event and soft limits flag it as OverLimit. Events
+// accepted earlier in the same batch are counted through tally, which may be
+// nil for a single event.
+//
+// Concurrent requests do not see each other and may overshoot a hard limit.
+func (s *Service) applyUsageLimit(
+	ctx context.Context,
+	record *usagedomain.UsageEvent,
+	subscriptionID snowflake.ID,
+	meterID snowflake.ID,
+	meter *meterdomain.Response,
+	tally usageTally,
+) error {
+	entitlement, err := s.findLimitedEntitlement(ctx, subscriptionID, meterID, record.RecordedAt)
+	if err != nil {
+		return err
+	}
+	if entitlement == nil {
+		return nil
+	}
+
+	billedAt := record.RecordedAt
+	if record.BillableAt != nil {
+		billedAt = *record.BillableAt
+	}
+	cycle, err := s.findCycleAt(ctx, s.db, record.OrgID, subscriptionID, billedAt)
+	if err != nil {
+		return err
+	}
+	if cycle == nil {
+		return nil
+	}
+
+	key := usageTallyKey(subscriptionID, meter.Code, cycle.PeriodStart)
+	running := tally[key]
+	if running == nil {
+		running, err = s.seedCycleTally(ctx, record, subscriptionID, meter, cycle.PeriodStart, cycle.PeriodEnd)
+		if err != nil {
+			return err
+		}
+		if tally != nil {
+			tally[key] = running
+		}
+	}
+	projected, seenKey, err := s.projectUsage(ctx, record, subscriptionID, meter, running, cycle.PeriodStart, cycle.PeriodEnd)
+	if err != nil {
+		return err
+	}
+	if projected > *entitlement.UsageLimit {
+		if entitlement.enforcement() == featuredomain.LimitEnforcementHard {
+			s.emitUsageLimitExceeded(record, featuredomain.LimitEnforcementHard)
+			return usagedomain.ErrUsageLimitExceeded
+		}
+		record.OverLimit = true
+	}
+
+	running.used = projected
+	running.count++
+	if seenKey != "" {
+		running.seen[seenKey] = true
+	}
+	return nil
+}
+
+// seedCycleTally reads the stored usage of the cycle the event is billed in.
+func (s *Service) seedCycleTally(
+	ctx context.Context,
+	record *usagedomain.UsageEvent,
+	subscriptionID snowflake.ID,
+	meter *meterdomain.Response,
+	start, end time.Time,
+) (*cycleTally, error) {
+	used, err := s.cycleUsage(ctx, record.OrgID, subscriptionID, record.CustomerID, meter, start, end)
+	if err != nil {
+		return nil, err
+	}
+	running := &cycleTally{used: used, seen: make(map[string]bool)}
+	aggregation, _ := meterdomain.NormalizeAggregation(meter.Aggregation)
+	if aggregation == meterdomain.AggregationMin || aggregation == meterdomain.AggregationAvg {
+		if err := s.db.WithContext(ctx).Raw(
+			`SELECT COUNT(*) `+usageWindow,
+			record.OrgID, meter.Code, subscriptionID, record.CustomerID, start, end, limitedUsageStatuses,
+		).Scan(&running.count).Error; err != nil {
+			return nil, err
+		}
+	}
+	return running, nil
+}
+
+// usageWindow selects the subscription's events of a meter billed in
+// [start, end). Accepted events have no meter ID yet, so events are matched by
+// meter code, and events the snapshot worker has not routed yet are counted
+// for the customer's subscription they were ingested against.
+const usageWindow = `FROM usage_events
+	 WHERE org_id = ? AND meter_code = ?
+	 AND (subscription_id = ? OR (subscription_id = 0 AND customer_id = ?))
+	 AND COALESCE(billable_at, recorded_at) >= ? AND COALESCE(billable_at, recorded_at) < ?
+	 AND status IN ?`
+
+// cycleUsage aggregates the subscription's usage of meter billed in
+// [start, end) with the meter's aggregation.
+func (s *Service) cycleUsage(
+	ctx context.Context,
+	orgID, subscriptionID, customerID snowflake.ID,
+	meter *meterdomain.Response,
+	start, end time.Time,
+) (float64, error) {
+	args := []any{orgID, meter.Code, subscriptionID, customerID, start, end, limitedUsageStatuses}
+
+	aggregation, _ := meterdomain.NormalizeAggregation(meter.Aggregation)
+	var query string
+	switch aggregation {
+	case meterdomain.AggregationMax:
+		query = `SELECT COALESCE(MAX(value), 0) ` + usageWindow
+	case meterdomain.AggregationMin:
+		query = `SELECT COALESCE(MIN(value), 0) ` + usageWindow
+	case meterdomain.AggregationAvg:
+		query = `SELECT COALESCE(AVG(value), 0) ` + usageWindow
+	case meterdomain.AggregationCount:
+		query = `SELECT COUNT(*) ` + usageWindow
+	case meterdomain.AggregationLast:
+		query = `SELECT value ` + usageWindow + ` ORDER BY recorded_at DESC, id DESC LIMIT 1`
+	case meterdomain.AggregationUniqueCount:
+		query = `SELECT COUNT(DISTINCT ` + metadataValueExpr(s.db) + `) ` + usageWindow
+		args = append([]any{strings.TrimSpace(meter.AggregationKey)}, args...)
+	default:
+		query = `SELECT COALESCE(SUM(value), 0) ` + usageWindow
+	}
+
+	var used float64
+	if err := s.db.WithContext(ctx).Raw(query, args...).Scan(&used).Error; err != nil {
+		return 0, err
+	}
+	return used, nil
+}
+
+// projectUsage returns the cycle usage once record is added to running. For
+// unique counts it also returns the record's key when it is a new one.
+func (s *Service) projectUsage(
+	ctx context.Context,
+	record *usagedomain.UsageEvent,
+	subscriptionID snowflake.ID,
+	meter *meterdomain.Response,
+	running *cycleTally,
+	start, end time.Time,
+) (float64, string, error) {
+	used := running.used
+	aggregation, _ := meterdomain.NormalizeAggregation(meter.Aggregation)
+	switch aggregation {
+	case meterdomain.AggregationMax:
+		return math.Max(used, record.Value), "", nil
+	case meterdomain.AggregationCount:
+		return used + 1, "", nil
+	case meterdomain.AggregationLast:
+		return record.Value, "", nil
+	case meterdomain.AggregationMin, meterdomain.AggregationAvg:
+		if running.count == 0 {
+			return record.Value, "", nil
+		}
+		if aggregation == meterdomain.AggregationMin {
+			return math.Min(used, record.Value), "", nil
+		}
+		return (used*float64(running.count) + record.Value) / float64(running.count+1), "", nil
+	case meterdomain.AggregationUniqueCount:
+		key := strings.TrimSpace(meter.AggregationKey)
+		if key == "" || record.Metadata[key] == nil {
+			return used, "", nil
+		}
+		value := metadataString(record.Metadata, key)
+		if running.seen[value] {
+			return used, "", nil
+		}
+		var seen int64
+		if err := s.db.WithContext(ctx).Raw(
+			`SELECT COUNT(*) `+usageWindow+` AND `+metadataValueExpr(s.db)+` = ?`,
+			record.OrgID, meter.Code, subscriptionID, record.CustomerID, start, end, limitedUsageStatuses,
+			key, value,
+		).Scan(&seen).Error; err != nil {
+			return 0, "", err
+		}
+		if seen > 0 {
+			return used, value, nil
+		}
+		return used + 1, value, nil
+	default:
+		return used + record.Value, "", nil
+	}
+}
+
+// emitUsageLimitExceeded publishes a usage.limit_exceeded event. Rejected
+// events are not stored, so their retries are deduplicated by idempotency key
+// instead of event ID.
+func (s *Service) emitUsageLimitExceeded(record *usagedomain.UsageEvent, enforcement featuredomain.LimitEnforcement) {
+	if s.outbox == nil || record == nil {
+		return
+	}
+	payload := map[string]any{
+		"customer_id":     record.CustomerID.String(),
+		"meter_code":      record.MeterCode,
+		"value":           record.Value,
+		"recorded_at":     record.RecordedAt.UTC().Format(time.RFC3339Nano),
+		"idempotency_key": record.IdempotencyKey,
+		"enforcement":     string(enforcement),
+	}
+	dedupeKey := "usage_limit_rejected:" + record.IdempotencyKey
+	if enforcement != featuredomain.LimitEnforcementHard {
+		payload["usage_event_id"] = record.ID.String()
+		dedupeKey = "usage_limit_exceeded:" + record.ID.String()
+	}
+	event := events.Event{
+		OrgID:     record.OrgID,
+		Type:      events.EventUsageLimitExceeded,
+		Payload:   payload,
+		DedupeKey: dedupeKey,
+	}
+	go func() {
+		_ = s.outbox.Publish(context.Background(), event)
+	}()
+}
+
+func (s *Service) findEntitlementByCode(ctx context.Context, subscriptionID snowflake.ID, featureCode string, at time.Time) (*usageEntitlementRow, error) {
+	return s.findEntitlement(ctx, `subscription_id = ? AND feature_code = ?`, subscriptionID, featureCode, at)
+}
+
+// findLimitedEntitlement returns the entitlement of meter when it carries a
+// usage limit.
+func (s *Service) findLimitedEntitlement(ctx context.Context, subscriptionID, meterID snowflake.ID, at time.Time) (*usageEntitlementRow, error) {
+	return s.findEntitlement(ctx, `subscription_id = ? AND meter_id = ? AND usage_limit IS NOT NULL`, subscriptionID, meterID, at)
+}
+
+func (s *Service) findEntitlement(ctx context.Context, filter string, subscriptionID snowflake.ID, value any, at time.Time) (*usageEntitlementRow, error) {
+	var rows []usageEntitlementRow
+	if err := s.db.WithContext(ctx).Raw(
+		`SELECT id, feature_code, feature_type, meter_id, usage_limit, enforcement
+		 FROM subscription_entitlements
+		 WHERE `+filter+`
+		   AND effective_from <= ?
+		   AND (effective_to IS NULL OR effective_to > ?)
+		 ORDER BY effective_from DESC
+		 LIMIT 1`,
+		subscriptionID,
+		value,
+		at,
+		at,
+	).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+// metadataValueExpr extracts a top-level metadata value as text, the same way
+// rating does for UNIQUE_COUNT meters.
+func metadataValueExpr(db *gorm.DB) string {
+	if db != nil && strings.EqualFold(db.Dialector.Name(), "sqlite") {
+		return `json_extract(metadata, '$.' || ?)`
+	}
+	return `metadata->>?`
+}
+
+// metadataString renders a top-level metadata value as text, matching
+// metadataValueExpr. Missing values yield "".
+func metadataString(metadata datatypes.JSONMap, key string) string {
+	value, ok := metadata[key]
+	if !ok || value == nil {
+		return ""
+	}
+	if text, ok := value.(string); ok {
+		return text
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smallbiznis/railzway/internal/cache"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestUsageLimitEnforcement(t *testing.T) {
+	node := mustNode(t)
+	orgID := node.Generate()
+	customerID := node.Generate()
+	subID := node.Generate()
+	callsID := node.Generate()
+	storageID := node.Generate()
+
+	calls := &meterdomain.Response{ID: callsID.String(), Code: "api_calls", Aggregation: meterdomain.AggregationSum}
+	storage := &meterdomain.Response{ID: storageID.String(), Code: "storage_gb", Aggregation: meterdomain.AggregationMax}
+	_, db := setupUsageService(t, node, &meterStub{}, cache.NewUsageResolverCache(), orgID, customerID)
+	meters := new(meterMock)
+	meters.On("GetByCode", mock.Anything, "api_calls").Return(calls, nil)
+	meters.On("GetByCode", mock.Anything, "storage_gb").Return(storage, nil)
+	sub := new(subscriptionMock)
+	sub.On("GetActiveByCustomerID", mock.Anything, mock.Anything).Return(subscriptiondomain.Subscription{ID: subID}, nil)
+	sub.On("ValidateUsageEntitlement", mock.Anything, subID, mock.Anything, mock.Anything).Return(nil)
+	service := NewService(ServiceParam{
+		DB:       db,
+		Log:      zap.NewNop(),
+		GenID:    node,
+		MeterSvc: &summaryMeters{meterMock: meters, byID: map[string]*meterdomain.Response{calls.ID: calls, storage.ID: storage}},
+		SubSvc:   sub,
+	})
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	cycleStart := now.Add(-24 * time.Hour)
+	if err := db.Exec(
+		`INSERT INTO billing_cycles (id, org_id, subscription_id, period_start, period_end, status) VALUES (?, ?, ?, ?, ?, ?)`,
+		node.Generate(), orgID, subID, cycleStart, now.AddDate(0, 1, 0), "OPEN",
+	).Error; err != nil {
+		t.Fatalf("seed cycle: %v", err)
+	}
+	hard, soft := "hard", "soft"
+	callsLimit, storageLimit := 10.0, 50.0
+	for _, entitlement := range []subscriptiondomain.SubscriptionEntitlement{
+		{FeatureCode: "api", FeatureType: "metered", MeterID: &callsID, UsageLimit: &callsLimit, Enforcement: &hard},
+		{FeatureCode: "storage", FeatureType: "metered", MeterID: &storageID, UsageLimit: &storageLimit, Enforcement: &soft},
+		{FeatureCode: "sso", FeatureType: "boolean"},
+	} {
+		entitlement.ID = node.Generate()
+		entitlement.OrgID = orgID
+		entitlement.SubscriptionID = subID
+		entitlement.FeatureName = entitlement.FeatureCode
+		entitlement.EffectiveFrom = cycleStart
+		entitlement.CreatedAt = cycleStart
+		if err := db.Create(&entitlement).Error; err != nil {
+			t.Fatalf("seed entitlement: %v", err)
+		}
+	}
+
+	ingest := func(meterCode, key string, value float64) (*usagedomain.UsageEvent, error) {
+		return service.Ingest(ctx, usagedomain.CreateIngestRequest{
+			CustomerID:     customerID.String(),
+			MeterCode:      meterCode,
+			Value:          value,
+			RecordedAt:     now,
+			IdempotencyKey: key,
+		})
+	}
+	if _, err := ingest("api_calls", "calls-1", 6); err != nil {
+		t.Fatalf("ingest within limit: %v", err)
+	}
+	if _, err := ingest("api_calls", "calls-2", 4); err != nil {
+		t.Fatalf("ingest up to limit: %v", err)
+	}
+	if _, err := ingest("api_calls", "calls-3", 1); !errors.Is(err, usagedomain.ErrUsageLimitExceeded) {
+		t.Fatalf("expected hard limit rejection, got %v", err)
+	}
+
+	// Usage routed to another subscription of the customer has its own limit.
+	if err := db.Create(&usagedomain.UsageEvent{
+		ID:             node.Generate(),
+		OrgID:          orgID,
+		CustomerID:     customerID,
+		SubscriptionID: node.Generate(),
+		MeterCode:      "api_calls",
+		Value:          5,
+		RecordedAt:     now,
+		Status:         usagedomain.UsageStatusEnriched,
+		IdempotencyKey: "other-subscription",
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}).Error; err != nil {
+		t.Fatalf("seed other subscription usage: %v", err)
+	}
+
+	within, err := ingest("storage_gb", "storage-1", 40)
+	if err != nil || within.OverLimit {
+		t.Fatalf("expected storage within limit, got %v (%v)", within, err)
+	}
+	over, err := ingest("storage_gb", "storage-2", 60)
+	if err != nil {
+		t.Fatalf("ingest over soft limit: %v", err)
+	}
+	if !over.OverLimit {
+		t.Fatalf("expected soft limit to flag the event")
+	}
+	var stored usagedomain.UsageEvent
+	if err := db.First(&stored, "id = ?", over.ID).Error; err != nil || !stored.OverLimit {
+		t.Fatalf("expected over_limit to be stored, got %v (%v)", stored.OverLimit, err)
+	}
+
+	check := func(featureCode string, quantity float64) *usagedomain.EntitlementCheckResponse {
+		t.Helper()
+		resp, err := service.CheckEntitlement(ctx, usagedomain.CheckEntitlementRequest{
+			CustomerID:  customerID.String(),
+			FeatureCode: featureCode,
+			Quantity:    quantity,
+		})
+		if err != nil {
+			t.Fatalf("check %s: %v", featureCode, err)
+		}
+		return resp
+	}
+	api := check("api", 0)
+	if api.Allowed || api.Reason != usagedomain.EntitlementReasonLimitExceeded || *api.Used != 10 || *api.Remaining != 0 {
+		t.Fatalf("unexpected api check: %+v", api)
+	}
+	if api.PeriodStart == nil || !api.PeriodStart.Equal(cycleStart) {
+		t.Fatalf("expected the open cycle period, got %v", api.PeriodStart)
+	}
+	storageCheck := check("storage", 0)
+	if !storageCheck.Allowed || !storageCheck.OverLimit || *storageCheck.Used != 60 {
+		t.Fatalf("expected soft limit to allow with over_limit, got %+v", storageCheck)
+	}
+	if sso := check("sso", 0); !sso.Allowed || sso.Limit != nil {
+		t.Fatalf("expected boolean feature to be allowed without limit, got %+v", sso)
+	}
+	if missing := check("reports", 0); missing.Allowed || missing.Reason != usagedomain.EntitlementReasonNotEntitled {
+		t.Fatalf("expected not entitled, got %+v", missing)
+	}
+
+	if _, err := service.CheckEntitlement(ctx, usagedomain.CheckEntitlementRequest{CustomerID: customerID.String()}); !errors.Is(err, usagedomain.ErrInvalidFeatureCode) {
+		t.Fatalf("expected invalid feature code, got %v", err)
+	}
+}
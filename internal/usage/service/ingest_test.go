@@ -8,6 +8,7 @@ import (
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/glebarez/sqlite"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
 	"github.com/smallbiznis/railzway/internal/meter/domain"
 	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
 	"github.com/smallbiznis/railzway/internal/orgcontext"
@@ -53,7 +54,47 @@ func (m *subscriptionMock) GetSubscriptionItem(context.Context, subscriptiondoma
 func (m *subscriptionMock) TransitionSubscription(ctx context.Context, id string, status subscriptiondomain.SubscriptionStatus, reason subscriptiondomain.TransitionReason) error {
 	return nil
 }
-func (m *subscriptionMock) ChangePlan(ctx context.Context, req subscriptiondomain.ChangePlanRequest) error {
+func (m *subscriptionMock) ChangePlan(ctx context.Context, req subscriptiondomain.ChangePlanRequest) (subscriptiondomain.SubscriptionPlanChange, error) {
+	return subscriptiondomain.SubscriptionPlanChange{}, nil
+}
+
+func (m *subscriptionMock) ListPlanChanges(ctx context.Context, subscriptionID string) ([]subscriptiondomain.SubscriptionPlanChange, error) {
+	return nil, nil
+}
+
+func (m *subscriptionMock) ApplyScheduledPlanChanges(ctx context.Context, limit int) error {
+	return nil
+}
+
+func (m *subscriptionMock) CreateSchedule(ctx context.Context, req subscriptiondomain.CreateScheduleRequest) (subscriptiondomain.SubscriptionSchedule, error) {
+	return subscriptiondomain.SubscriptionSchedule{}, nil
+}
+
+func (m *subscriptionMock) PreviewSchedule(ctx context.Context, req subscriptiondomain.CreateScheduleRequest) (subscriptiondomain.SchedulePreview, error) {
+	return subscriptiondomain.SchedulePreview{}, nil
+}
+
+func (m *subscriptionMock) GetSchedule(ctx context.Context, id string) (subscriptiondomain.SubscriptionSchedule, error) {
+	return subscriptiondomain.SubscriptionSchedule{}, nil
+}
+
+func (m *subscriptionMock) CancelSchedule(ctx context.Context, id string) (subscriptiondomain.SubscriptionSchedule, error) {
+	return subscriptiondomain.SubscriptionSchedule{}, nil
+}
+
+func (m *subscriptionMock) AdvanceSchedules(ctx context.Context, limit int) error {
+	return nil
+}
+
+func (m *subscriptionMock) UpdateItemQuantity(ctx context.Context, req subscriptiondomain.UpdateItemQuantityRequest) (subscriptiondomain.SubscriptionItemQuantityChange, error) {
+	return subscriptiondomain.SubscriptionItemQuantityChange{}, nil
+}
+
+func (m *subscriptionMock) ListItemQuantityChanges(ctx context.Context, subscriptionID, itemID string) ([]subscriptiondomain.SubscriptionItemQuantityChange, error) {
+	return nil, nil
+}
+
+func (m *subscriptionMock) ApplyScheduledQuantityChanges(ctx context.Context, limit int) error {
 	return nil
 }
 
@@ -93,7 +134,7 @@ func TestIngest_EntitlementGating(t *testing.T) {
 		t.Fatal(err)
 	}
 	// Migrate usage_events table
-	if err := db.AutoMigrate(&usagedomain.UsageEvent{}); err != nil {
+	if err := db.AutoMigrate(&usagedomain.UsageEvent{}, &billingcycledomain.BillingCycle{}, &subscriptiondomain.SubscriptionEntitlement{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -234,7 +275,7 @@ func TestIngest_EntitlementGating(t *testing.T) {
 
 func TestIngest_Idempotency_Strict(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
-	db.AutoMigrate(&usagedomain.UsageEvent{})
+	db.AutoMigrate(&usagedomain.UsageEvent{}, &billingcycledomain.BillingCycle{}, &subscriptiondomain.SubscriptionEntitlement{})
 	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS ux_usage_events_idempotency ON usage_events(org_id, idempotency_key)")
 
 	node, _ := snowflake.NewNode(1)
@@ -296,7 +337,7 @@ func TestIngest_Idempotency_Strict(t *testing.T) {
 func TestIngest_Idempotency_BypassEntitlementFailure(t *testing.T) {
 	// dedicated test for the "Entitlement Revoked" Case
 	db, _ := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
-	db.AutoMigrate(&usagedomain.UsageEvent{})
+	db.AutoMigrate(&usagedomain.UsageEvent{}, &billingcycledomain.BillingCycle{}, &subscriptiondomain.SubscriptionEntitlement{})
 	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS ux_usage_events_idempotency ON usage_events(org_id, idempotency_key)")
 
 	node, _ := snowflake.NewNode(1)
@@ -349,3 +390,56 @@ func TestIngest_Idempotency_BypassEntitlementFailure(t *testing.T) {
 func WithTestOrgContext(ctx context.Context, orgID snowflake.ID) context.Context {
 	return orgcontext.WithOrgID(ctx, int64(orgID))
 }
+
+func TestIngest_SubscriptionRouting(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	db.AutoMigrate(&usagedomain.UsageEvent{}, &billingcycledomain.BillingCycle{}, &subscriptiondomain.SubscriptionEntitlement{})
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS ux_usage_events_idempotency ON usage_events(org_id, idempotency_key)")
+
+	node, _ := snowflake.NewNode(1)
+	orgID := node.Generate()
+	customerID := node.Generate()
+	subID := node.Generate()
+	meterID := node.Generate()
+
+	mockSub := new(subscriptionMock)
+	mockMeter := new(meterMock)
+	mockMeter.On("GetByCode", mock.Anything, "storage").Return(&meterdomain.Response{ID: meterID.String(), Code: "storage"}, nil)
+	mockSub.On("GetActiveByCustomerID", mock.Anything, subscriptiondomain.GetActiveByCustomerIDRequest{
+		CustomerID: customerID.String(),
+		MeterCode:  "storage",
+	}).Return(subscriptiondomain.Subscription{}, subscriptiondomain.ErrAmbiguousSubscription)
+	mockSub.On("GetActiveByCustomerID", mock.Anything, subscriptiondomain.GetActiveByCustomerIDRequest{
+		CustomerID:     customerID.String(),
+		MeterCode:      "storage",
+		SubscriptionID: subID.String(),
+	}).Return(subscriptiondomain.Subscription{ID: subID}, nil)
+	mockSub.On("ValidateUsageEntitlement", mock.Anything, subID, meterID, mock.Anything).Return(nil)
+
+	svc := NewService(ServiceParam{
+		DB:       db,
+		Log:      zap.NewNop(),
+		GenID:    node,
+		MeterSvc: mockMeter,
+		SubSvc:   mockSub,
+	})
+	ctx := WithTestOrgContext(context.Background(), orgID)
+
+	req := usagedomain.CreateIngestRequest{
+		CustomerID:     customerID.String(),
+		MeterCode:      "storage",
+		Value:          1,
+		RecordedAt:     time.Now(),
+		IdempotencyKey: "routing_ambiguous",
+	}
+	_, err := svc.Ingest(ctx, req)
+	assert.ErrorIs(t, err, usagedomain.ErrAmbiguousSubscription)
+
+	req.IdempotencyKey = "routing_explicit"
+	req.SubscriptionID = subID.String()
+	res, err := svc.Ingest(ctx, req)
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) {
+		assert.Equal(t, subID, res.SubscriptionID)
+	}
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	billingcycledomain "github.com/smallbiznis/railzway/internal/billingcycle/domain"
+	"github.com/smallbiznis/railzway/internal/events"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type usageCycleRow struct {
+	ID          snowflake.ID
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Status      billingcycledomain.BillingCycleStatus
+}
+
+func (s *Service) GetLatePolicy(ctx context.Context) (*usagedomain.LatePolicyResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, usagedomain.ErrInvalidOrganization
+	}
+
+	policy, err := s.findLatePolicy(ctx, s.db, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return &usagedomain.LatePolicyResponse{
+			OrgID:  orgID.String(),
+			Policy: usagedomain.DefaultLatePolicy,
+		}, nil
+	}
+	return toLatePolicyResponse(policy), nil
+}
+
+func (s *Service) UpsertLatePolicy(ctx context.Context, req usagedomain.UpsertLatePolicyRequest) (*usagedomain.LatePolicyResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, usagedomain.ErrInvalidOrganization
+	}
+
+	value, err := parseLatePolicy(req.Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	policy := &usagedomain.LatePolicy{
+		OrgID:     orgID,
+		Policy:    value,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := s.findLatePolicy(ctx, tx, orgID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			policy.CreatedAt = existing.CreatedAt
+		}
+		return tx.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "org_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"policy", "updated_at"}),
+		}).Create(policy).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info("late usage policy updated",
+		zap.String("org_id", orgID.String()),
+		zap.String("policy", value),
+	)
+	return toLatePolicyResponse(policy), nil
+}
+
+// applyLatePolicy leaves events of OPEN cycles untouched. When the cycle
+// covering RecordedAt has started closing, the organization's late usage
+// policy decides whether the event is rejected, carried forward into the open
+// cycle or billed through an adjustment; the decision is recorded on record.
+func (s *Service) applyLatePolicy(ctx context.Context, record *usagedomain.UsageEvent, subscriptionID snowflake.ID) error {
+	db := s.db.WithContext(ctx)
+	cycle, err := s.findCycleAt(ctx, db, record.OrgID, subscriptionID, record.RecordedAt)
+	if err != nil {
+		return err
+	}
+	if cycle == nil || cycle.Status == billingcycledomain.BillingCycleStatusOpen {
+		return nil
+	}
+
+	policy := usagedomain.DefaultLatePolicy
+	stored, err := s.findLatePolicy(ctx, db, record.OrgID)
+	if err != nil {
+		return err
+	}
+	if stored != nil {
+		policy = stored.Policy
+	}
+
+	switch policy {
+	case usagedomain.LatePolicyCarryForward:
+		open, err := s.findOpenCycle(ctx, db, record.OrgID, subscriptionID)
+		if err != nil {
+			return err
+		}
+		if open == nil {
+			return usagedomain.ErrNoOpenBillingCycle
+		}
+		billableAt := open.PeriodStart
+		record.BillableAt = &billableAt
+	case usagedomain.LatePolicyAdjustment:
+		if s.adjustmentSvc == nil {
+			return usagedomain.ErrCorrectionUnavailable
+		}
+	default:
+		policy = usagedomain.LatePolicyReject
+		s.emitUsageLate(record, cycle, policy)
+		return usagedomain.ErrLateUsageRejected
+	}
+
+	record.LatePolicy = &policy
+	return nil
+}
+
+// emitUsageLate publishes a usage.late event. Rejected events are not stored,
+// so their retries are deduplicated by idempotency key instead of event ID.
+func (s *Service) emitUsageLate(record *usagedomain.UsageEvent, cycle *usageCycleRow, policy string) {
+	if s.outbox == nil || record == nil {
+		return
+	}
+	payload := map[string]any{
+		"customer_id":     record.CustomerID.String(),
+		"meter_code":      record.MeterCode,
+		"value":           record.Value,
+		"recorded_at":     record.RecordedAt.UTC().Format(time.RFC3339Nano),
+		"idempotency_key": record.IdempotencyKey,
+		"late_policy":     policy,
+	}
+	if cycle != nil {
+		payload["billing_cycle_id"] = cycle.ID.String()
+		payload["billing_cycle_status"] = string(cycle.Status)
+	}
+	dedupeKey := "usage_late_rejected:" + record.IdempotencyKey
+	if policy != usagedomain.LatePolicyReject {
+		payload["usage_event_id"] = record.ID.String()
+		dedupeKey = "usage_late:" + record.ID.String()
+	}
+	if record.BillableAt != nil {
+		payload["billable_at"] = record.BillableAt.UTC().Format(time.RFC3339Nano)
+	}
+	event := events.Event{
+		OrgID:     record.OrgID,
+		Type:      events.EventUsageLate,
+		Payload:   payload,
+		DedupeKey: dedupeKey,
+	}
+	go func() {
+		_ = s.outbox.Publish(context.Background(), event)
+	}()
+}
+
+func (s *Service) findLatePolicy(ctx context.Context, db *gorm.DB, orgID snowflake.ID) (*usagedomain.LatePolicy, error) {
+	var policies []usagedomain.LatePolicy
+	if err := db.WithContext(ctx).
+		Where("org_id = ?", orgID).
+		Limit(1).
+		Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	return &policies[0], nil
+}
+
+// findCycleAt returns the cycle of the subscription whose period contains at.
+func (s *Service) findCycleAt(ctx context.Context, db *gorm.DB, orgID, subscriptionID snowflake.ID, at time.Time) (*usageCycleRow, error) {
+	var rows []usageCycleRow
+	if err := db.WithContext(ctx).Raw(
+		`SELECT id, period_start, period_end, status
+		 FROM billing_cycles
+		 WHERE org_id = ? AND subscription_id = ?
+		   AND period_start <= ? AND period_end > ?
+		 ORDER BY period_start DESC
+		 LIMIT 1`,
+		orgID,
+		subscriptionID,
+		at,
+		at,
+	).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+func (s *Service) findOpenCycle(ctx context.Context, db *gorm.DB, orgID, subscriptionID snowflake.ID) (*usageCycleRow, error) {
+	var rows []usageCycleRow
+	if err := db.WithContext(ctx).Raw(
+		`SELECT id, period_start, period_end, status
+		 FROM billing_cycles
+		 WHERE org_id = ? AND subscription_id = ? AND status = ?
+		 ORDER BY period_start DESC
+		 LIMIT 1`,
+		orgID,
+		subscriptionID,
+		billingcycledomain.BillingCycleStatusOpen,
+	).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+func parseLatePolicy(value string) (string, error) {
+	switch policy := strings.ToLower(strings.TrimSpace(value)); policy {
+	case usagedomain.LatePolicyReject, usagedomain.LatePolicyCarryForward, usagedomain.LatePolicyAdjustment:
+		return policy, nil
+	default:
+		return "", usagedomain.ErrInvalidLatePolicy
+	}
+}
+
+func toLatePolicyResponse(policy *usagedomain.LatePolicy) *usagedomain.LatePolicyResponse {
+	createdAt := policy.CreatedAt
+	updatedAt := policy.UpdatedAt
+	return &usagedomain.LatePolicyResponse{
+		OrgID:     policy.OrgID.String(),
+		Policy:    policy.Policy,
+		CreatedAt: &createdAt,
+		UpdatedAt: &updatedAt,
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
+	"github.com/smallbiznis/railzway/internal/cache"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	subscriptiondomain "github.com/smallbiznis/railzway/internal/subscription/domain"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type adjustmentStub struct{}
+
+func (adjustmentStub) ApplyUsageCorrection(context.Context, string) (*adjustmentdomain.Adjustment, error) {
+	return nil, nil
+}
+
+func (adjustmentStub) ApplyLateUsage(context.Context, string) (*adjustmentdomain.Adjustment, error) {
+	return nil, nil
+}
+
+func (adjustmentStub) ProcessLateUsage(context.Context, int) error { return nil }
+
+func TestIngestLateUsagePolicies(t *testing.T) {
+	node := mustNode(t)
+	orgID := node.Generate()
+	customerID := node.Generate()
+	meterID := node.Generate()
+	subID := node.Generate()
+
+	meter := &meterStub{
+		response: &meterdomain.Response{
+			ID:   meterID.String(),
+			Code: "api_calls",
+		},
+	}
+	_, db := setupUsageService(t, node, meter, cache.NewUsageResolverCache(), orgID, customerID)
+
+	sub := new(subscriptionMock)
+	sub.On("GetActiveByCustomerID", mock.Anything, mock.Anything).Return(subscriptiondomain.Subscription{ID: subID}, nil)
+	sub.On("ValidateUsageEntitlement", mock.Anything, subID, meterID, mock.Anything).Return(nil)
+	newService := func(adjustments adjustmentdomain.Service) usagedomain.Service {
+		return NewService(ServiceParam{
+			DB:            db,
+			Log:           zap.NewNop(),
+			GenID:         node,
+			MeterSvc:      meter,
+			SubSvc:        sub,
+			AdjustmentSvc: adjustments,
+		})
+	}
+	service := newService(nil)
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	closedStart := now.AddDate(0, -1, 0)
+	if err := db.Exec(
+		`INSERT INTO billing_cycles (id, org_id, subscription_id, period_start, period_end, status) VALUES (?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?)`,
+		node.Generate(), orgID, subID, closedStart, now.Add(-time.Hour), "CLOSED",
+		node.Generate(), orgID, subID, now.Add(-time.Hour), now.AddDate(0, 1, 0), "OPEN",
+	).Error; err != nil {
+		t.Fatalf("seed cycles: %v", err)
+	}
+
+	ingest := func(svc usagedomain.Service, key string, recordedAt time.Time) (*usagedomain.UsageEvent, error) {
+		return svc.Ingest(ctx, usagedomain.CreateIngestRequest{
+			CustomerID:     customerID.String(),
+			MeterCode:      "api_calls",
+			Value:          1,
+			RecordedAt:     recordedAt,
+			IdempotencyKey: key,
+		})
+	}
+	late := closedStart.Add(time.Hour)
+
+	current, err := ingest(service, "evt-current", now)
+	if err != nil {
+		t.Fatalf("ingest current: %v", err)
+	}
+	if current.LatePolicy != nil || current.BillableAt != nil {
+		t.Fatalf("expected on-time event without late decision")
+	}
+
+	policy, err := service.GetLatePolicy(ctx)
+	if err != nil {
+		t.Fatalf("get policy: %v", err)
+	}
+	if policy.Policy != usagedomain.LatePolicyCarryForward {
+		t.Fatalf("expected default carry forward policy, got %s", policy.Policy)
+	}
+	if _, err := service.UpsertLatePolicy(ctx, usagedomain.UpsertLatePolicyRequest{Policy: usagedomain.LatePolicyReject}); err != nil {
+		t.Fatalf("upsert reject: %v", err)
+	}
+	if _, err := ingest(service, "evt-rejected", late); !errors.Is(err, usagedomain.ErrLateUsageRejected) {
+		t.Fatalf("expected late rejection, got %v", err)
+	}
+	if count := countUsageEvents(t, db); count != 1 {
+		t.Fatalf("expected rejected event not to be stored, got %d events", count)
+	}
+
+	if _, err := service.UpsertLatePolicy(ctx, usagedomain.UpsertLatePolicyRequest{Policy: "ignore"}); !errors.Is(err, usagedomain.ErrInvalidLatePolicy) {
+		t.Fatalf("expected invalid policy, got %v", err)
+	}
+	if _, err := service.UpsertLatePolicy(ctx, usagedomain.UpsertLatePolicyRequest{Policy: " Carry_Forward "}); err != nil {
+		t.Fatalf("upsert carry forward: %v", err)
+	}
+	carried, err := ingest(service, "evt-carried", late)
+	if err != nil {
+		t.Fatalf("ingest carried: %v", err)
+	}
+	if carried.LatePolicy == nil || *carried.LatePolicy != usagedomain.LatePolicyCarryForward {
+		t.Fatalf("expected carry forward decision, got %v", carried.LatePolicy)
+	}
+	if carried.BillableAt == nil || !carried.BillableAt.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("expected billable_at at the open cycle start, got %v", carried.BillableAt)
+	}
+	var stored usagedomain.UsageEvent
+	if err := db.First(&stored, "id = ?", carried.ID).Error; err != nil {
+		t.Fatalf("load carried: %v", err)
+	}
+	if stored.LatePolicy == nil || stored.BillableAt == nil || !stored.RecordedAt.Equal(late) {
+		t.Fatalf("expected late decision to be stored with the original recorded_at")
+	}
+
+	policy, err = service.UpsertLatePolicy(ctx, usagedomain.UpsertLatePolicyRequest{Policy: usagedomain.LatePolicyAdjustment})
+	if err != nil {
+		t.Fatalf("upsert adjustment: %v", err)
+	}
+	if policy.Policy != usagedomain.LatePolicyAdjustment || policy.CreatedAt == nil {
+		t.Fatalf("unexpected policy response: %+v", policy)
+	}
+	if _, err := ingest(service, "evt-unavailable", late); !errors.Is(err, usagedomain.ErrCorrectionUnavailable) {
+		t.Fatalf("expected adjustments to be required, got %v", err)
+	}
+	adjusted, err := ingest(newService(adjustmentStub{}), "evt-adjusted", late)
+	if err != nil {
+		t.Fatalf("ingest adjusted: %v", err)
+	}
+	if adjusted.LatePolicy == nil || *adjusted.LatePolicy != usagedomain.LatePolicyAdjustment || adjusted.BillableAt != nil {
+		t.Fatalf("expected adjustment decision without billable_at")
+	}
+	if count := countUsageEvents(t, db); count != 3 {
+		t.Fatalf("expected 3 usage events, got %d", count)
+	}
+}
@@ -8,9 +8,11 @@ import (
 	"time"
 
 	"github.com/bwmarrin/snowflake"
+	adjustmentdomain "github.com/smallbiznis/railzway/internal/adjustment/domain"
 	"github.com/smallbiznis/railzway/internal/cache"
 	"github.com/smallbiznis/railzway/internal/cloudmetrics"
 	"github.com/smallbiznis/railzway/internal/events"
+	featuredomain "github.com/smallbiznis/railzway/internal/feature/domain"
 	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
 	obsmetrics "github.com/smallbiznis/railzway/internal/observability/metrics"
 	"github.com/smallbiznis/railzway/internal/orgcontext"
@@ -38,8 +40,9 @@ type ServiceParam struct {
 	Metrics       *cloudmetrics.CloudMetrics
 	ObsMetrics    *obsmetrics.Metrics `optional:"true"`
 	ResolverCache cache.UsageResolverCache
-	Outbox        *events.Outbox  `optional:"true"`
-	LiveEvents    *liveevents.Hub `optional:"true"`
+	Outbox        *events.Outbox           `optional:"true"`
+	LiveEvents    *liveevents.Hub          `optional:"true"`
+	AdjustmentSvc adjustmentdomain.Service `optional:"true"`
 }
 
 type Service struct {
@@ -55,6 +58,7 @@ type Service struct {
 	resolverCache cache.UsageResolverCache
 	outbox        *events.Outbox
 	liveEvents    *liveevents.Hub
+	adjustmentSvc adjustmentdomain.Service
 }
 
 func NewService(p ServiceParam) usagedomain.Service {
@@ -71,6 +75,7 @@ func NewService(p ServiceParam) usagedomain.Service {
 		resolverCache: p.ResolverCache,
 		outbox:        p.Outbox,
 		liveEvents:    p.LiveEvents,
+		adjustmentSvc: p.AdjustmentSvc,
 	}
 }
 
@@ -84,22 +89,11 @@ func (s *Service) Ingest(
 		return nil, usagedomain.ErrInvalidOrganization
 	}
 
-	customerID, err := s.parseID(req.CustomerID, usagedomain.ErrInvalidCustomer)
+	customerID, meterCode, idempotencyKey, err := s.validateIngestRequest(req)
 	if err != nil {
 		return nil, err
 	}
 
-	meterCode := strings.TrimSpace(req.MeterCode)
-	if meterCode == "" {
-		return nil, usagedomain.ErrInvalidMeterCode
-	}
-
-	if err := validateUsageEvent(req); err != nil {
-		return nil, err
-	}
-
-	idempotencyKey := normalizeIdempotencyKey(req.IdempotencyKey)
-
 	// 1. Strict Idempotency: Check presence BEFORE logic
 	// If the event was already accepted, return it strictly as-is.
 	// This prevents "permission drift" on retries (e.g. sub cancelled after Event 1 but before Retry 1).
@@ -112,8 +106,70 @@ func (s *Service) Ingest(
 		return existing, nil
 	}
 
-	// ... continue to resolving ...
-	sub, err := s.resolveActiveSubscription(ctx, orgID, req.CustomerID)
+	record, err := s.buildUsageEvent(ctx, orgID, customerID, meterCode, idempotencyKey, req, time.Now().UTC(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	inserted, err := s.insertUsageEvent(ctx, record, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// 🔁 Idempotency hit → fetch existing
+	if !inserted && idempotencyKey != "" {
+		existing, err := s.findUsageEventByIdempotencyKey(
+			ctx,
+			orgID,
+			idempotencyKey,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			s.emitLiveUsageEvent(existing, liveevents.StatusDeduplicated, liveevents.SourceAPI)
+			return existing, nil
+		}
+	}
+
+	s.publishAccepted(ctx, record)
+
+	return record, nil
+}
+
+// validateIngestRequest checks the request shape without touching the database.
+func (s *Service) validateIngestRequest(req usagedomain.CreateIngestRequest) (snowflake.ID, string, string, error) {
+	customerID, err := s.parseID(req.CustomerID, usagedomain.ErrInvalidCustomer)
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	meterCode := strings.TrimSpace(req.MeterCode)
+	if meterCode == "" {
+		return 0, "", "", usagedomain.ErrInvalidMeterCode
+	}
+
+	if err := validateUsageEvent(req); err != nil {
+		return 0, "", "", err
+	}
+
+	return customerID, meterCode, normalizeIdempotencyKey(req.IdempotencyKey), nil
+}
+
+// buildUsageEvent resolves the subscription and meter the event is ingested
+// against, enforces entitlement gating and returns the record to insert.
+// tally carries the running usage of a batch and is nil for single events.
+func (s *Service) buildUsageEvent(
+	ctx context.Context,
+	orgID snowflake.ID,
+	customerID snowflake.ID,
+	meterCode string,
+	idempotencyKey string,
+	req usagedomain.CreateIngestRequest,
+	now time.Time,
+	tally usageTally,
+) (*usagedomain.UsageEvent, error) {
+	sub, err := s.resolveActiveSubscription(ctx, orgID, req.CustomerID, meterCode, req.SubscriptionID)
 	if err != nil {
 		return nil, err
 	}
@@ -129,7 +185,6 @@ func (s *Service) Ingest(
 		return nil, usagedomain.ErrInvalidMeter
 	}
 
-	now := time.Now().UTC()
 	recordedAt := req.RecordedAt
 	if recordedAt.IsZero() {
 		recordedAt = now
@@ -149,20 +204,16 @@ func (s *Service) Ingest(
 		if err := s.subSvc.ValidateUsageEntitlement(ctx, sub.ID, meterID, recordedAt); err != nil {
 			// If feature not entitled, we must reject.
 			if errors.Is(err, subscriptiondomain.ErrFeatureNotEntitled) {
-				// Map to a new usage domain error or return explicitly
-				return nil, errors.New("usage_rejected_feature_not_entitled")
+				return nil, usagedomain.ErrFeatureNotEntitled
 			}
-			// For other errors (db issues), return them?
 			// Strict gating -> if we can't validate, we shouldn't accept.
 			return nil, err
 		}
 	} else {
 		// Critical: If subSvc is missing, we cannot enforce gating.
-		return nil, errors.New("usage_ingestion_gating_unavailable")
+		return nil, usagedomain.ErrGatingUnavailable
 	}
 
-	// idempotencyKey already normalized above
-
 	record := &usagedomain.UsageEvent{
 		ID:             s.genID.Generate(),
 		OrgID:          orgID,
@@ -176,44 +227,42 @@ func (s *Service) Ingest(
 		UpdatedAt:      now,
 	}
 
+	// An explicit subscription is kept so the snapshot worker does not route
+	// the event again.
+	if strings.TrimSpace(req.SubscriptionID) != "" {
+		record.SubscriptionID = sub.ID
+	}
 	if req.Metadata != nil {
 		record.Metadata = datatypes.JSONMap(req.Metadata)
 	}
-
-	inserted, err := s.insertUsageEvent(ctx, record, idempotencyKey)
-	if err != nil {
+	if err := s.applyLatePolicy(ctx, record, sub.ID); err != nil {
 		return nil, err
 	}
-
-	// 🔁 Idempotency hit → fetch existing
-	if !inserted && idempotencyKey != "" {
-		existing, err := s.findUsageEventByIdempotencyKey(
-			ctx,
-			orgID,
-			idempotencyKey,
-		)
-		if err != nil {
-			return nil, err
-		}
-		if existing != nil {
-			s.emitLiveUsageEvent(existing, liveevents.StatusDeduplicated, liveevents.SourceAPI)
-			return existing, nil
-		}
+	if err := s.applyUsageLimit(ctx, record, sub.ID, meterID, meter, tally); err != nil {
+		return nil, err
 	}
+	return record, nil
+}
 
+// publishAccepted records metrics and emits events for a newly stored usage event.
+func (s *Service) publishAccepted(ctx context.Context, record *usagedomain.UsageEvent) {
 	// async metrics (best effort)
 	if s.metrics != nil {
-		go s.metrics.IncUsageEvent(orgID.String(), meterCode)
+		go s.metrics.IncUsageEvent(record.OrgID.String(), record.MeterCode)
 	}
 
 	if s.obsMetrics != nil {
-		s.obsMetrics.RecordUsageIngest(ctx, meterCode)
+		s.obsMetrics.RecordUsageIngest(ctx, record.MeterCode)
 	}
 
 	s.emitUsageIngested(record)
 	s.emitLiveUsageEvent(record, liveevents.StatusAccepted, liveevents.SourceAPI)
-
-	return record, nil
+	if record.LatePolicy != nil {
+		s.emitUsageLate(record, nil, *record.LatePolicy)
+	}
+	if record.OverLimit {
+		s.emitUsageLimitExceeded(record, featuredomain.LimitEnforcementSoft)
+	}
 }
 
 func (s *Service) List(ctx context.Context, req usagedomain.ListUsageRequest) (usagedomain.ListUsageResponse, error) {
@@ -277,9 +326,12 @@ func (s *Service) resolveMeter(ctx context.Context, orgID snowflake.ID, meterCod
 	return meter, nil
 }
 
-func (s *Service) resolveActiveSubscription(ctx context.Context, orgID snowflake.ID, customerID string) (subscriptiondomain.Subscription, error) {
+// resolveActiveSubscription routes to one of the customer's active
+// subscriptions: the explicit subscriptionID, else the one billing meterCode.
+// An empty meterCode resolves the most recent subscription.
+func (s *Service) resolveActiveSubscription(ctx context.Context, orgID snowflake.ID, customerID, meterCode, subscriptionID string) (subscriptiondomain.Subscription, error) {
 	if s.resolverCache != nil {
-		if cached, ok := s.resolverCache.GetActiveSubscription(orgID.String(), customerID); ok {
+		if cached, ok := s.resolverCache.GetActiveSubscription(orgID.String(), customerID, meterCode, subscriptionID); ok {
 			return cached, nil
 		}
 	}
@@ -287,7 +339,9 @@ func (s *Service) resolveActiveSubscription(ctx context.Context, orgID snowflake
 		return subscriptiondomain.Subscription{}, nil
 	}
 	subscription, err := s.subSvc.GetActiveByCustomerID(ctx, subscriptiondomain.GetActiveByCustomerIDRequest{
-		CustomerID: customerID,
+		CustomerID:     customerID,
+		MeterCode:      meterCode,
+		SubscriptionID: subscriptionID,
 	})
 	if err != nil {
 		switch {
@@ -295,12 +349,16 @@ func (s *Service) resolveActiveSubscription(ctx context.Context, orgID snowflake
 			return subscriptiondomain.Subscription{}, nil
 		case errors.Is(err, subscriptiondomain.ErrInvalidCustomer):
 			return subscriptiondomain.Subscription{}, usagedomain.ErrInvalidCustomer
+		case errors.Is(err, subscriptiondomain.ErrInvalidSubscription):
+			return subscriptiondomain.Subscription{}, usagedomain.ErrInvalidSubscription
+		case errors.Is(err, subscriptiondomain.ErrAmbiguousSubscription):
+			return subscriptiondomain.Subscription{}, usagedomain.ErrAmbiguousSubscription
 		default:
 			return subscriptiondomain.Subscription{}, nil
 		}
 	}
 	if s.resolverCache != nil {
-		s.resolverCache.SetActiveSubscription(orgID.String(), customerID, subscription)
+		s.resolverCache.SetActiveSubscription(orgID.String(), customerID, meterCode, subscriptionID, subscription)
 	}
 	return subscription, nil
 }
@@ -377,8 +435,9 @@ func (s *Service) insertUsageEventSQLite(ctx context.Context, record *usagedomai
 	query := `INSERT INTO usage_events (
 		id, org_id, customer_id, subscription_id, subscription_item_id,
 		meter_id, meter_code, value, recorded_at, status, error,
-		idempotency_key, metadata, created_at, updated_at
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		idempotency_key, metadata, late_policy, billable_at, over_limit,
+		created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	if idempotencyKey != "" {
 		query += " ON CONFLICT (org_id, idempotency_key) DO NOTHING"
 	}
@@ -397,6 +456,9 @@ func (s *Service) insertUsageEventSQLite(ctx context.Context, record *usagedomai
 		record.Error,
 		idempotencyKey,
 		record.Metadata,
+		record.LatePolicy,
+		record.BillableAt,
+		record.OverLimit,
 		record.CreatedAt,
 		record.UpdatedAt,
 	)
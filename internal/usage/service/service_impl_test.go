@@ -263,7 +263,47 @@ func (s *subscriptionStub) GetSubscriptionItem(context.Context, subscriptiondoma
 func (s *subscriptionStub) TransitionSubscription(ctx context.Context, id string, status subscriptiondomain.SubscriptionStatus, reason subscriptiondomain.TransitionReason) error {
 	return nil
 }
-func (s *subscriptionStub) ChangePlan(ctx context.Context, req subscriptiondomain.ChangePlanRequest) error {
+func (s *subscriptionStub) ChangePlan(ctx context.Context, req subscriptiondomain.ChangePlanRequest) (subscriptiondomain.SubscriptionPlanChange, error) {
+	return subscriptiondomain.SubscriptionPlanChange{}, nil
+}
+
+func (s *subscriptionStub) ListPlanChanges(ctx context.Context, subscriptionID string) ([]subscriptiondomain.SubscriptionPlanChange, error) {
+	return nil, nil
+}
+
+func (s *subscriptionStub) ApplyScheduledPlanChanges(ctx context.Context, limit int) error {
+	return nil
+}
+
+func (s *subscriptionStub) CreateSchedule(ctx context.Context, req subscriptiondomain.CreateScheduleRequest) (subscriptiondomain.SubscriptionSchedule, error) {
+	return subscriptiondomain.SubscriptionSchedule{}, nil
+}
+
+func (s *subscriptionStub) PreviewSchedule(ctx context.Context, req subscriptiondomain.CreateScheduleRequest) (subscriptiondomain.SchedulePreview, error) {
+	return subscriptiondomain.SchedulePreview{}, nil
+}
+
+func (s *subscriptionStub) GetSchedule(ctx context.Context, id string) (subscriptiondomain.SubscriptionSchedule, error) {
+	return subscriptiondomain.SubscriptionSchedule{}, nil
+}
+
+func (s *subscriptionStub) CancelSchedule(ctx context.Context, id string) (subscriptiondomain.SubscriptionSchedule, error) {
+	return subscriptiondomain.SubscriptionSchedule{}, nil
+}
+
+func (s *subscriptionStub) AdvanceSchedules(ctx context.Context, limit int) error {
+	return nil
+}
+
+func (s *subscriptionStub) UpdateItemQuantity(ctx context.Context, req subscriptiondomain.UpdateItemQuantityRequest) (subscriptiondomain.SubscriptionItemQuantityChange, error) {
+	return subscriptiondomain.SubscriptionItemQuantityChange{}, nil
+}
+
+func (s *subscriptionStub) ListItemQuantityChanges(ctx context.Context, subscriptionID, itemID string) ([]subscriptiondomain.SubscriptionItemQuantityChange, error) {
+	return nil, nil
+}
+
+func (s *subscriptionStub) ApplyScheduledQuantityChanges(ctx context.Context, limit int) error {
 	return nil
 }
 
@@ -290,6 +330,12 @@ func prepareUsageSchema(t *testing.T, db *gorm.DB) {
 		idempotency_key TEXT,
 		metadata JSON,
 		snapshot_at DATETIME,
+		corrects_event_id BIGINT,
+		correction_type TEXT,
+		correction_reason TEXT,
+		late_policy TEXT,
+		billable_at DATETIME,
+		over_limit BOOLEAN NOT NULL DEFAULT FALSE,
 		created_at DATETIME NOT NULL,
 		updated_at DATETIME NOT NULL
 	)`).Error; err != nil {
@@ -299,6 +345,46 @@ func prepareUsageSchema(t *testing.T, db *gorm.DB) {
 		ON usage_events (org_id, idempotency_key)`).Error; err != nil {
 		t.Fatalf("create usage idempotency index: %v", err)
 	}
+	if err := db.Exec(`CREATE TABLE billing_cycles (
+		id BIGINT PRIMARY KEY,
+		org_id BIGINT NOT NULL,
+		subscription_id BIGINT NOT NULL,
+		period_start DATETIME NOT NULL,
+		period_end DATETIME NOT NULL,
+		status TEXT NOT NULL
+	)`).Error; err != nil {
+		t.Fatalf("create billing_cycles: %v", err)
+	}
+	if err := db.Exec(`CREATE TABLE subscriptions (
+		id BIGINT PRIMARY KEY,
+		org_id BIGINT NOT NULL,
+		customer_id BIGINT NOT NULL,
+		created_at DATETIME NOT NULL
+	)`).Error; err != nil {
+		t.Fatalf("create subscriptions: %v", err)
+	}
+	if err := db.Exec(`CREATE TABLE subscription_items (
+		id BIGINT PRIMARY KEY,
+		org_id BIGINT NOT NULL,
+		subscription_id BIGINT NOT NULL,
+		price_id BIGINT NOT NULL,
+		meter_id BIGINT
+	)`).Error; err != nil {
+		t.Fatalf("create subscription_items: %v", err)
+	}
+	if err := db.Exec(`CREATE TABLE prices (
+		id BIGINT PRIMARY KEY,
+		org_id BIGINT NOT NULL,
+		aggregate_usage TEXT
+	)`).Error; err != nil {
+		t.Fatalf("create prices: %v", err)
+	}
+	if err := db.AutoMigrate(&usagedomain.LatePolicy{}); err != nil {
+		t.Fatalf("create usage_late_policies: %v", err)
+	}
+	if err := db.AutoMigrate(&subscriptiondomain.SubscriptionEntitlement{}); err != nil {
+		t.Fatalf("create subscription_entitlements: %v", err)
+	}
 }
 
 func seedCustomer(t *testing.T, db *gorm.DB, orgID, customerID snowflake.ID) {
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	pricedomain "github.com/smallbiznis/railzway/internal/price/domain"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+)
+
+const maxSummaryGroupByLength = 100
+
+type summaryWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+type summaryRow struct {
+	Bucket int
+	Grp    string
+	Value  float64
+}
+
+type summaryKey struct {
+	bucket int
+	group  string
+}
+
+type summaryPriceRow struct {
+	MeterID        snowflake.ID
+	AggregateUsage *pricedomain.AggregateUsage
+}
+
+func (s *Service) Summary(ctx context.Context, req usagedomain.UsageSummaryRequest) (*usagedomain.UsageSummaryResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, usagedomain.ErrInvalidOrganization
+	}
+	customerID, err := s.parseID(req.CustomerID, usagedomain.ErrInvalidCustomer)
+	if err != nil {
+		return nil, err
+	}
+
+	granularity := strings.ToLower(strings.TrimSpace(req.Granularity))
+	if granularity == "" {
+		granularity = usagedomain.SummaryGranularityDay
+	}
+	switch granularity {
+	case usagedomain.SummaryGranularityHour, usagedomain.SummaryGranularityDay,
+		usagedomain.SummaryGranularityWeek, usagedomain.SummaryGranularityCycle:
+	default:
+		return nil, usagedomain.ErrInvalidGranularity
+	}
+	groupBy := strings.TrimSpace(req.GroupBy)
+	if len(groupBy) > maxSummaryGroupByLength {
+		return nil, usagedomain.ErrInvalidGroupBy
+	}
+
+	var subscriptionID snowflake.ID
+	if strings.TrimSpace(req.SubscriptionID) != "" {
+		subscriptionID, err = s.parseID(req.SubscriptionID, usagedomain.ErrInvalidSubscription)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	start, end := req.Start.UTC(), req.End.UTC()
+	needsSubscription := granularity == usagedomain.SummaryGranularityCycle || (req.Start.IsZero() && req.End.IsZero())
+	if needsSubscription && subscriptionID == 0 {
+		sub, err := s.resolveActiveSubscription(ctx, orgID, customerID.String(), "", "")
+		if err != nil {
+			return nil, err
+		}
+		if sub.ID == 0 {
+			return nil, usagedomain.ErrInvalidSubscription
+		}
+		subscriptionID = sub.ID
+	}
+	if req.Start.IsZero() && req.End.IsZero() {
+		open, err := s.findOpenCycle(ctx, s.db, orgID, subscriptionID)
+		if err != nil {
+			return nil, err
+		}
+		if open == nil {
+			return nil, usagedomain.ErrNoOpenBillingCycle
+		}
+		start, end = open.PeriodStart.UTC(), open.PeriodEnd.UTC()
+	}
+	if start.IsZero() || end.IsZero() || !start.Before(end) {
+		return nil, usagedomain.ErrInvalidSummaryRange
+	}
+
+	windows, err := s.summaryWindows(ctx, orgID, subscriptionID, granularity, start, end)
+	if err != nil {
+		return nil, err
+	}
+	meters, err := s.resolveSummaryMeters(ctx, orgID, customerID, subscriptionID, req.MeterCodes, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &usagedomain.UsageSummaryResponse{
+		CustomerID:  customerID.String(),
+		Start:       start,
+		End:         end,
+		Granularity: granularity,
+		GroupBy:     groupBy,
+		Meters:      make([]usagedomain.MeterUsageSummary, 0, len(meters)),
+	}
+	if subscriptionID != 0 {
+		resp.SubscriptionID = subscriptionID.String()
+	}
+	meterIDs := make([]snowflake.ID, 0, len(meters))
+	for _, meter := range meters {
+		id, err := snowflake.ParseString(meter.ID)
+		if err != nil {
+			return nil, usagedomain.ErrInvalidMeter
+		}
+		meterIDs = append(meterIDs, id)
+	}
+	aggregations, err := s.summaryAggregations(ctx, orgID, customerID, subscriptionID, meterIDs, meters)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, meter := range meters {
+		values := make(map[summaryKey]float64)
+		if len(windows) > 0 {
+			values, err = s.aggregateSummary(ctx, orgID, customerID, subscriptionID, meterIDs[i], aggregations[i], strings.TrimSpace(meter.AggregationKey), groupBy, windows)
+			if err != nil {
+				return nil, err
+			}
+		}
+		resp.Meters = append(resp.Meters, usagedomain.MeterUsageSummary{
+			MeterID:     meter.ID,
+			MeterCode:   meter.Code,
+			Aggregation: aggregations[i],
+			Buckets:     buildSummaryBuckets(groupBy, windows, values),
+		})
+	}
+	return resp, nil
+}
+
+// summaryWindows splits [start, end) into buckets. Calendar buckets are
+// clipped to the range; cycle buckets are the overlapping billing cycles.
+func (s *Service) summaryWindows(ctx context.Context, orgID, subscriptionID snowflake.ID, granularity string, start, end time.Time) ([]summaryWindow, error) {
+	if granularity == usagedomain.SummaryGranularityCycle {
+		var cycles []usageCycleRow
+		if err := s.db.WithContext(ctx).Raw(
+			`SELECT id, period_start, period_end, status
+			 FROM billing_cycles
+			 WHERE org_id = ? AND subscription_id = ?
+			   AND period_start < ? AND period_end > ?
+			 ORDER BY period_start ASC`,
+			orgID,
+			subscriptionID,
+			end,
+			start,
+		).Scan(&cycles).Error; err != nil {
+			return nil, err
+		}
+		if len(cycles) > usagedomain.MaxUsageSummaryBuckets {
+			return nil, usagedomain.ErrTooManyBuckets
+		}
+		windows := make([]summaryWindow, 0, len(cycles))
+		for _, cycle := range cycles {
+			windows = append(windows, clipWindow(cycle.PeriodStart.UTC(), cycle.PeriodEnd.UTC(), start, end))
+		}
+		return windows, nil
+	}
+
+	windows := make([]summaryWindow, 0)
+	for cursor := truncateToBucket(start, granularity); cursor.Before(end); {
+		next := nextBucket(cursor, granularity)
+		if len(windows) == usagedomain.MaxUsageSummaryBuckets {
+			return nil, usagedomain.ErrTooManyBuckets
+		}
+		windows = append(windows, clipWindow(cursor, next, start, end))
+		cursor = next
+	}
+	return windows, nil
+}
+
+func (s *Service) resolveSummaryMeters(
+	ctx context.Context,
+	orgID, customerID, subscriptionID snowflake.ID,
+	codes []string,
+	start, end time.Time,
+) ([]*meterdomain.Response, error) {
+	if s.metersvc == nil {
+		return nil, usagedomain.ErrInvalidMeter
+	}
+
+	meters := make([]*meterdomain.Response, 0)
+	seen := make(map[string]struct{})
+	for _, raw := range codes {
+		code := strings.TrimSpace(raw)
+		if code == "" {
+			continue
+		}
+		if _, ok := seen[code]; ok {
+			continue
+		}
+		seen[code] = struct{}{}
+		meter, err := s.resolveMeter(ctx, orgID, code)
+		if err != nil {
+			return nil, err
+		}
+		if meter == nil {
+			return nil, usagedomain.ErrInvalidMeterCode
+		}
+		meters = append(meters, meter)
+	}
+	if len(seen) > 0 {
+		return meters, nil
+	}
+
+	query := s.db.WithContext(ctx).
+		Table("usage_events").
+		Distinct("meter_id").
+		Where("org_id = ? AND customer_id = ? AND status = ?", orgID, customerID, usagedomain.UsageStatusEnriched).
+		Where("COALESCE(billable_at, recorded_at) >= ? AND COALESCE(billable_at, recorded_at) < ?", start, end)
+	if subscriptionID != 0 {
+		query = query.Where("subscription_id = ?", subscriptionID)
+	}
+	var meterIDs []snowflake.ID
+	if err := query.Order("meter_id ASC").Pluck("meter_id", &meterIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range meterIDs {
+		meter, err := s.metersvc.GetByID(ctx, id.String())
+		if err != nil {
+			if errors.Is(err, meterdomain.ErrMeterNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		meters = append(meters, meter)
+	}
+	return meters, nil
+}
+
+// summaryAggregations resolves the aggregation of each meter the way rating
+// does, with the price of the subscription item billing it. Without a
+// subscription, the customer's most recent subscription billing the meter is
+// used.
+func (s *Service) summaryAggregations(
+	ctx context.Context,
+	orgID, customerID, subscriptionID snowflake.ID,
+	meterIDs []snowflake.ID,
+	meters []*meterdomain.Response,
+) ([]string, error) {
+	overrides := make(map[snowflake.ID]*pricedomain.AggregateUsage, len(meterIDs))
+	if len(meterIDs) > 0 {
+		query := s.db.WithContext(ctx).
+			Table("subscription_items si").
+			Select("si.meter_id, p.aggregate_usage").
+			Joins("JOIN subscriptions s ON s.id = si.subscription_id AND s.org_id = si.org_id").
+			Joins("JOIN prices p ON p.id = si.price_id AND p.org_id = si.org_id").
+			Where("si.org_id = ? AND s.customer_id = ? AND si.meter_id IN ?", orgID, customerID, meterIDs)
+		if subscriptionID != 0 {
+			query = query.Where("si.subscription_id = ?", subscriptionID)
+		}
+		var rows []summaryPriceRow
+		if err := query.Order("s.created_at DESC, si.id DESC").Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if _, ok := overrides[row.MeterID]; !ok {
+				overrides[row.MeterID] = row.AggregateUsage
+			}
+		}
+	}
+
+	aggregations := make([]string, 0, len(meters))
+	for i, meter := range meters {
+		aggregation, ok := pricedomain.ResolveAggregation(meter.Aggregation, overrides[meterIDs[i]])
+		if !ok {
+			aggregation = meterdomain.AggregationSum
+		}
+		aggregations = append(aggregations, aggregation)
+	}
+	return aggregations, nil
+}
+
+// aggregateSummary aggregates the meter's enriched events per window, and per
+// groupBy value when set, in SQL the same way rating aggregates a billing
+// cycle. Events are billed at billable_at when set.
+func (s *Service) aggregateSummary(
+	ctx context.Context,
+	orgID, customerID, subscriptionID, meterID snowflake.ID,
+	aggregation string,
+	aggregationKey string,
+	groupBy string,
+	windows []summaryWindow,
+) (map[summaryKey]float64, error) {
+	const billedAt = `COALESCE(billable_at, recorded_at)`
+	var bucket strings.Builder
+	args := make([]any, 0, 2*len(windows)+10)
+	bucket.WriteString(`CASE`)
+	for i, window := range windows {
+		fmt.Fprintf(&bucket, ` WHEN %s >= ? AND %s < ? THEN %d`, billedAt, billedAt, i)
+		args = append(args, window.start, window.end)
+	}
+	bucket.WriteString(` END`)
+
+	group := `''`
+	if groupBy != "" {
+		group = `COALESCE(` + metadataValueExpr(s.db) + `, '')`
+		args = append(args, groupBy)
+	}
+	unique := `NULL`
+	if aggregation == meterdomain.AggregationUniqueCount && aggregationKey != "" {
+		unique = metadataValueExpr(s.db)
+		args = append(args, aggregationKey)
+	}
+
+	events := `SELECT ` + bucket.String() + ` AS bucket, ` + group + ` AS grp, ` + unique + ` AS uniq, value, recorded_at, id
+		 FROM usage_events
+		 WHERE org_id = ? AND customer_id = ? AND meter_id = ? AND status = ?
+		 AND ` + billedAt + ` >= ? AND ` + billedAt + ` < ?`
+	args = append(args, orgID, customerID, meterID, usagedomain.UsageStatusEnriched, windows[0].start, windows[len(windows)-1].end)
+	if subscriptionID != 0 {
+		events += ` AND subscription_id = ?`
+		args = append(args, subscriptionID)
+	}
+
+	var query string
+	switch aggregation {
+	case meterdomain.AggregationMax:
+		query = `SELECT bucket, grp, COALESCE(MAX(value), 0) AS value`
+	case meterdomain.AggregationMin:
+		query = `SELECT bucket, grp, COALESCE(MIN(value), 0) AS value`
+	case meterdomain.AggregationAvg:
+		query = `SELECT bucket, grp, COALESCE(AVG(value), 0) AS value`
+	case meterdomain.AggregationCount:
+		query = `SELECT bucket, grp, COUNT(*) AS value`
+	case meterdomain.AggregationUniqueCount:
+		// Events without the key are NULL and ignored, as in rating.
+		query = `SELECT bucket, grp, COUNT(DISTINCT uniq) AS value`
+	case meterdomain.AggregationLast:
+		// Ties on recorded_at are broken by id, as in rating.
+		query = `SELECT bucket, grp, value FROM (
+			 SELECT bucket, grp, value, ROW_NUMBER() OVER (PARTITION BY bucket, grp ORDER BY recorded_at DESC, id DESC) AS rn
+			 FROM (` + events + `) e
+			 WHERE bucket IS NOT NULL
+			 ) ranked
+			 WHERE rn = 1`
+	default:
+		query = `SELECT bucket, grp, COALESCE(SUM(value), 0) AS value`
+	}
+	if aggregation != meterdomain.AggregationLast {
+		query += ` FROM (` + events + `) e WHERE bucket IS NOT NULL GROUP BY bucket, grp`
+	}
+
+	var rows []summaryRow
+	if err := s.db.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	values := make(map[summaryKey]float64, len(rows))
+	for _, row := range rows {
+		values[summaryKey{bucket: row.Bucket, group: row.Grp}] = row.Value
+	}
+	return values, nil
+}
+
+func buildSummaryBuckets(
+	groupBy string,
+	windows []summaryWindow,
+	values map[summaryKey]float64,
+) []usagedomain.UsageSummaryBucket {
+	groupsByBucket := make(map[int][]string)
+	if groupBy != "" {
+		for key := range values {
+			groupsByBucket[key.bucket] = append(groupsByBucket[key.bucket], key.group)
+		}
+	}
+
+	buckets := make([]usagedomain.UsageSummaryBucket, 0, len(windows))
+	for i, window := range windows {
+		if groupBy == "" {
+			buckets = append(buckets, usagedomain.UsageSummaryBucket{
+				Start: window.start,
+				End:   window.end,
+				Value: values[summaryKey{bucket: i}],
+			})
+			continue
+		}
+		groups := groupsByBucket[i]
+		sort.Strings(groups)
+		for _, group := range groups {
+			group := group
+			buckets = append(buckets, usagedomain.UsageSummaryBucket{
+				Start: window.start,
+				End:   window.end,
+				Group: &group,
+				Value: values[summaryKey{bucket: i, group: group}],
+			})
+		}
+	}
+	return buckets
+}
+
+func truncateToBucket(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	switch granularity {
+	case usagedomain.SummaryGranularityHour:
+		return t.Truncate(time.Hour)
+	case usagedomain.SummaryGranularityWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func nextBucket(t time.Time, granularity string) time.Time {
+	switch granularity {
+	case usagedomain.SummaryGranularityHour:
+		return t.Add(time.Hour)
+	case usagedomain.SummaryGranularityWeek:
+		return t.AddDate(0, 0, 7)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+func clipWindow(bucketStart, bucketEnd, start, end time.Time) summaryWindow {
+	if bucketStart.Before(start) {
+		bucketStart = start
+	}
+	if bucketEnd.After(end) {
+		bucketEnd = end
+	}
+	return summaryWindow{start: bucketStart, end: bucketEnd}
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/smallbiznis/railzway/internal/cache"
+	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	usagedomain "github.com/smallbiznis/railzway/internal/usage/domain"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+)
+
+func TestUsageSummary(t *testing.T) {
+	node := mustNode(t)
+	orgID := node.Generate()
+	customerID := node.Generate()
+	subID := node.Generate()
+	callsID := node.Generate()
+	usersID := node.Generate()
+
+	_, db := setupUsageService(t, node, &meterStub{}, cache.NewUsageResolverCache(), orgID, customerID)
+	calls := &meterdomain.Response{ID: callsID.String(), Code: "api_calls", Aggregation: meterdomain.AggregationSum}
+	users := &meterdomain.Response{ID: usersID.String(), Code: "active_users", Aggregation: meterdomain.AggregationUniqueCount, AggregationKey: "user_id"}
+	meters := new(meterMock)
+	meters.On("GetByCode", mock.Anything, "api_calls").Return(calls, nil)
+	meters.On("GetByCode", mock.Anything, "unknown").Return(nil, meterdomain.ErrMeterNotFound)
+	service := NewService(ServiceParam{
+		DB:       db,
+		Log:      zap.NewNop(),
+		GenID:    node,
+		MeterSvc: &summaryMeters{meterMock: meters, byID: map[string]*meterdomain.Response{calls.ID: calls, users.ID: users}},
+	})
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	day1 := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	insert := func(meterID snowflake.ID, value float64, recordedAt time.Time, status string, billableAt *time.Time, metadata map[string]any) {
+		t.Helper()
+		event := usagedomain.UsageEvent{
+			ID:             node.Generate(),
+			OrgID:          orgID,
+			CustomerID:     customerID,
+			SubscriptionID: subID,
+			MeterID:        meterID,
+			MeterCode:      "meter",
+			Value:          value,
+			RecordedAt:     recordedAt,
+			Status:         status,
+			IdempotencyKey: node.Generate().String(),
+			Metadata:       datatypes.JSONMap(metadata),
+			BillableAt:     billableAt,
+			CreatedAt:      recordedAt,
+			UpdatedAt:      recordedAt,
+		}
+		if err := db.Create(&event).Error; err != nil {
+			t.Fatalf("insert usage: %v", err)
+		}
+	}
+	carriedAt := day2.Add(time.Minute)
+	insert(callsID, 2, day1.Add(time.Hour), usagedomain.UsageStatusEnriched, nil, map[string]any{"region": "us"})
+	insert(callsID, 3, day1.Add(2*time.Hour), usagedomain.UsageStatusEnriched, nil, map[string]any{"region": "eu"})
+	insert(callsID, 100, day1.Add(3*time.Hour), usagedomain.UsageStatusAccepted, nil, nil)
+	insert(callsID, 5, day2.Add(time.Hour), usagedomain.UsageStatusEnriched, nil, map[string]any{"region": "us"})
+	insert(callsID, 1, day1.Add(-48*time.Hour), usagedomain.UsageStatusEnriched, &carriedAt, nil)
+	insert(usersID, 1, day1.Add(time.Hour), usagedomain.UsageStatusEnriched, nil, map[string]any{"user_id": "a"})
+	insert(usersID, 1, day1.Add(2*time.Hour), usagedomain.UsageStatusEnriched, nil, map[string]any{"user_id": "a"})
+	insert(usersID, 1, day1.Add(3*time.Hour), usagedomain.UsageStatusEnriched, nil, map[string]any{"user_id": "b"})
+	insert(usersID, 1, day1.Add(4*time.Hour), usagedomain.UsageStatusEnriched, nil, nil)
+
+	resp, err := service.Summary(ctx, usagedomain.UsageSummaryRequest{
+		CustomerID: customerID.String(),
+		MeterCodes: []string{"api_calls"},
+		Start:      day1,
+		End:        day1.AddDate(0, 0, 3),
+	})
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	if resp.Granularity != usagedomain.SummaryGranularityDay || len(resp.Meters) != 1 {
+		t.Fatalf("unexpected summary: %+v", resp)
+	}
+	buckets := resp.Meters[0].Buckets
+	if len(buckets) != 3 {
+		t.Fatalf("expected 3 daily buckets, got %d", len(buckets))
+	}
+	for i, want := range []float64{5, 6, 0} {
+		if buckets[i].Value != want || !buckets[i].Start.Equal(day1.AddDate(0, 0, i)) {
+			t.Fatalf("bucket %d: expected %v at %s, got %v at %s", i, want, day1.AddDate(0, 0, i), buckets[i].Value, buckets[i].Start)
+		}
+	}
+
+	grouped, err := service.Summary(ctx, usagedomain.UsageSummaryRequest{
+		CustomerID:  customerID.String(),
+		MeterCodes:  []string{"api_calls"},
+		Start:       day1,
+		End:         day1.AddDate(0, 0, 7),
+		Granularity: "week",
+		GroupBy:     "region",
+	})
+	if err != nil {
+		t.Fatalf("grouped summary: %v", err)
+	}
+	got := map[string]float64{}
+	for _, bucket := range grouped.Meters[0].Buckets {
+		if bucket.Group == nil {
+			t.Fatalf("expected grouped buckets")
+		}
+		got[*bucket.Group] = bucket.Value
+	}
+	if len(got) != 3 || got["us"] != 7 || got["eu"] != 3 || got[""] != 1 {
+		t.Fatalf("unexpected groups: %v", got)
+	}
+
+	all, err := service.Summary(ctx, usagedomain.UsageSummaryRequest{
+		CustomerID:     customerID.String(),
+		SubscriptionID: subID.String(),
+		Start:          day1,
+		End:            day2,
+		Granularity:    "hour",
+	})
+	if err != nil {
+		t.Fatalf("summary all meters: %v", err)
+	}
+	if len(all.Meters) != 2 || len(all.Meters[0].Buckets) != 24 {
+		t.Fatalf("expected 2 meters with 24 hourly buckets, got %+v", all.Meters)
+	}
+	for _, meter := range all.Meters {
+		var total float64
+		for _, bucket := range meter.Buckets {
+			total += bucket.Value
+		}
+		want := 5.0
+		if meter.MeterCode == "active_users" {
+			want = 3
+		}
+		if total != want {
+			t.Fatalf("%s: expected %v over hourly buckets, got %v", meter.MeterCode, want, total)
+		}
+	}
+
+	if _, err := service.Summary(ctx, usagedomain.UsageSummaryRequest{
+		CustomerID:  customerID.String(),
+		Start:       day1,
+		End:         day2,
+		Granularity: "month",
+	}); !errors.Is(err, usagedomain.ErrInvalidGranularity) {
+		t.Fatalf("expected invalid granularity, got %v", err)
+	}
+	if _, err := service.Summary(ctx, usagedomain.UsageSummaryRequest{
+		CustomerID: customerID.String(),
+		Start:      day2,
+		End:        day1,
+	}); !errors.Is(err, usagedomain.ErrInvalidSummaryRange) {
+		t.Fatalf("expected invalid range, got %v", err)
+	}
+	if _, err := service.Summary(ctx, usagedomain.UsageSummaryRequest{
+		CustomerID:  customerID.String(),
+		Start:       day1,
+		End:         day1.AddDate(1, 0, 0),
+		Granularity: "hour",
+	}); !errors.Is(err, usagedomain.ErrTooManyBuckets) {
+		t.Fatalf("expected too many buckets, got %v", err)
+	}
+}
+
+func TestUsageSummaryByCycle(t *testing.T) {
+	node := mustNode(t)
+	orgID := node.Generate()
+	customerID := node.Generate()
+	subID := node.Generate()
+	meterID := node.Generate()
+
+	meter := &meterStub{response: &meterdomain.Response{ID: meterID.String(), Code: "api_calls", Aggregation: meterdomain.AggregationMax}}
+	service, db := setupUsageService(t, node, meter, cache.NewUsageResolverCache(), orgID, customerID)
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mid := start.AddDate(0, 1, 0)
+	end := mid.AddDate(0, 1, 0)
+	if err := db.Exec(
+		`INSERT INTO billing_cycles (id, org_id, subscription_id, period_start, period_end, status) VALUES (?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?)`,
+		node.Generate(), orgID, subID, start, mid, "CLOSED",
+		node.Generate(), orgID, subID, mid, end, "OPEN",
+	).Error; err != nil {
+		t.Fatalf("seed cycles: %v", err)
+	}
+	for i, value := range []float64{4, 9, 2} {
+		recordedAt := start.AddDate(0, 0, 10+20*i)
+		if err := db.Create(&usagedomain.UsageEvent{
+			ID: node.Generate(), OrgID: orgID, CustomerID: customerID, SubscriptionID: subID, MeterID: meterID,
+			MeterCode: "api_calls", Value: value, RecordedAt: recordedAt, Status: usagedomain.UsageStatusEnriched,
+			IdempotencyKey: node.Generate().String(), CreatedAt: recordedAt, UpdatedAt: recordedAt,
+		}).Error; err != nil {
+			t.Fatalf("insert usage: %v", err)
+		}
+	}
+
+	resp, err := service.Summary(ctx, usagedomain.UsageSummaryRequest{
+		CustomerID:     customerID.String(),
+		SubscriptionID: subID.String(),
+		MeterCodes:     []string{"api_calls"},
+		Start:          start,
+		End:            end,
+		Granularity:    "cycle",
+	})
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	buckets := resp.Meters[0].Buckets
+	if len(buckets) != 2 || buckets[0].Value != 9 || buckets[1].Value != 2 || !buckets[1].Start.Equal(mid) {
+		t.Fatalf("unexpected cycle buckets: %+v", buckets)
+	}
+
+	current, err := service.Summary(ctx, usagedomain.UsageSummaryRequest{
+		CustomerID:     customerID.String(),
+		SubscriptionID: subID.String(),
+		MeterCodes:     []string{"api_calls"},
+	})
+	if err != nil {
+		t.Fatalf("current cycle summary: %v", err)
+	}
+	if !current.Start.Equal(mid) || !current.End.Equal(end) {
+		t.Fatalf("expected the open cycle range, got %s - %s", current.Start, current.End)
+	}
+}
+
+func TestUsageSummaryUsesPriceAggregation(t *testing.T) {
+	node := mustNode(t)
+	orgID := node.Generate()
+	customerID := node.Generate()
+	subID := node.Generate()
+	meterID := node.Generate()
+	priceID := node.Generate()
+
+	meter := &meterStub{response: &meterdomain.Response{ID: meterID.String(), Code: "seats", Aggregation: meterdomain.AggregationSum}}
+	service, db := setupUsageService(t, node, meter, cache.NewUsageResolverCache(), orgID, customerID)
+	ctx := orgcontext.WithOrgID(context.Background(), int64(orgID))
+
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	for _, stmt := range []struct {
+		query string
+		args  []any
+	}{
+		{`INSERT INTO subscriptions (id, org_id, customer_id, created_at) VALUES (?, ?, ?, ?)`, []any{subID, orgID, customerID, day}},
+		{`INSERT INTO prices (id, org_id, aggregate_usage) VALUES (?, ?, ?)`, []any{priceID, orgID, "LAST"}},
+		{`INSERT INTO subscription_items (id, org_id, subscription_id, price_id, meter_id) VALUES (?, ?, ?, ?, ?)`, []any{node.Generate(), orgID, subID, priceID, meterID}},
+	} {
+		if err := db.Exec(stmt.query, stmt.args...).Error; err != nil {
+			t.Fatalf("seed subscription: %v", err)
+		}
+	}
+	for i, value := range []float64{3, 8, 5} {
+		recordedAt := day.Add(time.Duration(i+1) * time.Hour)
+		if err := db.Create(&usagedomain.UsageEvent{
+			ID: node.Generate(), OrgID: orgID, CustomerID: customerID, SubscriptionID: subID, MeterID: meterID,
+			MeterCode: "seats", Value: value, RecordedAt: recordedAt, Status: usagedomain.UsageStatusEnriched,
+			IdempotencyKey: node.Generate().String(), CreatedAt: recordedAt, UpdatedAt: recordedAt,
+		}).Error; err != nil {
+			t.Fatalf("insert usage: %v", err)
+		}
+	}
+
+	// The price's LAST narrows the meter's SUM, as it does in rating.
+	resp, err := service.Summary(ctx, usagedomain.UsageSummaryRequest{
+		CustomerID: customerID.String(),
+		MeterCodes: []string{"seats"},
+		Start:      day,
+		End:        day.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	summary := resp.Meters[0]
+	if summary.Aggregation != meterdomain.AggregationLast || len(summary.Buckets) != 1 || summary.Buckets[0].Value != 5 {
+		t.Fatalf("expected the last value under LAST, got %+v", summary)
+	}
+}
+
+// summaryMeters resolves meters by ID for summaries without meter codes.
+type summaryMeters struct {
+	*meterMock
+	byID map[string]*meterdomain.Response
+}
+
+func (m *summaryMeters) GetByID(_ context.Context, id string) (*meterdomain.Response, error) {
+	meter, ok := m.byID[id]
+	if !ok {
+		return nil, meterdomain.ErrMeterNotFound
+	}
+	return meter, nil
+}
@@ -2,9 +2,11 @@ package snapshot
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"time"
 
+	"github.com/bwmarrin/snowflake"
 	"github.com/smallbiznis/railzway/internal/clock"
 	meterdomain "github.com/smallbiznis/railzway/internal/meter/domain"
 	"github.com/smallbiznis/railzway/internal/observability/metrics"
@@ -149,7 +151,7 @@ func (w *Worker) buildSnapshot(
 		return update, nil
 	}
 
-	subscription, err := w.subscriptionRepo.FindActiveByCustomerIDAt(ctx, tx, row.OrgID, row.CustomerID, row.RecordedAt)
+	subscription, err := w.routeSubscription(ctx, tx, row, meter.ID)
 	if err != nil {
 		return update, err
 	}
@@ -175,6 +177,43 @@ func (w *Worker) buildSnapshot(
 	return update, nil
 }
 
+// routeSubscription resolves the subscription active at the event's recorded
+// time the same way ingest does. It returns nil when no subscription matches
+// or several subscriptions bill the meter and the event names none of them.
+func (w *Worker) routeSubscription(
+	ctx context.Context,
+	tx *gorm.DB,
+	row usagedomain.SnapshotCandidate,
+	meterID snowflake.ID,
+) (*subscriptiondomain.Subscription, error) {
+	subscriptions, err := w.subscriptionRepo.ListActiveByCustomerIDAt(ctx, tx, row.OrgID, row.CustomerID, row.RecordedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]subscriptiondomain.UsageRouteCandidate, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		candidate := subscriptiondomain.UsageRouteCandidate{Subscription: subscription}
+		if row.SubscriptionID == 0 && len(subscriptions) > 1 {
+			item, err := w.subscriptionRepo.FindSubscriptionItemByMeterIDAt(ctx, tx, row.OrgID, subscription.ID, meterID, row.RecordedAt)
+			if err != nil {
+				return nil, err
+			}
+			candidate.HasMeter = item != nil
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	subscription, err := subscriptiondomain.RouteUsage(candidates, row.SubscriptionID)
+	if err != nil {
+		if errors.Is(err, subscriptiondomain.ErrSubscriptionNotFound) || errors.Is(err, subscriptiondomain.ErrAmbiguousSubscription) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
 func (w *Worker) updateBacklogMetrics(ctx context.Context) {
 	snapshot := metrics.Snapshot()
 
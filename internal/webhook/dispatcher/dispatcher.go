@@ -0,0 +1,398 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	webhookdomain "github.com/smallbiznis/railzway/internal/webhook/domain"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	userAgent            = "Railzway-Webhooks/1.0"
+	maxResponseBodyBytes = 2048
+)
+
+// Config controls fan-out and retry behaviour.
+type Config struct {
+	// MaxAttempts is the number of automatic attempts before a delivery is dead.
+	MaxAttempts int
+	// BaseBackoff is the delay after the first failed attempt; it doubles on
+	// every further failure up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// SettleDelay keeps fan-out behind the newest events so that transactions
+	// committing slightly out of id order are not skipped by the cursor.
+	SettleDelay time.Duration
+	// Timeout bounds each HTTP request.
+	Timeout time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts: 8,
+		BaseBackoff: 30 * time.Second,
+		MaxBackoff:  6 * time.Hour,
+		SettleDelay: 5 * time.Second,
+		Timeout:     10 * time.Second,
+	}
+}
+
+func (c Config) withDefaults() Config {
+	defaults := DefaultConfig()
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaults.MaxAttempts
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = defaults.BaseBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaults.MaxBackoff
+	}
+	if c.SettleDelay < 0 {
+		c.SettleDelay = defaults.SettleDelay
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaults.Timeout
+	}
+	return c
+}
+
+type Params struct {
+	fx.In
+
+	DB         *gorm.DB
+	Log        *zap.Logger
+	GenID      *snowflake.Node
+	Repo       webhookdomain.Repository
+	Config     Config       `optional:"true"`
+	HTTPClient *http.Client `optional:"true"`
+}
+
+// Dispatcher turns billing events into webhook deliveries and sends them.
+// It keeps its own cursor per endpoint and never touches the published flag
+// used by the internal outbox consumers.
+type Dispatcher struct {
+	db     *gorm.DB
+	log    *zap.Logger
+	genID  *snowflake.Node
+	repo   webhookdomain.Repository
+	cfg    Config
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewDispatcher(p Params) *Dispatcher {
+	cfg := p.Config
+	if cfg == (Config{}) {
+		cfg = DefaultConfig()
+	}
+	cfg = cfg.withDefaults()
+
+	client := p.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &Dispatcher{
+		db:     p.DB,
+		log:    p.Log.Named("webhook.dispatcher"),
+		genID:  p.GenID,
+		repo:   p.Repo,
+		cfg:    cfg,
+		client: client,
+		now:    func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// ProcessPending fans new billing events out to endpoints, then sends the
+// deliveries that are due.
+func (d *Dispatcher) ProcessPending(ctx context.Context, limit int) error {
+	if limit <= 0 {
+		limit = 50
+	}
+	return errors.Join(d.FanOut(ctx, limit), d.DeliverDue(ctx, limit))
+}
+
+// FanOut creates deliveries for up to limit new events per active endpoint.
+func (d *Dispatcher) FanOut(ctx context.Context, limit int) error {
+	endpoints, err := d.repo.ListActiveEndpoints(ctx, d.db)
+	if err != nil {
+		return err
+	}
+
+	var jobErr error
+	for _, endpoint := range endpoints {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := d.fanOutEndpoint(ctx, endpoint.ID, limit); err != nil {
+			jobErr = errors.Join(jobErr, err)
+			d.log.Warn("failed to fan out webhook events", zap.Error(err), zap.String("endpoint_id", endpoint.ID.String()))
+		}
+	}
+	return jobErr
+}
+
+func (d *Dispatcher) fanOutEndpoint(ctx context.Context, endpointID snowflake.ID, limit int) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		endpoint, err := d.repo.LockEndpoint(ctx, tx, endpointID)
+		if err != nil {
+			return err
+		}
+		if endpoint == nil || endpoint.Status != webhookdomain.EndpointStatusActive {
+			return nil
+		}
+
+		now := d.now()
+		rows, err := d.repo.ListEventsAfter(ctx, tx, endpoint.OrgID, endpoint.EventCursor, now.Add(-d.cfg.SettleDelay), limit)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		for _, row := range rows {
+			if !endpoint.Subscribed(row.EventType) {
+				continue
+			}
+			delivery := &webhookdomain.Delivery{
+				ID:            d.genID.Generate(),
+				OrgID:         endpoint.OrgID,
+				EndpointID:    endpoint.ID,
+				EventID:       row.ID,
+				EventType:     row.EventType,
+				Payload:       envelope(row),
+				Status:        webhookdomain.DeliveryStatusPending,
+				NextAttemptAt: &now,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			}
+			if err := d.repo.InsertDelivery(ctx, tx, delivery); err != nil {
+				return err
+			}
+		}
+
+		return d.repo.AdvanceCursor(ctx, tx, endpoint.ID, rows[len(rows)-1].ID, now)
+	})
+}
+
+// DeliverDue sends up to limit deliveries whose next attempt is due.
+func (d *Dispatcher) DeliverDue(ctx context.Context, limit int) error {
+	now := d.now()
+	due, err := d.repo.ListDueDeliveries(ctx, d.db, now, limit)
+	if err != nil {
+		return err
+	}
+
+	var jobErr error
+	for i := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := d.deliver(ctx, &due[i], now); err != nil {
+			jobErr = errors.Join(jobErr, err)
+			d.log.Warn("failed to deliver webhook", zap.Error(err), zap.String("delivery_id", due[i].ID.String()))
+		}
+	}
+	return jobErr
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *webhookdomain.Delivery, now time.Time) error {
+	// Hold the delivery for longer than one request so a concurrent worker
+	// does not send it twice; the result below replaces the lease.
+	claimed, err := d.repo.ClaimDelivery(ctx, d.db, delivery, now, now.Add(2*d.cfg.Timeout))
+	if err != nil || !claimed {
+		return err
+	}
+
+	endpoint, err := d.repo.FindEndpointByID(ctx, d.db, delivery.OrgID, delivery.EndpointID)
+	if err != nil {
+		return err
+	}
+	if endpoint == nil {
+		return webhookdomain.ErrEndpointNotFound
+	}
+
+	attempt, ok := d.send(ctx, endpoint, delivery, false)
+
+	finished := d.now()
+	result := webhookdomain.DeliveryResult{
+		AttemptCount:   attempt.AttemptNumber,
+		LastStatusCode: attempt.StatusCode,
+		LastError:      attempt.Error,
+	}
+	switch {
+	case ok:
+		result.Status = webhookdomain.DeliveryStatusSucceeded
+		result.DeliveredAt = &finished
+	case attempt.AttemptNumber >= d.cfg.MaxAttempts:
+		result.Status = webhookdomain.DeliveryStatusDead
+	default:
+		next := finished.Add(d.backoff(attempt.AttemptNumber))
+		result.Status = webhookdomain.DeliveryStatusRetrying
+		result.NextAttemptAt = &next
+	}
+
+	if result.Status == webhookdomain.DeliveryStatusDead {
+		d.log.Warn("webhook delivery moved to dead letter",
+			zap.String("delivery_id", delivery.ID.String()),
+			zap.String("endpoint_id", endpoint.ID.String()),
+			zap.Int("attempts", attempt.AttemptNumber),
+		)
+	}
+	return d.record(ctx, attempt, delivery.ID, result, finished)
+}
+
+// Redeliver sends a delivery once, outside the retry schedule. A success
+// marks the delivery succeeded; a failure is logged but leaves the status,
+// the retry count and the next scheduled attempt alone. The delivery is
+// leased first so a worker cannot send it at the same time.
+func (d *Dispatcher) Redeliver(ctx context.Context, endpoint *webhookdomain.Endpoint, delivery *webhookdomain.Delivery) (*webhookdomain.DeliveryAttempt, error) {
+	if endpoint.Status != webhookdomain.EndpointStatusActive {
+		return nil, webhookdomain.ErrEndpointDisabled
+	}
+
+	now := d.now()
+	claimed, err := d.repo.ClaimRedelivery(ctx, d.db, delivery.ID, now, now.Add(2*d.cfg.Timeout))
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, webhookdomain.ErrDeliveryInProgress
+	}
+	// A worker may have finished a send between the caller's read and the
+	// claim; build the result on the current row.
+	current, err := d.repo.FindDeliveryByID(ctx, d.db, delivery.OrgID, delivery.ID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, webhookdomain.ErrDeliveryNotFound
+	}
+	delivery = current
+
+	attempt, ok := d.send(ctx, endpoint, delivery, true)
+
+	finished := d.now()
+	result := webhookdomain.DeliveryResult{
+		Status:         delivery.Status,
+		AttemptCount:   delivery.AttemptCount,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastStatusCode: attempt.StatusCode,
+		LastError:      attempt.Error,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+	if ok {
+		result.Status = webhookdomain.DeliveryStatusSucceeded
+		result.NextAttemptAt = nil
+		result.DeliveredAt = &finished
+	}
+	if err := d.record(ctx, attempt, delivery.ID, result, finished); err != nil {
+		return nil, err
+	}
+	return attempt, nil
+}
+
+func (d *Dispatcher) record(ctx context.Context, attempt *webhookdomain.DeliveryAttempt, deliveryID snowflake.ID, result webhookdomain.DeliveryResult, now time.Time) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := d.repo.InsertAttempt(ctx, tx, attempt); err != nil {
+			return err
+		}
+		return d.repo.UpdateDeliveryResult(ctx, tx, deliveryID, result, now)
+	})
+}
+
+// send performs one signed POST and reports whether the endpoint answered 2xx.
+func (d *Dispatcher) send(ctx context.Context, endpoint *webhookdomain.Endpoint, delivery *webhookdomain.Delivery, manual bool) (*webhookdomain.DeliveryAttempt, bool) {
+	start := d.now()
+	attempt := &webhookdomain.DeliveryAttempt{
+		ID:            d.genID.Generate(),
+		OrgID:         delivery.OrgID,
+		DeliveryID:    delivery.ID,
+		AttemptNumber: delivery.AttemptCount + 1,
+		Manual:        manual,
+		CreatedAt:     start,
+	}
+
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		attempt.Error = stringPtr(err.Error())
+		return attempt, false
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = stringPtr(err.Error())
+		return attempt, false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(webhookdomain.HeaderEventID, delivery.EventID.String())
+	req.Header.Set(webhookdomain.HeaderEventType, delivery.EventType)
+	req.Header.Set(webhookdomain.HeaderDeliveryID, delivery.ID.String())
+	req.Header.Set(webhookdomain.HeaderSignature, webhookdomain.Sign(endpoint.Secret, start, body))
+
+	resp, err := d.client.Do(req)
+	attempt.DurationMs = d.now().Sub(start).Milliseconds()
+	if err != nil {
+		attempt.Error = stringPtr(err.Error())
+		return attempt, false
+	}
+	defer resp.Body.Close()
+
+	statusCode := resp.StatusCode
+	attempt.StatusCode = &statusCode
+	if raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodyBytes)); err == nil && len(raw) > 0 {
+		attempt.ResponseBody = stringPtr(string(raw))
+	}
+
+	ok := statusCode >= 200 && statusCode < 300
+	if !ok {
+		attempt.Error = stringPtr("unexpected status " + resp.Status)
+	}
+	return attempt, ok
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.cfg.MaxBackoff {
+			return d.cfg.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// envelope wraps a billing event in the JSON body sent to endpoints.
+func envelope(row webhookdomain.BillingEvent) datatypes.JSONMap {
+	data := map[string]any{}
+	for key, value := range row.Payload {
+		data[key] = value
+	}
+	return datatypes.JSONMap{
+		"id":              row.ID.String(),
+		"type":            row.EventType,
+		"organization_id": row.OrgID.String(),
+		"created_at":      row.CreatedAt.UTC().Format(time.RFC3339),
+		"data":            data,
+	}
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
@@ -0,0 +1,284 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/glebarez/sqlite"
+	billingeventdomain "github.com/smallbiznis/railzway/internal/billingevent/domain"
+	"github.com/smallbiznis/railzway/internal/events"
+	"github.com/smallbiznis/railzway/internal/webhook/domain"
+	"github.com/smallbiznis/railzway/internal/webhook/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []receivedRequest
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: body})
+	w.WriteHeader(r.status)
+	_, _ = w.Write([]byte(`{"ok":true}`))
+}
+
+func (r *receiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *receiver) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+type dispatcherFixture struct {
+	db       *gorm.DB
+	node     *snowflake.Node
+	outbox   *events.Outbox
+	receiver *receiver
+	server   *httptest.Server
+	d        *Dispatcher
+	orgID    snowflake.ID
+	endpoint *domain.Endpoint
+	clock    time.Time
+}
+
+func setupDispatcherTest(t *testing.T, cfg Config) *dispatcherFixture {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&billingeventdomain.BillingEvent{},
+		&domain.Endpoint{},
+		&domain.Delivery{},
+		&domain.DeliveryAttempt{},
+	))
+
+	node, _ := snowflake.NewNode(1)
+	rcv := &receiver{status: http.StatusOK}
+	srv := httptest.NewServer(rcv)
+	t.Cleanup(srv.Close)
+
+	f := &dispatcherFixture{
+		db:       db,
+		node:     node,
+		outbox:   events.NewOutbox(db, node),
+		receiver: rcv,
+		server:   srv,
+		orgID:    node.Generate(),
+		clock:    time.Now().UTC(),
+	}
+	f.d = NewDispatcher(Params{
+		DB:         db,
+		Log:        zap.NewNop(),
+		GenID:      node,
+		Repo:       repository.Provide(),
+		Config:     cfg,
+		HTTPClient: srv.Client(),
+	})
+	f.d.now = func() time.Time { return f.clock }
+
+	// An event raised before the endpoint exists must not be delivered.
+	f.publish(t, events.EventInvoiceFinalized, "before")
+
+	id := node.Generate()
+	f.endpoint = &domain.Endpoint{
+		ID:          id,
+		OrgID:       f.orgID,
+		URL:         srv.URL + "/hooks",
+		EventTypes:  datatypes.JSONSlice[string]{events.EventInvoiceFinalized, events.EventCreditNoteIssued},
+		Secret:      "whsec_test",
+		Status:      domain.EndpointStatusActive,
+		EventCursor: id,
+		CreatedAt:   f.clock,
+		UpdatedAt:   f.clock,
+	}
+	require.NoError(t, db.Create(f.endpoint).Error)
+	return f
+}
+
+func (f *dispatcherFixture) publish(t *testing.T, eventType, invoiceID string) {
+	require.NoError(t, f.outbox.Publish(context.Background(), events.Event{
+		OrgID:     f.orgID,
+		Type:      eventType,
+		Payload:   map[string]any{"invoice_id": invoiceID},
+		DedupeKey: eventType + ":" + invoiceID,
+	}))
+}
+
+func (f *dispatcherFixture) deliveries(t *testing.T) []domain.Delivery {
+	var deliveries []domain.Delivery
+	require.NoError(t, f.db.Order("id ASC").Find(&deliveries).Error)
+	return deliveries
+}
+
+func TestDispatcher_DeliversSignedSubscribedEvents(t *testing.T) {
+	f := setupDispatcherTest(t, Config{SettleDelay: 0, MaxAttempts: 3, BaseBackoff: time.Minute, MaxBackoff: time.Hour})
+
+	f.publish(t, events.EventInvoiceFinalized, "inv_1")
+	f.publish(t, events.EventInvoiceVoided, "inv_1")
+	f.publish(t, events.EventCreditNoteIssued, "inv_1")
+	f.clock = f.clock.Add(time.Second)
+
+	require.NoError(t, f.d.ProcessPending(context.Background(), 10))
+
+	requests := f.receiver.received()
+	require.Len(t, requests, 2)
+
+	first := requests[0]
+	assert.Equal(t, "application/json", first.header.Get("Content-Type"))
+	assert.Equal(t, events.EventInvoiceFinalized, first.header.Get(domain.HeaderEventType))
+	assert.NoError(t, domain.VerifySignature("whsec_test", first.header.Get(domain.HeaderSignature), first.body, time.Hour, time.Now()))
+	assert.ErrorIs(t, domain.VerifySignature("whsec_other", first.header.Get(domain.HeaderSignature), first.body, time.Hour, time.Now()), domain.ErrInvalidSignature)
+	assert.ErrorIs(t, domain.VerifySignature("whsec_test", first.header.Get(domain.HeaderSignature), first.body, time.Minute, f.clock.Add(time.Hour)), domain.ErrSignatureExpired)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(first.body, &body))
+	assert.Equal(t, events.EventInvoiceFinalized, body["type"])
+	assert.Equal(t, first.header.Get(domain.HeaderEventID), body["id"])
+	assert.Equal(t, f.orgID.String(), body["organization_id"])
+	assert.Equal(t, "inv_1", body["data"].(map[string]any)["invoice_id"])
+
+	deliveries := f.deliveries(t)
+	require.Len(t, deliveries, 2)
+	for _, delivery := range deliveries {
+		assert.Equal(t, domain.DeliveryStatusSucceeded, delivery.Status)
+		assert.Equal(t, 1, delivery.AttemptCount)
+		assert.NotNil(t, delivery.DeliveredAt)
+		assert.Nil(t, delivery.NextAttemptAt)
+	}
+
+	// Running again neither duplicates deliveries nor resends them.
+	require.NoError(t, f.d.ProcessPending(context.Background(), 10))
+	assert.Len(t, f.receiver.received(), 2)
+	assert.Len(t, f.deliveries(t), 2)
+
+	// Internal outbox consumers still see every event as unpublished.
+	var unpublished int64
+	require.NoError(t, f.db.Model(&billingeventdomain.BillingEvent{}).Where("published = ?", false).Count(&unpublished).Error)
+	assert.Equal(t, int64(4), unpublished)
+}
+
+func TestDispatcher_RetriesWithBackoffThenDeadLettersAndRedelivers(t *testing.T) {
+	f := setupDispatcherTest(t, Config{SettleDelay: 0, MaxAttempts: 3, BaseBackoff: time.Minute, MaxBackoff: time.Hour})
+	f.receiver.setStatus(http.StatusInternalServerError)
+
+	f.publish(t, events.EventInvoiceFinalized, "inv_1")
+	f.clock = f.clock.Add(time.Second)
+
+	require.NoError(t, f.d.ProcessPending(context.Background(), 10))
+	deliveries := f.deliveries(t)
+	require.Len(t, deliveries, 1)
+	delivery := deliveries[0]
+	assert.Equal(t, domain.DeliveryStatusRetrying, delivery.Status)
+	assert.Equal(t, 1, delivery.AttemptCount)
+	require.NotNil(t, delivery.LastStatusCode)
+	assert.Equal(t, http.StatusInternalServerError, *delivery.LastStatusCode)
+	require.NotNil(t, delivery.NextAttemptAt)
+	assert.WithinDuration(t, f.clock.Add(time.Minute), *delivery.NextAttemptAt, time.Second)
+
+	// Not due yet.
+	require.NoError(t, f.d.ProcessPending(context.Background(), 10))
+	assert.Len(t, f.receiver.received(), 1)
+
+	f.clock = f.clock.Add(time.Minute)
+	require.NoError(t, f.d.ProcessPending(context.Background(), 10))
+	delivery = f.deliveries(t)[0]
+	assert.Equal(t, 2, delivery.AttemptCount)
+	require.NotNil(t, delivery.NextAttemptAt)
+	assert.WithinDuration(t, f.clock.Add(2*time.Minute), *delivery.NextAttemptAt, time.Second)
+
+	f.clock = f.clock.Add(2 * time.Minute)
+	require.NoError(t, f.d.ProcessPending(context.Background(), 10))
+	delivery = f.deliveries(t)[0]
+	assert.Equal(t, domain.DeliveryStatusDead, delivery.Status)
+	assert.Equal(t, 3, delivery.AttemptCount)
+	assert.Nil(t, delivery.NextAttemptAt)
+
+	// Dead deliveries are not retried automatically.
+	f.clock = f.clock.Add(24 * time.Hour)
+	require.NoError(t, f.d.ProcessPending(context.Background(), 10))
+	assert.Len(t, f.receiver.received(), 3)
+
+	// A manual redelivery against the recovered receiver succeeds.
+	f.receiver.setStatus(http.StatusNoContent)
+	attempt, err := f.d.Redeliver(context.Background(), f.endpoint, &delivery)
+	require.NoError(t, err)
+	assert.True(t, attempt.Manual)
+	assert.Equal(t, 4, attempt.AttemptNumber)
+
+	delivery = f.deliveries(t)[0]
+	assert.Equal(t, domain.DeliveryStatusSucceeded, delivery.Status)
+	assert.NotNil(t, delivery.DeliveredAt)
+
+	var attempts []domain.DeliveryAttempt
+	require.NoError(t, f.db.Where("delivery_id = ?", delivery.ID).Order("attempt_number ASC").Find(&attempts).Error)
+	require.Len(t, attempts, 4)
+	for _, a := range attempts[:3] {
+		assert.False(t, a.Manual)
+		require.NotNil(t, a.StatusCode)
+		assert.Equal(t, http.StatusInternalServerError, *a.StatusCode)
+	}
+	require.NotNil(t, attempts[3].StatusCode)
+	assert.Equal(t, http.StatusNoContent, *attempts[3].StatusCode)
+
+	// Every attempt carried the same signed body.
+	requests := f.receiver.received()
+	for _, req := range requests[1:] {
+		assert.Equal(t, string(requests[0].body), string(req.body))
+	}
+}
+
+func TestDispatcher_ManualRedeliveryKeepsRetryCountAndTakesLease(t *testing.T) {
+	f := setupDispatcherTest(t, Config{SettleDelay: 0, MaxAttempts: 3, BaseBackoff: time.Minute, MaxBackoff: time.Hour})
+	f.receiver.setStatus(http.StatusInternalServerError)
+
+	f.publish(t, events.EventInvoiceFinalized, "inv_1")
+	f.clock = f.clock.Add(time.Second)
+	require.NoError(t, f.d.ProcessPending(context.Background(), 10))
+	delivery := f.deliveries(t)[0]
+	require.Equal(t, 1, delivery.AttemptCount)
+
+	// A failed manual send is recorded but does not move toward dead letter.
+	attempt, err := f.d.Redeliver(context.Background(), f.endpoint, &delivery)
+	require.NoError(t, err)
+	assert.True(t, attempt.Manual)
+	delivery = f.deliveries(t)[0]
+	assert.Equal(t, 1, delivery.AttemptCount)
+	assert.Equal(t, domain.DeliveryStatusRetrying, delivery.Status)
+	assert.Nil(t, delivery.LockedUntil)
+
+	// While another send holds the delivery, neither path sends it.
+	lease := f.clock.Add(time.Hour)
+	require.NoError(t, f.db.Model(&domain.Delivery{}).Where("id = ?", delivery.ID).Update("locked_until", lease).Error)
+	_, err = f.d.Redeliver(context.Background(), f.endpoint, &delivery)
+	assert.ErrorIs(t, err, domain.ErrDeliveryInProgress)
+
+	f.clock = f.clock.Add(2 * time.Minute)
+	require.NoError(t, f.d.ProcessPending(context.Background(), 10))
+	assert.Len(t, f.receiver.received(), 2)
+	assert.Equal(t, 1, f.deliveries(t)[0].AttemptCount)
+}
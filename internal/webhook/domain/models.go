@@ -0,0 +1,100 @@
+// Package domain contains persistence models for outbound webhooks.
+package domain
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/datatypes"
+)
+
+// EndpointStatus controls whether an endpoint receives new events.
+type EndpointStatus string
+
+const (
+	EndpointStatusActive   EndpointStatus = "active"
+	EndpointStatusDisabled EndpointStatus = "disabled"
+)
+
+// DeliveryStatus represents the lifecycle of one event sent to one endpoint.
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusRetrying  DeliveryStatus = "retrying"
+	DeliveryStatusSucceeded DeliveryStatus = "succeeded"
+	DeliveryStatusDead      DeliveryStatus = "dead"
+)
+
+// AllEventTypes subscribes an endpoint to every event type.
+const AllEventTypes = "*"
+
+// Endpoint is an organization-owned URL that receives billing events.
+// EventCursor is the last billing event id that was fanned out to it.
+type Endpoint struct {
+	ID          snowflake.ID                `gorm:"primaryKey"`
+	OrgID       snowflake.ID                `gorm:"not null;index"`
+	URL         string                      `gorm:"type:text;not null"`
+	Description string                      `gorm:"type:text;not null;default:''"`
+	EventTypes  datatypes.JSONSlice[string] `gorm:"type:jsonb;not null"`
+	Secret      string                      `gorm:"type:text;not null"`
+	Status      EndpointStatus              `gorm:"type:text;not null"`
+	EventCursor snowflake.ID                `gorm:"not null;default:0"`
+	CreatedAt   time.Time                   `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time                   `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName sets the database table name.
+func (Endpoint) TableName() string { return "webhook_endpoints" }
+
+// Subscribed reports whether the endpoint wants events of the given type.
+func (e Endpoint) Subscribed(eventType string) bool {
+	for _, t := range e.EventTypes {
+		if t == AllEventTypes || t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery tracks one billing event sent to one endpoint. Payload holds the
+// exact envelope that is signed and sent, so redeliveries are byte-identical.
+type Delivery struct {
+	ID             snowflake.ID      `gorm:"primaryKey"`
+	OrgID          snowflake.ID      `gorm:"not null;index"`
+	EndpointID     snowflake.ID      `gorm:"not null;uniqueIndex:ux_webhook_deliveries_endpoint_event,priority:1"`
+	EventID        snowflake.ID      `gorm:"not null;uniqueIndex:ux_webhook_deliveries_endpoint_event,priority:2"`
+	EventType      string            `gorm:"type:text;not null"`
+	Payload        datatypes.JSONMap `gorm:"type:jsonb;not null"`
+	Status         DeliveryStatus    `gorm:"type:text;not null"`
+	AttemptCount   int               `gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time        `gorm:"index"`
+	LastStatusCode *int
+	LastError      *string `gorm:"type:text"`
+	DeliveredAt    *time.Time
+	// LockedUntil leases the delivery to the worker or manual redelivery
+	// sending it, so the two never send it at the same time.
+	LockedUntil *time.Time
+	CreatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName sets the database table name.
+func (Delivery) TableName() string { return "webhook_deliveries" }
+
+// DeliveryAttempt records one HTTP request made for a delivery.
+type DeliveryAttempt struct {
+	ID            snowflake.ID `gorm:"primaryKey"`
+	OrgID         snowflake.ID `gorm:"not null"`
+	DeliveryID    snowflake.ID `gorm:"not null;index"`
+	AttemptNumber int          `gorm:"not null"`
+	Manual        bool         `gorm:"not null;default:false"`
+	StatusCode    *int
+	ResponseBody  *string   `gorm:"type:text"`
+	Error         *string   `gorm:"type:text"`
+	DurationMs    int64     `gorm:"not null;default:0"`
+	CreatedAt     time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName sets the database table name.
+func (DeliveryAttempt) TableName() string { return "webhook_delivery_attempts" }
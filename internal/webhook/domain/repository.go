@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// DeliveryFilter narrows delivery listings.
+type DeliveryFilter struct {
+	EndpointID *snowflake.ID
+	EventID    *snowflake.ID
+	Status     DeliveryStatus
+	Limit      int
+}
+
+// BillingEvent is the part of an outbox row that is sent to endpoints.
+type BillingEvent struct {
+	ID        snowflake.ID
+	OrgID     snowflake.ID
+	EventType string
+	Payload   datatypes.JSONMap
+	CreatedAt time.Time
+}
+
+// DeliveryResult is the outcome of one attempt, applied to its delivery.
+type DeliveryResult struct {
+	Status         DeliveryStatus
+	AttemptCount   int
+	NextAttemptAt  *time.Time
+	LastStatusCode *int
+	LastError      *string
+	DeliveredAt    *time.Time
+}
+
+type Repository interface {
+	InsertEndpoint(ctx context.Context, db *gorm.DB, endpoint *Endpoint) error
+	FindEndpointByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*Endpoint, error)
+	ListEndpoints(ctx context.Context, db *gorm.DB, orgID snowflake.ID) ([]Endpoint, error)
+	UpdateEndpoint(ctx context.Context, db *gorm.DB, endpoint *Endpoint) error
+	DeleteEndpoint(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) error
+	// ListActiveEndpoints returns active endpoints across all organizations.
+	ListActiveEndpoints(ctx context.Context, db *gorm.DB) ([]Endpoint, error)
+	// LockEndpoint re-reads an endpoint, locking the row where supported.
+	LockEndpoint(ctx context.Context, db *gorm.DB, id snowflake.ID) (*Endpoint, error)
+	AdvanceCursor(ctx context.Context, db *gorm.DB, id, cursor snowflake.ID, now time.Time) error
+
+	// ListEventsAfter returns an organization's billing events with an id
+	// above cursor that were created before the given time, oldest first.
+	ListEventsAfter(ctx context.Context, db *gorm.DB, orgID, cursor snowflake.ID, before time.Time, limit int) ([]BillingEvent, error)
+
+	// InsertDelivery is a no-op when the event was already fanned out to the endpoint.
+	InsertDelivery(ctx context.Context, db *gorm.DB, delivery *Delivery) error
+	FindDeliveryByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*Delivery, error)
+	ListDeliveries(ctx context.Context, db *gorm.DB, orgID snowflake.ID, filter DeliveryFilter) ([]Delivery, error)
+	// ListDueDeliveries returns pending and retrying deliveries of active endpoints whose next attempt is due.
+	ListDueDeliveries(ctx context.Context, db *gorm.DB, now time.Time, limit int) ([]Delivery, error)
+	// ClaimDelivery pushes a delivery that is due at now to leaseUntil. It
+	// returns false when another worker claimed it first.
+	ClaimDelivery(ctx context.Context, db *gorm.DB, delivery *Delivery, now, leaseUntil time.Time) (bool, error)
+	// ClaimRedelivery leases a delivery for a manual send until leaseUntil,
+	// whatever its status. It returns false while another send holds it.
+	ClaimRedelivery(ctx context.Context, db *gorm.DB, id snowflake.ID, now, leaseUntil time.Time) (bool, error)
+	// UpdateDeliveryResult stores the outcome of a send and releases its lease.
+	UpdateDeliveryResult(ctx context.Context, db *gorm.DB, id snowflake.ID, result DeliveryResult, now time.Time) error
+
+	InsertAttempt(ctx context.Context, db *gorm.DB, attempt *DeliveryAttempt) error
+	ListAttempts(ctx context.Context, db *gorm.DB, orgID, deliveryID snowflake.ID) ([]DeliveryAttempt, error)
+}
@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/smallbiznis/railzway/internal/events"
+)
+
+// SupportedEventTypes lists the billing events that can be sent to webhook endpoints.
+var SupportedEventTypes = []string{
+	events.EventInvoiceFinalized,
+	events.EventInvoiceVoided,
+	events.EventCreditNoteIssued,
+	events.EventLedgerEntryCreated,
+	events.EventPaymentSettled,
+	events.EventRefundSettled,
+	events.EventDisputeWithdrawn,
+	events.EventDisputeReinstated,
+	events.EventUsageIngested,
+	events.EventUsageCorrected,
+	events.EventUsageLate,
+}
+
+// IsSupportedEventType reports whether eventType can be subscribed to.
+func IsSupportedEventType(eventType string) bool {
+	if eventType == AllEventTypes {
+		return true
+	}
+	for _, t := range SupportedEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateEndpointRequest struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description,omitempty"`
+	EventTypes  []string `json:"event_types"`
+}
+
+// UpdateEndpointRequest changes an endpoint. Nil fields are left unchanged.
+type UpdateEndpointRequest struct {
+	URL         *string   `json:"url,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	EventTypes  *[]string `json:"event_types,omitempty"`
+	Status      *string   `json:"status,omitempty"`
+}
+
+// EndpointResponse describes an endpoint. Secret is only returned when the
+// endpoint is created or its secret is rotated.
+type EndpointResponse struct {
+	ID          string    `json:"id"`
+	OrgID       string    `json:"organization_id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	EventTypes  []string  `json:"event_types"`
+	Status      string    `json:"status"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ListDeliveriesRequest struct {
+	EndpointID string `form:"endpoint_id"`
+	EventID    string `form:"event_id"`
+	Status     string `form:"status"`
+	Limit      int    `form:"limit"`
+}
+
+type DeliveryResponse struct {
+	ID             string         `json:"id"`
+	OrgID          string         `json:"organization_id"`
+	EndpointID     string         `json:"endpoint_id"`
+	EventID        string         `json:"event_id"`
+	EventType      string         `json:"event_type"`
+	Payload        map[string]any `json:"payload"`
+	Status         string         `json:"status"`
+	AttemptCount   int            `json:"attempt_count"`
+	NextAttemptAt  *time.Time     `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int           `json:"last_status_code,omitempty"`
+	LastError      *string        `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+type DeliveryAttemptResponse struct {
+	ID            string    `json:"id"`
+	DeliveryID    string    `json:"delivery_id"`
+	AttemptNumber int       `json:"attempt_number"`
+	Manual        bool      `json:"manual"`
+	StatusCode    *int      `json:"status_code,omitempty"`
+	ResponseBody  *string   `json:"response_body,omitempty"`
+	Error         *string   `json:"error,omitempty"`
+	DurationMs    int64     `json:"duration_ms"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type Service interface {
+	CreateEndpoint(ctx context.Context, req CreateEndpointRequest) (*EndpointResponse, error)
+	ListEndpoints(ctx context.Context) ([]EndpointResponse, error)
+	GetEndpoint(ctx context.Context, id string) (*EndpointResponse, error)
+	UpdateEndpoint(ctx context.Context, id string, req UpdateEndpointRequest) (*EndpointResponse, error)
+	RotateSecret(ctx context.Context, id string) (*EndpointResponse, error)
+	DeleteEndpoint(ctx context.Context, id string) error
+
+	ListDeliveries(ctx context.Context, req ListDeliveriesRequest) ([]DeliveryResponse, error)
+	GetDelivery(ctx context.Context, id string) (*DeliveryResponse, error)
+	ListDeliveryAttempts(ctx context.Context, deliveryID string) ([]DeliveryAttemptResponse, error)
+	// Redeliver sends a delivery again right away, whatever its status, and
+	// returns the recorded attempt.
+	Redeliver(ctx context.Context, deliveryID string) (*DeliveryAttemptResponse, error)
+}
+
+var (
+	ErrInvalidOrganization = errors.New("invalid_organization")
+	ErrInvalidID           = errors.New("invalid_id")
+	ErrInvalidURL          = errors.New("invalid_url")
+	ErrInvalidEventTypes   = errors.New("invalid_event_types")
+	ErrInvalidStatus       = errors.New("invalid_status")
+	ErrInvalidEndpointID   = errors.New("invalid_endpoint_id")
+	ErrInvalidEventID      = errors.New("invalid_event_id")
+	ErrEndpointDisabled    = errors.New("endpoint_disabled")
+	ErrInvalidSignature    = errors.New("invalid_signature")
+	ErrSignatureExpired    = errors.New("signature_expired")
+	ErrEndpointNotFound    = errors.New("endpoint_not_found")
+	ErrDeliveryNotFound    = errors.New("delivery_not_found")
+	ErrDeliveryInProgress  = errors.New("delivery_in_progress")
+)
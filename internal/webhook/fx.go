@@ -0,0 +1,14 @@
+package webhook
+
+import (
+	"github.com/smallbiznis/railzway/internal/webhook/dispatcher"
+	"github.com/smallbiznis/railzway/internal/webhook/repository"
+	"github.com/smallbiznis/railzway/internal/webhook/service"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("webhook.service",
+	fx.Provide(repository.Provide),
+	fx.Provide(dispatcher.NewDispatcher),
+	fx.Provide(service.NewService),
+)
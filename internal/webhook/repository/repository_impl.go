@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	webhookdomain "github.com/smallbiznis/railzway/internal/webhook/domain"
+	"gorm.io/gorm"
+)
+
+type repo struct{}
+
+func Provide() webhookdomain.Repository {
+	return &repo{}
+}
+
+const endpointColumns = `id, org_id, url, description, event_types, secret, status, event_cursor, created_at, updated_at`
+
+const deliveryColumns = `id, org_id, endpoint_id, event_id, event_type, payload, status, attempt_count,
+	next_attempt_at, last_status_code, last_error, delivered_at, created_at, updated_at`
+
+func (r *repo) InsertEndpoint(ctx context.Context, db *gorm.DB, endpoint *webhookdomain.Endpoint) error {
+	return db.WithContext(ctx).Exec(
+		`INSERT INTO webhook_endpoints (`+endpointColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		endpoint.ID,
+		endpoint.OrgID,
+		endpoint.URL,
+		endpoint.Description,
+		endpoint.EventTypes,
+		endpoint.Secret,
+		endpoint.Status,
+		endpoint.EventCursor,
+		endpoint.CreatedAt,
+		endpoint.UpdatedAt,
+	).Error
+}
+
+func (r *repo) FindEndpointByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*webhookdomain.Endpoint, error) {
+	var endpoint webhookdomain.Endpoint
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+endpointColumns+`
+		 FROM webhook_endpoints
+		 WHERE org_id = ? AND id = ?`,
+		orgID,
+		id,
+	).Scan(&endpoint).Error
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.ID == 0 {
+		return nil, nil
+	}
+	return &endpoint, nil
+}
+
+func (r *repo) ListEndpoints(ctx context.Context, db *gorm.DB, orgID snowflake.ID) ([]webhookdomain.Endpoint, error) {
+	var endpoints []webhookdomain.Endpoint
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+endpointColumns+`
+		 FROM webhook_endpoints
+		 WHERE org_id = ?
+		 ORDER BY created_at DESC`,
+		orgID,
+	).Scan(&endpoints).Error
+	if err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (r *repo) UpdateEndpoint(ctx context.Context, db *gorm.DB, endpoint *webhookdomain.Endpoint) error {
+	return db.WithContext(ctx).Exec(
+		`UPDATE webhook_endpoints
+		 SET url = ?, description = ?, event_types = ?, secret = ?, status = ?, event_cursor = ?, updated_at = ?
+		 WHERE org_id = ? AND id = ?`,
+		endpoint.URL,
+		endpoint.Description,
+		endpoint.EventTypes,
+		endpoint.Secret,
+		endpoint.Status,
+		endpoint.EventCursor,
+		endpoint.UpdatedAt,
+		endpoint.OrgID,
+		endpoint.ID,
+	).Error
+}
+
+func (r *repo) DeleteEndpoint(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) error {
+	if err := db.WithContext(ctx).Exec(
+		`DELETE FROM webhook_delivery_attempts
+		 WHERE org_id = ? AND delivery_id IN (SELECT id FROM webhook_deliveries WHERE org_id = ? AND endpoint_id = ?)`,
+		orgID,
+		orgID,
+		id,
+	).Error; err != nil {
+		return err
+	}
+	if err := db.WithContext(ctx).Exec(
+		`DELETE FROM webhook_deliveries WHERE org_id = ? AND endpoint_id = ?`,
+		orgID,
+		id,
+	).Error; err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Exec(
+		`DELETE FROM webhook_endpoints WHERE org_id = ? AND id = ?`,
+		orgID,
+		id,
+	).Error
+}
+
+func (r *repo) ListActiveEndpoints(ctx context.Context, db *gorm.DB) ([]webhookdomain.Endpoint, error) {
+	var endpoints []webhookdomain.Endpoint
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+endpointColumns+`
+		 FROM webhook_endpoints
+		 WHERE status = ?
+		 ORDER BY id ASC`,
+		webhookdomain.EndpointStatusActive,
+	).Scan(&endpoints).Error
+	if err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (r *repo) LockEndpoint(ctx context.Context, db *gorm.DB, id snowflake.ID) (*webhookdomain.Endpoint, error) {
+	query := `SELECT ` + endpointColumns + `
+		 FROM webhook_endpoints
+		 WHERE id = ?`
+	if db.Dialector.Name() != "sqlite" {
+		query += " FOR UPDATE"
+	}
+
+	var endpoint webhookdomain.Endpoint
+	if err := db.WithContext(ctx).Raw(query, id).Scan(&endpoint).Error; err != nil {
+		return nil, err
+	}
+	if endpoint.ID == 0 {
+		return nil, nil
+	}
+	return &endpoint, nil
+}
+
+func (r *repo) AdvanceCursor(ctx context.Context, db *gorm.DB, id, cursor snowflake.ID, now time.Time) error {
+	return db.WithContext(ctx).Exec(
+		`UPDATE webhook_endpoints SET event_cursor = ?, updated_at = ? WHERE id = ? AND event_cursor < ?`,
+		cursor,
+		now,
+		id,
+		cursor,
+	).Error
+}
+
+func (r *repo) ListEventsAfter(ctx context.Context, db *gorm.DB, orgID, cursor snowflake.ID, before time.Time, limit int) ([]webhookdomain.BillingEvent, error) {
+	var rows []webhookdomain.BillingEvent
+	err := db.WithContext(ctx).Raw(
+		`SELECT id, org_id, event_type, payload, created_at
+		 FROM billing_events
+		 WHERE org_id = ? AND id > ? AND created_at <= ?
+		 ORDER BY id ASC
+		 LIMIT ?`,
+		orgID,
+		cursor,
+		before,
+		limit,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *repo) InsertDelivery(ctx context.Context, db *gorm.DB, delivery *webhookdomain.Delivery) error {
+	return db.WithContext(ctx).Exec(
+		`INSERT INTO webhook_deliveries (`+deliveryColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (endpoint_id, event_id) DO NOTHING`,
+		delivery.ID,
+		delivery.OrgID,
+		delivery.EndpointID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Payload,
+		delivery.Status,
+		delivery.AttemptCount,
+		delivery.NextAttemptAt,
+		delivery.LastStatusCode,
+		delivery.LastError,
+		delivery.DeliveredAt,
+		delivery.CreatedAt,
+		delivery.UpdatedAt,
+	).Error
+}
+
+func (r *repo) FindDeliveryByID(ctx context.Context, db *gorm.DB, orgID, id snowflake.ID) (*webhookdomain.Delivery, error) {
+	var delivery webhookdomain.Delivery
+	err := db.WithContext(ctx).Raw(
+		`SELECT `+deliveryColumns+`
+		 FROM webhook_deliveries
+		 WHERE org_id = ? AND id = ?`,
+		orgID,
+		id,
+	).Scan(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	if delivery.ID == 0 {
+		return nil, nil
+	}
+	return &delivery, nil
+}
+
+func (r *repo) ListDeliveries(ctx context.Context, db *gorm.DB, orgID snowflake.ID, filter webhookdomain.DeliveryFilter) ([]webhookdomain.Delivery, error) {
+	var deliveries []webhookdomain.Delivery
+	stmt := db.WithContext(ctx).Model(&webhookdomain.Delivery{}).Where("org_id = ?", orgID)
+	if filter.EndpointID != nil {
+		stmt = stmt.Where("endpoint_id = ?", *filter.EndpointID)
+	}
+	if filter.EventID != nil {
+		stmt = stmt.Where("event_id = ?", *filter.EventID)
+	}
+	if filter.Status != "" {
+		stmt = stmt.Where("status = ?", filter.Status)
+	}
+	if filter.Limit > 0 {
+		stmt = stmt.Limit(filter.Limit)
+	}
+	if err := stmt.Order("created_at DESC").Order("id DESC").Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *repo) ListDueDeliveries(ctx context.Context, db *gorm.DB, now time.Time, limit int) ([]webhookdomain.Delivery, error) {
+	var deliveries []webhookdomain.Delivery
+	err := db.WithContext(ctx).Raw(
+		`SELECT d.id, d.org_id, d.endpoint_id, d.event_id, d.event_type, d.payload, d.status, d.attempt_count,
+		        d.next_attempt_at, d.last_status_code, d.last_error, d.delivered_at, d.created_at, d.updated_at
+		 FROM webhook_deliveries d
+		 JOIN webhook_endpoints e ON e.id = d.endpoint_id
+		 WHERE d.status IN ? AND d.next_attempt_at <= ? AND e.status = ?
+		 ORDER BY d.next_attempt_at ASC, d.id ASC
+		 LIMIT ?`,
+		[]webhookdomain.DeliveryStatus{webhookdomain.DeliveryStatusPending, webhookdomain.DeliveryStatusRetrying},
+		now,
+		webhookdomain.EndpointStatusActive,
+		limit,
+	).Scan(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *repo) ClaimDelivery(ctx context.Context, db *gorm.DB, delivery *webhookdomain.Delivery, now, leaseUntil time.Time) (bool, error) {
+	result := db.WithContext(ctx).Exec(
+		`UPDATE webhook_deliveries
+		 SET next_attempt_at = ?, locked_until = ?
+		 WHERE id = ? AND status = ? AND attempt_count = ? AND next_attempt_at <= ?
+		   AND (locked_until IS NULL OR locked_until <= ?)`,
+		leaseUntil,
+		leaseUntil,
+		delivery.ID,
+		delivery.Status,
+		delivery.AttemptCount,
+		now,
+		now,
+	)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repo) ClaimRedelivery(ctx context.Context, db *gorm.DB, id snowflake.ID, now, leaseUntil time.Time) (bool, error) {
+	result := db.WithContext(ctx).Exec(
+		`UPDATE webhook_deliveries
+		 SET locked_until = ?
+		 WHERE id = ? AND (locked_until IS NULL OR locked_until <= ?)`,
+		leaseUntil,
+		id,
+		now,
+	)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repo) UpdateDeliveryResult(ctx context.Context, db *gorm.DB, id snowflake.ID, result webhookdomain.DeliveryResult, now time.Time) error {
+	return db.WithContext(ctx).Exec(
+		`UPDATE webhook_deliveries
+		 SET status = ?, attempt_count = ?, next_attempt_at = ?, last_status_code = ?, last_error = ?,
+		     delivered_at = ?, locked_until = NULL, updated_at = ?
+		 WHERE id = ?`,
+		result.Status,
+		result.AttemptCount,
+		result.NextAttemptAt,
+		result.LastStatusCode,
+		result.LastError,
+		result.DeliveredAt,
+		now,
+		id,
+	).Error
+}
+
+func (r *repo) InsertAttempt(ctx context.Context, db *gorm.DB, attempt *webhookdomain.DeliveryAttempt) error {
+	return db.WithContext(ctx).Exec(
+		`INSERT INTO webhook_delivery_attempts (
+			id, org_id, delivery_id, attempt_number, manual, status_code, response_body, error, duration_ms, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		attempt.ID,
+		attempt.OrgID,
+		attempt.DeliveryID,
+		attempt.AttemptNumber,
+		attempt.Manual,
+		attempt.StatusCode,
+		attempt.ResponseBody,
+		attempt.Error,
+		attempt.DurationMs,
+		attempt.CreatedAt,
+	).Error
+}
+
+func (r *repo) ListAttempts(ctx context.Context, db *gorm.DB, orgID, deliveryID snowflake.ID) ([]webhookdomain.DeliveryAttempt, error) {
+	var attempts []webhookdomain.DeliveryAttempt
+	err := db.WithContext(ctx).Raw(
+		`SELECT id, org_id, delivery_id, attempt_number, manual, status_code, response_body, error, duration_ms, created_at
+		 FROM webhook_delivery_attempts
+		 WHERE org_id = ? AND delivery_id = ?
+		 ORDER BY created_at ASC, id ASC`,
+		orgID,
+		deliveryID,
+	).Scan(&attempts).Error
+	if err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
@@ -0,0 +1,457 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	auditdomain "github.com/smallbiznis/railzway/internal/audit/domain"
+	"github.com/smallbiznis/railzway/internal/orgcontext"
+	"github.com/smallbiznis/railzway/internal/webhook/dispatcher"
+	webhookdomain "github.com/smallbiznis/railzway/internal/webhook/domain"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	secretPrefix      = "whsec_"
+	secretBytes       = 32
+	maxDeliveriesList = 100
+)
+
+type Params struct {
+	fx.In
+
+	DB         *gorm.DB
+	Log        *zap.Logger
+	GenID      *snowflake.Node
+	Repo       webhookdomain.Repository
+	Dispatcher *dispatcher.Dispatcher
+	AuditSvc   auditdomain.Service `optional:"true"`
+}
+
+type Service struct {
+	db         *gorm.DB
+	log        *zap.Logger
+	genID      *snowflake.Node
+	repo       webhookdomain.Repository
+	dispatcher *dispatcher.Dispatcher
+	auditSvc   auditdomain.Service
+}
+
+func NewService(p Params) webhookdomain.Service {
+	return &Service{
+		db:         p.DB,
+		log:        p.Log.Named("webhook.service"),
+		genID:      p.GenID,
+		repo:       p.Repo,
+		dispatcher: p.Dispatcher,
+		auditSvc:   p.AuditSvc,
+	}
+}
+
+func (s *Service) CreateEndpoint(ctx context.Context, req webhookdomain.CreateEndpointRequest) (*webhookdomain.EndpointResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, webhookdomain.ErrInvalidOrganization
+	}
+
+	endpointURL, err := normalizeURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	eventTypes, err := normalizeEventTypes(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	id := s.genID.Generate()
+	endpoint := &webhookdomain.Endpoint{
+		ID:          id,
+		OrgID:       orgID,
+		URL:         endpointURL,
+		Description: strings.TrimSpace(req.Description),
+		EventTypes:  eventTypes,
+		Secret:      secret,
+		Status:      webhookdomain.EndpointStatusActive,
+		// Only events created after the endpoint are sent to it.
+		EventCursor: id,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.InsertEndpoint(ctx, s.db, endpoint); err != nil {
+		return nil, err
+	}
+
+	s.emitAudit(ctx, orgID, "webhook_endpoint.create", "webhook_endpoint", endpoint.ID, map[string]any{
+		"url":         endpoint.URL,
+		"event_types": []string(endpoint.EventTypes),
+	})
+	return toEndpointResponse(endpoint, true), nil
+}
+
+func (s *Service) ListEndpoints(ctx context.Context) ([]webhookdomain.EndpointResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, webhookdomain.ErrInvalidOrganization
+	}
+
+	endpoints, err := s.repo.ListEndpoints(ctx, s.db, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]webhookdomain.EndpointResponse, 0, len(endpoints))
+	for i := range endpoints {
+		resp = append(resp, *toEndpointResponse(&endpoints[i], false))
+	}
+	return resp, nil
+}
+
+func (s *Service) GetEndpoint(ctx context.Context, id string) (*webhookdomain.EndpointResponse, error) {
+	endpoint, err := s.loadEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toEndpointResponse(endpoint, false), nil
+}
+
+func (s *Service) UpdateEndpoint(ctx context.Context, id string, req webhookdomain.UpdateEndpointRequest) (*webhookdomain.EndpointResponse, error) {
+	endpoint, err := s.loadEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		endpoint.URL, err = normalizeURL(*req.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if req.Description != nil {
+		endpoint.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.EventTypes != nil {
+		endpoint.EventTypes, err = normalizeEventTypes(*req.EventTypes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if req.Status != nil {
+		status := webhookdomain.EndpointStatus(strings.ToLower(strings.TrimSpace(*req.Status)))
+		switch status {
+		case webhookdomain.EndpointStatusActive, webhookdomain.EndpointStatusDisabled:
+		default:
+			return nil, webhookdomain.ErrInvalidStatus
+		}
+		if status == webhookdomain.EndpointStatusActive && endpoint.Status != webhookdomain.EndpointStatusActive {
+			// Events raised while the endpoint was disabled are not replayed.
+			endpoint.EventCursor = s.genID.Generate()
+		}
+		endpoint.Status = status
+	}
+	endpoint.UpdatedAt = time.Now().UTC()
+
+	if err := s.repo.UpdateEndpoint(ctx, s.db, endpoint); err != nil {
+		return nil, err
+	}
+
+	s.emitAudit(ctx, endpoint.OrgID, "webhook_endpoint.update", "webhook_endpoint", endpoint.ID, map[string]any{
+		"url":         endpoint.URL,
+		"event_types": []string(endpoint.EventTypes),
+		"status":      string(endpoint.Status),
+	})
+	return toEndpointResponse(endpoint, false), nil
+}
+
+// RotateSecret replaces the signing secret. Deliveries sent afterwards,
+// including retries of older events, are signed with the new secret.
+func (s *Service) RotateSecret(ctx context.Context, id string) (*webhookdomain.EndpointResponse, error) {
+	endpoint, err := s.loadEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.Secret, err = newSecret()
+	if err != nil {
+		return nil, err
+	}
+	endpoint.UpdatedAt = time.Now().UTC()
+	if err := s.repo.UpdateEndpoint(ctx, s.db, endpoint); err != nil {
+		return nil, err
+	}
+
+	s.emitAudit(ctx, endpoint.OrgID, "webhook_endpoint.rotate_secret", "webhook_endpoint", endpoint.ID, nil)
+	return toEndpointResponse(endpoint, true), nil
+}
+
+func (s *Service) DeleteEndpoint(ctx context.Context, id string) error {
+	endpoint, err := s.loadEndpoint(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.repo.DeleteEndpoint(ctx, tx, endpoint.OrgID, endpoint.ID)
+	}); err != nil {
+		return err
+	}
+
+	s.emitAudit(ctx, endpoint.OrgID, "webhook_endpoint.delete", "webhook_endpoint", endpoint.ID, map[string]any{
+		"url": endpoint.URL,
+	})
+	return nil
+}
+
+func (s *Service) ListDeliveries(ctx context.Context, req webhookdomain.ListDeliveriesRequest) ([]webhookdomain.DeliveryResponse, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, webhookdomain.ErrInvalidOrganization
+	}
+
+	filter := webhookdomain.DeliveryFilter{Limit: req.Limit}
+	if filter.Limit <= 0 || filter.Limit > maxDeliveriesList {
+		filter.Limit = maxDeliveriesList
+	}
+	if strings.TrimSpace(req.EndpointID) != "" {
+		id, err := snowflake.ParseString(strings.TrimSpace(req.EndpointID))
+		if err != nil {
+			return nil, webhookdomain.ErrInvalidEndpointID
+		}
+		filter.EndpointID = &id
+	}
+	if strings.TrimSpace(req.EventID) != "" {
+		id, err := snowflake.ParseString(strings.TrimSpace(req.EventID))
+		if err != nil {
+			return nil, webhookdomain.ErrInvalidEventID
+		}
+		filter.EventID = &id
+	}
+	if strings.TrimSpace(req.Status) != "" {
+		status := webhookdomain.DeliveryStatus(strings.ToLower(strings.TrimSpace(req.Status)))
+		switch status {
+		case webhookdomain.DeliveryStatusPending,
+			webhookdomain.DeliveryStatusRetrying,
+			webhookdomain.DeliveryStatusSucceeded,
+			webhookdomain.DeliveryStatusDead:
+		default:
+			return nil, webhookdomain.ErrInvalidStatus
+		}
+		filter.Status = status
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, s.db, orgID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]webhookdomain.DeliveryResponse, 0, len(deliveries))
+	for i := range deliveries {
+		resp = append(resp, *toDeliveryResponse(&deliveries[i]))
+	}
+	return resp, nil
+}
+
+func (s *Service) GetDelivery(ctx context.Context, id string) (*webhookdomain.DeliveryResponse, error) {
+	delivery, err := s.loadDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toDeliveryResponse(delivery), nil
+}
+
+func (s *Service) ListDeliveryAttempts(ctx context.Context, deliveryID string) ([]webhookdomain.DeliveryAttemptResponse, error) {
+	delivery, err := s.loadDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.repo.ListAttempts(ctx, s.db, delivery.OrgID, delivery.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]webhookdomain.DeliveryAttemptResponse, 0, len(attempts))
+	for i := range attempts {
+		resp = append(resp, *toAttemptResponse(&attempts[i]))
+	}
+	return resp, nil
+}
+
+func (s *Service) Redeliver(ctx context.Context, deliveryID string) (*webhookdomain.DeliveryAttemptResponse, error) {
+	delivery, err := s.loadDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := s.repo.FindEndpointByID(ctx, s.db, delivery.OrgID, delivery.EndpointID)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == nil {
+		return nil, webhookdomain.ErrEndpointNotFound
+	}
+
+	attempt, err := s.dispatcher.Redeliver(ctx, endpoint, delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitAudit(ctx, delivery.OrgID, "webhook_delivery.redeliver", "webhook_delivery", delivery.ID, map[string]any{
+		"endpoint_id": endpoint.ID.String(),
+		"event_id":    delivery.EventID.String(),
+		"status_code": attempt.StatusCode,
+	})
+	return toAttemptResponse(attempt), nil
+}
+
+func (s *Service) loadEndpoint(ctx context.Context, id string) (*webhookdomain.Endpoint, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, webhookdomain.ErrInvalidOrganization
+	}
+
+	endpointID, err := snowflake.ParseString(strings.TrimSpace(id))
+	if err != nil {
+		return nil, webhookdomain.ErrInvalidID
+	}
+
+	endpoint, err := s.repo.FindEndpointByID(ctx, s.db, orgID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == nil {
+		return nil, webhookdomain.ErrEndpointNotFound
+	}
+	return endpoint, nil
+}
+
+func (s *Service) loadDelivery(ctx context.Context, id string) (*webhookdomain.Delivery, error) {
+	orgID, ok := orgcontext.OrgIDFromContext(ctx)
+	if !ok || orgID == 0 {
+		return nil, webhookdomain.ErrInvalidOrganization
+	}
+
+	deliveryID, err := snowflake.ParseString(strings.TrimSpace(id))
+	if err != nil {
+		return nil, webhookdomain.ErrInvalidID
+	}
+
+	delivery, err := s.repo.FindDeliveryByID(ctx, s.db, orgID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, webhookdomain.ErrDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+func (s *Service) emitAudit(ctx context.Context, orgID snowflake.ID, action, targetType string, targetID snowflake.ID, metadata map[string]any) {
+	if s.auditSvc == nil {
+		return
+	}
+	target := targetID.String()
+	_ = s.auditSvc.AuditLog(ctx, &orgID, "", nil, action, targetType, &target, metadata)
+}
+
+func normalizeURL(raw string) (string, error) {
+	value := strings.TrimSpace(raw)
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		return "", webhookdomain.ErrInvalidURL
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return "", webhookdomain.ErrInvalidURL
+	}
+	return value, nil
+}
+
+func normalizeEventTypes(values []string) (datatypes.JSONSlice[string], error) {
+	seen := make(map[string]struct{}, len(values))
+	out := make(datatypes.JSONSlice[string], 0, len(values))
+	for _, value := range values {
+		eventType := strings.TrimSpace(value)
+		if !webhookdomain.IsSupportedEventType(eventType) {
+			return nil, webhookdomain.ErrInvalidEventTypes
+		}
+		if _, ok := seen[eventType]; ok {
+			continue
+		}
+		seen[eventType] = struct{}{}
+		out = append(out, eventType)
+	}
+	if len(out) == 0 {
+		return nil, webhookdomain.ErrInvalidEventTypes
+	}
+	return out, nil
+}
+
+func newSecret() (string, error) {
+	buf := make([]byte, secretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return secretPrefix + hex.EncodeToString(buf), nil
+}
+
+func toEndpointResponse(endpoint *webhookdomain.Endpoint, withSecret bool) *webhookdomain.EndpointResponse {
+	resp := &webhookdomain.EndpointResponse{
+		ID:          endpoint.ID.String(),
+		OrgID:       endpoint.OrgID.String(),
+		URL:         endpoint.URL,
+		Description: endpoint.Description,
+		EventTypes:  append([]string{}, endpoint.EventTypes...),
+		Status:      string(endpoint.Status),
+		CreatedAt:   endpoint.CreatedAt,
+		UpdatedAt:   endpoint.UpdatedAt,
+	}
+	if withSecret {
+		resp.Secret = endpoint.Secret
+	}
+	return resp
+}
+
+func toDeliveryResponse(delivery *webhookdomain.Delivery) *webhookdomain.DeliveryResponse {
+	return &webhookdomain.DeliveryResponse{
+		ID:             delivery.ID.String(),
+		OrgID:          delivery.OrgID.String(),
+		EndpointID:     delivery.EndpointID.String(),
+		EventID:        delivery.EventID.String(),
+		EventType:      delivery.EventType,
+		Payload:        map[string]any(delivery.Payload),
+		Status:         string(delivery.Status),
+		AttemptCount:   delivery.AttemptCount,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+}
+
+func toAttemptResponse(attempt *webhookdomain.DeliveryAttempt) *webhookdomain.DeliveryAttemptResponse {
+	return &webhookdomain.DeliveryAttemptResponse{
+		ID:            attempt.ID.String(),
+		DeliveryID:    attempt.DeliveryID.String(),
+		AttemptNumber: attempt.AttemptNumber,
+		Manual:        attempt.Manual,
+		StatusCode:    attempt.StatusCode,
+		ResponseBody:  attempt.ResponseBody,
+		Error:         attempt.Error,
+		DurationMs:    attempt.DurationMs,
+		CreatedAt:     attempt.CreatedAt,
+	}
+}